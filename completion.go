@@ -0,0 +1,213 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/app"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// shells are the shells completion scripts can be generated for.
+var shells = []string{"bash", "zsh", "fish"}
+
+// flagInfo describes one flag of a command for the completion scripts.
+type flagInfo struct {
+	name    string
+	usage   string
+	boolean bool
+	// values are the accepted values, empty when any value goes
+	values []string
+}
+
+// commandFlags returns the flags a command accepts, in the order of its -h output.
+func commandFlags(cmd command) []flagInfo {
+	if cmd.flags == nil {
+		return nil
+	}
+	cfg := app.DefaultConfig()
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.flags(fs, &cfg)
+
+	var flags []flagInfo
+	fs.VisitAll(func(f *flag.Flag) {
+		info := flagInfo{name: f.Name, usage: f.Usage}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			info.boolean = true
+		}
+		switch f.Name {
+		case "scene":
+			info.values = append(app.SceneNames(), app.Random)
+		case "theme":
+			info.values = append(theme.Names(), app.Random)
+		case "intro":
+			info.values = []string{"melt", "dissolve"}
+		case "temperature":
+			info.values = []string{"auto", "off"}
+		case "font":
+			info.values = font.Names()
+		case "format":
+			info.values = []string{"txt", "svg", "png"}
+		case "colors":
+			info.values = []string{"truecolor", "256", "mono"}
+		case "color-depth":
+			info.values = []string{"auto", "truecolor", "256", "16", "8"}
+		}
+		flags = append(flags, info)
+	})
+	return flags
+}
+
+// completion prints the completion script for the shell named in args.
+func completion(_ *app.Config, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: usage: screensaver completion <%s>", app.ErrConfigInvalid, strings.Join(shells, "|"))
+	}
+	switch args[0] {
+	case "bash":
+		bashCompletion()
+	case "zsh":
+		zshCompletion()
+	case "fish":
+		fishCompletion()
+	default:
+		return fmt.Errorf("%w: unknown shell %q (available: %s)", app.ErrConfigInvalid, args[0], strings.Join(shells, ", "))
+	}
+	return nil
+}
+
+// commandNames returns the names of all subcommands.
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// bashCompletion prints a completion function for bash.
+// Load it with: source <(screensaver completion bash)
+func bashCompletion() {
+	out := os.Stdout
+	fmt.Fprintf(out, "# bash completion for screensaver\n")
+	fmt.Fprintf(out, "_screensaver() {\n")
+	fmt.Fprintf(out, "\tlocal cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} cmd=%s\n", commands[0].name)
+	fmt.Fprintf(out, "\tif [[ $COMP_CWORD -gt 1 && ${COMP_WORDS[1]} != -* ]]; then\n\t\tcmd=${COMP_WORDS[1]}\n\tfi\n")
+
+	// Flag values are the same for every command that has the flag
+	fmt.Fprintf(out, "\tcase $prev in\n")
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		for _, f := range commandFlags(cmd) {
+			if len(f.values) == 0 || seen[f.name] {
+				continue
+			}
+			seen[f.name] = true
+			fmt.Fprintf(out, "\t-%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", f.name, strings.Join(f.values, " "))
+		}
+	}
+	fmt.Fprintf(out, "\tesac\n")
+
+	fmt.Fprintf(out, "\tif [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then\n")
+	fmt.Fprintf(out, "\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\tfi\n", strings.Join(commandNames(), " "))
+
+	fmt.Fprintf(out, "\tcase $cmd in\n")
+	for _, cmd := range commands {
+		words := cmd.args
+		for _, f := range commandFlags(cmd) {
+			words = append(words, "-"+f.name)
+		}
+		if len(words) > 0 {
+			fmt.Fprintf(out, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", cmd.name, strings.Join(words, " "))
+		}
+	}
+	fmt.Fprintf(out, "\tesac\n")
+	fmt.Fprintf(out, "}\n")
+	fmt.Fprintf(out, "complete -F _screensaver screensaver\n")
+}
+
+// zshCompletion prints a completion function for zsh.
+// Load it with: source <(screensaver completion zsh)
+func zshCompletion() {
+	// Specs are single quoted, brackets end flag descriptions and colons
+	// end command names
+	quote := strings.NewReplacer("'", "'\\''")
+	escapeFlag := strings.NewReplacer("[", "\\[", "]", "\\]")
+	escapeCommand := strings.NewReplacer(":", "\\:")
+
+	out := os.Stdout
+	fmt.Fprintf(out, "#compdef screensaver\n\n")
+	fmt.Fprintf(out, "_screensaver() {\n")
+	fmt.Fprintf(out, "\tlocal -a commands\n\tcommands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "\t\t'%s:%s'\n", cmd.name, quote.Replace(escapeCommand.Replace(cmd.summary)))
+	}
+	fmt.Fprintf(out, "\t)\n")
+	fmt.Fprintf(out, "\tif (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then\n\t\t_describe 'command' commands\n\t\treturn\n\tfi\n")
+	fmt.Fprintf(out, "\tlocal cmd=%s\n", commands[0].name)
+	fmt.Fprintf(out, "\tif [[ $words[2] != -* ]]; then\n\t\tcmd=$words[2]\n\t\tshift words\n\t\t(( CURRENT-- ))\n\tfi\n")
+
+	fmt.Fprintf(out, "\tcase $cmd in\n")
+	for _, cmd := range commands {
+		var specs []string
+		for _, f := range commandFlags(cmd) {
+			spec := fmt.Sprintf("'-%s[%s]", f.name, quote.Replace(escapeFlag.Replace(f.usage)))
+			switch {
+			case f.boolean:
+			case len(f.values) > 0:
+				spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(f.values, " "))
+			default:
+				spec += fmt.Sprintf(":%s:", f.name)
+			}
+			specs = append(specs, spec+"'")
+		}
+		if len(cmd.args) > 0 {
+			specs = append(specs, fmt.Sprintf("'1:argument:(%s)'", strings.Join(cmd.args, " ")))
+		}
+		if len(specs) > 0 {
+			fmt.Fprintf(out, "\t%s)\n\t\t_arguments \\\n\t\t\t%s\n\t\t;;\n", cmd.name, strings.Join(specs, " \\\n\t\t\t"))
+		}
+	}
+	fmt.Fprintf(out, "\tesac\n")
+	fmt.Fprintf(out, "}\n\n")
+	fmt.Fprintf(out, "compdef _screensaver screensaver\n")
+}
+
+// fishCompletion prints completions for fish.
+// Load them with: screensaver completion fish | source
+func fishCompletion() {
+	escape := strings.NewReplacer("'", "\\'")
+
+	out := os.Stdout
+	fmt.Fprintf(out, "# fish completion for screensaver\n")
+	fmt.Fprintf(out, "complete -c screensaver -f\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "complete -c screensaver -n __fish_use_subcommand -a %s -d '%s'\n", cmd.name, escape.Replace(cmd.summary))
+	}
+
+	for i, cmd := range commands {
+		cond := "__fish_seen_subcommand_from " + cmd.name
+		if i == 0 {
+			// The default command's flags also apply before any command is given
+			cond = "not __fish_seen_subcommand_from " + strings.Join(commandNames()[1:], " ")
+		}
+		for _, f := range commandFlags(cmd) {
+			line := fmt.Sprintf("complete -c screensaver -n '%s' -o %s -d '%s'", cond, f.name, escape.Replace(f.usage))
+			switch {
+			case f.boolean:
+			case len(f.values) > 0:
+				line += fmt.Sprintf(" -x -a '%s'", strings.Join(f.values, " "))
+			default:
+				line += " -r -F"
+			}
+			fmt.Fprintln(out, line)
+		}
+		if len(cmd.args) > 0 {
+			fmt.Fprintf(out, "complete -c screensaver -n '__fish_seen_subcommand_from %s; and not __fish_seen_subcommand_from %s' -a '%s'\n",
+				cmd.name, strings.Join(cmd.args, " "), strings.Join(cmd.args, " "))
+		}
+	}
+}
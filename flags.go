@@ -0,0 +1,389 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/olegchuev/screensaver/internal/ansi"
+	"github.com/olegchuev/screensaver/internal/app"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/holiday"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/scenes/clock"
+	"github.com/olegchuev/screensaver/internal/scenes/fractal"
+	"github.com/olegchuev/screensaver/internal/scenes/lava"
+	"github.com/olegchuev/screensaver/internal/scenes/life"
+	"github.com/olegchuev/screensaver/internal/scenes/plasma"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/transition"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// replayPath is the session to play back, set by the -replay flag of run.
+var replayPath string
+
+// einkOptions configures the display of run -eink.
+var einkOptions = app.DefaultEInkOptions()
+
+// Options of the snapshot command.
+var (
+	snapshotOptions = app.DefaultSnapshotOptions()
+	snapshotOutput  string
+	compareOptions  = app.DefaultCompareOptions()
+	compareOutput   string
+)
+
+// calibrateOptions selects the glyphs of the calibrate command.
+var calibrateOptions app.CalibrateOptions
+
+// Options of the stream, serve and video commands.
+var (
+	streamOptions = app.DefaultStreamOptions()
+	serveOptions  = app.DefaultServeOptions()
+	videoOptions  = app.DefaultVideoOptions()
+)
+
+// configFlags registers the flags that override the configuration. They are
+// shared by every command that works with a configuration.
+func configFlags(fs *flag.FlagSet, cfg *app.Config) {
+	fs.StringVar(&cfg.Scene, "scene", cfg.Scene, "scene to display ("+strings.Join(app.SceneNames(), ", ")+"), "+app.Random+" for a different one each launch, or plugin:NAME for a scene a plugin draws")
+	fs.Int64Var(&cfg.Seed, "seed", cfg.Seed, "seed picking the "+app.Random+" -scene, -theme and -preset, to pick the same again (default: a new one each launch)")
+	fs.IntVar(&cfg.Kaleidoscope, "kaleidoscope", cfg.Kaleidoscope, "mirror the scene into N kaleidoscope segments (0 disables)")
+	fs.StringVar(&cfg.Holiday, "holiday", cfg.Holiday, "show a holiday effect over the scene ("+strings.Join(holiday.Effects, ", ")+"), or off for none (default: by the calendar)")
+	fs.Func("holiday-rule", "show a holiday effect on some days as effect=MM-DD[..MM-DD], or none to show nothing then (repeatable)", func(s string) error {
+		r, err := holiday.ParseRule(s)
+		if err != nil {
+			return err
+		}
+		cfg.Holidays = append(cfg.Holidays, r)
+		return nil
+	})
+	fs.StringVar(&cfg.Intro, "intro", cfg.Intro, "startup effect over the previous terminal text (melt, dissolve)")
+	fs.StringVar(&cfg.IntroFile, "intro-file", cfg.IntroFile, "text file to use for the intro effect instead of the terminal contents")
+	fs.StringVar(&cfg.MOTD, "motd", cfg.MOTD, `message shown in large letters before the scene starts, lines broken with \n`)
+	fs.StringVar(&cfg.MOTDFile, "motd-file", cfg.MOTDFile, "text file shown as it is before the scene starts, such as figlet output")
+	fs.DurationVar(&cfg.MOTDTime, "motd-time", cfg.MOTDTime, "how long -motd or -motd-file shows before fading out")
+	fs.DurationVar(&cfg.FadeIn, "fade-in", cfg.FadeIn, "fade in from black over this duration at startup")
+	fs.DurationVar(&cfg.FadeOut, "fade-out", cfg.FadeOut, "fade out to black over this duration before exiting")
+	fs.StringVar(&cfg.Transition, "transition", cfg.Transition, "how a scene gives way to the next when switching ("+strings.Join(transition.Switches, ", ")+")")
+	fs.DurationVar(&cfg.TransitionTime, "transition-time", cfg.TransitionTime, "how long the -transition between scenes takes")
+	fs.Float64Var(&cfg.Color.Brightness, "brightness", cfg.Color.Brightness, "global brightness multiplier")
+	fs.Float64Var(&cfg.Color.Contrast, "contrast", cfg.Color.Contrast, "global contrast around mid grey")
+	fs.Float64Var(&cfg.Color.Gamma, "gamma", cfg.Color.Gamma, "global gamma, above 1 lifts shadows")
+	fs.StringVar(&cfg.Location, "location", cfg.Location, "place as LAT,LON, or ip to look it up, whose sunset and sunrise -temperature auto follows (default: the -weather place)")
+	fs.Func("temperature", "color temperature shift: auto (warm at night), off, or a value like 3500K", func(s string) error {
+		t, err := app.ParseTemperature(s)
+		cfg.Temperature = t
+		return err
+	})
+	fs.Func("cell-aspect", "terminal cell width:height ratio used to keep shapes round (default 1:2)", func(s string) error {
+		aspect, err := app.ParseCellAspect(s)
+		cfg.CellAspect = aspect
+		return err
+	})
+	fs.StringVar(&cfg.ColorDepth, "color-depth", cfg.ColorDepth, "color depth to draw with when the terminal reports it wrongly (truecolor, 256, 16, 8), auto to detect it")
+	fs.StringVar(&cfg.RenderMode, "render-mode", cfg.RenderMode, "how the ocean surface is drawn ("+strings.Join(renderer.ModeNames(), ", ")+"), text where the font lacks the characters")
+	fs.BoolVar(&cfg.LinearBlending, "linear-blend", cfg.LinearBlending, "mix colors in linear light for fades, layer opacity and transitions; false blends raw sRGB values as before, for comparison")
+	fs.StringVar(&cfg.ToneMap, "tone-map", cfg.ToneMap, "curve brightness that builds up, as in the galaxy and the ocean's spray, is brought into range with ("+strings.Join(renderer.ToneMapNames(), ", ")+")")
+	fs.BoolVar(&cfg.TemporalDither, "temporal-dither", cfg.TemporalDither, "on 256 color terminals, alternate colors between the nearest palette entries from frame to frame for smoother gradients; false avoids the faint flicker")
+	fs.StringVar(&cfg.ShadeDither, "shade-dither", cfg.ShadeDither, "dither brightness onto the shade characters for finer detail ("+strings.Join(renderer.ShadeDitherNames(), ", ")+"), for mono themes, e-ink and text snapshots")
+	fs.StringVar(&cfg.ShadeRamp, "shade-ramp", cfg.ShadeRamp, "characters brightness is shaded with, darkest first, e.g. \" .:-=+*#%@\"; screensaver calibrate orders them for a font")
+	fs.Func("fps", "frames per second (1-60)", func(s string) error {
+		fps, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		// Out of range values are reported by Validate with the other problems
+		cfg.FrameDelay = 0
+		if fps > 0 {
+			cfg.FrameDelay = time.Second / time.Duration(fps)
+		}
+		return nil
+	})
+	fs.StringVar(&cfg.Audio, "audio", cfg.Audio, "WAV file whose music audio-reactive scenes such as ocean follow, in step with the frames")
+	fs.Float64Var(&cfg.Beat.Sensitivity, "beat-sensitivity", cfg.Beat.Sensitivity, "share of the rises in -audio loudness taken as beats (0-1)")
+	fs.DurationVar(&cfg.Beat.MinInterval, "beat-interval", cfg.Beat.MinInterval, "shortest time between two -audio beats")
+	fs.StringVar(&cfg.Font, "font", cfg.Font, "raster font for -window, -images and png snapshots ("+strings.Join(font.Names(), ", ")+" or a .bdf file)")
+	fs.Func("cell-pixels", "cell size in pixels for -window, -images and png snapshots as WxH (default: the font's own)", func(s string) error {
+		size, err := app.ParseCellPixels(s)
+		if err != nil {
+			return err
+		}
+		cfg.CellPixels = size
+		return nil
+	})
+	fs.IntVar(&cfg.WaveConfig.GridWidth, "grid-width", cfg.WaveConfig.GridWidth, "ocean grid points across, more is finer and slower")
+	fs.IntVar(&cfg.WaveConfig.GridDepth, "grid-depth", cfg.WaveConfig.GridDepth, "ocean grid points into the distance")
+	fs.IntVar(&cfg.WaveConfig.WaveCount, "wave-count", cfg.WaveConfig.WaveCount, fmt.Sprintf("ocean wave components to combine (1-%d)", wave.MaxWaveCount))
+	fs.StringVar(&cfg.Preset, "preset", cfg.Preset, "curated ocean that sets the waves and theme together ("+strings.Join(wave.PresetNames(), ", ")+", or "+app.Random+")")
+	fs.Float64Var(&cfg.WaveConfig.ParticleDensity, "density", cfg.WaveConfig.ParticleDensity, "ocean spray density (0-1)")
+	fs.Float64Var(&cfg.WaveConfig.Steepness, "steepness", cfg.WaveConfig.Steepness, "ocean wave sharpness, 1 is the default look")
+	fs.Float64Var(&cfg.WaveConfig.Shadows, "shadows", cfg.WaveConfig.Shadows, "how dark the ocean's crests shade the water behind them from a low sun (0-1, 0 for none)")
+	fs.IntVar(&cfg.StarfieldConfig.Stars, "stars", cfg.StarfieldConfig.Stars, "stars in flight in the starfield scene")
+	fs.Float64Var(&cfg.StarfieldConfig.Speed, "star-speed", cfg.StarfieldConfig.Speed, "starfield velocity in depths of the field per second")
+	fs.Float64Var(&cfg.MatrixConfig.Speed, "rain-speed", cfg.MatrixConfig.Speed, "average fall speed of the matrix scene's glyphs in rows per second")
+	fs.Float64Var(&cfg.MatrixConfig.Density, "rain-density", cfg.MatrixConfig.Density, "share of the matrix scene's columns with glyphs falling (0.01-1)")
+	fs.StringVar(&cfg.PlasmaConfig.Palette, "plasma-palette", cfg.PlasmaConfig.Palette, "how the plasma scene lays the theme colors on ("+strings.Join(plasma.Palettes, ", ")+")")
+	fs.Float64Var(&cfg.PlasmaConfig.Turbulence, "turbulence", cfg.PlasmaConfig.Turbulence, "how strongly noise warps the plasma scene's waves (0-4)")
+	fs.Float64Var(&cfg.PlasmaConfig.Speed, "plasma-speed", cfg.PlasmaConfig.Speed, "how fast the plasma scene flows, 1 for the default pace")
+	fs.Float64Var(&cfg.FireConfig.Intensity, "fire-intensity", cfg.FireConfig.Intensity, "how hot the fire scene burns, 1 for flames over half the screen high (0.1-2)")
+	fs.Float64Var(&cfg.FireConfig.Wind, "fire-wind", cfg.FireConfig.Wind, "wind leaning the fire scene's flames, from -1 to the left to 1 to the right")
+	fs.IntVar(&cfg.PipesConfig.Size, "pipe-grid", cfg.PipesConfig.Size, "voxels along each edge of the pipes scene's grid")
+	fs.Float64Var(&cfg.PipesConfig.Speed, "pipe-speed", cfg.PipesConfig.Speed, "voxels the pipes scene's pipes grow per second")
+	fs.Float64Var(&cfg.PipesConfig.Turns, "pipe-turns", cfg.PipesConfig.Turns, "chance of a pipe bending at each voxel (0-1)")
+	fs.Float64Var(&cfg.SnowConfig.Density, "snow-density", cfg.SnowConfig.Density, "how thick the snow scene's snow falls, 1 for a steady snowfall (0.1-5)")
+	fs.Float64Var(&cfg.SnowConfig.Wind, "snow-wind", cfg.SnowConfig.Wind, "wind blowing the snow scene's flakes, from -1 to the left to 1 to the right")
+	fs.Float64Var(&cfg.SnowConfig.Melt, "snow-melt", cfg.SnowConfig.Melt, "rows of the snow scene's fallen snow melting away a minute (0 keeps it)")
+	fs.Float64Var(&cfg.RipplesConfig.Rain, "rain", cfg.RipplesConfig.Rain, "how hard it rains on the ripples scene's water, 1 for a steady shower (0-10)")
+	fs.Float64Var(&cfg.RipplesConfig.Damping, "ripple-damping", cfg.RipplesConfig.Damping, "share of the ripples scene's ripples kept every step, higher for longer lasting rings (0-0.999)")
+	fs.IntVar(&cfg.AquariumConfig.Fish, "fish", cfg.AquariumConfig.Fish, "fish swimming in the aquarium scene (0-100)")
+	fs.Float64Var(&cfg.AquariumConfig.Speed, "fish-speed", cfg.AquariumConfig.Speed, "how fast the aquarium scene's fish swim, 1 for a lazy pace (0.1-5)")
+	fs.IntVar(&cfg.LavaConfig.Blobs, "lava-blobs", cfg.LavaConfig.Blobs, "wax blobs in the lava scene's lamp (0-20)")
+	fs.Float64Var(&cfg.LavaConfig.Speed, "lava-speed", cfg.LavaConfig.Speed, "how fast the lava scene's wax moves, 1 for the pace of a real lamp (0.1-5)")
+	fs.StringVar(&cfg.LavaConfig.Palette, "lava-palette", cfg.LavaConfig.Palette, "colors of the lava scene's wax ("+strings.Join(lava.Palettes, ", ")+")")
+	fs.Float64Var(&cfg.DNAConfig.Speed, "dna-speed", cfg.DNAConfig.Speed, "how fast the dna scene's helix turns, 1 for a slow turn (0-5)")
+	strands := cfg.DNAConfig.Strands
+	fs.Func("dna-colors", "colors of the dna scene's two strands as #rrggbb,#rrggbb (default "+strands[0].Hex()+","+strands[1].Hex()+")", func(s string) error {
+		first, second, ok := strings.Cut(s, ",")
+		if !ok {
+			return fmt.Errorf("invalid strand colors %q: want #rrggbb,#rrggbb", s)
+		}
+		var err error
+		if cfg.DNAConfig.Strands[0], err = color.ParseHex(first); err != nil {
+			return err
+		}
+		cfg.DNAConfig.Strands[1], err = color.ParseHex(second)
+		return err
+	})
+	fs.StringVar(&cfg.FractalConfig.Set, "fractal-set", cfg.FractalConfig.Set, "set the fractal scene zooms into ("+strings.Join(fractal.Sets, ", ")+")")
+	fs.Float64Var(&cfg.FractalConfig.Speed, "fractal-speed", cfg.FractalConfig.Speed, "how fast the fractal scene zooms, 1 for a doubling every 2.5 seconds (0.1-5)")
+	fs.Float64Var(&cfg.FractalConfig.Depth, "fractal-depth", cfg.FractalConfig.Depth, "magnification the fractal scene's zooms end at, as a power of ten (1-280)")
+	fs.Func("bounce-text", `logo the bounce scene bounces around, lines broken with \n (default: the program's name)`, func(s string) error {
+		cfg.BounceConfig.Logo = strings.Split(strings.ReplaceAll(s, `\n`, "\n"), "\n")
+		return nil
+	})
+	fs.Func("bounce-file", "text file with the logo the bounce scene bounces around, such as figlet output", func(s string) error {
+		data, err := os.ReadFile(s)
+		if err != nil {
+			return err
+		}
+		cfg.BounceConfig.Logo = strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r", ""), "\n"), "\n")
+		return nil
+	})
+	fs.Float64Var(&cfg.BounceConfig.Speed, "bounce-speed", cfg.BounceConfig.Speed, "how fast the bounce scene's logo moves, 1 for 8 columns a second (0.1-10)")
+	fs.StringVar(&cfg.ClockConfig.Style, "clock-style", cfg.ClockConfig.Style, "kind of clock the clock scene shows ("+strings.Join(clock.Styles, ", ")+")")
+	fs.BoolVar(&cfg.ClockConfig.Hour12, "clock-12h", cfg.ClockConfig.Hour12, "count the clock scene's hours from 1 to 12 with AM and PM")
+	fs.BoolVar(&cfg.ClockConfig.Seconds, "clock-seconds", cfg.ClockConfig.Seconds, "show the seconds on the clock scene")
+	fs.StringVar(&cfg.ClockConfig.Zone, "clock-zone", cfg.ClockConfig.Zone, "IANA time zone the clock scene shows, such as Asia/Tokyo (default: local time)")
+	fs.BoolVar(&cfg.ClockConfig.Waves, "clock-waves", cfg.ClockConfig.Waves, "float the clock scene's clock on waves")
+	fs.StringVar(&cfg.LifeConfig.Surface, "life-surface", cfg.LifeConfig.Surface, "shape the life scene's board wraps around ("+strings.Join(life.Surfaces, ", ")+")")
+	fs.Func("heatmap-range", "heatmap values at the ends of the gradient as MIN:MAX (default: each matrix's own range)", func(s string) error {
+		lo, hi, err := app.ParseRange(s)
+		cfg.HeatmapConfig.Min, cfg.HeatmapConfig.Max = lo, hi
+		return err
+	})
+	fs.StringVar(&cfg.HeatmapSource, "heatmap-source", cfg.HeatmapSource, "matrices for the heatmap scene from https://host/path, exec:command, file:path or mqtt://host/topic")
+	fs.DurationVar(&cfg.SourceTTL, "source-ttl", cfg.SourceTTL, "how long data from a -heatmap-source URL or command stays fresh before it is fetched again")
+	fs.StringVar(&cfg.Ticker, "ticker", cfg.Ticker, "message to scroll along the bottom of the screen")
+	fs.Float64Var(&cfg.TickerSpeed, "ticker-speed", cfg.TickerSpeed, "ticker scroll speed in cells per second")
+	fs.Func("place", "overlay position as name=anchor[,margin] with the anchor one of "+strings.Join(overlay.AnchorNames, ", ")+" (repeatable)", func(s string) error {
+		name, p, err := app.ParsePlacement(s)
+		if err != nil {
+			return err
+		}
+		if cfg.Placements == nil {
+			cfg.Placements = make(map[string]overlay.Placement)
+		}
+		cfg.Placements[name] = p
+		return nil
+	})
+	fs.StringVar(&cfg.Logo, "logo", cfg.Logo, "watermark kept on screen: a PNG image, a text file with ANSI colors (.ans) or plain text")
+	fs.IntVar(&cfg.LogoWidth, "logo-width", cfg.LogoWidth, "most cells a PNG -logo is scaled down to across")
+	fs.Float64Var(&cfg.LogoOpacity, "logo-opacity", cfg.LogoOpacity, "opacity of the -logo over the scene (0-1)")
+	fs.IntVar(&cfg.LogoDrift, "logo-drift", cfg.LogoDrift, "cells the -logo wanders around its place against burn-in (0 keeps it still)")
+	fs.Func("page", "dashboard page as scene[+overlay...][@time], such as ocean+weather@2m, cycled through in order (repeatable)", func(s string) error {
+		p, err := app.ParsePage(s)
+		if err != nil {
+			return err
+		}
+		cfg.Pages = append(cfg.Pages, p)
+		return nil
+	})
+	fs.DurationVar(&cfg.PageTime, "page-time", cfg.PageTime, "how long each dashboard -page shows unless it sets its own time")
+	fs.BoolVar(&cfg.Plugins, "plugins", cfg.Plugins, "start the overlay plugins in ~/.config/screensaver/plugins")
+	fs.DurationVar(&cfg.PluginBudget, "plugin-budget", cfg.PluginBudget, "how long a plugin may take to answer for a frame; one late 25 frames in a row is stopped")
+	fs.Float64Var(&cfg.PluginCPU, "plugin-cpu", cfg.PluginCPU, "share of one processor each plugin may use before it is stopped, measured on Linux (0-1, 0 for no limit)")
+	fs.DurationVar(&cfg.HideOverlays, "hide-overlays", cfg.HideOverlays, "fade out the overlays after this long without input, until the next key, click or button (0 keeps them)")
+	fs.StringVar(&cfg.Theme, "theme", cfg.Theme, "color theme ("+strings.Join(theme.Names(), ", ")+", or "+app.Random+")")
+	fs.BoolVar(&cfg.Control, "control", cfg.Control, "accept commands on the ~/.cache/screensaver/control named pipe")
+	fs.BoolVar(&cfg.SeaState, "sea-state", cfg.SeaState, "publish the ocean's sea state every second in ~/.cache/screensaver/sea-state-PID.json")
+	fs.Func("layer", "layer opacity and tint as name=opacity[,#rrggbb] for sky, scene, particles or overlay (repeatable)", func(s string) error {
+		layer, style, err := app.ParseLayerStyle(s)
+		if err != nil {
+			return err
+		}
+		if cfg.Layers == nil {
+			cfg.Layers = make(map[renderer.Layer]renderer.LayerStyle)
+		}
+		cfg.Layers[layer] = style
+		return nil
+	})
+}
+
+// runFlags registers the configuration flags plus those only run accepts.
+func runFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	fs.BoolVar(&cfg.Window, "window", false, "show the screensaver in a graphical window (builds with -tags ebiten)")
+	fs.BoolVar(&cfg.Fullscreen, "fullscreen", false, "with -window, cover the whole screen and quit on any key or mouse movement")
+	fs.StringVar(&cfg.Images, "images", cfg.Images, "draw frames on the terminal as images: iterm2 for the iTerm2 inline image protocol, off for text, auto for images in iTerm2")
+	fs.StringVar(&cfg.Framebuffer, "framebuffer", "", "draw on a Linux framebuffer device such as /dev/fb0 instead of the terminal")
+	fs.Func("eink", "show the screensaver on an e-ink display with an IT8951 controller on this SPI device, e.g. /dev/spidev0.0", func(s string) error {
+		einkOptions.Device = s
+		cfg.EInk = &einkOptions
+		return nil
+	})
+	fs.StringVar(&einkOptions.GPIOChip, "eink-gpio", einkOptions.GPIOChip, "with -eink, the GPIO chip with the controller's ready and reset lines")
+	fs.Float64Var(&einkOptions.VCOM, "eink-vcom", einkOptions.VCOM, "with -eink, the panel's VCOM voltage printed on its cable, e.g. -1.50 (0 keeps the controller's)")
+	fs.IntVar(&einkOptions.FullEvery, "eink-full-every", einkOptions.FullEvery, "with -eink, do a full refresh after this many partial ones to clear ghosting")
+	fs.Func("led", "show the screensaver on an LED matrix display such as pixoo://192.168.1.20 or awtrix://192.168.1.30 (repeatable)", func(s string) error {
+		cfg.LED = append(cfg.LED, s)
+		return nil
+	})
+	fs.Func("presence", "show that the screensaver runs on a Discord webhook URL, or slack for the Slack status of the SLACK_TOKEN user (repeatable)", func(s string) error {
+		cfg.Presence = append(cfg.Presence, s)
+		return nil
+	})
+	fs.DurationVar(&cfg.PresenceInterval, "presence-interval", cfg.PresenceInterval, "time between -presence updates")
+	fs.BoolVar(&cfg.Mic, "mic", false, "blow on the microphone to send gusts over the ocean; only the loudness is measured, nothing is recorded")
+	fs.BoolVar(&cfg.Gamepad, "gamepad", false, "turn the camera, switch scenes and set off effects with a game controller")
+	fs.BoolVar(&cfg.Touch, "touch", false, "react to touchscreen gestures through terminal mouse reporting: double tap, drag and two-finger scroll")
+	fs.StringVar(&cfg.Weather, "weather", cfg.Weather, "show the weather and a 3-day forecast for the place at LAT,LON, from open-meteo.com")
+	fs.BoolVar(&cfg.Fahrenheit, "fahrenheit", cfg.Fahrenheit, "give -weather temperatures in degrees Fahrenheit")
+	fs.BoolVar(&cfg.Takeover, "takeover", false, "quit an already running instance instead of refusing to start")
+	fs.StringVar(&cfg.Record, "record", "", "record the session's input to a replay file")
+	fs.StringVar(&replayPath, "replay", "", "play back a session recorded with -record")
+	fs.StringVar(&cfg.DebugDump, "debug-dump", "", "write frames with their timing, the terminal and the configuration to this directory, to attach to bug reports")
+	fs.IntVar(&cfg.DebugDumpEvery, "debug-dump-every", cfg.DebugDumpEvery, "with -debug-dump, write every Nth frame")
+}
+
+// snapshotFlags registers the configuration flags plus the capture options.
+func snapshotFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	captureFlags(fs, &snapshotOptions)
+	fs.StringVar(&snapshotOutput, "o", "", "write the capture to this file instead of stdout")
+}
+
+// compareFlags registers the configuration flags plus the capture options
+// and the pipelines to compare.
+func compareFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	opts := &compareOptions
+	captureFlags(fs, &opts.SnapshotOptions)
+	fs.Func("pipelines", fmt.Sprintf("the two ways of rendering to compare as A,B, from %s (default %s)", strings.Join(app.RenderPipelines(), ", "), strings.Join(opts.Pipelines[:], ",")), func(s string) error {
+		a, b, ok := strings.Cut(s, ",")
+		if !ok {
+			return fmt.Errorf("want two pipelines as A,B")
+		}
+		opts.Pipelines = [2]string{a, b}
+		return nil
+	})
+	fs.StringVar(&compareOutput, "o", "", "write a diff view of every frame that differs to this file, named like diff%04d.png for several")
+}
+
+// captureFlags registers the options selecting which frames of which size
+// are captured, in what format.
+func captureFlags(fs *flag.FlagSet, opts *app.SnapshotOptions) {
+	fs.IntVar(&opts.Frame, "frame", opts.Frame, "number of frames to advance before the capture")
+	fs.IntVar(&opts.Count, "count", opts.Count, "number of consecutive frames to capture, into files named by an -o like frame%04d.png")
+	fs.Func("size", fmt.Sprintf("screen size in cells as WxH (default %dx%d)", opts.Width, opts.Height), func(s string) error {
+		w, h, err := app.ParseSize(s)
+		opts.Width, opts.Height = w, h
+		return err
+	})
+	fs.StringVar(&opts.Format, "format", "", "output format: txt, svg or png (default from the -o extension, else txt)")
+}
+
+// calibrateFlags registers the configuration flags plus the glyphs to
+// calibrate.
+func calibrateFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	fs.StringVar(&calibrateOptions.Candidates, "candidates", "", "glyphs to order into a shade ramp (default the -shade-ramp)")
+	fs.BoolVar(&calibrateOptions.Print, "print", false, "print the ramp as measured in the -font instead of showing the calibration screen")
+}
+
+// streamFlags registers the configuration flags plus the stream options.
+func streamFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	streamOptionFlags(fs, &streamOptions)
+}
+
+// serveFlags registers the configuration flags plus the server options.
+func serveFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	fs.StringVar(&serveOptions.Addr, "listen", serveOptions.Addr, "TCP address telnet clients connect to")
+	fs.Func("allow", "only accept clients from these comma separated addresses or CIDR ranges (repeatable, default any)", func(s string) error {
+		nets, err := app.ParseAllow(s)
+		serveOptions.Allow = append(serveOptions.Allow, nets...)
+		return err
+	})
+	fs.Func("password-file", "ask clients for the password on the first line of this file", func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		password, _, _ := strings.Cut(string(data), "\n")
+		if password = strings.TrimSuffix(password, "\r"); password == "" {
+			return fmt.Errorf("%s: the first line is empty", path)
+		}
+		if n := utf8.RuneCountInString(password); n > app.MaxPassword {
+			return fmt.Errorf("%s: the password is %d characters long, want at most %d", path, n, app.MaxPassword)
+		}
+		serveOptions.Password = password
+		return nil
+	})
+	fs.IntVar(&serveOptions.MaxSessions, "max-sessions", serveOptions.MaxSessions, "most clients served at once")
+	fs.IntVar(&serveOptions.MaxFPS, "max-fps", serveOptions.MaxFPS, "frame rate cap of every session")
+	fs.Func("max-size", fmt.Sprintf("largest screen in cells a client gets as WxH (default %dx%d)", serveOptions.MaxSize.X, serveOptions.MaxSize.Y), func(s string) error {
+		w, h, err := app.ParseSize(s)
+		serveOptions.MaxSize = image.Pt(w, h)
+		return err
+	})
+	fs.DurationVar(&serveOptions.IdleTimeout, "idle-timeout", serveOptions.IdleTimeout, "end sessions without a key press for this long (0 disables)")
+	streamOptionFlags(fs, &serveOptions.Stream)
+}
+
+// videoFlags registers the configuration flags plus the video options.
+func videoFlags(fs *flag.FlagSet, cfg *app.Config) {
+	configFlags(fs, cfg)
+	fs.StringVar(&videoOptions.Addr, "listen", videoOptions.Addr, "TCP address of the HTTP server")
+	fs.Func("size", fmt.Sprintf("screen size in cells as WxH; -font and -cell-pixels set the pixels per cell (default %dx%d)", videoOptions.Width, videoOptions.Height), func(s string) error {
+		w, h, err := app.ParseSize(s)
+		videoOptions.Width, videoOptions.Height = w, h
+		return err
+	})
+	fs.BoolVar(&videoOptions.Transparent, "transparent", videoOptions.Transparent, "make dark pixels transparent, to lay the animation over other video")
+}
+
+// streamOptionFlags registers the size and encoding flags shared by stream and serve.
+func streamOptionFlags(fs *flag.FlagSet, opts *app.StreamOptions) {
+	fs.Func("size", fmt.Sprintf("screen size in cells as WxH, for serve only until the client reports its own (default %dx%d)", opts.Width, opts.Height), func(s string) error {
+		w, h, err := app.ParseSize(s)
+		opts.Width, opts.Height = w, h
+		return err
+	})
+	fs.BoolVar(&opts.Repeat, "repeat", opts.Repeat, "shorten runs of one character with the REP escape code, not supported by every terminal")
+	fs.Func("colors", "color depth: truecolor, 256 or mono (default truecolor)", func(s string) error {
+		depth, err := ansi.ParseDepth(s)
+		opts.Depth = depth
+		return err
+	})
+	fs.BoolVar(&opts.Adaptive, "adaptive", opts.Adaptive, "lower the color depth, then the frame rate, while the receiver cannot keep up")
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/app"
+)
+
+// TestCommandFlags registers the flags of every command, which panics when
+// two of them share a name.
+func TestCommandFlags(t *testing.T) {
+	for _, cmd := range commands {
+		if cmd.flags == nil {
+			continue
+		}
+		t.Run(cmd.name, func(t *testing.T) {
+			defer func() {
+				if err := recover(); err != nil {
+					t.Fatalf("registering the flags of %s: %v", cmd.name, err)
+				}
+			}()
+			cfg := app.DefaultConfig()
+			cmd.flags(flag.NewFlagSet(cmd.name, flag.ContinueOnError), &cfg)
+		})
+	}
+}
@@ -2,12 +2,21 @@ module github.com/olegchuev/screensaver
 
 go 1.24.0
 
-require github.com/gdamore/tcell/v2 v2.13.5
+require (
+	github.com/gdamore/tcell/v2 v2.13.5
+	github.com/hajimehoshi/ebiten/v2 v2.8.8
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/image v0.20.0
+)
 
 require (
+	github.com/ebitengine/gomobile v0.0.0-20240911145611-4856209ac325 // indirect
+	github.com/ebitengine/hideconsole v1.0.0 // indirect
+	github.com/ebitengine/purego v0.8.0 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
+	github.com/jezek/xgb v1.1.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
 	golang.org/x/text v0.31.0 // indirect
@@ -0,0 +1,155 @@
+package animation
+
+import "math"
+
+// Animation is a value that changes over a fixed duration. Times are in
+// seconds relative to the start of the animation.
+type Animation interface {
+	// Duration returns the length of the animation in seconds.
+	Duration() float64
+	// Value returns the animated value at the given elapsed time. Times
+	// before the start or after the end clamp to the first or last value.
+	Value(elapsed float64) float64
+}
+
+// Tween interpolates from one value to another with an easing curve.
+type Tween struct {
+	From, To float64
+	Length   float64 // Seconds
+	Ease     Easing  // Linear when nil
+}
+
+// NewTween creates a tween from one value to another over length seconds.
+func NewTween(from, to, length float64, ease Easing) Tween {
+	return Tween{From: from, To: to, Length: length, Ease: ease}
+}
+
+// Duration returns the tween length in seconds.
+func (tw Tween) Duration() float64 {
+	return tw.Length
+}
+
+// Value returns the eased value at the given elapsed time.
+func (tw Tween) Value(elapsed float64) float64 {
+	return tw.From + (tw.To-tw.From)*tw.Progress(elapsed)
+}
+
+// Progress returns the eased progress (nominally 0-1) at the given elapsed time.
+func (tw Tween) Progress(elapsed float64) float64 {
+	p := 1.0
+	if tw.Length > 0 {
+		p = clamp01(elapsed / tw.Length)
+	}
+	if tw.Ease == nil {
+		return p
+	}
+	return tw.Ease(p)
+}
+
+// Done reports whether the tween has finished at the given elapsed time.
+func (tw Tween) Done(elapsed float64) bool {
+	return elapsed >= tw.Length
+}
+
+// Hold keeps a constant value for a duration, used as a pause in sequences.
+type Hold struct {
+	At     float64
+	Length float64
+}
+
+// Duration returns the hold length in seconds.
+func (h Hold) Duration() float64 {
+	return h.Length
+}
+
+// Value returns the held value.
+func (h Hold) Value(float64) float64 {
+	return h.At
+}
+
+// Sequence plays animations one after another.
+type Sequence []Animation
+
+// Duration returns the combined length of all steps in seconds.
+func (s Sequence) Duration() float64 {
+	total := 0.0
+	for _, a := range s {
+		total += a.Duration()
+	}
+	return total
+}
+
+// Value returns the value of the step active at the given elapsed time.
+func (s Sequence) Value(elapsed float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	for _, a := range s {
+		if elapsed < a.Duration() {
+			return a.Value(elapsed)
+		}
+		elapsed -= a.Duration()
+	}
+	last := s[len(s)-1]
+	return last.Value(last.Duration())
+}
+
+// Loop repeats an animation forever.
+type Loop struct {
+	Animation Animation
+}
+
+// Duration returns the length of a single iteration.
+func (l Loop) Duration() float64 {
+	return l.Animation.Duration()
+}
+
+// Value returns the value within the current iteration.
+func (l Loop) Value(elapsed float64) float64 {
+	d := l.Animation.Duration()
+	if d <= 0 {
+		return l.Animation.Value(0)
+	}
+	return l.Animation.Value(math.Mod(max(elapsed, 0), d))
+}
+
+// Timer fires at a fixed interval of animation time.
+type Timer struct {
+	Interval float64
+	next     float64
+	started  bool
+}
+
+// NewTimer creates a timer firing every interval seconds.
+func NewTimer(interval float64) *Timer {
+	return &Timer{Interval: interval}
+}
+
+// Tick reports whether the timer fired since the previous call at time t.
+// The first call starts the timer without firing.
+func (tm *Timer) Tick(t float64) bool {
+	if !tm.started {
+		tm.started = true
+		tm.next = t + tm.Interval
+		return false
+	}
+	if tm.Interval <= 0 || t < tm.next {
+		return false
+	}
+	// Skip missed intervals instead of firing repeatedly after a stall
+	for tm.next <= t {
+		tm.next += tm.Interval
+	}
+	return true
+}
+
+// Reset restarts the timer interval from time t.
+func (tm *Timer) Reset(t float64) {
+	tm.started = true
+	tm.next = t + tm.Interval
+}
+
+// clamp01 limits v to the range [0, 1].
+func clamp01(v float64) float64 {
+	return max(0, min(1, v))
+}
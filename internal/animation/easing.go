@@ -0,0 +1,102 @@
+// Package animation provides easing curves, tweens, timers and sequences so
+// overlays, transitions and scenes animate consistently.
+package animation
+
+import "math"
+
+// Easing maps linear progress in [0, 1] to eased progress. Most curves stay
+// within [0, 1]; elastic and back curves overshoot briefly.
+type Easing func(p float64) float64
+
+// Linear progresses at a constant rate.
+func Linear(p float64) float64 { return p }
+
+// EaseInQuad starts slowly and accelerates.
+func EaseInQuad(p float64) float64 { return p * p }
+
+// EaseOutQuad starts quickly and decelerates.
+func EaseOutQuad(p float64) float64 { return 1 - (1-p)*(1-p) }
+
+// EaseInOutQuad accelerates then decelerates.
+func EaseInOutQuad(p float64) float64 {
+	if p < 0.5 {
+		return 2 * p * p
+	}
+	return 1 - math.Pow(-2*p+2, 2)/2
+}
+
+// EaseInCubic starts slowly and accelerates sharply.
+func EaseInCubic(p float64) float64 { return p * p * p }
+
+// EaseOutCubic starts quickly and decelerates smoothly, good for slide-ins.
+func EaseOutCubic(p float64) float64 { return 1 - math.Pow(1-p, 3) }
+
+// EaseInOutCubic accelerates then decelerates more strongly than quad.
+func EaseInOutCubic(p float64) float64 {
+	if p < 0.5 {
+		return 4 * p * p * p
+	}
+	return 1 - math.Pow(-2*p+2, 3)/2
+}
+
+// EaseInOutSine follows a half cosine, the gentlest symmetric curve, good for fades.
+func EaseInOutSine(p float64) float64 { return -(math.Cos(math.Pi*p) - 1) / 2 }
+
+// EaseOutBack overshoots the target slightly before settling.
+func EaseOutBack(p float64) float64 {
+	const c1 = 1.70158
+	const c3 = c1 + 1
+	return 1 + c3*math.Pow(p-1, 3) + c1*math.Pow(p-1, 2)
+}
+
+// EaseOutElastic springs past the target and oscillates into place.
+func EaseOutElastic(p float64) float64 {
+	if p <= 0 || p >= 1 {
+		return p
+	}
+	const c4 = 2 * math.Pi / 3
+	return math.Pow(2, -10*p)*math.Sin((p*10-0.75)*c4) + 1
+}
+
+// EaseOutBounce bounces against the target like a dropped ball, good for logos.
+func EaseOutBounce(p float64) float64 {
+	const n1 = 7.5625
+	const d1 = 2.75
+	switch {
+	case p < 1/d1:
+		return n1 * p * p
+	case p < 2/d1:
+		p -= 1.5 / d1
+		return n1*p*p + 0.75
+	case p < 2.5/d1:
+		p -= 2.25 / d1
+		return n1*p*p + 0.9375
+	default:
+		p -= 2.625 / d1
+		return n1*p*p + 0.984375
+	}
+}
+
+// easings lists the curves by name for configuration lookup.
+var easings = map[string]Easing{
+	"linear":         Linear,
+	"ease-in-quad":   EaseInQuad,
+	"ease-out-quad":  EaseOutQuad,
+	"ease-in-out":    EaseInOutQuad,
+	"ease-in-cubic":  EaseInCubic,
+	"ease-out-cubic": EaseOutCubic,
+	"ease-in-out-3":  EaseInOutCubic,
+	"sine":           EaseInOutSine,
+	"back":           EaseOutBack,
+	"elastic":        EaseOutElastic,
+	"bounce":         EaseOutBounce,
+}
+
+// ByName returns the easing curve with the given name, or Linear and false if unknown.
+func ByName(name string) (Easing, bool) {
+	e, ok := easings[name]
+	if !ok {
+		return Linear, false
+	}
+	return e, true
+}
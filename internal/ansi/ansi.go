@@ -0,0 +1,327 @@
+// Package ansi encodes frames of colored cells as ANSI escape sequences for
+// terminals that are not driven through tcell, such as a pipe over ssh.
+//
+// Encoding is incremental: only cells that changed since the previous frame
+// are written, consecutive cells share one color change, and the cursor is
+// moved with whichever sequence is shortest. A frame where little moves
+// costs a few hundred bytes instead of a full redraw.
+package ansi
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"unicode/utf8"
+
+	scolor "github.com/olegchuev/screensaver/internal/color"
+)
+
+// Cell is one character cell of a frame. Cells are one column wide.
+type Cell struct {
+	Char   rune
+	FG, BG color.RGBA
+}
+
+// Depth is the color depth frames are encoded with. Lower depths need
+// fewer bytes per color change and let more neighbours share one.
+type Depth int
+
+const (
+	// TrueColor sends exact 24-bit colors
+	TrueColor Depth = iota
+	// Colors256 maps colors onto the xterm 256 color palette
+	Colors256
+	// Mono sends characters only, in the terminal's default colors
+	Mono
+)
+
+// depthNames are the names of the depths, as accepted by ParseDepth.
+var depthNames = []string{"truecolor", "256", "mono"}
+
+// ParseDepth returns the depth with the given name.
+func ParseDepth(name string) (Depth, error) {
+	for i, n := range depthNames {
+		if n == name {
+			return Depth(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown color depth %q (available: truecolor, 256, mono)", name)
+}
+
+// String returns the name of the depth.
+func (d Depth) String() string {
+	if d < 0 || int(d) >= len(depthNames) {
+		return "Depth(" + strconv.Itoa(int(d)) + ")"
+	}
+	return depthNames[d]
+}
+
+// Control sequences written around the frames.
+const (
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+	clear      = "\x1b[0m\x1b[2J\x1b[H"
+)
+
+// Encoder writes frames to a terminal, sending only the changes from the
+// previous frame.
+type Encoder struct {
+	w io.Writer
+	// Repeat writes runs of one character with the REP sequence. It makes
+	// flat areas much cheaper but is not understood by every terminal.
+	Repeat bool
+
+	depth   Depth
+	prev    []Cell
+	cells   []Cell             // The frame being encoded, reduced to the depth
+	nearest map[color.RGBA]int // Cached palette lookups for Colors256
+	width   int
+	buf     []byte
+	x, y    int // Cursor position, x is -1 when unknown
+	fg, bg  color.RGBA
+	styled  bool // Whether fg and bg are what the terminal currently uses
+}
+
+// NewEncoder returns an encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, x: -1}
+}
+
+// Reset makes the next frame redraw every cell, for example after the
+// terminal was cleared.
+func (e *Encoder) Reset() {
+	e.prev = nil
+}
+
+// SetDepth changes the color depth of the following frames. Cells that do
+// not change keep the colors they were sent with; call Reset as well to
+// redraw them.
+func (e *Encoder) SetDepth(d Depth) {
+	if d != e.depth {
+		e.depth = d
+		e.styled = false
+	}
+}
+
+// Depth returns the color depth frames are encoded with.
+func (e *Encoder) Depth() Depth {
+	return e.depth
+}
+
+// Encode writes a frame of cells given row by row, width cells per row, in a
+// single write.
+func (e *Encoder) Encode(cells []Cell, width int) error {
+	if width <= 0 {
+		return nil
+	}
+	if e.depth != TrueColor {
+		cells = e.reduce(cells)
+	}
+	e.buf = e.buf[:0]
+	full := e.prev == nil || width != e.width || len(cells) != len(e.prev)
+	if full {
+		e.buf = append(e.buf, hideCursor+clear...)
+		e.x, e.y, e.styled = 0, 0, false
+	}
+
+	for i := 0; i < len(cells); i++ {
+		c := cells[i]
+		if !full && c == e.prev[i] {
+			continue
+		}
+		x, y := i%width, i/width
+		e.move(cells, x, y, width)
+		e.style(c)
+
+		// Identical neighbours in the same row are written as one run
+		n := 1
+		if e.Repeat {
+			for x+n < width && cells[i+n] == c {
+				n++
+			}
+		}
+		e.buf = utf8.AppendRune(e.buf, c.Char)
+		if rep := n - 1; rep > 0 && repeatCost(rep) < rep*utf8.RuneLen(c.Char) {
+			e.buf = append(e.buf, "\x1b["...)
+			e.buf = strconv.AppendInt(e.buf, int64(rep), 10)
+			e.buf = append(e.buf, 'b')
+		} else {
+			for range rep {
+				e.buf = utf8.AppendRune(e.buf, c.Char)
+			}
+		}
+		i += n - 1
+		e.x += n
+		if e.x >= width {
+			// The cursor waits at the margin in a terminal specific way
+			e.x = -1
+		}
+	}
+
+	e.prev = append(e.prev[:0], cells...)
+	e.width = width
+	if len(e.buf) == 0 {
+		return nil
+	}
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// Close restores the default colors, clears the screen and shows the cursor.
+func (e *Encoder) Close() error {
+	_, err := io.WriteString(e.w, clear+showCursor)
+	return err
+}
+
+// move places the cursor at (x, y) with the shortest of an absolute
+// position, a newline or relative motion. Unchanged cells in the way are
+// rewritten instead when that is shorter and needs no color change.
+func (e *Encoder) move(cells []Cell, x, y, width int) {
+	if e.x == x && e.y == y {
+		return
+	}
+	best := cursorPosition(x, y)
+	switch {
+	case e.x >= 0 && e.y == y && x > e.x:
+		if s := forward(x - e.x); len(s) < len(best) {
+			best = s
+		}
+		if s, ok := e.rewrite(cells[y*width+e.x : y*width+x]); ok && len(s) <= len(best) {
+			best = s
+		}
+	case e.x >= 0 && e.y == y && x < e.x:
+		if s := "\r" + forward(x); len(s) < len(best) {
+			best = s
+		}
+	case y > e.y && y-e.y < 4:
+		// Newlines also work from an unknown column
+		s := "\r"
+		for range y - e.y {
+			s += "\n"
+		}
+		if s += forward(x); len(s) < len(best) {
+			best = s
+		}
+	}
+	e.buf = append(e.buf, best...)
+	e.x, e.y = x, y
+}
+
+// rewrite returns the characters of cells if they can be written again in
+// the current colors.
+func (e *Encoder) rewrite(cells []Cell) (string, bool) {
+	if !e.styled || len(cells) > 8 {
+		return "", false
+	}
+	s := ""
+	for _, c := range cells {
+		if c.FG != e.fg || c.BG != e.bg {
+			return "", false
+		}
+		s += string(c.Char)
+	}
+	return s, true
+}
+
+// reduce maps the colors of cells onto the encoder's depth, so cells that
+// look the same at that depth compare equal.
+func (e *Encoder) reduce(cells []Cell) []Cell {
+	e.cells = append(e.cells[:0], cells...)
+	for i := range e.cells {
+		c := &e.cells[i]
+		if e.depth == Mono {
+			c.FG, c.BG = color.RGBA{}, color.RGBA{}
+			continue
+		}
+		c.FG, c.BG = e.reduce256(c.FG), e.reduce256(c.BG)
+	}
+	return e.cells
+}
+
+// xterm256 is the palette Colors256 maps onto.
+var xterm256 = scolor.XTerm256()
+
+// index256 returns the index of the xterm palette color closest to c.
+func (e *Encoder) index256(c color.RGBA) int {
+	i, ok := e.nearest[c]
+	if !ok {
+		if e.nearest == nil {
+			e.nearest = make(map[color.RGBA]int)
+		}
+		i = xterm256.Nearest(scolor.From8(int32(c.R), int32(c.G), int32(c.B)))
+		e.nearest[c] = i
+	}
+	return i
+}
+
+// reduce256 returns the xterm palette color closest to c.
+func (e *Encoder) reduce256(c color.RGBA) color.RGBA {
+	r, g, b := xterm256.Colors[e.index256(c)].To8()
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+}
+
+// style switches the colors to those of c, sending only what changed.
+func (e *Encoder) style(c Cell) {
+	if e.depth == Mono {
+		if !e.styled {
+			e.buf = append(e.buf, "\x1b[0m"...)
+			e.fg, e.bg, e.styled = c.FG, c.BG, true
+		}
+		return
+	}
+	fg := !e.styled || c.FG != e.fg
+	bg := !e.styled || c.BG != e.bg
+	if !fg && !bg {
+		return
+	}
+	e.buf = append(e.buf, "\x1b["...)
+	if fg {
+		e.buf = e.appendColor(e.buf, 38, c.FG)
+	}
+	if bg {
+		if fg {
+			e.buf = append(e.buf, ';')
+		}
+		e.buf = e.appendColor(e.buf, 48, c.BG)
+	}
+	e.buf = append(e.buf, 'm')
+	e.fg, e.bg, e.styled = c.FG, c.BG, true
+}
+
+// appendColor appends the SGR parameters of a color at the encoder's depth,
+// where kind is 38 for the foreground and 48 for the background.
+func (e *Encoder) appendColor(buf []byte, kind int, c color.RGBA) []byte {
+	buf = strconv.AppendInt(buf, int64(kind), 10)
+	if e.depth == Colors256 {
+		buf = append(buf, ";5;"...)
+		return strconv.AppendInt(buf, int64(e.index256(c)), 10)
+	}
+	buf = append(buf, ";2;"...)
+	buf = strconv.AppendInt(buf, int64(c.R), 10)
+	buf = append(buf, ';')
+	buf = strconv.AppendInt(buf, int64(c.G), 10)
+	buf = append(buf, ';')
+	return strconv.AppendInt(buf, int64(c.B), 10)
+}
+
+// cursorPosition returns the sequence moving the cursor to (x, y).
+func cursorPosition(x, y int) string {
+	return "\x1b[" + strconv.Itoa(y+1) + ";" + strconv.Itoa(x+1) + "H"
+}
+
+// forward returns the sequence moving the cursor n columns right.
+func forward(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "\x1b[C"
+	}
+	return "\x1b[" + strconv.Itoa(n) + "C"
+}
+
+// repeatCost returns the length of the REP sequence repeating n times.
+func repeatCost(n int) int {
+	return 3 + len(strconv.Itoa(n))
+}
@@ -0,0 +1,103 @@
+package ansi
+
+import (
+	"bytes"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+var (
+	white = color.RGBA{255, 255, 255, 255}
+	black = color.RGBA{0, 0, 0, 255}
+	red   = color.RGBA{255, 0, 0, 255}
+)
+
+// frame builds cells from rows of text in white on black.
+func frame(rows ...string) []Cell {
+	var cells []Cell
+	for _, row := range rows {
+		for _, ch := range row {
+			cells = append(cells, Cell{Char: ch, FG: white, BG: black})
+		}
+	}
+	return cells
+}
+
+func TestEncodeFullFrame(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	if err := e.Encode(frame("ab", "cd"), 2); err != nil {
+		t.Fatal(err)
+	}
+	want := hideCursor + clear + "\x1b[38;2;255;255;255;48;2;0;0;0m" + "ab" + "\r\n" + "cd"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeOnlyChanges(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	e.Encode(frame("abcdefghijklmnop", "abcdefghijklmnop"), 16)
+
+	out.Reset()
+	e.Encode(frame("abcdefghijklmnop", "abcdefghijklmnop"), 16)
+	if out.Len() != 0 {
+		t.Errorf("unchanged frame: wrote %q, want nothing", out.String())
+	}
+
+	// A far jump uses an absolute position, a near one rewrites the gap
+	cells := frame("abcdefghijklmnop", "abcdefghijklmnop")
+	cells[16+12].Char = 'X'
+	cells[16+14].Char = 'Y'
+	cells[16+14].FG = red
+	e.Encode(cells, 16)
+	want := "\x1b[2;13H" + "X" + "n" + "\x1b[38;2;255;0;0m" + "Y"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncodeRepeat(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	e.Repeat = true
+	e.Encode(frame(strings.Repeat("~", 20)+"ab"), 22)
+	if got, want := out.String(), "~\x1b[19bab"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestEncodeResize(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	e.Encode(frame("ab", "cd"), 2)
+	out.Reset()
+	e.Encode(frame("abc", "def"), 3)
+	if got := out.String(); !strings.HasPrefix(got, hideCursor+clear) || !strings.Contains(got, "def") {
+		t.Errorf("resized frame was not redrawn: %q", got)
+	}
+}
+
+func TestEncodeDepth(t *testing.T) {
+	var out bytes.Buffer
+	e := NewEncoder(&out)
+	e.SetDepth(Colors256)
+	cells := frame("ab")
+	cells[1].FG = red
+	e.Encode(cells, 2)
+	want := "\x1b[38;5;15;48;5;0ma\x1b[38;5;9mb"
+	if got := out.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("256 colors: got %q, want suffix %q", got, want)
+	}
+
+	// Mono sends the characters only
+	out.Reset()
+	e.SetDepth(Mono)
+	e.Reset()
+	e.Encode(cells, 2)
+	if got, want := out.String(), hideCursor+clear+"\x1b[0mab"; got != want {
+		t.Errorf("mono: got %q, want %q", got, want)
+	}
+}
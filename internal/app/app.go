@@ -2,27 +2,337 @@
 package app
 
 import (
+	"fmt"
+	"image"
+	"math"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/animation"
+	"github.com/olegchuev/screensaver/internal/audio"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/gamepad"
+	"github.com/olegchuev/screensaver/internal/holiday"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/plugin"
 	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/replay"
+	"github.com/olegchuev/screensaver/internal/scenes/aquarium"
+	"github.com/olegchuev/screensaver/internal/scenes/bounce"
+	"github.com/olegchuev/screensaver/internal/scenes/clock"
+	"github.com/olegchuev/screensaver/internal/scenes/dna"
+	"github.com/olegchuev/screensaver/internal/scenes/fire"
+	"github.com/olegchuev/screensaver/internal/scenes/fractal"
+	"github.com/olegchuev/screensaver/internal/scenes/galaxy"
+	"github.com/olegchuev/screensaver/internal/scenes/heatmap"
+	"github.com/olegchuev/screensaver/internal/scenes/kaleidoscope"
+	"github.com/olegchuev/screensaver/internal/scenes/lava"
+	"github.com/olegchuev/screensaver/internal/scenes/life"
+	"github.com/olegchuev/screensaver/internal/scenes/matrix"
+	"github.com/olegchuev/screensaver/internal/scenes/pendulum"
+	"github.com/olegchuev/screensaver/internal/scenes/pipes"
+	"github.com/olegchuev/screensaver/internal/scenes/plants"
+	"github.com/olegchuev/screensaver/internal/scenes/plasma"
+	"github.com/olegchuev/screensaver/internal/scenes/reaction"
+	"github.com/olegchuev/screensaver/internal/scenes/ripples"
+	"github.com/olegchuev/screensaver/internal/scenes/snow"
+	"github.com/olegchuev/screensaver/internal/scenes/starfield"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/timing"
+	"github.com/olegchuev/screensaver/internal/transition"
 	"github.com/olegchuev/screensaver/internal/wave"
+	"github.com/olegchuev/screensaver/internal/weather"
 )
 
+// maxFrameStep is the most the animation clock advances in one frame, in
+// seconds, so scenes do not leap after the process was suspended or a
+// frame stalled.
+const maxFrameStep = 0.25
+
 // Config holds application configuration including timing and wave parameters.
 type Config struct {
 	FrameDelay time.Duration
-	WaveConfig wave.Config
+	// FadeIn and FadeOut are the brightness envelope durations at start and exit
+	FadeIn  time.Duration
+	FadeOut time.Duration
+	Scene   string
+	// Theme names the color gradient scenes are rendered with
+	Theme string
+	// Preset names a curated sea from wave.Presets that sets the ocean's
+	// waves and the theme together, see ApplyPreset; empty keeps them
+	Preset string
+	// Seed picks the scene, theme or preset set to Random, see ApplyRandom
+	Seed int64
+	// Control enables the named pipe command interface
+	Control bool
+	// SeaState publishes the state of the ocean's sea to a file for scripts,
+	// see seaStateFile
+	SeaState bool
+	// Layers overrides the opacity and tint of individual render layers
+	Layers map[renderer.Layer]renderer.LayerStyle
+	// Takeover asks an already running instance to quit instead of refusing to start
+	Takeover bool
+	// Window shows the screensaver in a graphical window instead of the terminal
+	Window bool `json:"-"`
+	// Fullscreen covers the whole screen with the window and quits on any key
+	// or mouse movement, like a system screensaver
+	Fullscreen bool `json:"-"`
+	// Images is how frames are drawn on the terminal: "iterm2" as inline
+	// images in the iTerm2 protocol, "off" as text, or "auto" for images in
+	// iTerm2 and text elsewhere
+	Images string `json:"-"`
+	// Framebuffer is the path of a Linux framebuffer device such as /dev/fb0
+	// to draw on instead of the terminal, empty disables
+	Framebuffer string `json:"-"`
+	// EInk shows the screensaver on an e-ink display instead of the terminal, nil disables
+	EInk *EInkOptions `json:"-"`
+	// LED lists LED matrix displays on the network to show the screensaver
+	// on instead of the terminal, as URLs described at ledmatrix.Open
+	LED []string `json:"-"`
+	// Stream writes the animation to a plain output instead of the terminal, nil disables
+	Stream *StreamOptions `json:"-"`
+	// Video serves the animation as an HTTP video stream instead of drawing
+	// on the terminal, nil disables
+	Video *VideoOptions `json:"-"`
+	// Presence lists chat services told that the screensaver is running, as
+	// targets described at presence.Open
+	Presence []string `json:"-"`
+	// PresenceInterval is the time between presence updates
+	PresenceInterval time.Duration `json:"-"`
+	// Guest runs a session for someone else, such as a server client, which
+	// never writes the saved settings or themes
+	Guest bool `json:"-"`
+	// Font is the raster font of every pixel output, such as the window and
+	// png snapshots: a bundled font or the path of a BDF file, see font.Load
+	Font string
+	// CellPixels is the cell size in pixels of every pixel output, zero for
+	// the font's own size
+	CellPixels image.Point
+	// Color holds brightness, contrast and gamma corrections
+	Color renderer.Adjustment
+	// Temperature shifts output colors warmer, fixed or following local time
+	Temperature Temperature
+	// Location is the place at "latitude,longitude" whose sunrise and
+	// sunset the automatic temperature follows, or "ip" to look it up from
+	// the public IP address; empty uses the Weather place, or the clock
+	Location string
+	// CellAspect is the height-to-width ratio of terminal cells
+	CellAspect float64
+	// ColorDepth overrides the color depth the terminal reports when it is
+	// wrong: truecolor, 256, 16 or 8, or auto to go by the terminal
+	ColorDepth string
+	// RenderMode is how the ocean surface is drawn, one of
+	// renderer.ModeNames; terminals whose font lacks its characters fall
+	// back to text
+	RenderMode string
+	// LinearBlending mixes colors in linear light for fades, layer opacity
+	// and transitions, instead of in raw sRGB values
+	LinearBlending bool
+	// ToneMap is the curve brightness that builds up in scenes such as the
+	// galaxy is brought into range with, one of renderer.ToneMapNames
+	ToneMap string
+	// TemporalDither alternates colors on 256 color terminals between the
+	// two nearest palette entries from frame to frame, smoothing gradients
+	// at the cost of a faint flicker
+	TemporalDither bool
+	// ShadeDither is how fields of brightness are dithered onto the shade
+	// characters, one of renderer.ShadeDitherNames
+	ShadeDither string
+	// ShadeRamp is the characters brightness is shaded with, from darkest
+	// to brightest
+	ShadeRamp string
+	// Audio is a WAV file whose loudness drives audio-reactive scenes,
+	// following the frame clock, empty disables
+	Audio string `json:"-"`
+	// Mic lets bursts of microphone loudness, like blowing on it, send
+	// gusts over the scene. Only the loudness is measured, nothing is
+	// recorded
+	Mic bool `json:"-"`
+	// Gamepad lets game controllers turn the camera, switch scenes and
+	// set off effects
+	Gamepad bool `json:"-"`
+	// Touch reads gestures from touchscreens through the terminal's mouse
+	// reporting: double tap, drag and two-finger scroll
+	Touch bool `json:"-"`
+	// Beat tunes the beats found in the audio, which surge the ocean, seed
+	// life and pulse the colors
+	Beat audio.BeatConfig
+	// Kaleidoscope mirrors any scene but the kaleidoscope into this many
+	// segments (0 disables)
+	Kaleidoscope int
+	// Holiday forces a holiday effect, one of holiday.Effects, over the
+	// scene; "off" shows none and empty follows the calendar
+	Holiday string
+	// Holidays are rules for the calendar's effects, taking precedence over
+	// the built-in holiday.Calendar
+	Holidays []holiday.Rule
+	// HideOverlays fades out the overlays such as the ticker after this
+	// long without input, and any input shows them again (0 keeps them)
+	HideOverlays time.Duration
+	// Ticker is a message scrolled along the bottom of the screen (empty disables)
+	Ticker string
+	// TickerSpeed is the ticker scroll speed in cells per second
+	TickerSpeed float64
+	// Weather shows a forecast panel for the place at "latitude,longitude"
+	// (empty disables)
+	Weather string
+	// Fahrenheit gives the weather in degrees Fahrenheit instead of Celsius
+	Fahrenheit bool
+	// Logo is a watermark kept on screen, a PNG image, a text file with ANSI
+	// colors (.ans) or plain text (empty disables)
+	Logo string
+	// LogoWidth is the most cells a PNG logo is scaled down to across
+	LogoWidth int
+	// LogoOpacity blends the logo with the scene, from 0 (invisible) to 1
+	LogoOpacity float64
+	// LogoDrift is how many cells the logo wanders around its place over
+	// the minutes against burn-in, 0 keeps it still
+	LogoDrift int
+	// Placements moves overlays, by name, from their default places
+	Placements map[string]overlay.Placement
+	// Pages turns the screensaver into a dashboard cycling through scenes,
+	// each with its own overlays; empty shows Scene with every overlay
+	Pages []Page
+	// PageTime is how long a dashboard page shows unless it sets its own time
+	PageTime time.Duration
+	// Transition is how a scene gives way to the next when switching, one
+	// of transition.Switches
+	Transition string
+	// TransitionTime is how long the transition between scenes takes
+	TransitionTime time.Duration
+	// pageOverlays are the overlays of the page onPage returned the
+	// configuration for
+	pageOverlays []string
+	// Plugins starts the plugins in the plugins directory of the config
+	// directory, which draw overlays of their own
+	Plugins bool
+	// PluginBudget is how long a plugin may take to answer for a frame
+	// before the answer counts as late; one late too often is stopped
+	PluginBudget time.Duration
+	// PluginCPU is the share of one processor a plugin may use before it
+	// is stopped, 0 for no limit
+	PluginCPU float64
+	// Record writes the session's input to this replay file (empty disables)
+	Record string `json:"-"`
+	// DebugDump writes every DebugDumpEvery-th frame with its timing and
+	// the configuration to this directory, for bug reports (empty disables)
+	DebugDump      string `json:"-"`
+	DebugDumpEvery int    `json:"-"`
+	// Replay plays back a recorded session instead of live input, see LoadReplay
+	Replay *replay.Player `json:"-"`
+	// Reload reads the configuration again from where it came from, for
+	// picking up changes while running, nil disables
+	Reload func() (Config, error) `json:"-"`
+	// Sources maps setting names to where they were given, see SetSource
+	Sources map[string]string `json:"-"`
+	// sourceFile is reported for settings without a source, e.g. when the
+	// whole configuration was read from a replay file
+	sourceFile string
+	// shared is what the sessions of serve take from the server, nil
+	// outside serve
+	shared *sessionShare
+	// Intro effect ("melt", "dissolve" or empty) played over the captured terminal text
+	Intro string
+	// IntroFile provides the text to melt when the terminal contents cannot be captured
+	IntroFile string
+	// HeatmapSource is a data source the heatmap scene shows the matrices
+	// of, as described at datasource.Open (empty disables)
+	HeatmapSource string `json:"-"`
+	// SourceTTL is how long data from sources stays fresh before HTTP and
+	// command sources fetch it again
+	SourceTTL time.Duration
+	// MOTD is a message of the day shown in large letters before the scene
+	// starts, lines broken with \n (empty disables)
+	MOTD string
+	// MOTDFile is a file shown as it is before the scene starts instead of
+	// MOTD, such as a banner made with figlet
+	MOTDFile string
+	// MOTDTime is how long the message shows, not counting its fades
+	MOTDTime        time.Duration
+	WaveConfig      wave.Config
+	PendulumConfig  pendulum.Config
+	GalaxyConfig    galaxy.Config
+	ReactionConfig  reaction.Config
+	PlantsConfig    plants.Config
+	KaleidoConfig   kaleidoscope.Config
+	HeatmapConfig   heatmap.Config
+	LifeConfig      life.Config
+	StarfieldConfig starfield.Config
+	MatrixConfig    matrix.Config
+	PlasmaConfig    plasma.Config
+	FireConfig      fire.Config
+	PipesConfig     pipes.Config
+	SnowConfig      snow.Config
+	RipplesConfig   ripples.Config
+	AquariumConfig  aquarium.Config
+	LavaConfig      lava.Config
+	DNAConfig       dna.Config
+	FractalConfig   fractal.Config
+	BounceConfig    bounce.Config
+	ClockConfig     clock.Config
 }
 
 // DefaultConfig returns default application configuration with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		FrameDelay: 80 * time.Millisecond, // Smooth animation at ~12.5 FPS
-		WaveConfig: wave.DefaultConfig(),
+		FrameDelay:       80 * time.Millisecond, // Smooth animation at ~12.5 FPS
+		FadeIn:           time.Second,
+		FadeOut:          500 * time.Millisecond,
+		PresenceInterval: 5 * time.Minute,
+		Scene:            "ocean",
+		Theme:            theme.Default,
+		Control:          true,
+		Color:            renderer.DefaultAdjustment(),
+		CellAspect:       renderer.DefaultCellAspect,
+		ColorDepth:       "auto",
+		RenderMode:       renderer.ModeText.String(),
+		Images:           "auto",
+		LinearBlending:   true,
+		ToneMap:          renderer.ToneMapExponential.String(),
+		TemporalDither:   true,
+		ShadeDither:      renderer.ShadeDitherOff.String(),
+		ShadeRamp:        renderer.DefaultShadeRamp,
+		TickerSpeed:      6,
+		MOTDTime:         3 * time.Second,
+		LogoWidth:        24,
+		LogoOpacity:      0.6,
+		LogoDrift:        2,
+		PageTime:         time.Minute,
+		Transition:       "dissolve",
+		TransitionTime:   time.Second,
+		Plugins:          true,
+		PluginBudget:     plugin.DefaultLimits().Frame,
+		PluginCPU:        plugin.DefaultLimits().CPU,
+		SourceTTL:        time.Minute,
+		DebugDumpEvery:   25,
+		Beat:             audio.DefaultBeatConfig(),
+		WaveConfig:       wave.DefaultConfig(),
+		PendulumConfig:   pendulum.DefaultConfig(),
+		GalaxyConfig:     galaxy.DefaultConfig(),
+		ReactionConfig:   reaction.DefaultConfig(),
+		PlantsConfig:     plants.DefaultConfig(),
+		KaleidoConfig:    kaleidoscope.DefaultConfig(),
+		HeatmapConfig:    heatmap.DefaultConfig(),
+		LifeConfig:       life.DefaultConfig(),
+		StarfieldConfig:  starfield.DefaultConfig(),
+		MatrixConfig:     matrix.DefaultConfig(),
+		PlasmaConfig:     plasma.DefaultConfig(),
+		FireConfig:       fire.DefaultConfig(),
+		PipesConfig:      pipes.DefaultConfig(),
+		SnowConfig:       snow.DefaultConfig(),
+		RipplesConfig:    ripples.DefaultConfig(),
+		AquariumConfig:   aquarium.DefaultConfig(),
+		LavaConfig:       lava.DefaultConfig(),
+		DNAConfig:        dna.DefaultConfig(),
+		FractalConfig:    fractal.DefaultConfig(),
+		BounceConfig:     bounce.DefaultConfig(),
+		ClockConfig:      clock.DefaultConfig(),
 	}
 }
 
@@ -31,64 +341,468 @@ type App struct {
 	config   Config
 	screen   tcell.Screen
 	renderer *renderer.Renderer
-	wave     *wave.Wave
+	scene    scene
+	overlays overlay.Layout
+	intro    transition.Effect
+	motd     *transition.Banner // Message of the day before the scene, nil once shown
 	running  bool
+	paused   bool
+	switcher *switcher // Scene menu, nil while closed
+	handover *handover // Transition from the previous scene, nil once done
+	designer *designer // Theme editor, nil while closed
+	pacer    *timing.Pacer
+	window   *window           // Graphical window showing the screen, nil in a terminal
+	recorder *replay.Recorder  // Replay file being written, nil unless recording
+	dumper   *debugDumper      // Nil unless dumping frames
+	epoch    time.Time         // Wall clock time of frame 0 in recorded and replayed sessions
+	presence *presenceReporter // Chat services told about the screensaver, nil if none
+	seaState *seaStateFile     // Sea state published for scripts, nil if disabled
+	weather  *forecast         // Weather for the forecast panel, nil if disabled
+	plugins  *plugin.Host      // Plugins drawing overlays, nil if disabled
+	matrix   [][]float64       // Latest heatmap data, nil before any arrives
+	audio    *audio.Clip       // Music the scene reacts to, nil for none
+	beats    *audio.BeatDetector
+	pulse    float64      // Brightness boost of the last beat, fading out
+	mic      *micListener // Microphone gusts, nil unless opted in
+	kick     float64      // Strength of a beat set off by hand for the next frame
+	pad      *gamepad.Pad // Game controllers, nil unless enabled
+	padAxes  map[gamepad.Control]float64
+	touch    touchTracker
+	commands <-chan string
+	closers  []func()
+	// Time of the last key, mouse or controller input, and how far the
+	// overlays have faded out since, from 0 to 1
+	lastInput      time.Time
+	overlaysHidden float64
+	// Place the night light follows the sun at, nil to follow the clock
+	place atomic.Pointer[weather.Location]
+	// Effect of the day over the scene and its name, nil and empty for none
+	holiday     holiday.Effect
+	holidayName string
+	// Dashboard page showing and when it came up, zero until its first frame
+	page      int
+	pageStart time.Time
+	// Themes designed in a session that does not save them, by name, kept
+	// from the other sessions of a server
+	themes map[string]theme.Theme
+	// Error shown along the bottom of the screen for a while, see notify
+	notice notice
+}
+
+// scene is an animation that can be advanced in time and drawn by the renderer.
+// Scenes are created from sceneRegistry and may implement keyHandler,
+// seaStater, matrixReceiver, audioReactive, beatReactive, gustReactive or
+// clockReactive to take part in more than drawing.
+type scene interface {
+	Update(t float64)
+	Render(r *renderer.Renderer)
+}
+
+// keyHandler is implemented by scenes that react to key presses at runtime.
+type keyHandler interface {
+	// HandleKey returns true if the key was consumed by the scene.
+	HandleKey(ev *tcell.EventKey) bool
+}
+
+// clockReactive is implemented by scenes showing the time of day. They are
+// given the wall clock time of every frame, which recorded sessions and
+// snapshots take from the frame count.
+type clockReactive interface {
+	SetTime(now time.Time)
 }
 
 // New creates and initializes a new screensaver application instance.
-func New(cfg Config) (*App, error) {
-	screen, err := tcell.NewScreen()
-	if err != nil {
+func New(cfg Config) (_ *App, err error) {
+	if err := Validate(cfg); err != nil {
 		return nil, err
 	}
-	if err := screen.Init(); err != nil {
+	// A dashboard starts on its first page
+	cfg.Scene = cfg.onPage(0).Scene
+	sc, err := newScene(cfg)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	clip, err := loadAudio(cfg)
+	if err != nil {
 		return nil, err
 	}
+	th, ok := theme.Lookup(cfg.Theme)
+	if !ok {
+		return nil, invalidConfig(fmt.Errorf("unknown theme %q (available: %s)", cfg.Theme, strings.Join(theme.Names(), ", ")))
+	}
+
+	// Two renderers on one terminal fight over every cell, so only one may run.
+	// The previous instance gets its fade-out plus some slack to exit.
+	// Streams and video do not touch the terminal and may run alongside.
+	release := func() {}
+	if cfg.Stream == nil && cfg.Video == nil {
+		if release, err = acquireLock(cfg.Takeover, cfg.FadeOut+2*time.Second); err != nil {
+			return nil, err
+		}
+	}
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	// Capture before the screen switches to the alternate buffer
+	var intro transition.Effect
+	if cfg.Intro != "" {
+		lines, err := transition.Capture(cfg.IntroFile)
+		if err != nil {
+			return nil, invalidConfig(err)
+		}
+		if len(lines) > 0 {
+			if intro, err = transition.New(cfg.Intro, lines, time.Now().UnixNano()); err != nil {
+				return nil, invalidConfig(err)
+			}
+			// The intro already blends from the previous terminal, so skip the fade-in
+			cfg.FadeIn = 0
+		}
+	}
 
-	screen.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
-	screen.HideCursor()
+	motd, err := newMOTD(cfg)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+
+	if cfg.EInk != nil {
+		// Every frame costs a display refresh, and fades would only be a
+		// series of grey refreshes
+		cfg.FrameDelay = max(cfg.FrameDelay, einkFrameDelay)
+		cfg.FadeIn, cfg.FadeOut = 0, 0
+	}
+
+	requestColors(cfg)
+	var screen tcell.Screen
+	var win *window
+	switch {
+	case cfg.Window:
+		screen, win, err = openWindow(cfg)
+	case cfg.Framebuffer != "":
+		screen, err = openFramebuffer(cfg)
+	case cfg.EInk != nil:
+		screen, err = openEInk(cfg)
+	case len(cfg.LED) > 0:
+		screen, err = openLED(cfg)
+	case cfg.Stream != nil:
+		screen, err = openStream(*cfg.Stream, cfg.FrameDelay)
+	case cfg.Video != nil:
+		screen, err = openVideo(*cfg.Video, cfg)
+	case useITerm(cfg):
+		screen, err = openITerm(cfg)
+	default:
+		screen, err = openScreen()
+	}
+	if err != nil {
+		return nil, err
+	}
 	screen.Clear()
+	if cfg.Touch {
+		// Drags come as motion with the button held
+		screen.EnableMouse(tcell.MouseDragEvents)
+	}
 
-	return &App{
+	r := renderer.NewRenderer(screen)
+	r.SetAdjustment(cfg.Color)
+	r.SetCellAspect(cfg.CellAspect)
+	r.SetColors(colorDepth(cfg))
+	r.SetLinearBlending(cfg.LinearBlending)
+	r.SetToneMap(toneMap(cfg))
+	r.SetMode(renderMode(cfg, screen))
+	r.SetTemporalDither(cfg.TemporalDither)
+	r.SetShadeDither(shadeDither(cfg))
+	r.SetShadeRamp(cfg.ShadeRamp)
+	r.SetTheme(th)
+	for l, style := range cfg.Layers {
+		r.SetLayerStyle(l, style)
+	}
+
+	a := &App{
 		config:   cfg,
 		screen:   screen,
-		renderer: renderer.NewRenderer(screen),
-		wave:     wave.NewWave(cfg.WaveConfig),
+		renderer: r,
+		scene:    sc,
+		overlays: newOverlays(cfg.onPage(0), nil, nil),
+		intro:    intro,
+		motd:     motd,
 		running:  true,
-	}, nil
+		window:   win,
+		audio:    clip,
+	}
+
+	if cfg.Replay != nil {
+		a.epoch = cfg.Replay.Header.Start
+	} else if cfg.Record != "" {
+		a.epoch = time.Now()
+		if err := a.startRecording(a.epoch); err != nil {
+			screen.Fini()
+			return nil, err
+		}
+	}
+
+	if cfg.DebugDump != "" {
+		if a.dumper, err = newDebugDumper(cfg); err != nil {
+			screen.Fini()
+			return nil, err
+		}
+	}
+
+	if cfg.Control {
+		// The pipe is a convenience, the animation runs fine without it
+		if commands, closeFn, err := openControl(); err == nil {
+			a.commands = commands
+			a.closers = append(a.closers, closeFn)
+		}
+	}
+	if cfg.SeaState {
+		if a.seaState = newSeaStateFile(); a.seaState != nil {
+			a.closers = append(a.closers, a.seaState.close)
+		}
+	}
+	a.locate()
+	if cfg.shared != nil {
+		a.weather = cfg.shared.weather
+	} else {
+		if a.weather, err = openWeather(cfg); err != nil {
+			screen.Fini()
+			return nil, invalidConfig(err)
+		}
+		if a.weather != nil {
+			a.closers = append(a.closers, a.weather.Close)
+		}
+	}
+	// Plugins that fail to start are left out, the doctor command tells why
+	if a.plugins = startPlugins(cfg); a.plugins != nil {
+		a.closers = append(a.closers, a.plugins.Close)
+	}
+	a.attach(a.scene)
+	a.overlays = newOverlays(cfg.onPage(0), a.weather, a.runningPlugins())
+	// The status is the owner's, which guests of serve would each post to
+	if len(cfg.Presence) > 0 && !cfg.Guest {
+		if a.presence, err = newPresenceReporter(cfg); err != nil {
+			screen.Fini()
+			return nil, invalidConfig(err)
+		}
+		a.closers = append(a.closers, a.presence.close)
+	}
+	// Piped matrices feed the heatmap scene, the microphone blows on it
+	// and controllers steer it; guests share the server's input
+	if !cfg.Guest {
+		if a.mic, err = openMic(cfg); err != nil {
+			screen.Fini()
+			return nil, err
+		}
+		if a.mic != nil {
+			a.closers = append(a.closers, a.mic.close)
+		}
+		if a.pad, err = openGamepad(cfg); err != nil {
+			screen.Fini()
+			return nil, err
+		}
+		if a.pad != nil {
+			a.padAxes = make(map[gamepad.Control]float64)
+			a.closers = append(a.closers, func() { _ = a.pad.Close() })
+		}
+		a.commands = mergeCommands(a.commands, stdinMatrices())
+		matrices, closeSource, err := openHeatmapSource(cfg)
+		if err != nil {
+			screen.Fini()
+			return nil, invalidConfig(err)
+		}
+		if matrices != nil {
+			a.commands = mergeCommands(a.commands, matrices)
+			a.closers = append(a.closers, closeSource)
+		}
+	}
+	// Released last, so a takeover never races the control pipe cleanup
+	a.closers = append(a.closers, release)
+
+	return a, nil
 }
 
 // Run starts the main loop of the screensaver, handling events and rendering frames.
 func (a *App) Run() error {
+	// The window has to own the main goroutine, so the loop moves aside
+	if a.window != nil {
+		return a.window.run(a.loop)
+	}
+	return a.loop()
+}
+
+// loop runs frames until the user or a signal quits.
+func (a *App) loop() error {
 	defer a.screen.Fini()
+	defer func() {
+		for _, closeFn := range a.closers {
+			closeFn()
+		}
+	}()
 
 	// Signal handling for graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	ticker := time.NewTicker(a.config.FrameDelay)
-	defer ticker.Stop()
+	// SIGHUP and edits to the configuration file apply it again
+	var hupChan chan os.Signal
+	var watcher *configWatcher
+	if a.reloads() {
+		hupChan = make(chan os.Signal, 1)
+		signal.Notify(hupChan, syscall.SIGHUP)
+		defer signal.Stop(hupChan)
+		watcher = newConfigWatcher()
+	}
+
+	a.pacer = timing.NewPacer(a.config.FrameDelay)
+	defer a.pacer.Stop()
+
+	var padEvents <-chan gamepad.Event
+	if a.pad != nil {
+		padEvents = a.pad.Events()
+	}
+	// Plugins restarted after their files changed bring new overlays
+	var pluginChanges <-chan struct{}
+	if a.plugins != nil {
+		pluginChanges = a.plugins.Changed()
+	}
 
 	t := 0.0
+	frame := 0
+	// Recorded and replayed sessions run on frame time so they play out identically
+	clock := time.Now
+	if a.recorder != nil || a.config.Replay != nil {
+		clock = func() time.Time { return a.epoch.Add(time.Duration(frame) * a.config.FrameDelay) }
+	}
+	defer func() { a.record(replay.Event{Frame: frame, Kind: replay.KindQuit}) }()
+
+	start := clock()
+	a.touched(start)
+	// The intro and the banner play out in seconds of wall time from the
+	// first frame, whatever the frame rate, and scenes move on by the time
+	// since the last one
+	var firstFrame, lastFrame time.Time
+	var fadeOutStart time.Time
+	fadeIn := animation.NewTween(0, 1, a.config.FadeIn.Seconds(), animation.EaseInOutSine)
+	fadeOut := animation.NewTween(1, 0, a.config.FadeOut.Seconds(), animation.EaseInOutSine)
+
+	// quit starts the fade-out, or reports true if the app should exit now
+	quit := func() bool {
+		if a.config.FadeOut <= 0 || !fadeOutStart.IsZero() {
+			return true
+		}
+		fadeOutStart = clock()
+		return false
+	}
 
 	for a.running {
 		select {
 		case <-sigChan:
-			return nil
-		case <-ticker.C:
+			if quit() {
+				return nil
+			}
+		case <-hupChan:
+			if err := a.reload(); err != nil {
+				a.notify(clock(), fmt.Errorf("configuration not reloaded: %w", err))
+			}
+		case ev := <-padEvents:
+			a.touched(clock())
+			a.handlePad(ev)
+		case <-pluginChanges:
+			a.overlays = newOverlays(a.config.onPage(a.page), a.weather, a.runningPlugins())
+		case line := <-a.commands:
+			a.record(replay.Event{Frame: frame, Kind: replay.KindCommand, Command: line})
+			exit, err := a.execute(line)
+			if err != nil {
+				a.notify(clock(), err)
+			}
+			if exit && quit() {
+				return nil
+			}
+		case now := <-a.pacer.C():
+			a.pacer.Begin(now)
+			if watcher != nil && watcher.changed(now) {
+				if err := a.reload(); err != nil {
+					a.notify(now, fmt.Errorf("configuration not reloaded: %w", err))
+				}
+			}
+
+			// Recorded input is delivered at the frame it originally arrived on
+			replaying := a.config.Replay != nil && !a.config.Replay.Done()
+			if replaying && a.replayFrame(frame, clock()) && quit() {
+				return nil
+			}
+
 			// Handle pending input events
 			if a.screen.HasPendingEvent() {
 				ev := a.screen.PollEvent()
-				if a.handleEvent(ev) {
+				if !replaying || replayInput(ev) {
+					if _, resize := ev.(*tcell.EventResize); !resize {
+						a.touched(clock())
+					}
+					a.recordEvent(frame, ev)
+					if a.handleEvent(ev) && quit() {
+						return nil
+					}
+				}
+			}
+
+			now = clock()
+			if frame > 0 && !a.paused {
+				t += min(now.Sub(lastFrame).Seconds(), maxFrameStep)
+			}
+			lastFrame = now
+			a.expireNotice(now)
+			brightness := fadeIn.Value(now.Sub(start).Seconds())
+			// The banner fades itself, and the scene fades in after it
+			splash := a.motd != nil
+			if splash {
+				brightness = 1
+			}
+			if !fadeOutStart.IsZero() {
+				elapsed := now.Sub(fadeOutStart).Seconds()
+				if fadeOut.Done(elapsed) {
 					return nil
 				}
+				brightness = min(brightness, fadeOut.Value(elapsed))
 			}
 
-			// Update wave state and render frame
-			a.update(t)
-			a.render()
+			a.renderer.SetFade(brightness)
+			a.renderer.SetTemperature(a.temperatureAt(now))
+			a.fadeOverlays(now)
+			a.celebrate(now)
+			a.turnPages(now)
 
-			t += 0.08 // Time progression for wave animation
+			// Update wave state and render frame; a paused scene keeps
+			// rendering so resizes and color changes still show
+			if !a.paused {
+				a.blow(now)
+				a.tell(now)
+				a.hear(frame)
+				a.update(t)
+			}
+			if a.pad != nil {
+				a.steer()
+			}
+			if frame == 0 {
+				firstFrame = now
+			}
+			a.render(t, now.Sub(firstFrame).Seconds())
+			if splash && a.motd == nil {
+				start = clock()
+			}
+			if a.presence != nil {
+				a.presence.capture(a.screen, a.config.CellAspect, a.config.Scene)
+			}
+			if a.seaState != nil {
+				a.seaState.update(a.scene, a.config.Scene, now)
+			}
+			if a.dumper != nil {
+				a.dumper.dump(a, frame, t, now)
+			}
+
+			frame++
+			a.pacer.End(time.Now())
 		}
 	}
 
@@ -99,14 +813,63 @@ func (a *App) Run() error {
 func (a *App) handleEvent(ev tcell.Event) bool {
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
+		if a.designer != nil && ev.Key() != tcell.KeyCtrlC {
+			// The panel only changes on key presses, so it is redrawn from cache otherwise
+			a.renderer.Invalidate(a.designer)
+			if a.designer.HandleKey(ev) {
+				a.designer = nil
+			}
+			return false
+		}
+		if a.switcher != nil && ev.Key() != tcell.KeyCtrlC {
+			if done, name := a.switcher.HandleKey(ev); done {
+				a.switcher = nil
+				if name != "" && name != a.config.Scene {
+					_ = a.switchScene(name)
+				}
+			}
+			return false
+		}
 		switch ev.Key() {
 		case tcell.KeyEscape, tcell.KeyCtrlC:
 			return true
+		case tcell.KeyTab:
+			th, _ := a.lookupTheme(a.config.Theme)
+			if sw, err := newSwitcher(a.config, th); err == nil {
+				a.switcher = sw
+			}
+			return false
+		case tcell.KeyPgDn:
+			a.showPage(a.page + 1)
+			return false
+		case tcell.KeyPgUp:
+			a.showPage(a.page - 1)
+			return false
 		case tcell.KeyRune:
 			if ev.Rune() == 'q' || ev.Rune() == 'Q' {
 				return true
 			}
+			if ev.Rune() == ' ' {
+				a.paused = !a.paused
+				return false
+			}
+			if ev.Rune() == 'T' {
+				a.openDesigner()
+				return false
+			}
+			if ev.Rune() == 't' {
+				a.nextTheme()
+				return false
+			}
+			if a.adjustColor(ev.Rune()) {
+				return false
+			}
+		}
+		if h, ok := a.scene.(keyHandler); ok {
+			h.HandleKey(ev)
 		}
+	case *tcell.EventMouse:
+		a.handleMouse(ev, ev.When())
 	case *tcell.EventResize:
 		a.screen.Sync()
 		a.renderer.Resize()
@@ -114,18 +877,230 @@ func (a *App) handleEvent(ev tcell.Event) bool {
 	return false
 }
 
-// update advances the wave simulation by the given time delta.
+// openDesigner starts the theme editor on the active theme. Saved themes
+// go to the config directory and become the active theme.
+func (a *App) openDesigner() {
+	current, ok := a.lookupTheme(a.config.Theme)
+	if !ok {
+		return
+	}
+	a.designer = newDesigner(current, a.renderer.SetTheme, func(t theme.Theme) error {
+		// Replayed and guest sessions show the theme without writing it
+		if !a.saves() {
+			if err := t.Validate(); err != nil {
+				return err
+			}
+			if a.themes == nil {
+				a.themes = make(map[string]theme.Theme)
+			}
+			a.themes[t.Name] = t
+		} else {
+			dir, err := themeDir()
+			if err != nil {
+				return err
+			}
+			if err := theme.Save(dir, t); err != nil {
+				return err
+			}
+		}
+		a.config.Theme = t.Name
+		a.renderer.SetTheme(t)
+		return nil
+	})
+}
+
+// lookupTheme returns the theme with the given name, preferring the ones
+// designed in this session.
+func (a *App) lookupTheme(name string) (theme.Theme, bool) {
+	if t, ok := a.themes[name]; ok {
+		return t, true
+	}
+	return theme.Lookup(name)
+}
+
+// saves reports whether runtime changes, such as color adjustments, are
+// written to the saved settings.
+func (a *App) saves() bool {
+	return a.config.Replay == nil && !a.config.Guest
+}
+
+// adjustColor changes brightness, contrast or gamma for the given key and
+// persists the result. It returns false if the key is not a color binding.
+func (a *App) adjustColor(key rune) bool {
+	const step = 0.05
+
+	adj := a.renderer.Adjustment()
+	switch key {
+	case '+', '=':
+		adj.Brightness = min(adj.Brightness+step, 2)
+	case '-', '_':
+		adj.Brightness = max(adj.Brightness-step, 0.05)
+	case '>', '.':
+		adj.Contrast = min(adj.Contrast+step, 3)
+	case '<', ',':
+		adj.Contrast = max(adj.Contrast-step, 0.1)
+	case 'G':
+		adj.Gamma = min(adj.Gamma+step, 3)
+	case 'g':
+		adj.Gamma = max(adj.Gamma-step, 0.2)
+	case '0':
+		adj = renderer.DefaultAdjustment()
+	default:
+		return false
+	}
+
+	a.renderer.SetAdjustment(adj)
+	a.config.Color = adj
+	// Persisting is best effort, a read-only config directory must not stop the animation
+	if a.saves() {
+		_ = updateState(func(s *State) { s.Color = adj })
+	}
+	return true
+}
+
+// update advances the active scene to the given time.
 func (a *App) update(t float64) {
-	a.wave.Update(t)
+	// The running scene holds still while the menu previews the others
+	if a.switcher != nil {
+		a.switcher.Update(t)
+	} else {
+		a.scene.Update(t)
+		if h := a.handover; h != nil && h.update(t, a.config.FrameDelay.Seconds()) {
+			a.handover = nil
+		}
+	}
+	if a.holiday != nil {
+		a.holiday.Update(t)
+	}
+	a.overlays.Update(t)
+}
+
+// tell passes the wall clock time of the frame to a scene showing it.
+func (a *App) tell(now time.Time) {
+	if c, ok := a.scene.(clockReactive); ok {
+		c.SetTime(now)
+	}
 }
 
-// render clears the screen and draws the current wave state.
-func (a *App) render() {
+// render clears the screen and draws the current scene state with
+// post-effects. shown is the seconds since the first frame, which time the
+// intro and the banner.
+func (a *App) render(t, shown float64) {
 	a.renderer.Clear()
-	a.renderer.RenderWave(a.wave)
+	switch {
+	case a.motd != nil:
+		// The banner has the screen to itself until it has faded out
+		a.renderer.SetLayer(renderer.LayerUI)
+		if a.motd.Render(a.renderer, shown) {
+			a.motd = nil
+		}
+	case a.switcher != nil:
+		a.renderer.SetLayer(renderer.LayerUI)
+		a.switcher.Render(a.renderer)
+	default:
+		a.renderer.SetLayer(renderer.LayerScene)
+		if a.handover != nil {
+			a.handover.render(a.renderer, a.scene)
+		} else {
+			a.scene.Render(a.renderer)
+		}
+		// The kaleidoscope scene is mirrored already, and mirroring it again
+		// only folds its own symmetry over itself
+		if a.config.Kaleidoscope > 0 && a.config.Scene != "kaleidoscope" {
+			a.renderer.Kaleidoscope(a.config.Kaleidoscope, t*0.1)
+		}
+		if a.holiday != nil {
+			a.renderer.SetLayer(renderer.LayerParticles)
+			a.holiday.Render(a.renderer)
+		}
+	}
+	a.renderer.SetLayer(renderer.LayerOverlay)
+	if a.overlaysHidden < 1 && a.motd == nil {
+		a.overlays.Render(a.renderer)
+	}
+	a.renderer.SetLayer(renderer.LayerUI)
+	if d := a.designer; d != nil {
+		a.renderer.Cached(d, func() { d.Render(a.renderer) })
+	}
+	if a.intro != nil && a.intro.Render(a.renderer, shown) {
+		a.intro = nil
+	}
+	a.renderNotice(a.renderer)
 	a.renderer.Flush()
 }
 
+// renderMode returns the configured render mode, or text when the screen
+// cannot show the characters the mode draws with.
+func renderMode(cfg Config, screen tcell.Screen) renderer.Mode {
+	mode, err := renderer.ParseMode(cfg.RenderMode)
+	if err != nil || (mode.Glyph() != 0 && !screen.CanDisplay(mode.Glyph(), false)) {
+		return renderer.ModeText
+	}
+	return mode
+}
+
+// toneMap returns the configured tone map, exponential if it is unknown.
+func toneMap(cfg Config) renderer.ToneMap {
+	t, _ := renderer.ParseToneMap(cfg.ToneMap)
+	return t
+}
+
+// shadeDither returns the configured shade dithering, off if it is unknown.
+func shadeDither(cfg Config) renderer.ShadeDither {
+	d, _ := renderer.ParseShadeDither(cfg.ShadeDither)
+	return d
+}
+
+// ParseCellAspect parses a cell aspect ratio given as "W:H" (e.g. "1:2") or
+// as a single height-to-width number (e.g. "2").
+func ParseCellAspect(s string) (float64, error) {
+	// positive parses one side or the ratio, rejecting anything after it
+	positive := func(v string) (float64, bool) {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil && f > 0 && !math.IsInf(f, 0)
+	}
+	if width, height, ok := strings.Cut(s, ":"); ok {
+		w, wok := positive(width)
+		h, hok := positive(height)
+		if !wok || !hok {
+			return 0, fmt.Errorf("invalid cell aspect %q: both sides must be positive numbers", s)
+		}
+		return h / w, nil
+	}
+	ratio, ok := positive(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid cell aspect %q: want W:H like 1:2 or a positive number", s)
+	}
+	return ratio, nil
+}
+
+// ParseLayerStyle parses a layer setting of the form "name=opacity" or
+// "name=opacity,#rrggbb", for example "scene=0.3" or "overlay=1,#ffd080".
+func ParseLayerStyle(s string) (renderer.Layer, renderer.LayerStyle, error) {
+	style := renderer.DefaultLayerStyle()
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return 0, style, fmt.Errorf("invalid layer setting %q: want name=opacity[,#rrggbb]", s)
+	}
+	layer, err := renderer.ParseLayer(name)
+	if err != nil {
+		return 0, style, err
+	}
+
+	opacity, tint, hasTint := strings.Cut(value, ",")
+	if style.Opacity, err = strconv.ParseFloat(opacity, 64); err != nil || !(style.Opacity >= 0 && style.Opacity <= 1) {
+		return 0, style, fmt.Errorf("invalid layer opacity %q: want a number from 0 to 1", opacity)
+	}
+	if hasTint {
+		c, err := color.ParseHex(tint)
+		if err != nil {
+			return 0, style, fmt.Errorf("invalid layer tint %q: want #rrggbb", tint)
+		}
+		style.Tint = [3]float64{c.R, c.G, c.B}
+	}
+	return layer, style, nil
+}
+
 // Stop signals the application to stop running.
 func (a *App) Stop() {
 	a.running = false
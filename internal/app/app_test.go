@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+func TestParseCellAspect(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float64
+	}{
+		{"1:2", 2},
+		{"2:3", 1.5},
+		{" 1 : 2 ", 2},
+		{"2", 2},
+		{"1.8", 1.8},
+		{"5e-1", 0.5},
+	}
+	for _, tt := range tests {
+		got, err := ParseCellAspect(tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCellAspect(%q) = %v, %v; want %v", tt.s, got, err, tt.want)
+		}
+	}
+	for _, s := range []string{"", "0", "-2", "1:0", "0:1", "1:-2", "1:2x", "2x", "1:2:3", "1:", ":2", "a:b", "inf", "1:inf", "nan", "2 3"} {
+		if _, err := ParseCellAspect(s); err == nil {
+			t.Errorf("ParseCellAspect(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestParseLayerStyle(t *testing.T) {
+	tests := []struct {
+		s       string
+		opacity float64
+		tint    [3]float64
+	}{
+		{"scene=0.3", 0.3, renderer.DefaultLayerStyle().Tint},
+		{"overlay=1,#ff0000", 1, [3]float64{1, 0, 0}},
+		{"scene=0", 0, renderer.DefaultLayerStyle().Tint},
+	}
+	for _, tt := range tests {
+		_, style, err := ParseLayerStyle(tt.s)
+		if err != nil || style.Opacity != tt.opacity || style.Tint != tt.tint {
+			t.Errorf("ParseLayerStyle(%q) = %+v, %v; want opacity %v, tint %v", tt.s, style, err, tt.opacity, tt.tint)
+		}
+	}
+	for _, s := range []string{"scene", "scene=", "scene=0.5junk", "scene=1.5", "scene=-0.1", "scene=nan", "scene=0.5,#ff00ffzz", "scene=0.5,#ff00f", "scene=0.5,ff00ff", "nolayer=0.5"} {
+		if _, _, err := ParseLayerStyle(s); err == nil {
+			t.Errorf("ParseLayerStyle(%q) succeeded, want an error", s)
+		}
+	}
+}
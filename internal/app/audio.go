@@ -0,0 +1,69 @@
+package app
+
+import (
+	"math"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/audio"
+)
+
+// audioReactive is implemented by scenes that react to music.
+type audioReactive interface {
+	SetAudio(l audio.Levels)
+}
+
+// beatReactive is implemented by scenes with an event for each beat of the
+// music, such as a surge of the waves.
+type beatReactive interface {
+	// Beat is called on the frame a beat starts, with its strength from
+	// just above 0 to 1
+	Beat(strength float64)
+}
+
+// pulseDecay is the time for the color pulse of a beat to fade to a third.
+const pulseDecay = 150 * time.Millisecond
+
+// loadAudio decodes the audio file of the configuration, if any.
+func loadAudio(cfg Config) (*audio.Clip, error) {
+	if cfg.Audio == "" {
+		return nil, nil
+	}
+	clip, err := audio.Load(cfg.Audio)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	return clip, nil
+}
+
+// hear passes the loudness of the audio at a frame to the scene, and its
+// beats along with any set off by hand. The audio runs on the frame clock
+// rather than the wall clock, so every render of the same frames hears the
+// same music, however long the frames take.
+func (a *App) hear(frame int) {
+	strength := a.kick
+	a.kick = 0
+	if a.audio != nil {
+		at := time.Duration(frame) * a.config.FrameDelay
+		if r, ok := a.scene.(audioReactive); ok {
+			r.SetAudio(a.audio.Levels(at))
+		}
+		if a.beats == nil {
+			a.beats = a.audio.Beats(a.config.Beat)
+		}
+		strength = max(strength, a.beats.Advance(at))
+	}
+	if r, ok := a.scene.(beatReactive); ok && strength > 0 {
+		r.Beat(strength)
+	}
+	// Every scene pulses its colors brighter on the beat
+	if strength == 0 && a.pulse == 0 {
+		return
+	}
+	a.pulse = max(a.pulse*math.Exp(-a.config.FrameDelay.Seconds()/pulseDecay.Seconds()), strength)
+	if a.pulse < 0.01 {
+		a.pulse = 0
+	}
+	adj := a.config.Color
+	adj.Brightness *= 1 + 0.4*a.pulse
+	a.renderer.SetAdjustment(adj)
+}
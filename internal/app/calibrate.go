@@ -0,0 +1,269 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	rampColumn  = 5  // Cells per glyph on the calibration screen: a swatch and a gap
+	rampPreview = 48 // Cells of the gradient drawn with the ramp
+)
+
+// CalibrateOptions selects the glyphs Calibrate orders.
+type CalibrateOptions struct {
+	// Candidates are the glyphs to order into a ramp, empty for the
+	// configured shade ramp
+	Candidates string
+	// Print writes the measured ramp to the writer instead of showing the
+	// calibration screen
+	Print bool
+}
+
+// rampGlyph is a candidate shade character with the share of its cell the
+// font covers.
+type rampGlyph struct {
+	char    rune
+	density float64
+}
+
+// measureRamp orders the glyphs from the sparsest to the densest as face
+// draws them, leaving out repeats and the glyphs face lacks, which it
+// returns as missing.
+func measureRamp(face font.Face, glyphs string) (ramp []rampGlyph, missing []rune) {
+	for _, r := range glyphs {
+		if slices.ContainsFunc(ramp, func(g rampGlyph) bool { return g.char == r }) || slices.Contains(missing, r) {
+			continue
+		}
+		if !blockGlyph(r) {
+			if _, ok := face.Glyph(r); !ok {
+				missing = append(missing, r)
+				continue
+			}
+		}
+		ramp = append(ramp, rampGlyph{char: r, density: glyphDensity(face, r)})
+	}
+	slices.SortStableFunc(ramp, func(a, b rampGlyph) int {
+		switch {
+		case a.density < b.density:
+			return -1
+		case a.density > b.density:
+			return 1
+		}
+		return 0
+	})
+	return ramp, missing
+}
+
+// glyphDensity returns the share of a cell the glyph of r covers, as it is
+// drawn in png snapshots: the mean coverage of its pixels from 0 to 1.
+func glyphDensity(face font.Face, r rune) float64 {
+	mask := cellMask(face, r)
+	w, h := face.CellSize()
+	sum := 0
+	for y := range h {
+		for x := range w {
+			sum += int(mask.AlphaAt(x, y).A)
+		}
+	}
+	return float64(sum) / float64(255*w*h)
+}
+
+// rampString returns the characters of a ramp.
+func rampString(ramp []rampGlyph) string {
+	chars := make([]rune, len(ramp))
+	for i, g := range ramp {
+		chars[i] = g.char
+	}
+	return string(chars)
+}
+
+// Calibrate orders the candidate glyphs into a shade ramp by how densely
+// the -font draws them. Terminal fonts differ from it, so the calibration
+// screen then shows the glyphs as swatches in the terminal's own font,
+// where they can be reordered by eye before the ramp is saved as the
+// shade ramp future runs start with.
+func Calibrate(cfg Config, opts CalibrateOptions, out io.Writer) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return invalidConfig(err)
+	}
+	measured, missing := measureRamp(face, cmp.Or(opts.Candidates, cfg.ShadeRamp))
+	if len(missing) > 0 {
+		fmt.Fprintf(out, "The font has no glyph for %q, leaving it out\n", string(missing))
+	}
+	if len(measured) < 2 {
+		return invalidConfig(errors.New("calibration needs at least 2 different glyphs the font has"))
+	}
+	if opts.Print {
+		fmt.Fprintln(out, rampString(measured))
+		return nil
+	}
+
+	screen, err := openScreen()
+	if err != nil {
+		return err
+	}
+	c := &calibrator{
+		screen:   screen,
+		renderer: renderer.NewRenderer(screen),
+		measured: measured,
+		ramp:     slices.Clone(measured),
+	}
+	save := c.run()
+	screen.Fini()
+	if !save {
+		return nil
+	}
+	ramp := rampString(c.ramp)
+	if err := updateState(func(st *State) { st.ShadeRamp = ramp }); err != nil {
+		return err
+	}
+	path, _ := statePath()
+	fmt.Fprintf(out, "Saved shade ramp %q to %s\n", ramp, path)
+	return nil
+}
+
+// calibrator is the calibration screen, showing the ramp being ordered.
+type calibrator struct {
+	screen   tcell.Screen
+	renderer *renderer.Renderer
+	measured []rampGlyph // The ramp in the order measured from the font
+	ramp     []rampGlyph // The ramp as reordered on the screen
+	selected int
+}
+
+// run shows the screen until the ramp is saved or the calibration is
+// cancelled, reporting whether to save.
+func (c *calibrator) run() bool {
+	for {
+		c.draw()
+		switch ev := c.screen.PollEvent().(type) {
+		case nil:
+			return false
+		case *tcell.EventResize:
+			c.screen.Sync()
+			c.renderer.Resize()
+		case *tcell.EventKey:
+			if done, save := c.handleKey(ev); done {
+				return save
+			}
+		}
+	}
+}
+
+// handleKey moves the selection or the selected glyph. It reports whether
+// the calibration is finished and, if so, whether to save the ramp.
+func (c *calibrator) handleKey(ev *tcell.EventKey) (done, save bool) {
+	move := ev.Modifiers()&tcell.ModShift != 0
+	switch ev.Key() {
+	case tcell.KeyCtrlC, tcell.KeyEscape:
+		return true, false
+	case tcell.KeyEnter:
+		return true, true
+	case tcell.KeyLeft:
+		c.step(-1, move)
+	case tcell.KeyRight:
+		c.step(1, move)
+	case tcell.KeyDelete, tcell.KeyBackspace, tcell.KeyBackspace2:
+		c.remove()
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'q':
+			return true, false
+		case '<', ',':
+			c.step(-1, true)
+		case '>', '.':
+			c.step(1, true)
+		case 'x':
+			c.remove()
+		case 'm':
+			c.ramp = slices.Clone(c.measured)
+			c.selected = min(c.selected, len(c.ramp)-1)
+		}
+	}
+	return false, false
+}
+
+// step moves the selection by delta, taking the selected glyph along when
+// move is set.
+func (c *calibrator) step(delta int, move bool) {
+	next := c.selected + delta
+	if next < 0 || next >= len(c.ramp) {
+		return
+	}
+	if move {
+		c.ramp[c.selected], c.ramp[next] = c.ramp[next], c.ramp[c.selected]
+	}
+	c.selected = next
+}
+
+// remove drops the selected glyph from the ramp, keeping at least two.
+func (c *calibrator) remove() {
+	if len(c.ramp) <= 2 {
+		return
+	}
+	c.ramp = slices.Delete(c.ramp, c.selected, c.selected+1)
+	c.selected = min(c.selected, len(c.ramp)-1)
+}
+
+// draw shows the glyphs as swatches with their measured density, in rows
+// as wide as the screen allows, and a gradient drawn with the ramp.
+func (c *calibrator) draw() {
+	r := c.renderer
+	r.Clear()
+	width, _ := r.Size()
+	perRow := max(1, min(len(c.ramp), (width-8)/rampColumn))
+
+	lines := []panelLine{{"Shade ramp calibration, sparsest to densest", panelTitle}, {}}
+	swatchRow := make(map[int]int) // First line of each row of swatches
+	for start := 0; start < len(c.ramp); start += perRow {
+		row := c.ramp[start:min(start+perRow, len(c.ramp))]
+		var swatch, density strings.Builder
+		for _, g := range row {
+			swatch.WriteString(strings.Repeat(string(g.char), rampColumn-1) + " ")
+			fmt.Fprintf(&density, "%3.0f%% ", g.density*100)
+		}
+		swatchRow[start] = len(lines)
+		for range 2 {
+			lines = append(lines, panelLine{swatch.String(), panelStyle})
+		}
+		lines = append(lines, panelLine{density.String(), panelHint}, panelLine{})
+	}
+	r.SetShadeRamp(rampString(c.ramp))
+	preview := make([]rune, rampPreview)
+	for i := range preview {
+		preview[i] = r.ShadeChar(float64(i) / float64(rampPreview-1))
+	}
+	lines = append(lines,
+		panelLine{string(preview), panelStyle},
+		panelLine{},
+		panelLine{"←/→ select   Shift+←/→ or </> move   x remove", panelHint},
+		panelLine{"m measured order   Enter save   Esc cancel", panelHint})
+	x, y := drawPanel(r, 2, 1, lines)
+
+	// The selected swatch is highlighted over its density
+	start := c.selected / perRow * perRow
+	col := x + (c.selected-start)*rampColumn
+	g := c.ramp[c.selected]
+	label := []rune(fmt.Sprintf("%3.0f%%", g.density*100))
+	for i := range rampColumn - 1 {
+		for line := range 2 {
+			r.SetCell(col+i, y+swatchRow[start]+line, g.char, overlay.Depth+1, panelSelected)
+		}
+		r.SetCell(col+i, y+swatchRow[start]+2, label[i], overlay.Depth+1, panelSelected)
+	}
+	r.Flush()
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// colorDepthNames are the values of Config.ColorDepth.
+var colorDepthNames = []string{"auto", "truecolor", "256", "16", "8"}
+
+// colorDepth returns the number of colors cfg.ColorDepth names, 0 for the
+// number the terminal reports.
+func colorDepth(cfg Config) int {
+	switch cfg.ColorDepth {
+	case "auto":
+		return 0
+	case "truecolor":
+		return 1 << 24
+	}
+	n, _ := strconv.Atoi(cfg.ColorDepth)
+	return n
+}
+
+// trueColorPrograms are terminals that show true color, by TERM_PROGRAM.
+var trueColorPrograms = []string{"iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper", "Tabby"}
+
+// trueColorTerms are values of TERM that only terminals showing true color
+// set.
+var trueColorTerms = []string{"xterm-kitty", "xterm-ghostty", "alacritty", "foot", "wezterm"}
+
+// detectTrueColor tells tcell that the terminal shows true color where it
+// is known to but leaves COLORTERM unset, which tcell goes by otherwise.
+// Many terminals only set TERM to xterm-256color, and COLORTERM is lost
+// over ssh and sudo, while the hints checked here, such as the LC_TERMINAL
+// iTerm2 sets for ssh to pass on, often survive.
+func detectTrueColor() {
+	if os.Getenv("COLORTERM") != "" {
+		return
+	}
+	term := os.Getenv("TERM")
+	if slices.Contains(trueColorPrograms, os.Getenv("TERM_PROGRAM")) ||
+		os.Getenv("LC_TERMINAL") == "iTerm2" ||
+		os.Getenv("WT_SESSION") != "" ||
+		slices.Contains(trueColorTerms, term) || strings.HasSuffix(term, "-direct") {
+		os.Setenv("COLORTERM", "truecolor")
+	}
+}
+
+// requestColors makes tcell send true color to the terminal when the
+// configuration asks for it, whatever the terminal reports. Other color
+// depths are kept to by the renderer, see Renderer.SetColors.
+func requestColors(cfg Config) {
+	if cfg.ColorDepth == "truecolor" {
+		os.Setenv("COLORTERM", "truecolor")
+	}
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"os"
+	"testing"
+)
+
+func TestColorDepth(t *testing.T) {
+	tests := []struct {
+		depth string
+		want  int
+	}{
+		{"auto", 0},
+		{"truecolor", 1 << 24},
+		{"256", 256},
+		{"16", 16},
+		{"8", 8},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig()
+		cfg.ColorDepth = tt.depth
+		if got := colorDepth(cfg); got != tt.want {
+			t.Errorf("colorDepth(%q) = %d, want %d", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestDetectTrueColor(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want string // COLORTERM afterwards
+	}{
+		{name: "plain xterm", env: map[string]string{"TERM": "xterm-256color"}},
+		{name: "COLORTERM kept", env: map[string]string{"COLORTERM": "24bit", "TERM": "xterm-kitty"}, want: "24bit"},
+		{name: "iTerm2", env: map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, want: "truecolor"},
+		{name: "unknown program", env: map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "Apple_Terminal"}},
+		{name: "iTerm2 over ssh", env: map[string]string{"TERM": "xterm-256color", "LC_TERMINAL": "iTerm2"}, want: "truecolor"},
+		{name: "Windows Terminal", env: map[string]string{"TERM": "xterm-256color", "WT_SESSION": "1"}, want: "truecolor"},
+		{name: "kitty", env: map[string]string{"TERM": "xterm-kitty"}, want: "truecolor"},
+		{name: "direct color terminfo", env: map[string]string{"TERM": "xterm-direct"}, want: "truecolor"},
+		{name: "screen", env: map[string]string{"TERM": "screen-256color"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"COLORTERM", "TERM", "TERM_PROGRAM", "LC_TERMINAL", "WT_SESSION"} {
+				t.Setenv(name, tt.env[name])
+			}
+			detectTrueColor()
+			if got := os.Getenv("COLORTERM"); got != tt.want {
+				t.Errorf("COLORTERM = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// execute runs a single text command such as "scene pendulum" or "pause".
+// It returns true if the command asks the application to quit.
+func (a *App) execute(line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false, nil
+	}
+
+	switch cmd, args := strings.ToLower(fields[0]), fields[1:]; cmd {
+	case "quit", "exit":
+		return true, nil
+	case "pause":
+		a.paused = true
+	case "resume", "play":
+		a.paused = false
+	case "toggle":
+		a.paused = !a.paused
+	case "scene":
+		if len(args) != 1 {
+			return false, fmt.Errorf("usage: scene <name>")
+		}
+		return false, a.switchScene(args[0])
+	case "page":
+		if len(args) != 1 {
+			return false, fmt.Errorf("usage: page next|prev|<number>")
+		}
+		return false, a.flipPage(args[0])
+	case "heatmap":
+		if len(args) == 0 {
+			return false, fmt.Errorf("usage: heatmap <row>[;<row>...]")
+		}
+		return false, a.setMatrix(strings.Join(args, " "))
+	case "theme":
+		if len(args) != 1 {
+			return false, fmt.Errorf("usage: theme <name>")
+		}
+		return false, a.switchTheme(args[0])
+	default:
+		return false, fmt.Errorf("unknown command %q", cmd)
+	}
+	return false, nil
+}
+
+// switchScene replaces the running scene with a new instance of the named one.
+func (a *App) switchScene(name string) error {
+	cfg := a.config
+	cfg.Scene = name
+	sc, err := newScene(cfg)
+	if err != nil {
+		return err
+	}
+	a.config.Scene = name
+	a.replaceScene(sc)
+	a.attach(sc)
+	return nil
+}
+
+// attach connects a new scene to what it draws from outside: the latest
+// heatmap data and the running plugins.
+func (a *App) attach(sc scene) {
+	if r, ok := sc.(matrixReceiver); ok && a.matrix != nil {
+		r.SetMatrix(a.matrix)
+	}
+	if h, ok := sc.(pluginHosted); ok {
+		h.SetHost(a.plugins)
+	}
+}
+
+// switchTheme changes the color gradient used by all scenes.
+func (a *App) switchTheme(name string) error {
+	t, ok := a.lookupTheme(name)
+	if !ok {
+		return fmt.Errorf("unknown theme %q (available: %s)", name, strings.Join(theme.Names(), ", "))
+	}
+	a.config.Theme = name
+	a.renderer.SetTheme(t)
+	return nil
+}
+
+// nextTheme switches to the theme after the active one in name order.
+func (a *App) nextTheme() {
+	names := theme.Names()
+	_ = a.switchTheme(names[(indexOf(names, a.config.Theme)+1)%len(names)])
+}
+
+// maxCommandLine is the longest line read as a command from the control
+// pipe or standard input.
+const maxCommandLine = 1 << 20
+
+// readLines calls line with every line read from r, without its line
+// ending, until r fails. Lines longer than maxCommandLine are skipped
+// rather than ending the reading.
+func readLines(r io.Reader, line func(string)) {
+	br := bufio.NewReader(r)
+	var buf []byte
+	skipping := false
+	for {
+		chunk, err := br.ReadSlice('\n')
+		if !skipping {
+			buf = append(buf, chunk...)
+			if len(buf) > maxCommandLine {
+				skipping, buf = true, buf[:0]
+			}
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if !skipping && len(buf) > 0 {
+			line(strings.TrimSuffix(strings.TrimSuffix(string(buf), "\n"), "\r"))
+		}
+		skipping, buf = false, buf[:0]
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// renderPipeline is a way of drawing a scene onto the screen. Pipelines
+// that should draw the same cells are compared by Compare, to check a new
+// way of drawing against the one it replaces.
+type renderPipeline struct {
+	name string
+	// prepare sets up r to draw with the pipeline and returns the scene as
+	// the pipeline draws it
+	prepare func(cfg Config, r *renderer.Renderer, sc scene) scene
+}
+
+// renderPipelines lists the pipelines Compare can run.
+var renderPipelines = []renderPipeline{
+	{
+		// The scene draws straight onto the screen, as when running
+		name:    "direct",
+		prepare: func(_ Config, _ *renderer.Renderer, sc scene) scene { return sc },
+	},
+	{
+		// The scene draws into an offscreen viewport copied onto the
+		// screen, as in the scene switcher
+		name: "viewport",
+		prepare: func(_ Config, r *renderer.Renderer, sc scene) scene {
+			return &viewportScene{scene: sc, view: r.Offscreen()}
+		},
+	},
+	{
+		// Drawings the renderer caches are drawn afresh every frame
+		name: "uncached",
+		prepare: func(_ Config, r *renderer.Renderer, sc scene) scene {
+			r.SetCaching(false)
+			return sc
+		},
+	},
+	{
+		// Colors are blended in raw sRGB values rather than in linear
+		// light, to see what linear blending changes
+		name: "srgb",
+		prepare: func(_ Config, r *renderer.Renderer, sc scene) scene {
+			r.SetLinearBlending(false)
+			return sc
+		},
+	},
+}
+
+// RenderPipelines returns the names of the pipelines Compare can run.
+func RenderPipelines() []string {
+	names := make([]string, len(renderPipelines))
+	for i, p := range renderPipelines {
+		names[i] = p.name
+	}
+	return names
+}
+
+// lookupPipeline finds a pipeline by name.
+func lookupPipeline(name string) (renderPipeline, error) {
+	for _, p := range renderPipelines {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return renderPipeline{}, fmt.Errorf("unknown pipeline %q (available: %s)", name, strings.Join(RenderPipelines(), ", "))
+}
+
+// viewportScene draws a scene through an offscreen viewport.
+type viewportScene struct {
+	scene
+	view *renderer.Renderer
+}
+
+// Render draws the scene into the viewport and copies it onto r.
+func (s *viewportScene) Render(r *renderer.Renderer) {
+	s.view.SetSize(r.Size())
+	s.view.Clear()
+	s.scene.Render(s.view)
+	r.Blit(s.view, 0, 0, 0)
+}
+
+// CompareOptions selects the frames Compare renders and the pipelines it
+// compares.
+type CompareOptions struct {
+	SnapshotOptions
+	// Pipelines are the names of the two pipelines compared
+	Pipelines [2]string
+}
+
+// DefaultCompareOptions returns options comparing the scene drawn directly
+// and through a viewport over a few seconds.
+func DefaultCompareOptions() CompareOptions {
+	opts := CompareOptions{SnapshotOptions: DefaultSnapshotOptions(), Pipelines: [2]string{"direct", "viewport"}}
+	opts.Frame, opts.Count = 0, 100
+	return opts
+}
+
+// Colors of the diff view: changed characters on red, changed colors only
+// on amber, between frames set apart by grey borders.
+var (
+	diffChar   = color.RGBA{200, 30, 30, 255}
+	diffColor  = color.RGBA{170, 120, 0, 255}
+	diffMark   = color.RGBA{255, 255, 255, 255}
+	diffBorder = color.RGBA{90, 90, 90, 255}
+	diffBlank  = color.RGBA{0, 0, 0, 255}
+)
+
+// Compare renders frames of the configured scene with two pipelines on the
+// same simulation, stepping the scene once and drawing it with each. Every
+// frame that differs is reported to report with a count of the differing
+// cells and written to the writer create returns for its number as a diff
+// view: the first pipeline's frame, the second's, and a map marking cells
+// whose characters differ with # and cells that differ only in color with
+// ~. It returns an error if any frame differs.
+func Compare(cfg Config, opts CompareOptions, report io.Writer, create func(frame int) (io.WriteCloser, error)) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	if err := opts.check(); err != nil {
+		return err
+	}
+	var pipelines [2]renderPipeline
+	for i, name := range opts.Pipelines {
+		p, err := lookupPipeline(name)
+		if err != nil {
+			return invalidConfig(err)
+		}
+		pipelines[i] = p
+	}
+	cfg.Scene = cfg.onPage(0).Scene
+	sc, err := newScene(cfg)
+	if err != nil {
+		return invalidConfig(err)
+	}
+	clip, err := loadAudio(cfg)
+	if err != nil {
+		return err
+	}
+	face, err := snapshotFace(cfg, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	// The first side also steps the simulation, with the scene itself so
+	// the scene hears the music and the time
+	overlays := newOverlays(cfg.onPage(0), nil, nil)
+	var sides [2]*App
+	var screens [2]trueColorScreen
+	for i, p := range pipelines {
+		screen, r, err := newSnapshotScreen(cfg, opts.Width, opts.Height)
+		if err != nil {
+			return err
+		}
+		defer screen.Fini()
+		screens[i] = screen
+		sides[i] = &App{config: cfg, screen: screen, renderer: r, scene: p.prepare(cfg, r, sc), overlays: overlays}
+	}
+	driver := &App{config: cfg, screen: screens[0], renderer: sides[0].renderer, scene: sc, overlays: overlays, audio: clip}
+	driver.celebrate(snapshotNoon)
+
+	t := 0.0
+	differing := 0
+	for frame := range opts.Frame + opts.Count {
+		driver.tell(snapshotNoon.Add(time.Duration(frame) * cfg.FrameDelay))
+		driver.hear(frame)
+		driver.update(t)
+		if frame >= opts.Frame {
+			var grids [2][][]snapshotCell
+			for i, side := range sides {
+				// Beats brighten the first side; the second follows
+				side.renderer.SetAdjustment(driver.renderer.Adjustment())
+				side.holiday = driver.holiday
+				side.render(t, float64(frame)*cfg.FrameDelay.Seconds())
+				grids[i] = screenGrid(screens[i])
+			}
+			view, chars, colors := diffGrids(grids[0], grids[1])
+			if chars+colors > 0 {
+				differing++
+				fmt.Fprintf(report, "frame %d: %d of %d cells differ, %d in character and %d only in color\n",
+					frame, chars+colors, opts.Width*opts.Height, chars, colors)
+				if create != nil {
+					if err := writeSnapshot(view, cfg, opts.Format, face, frame, create); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		t += cfg.FrameDelay.Seconds()
+	}
+	if differing > 0 {
+		return fmt.Errorf("%s and %s pipelines differ in %d of %d frames", opts.Pipelines[0], opts.Pipelines[1], differing, opts.Count)
+	}
+	fmt.Fprintf(report, "%s and %s pipelines agree on all %d frames\n", opts.Pipelines[0], opts.Pipelines[1], opts.Count)
+	return nil
+}
+
+// diffGrids lays out the diff view of two captures of the same size and
+// counts the cells that differ in character, and only in color.
+func diffGrids(a, b [][]snapshotCell) (view [][]snapshotCell, chars, colors int) {
+	border := snapshotCell{char: '│', fg: diffBorder, bg: diffBlank}
+	for y := range a {
+		var marks []snapshotCell
+		for x := range a[y] {
+			ca, cb := a[y][x], b[y][x]
+			mark := snapshotCell{char: ' ', fg: diffMark, bg: diffBlank}
+			switch {
+			case ca.char != cb.char:
+				chars++
+				mark = snapshotCell{char: '#', fg: diffMark, bg: diffChar}
+			case ca.fg != cb.fg || ca.bg != cb.bg:
+				colors++
+				mark = snapshotCell{char: '~', fg: diffMark, bg: diffColor}
+			}
+			marks = append(marks, mark)
+		}
+		row := append([]snapshotCell{}, a[y]...)
+		row = append(row, border)
+		row = append(row, b[y]...)
+		row = append(row, border)
+		view = append(view, append(row, marks...))
+	}
+	return view, chars, colors
+}
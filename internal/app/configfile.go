@@ -0,0 +1,76 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/olegchuev/screensaver/internal/toml"
+)
+
+// configFile is the name of the hand-written configuration file.
+const configFile = "config.toml"
+
+// configPath returns the location of the configuration file in the user
+// config directory, which follows XDG_CONFIG_HOME.
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", configFile), nil
+}
+
+// LoadConfigFile reads the configuration file over cfg. Its settings are
+// named like the fields of the export command's output, in snake_case or
+// as written there. A missing file is not an error.
+func LoadConfigFile(cfg *Config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		var syntax *toml.Error
+		if errors.As(err, &syntax) {
+			return invalidConfig(fmt.Errorf("%s:%d: %s", path, syntax.Line, syntax.Msg))
+		}
+		return invalidConfig(fmt.Errorf("%s: %w", path, err))
+	}
+	// Problems Validate finds without a flag or saved setting to blame
+	// come from the file
+	cfg.sourceFile = path
+	return nil
+}
+
+// saveConfigFile sets top-level settings of the configuration file to the
+// given TOML values, by name, keeping the rest of the file as the user
+// wrote it, and returns the file's path. A missing file is created.
+func saveConfigFile(settings [][2]string) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return path, err
+	}
+	// Settings missing from the file go on top, last first so they keep
+	// their order
+	doc := string(data)
+	for _, s := range slices.Backward(settings) {
+		doc = toml.Set(doc, s[0], s[1])
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return path, err
+	}
+	return path, os.WriteFile(path, []byte(doc), 0o644)
+}
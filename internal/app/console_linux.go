@@ -0,0 +1,87 @@
+//go:build linux
+
+package app
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/telnet"
+)
+
+// Console ioctls, see linux/kd.h.
+const (
+	kdSetMode  = 0x4b3a
+	kdText     = 0
+	kdGraphics = 1
+)
+
+// console reads keys from the terminal the program was started on, for
+// outputs that draw somewhere else and leave the terminal alone.
+type console struct {
+	tty     *os.File         // Console switched to graphics, nil if unchanged
+	termios *syscall.Termios // Input mode to restore, nil if unchanged
+}
+
+// openConsole reads keys from standard input without echo and passes them
+// to inject. With graphics it also stops the kernel from drawing the text
+// console over the framebuffer. Standard input that is no terminal is left
+// alone, and nil is returned.
+func openConsole(graphics bool, inject func(tcell.Key, rune, tcell.ModMask)) *console {
+	tty := os.Stdin
+	var termios syscall.Termios
+	if ioctl(tty, syscall.TCGETS, unsafe.Pointer(&termios)) != nil {
+		return nil
+	}
+	c := &console{}
+	raw := termios
+	// Signals stay enabled, so Ctrl+C still quits through the signal handler
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	raw.Cc[syscall.VMIN], raw.Cc[syscall.VTIME] = 1, 0
+	if ioctl(tty, syscall.TCSETS, unsafe.Pointer(&raw)) == nil {
+		c.termios = &termios
+	}
+	// Fails on terminal emulators, where there is no text console to hide
+	if graphics && ioctlValue(tty, kdSetMode, kdGraphics) == nil {
+		c.tty = tty
+	}
+	go func() {
+		in := telnet.NewReader(tty)
+		for {
+			ev, err := in.Read()
+			if err != nil {
+				return
+			}
+			inject(ev.Key, ev.Rune, ev.Mod)
+		}
+	}()
+	return c
+}
+
+// close gives the console back as it was.
+func (c *console) close() {
+	if c.tty != nil {
+		ioctlValue(c.tty, kdSetMode, kdText)
+	}
+	if c.termios != nil {
+		ioctl(os.Stdin, syscall.TCSETS, unsafe.Pointer(c.termios))
+	}
+}
+
+// ioctl issues a request taking a pointer argument.
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// ioctlValue issues a request taking an integer argument.
+func ioctlValue(f *os.File, req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package app
+
+import "github.com/gdamore/tcell/v2"
+
+// console is unavailable outside Linux, where outputs drawing somewhere
+// else than the terminal take no keys.
+type console struct{}
+
+// openConsole returns nil, keys are only read on Linux.
+func openConsole(graphics bool, inject func(tcell.Key, rune, tcell.ModMask)) *console {
+	return nil
+}
+
+// close is never called, since openConsole returns nil.
+func (c *console) close() {}
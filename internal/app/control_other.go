@@ -0,0 +1,10 @@
+//go:build !unix
+
+package app
+
+import "errors"
+
+// openControl is unavailable on platforms without named pipes.
+func openControl() (<-chan string, func(), error) {
+	return nil, nil, errors.New("control pipe is not supported on this platform")
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// controlPath returns the location of the control FIFO in the user cache directory.
+func controlPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", "control"), nil
+}
+
+// openControl creates the control FIFO and streams the commands written to it.
+// The returned function closes and removes the FIFO.
+func openControl() (<-chan string, func(), error) {
+	path, err := controlPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, nil, err
+	}
+
+	// Replace a stale FIFO left behind by a crashed instance, but leave one
+	// that another instance still reads from to that instance
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeNamedPipe == 0 {
+			return nil, nil, errors.New(path + " exists and is not a named pipe")
+		}
+		if listening(path) {
+			return nil, nil, errors.New("another instance is listening on " + path)
+		}
+		os.Remove(path)
+	}
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		return nil, nil, err
+	}
+
+	// Opening read-write keeps a writer attached, so reads never hit EOF
+	// between clients and the open does not block waiting for one
+	f, err := os.OpenFile(path, os.O_RDWR, os.ModeNamedPipe)
+	if err != nil {
+		os.Remove(path)
+		return nil, nil, err
+	}
+
+	commands := make(chan string, 16)
+	done := make(chan struct{})
+	go func() {
+		readLines(f, func(line string) {
+			// The app stops taking commands when it closes the pipe
+			select {
+			case commands <- line:
+			case <-done:
+			}
+		})
+	}()
+
+	closeFn := func() {
+		close(done)
+		f.Close()
+		os.Remove(path)
+	}
+	return commands, closeFn, nil
+}
+
+// listening reports whether a process has the FIFO at path open for
+// reading. Opening a FIFO to write without blocking fails with ENXIO when
+// nothing reads from it.
+func listening(path string) bool {
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
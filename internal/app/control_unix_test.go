@@ -0,0 +1,31 @@
+//go:build unix
+
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if listening(path) {
+		t.Error("listening on a FIFO nothing reads from")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, os.ModeNamedPipe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !listening(path) {
+		t.Error("not listening on a FIFO an instance reads from")
+	}
+	f.Close()
+	if listening(path) {
+		t.Error("listening on a FIFO after its reader closed it")
+	}
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Page is one page of a dashboard: a scene with its own set of overlays,
+// shown for a while before the next page takes over.
+type Page struct {
+	Scene string
+	// Overlays names the overlays shown on the page, none for the scene alone
+	Overlays []string
+	// Time is how long the page shows, zero for the configured PageTime
+	Time time.Duration
+}
+
+// ParsePage parses a page of the form "scene", "scene+overlay+overlay" or
+// any of these followed by "@time", for example "ocean+weather+ticker@2m".
+func ParsePage(s string) (Page, error) {
+	spec, dwell, timed := strings.Cut(s, "@")
+	names := strings.Split(spec, "+")
+	p := Page{Scene: names[0], Overlays: names[1:]}
+	if !slices.Contains(sceneNames, p.Scene) {
+		return Page{}, fmt.Errorf("unknown scene %q (available: %s)", p.Scene, strings.Join(sceneNames, ", "))
+	}
+	for _, name := range p.Overlays {
+		if !knownOverlay(name) {
+			return Page{}, fmt.Errorf("unknown overlay %q (available: %s)", name, strings.Join(overlayNames(), ", "))
+		}
+	}
+	if timed {
+		var err error
+		if p.Time, err = time.ParseDuration(dwell); err != nil || p.Time <= 0 {
+			return Page{}, fmt.Errorf("invalid page time %q: want a positive duration like 30s", dwell)
+		}
+	}
+	return p, nil
+}
+
+// String formats the page the way ParsePage accepts it.
+func (p Page) String() string {
+	s := strings.Join(append([]string{p.Scene}, p.Overlays...), "+")
+	if p.Time > 0 {
+		s += "@" + p.Time.String()
+	}
+	return s
+}
+
+// MarshalText formats the page for configuration files.
+func (p Page) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a page from a configuration file, see ParsePage.
+func (p *Page) UnmarshalText(text []byte) error {
+	page, err := ParsePage(string(text))
+	if err != nil {
+		return err
+	}
+	*p = page
+	return nil
+}
+
+// onPage returns the configuration as dashboard page i shows it: the page's
+// scene with only its overlays. Without pages it is returned as it is.
+func (c Config) onPage(i int) Config {
+	if len(c.Pages) == 0 {
+		return c
+	}
+	p := c.Pages[i%len(c.Pages)]
+	c.Scene = p.Scene
+	c.pageOverlays = p.Overlays
+	if !slices.Contains(p.Overlays, "ticker") {
+		c.Ticker = ""
+	}
+	if !slices.Contains(p.Overlays, "logo") {
+		c.Logo = ""
+	}
+	if !slices.Contains(p.Overlays, "weather") {
+		c.Weather = ""
+	}
+	return c
+}
+
+// turnPages moves the dashboard on to the next page once the current one
+// has shown for its time.
+func (a *App) turnPages(now time.Time) {
+	if len(a.config.Pages) < 2 {
+		return
+	}
+	// The clock starts on the first frame a page shows
+	if a.pageStart.IsZero() {
+		a.pageStart = now
+		return
+	}
+	dwell := a.config.Pages[a.page].Time
+	if dwell == 0 {
+		dwell = a.config.PageTime
+	}
+	if now.Sub(a.pageStart) >= dwell {
+		a.showPage(a.page + 1)
+	}
+}
+
+// showPage switches the dashboard to page i, counting around from either
+// end, with its scene and overlays.
+func (a *App) showPage(i int) {
+	n := len(a.config.Pages)
+	if n == 0 {
+		return
+	}
+	a.page = (i%n + n) % n
+	a.pageStart = time.Time{}
+	page := a.config.onPage(a.page)
+	// A scene on two pages in a row keeps running
+	if page.Scene != a.config.Scene {
+		// Validate checked the scene names
+		_ = a.switchScene(page.Scene)
+	}
+	a.overlays = newOverlays(page, a.weather, a.runningPlugins())
+}
+
+// flipPage handles the "page" command: "next", "prev" or a page number
+// counted from 1.
+func (a *App) flipPage(arg string) error {
+	if len(a.config.Pages) == 0 {
+		return fmt.Errorf("no dashboard pages configured")
+	}
+	switch arg {
+	case "next":
+		a.showPage(a.page + 1)
+	case "prev":
+		a.showPage(a.page - 1)
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 || n > len(a.config.Pages) {
+			return fmt.Errorf("invalid page %q: want next, prev or 1 to %d", arg, len(a.config.Pages))
+		}
+		a.showPage(n - 1)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/timing"
+)
+
+// debugDumper writes every nth frame of the screen to a directory along
+// with what it was drawn with, for attaching to reports of rendering
+// glitches. Each dump is a JSON file with the cells, the frame timing, the
+// terminal and the configuration, and a text file with just the
+// characters for a quick look.
+type debugDumper struct {
+	dir   string
+	every int
+}
+
+// debugDump is the JSON file written for a frame.
+type debugDump struct {
+	Frame    int
+	Time     float64   // Animation time the frame was drawn at
+	Wall     time.Time // Wall clock time of the frame
+	Scene    string
+	Theme    string
+	Width    int
+	Height   int
+	Terminal debugTerminal
+	Timing   debugTiming
+	Config   json.RawMessage
+	Rows     []debugRow
+}
+
+// debugTerminal describes the terminal the frame was drawn on.
+type debugTerminal struct {
+	Term, ColorTerm, TermProgram, Lang string
+	Colors                             int // Colors the screen reported
+	CharacterSet                       string
+	CellAspect                         float64
+	OS                                 string
+}
+
+// debugTiming is the frame loop's timing over the last frames, in
+// milliseconds.
+type debugTiming struct {
+	FPS                                float64
+	Interval, Work, P50, P95, P99, Max float64
+	Dropped, Frames                    int
+}
+
+// debugRow is a row of cells: its characters and the styles they were
+// drawn with as runs of consecutive cells.
+type debugRow struct {
+	Text string
+	Runs []debugRun
+}
+
+// debugRun is a run of cells in one style. Colors are #rrggbb, "palette N"
+// for the terminal's indexed colors or "default".
+type debugRun struct {
+	Cells  int
+	Fg, Bg string
+	Attrs  string `json:",omitempty"`
+}
+
+// newDebugDumper creates the directory frames of cfg.DebugDump are written
+// to.
+func newDebugDumper(cfg Config) (*debugDumper, error) {
+	if err := os.MkdirAll(cfg.DebugDump, 0o755); err != nil {
+		return nil, err
+	}
+	return &debugDumper{dir: cfg.DebugDump, every: cfg.DebugDumpEvery}, nil
+}
+
+// dump writes the frame shown on screen when it is one to keep. Dumping is
+// best effort and never interrupts the animation.
+func (d *debugDumper) dump(a *App, frame int, t float64, now time.Time) {
+	if frame%d.every != 0 {
+		return
+	}
+	config, _ := json.Marshal(a.config)
+	w, h := a.screen.Size()
+	dump := debugDump{
+		Frame:  frame,
+		Time:   t,
+		Wall:   now,
+		Scene:  a.config.Scene,
+		Theme:  a.config.Theme,
+		Width:  w,
+		Height: h,
+		Terminal: debugTerminal{
+			Term:         os.Getenv("TERM"),
+			ColorTerm:    os.Getenv("COLORTERM"),
+			TermProgram:  os.Getenv("TERM_PROGRAM"),
+			Lang:         cmp.Or(os.Getenv("LC_ALL"), os.Getenv("LANG")),
+			Colors:       a.screen.Colors(),
+			CharacterSet: a.screen.CharacterSet(),
+			CellAspect:   a.renderer.CellAspect(),
+			OS:           runtime.GOOS + "/" + runtime.GOARCH,
+		},
+		Timing: newDebugTiming(a.pacer.Summary()),
+		Config: config,
+	}
+	var text strings.Builder
+	for y := range h {
+		row := debugRow{}
+		line := make([]rune, w)
+		for x := range w {
+			char, _, style, _ := a.screen.GetContent(x, y)
+			line[x] = styledCell(char, style).char
+			run := newDebugRun(style)
+			if n := len(row.Runs); n > 0 && row.Runs[n-1].same(run) {
+				row.Runs[n-1].Cells++
+			} else {
+				row.Runs = append(row.Runs, run)
+			}
+		}
+		row.Text = string(line)
+		dump.Rows = append(dump.Rows, row)
+		fmt.Fprintln(&text, strings.TrimRight(row.Text, " "))
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return
+	}
+	name := filepath.Join(d.dir, fmt.Sprintf("frame%06d", frame))
+	_ = os.WriteFile(name+".json", data, 0o644)
+	_ = os.WriteFile(name+".txt", []byte(text.String()), 0o644)
+}
+
+// newDebugTiming converts a timing summary to milliseconds.
+func newDebugTiming(s timing.Summary) debugTiming {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	return debugTiming{
+		FPS:      s.FPS,
+		Interval: ms(s.Interval),
+		Work:     ms(s.Work),
+		P50:      ms(s.P50),
+		P95:      ms(s.P95),
+		P99:      ms(s.P99),
+		Max:      ms(s.Max),
+		Dropped:  s.Dropped,
+		Frames:   s.Frames,
+	}
+}
+
+// newDebugRun returns a run of one cell in style.
+func newDebugRun(style tcell.Style) debugRun {
+	fg, bg, attrs := style.Decompose()
+	return debugRun{Cells: 1, Fg: debugColor(fg), Bg: debugColor(bg), Attrs: debugAttrs(attrs)}
+}
+
+// same reports whether o is in the style of the run.
+func (r debugRun) same(o debugRun) bool {
+	return r.Fg == o.Fg && r.Bg == o.Bg && r.Attrs == o.Attrs
+}
+
+// debugColor names a color as the terminal is asked to show it.
+func debugColor(c tcell.Color) string {
+	switch {
+	case c == tcell.ColorDefault || c == tcell.ColorReset:
+		return "default"
+	case c&tcell.ColorIsRGB != 0:
+		return fmt.Sprintf("#%06x", c.Hex())
+	default:
+		return fmt.Sprintf("palette %d", c-tcell.ColorValid)
+	}
+}
+
+// debugAttrNames names the text attributes in the order of their bits.
+var debugAttrNames = []struct {
+	attr tcell.AttrMask
+	name string
+}{
+	{tcell.AttrBold, "bold"},
+	{tcell.AttrBlink, "blink"},
+	{tcell.AttrReverse, "reverse"},
+	{tcell.AttrUnderline, "underline"},
+	{tcell.AttrDim, "dim"},
+	{tcell.AttrItalic, "italic"},
+	{tcell.AttrStrikeThrough, "strikethrough"},
+}
+
+// debugAttrs names the attributes set in attrs, separated by spaces.
+func debugAttrs(attrs tcell.AttrMask) string {
+	var names []string
+	for _, a := range debugAttrNames {
+		if attrs&a.attr != 0 {
+			names = append(names, a.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+const (
+	sliderWidth = 24 // Cells spanned by a full 0-255 channel slider
+	minStops    = 2
+	maxStops    = 12
+)
+
+var (
+	channelNames  = [3]string{"R", "G", "B"}
+	channelColors = [3]tcell.Color{
+		tcell.NewRGBColor(230, 70, 70),
+		tcell.NewRGBColor(70, 210, 90),
+		tcell.NewRGBColor(80, 130, 240),
+	}
+	sliderTrack = tcell.StyleDefault.Background(tcell.NewRGBColor(5, 5, 8))
+)
+
+// designer is an overlay for editing the active theme's gradient while the
+// scene keeps running behind it, so every change is visible immediately.
+type designer struct {
+	original theme.Theme
+	edited   theme.Theme
+	stop     int // Selected gradient stop
+	channel  int // Selected color channel, 0-2
+	naming   bool
+	name     []rune
+	message  string
+	apply    func(theme.Theme)       // Previews the edited theme
+	save     func(theme.Theme) error // Persists a named theme
+}
+
+// newDesigner starts editing a copy of t.
+func newDesigner(t theme.Theme, apply func(theme.Theme), save func(theme.Theme) error) *designer {
+	edited := t
+	edited.Gradient = append([]theme.Stop(nil), t.Gradient...)
+	return &designer{
+		original: t,
+		edited:   edited,
+		name:     []rune(t.Name + "-custom"),
+		apply:    apply,
+		save:     save,
+	}
+}
+
+// HandleKey edits the gradient and returns true once the designer should close.
+func (d *designer) HandleKey(ev *tcell.EventKey) bool {
+	if d.naming {
+		return d.handleName(ev)
+	}
+
+	stops := d.edited.Gradient
+	step := int32(5)
+	if ev.Modifiers()&tcell.ModShift != 0 {
+		step = 1
+	}
+
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		// Leaving without saving restores the theme that was active before
+		d.apply(d.original)
+		return true
+	case tcell.KeyUp:
+		d.stop = max(d.stop-1, 0)
+	case tcell.KeyDown:
+		d.stop = min(d.stop+1, len(stops)-1)
+	case tcell.KeyTab:
+		d.channel = (d.channel + 1) % 3
+	case tcell.KeyBacktab:
+		d.channel = (d.channel + 2) % 3
+	case tcell.KeyLeft:
+		d.adjust(-step)
+	case tcell.KeyRight:
+		d.adjust(step)
+	case tcell.KeyRune:
+		switch ev.Rune() {
+		case 'r', 'R':
+			d.channel = 0
+		case 'g', 'G':
+			d.channel = 1
+		case 'b', 'B':
+			d.channel = 2
+		case 'a':
+			d.addStop()
+		case 'x':
+			d.removeStop()
+		case 's':
+			d.naming = true
+			d.message = ""
+		}
+	}
+	return false
+}
+
+// handleName edits the theme name prompt and saves on Enter.
+func (d *designer) handleName(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyEscape:
+		d.naming = false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(d.name) > 0 {
+			d.name = d.name[:len(d.name)-1]
+		}
+	case tcell.KeyEnter:
+		t := d.edited
+		t.Name = string(d.name)
+		t.Description = "Custom theme based on " + d.original.Name
+		if err := d.save(t); err != nil {
+			d.message = err.Error()
+			return false
+		}
+		return true
+	case tcell.KeyRune:
+		if ch := ev.Rune(); ch != ' ' && ch != '/' && ch != '\\' && len(d.name) < 24 {
+			d.name = append(d.name, ch)
+		}
+	}
+	return false
+}
+
+// adjust changes the selected channel of the selected stop by delta.
+func (d *designer) adjust(delta int32) {
+	s := &d.edited.Gradient[d.stop]
+	channels := [3]*int32{&s.R, &s.G, &s.B}
+	*channels[d.channel] = min(max(*channels[d.channel]+delta, 0), 255)
+	d.apply(d.edited)
+}
+
+// addStop inserts a copy of the selected stop after it.
+func (d *designer) addStop() {
+	stops := d.edited.Gradient
+	if len(stops) >= maxStops {
+		return
+	}
+	d.edited.Gradient = respace(slices.Insert(stops, d.stop+1, stops[d.stop]))
+	d.stop++
+	d.apply(d.edited)
+}
+
+// removeStop deletes the selected stop.
+func (d *designer) removeStop() {
+	stops := d.edited.Gradient
+	if len(stops) <= minStops {
+		return
+	}
+	stops = slices.Delete(stops, d.stop, d.stop+1)
+	d.edited.Gradient = respace(stops)
+	d.stop = min(d.stop, len(stops)-1)
+	d.apply(d.edited)
+}
+
+// respace spreads the thresholds evenly, keeping the last stop as the
+// catch-all for values at the top of the range like the built-in themes.
+func respace(stops []theme.Stop) []theme.Stop {
+	n := len(stops)
+	for i := range stops {
+		stops[i].Threshold = 0.9 * float64(i+1) / float64(n-1)
+	}
+	stops[n-1].Threshold = 2
+	return stops
+}
+
+// Render draws the stop swatches, channel sliders and key help.
+func (d *designer) Render(r *renderer.Renderer) {
+	stops := d.edited.Gradient
+	sel := stops[d.stop]
+	values := [3]int32{sel.R, sel.G, sel.B}
+
+	lines := []panelLine{
+		{"Theme designer, editing " + d.original.Name, panelTitle},
+		{},
+		{"Stops  " + strings.Repeat("   ", len(stops)), panelStyle}, // Swatches drawn below
+		{},
+	}
+	for c := range channelNames {
+		style := panelStyle
+		if c == d.channel {
+			style = panelTitle
+		}
+		label := fmt.Sprintf("%s %s  %s  %3d", marker(c == d.channel), channelNames[c], strings.Repeat(" ", sliderWidth), values[c])
+		lines = append(lines, panelLine{label, style})
+	}
+	lines = append(lines, panelLine{})
+	if d.naming {
+		lines = append(lines, panelLine{"Save as: " + string(d.name) + "_", panelSelected},
+			panelLine{"Enter save   Esc back", panelHint})
+	} else {
+		lines = append(lines,
+			panelLine{"↑/↓ stop   Tab/r/g/b channel   ←/→ adjust (Shift fine)", panelHint},
+			panelLine{"a add stop   x remove stop   s save   Esc cancel", panelHint})
+	}
+	if d.message != "" {
+		lines = append(lines, panelLine{d.message, panelStyle.Foreground(tcell.NewRGBColor(255, 120, 100))})
+	}
+
+	x, y := drawPanel(r, 2, 1, lines)
+
+	// Swatches, with the selected stop bracketed
+	for i, s := range stops {
+		sx := x + 7 + i*3
+		swatch := panelStyle.Foreground(tcell.NewRGBColor(s.R, s.G, s.B))
+		r.SetCell(sx+1, y+2, '█', overlay.Depth+1, swatch)
+		if i == d.stop {
+			r.SetCell(sx, y+2, '[', overlay.Depth+1, panelTitle)
+			r.SetCell(sx+2, y+2, ']', overlay.Depth+1, panelTitle)
+		}
+	}
+
+	// Sliders fill in eighth-cell steps over a dark track
+	for c := range channelNames {
+		sy := y + 4 + c
+		for i := 0; i < sliderWidth; i++ {
+			r.SetCell(x+5+i, sy, ' ', overlay.Depth+1, sliderTrack)
+		}
+		filled := float64(values[c]) / 255 * sliderWidth
+		r.FillRect(float64(x+5), float64(sy), filled, 1, channelColors[c], overlay.Depth+2)
+	}
+}
+
+// marker returns the selection cursor for a menu row.
+func marker(selected bool) string {
+	if selected {
+		return ">"
+	}
+	return " "
+}
@@ -0,0 +1,472 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/devkit"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/timing"
+)
+
+const (
+	devSidebar  = 40          // Width of the settings pane
+	devLogRows  = 8           // Height of the log pane
+	devLogKeep  = 200         // Log lines kept
+	devWatch    = time.Second // How often the scene's source is checked for changes
+	devSteps    = 40          // Steps across a setting's range, ten times as many with Shift
+	devNameCols = 12          // Columns of a setting's name
+)
+
+var (
+	devSlider = tcell.NewRGBColor(150, 200, 255)
+	devError  = panelStyle.Foreground(tcell.NewRGBColor(255, 120, 100))
+)
+
+// devBuild is the outcome of building the scene's worker.
+type devBuild struct {
+	path  string
+	stamp string
+	err   error
+}
+
+// devSession is the state of the dev mode.
+type devSession struct {
+	config   Config
+	dir      string
+	tmp      string // Directory of the built workers
+	built    int    // Builds started, numbering the workers
+	builds   chan devBuild
+	building bool
+	stamp    string // Of the source last built
+	status   string // Outcome of the last build
+	screen   tcell.Screen
+	renderer *renderer.Renderer
+
+	worker   *devkit.Worker
+	binary   string // Executable of the worker
+	params   []devkit.Param
+	values   []float64 // Current values of params
+	selected int
+	pending  map[string]float64 // Changed settings not yet sent
+	restart  bool
+	theme    bool // The worker needs the theme
+	paused   bool
+	t        float64
+	frames   int
+	frame    devkit.Frame
+	dirty    bool // The frame is out of date
+
+	mu  sync.Mutex
+	log []string
+}
+
+// Dev runs a scene from the directory of its package in a split view for
+// working on it: the scene, its settings with sliders beside it and its
+// log below. Whenever the source changes the scene is rebuilt and carries
+// on from the same time with the same settings; it can also be paused and
+// stepped frame by frame. The package must be in the screensaver's source
+// tree, and building it needs the Go toolchain.
+func Dev(cfg Config) error {
+	dir := cfg.Scene
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return invalidConfig(fmt.Errorf("-scene %q is not a directory: dev runs a scene from the directory of its package, like ./internal/scenes/fire", dir))
+	}
+	// Everything but the scene is checked as for the built-in ones
+	check := cfg
+	check.Scene = DefaultConfig().Scene
+	if err := Validate(check); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp("", "screensaver-dev-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	d := &devSession{config: cfg, dir: dir, tmp: tmp, builds: make(chan devBuild, 1), pending: map[string]float64{}}
+	// The first build is waited for, so its errors show before the
+	// terminal is taken over
+	stamp, err := devkit.Stamp(dir)
+	if err != nil {
+		return err
+	}
+	d.startBuild(stamp)
+	first := <-d.builds
+	if first.err != nil {
+		return first.err
+	}
+
+	screen, err := openScreen()
+	if err != nil {
+		return err
+	}
+	defer screen.Fini()
+	d.screen = screen
+	d.renderer = renderer.NewRenderer(screen)
+	d.renderer.SetCellAspect(cfg.CellAspect)
+	th, _ := theme.Lookup(cfg.Theme)
+	d.renderer.SetTheme(th)
+	d.finishBuild(first)
+	if d.worker == nil {
+		return fmt.Errorf("starting %s failed: %s", dir, strings.Join(d.log, "\n"))
+	}
+	defer func() {
+		if d.worker != nil {
+			d.worker.Close()
+		}
+	}()
+	return d.run()
+}
+
+// run draws frames and reacts to keys and changes of the source until the
+// user quits.
+func (d *devSession) run() error {
+	pacer := timing.NewPacer(d.config.FrameDelay)
+	defer pacer.Stop()
+	watch := time.NewTicker(devWatch)
+	defer watch.Stop()
+
+	events := make(chan tcell.Event, 8)
+	go func() {
+		for {
+			ev := d.screen.PollEvent()
+			if ev == nil {
+				return
+			}
+			events <- ev
+		}
+	}()
+
+	for {
+		select {
+		case ev := <-events:
+			if d.handleEvent(ev) {
+				return nil
+			}
+		case <-watch.C:
+			if stamp, err := devkit.Stamp(d.dir); err == nil && stamp != d.stamp && !d.building {
+				d.startBuild(stamp)
+			}
+		case b := <-d.builds:
+			d.finishBuild(b)
+		case <-pacer.C():
+			if !d.paused {
+				d.t += d.config.FrameDelay.Seconds()
+				d.dirty = true
+			}
+			d.draw()
+		}
+	}
+}
+
+// startBuild builds the source with the given stamp in the background.
+func (d *devSession) startBuild(stamp string) {
+	d.built++
+	d.building, d.stamp, d.status = true, stamp, "building…"
+	path := filepath.Join(d.tmp, fmt.Sprintf("scene-%d", d.built))
+	go func() {
+		d.builds <- devBuild{path: path, stamp: stamp, err: devkit.Build(d.dir, path)}
+	}()
+}
+
+// finishBuild switches to the worker of a successful build, keeping the
+// settings changed so far, or logs why the build failed and keeps the
+// running worker.
+func (d *devSession) finishBuild(b devBuild) {
+	d.building = false
+	if b.err != nil {
+		d.status = "build failed, see the log"
+		d.logf(b.err.Error())
+		return
+	}
+	w, err := devkit.Start(b.path, d.logf)
+	if err != nil {
+		d.status = "start failed, see the log"
+		d.logf(err.Error())
+		return
+	}
+	if d.worker != nil {
+		d.worker.Close()
+		os.Remove(d.binary)
+		d.logf("rebuilt " + w.Scene())
+	}
+	d.worker, d.binary = w, b.path
+	d.status = "built " + time.Now().Format(time.TimeOnly)
+
+	// Settings changed before carry over to the new build where it still
+	// has them
+	changed := map[string]float64{}
+	for i, p := range d.params {
+		if d.values[i] != p.Value {
+			changed[p.Name] = d.values[i]
+		}
+	}
+	d.params = w.Params()
+	d.values = make([]float64, len(d.params))
+	clear(d.pending)
+	for i, p := range d.params {
+		d.values[i] = p.Value
+		if v, ok := changed[p.Name]; ok {
+			d.values[i], d.pending[p.Name] = v, v
+		}
+	}
+	d.selected = min(d.selected, max(len(d.params)-1, 0))
+	d.theme, d.dirty = true, true
+}
+
+// logf adds a message, possibly of several lines, to the log. It is safe
+// to call from the worker's goroutines.
+func (d *devSession) logf(msg string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, line := range strings.Split(msg, "\n") {
+		d.log = append(d.log, strings.ReplaceAll(line, "\t", "    "))
+	}
+	if len(d.log) > devLogKeep {
+		d.log = d.log[len(d.log)-devLogKeep:]
+	}
+}
+
+// handleEvent reacts to keys and resizes and reports whether to quit.
+func (d *devSession) handleEvent(ev tcell.Event) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		d.screen.Sync()
+		d.renderer.Resize()
+		d.dirty = true
+	case *tcell.EventKey:
+		fine := ev.Modifiers()&tcell.ModShift != 0
+		switch ev.Key() {
+		case tcell.KeyCtrlC, tcell.KeyEscape:
+			return true
+		case tcell.KeyUp:
+			d.selected = max(d.selected-1, 0)
+		case tcell.KeyDown:
+			d.selected = max(min(d.selected+1, len(d.params)-1), 0)
+		case tcell.KeyLeft:
+			d.adjust(-1, fine)
+		case tcell.KeyRight:
+			d.adjust(1, fine)
+		case tcell.KeyRune:
+			switch ev.Rune() {
+			case 'q':
+				return true
+			case ' ':
+				d.paused = !d.paused
+			case '.':
+				d.paused = true
+				d.t += d.config.FrameDelay.Seconds()
+				d.dirty = true
+			case 'r':
+				d.t, d.restart, d.dirty = 0, true, true
+			case 'd':
+				if len(d.params) > 0 {
+					d.set(d.params[d.selected].Value)
+				}
+			case 'b':
+				if !d.building {
+					d.startBuild(d.stamp)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// adjust moves the selected setting a step along its range in direction
+// dir, a tenth of a step if fine; switches flip.
+func (d *devSession) adjust(dir float64, fine bool) {
+	if len(d.params) == 0 {
+		return
+	}
+	p, v := d.params[d.selected], d.values[d.selected]
+	if p.Bool {
+		d.set(1 - v)
+		return
+	}
+	step := (p.Max - p.Min) / devSteps
+	if fine {
+		step /= 10
+	}
+	if p.Int {
+		step = max(math.Round(step), 1)
+	}
+	d.set(min(max(v+dir*step, p.Min), p.Max))
+}
+
+// set changes the selected setting to v, recreating the scene with it.
+func (d *devSession) set(v float64) {
+	if d.values[d.selected] == v {
+		return
+	}
+	d.values[d.selected] = v
+	d.pending[d.params[d.selected].Name] = v
+	d.dirty = true
+}
+
+// draw shows the scene's latest frame beside the settings and above the
+// log, asking the worker for a new frame if it is out of date.
+func (d *devSession) draw() {
+	r := d.renderer
+	width, height := r.Size()
+	side := min(devSidebar, width/2)
+	logRows := min(devLogRows, height/3)
+	paneWidth, paneHeight := width-side, height-logRows
+	if d.dirty && d.worker.Err() == nil {
+		d.request(paneWidth, paneHeight)
+	}
+
+	r.Clear()
+	r.SetLayer(renderer.LayerScene)
+	for i, c := range d.frame.Cells {
+		x, y := i%d.frame.Width, i/d.frame.Width
+		if x < paneWidth && y < paneHeight && !c.Blank() {
+			r.SetCell(x, y, c.Ch, 0, c.Style())
+		}
+	}
+	r.SetLayer(renderer.LayerUI)
+	d.drawSettings(paneWidth, side, height)
+	d.drawLog(paneHeight, paneWidth, logRows)
+	r.Flush()
+}
+
+// request asks the worker for the frame at the current time, sending the
+// changes since the last one along.
+func (d *devSession) request(width, height int) {
+	req := devkit.Request{Time: d.t, Width: width, Height: height, Aspect: d.config.CellAspect, Restart: d.restart}
+	if d.theme {
+		th, _ := theme.Lookup(d.config.Theme)
+		req.Theme = &th
+	}
+	if len(d.pending) > 0 {
+		req.Params = d.pending
+	}
+	f, err := d.worker.Frame(req)
+	if err != nil {
+		d.status = "stopped, see the log"
+		d.logf(err.Error())
+		return
+	}
+	d.frame, d.frames = f, d.frames+1
+	d.pending = map[string]float64{}
+	d.theme, d.restart, d.dirty = false, false, false
+}
+
+// drawSettings draws the pane of status and settings at column left.
+func (d *devSession) drawSettings(left, width, height int) {
+	state := "▶ running"
+	if d.paused {
+		state = "❚❚ paused"
+	}
+	statusStyle := panelStyle
+	if d.worker.Err() != nil || strings.Contains(d.status, "failed") {
+		statusStyle = devError
+	}
+	lines := []panelLine{
+		{"dev " + d.worker.Scene(), panelTitle},
+		{d.dir, panelHint},
+		{},
+		{fmt.Sprintf("%s  t %.2fs  frame %d", state, d.t, d.frames), panelStyle},
+		{d.status, statusStyle},
+		{},
+		{"Settings", panelTitle},
+	}
+	top := len(lines)
+	sliderCols := max(width-devNameCols-12, 4)
+	for i, p := range d.params {
+		style := panelStyle
+		if i == d.selected {
+			style = panelTitle
+		}
+		name := p.Name
+		if len(name) > devNameCols {
+			name = name[:devNameCols-1] + "…"
+		}
+		text := fmt.Sprintf("%s %-*s %s %s", marker(i == d.selected), devNameCols, name, strings.Repeat(" ", sliderCols), devValue(p, d.values[i]))
+		lines = append(lines, panelLine{text, style})
+	}
+	if len(d.params) == 0 {
+		lines = append(lines, panelLine{"  none to change", panelHint})
+	}
+	lines = append(lines,
+		panelLine{},
+		panelLine{"space pause  . step  r restart", panelHint},
+		panelLine{"↑/↓ setting  ←/→ adjust (Shift fine)", panelHint},
+		panelLine{"d default  b rebuild  q quit", panelHint},
+	)
+
+	r := d.renderer
+	for y := range height {
+		var l panelLine
+		if y > 0 && y-1 < len(lines) {
+			l = lines[y-1]
+		}
+		text := []rune(l.text)
+		for x := range width {
+			ch, style := ' ', panelStyle
+			if x > 0 && x-1 < len(text) {
+				ch, style = text[x-1], l.style
+			}
+			r.SetCell(left+x, y, ch, 0, style)
+		}
+	}
+
+	// Sliders over a dark track, filled up to the value
+	for i, p := range d.params {
+		x, y := left+devNameCols+4, top+1+i
+		for c := range sliderCols {
+			r.SetCell(x+c, y, ' ', 1, sliderTrack)
+		}
+		if p.Max > p.Min {
+			filled := (d.values[i] - p.Min) / (p.Max - p.Min) * float64(sliderCols)
+			r.FillRect(float64(x), float64(y), filled, 1, devSlider, 2)
+		}
+	}
+}
+
+// devValue formats the value v of setting p.
+func devValue(p devkit.Param, v float64) string {
+	switch {
+	case p.Bool && v != 0:
+		return "on"
+	case p.Bool:
+		return "off"
+	case p.Int:
+		return strconv.Itoa(int(v))
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
+
+// drawLog draws the latest lines of the log in rows from top on.
+func (d *devSession) drawLog(top, width, rows int) {
+	d.mu.Lock()
+	lines := d.log[max(len(d.log)-(rows-1), 0):]
+	d.mu.Unlock()
+
+	r := d.renderer
+	header := []rune(" log")
+	for x := range width {
+		ch := ' '
+		if x < len(header) {
+			ch = header[x]
+		}
+		r.SetCell(x, top, ch, 0, panelTitle)
+	}
+	for i, line := range lines {
+		for x, ch := range []rune(line) {
+			if x >= width {
+				break
+			}
+			r.SetCell(x, top+1+i, ch, 0, panelHint.Background(tcell.ColorBlack))
+		}
+	}
+}
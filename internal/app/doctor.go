@@ -0,0 +1,97 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Doctor checks the configuration and its file, the saved settings and the
+// terminal and writes a report to out. It returns the first problem that would stop the
+// screensaver from running, after reporting everything it found.
+func Doctor(cfg Config, out io.Writer) error {
+	var first error
+	check := func(name string, err error) {
+		if err == nil {
+			fmt.Fprintf(out, "ok    %s\n", name)
+			return
+		}
+		fmt.Fprintf(out, "FAIL  %s: %v\n", name, err)
+		if hint := Hint(err); hint != "" {
+			fmt.Fprintf(out, "      %s\n", hint)
+		}
+		if first == nil {
+			first = err
+		}
+	}
+
+	path, err := statePath()
+	if err == nil {
+		_, err = readState(State{})
+	}
+	if err != nil {
+		// The saved settings are ignored when unreadable, so this is not fatal
+		fmt.Fprintf(out, "warn  saved settings: %v\n", err)
+	} else {
+		fmt.Fprintf(out, "ok    saved settings (%s)\n", path)
+	}
+	if path, err := configPath(); err == nil {
+		// The configuration file is left out of cfg when it has mistakes
+		file := DefaultConfig()
+		if err := LoadConfigFile(&file); err != nil {
+			check("configuration file", err)
+		} else if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(out, "ok    configuration file (none at %s)\n", path)
+		} else {
+			fmt.Fprintf(out, "ok    configuration file (%s)\n", path)
+		}
+	}
+	check("configuration", Validate(cfg))
+	if host := startPlugins(cfg); host != nil {
+		for _, p := range host.Plugins() {
+			var names []string
+			for _, o := range p.Overlays() {
+				names = append(names, pluginPrefix+o.Name())
+			}
+			for _, s := range p.Scenes() {
+				names = append(names, "scene "+pluginPrefix+s.Name())
+			}
+			fmt.Fprintf(out, "ok    plugin %s (%s)\n", p.Name(), strings.Join(names, ", "))
+		}
+		// The screensaver runs on without plugins that fail to start
+		for _, err := range host.Errors() {
+			fmt.Fprintf(out, "warn  %v\n", err)
+		}
+		host.Close()
+	}
+
+	screen, err := openScreen()
+	if err != nil {
+		check("terminal", err)
+		return first
+	}
+	caps := detectCapabilities(screen)
+	screen.Fini()
+	check("terminal", nil)
+	for _, line := range caps.lines() {
+		if line == "" {
+			fmt.Fprintln(out)
+			continue
+		}
+		fmt.Fprintf(out, "      %s\n", line)
+	}
+	return first
+}
+
+// Export writes the effective configuration, after saved settings and
+// flags are applied, to out as indented JSON.
+func Export(cfg Config, out io.Writer) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
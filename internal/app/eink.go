@@ -0,0 +1,27 @@
+package app
+
+import "time"
+
+// EInkOptions configures drawing on an e-ink display driven by an IT8951
+// controller, such as the Waveshare e-Paper HATs for the Raspberry Pi.
+type EInkOptions struct {
+	// Device is the SPI device the controller is attached to
+	Device string
+	// GPIOChip is the GPIO character device with its ready and reset lines
+	GPIOChip string
+	// VCOM is the panel's VCOM voltage printed on its cable, e.g. -1.50,
+	// or 0 to keep the controller's setting
+	VCOM float64
+	// FullEvery forces a full, flashing refresh after this many partial
+	// ones, to clear the ghosting they leave
+	FullEvery int
+}
+
+// DefaultEInkOptions returns options for a Waveshare HAT on a Raspberry Pi.
+func DefaultEInkOptions() EInkOptions {
+	return EInkOptions{Device: "/dev/spidev0.0", GPIOChip: "/dev/gpiochip0", FullEvery: 30}
+}
+
+// einkFrameDelay is the shortest time between frames on e-ink displays,
+// which take a few hundred milliseconds for every refresh.
+const einkFrameDelay = time.Second
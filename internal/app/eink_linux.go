@@ -0,0 +1,115 @@
+//go:build linux
+
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/eink"
+	"github.com/olegchuev/screensaver/internal/font"
+)
+
+// einkScreen is a simulated screen shown on an e-ink display with a raster
+// font. Keys are read from the console the program was started on.
+type einkScreen struct {
+	trueColorScreen
+	face         font.Face
+	cellW, cellH int
+	masks        map[rune]*image.Alpha
+	prev         []snapshotCell // Cells as last drawn, to skip unchanged ones
+
+	panel     eink.Panel
+	refresher *eink.Refresher
+	frame     *image.Gray
+	console   *console
+}
+
+// openEInk starts the display and creates a simulated screen filling it
+// with whole cells.
+func openEInk(cfg Config) (tcell.Screen, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	opts := cfg.EInk
+	panel, err := eink.OpenIT8951(opts.Device, opts.GPIOChip, opts.VCOM)
+	if err != nil {
+		return nil, err
+	}
+	s := &einkScreen{face: face, masks: make(map[rune]*image.Alpha), panel: panel}
+	s.cellW, s.cellH = face.CellSize()
+	s.refresher = eink.NewRefresher(panel)
+	s.refresher.FullEvery = opts.FullEvery
+	s.frame = image.NewGray(panel.Bounds())
+
+	b := panel.Bounds()
+	cols, rows := b.Dx()/s.cellW, b.Dy()/s.cellH
+	if cols < minWidth || rows < minHeight {
+		panel.Close()
+		return nil, fmt.Errorf("%w: %dx%d pixels hold %dx%d cells, need at least %dx%d",
+			ErrTermTooSmall, b.Dx(), b.Dy(), cols, rows, minWidth, minHeight)
+	}
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		panel.Close()
+		return nil, err
+	}
+	sim.SetSize(cols, rows)
+	s.trueColorScreen = trueColorScreen{sim}
+	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+
+	s.console = openConsole(false, s.InjectKey)
+	return s, nil
+}
+
+// Show draws the cells that changed into the frame and refreshes the
+// display where the dithered frame differs.
+func (s *einkScreen) Show() {
+	s.SimulationScreen.Show()
+	contents, cols, _ := s.GetContents()
+	if len(s.prev) != len(contents) {
+		s.prev = make([]snapshotCell, len(contents))
+	}
+	changed := false
+	for i, c := range contents {
+		cell := newSnapshotCell(c)
+		if cell == s.prev[i] {
+			continue
+		}
+		s.prev[i] = cell
+		s.paint(i%cols, i/cols, cell)
+		changed = true
+	}
+	if changed {
+		// A failed refresh leaves the old frame up, the next one retries
+		s.refresher.Show(s.frame)
+	}
+}
+
+// paint draws one cell in grey, blending its colors by the glyph's coverage.
+func (s *einkScreen) paint(x, y int, c snapshotCell) {
+	mask, ok := s.masks[c.char]
+	if !ok {
+		mask = cellMask(s.face, c.char)
+		s.masks[c.char] = mask
+	}
+	for py := range s.cellH {
+		for px := range s.cellW {
+			cover := float64(mask.AlphaAt(px, py).A) / 255
+			s.frame.SetGray(x*s.cellW+px, y*s.cellH+py, color.GrayModel.Convert(blend(c.bg, c.fg, cover)).(color.Gray))
+		}
+	}
+}
+
+// Fini puts the display to sleep, leaving the last frame on it as e-ink
+// does, and gives the console back.
+func (s *einkScreen) Fini() {
+	s.SimulationScreen.Fini()
+	s.panel.Close()
+	if s.console != nil {
+		s.console.close()
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package app
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// openEInk reports that e-ink displays are only driven on Linux.
+func openEInk(cfg Config) (tcell.Screen, error) {
+	return nil, invalidConfig(errors.New("e-ink output is only supported on Linux"))
+}
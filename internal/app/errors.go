@@ -0,0 +1,99 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Minimum terminal size the scenes are laid out for.
+const (
+	minWidth  = 20
+	minHeight = 8
+)
+
+// Errors returned by New, Run and Setup. They are wrapped with details, so
+// match them with errors.Is.
+var (
+	// ErrNoTTY means there is no terminal to draw on, e.g. under cron or in a pipe
+	ErrNoTTY = errors.New("no terminal available")
+	// ErrTermTooSmall means the terminal has fewer cells than the scenes need
+	ErrTermTooSmall = errors.New("terminal too small")
+	// ErrUnsupportedColor means the terminal cannot show colors at all
+	ErrUnsupportedColor = errors.New("terminal does not support color")
+	// ErrConfigInvalid means a flag, saved setting or replay file is unusable
+	ErrConfigInvalid = errors.New("invalid configuration")
+)
+
+// Process exit codes for the typed errors; anything else exits with 1.
+const (
+	ExitConfig = 2
+	ExitNoTTY  = 3
+	ExitSize   = 4
+	ExitColor  = 5
+)
+
+// ExitCode returns the process exit code for an error returned by the app.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrConfigInvalid):
+		return ExitConfig
+	case errors.Is(err, ErrNoTTY):
+		return ExitNoTTY
+	case errors.Is(err, ErrTermTooSmall):
+		return ExitSize
+	case errors.Is(err, ErrUnsupportedColor):
+		return ExitColor
+	}
+	return 1
+}
+
+// Hint suggests how to fix an error returned by the app, or returns "".
+func Hint(err error) string {
+	switch {
+	case errors.Is(err, ErrConfigInvalid):
+		return "Run with -h to see the accepted flags and values."
+	case errors.Is(err, ErrNoTTY):
+		return "Run the screensaver from an interactive terminal; it cannot draw when started by cron, systemd or over a pipe."
+	case errors.Is(err, ErrTermTooSmall):
+		return fmt.Sprintf("Enlarge the window to at least %dx%d cells or reduce the font size.", minWidth, minHeight)
+	case errors.Is(err, ErrUnsupportedColor):
+		return "Set TERM to a color capable terminal type such as xterm-256color."
+	}
+	return ""
+}
+
+// invalidConfig marks err as a configuration problem.
+func invalidConfig(err error) error {
+	return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+}
+
+// openScreen initializes the terminal and checks that it can show the
+// screensaver, returning one of the typed errors if not.
+func openScreen() (tcell.Screen, error) {
+	detectTrueColor()
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoTTY, err)
+	}
+	if err := screen.Init(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoTTY, err)
+	}
+
+	if w, h := screen.Size(); w < minWidth || h < minHeight {
+		screen.Fini()
+		return nil, fmt.Errorf("%w: %dx%d cells, need at least %dx%d", ErrTermTooSmall, w, h, minWidth, minHeight)
+	}
+	if colors := screen.Colors(); colors < 8 {
+		screen.Fini()
+		return nil, fmt.Errorf("%w: TERM=%s reports %d colors", ErrUnsupportedColor, os.Getenv("TERM"), colors)
+	}
+
+	screen.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+	screen.HideCursor()
+	return screen, nil
+}
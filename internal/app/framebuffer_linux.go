@@ -0,0 +1,198 @@
+//go:build linux
+
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+)
+
+// Framebuffer ioctls, see linux/fb.h.
+const (
+	fbioGetVScreenInfo = 0x4600
+	fbioGetFScreenInfo = 0x4602
+)
+
+// fbBitfield locates one color channel inside a pixel.
+type fbBitfield struct {
+	Offset, Length, MSBRight uint32
+}
+
+// fbVarScreeninfo mirrors struct fb_var_screeninfo.
+type fbVarScreeninfo struct {
+	XRes, YRes, XResVirtual, YResVirtual uint32
+	XOffset, YOffset                     uint32
+	BitsPerPixel, Grayscale              uint32
+	Red, Green, Blue, Transp             fbBitfield
+	NonStd, Activate, Height, Width      uint32
+	AccelFlags, PixClock                 uint32
+	Margins                              [4]uint32
+	HSyncLen, VSyncLen, Sync, VMode      uint32
+	Rotate, Colorspace                   uint32
+	Reserved                             [4]uint32
+}
+
+// fbFixScreeninfo mirrors struct fb_fix_screeninfo; uintptr stands in for
+// unsigned long.
+type fbFixScreeninfo struct {
+	ID                            [16]byte
+	SmemStart                     uintptr
+	SmemLen                       uint32
+	Type, TypeAux, Visual         uint32
+	XPanStep, YPanStep, YWrapStep uint16
+	LineLength                    uint32
+	MmioStart                     uintptr
+	MmioLen, Accel                uint32
+	Capabilities                  uint16
+	Reserved                      [2]uint16
+}
+
+// framebufferScreen is a simulated screen painted onto a Linux framebuffer
+// device with a raster font, for consoles without a terminal emulator such
+// as a Raspberry Pi driving a display directly. Keys are read from the
+// console the program was started on.
+type framebufferScreen struct {
+	trueColorScreen
+	face         font.Face
+	cellW, cellH int
+	masks        map[rune]*image.Alpha
+	prev         []snapshotCell // Cells as last painted, to skip unchanged ones
+
+	dev     *os.File
+	mem     []byte
+	stride  int // Bytes per pixel row
+	bytes   int // Bytes per pixel
+	info    fbVarScreeninfo
+	console *console
+}
+
+// openFramebuffer maps the framebuffer device cfg.Framebuffer and creates a
+// simulated screen filling it with whole cells.
+func openFramebuffer(cfg Config) (tcell.Screen, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	dev, err := os.OpenFile(cfg.Framebuffer, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	s := &framebufferScreen{face: face, masks: make(map[rune]*image.Alpha), dev: dev}
+	s.cellW, s.cellH = face.CellSize()
+
+	var fix fbFixScreeninfo
+	if err := ioctl(dev, fbioGetVScreenInfo, unsafe.Pointer(&s.info)); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("%s: %w", cfg.Framebuffer, err)
+	}
+	if err := ioctl(dev, fbioGetFScreenInfo, unsafe.Pointer(&fix)); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("%s: %w", cfg.Framebuffer, err)
+	}
+	s.bytes = int(s.info.BitsPerPixel) / 8
+	if s.bytes < 2 || s.bytes > 4 {
+		dev.Close()
+		return nil, fmt.Errorf("%s: %d bits per pixel is not supported, want 16, 24 or 32", cfg.Framebuffer, s.info.BitsPerPixel)
+	}
+	s.stride = int(fix.LineLength)
+	if s.mem, err = syscall.Mmap(int(dev.Fd()), 0, int(fix.SmemLen), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("%s: %w", cfg.Framebuffer, err)
+	}
+
+	cols, rows := int(s.info.XRes)/s.cellW, int(s.info.YRes)/s.cellH
+	if cols < minWidth || rows < minHeight {
+		s.close()
+		return nil, fmt.Errorf("%w: %dx%d pixels hold %dx%d cells, need at least %dx%d",
+			ErrTermTooSmall, s.info.XRes, s.info.YRes, cols, rows, minWidth, minHeight)
+	}
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		s.close()
+		return nil, err
+	}
+	sim.SetSize(cols, rows)
+	s.trueColorScreen = trueColorScreen{sim}
+	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+	clear(s.mem)
+
+	s.console = openConsole(true, s.InjectKey)
+	return s, nil
+}
+
+// Show paints the cells that changed since the previous frame.
+func (s *framebufferScreen) Show() {
+	s.SimulationScreen.Show()
+	contents, cols, _ := s.GetContents()
+	if len(s.prev) != len(contents) {
+		s.prev = make([]snapshotCell, len(contents))
+	}
+	for i, c := range contents {
+		cell := newSnapshotCell(c)
+		if cell == s.prev[i] {
+			continue
+		}
+		s.prev[i] = cell
+		s.paint(i%cols, i/cols, cell)
+	}
+}
+
+// paint draws one cell, blending its colors by the glyph's coverage.
+func (s *framebufferScreen) paint(x, y int, c snapshotCell) {
+	mask, ok := s.masks[c.char]
+	if !ok {
+		mask = cellMask(s.face, c.char)
+		s.masks[c.char] = mask
+	}
+	fg, bg := s.pixel(c.fg), s.pixel(c.bg)
+	for py := range s.cellH {
+		row := (y*s.cellH+py)*s.stride + x*s.cellW*s.bytes
+		for px := range s.cellW {
+			v := bg
+			switch a := mask.AlphaAt(px, py).A; a {
+			case 0:
+			case 255:
+				v = fg
+			default:
+				v = s.pixel(blend(c.bg, c.fg, float64(a)/255))
+			}
+			i := row + px*s.bytes
+			for b := range s.bytes {
+				s.mem[i+b] = byte(v >> (8 * b))
+			}
+		}
+	}
+}
+
+// pixel packs a color into the framebuffer's pixel format.
+func (s *framebufferScreen) pixel(c color.RGBA) uint32 {
+	channel := func(v uint8, f fbBitfield) uint32 {
+		return uint32(v) >> (8 - min(f.Length, 8)) << f.Offset
+	}
+	return channel(c.R, s.info.Red) | channel(c.G, s.info.Green) | channel(c.B, s.info.Blue)
+}
+
+// Fini blanks the framebuffer and gives the console back.
+func (s *framebufferScreen) Fini() {
+	s.SimulationScreen.Fini()
+	clear(s.mem)
+	s.close()
+}
+
+// close restores the console and releases the device.
+func (s *framebufferScreen) close() {
+	if s.console != nil {
+		s.console.close()
+	}
+	if s.mem != nil {
+		syscall.Munmap(s.mem)
+	}
+	s.dev.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package app
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// openFramebuffer reports that framebuffer devices only exist on Linux.
+func openFramebuffer(cfg Config) (tcell.Screen, error) {
+	return nil, invalidConfig(errors.New("framebuffer output is only supported on Linux"))
+}
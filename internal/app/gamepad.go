@@ -0,0 +1,83 @@
+package app
+
+import (
+	"math"
+
+	"github.com/olegchuev/screensaver/internal/gamepad"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Camera speeds with a stick or trigger held all the way.
+const (
+	padTurnSpeed = 1.2 // Radians per second
+	padTiltSpeed = 1.0 // Tilt per second
+	padZoomSpeed = 0.7 // Zoom grows by e in this many inverse seconds
+)
+
+// openGamepad starts reading the game controllers if the configuration asks for them.
+func openGamepad(cfg Config) (*gamepad.Pad, error) {
+	if !cfg.Gamepad {
+		return nil, nil
+	}
+	return gamepad.Open()
+}
+
+// handlePad acts on a pressed button or remembers where an axis is held.
+//
+//	left stick           turn and tilt the camera
+//	right stick, LT, RT  zoom
+//	LB, RB, d-pad        previous and next scene
+//	A                    a beat: surge, burst and pulse
+//	B                    a gust of air
+//	X                    next theme
+//	Y                    reset the camera
+//	Start                pause and resume
+func (a *App) handlePad(ev gamepad.Event) {
+	if !ev.Control.Button() {
+		a.padAxes[ev.Control] = ev.Value
+		if ev.Control == gamepad.DPadX && ev.Value != 0 {
+			a.cycleScene(int(math.Copysign(1, ev.Value)))
+		}
+		return
+	}
+	if ev.Value == 0 {
+		return
+	}
+	switch ev.Control {
+	case gamepad.ButtonA:
+		a.kick = 1
+	case gamepad.ButtonB:
+		if r, ok := a.scene.(gustReactive); ok {
+			r.Blow(1)
+		}
+	case gamepad.ButtonX:
+		a.nextTheme()
+	case gamepad.ButtonY:
+		a.renderer.SetCamera(renderer.DefaultCamera())
+	case gamepad.ButtonLB:
+		a.cycleScene(-1)
+	case gamepad.ButtonRB:
+		a.cycleScene(1)
+	case gamepad.ButtonStart:
+		a.paused = !a.paused
+	}
+}
+
+// steer moves the camera by one frame's worth of the held sticks and triggers.
+func (a *App) steer() {
+	dt := a.config.FrameDelay.Seconds()
+	axis := a.padAxes
+	c := a.renderer.Camera()
+	c.Yaw += axis[gamepad.AxisLeftX] * padTurnSpeed * dt
+	c.Tilt = min(max(c.Tilt-axis[gamepad.AxisLeftY]*padTiltSpeed*dt, 0.2), 3)
+	zoom := axis[gamepad.AxisRT] - axis[gamepad.AxisLT] - axis[gamepad.AxisRightY]
+	a.renderer.SetCamera(c)
+	a.zoom(math.Exp(zoom * padZoomSpeed * dt))
+}
+
+// cycleScene switches to the scene step places along the menu order.
+func (a *App) cycleScene(step int) {
+	n := len(sceneNames)
+	i := indexOf(sceneNames, a.config.Scene)
+	_ = a.switchScene(sceneNames[((i+step)%n+n)%n])
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"github.com/olegchuev/screensaver/internal/animation"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/transition"
+)
+
+// handover is a transition from the scene that was running to the one
+// switched to. Both keep running, each drawn into a viewport of its own,
+// and the screen shows the two mixed cell by cell as the configured
+// transition.Switch has them.
+type handover struct {
+	from     scene
+	turn     transition.Switch
+	length   float64 // Seconds the transition takes
+	elapsed  float64
+	old, new *renderer.Renderer
+}
+
+// replaceScene makes sc the running scene, handing over to it from the one
+// before with the configured transition. A switch during a transition
+// starts from whichever of its scenes shows more.
+func (a *App) replaceScene(sc scene) {
+	from := a.scene
+	if h := a.handover; h != nil && h.progress() < 0.5 {
+		from = h.from
+	}
+	a.scene, a.handover = sc, nil
+	turn, _ := transition.NewSwitch(a.config.Transition)
+	if turn == nil || a.config.TransitionTime <= 0 || from == nil {
+		return
+	}
+	a.handover = &handover{
+		from:   from,
+		turn:   turn,
+		length: a.config.TransitionTime.Seconds(),
+		old:    a.renderer.Offscreen(),
+		new:    a.renderer.Offscreen(),
+	}
+}
+
+// progress returns how far along the transition is, from 0 to 1, eased so
+// it starts and ends gently.
+func (h *handover) progress() float64 {
+	return animation.EaseInOutSine(min(h.elapsed/h.length, 1))
+}
+
+// update advances the scene handed over from to time t and the transition
+// by a frame of delay seconds, returning true once it is over.
+func (h *handover) update(t, delay float64) bool {
+	h.from.Update(t)
+	h.elapsed += delay
+	return h.elapsed >= h.length
+}
+
+// render draws both scenes and mixes them onto r, to being the scene
+// handed over to.
+func (h *handover) render(r *renderer.Renderer, to scene) {
+	width, height := r.Size()
+	for _, side := range []struct {
+		sc   scene
+		view *renderer.Renderer
+	}{{h.from, h.old}, {to, h.new}} {
+		side.view.SetSize(width, height)
+		side.view.Clear()
+		side.sc.Render(side.view)
+	}
+	progress := h.progress()
+	r.Mix(h.old, h.new, 0, func(x, y int) float64 {
+		return h.turn(x, y, width, height, progress)
+	})
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/datasource"
+	"github.com/olegchuev/screensaver/internal/scenes/heatmap"
+)
+
+// matrixReceiver is implemented by scenes showing a matrix fed from outside.
+type matrixReceiver interface {
+	SetMatrix(m [][]float64)
+}
+
+// ParseRange parses a value range given as "MIN:MAX", e.g. "0:100".
+func ParseRange(s string) (float64, float64, error) {
+	invalid := fmt.Errorf("invalid range %q: want MIN:MAX like 0:100", s)
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, invalid
+	}
+	lo, err := strconv.ParseFloat(strings.TrimSpace(from), 64)
+	if err != nil || math.IsNaN(lo) || math.IsInf(lo, 0) {
+		return 0, 0, invalid
+	}
+	hi, err := strconv.ParseFloat(strings.TrimSpace(to), 64)
+	if err != nil || math.IsNaN(hi) || math.IsInf(hi, 0) || lo >= hi {
+		return 0, 0, invalid
+	}
+	return lo, hi, nil
+}
+
+// setMatrix shows the matrix given to the heatmap command, and keeps it
+// for the heatmap scene if another scene is running.
+func (a *App) setMatrix(text string) error {
+	m, err := heatmap.Parse(text)
+	if err != nil {
+		return err
+	}
+	a.matrix = m
+	if r, ok := a.scene.(matrixReceiver); ok {
+		r.SetMatrix(m)
+	}
+	return nil
+}
+
+// stdinMatrices turns matrices piped to standard input into heatmap
+// commands, so they take the same path as the control pipe and are
+// recorded alike. A matrix has one row per line and ends at a blank line.
+// It returns nil when standard input is a terminal.
+func stdinMatrices() <-chan string {
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return nil
+	}
+	return readMatrices(os.Stdin)
+}
+
+// readMatrices sends a heatmap command for every matrix read from r.
+func readMatrices(r io.Reader) <-chan string {
+	commands := make(chan string, 16)
+	go func() {
+		var rows []string
+		flush := func() {
+			if len(rows) > 0 {
+				commands <- "heatmap " + strings.Join(rows, ";")
+				rows = rows[:0]
+			}
+		}
+		readLines(r, func(line string) {
+			if line = strings.TrimSpace(line); line != "" {
+				rows = append(rows, line)
+			} else {
+				flush()
+			}
+		})
+		flush()
+	}()
+	return commands
+}
+
+// mergeCommands forwards the commands of both channels into one; either
+// may be nil.
+func mergeCommands(a, b <-chan string) <-chan string {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := make(chan string, 16)
+	forward := func(c <-chan string) {
+		for line := range c {
+			merged <- line
+		}
+	}
+	go forward(a)
+	go forward(b)
+	return merged
+}
+
+// sourceCachePath returns the cache file for the data of a source spec in
+// the user cache directory, empty if there is none.
+func sourceCachePath(spec string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(spec))
+	return filepath.Join(dir, "screensaver", "sources", fmt.Sprintf("%x.json", sum[:8]))
+}
+
+// decodeMatrix reads a matrix from a data source: a JSON array of rows, or
+// text as the heatmap command takes it.
+func decodeMatrix(data []byte) ([][]float64, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return datasource.JSON[[][]float64]("")(data)
+	}
+	return heatmap.Parse(string(data))
+}
+
+// openHeatmapSource follows the configured heatmap source, turning every
+// matrix it delivers into a heatmap command like stdinMatrices does. It
+// returns nil without a source.
+func openHeatmapSource(cfg Config) (<-chan string, func(), error) {
+	if cfg.HeatmapSource == "" {
+		return nil, nil, nil
+	}
+	src, err := datasource.Open(cfg.HeatmapSource, datasource.Options{
+		TTL:     cfg.SourceTTL,
+		Backoff: datasource.DefaultBackoff(),
+		Cache:   sourceCachePath(cfg.HeatmapSource),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	matrices := datasource.Bind(src, decodeMatrix)
+	commands := make(chan string, 16)
+	done := make(chan struct{})
+	go func() {
+		send := func() {
+			m, ok := matrices.Get()
+			if !ok {
+				return
+			}
+			rows := make([]string, len(m))
+			for i, row := range m {
+				values := make([]string, len(row))
+				for j, v := range row {
+					values[j] = strconv.FormatFloat(v, 'g', -1, 64)
+				}
+				rows[i] = strings.Join(values, " ")
+			}
+			select {
+			case commands <- "heatmap " + strings.Join(rows, ";"):
+			case <-done:
+			}
+		}
+		// Cached data shows at once
+		send()
+		for {
+			select {
+			case <-done:
+				return
+			case <-src.Changed():
+				send()
+			}
+		}
+	}()
+	return commands, func() {
+		close(done)
+		src.Close()
+	}, nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		s      string
+		lo, hi float64
+	}{
+		{"0:100", 0, 100},
+		{"-1.5:2e3", -1.5, 2000},
+		{" 3 : 4 ", 3, 4},
+	}
+	for _, tt := range tests {
+		lo, hi, err := ParseRange(tt.s)
+		if err != nil || lo != tt.lo || hi != tt.hi {
+			t.Errorf("ParseRange(%q) = %v, %v, %v; want %v, %v", tt.s, lo, hi, err, tt.lo, tt.hi)
+		}
+	}
+	for _, s := range []string{"", "0", "0:", ":1", "1:0", "2:2", "0:100x", "0:100:200", "a:b", "0:inf", "nan:1"} {
+		if _, _, err := ParseRange(s); err == nil {
+			t.Errorf("ParseRange(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", maxCommandLine+1)
+	var got []string
+	readLines(strings.NewReader("pause\r\n\n"+long+"\nscene ocean\nresume"), func(line string) {
+		got = append(got, line)
+	})
+	want := []string{"pause", "", "scene ocean", "resume"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadMatrices(t *testing.T) {
+	var got []string
+	for command := range readMatrices(strings.NewReader("1 2\n3 4\n\n\n5\n")) {
+		got = append(got, command)
+		if len(got) == 2 {
+			break
+		}
+	}
+	want := []string{"heatmap 1 2;3 4", "heatmap 5"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/holiday"
+)
+
+// holidaySeed seeds every holiday effect, so recorded and replayed
+// sessions and snapshots show the same snowfall.
+const holidaySeed = 1
+
+// holidayAt returns the holiday effect to show on the day of now: the one
+// forced by Holiday, or the calendar's, or "" for none.
+func (c Config) holidayAt(now time.Time) string {
+	switch c.Holiday {
+	case "":
+		return holiday.Find(c.Holidays, now)
+	case "off":
+		return ""
+	}
+	return c.Holiday
+}
+
+// celebrate starts the holiday effect for the day of now when it changes,
+// at midnight or when the configuration is reloaded.
+func (a *App) celebrate(now time.Time) {
+	name := a.config.holidayAt(now)
+	if name == a.holidayName {
+		return
+	}
+	a.holidayName = name
+	a.holiday = nil
+	if name != "" {
+		// Validate checked the name
+		a.holiday, _ = holiday.New(name, holidaySeed)
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// overlayFadeTime is how long the overlays take to fade out once idle.
+const overlayFadeTime = time.Second
+
+// touched notes user input at now, bringing hidden overlays straight back.
+func (a *App) touched(now time.Time) {
+	a.lastInput = now
+}
+
+// fadeOverlays fades the overlay layer out after HideOverlays without
+// input, leaving the scene alone on screen.
+func (a *App) fadeOverlays(now time.Time) {
+	if a.config.HideOverlays <= 0 {
+		a.overlaysHidden = 0
+		return
+	}
+	idle := now.Sub(a.lastInput) - a.config.HideOverlays
+	a.overlaysHidden = min(max(idle.Seconds()/overlayFadeTime.Seconds(), 0), 1)
+	style, ok := a.config.Layers[renderer.LayerOverlay]
+	if !ok {
+		style = renderer.DefaultLayerStyle()
+	}
+	style.Opacity *= 1 - a.overlaysHidden
+	a.renderer.SetLayerStyle(renderer.LayerOverlay, style)
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+)
+
+// Image protocols the terminal can draw frames with, see Config.Images.
+var imageProtocols = []string{"auto", "iterm2", "off"}
+
+// useITerm reports whether frames go to the terminal as iTerm2 inline
+// images: when asked to, or automatically in iTerm2 itself.
+func useITerm(cfg Config) bool {
+	switch cfg.Images {
+	case "iterm2":
+		return true
+	case "auto":
+		return os.Getenv("TERM_PROGRAM") == "iTerm.app"
+	}
+	return false
+}
+
+// itermScreen is the terminal screen with every frame drawn as one inline
+// image in the iTerm2 protocol (OSC 1337), rasterized with the raster font
+// and stretched over the window, for smooth antialiased waves. Keys, mouse
+// and resizes still come from the terminal.
+type itermScreen struct {
+	tcell.Screen
+	tty          tcell.Tty
+	face         font.Face
+	cellW, cellH int
+	masks        map[rune]*image.Alpha
+	prev         []snapshotCell // Cells as last painted, to skip unchanged ones
+	frame        *image.RGBA
+	encoder      png.Encoder
+	buf          bytes.Buffer
+}
+
+// openITerm opens the terminal and draws on it with inline images.
+func openITerm(cfg Config) (tcell.Screen, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	screen, err := openScreen()
+	if err != nil {
+		return nil, err
+	}
+	tty, ok := screen.Tty()
+	if !ok {
+		screen.Fini()
+		return nil, fmt.Errorf("%w: the terminal gives no access to its tty for images", ErrNoTTY)
+	}
+	s := &itermScreen{
+		Screen:  screen,
+		tty:     tty,
+		face:    face,
+		masks:   make(map[rune]*image.Alpha),
+		encoder: png.Encoder{CompressionLevel: png.BestSpeed},
+	}
+	s.cellW, s.cellH = face.CellSize()
+	return s, nil
+}
+
+// Colors reports 24-bit color support, as frames are drawn as images.
+func (s *itermScreen) Colors() int {
+	return 1 << 24
+}
+
+// Show paints the cells that changed since the previous frame and sends
+// the frame to the terminal, if anything changed.
+func (s *itermScreen) Show() {
+	cols, rows := s.Size()
+	bounds := image.Rect(0, 0, cols*s.cellW, rows*s.cellH)
+	if s.frame == nil || s.frame.Rect != bounds {
+		s.frame = image.NewRGBA(bounds)
+		s.prev = nil
+	}
+	if len(s.prev) != cols*rows {
+		s.prev = make([]snapshotCell, cols*rows)
+		for i := range s.prev {
+			s.prev[i].char = -1 // Painted by nothing yet
+		}
+	}
+	changed := false
+	for y := range rows {
+		for x := range cols {
+			char, _, style, _ := s.GetContent(x, y)
+			cell := styledCell(char, style)
+			if i := y*cols + x; cell != s.prev[i] {
+				s.prev[i] = cell
+				s.paint(x, y, cell)
+				changed = true
+			}
+		}
+	}
+	if changed {
+		s.send(cols, rows)
+	}
+}
+
+// Sync sends the whole frame again.
+func (s *itermScreen) Sync() {
+	s.prev = nil
+	s.Show()
+}
+
+// paint draws one cell into the frame, blending its colors by the glyph's
+// coverage.
+func (s *itermScreen) paint(x, y int, c snapshotCell) {
+	mask, ok := s.masks[c.char]
+	if !ok {
+		mask = cellMask(s.face, c.char)
+		s.masks[c.char] = mask
+	}
+	for py := range s.cellH {
+		for px := range s.cellW {
+			cover := float64(mask.AlphaAt(px, py).A) / 255
+			s.frame.SetRGBA(x*s.cellW+px, y*s.cellH+py, blend(c.bg, c.fg, cover))
+		}
+	}
+}
+
+// send writes the frame as an inline image covering the window from the
+// top-left cell, leaving the cursor where it is so the window never
+// scrolls.
+func (s *itermScreen) send(cols, rows int) {
+	s.buf.Reset()
+	var encoded bytes.Buffer
+	if err := s.encoder.Encode(&encoded, s.frame); err != nil {
+		return
+	}
+	fmt.Fprintf(&s.buf, "\x1b[H\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=0;doNotMoveCursor=1:",
+		encoded.Len(), cols, rows)
+	b64 := base64.NewEncoder(base64.StdEncoding, &s.buf)
+	b64.Write(encoded.Bytes())
+	b64.Close()
+	s.buf.WriteByte('\a')
+	s.tty.Write(s.buf.Bytes())
+}
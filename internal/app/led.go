@@ -0,0 +1,119 @@
+package app
+
+import (
+	"image"
+	"log"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/ledmatrix"
+)
+
+// ledScreen is a simulated screen whose frames are rasterized with a font
+// and scaled down to LED matrix displays on the network. Keys are read from
+// the console the program was started on.
+type ledScreen struct {
+	trueColorScreen
+	face    font.Face
+	outputs []*ledOutput
+	console *console
+}
+
+// ledOutput sends frames to one display without holding up the animation.
+type ledOutput struct {
+	dev    ledmatrix.Device
+	frames chan *image.RGBA // The frame waiting to be sent, if any
+	due    time.Time        // When the display takes the next frame
+}
+
+// openLED creates a simulated screen shown on the displays cfg.LED lists.
+// The screen has the shape of the first display; others show the middle
+// part that fits their own shape.
+func openLED(cfg Config) (tcell.Screen, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	s := &ledScreen{face: face}
+	for _, spec := range cfg.LED {
+		dev, err := ledmatrix.Open(spec)
+		if err != nil {
+			return nil, invalidConfig(err)
+		}
+		s.outputs = append(s.outputs, &ledOutput{dev: dev, frames: make(chan *image.RGBA, 1)})
+	}
+
+	// The fewest cells the scenes fit in, in the display's shape
+	cw, ch := face.CellSize()
+	size := s.outputs[0].dev.Size()
+	aspect := float64(size.X) / float64(size.Y)
+	rows := max(minHeight, int(float64(minWidth*cw)/(float64(ch)*aspect)+0.999))
+	cols := max(minWidth, int(float64(rows*ch)*aspect/float64(cw)+0.5))
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return nil, err
+	}
+	sim.SetSize(cols, rows)
+	s.trueColorScreen = trueColorScreen{sim}
+	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+
+	for _, out := range s.outputs {
+		go out.send()
+	}
+	s.console = openConsole(false, s.InjectKey)
+	return s, nil
+}
+
+// Show hands the frame to the displays that are due for one.
+func (s *ledScreen) Show() {
+	s.SimulationScreen.Show()
+	now := time.Now()
+	var img *image.RGBA
+	for _, out := range s.outputs {
+		if now.Before(out.due) {
+			continue
+		}
+		out.due = now.Add(out.dev.Interval())
+		if img == nil {
+			img = snapshotImage(screenGrid(s), s.face)
+		}
+		// A display still busy with the previous frame skips this one
+		select {
+		case out.frames <- img:
+		default:
+		}
+	}
+}
+
+// send scales the frames down to the display and sends them, logging when
+// the display stops and starts taking them.
+func (o *ledOutput) send() {
+	var failing error
+	for frame := range o.frames {
+		size := o.dev.Size()
+		img := image.NewRGBA(image.Rectangle{Max: size})
+		ledmatrix.Scale(img, frame)
+		err := o.dev.Show(img)
+		switch {
+		case err != nil && failing == nil:
+			log.Printf("%s: %v", o.dev, err)
+		case err == nil && failing != nil:
+			log.Printf("%s: showing frames again", o.dev)
+		}
+		failing = err
+	}
+}
+
+// Fini stops sending frames, leaving the displays on the last one, and
+// gives the console back.
+func (s *ledScreen) Fini() {
+	s.SimulationScreen.Fini()
+	for _, out := range s.outputs {
+		close(out.frames)
+	}
+	if s.console != nil {
+		s.console.close()
+	}
+}
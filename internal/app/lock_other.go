@@ -0,0 +1,10 @@
+//go:build !unix
+
+package app
+
+import "time"
+
+// acquireLock is a no-op on platforms without flock; multiple instances are allowed.
+func acquireLock(takeover bool, timeout time.Duration) (func(), error) {
+	return func() {}, nil
+}
@@ -0,0 +1,103 @@
+//go:build unix
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// lockPoll is how often a takeover checks whether the previous instance has exited.
+const lockPoll = 50 * time.Millisecond
+
+// lockPath returns the location of the single-instance lockfile in the user cache directory.
+func lockPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", "lock"), nil
+}
+
+// acquireLock makes sure only one screensaver runs at a time. If another
+// instance holds the lock it either fails or, with takeover set, asks that
+// instance to quit and waits up to timeout for it to exit.
+// The returned function releases the lock.
+func acquireLock(takeover bool, timeout time.Duration) (func(), error) {
+	path, err := lockPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	// The kernel drops a flock when its holder dies, so a stale file never blocks
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		pid := lockOwner(f)
+		if !takeover {
+			f.Close()
+			if pid > 0 {
+				return nil, fmt.Errorf("screensaver is already running (pid %d), use -takeover to replace it", pid)
+			}
+			return nil, errors.New("screensaver is already running, use -takeover to replace it")
+		}
+		err = takeOver(f, pid, timeout)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	return func() {
+		f.Truncate(0)
+		f.Close()
+	}, nil
+}
+
+// takeOver asks the instance holding the lock to quit and waits until the lock is free.
+func takeOver(f *os.File, pid int, timeout time.Duration) error {
+	// SIGTERM gets the same graceful fade-out as pressing q
+	if pid > 0 {
+		if err := syscall.Kill(pid, syscall.SIGTERM); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return fmt.Errorf("stopping running screensaver (pid %d): %w", pid, err)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("running screensaver (pid %d) did not exit within %v", pid, timeout)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// lockOwner reads the pid recorded in the lockfile, or 0 if there is none.
+func lockOwner(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/audio"
+)
+
+const (
+	// gustRise is how far above the room's usual loudness a burst must be
+	// to count as blowing into the microphone
+	gustRise = 0.25
+	// gustInterval is the shortest time between two gusts, so blowing
+	// steadily sends a series of them
+	gustInterval = 600 * time.Millisecond
+)
+
+// gustReactive is implemented by scenes that can be blown on.
+type gustReactive interface {
+	// Blow sends a gust of air with a strength from 0 to 1
+	Blow(strength float64)
+}
+
+// micListener turns bursts of loudness from the microphone, such as
+// blowing on it, into gusts. It only ever sees the loudness, see audio.Mic.
+type micListener struct {
+	mic   *audio.Mic
+	floor float64 // Loudness of the room without blowing
+	next  time.Time
+}
+
+// openMic starts listening to the microphone if the configuration opts in.
+func openMic(cfg Config) (*micListener, error) {
+	if !cfg.Mic {
+		return nil, nil
+	}
+	mic, err := audio.OpenMic()
+	if err != nil {
+		return nil, err
+	}
+	return &micListener{mic: mic}, nil
+}
+
+// close stops listening.
+func (l *micListener) close() {
+	_ = l.mic.Close()
+}
+
+// gust returns the strength of a burst of loudness at now, 0 for none.
+func (l *micListener) gust(now time.Time) float64 {
+	level := l.mic.Level()
+	rise := level - l.floor
+	// The floor follows the room slowly, and quiet moments quickly
+	if rise < gustRise {
+		l.floor += rise * 0.05
+		if rise < 0 {
+			l.floor += rise * 0.25
+		}
+	}
+	if rise < gustRise || now.Before(l.next) {
+		return 0
+	}
+	l.next = now.Add(gustInterval)
+	return min(rise/(2*gustRise), 1)
+}
+
+// blow passes gusts from the microphone to the scene.
+func (a *App) blow(now time.Time) {
+	if a.mic == nil {
+		return
+	}
+	if strength := a.mic.gust(now); strength > 0 {
+		if r, ok := a.scene.(gustReactive); ok {
+			r.Blow(strength)
+		}
+	}
+}
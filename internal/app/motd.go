@@ -0,0 +1,25 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/transition"
+)
+
+// newMOTD returns the banner showing the message of the day, or nil if
+// there is none. The message may break lines with \n; a file is shown as
+// it is, so text made into letters by figlet keeps its shape.
+func newMOTD(cfg Config) (*transition.Banner, error) {
+	switch {
+	case cfg.MOTDFile != "":
+		lines, err := transition.Capture(cfg.MOTDFile)
+		if err != nil {
+			return nil, err
+		}
+		return transition.NewBanner(lines, false, cfg.MOTDTime.Seconds())
+	case cfg.MOTD != "":
+		lines := strings.Split(strings.ReplaceAll(cfg.MOTD, `\n`, "\n"), "\n")
+		return transition.NewBanner(lines, true, cfg.MOTDTime.Seconds())
+	}
+	return nil, nil
+}
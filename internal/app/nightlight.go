@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/sun"
+	"github.com/olegchuev/screensaver/internal/weather"
+)
+
+const (
+	// nightTemperature is the warm target used by the automatic night-light
+	nightTemperature = 3400
+	// Hours of day when the automatic shift starts warming and cooling again
+	eveningHour = 20
+	morningHour = 7
+	// nightTransition is how long the shift takes to reach the target
+	nightTransition = time.Hour
+)
+
+// Temperature selects the color temperature shift applied to all output.
+type Temperature struct {
+	// Kelvin is a fixed color temperature, ignored when Auto is set
+	Kelvin float64
+	// Auto follows local time: neutral during the day, warm at night
+	Auto bool
+}
+
+// String formats the temperature the way it is accepted on the command line.
+func (t Temperature) String() string {
+	switch {
+	case t.Auto:
+		return "auto"
+	case t.Kelvin <= 0:
+		return "off"
+	default:
+		return fmt.Sprintf("%.0fK", t.Kelvin)
+	}
+}
+
+// ParseTemperature parses "auto", "off" or a Kelvin value such as "3500K".
+func ParseTemperature(s string) (Temperature, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	switch s {
+	case "auto":
+		return Temperature{Auto: true}, nil
+	case "", "off":
+		return Temperature{}, nil
+	}
+	k, err := strconv.ParseFloat(strings.TrimSuffix(s, "k"), 64)
+	if err != nil || k < 1000 || k > 40000 {
+		return Temperature{}, fmt.Errorf("invalid temperature %q: want auto, off, or 1000K-40000K", s)
+	}
+	return Temperature{Kelvin: k}, nil
+}
+
+// At returns the color temperature in Kelvin to use at the given local time.
+func (t Temperature) At(now time.Time) float64 {
+	if !t.Auto {
+		return t.Kelvin
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	evening := day.Add(eveningHour * time.Hour)
+	morning := day.Add(morningHour * time.Hour)
+
+	// Fraction of the way into night: ramps up after evening, down after morning
+	var night float64
+	switch {
+	case now.Before(morning):
+		night = 1
+	case now.Before(morning.Add(nightTransition)):
+		night = 1 - float64(now.Sub(morning))/float64(nightTransition)
+	case now.Before(evening):
+		night = 0
+	case now.Before(evening.Add(nightTransition)):
+		night = float64(now.Sub(evening)) / float64(nightTransition)
+	default:
+		night = 1
+	}
+	return renderer.NeutralTemperature + (nightTemperature-renderer.NeutralTemperature)*night
+}
+
+// AtPlace is like At, but the automatic shift follows the sun at the place
+// instead of the clock: it warms through golden hour before sunset and is
+// fully warm once twilight ends, and cools again through dawn.
+func (t Temperature) AtPlace(now time.Time, place weather.Location) float64 {
+	if !t.Auto {
+		return t.Kelvin
+	}
+	night := sun.Phase(sun.Elevation(now, place.Latitude, place.Longitude))
+	return renderer.NeutralTemperature + (nightTemperature-renderer.NeutralTemperature)*night
+}
+
+// sunPlace returns the place whose sun the night light follows: the
+// configured location, else the weather's, and false for neither or for a
+// location to look up.
+func sunPlace(cfg Config) (weather.Location, bool) {
+	s := cfg.Location
+	if s == "" {
+		s = cfg.Weather
+	}
+	l, err := weather.ParseLocation(s)
+	return l, err == nil
+}
+
+// locateByIP is the location setting that looks the place up from the
+// public IP address.
+const locateByIP = "ip"
+
+// locate finds the place for the night light, looking it up in the
+// background when asked to and the shift is automatic. Until a lookup
+// succeeds, the shift follows the clock. The sessions of serve share the
+// server's lookup.
+func (a *App) locate() {
+	if l, ok := sunPlace(a.config); ok {
+		a.place.Store(&l)
+		return
+	}
+	a.place.Store(nil)
+	if a.config.Location != locateByIP || !a.config.Temperature.Auto {
+		return
+	}
+	lookup := lookUpIP
+	if s := a.config.shared; s != nil {
+		lookup = s.locateIP
+	}
+	go func() {
+		if l, err := lookup(); err == nil {
+			a.place.Store(&l)
+		}
+	}()
+}
+
+// lookUpIP returns the place of the public IP address, giving up after a
+// minute.
+func lookUpIP() (weather.Location, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return weather.LocateIP(ctx)
+}
+
+// temperatureAt returns the color temperature at now.
+func (a *App) temperatureAt(now time.Time) float64 {
+	if place := a.place.Load(); place != nil {
+		return a.config.Temperature.AtPlace(now, *place)
+	}
+	return a.config.Temperature.At(now)
+}
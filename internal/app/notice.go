@@ -0,0 +1,48 @@
+package app
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// noticeTime is how long a notice stays on screen.
+const noticeTime = 5 * time.Second
+
+var noticeStyle = tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, 235, 235)).Background(tcell.NewRGBColor(140, 30, 30))
+
+// notice is an error shown along the bottom of the screen for a while,
+// since the animation owns the terminal and there is nowhere else to
+// report it.
+type notice struct {
+	text  string
+	until time.Time
+}
+
+// notify shows err as a notice from now on, replacing the previous one.
+func (a *App) notify(now time.Time, err error) {
+	a.notice = notice{text: strings.ReplaceAll(err.Error(), "\n", "; "), until: now.Add(noticeTime)}
+}
+
+// expireNotice takes the notice off the screen once its time is up.
+func (a *App) expireNotice(now time.Time) {
+	if a.notice.text != "" && !now.Before(a.notice.until) {
+		a.notice = notice{}
+	}
+}
+
+// renderNotice draws the notice on the bottom row, cut to the width of the
+// screen.
+func (a *App) renderNotice(r *renderer.Renderer) {
+	if a.notice.text == "" {
+		return
+	}
+	width, height := r.Size()
+	text := []rune(" " + a.notice.text + " ")
+	if len(text) > width {
+		text = append(text[:max(width-1, 0)], '…')
+	}
+	drawText(r, 0, height-1, string(text[:min(len(text), width)]), noticeStyle)
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/audio"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// oceanScene adapts the Gerstner wave simulation to the scene interface.
+type oceanScene struct {
+	wave *wave.Wave
+}
+
+// Update advances the wave simulation to time t.
+func (s *oceanScene) Update(t float64) {
+	s.wave.Update(t)
+}
+
+// SeaState measures the wave surface.
+func (s *oceanScene) SeaState() wave.SeaState {
+	return s.wave.SeaState()
+}
+
+// SetAudio lifts the swell with the bass, eased so single loud frames do
+// not jerk the surface.
+func (s *oceanScene) SetAudio(l audio.Levels) {
+	target := 1 + 1.5*l.Bass
+	s.wave.Swell += (target - s.wave.Swell) * 0.4
+}
+
+// Beat surges the waves; SetAudio eases them back to the music's swell.
+func (s *oceanScene) Beat(strength float64) {
+	s.wave.Swell += 0.6 * strength
+}
+
+// Blow lets a gust hit the water somewhere away from the edges.
+func (s *oceanScene) Blow(strength float64) {
+	s.wave.Blow(rand.Float64()*1.4-0.7, rand.Float64()*1.4-0.7, strength)
+}
+
+// Touch blows softly on the water under the finger.
+func (s *oceanScene) Touch(x, y int, r *renderer.Renderer) {
+	gx, gy := r.Unproject(x, y)
+	s.wave.Blow(gx, gy, 0.5)
+}
+
+// Render draws the wave surface and its particles.
+func (s *oceanScene) Render(r *renderer.Renderer) {
+	r.RenderWave(s.wave)
+}
@@ -0,0 +1,96 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/plugin"
+)
+
+// defaultPlacements are where the overlays go unless configured otherwise,
+// by the name they are placed with.
+var defaultPlacements = map[string]overlay.Placement{
+	"logo":    {Anchor: overlay.BottomRight, Margin: 1},
+	"ticker":  {Anchor: overlay.Bottom, Margin: 1},
+	"weather": {Anchor: overlay.TopRight, Margin: 1},
+}
+
+// overlayNames returns the names overlays are placed with, sorted, and
+// the form of the names of plugin overlays.
+func overlayNames() []string {
+	names := make([]string, 0, len(defaultPlacements)+1)
+	for name := range defaultPlacements {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return append(names, pluginPrefix+"NAME")
+}
+
+// placement returns where the named overlay goes.
+func (c Config) placement(name string) overlay.Placement {
+	if p, ok := c.Placements[name]; ok {
+		return p
+	}
+	return defaultPlacements[name]
+}
+
+// newOverlays lays out the overlays the configuration enables; the forecast
+// panel needs the weather reports, nil while they are not followed. The overlays of the
+// running plugins follow the built-in ones.
+func newOverlays(cfg Config, reports *forecast, plugins []*plugin.Plugin) overlay.Layout {
+	var l overlay.Layout
+	if reports != nil && cfg.Weather != "" {
+		l.Add(overlay.NewWeather(reports), cfg.placement("weather"))
+	}
+	if cfg.Logo != "" {
+		// Validate made sure it loads
+		if logo, err := overlay.LoadLogo(cfg.Logo, cfg.LogoWidth, cfg.LogoOpacity, cfg.LogoDrift); err == nil {
+			l.Add(logo, cfg.placement("logo"))
+		}
+	}
+	if cfg.Ticker != "" {
+		l.Add(overlay.NewTicker(cfg.Ticker, cfg.TickerSpeed), cfg.placement("ticker"))
+	}
+	for _, p := range plugins {
+		for _, o := range p.Overlays() {
+			name := pluginPrefix + o.Name()
+			// Dashboard pages show only the plugin overlays they name
+			if len(cfg.Pages) > 0 && !slices.Contains(cfg.pageOverlays, name) {
+				continue
+			}
+			place, ok := cfg.Placements[name]
+			if !ok {
+				place = o.Placement()
+			}
+			l.Add(o, place)
+		}
+	}
+	return l
+}
+
+// ParsePlacement parses an overlay placement of the form "name=anchor" or
+// "name=anchor,margin", for example "ticker=top" or "ticker=bottom-left,2".
+func ParsePlacement(s string) (string, overlay.Placement, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return "", overlay.Placement{}, fmt.Errorf("invalid placement %q: want name=anchor[,margin]", s)
+	}
+	if !knownOverlay(name) {
+		return "", overlay.Placement{}, fmt.Errorf("unknown overlay %q (available: %s)", name, strings.Join(overlayNames(), ", "))
+	}
+	anchor, margin, hasMargin := strings.Cut(value, ",")
+	var p overlay.Placement
+	var err error
+	if p.Anchor, err = overlay.ParseAnchor(anchor); err != nil {
+		return "", p, err
+	}
+	if hasMargin {
+		if p.Margin, err = strconv.Atoi(margin); err != nil || p.Margin < 0 {
+			return "", p, fmt.Errorf("invalid margin %q: want a whole number of cells, at least 0", margin)
+		}
+	}
+	return name, p, nil
+}
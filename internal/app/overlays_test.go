@@ -0,0 +1,30 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/overlay"
+)
+
+func TestParsePlacement(t *testing.T) {
+	tests := []struct {
+		s    string
+		name string
+		want overlay.Placement
+	}{
+		{"logo=top-left", "logo", overlay.Placement{Anchor: overlay.TopLeft}},
+		{"logo=bottom-right,2", "logo", overlay.Placement{Anchor: overlay.BottomRight, Margin: 2}},
+		{"plugin:clock=top-left,0", "plugin:clock", overlay.Placement{Anchor: overlay.TopLeft}},
+	}
+	for _, tt := range tests {
+		name, p, err := ParsePlacement(tt.s)
+		if err != nil || name != tt.name || p != tt.want {
+			t.Errorf("ParsePlacement(%q) = %q, %+v, %v; want %q, %+v", tt.s, name, p, err, tt.name, tt.want)
+		}
+	}
+	for _, s := range []string{"logo", "logo=", "logo=middle", "nothing=top-left", "logo=top-left,", "logo=top-left,2x", "logo=top-left,2.5", "logo=top-left,-1"} {
+		if _, _, err := ParsePlacement(s); err == nil {
+			t.Errorf("ParsePlacement(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/plugin"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// pluginPrefix starts the names plugin overlays are placed with and plugin
+// scenes are picked by, as in "plugin:clock", since the plugins have not
+// run when settings are read.
+const pluginPrefix = "plugin:"
+
+// pluginName returns the name a plugin gave an overlay or scene, reporting
+// whether name refers to one.
+func pluginName(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, pluginPrefix)
+	return rest, ok && rest != ""
+}
+
+// knownOverlay reports whether overlays can be placed under name.
+func knownOverlay(name string) bool {
+	_, ok := defaultPlacements[name]
+	_, plugin := pluginName(name)
+	return ok || plugin
+}
+
+// pluginDir returns the directory plugins are started from.
+func pluginDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", "plugins"), nil
+}
+
+// startPlugins starts the plugins if enabled, restarting each whenever its
+// file changes; nil if disabled. Guests of serve run none, so connecting
+// never starts processes on the server.
+func startPlugins(cfg Config) *plugin.Host {
+	if !cfg.Plugins || cfg.Guest {
+		return nil
+	}
+	dir, err := pluginDir()
+	if err != nil {
+		return nil
+	}
+	return plugin.NewHost(dir, plugin.Limits{Frame: cfg.PluginBudget, CPU: cfg.PluginCPU})
+}
+
+// runningPlugins returns the plugins drawing overlays, none if disabled.
+func (a *App) runningPlugins() []*plugin.Plugin {
+	if a.plugins == nil {
+		return nil
+	}
+	return a.plugins.Plugins()
+}
+
+// pluginHosted is implemented by scenes drawn by plugins, given the plugins
+// running.
+type pluginHosted interface {
+	SetHost(h *plugin.Host)
+}
+
+// pluginScene is a scene a plugin draws, looked up by name among the
+// running plugins every frame, so it carries on at the same time when the
+// plugin is restarted with new code. It is blank while no plugin provides
+// it.
+type pluginScene struct {
+	name string
+	host *plugin.Host
+}
+
+// SetHost gives the scene the plugins to find it among.
+func (s *pluginScene) SetHost(h *plugin.Host) {
+	s.host = h
+}
+
+// scene returns the scene of the plugin providing it now, nil if none.
+func (s *pluginScene) scene() *plugin.Scene {
+	if s.host == nil {
+		return nil
+	}
+	return s.host.Scene(s.name)
+}
+
+// Update asks the plugin for the scene at time t.
+func (s *pluginScene) Update(t float64) {
+	if sc := s.scene(); sc != nil {
+		sc.Update(t)
+	}
+}
+
+// Render draws the plugin's latest answer.
+func (s *pluginScene) Render(r *renderer.Renderer) {
+	if sc := s.scene(); sc != nil {
+		sc.Render(r)
+	}
+}
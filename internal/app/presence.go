@@ -0,0 +1,135 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/presence"
+)
+
+// Presence previews are this many frames this far apart, at most this many
+// pixels wide and high.
+const (
+	presenceFrames   = 20
+	presenceFrameGap = 100 * time.Millisecond
+	presenceSize     = 128
+)
+
+// presenceReporter shows on chat services that the screensaver is running,
+// updating them every interval with a short animated preview of the screen.
+type presenceReporter struct {
+	targets  []presence.Target
+	interval time.Duration
+	since    time.Time
+	next     time.Time     // When capturing the next preview starts
+	frames   []*image.RGBA // Preview being captured
+	last     time.Time     // When the last preview frame was captured
+	posting  sync.WaitGroup
+	busy     atomic.Bool // Whether the previous update is still being sent
+}
+
+// newPresenceReporter opens the targets cfg.Presence lists.
+func newPresenceReporter(cfg Config) (*presenceReporter, error) {
+	p := &presenceReporter{interval: cfg.PresenceInterval, since: time.Now()}
+	for _, spec := range cfg.Presence {
+		t, err := presence.Open(spec)
+		if err != nil {
+			return nil, err
+		}
+		p.targets = append(p.targets, t)
+	}
+	return p, nil
+}
+
+// capture records a preview frame from the screen when one is due, and
+// sends the update once the preview is complete.
+func (p *presenceReporter) capture(screen tcell.Screen, aspect float64, scene string) {
+	now := time.Now()
+	if now.Before(p.next) || (len(p.frames) > 0 && now.Sub(p.last) < presenceFrameGap) || p.busy.Load() {
+		return
+	}
+	p.frames = append(p.frames, previewFrame(screen, aspect))
+	p.last = now
+	if len(p.frames) < presenceFrames {
+		return
+	}
+
+	frames := p.frames
+	p.frames, p.next = nil, now.Add(p.interval)
+	status := presence.Status{
+		Text:    fmt.Sprintf("Idle since %s, watching %s", p.since.Format("15:04"), scene),
+		Expires: now.Add(2 * p.interval),
+	}
+	p.busy.Store(true)
+	p.posting.Add(1)
+	go func() {
+		defer p.posting.Done()
+		status.GIF = encodePreview(frames)
+		// Errors have nowhere to go while the screen is owned by the
+		// animation; the next update tries again
+		for _, t := range p.targets {
+			_ = t.Update(status)
+		}
+		p.busy.Store(false)
+	}()
+}
+
+// close waits for the update being sent and tells the targets the
+// screensaver quit.
+func (p *presenceReporter) close() {
+	p.posting.Wait()
+	text := fmt.Sprintf("Back after %s idle.", time.Since(p.since).Round(time.Minute))
+	for _, t := range p.targets {
+		_ = t.Clear(text)
+	}
+}
+
+// previewFrame samples the screen into a small image with the shape of the
+// screen, drawing each character by how much of its cell it covers.
+func previewFrame(screen tcell.Screen, aspect float64) *image.RGBA {
+	cols, rows := screen.Size()
+	w, h := float64(presenceSize), float64(presenceSize)*float64(rows)*aspect/float64(cols)
+	if h > presenceSize {
+		w, h = w*presenceSize/h, presenceSize
+	}
+	img := image.NewRGBA(image.Rect(0, 0, max(int(w), 1), max(int(h), 1)))
+	b := img.Bounds()
+	for y := range b.Dy() {
+		fy := (float64(y) + 0.5) * float64(rows) / float64(b.Dy())
+		cy := int(fy)
+		for x := range b.Dx() {
+			fx := (float64(x) + 0.5) * float64(cols) / float64(b.Dx())
+			cx := int(fx)
+			char, _, style, _ := screen.GetContent(cx, cy)
+			c := styledCell(char, style)
+			cover := glyphCoverage(c.char, fx-float64(cx), (fy-float64(cy))*aspect, 1, aspect)
+			img.SetRGBA(x, y, blend(c.bg, c.fg, cover))
+		}
+	}
+	return img
+}
+
+// encodePreview encodes the frames as a looping GIF.
+func encodePreview(frames []*image.RGBA) []byte {
+	anim := &gif.GIF{}
+	for _, f := range frames {
+		// Nearest colors without dithering, so still areas do not shimmer
+		paletted := image.NewPaletted(f.Bounds(), palette.Plan9)
+		draw.Draw(paletted, f.Bounds(), f, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, int(presenceFrameGap/(10*time.Millisecond)))
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// ApplyPreset replaces the ocean's waves, spray and theme with those of the
+// configured preset. It goes over the configuration file and the saved
+// settings, but a setting given by its own flag keeps the flag's value, so
+// -preset storm -theme ocean shows a storm in ocean colors. An unknown
+// preset is left for Validate to report.
+func (c *Config) ApplyPreset() {
+	p, ok := wave.LookupPreset(c.Preset)
+	if !ok {
+		return
+	}
+	flagged := func(setting string) bool {
+		return strings.HasPrefix(c.Sources[setting], "-")
+	}
+
+	wc := c.WaveConfig
+	p.Apply(&c.WaveConfig)
+	if flagged("wave-count") {
+		// The largest waves come first, so fewer keeps the character
+		n := min(max(wc.WaveCount, 1), len(p.Components))
+		c.WaveConfig.Components = c.WaveConfig.Components[:n]
+		c.WaveConfig.WaveCount = n
+	}
+	if flagged("steepness") {
+		c.WaveConfig.Steepness = wc.Steepness
+	}
+	if flagged("density") {
+		c.WaveConfig.ParticleDensity = wc.ParticleDensity
+	}
+	if !flagged("theme") {
+		c.Theme = p.Theme
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+func TestApplyPreset(t *testing.T) {
+	storm, _ := wave.LookupPreset("storm")
+	def := DefaultConfig()
+	tests := []struct {
+		name      string
+		preset    string
+		flags     func(c *Config) // Sets settings as their flags would
+		waves     int
+		steepness float64
+		density   float64
+		theme     string
+	}{
+		{name: "preset", preset: "storm",
+			waves: len(storm.Components), steepness: storm.Steepness, density: storm.ParticleDensity, theme: storm.Theme},
+		{name: "flagged theme", preset: "storm",
+			flags: func(c *Config) { c.Theme = "ocean"; c.SetSource("theme", "-theme") },
+			waves: len(storm.Components), steepness: storm.Steepness, density: storm.ParticleDensity, theme: "ocean"},
+		{name: "theme from the configuration file", preset: "storm",
+			flags: func(c *Config) { c.Theme = "ocean"; c.SetSource("theme", "config.toml") },
+			waves: len(storm.Components), steepness: storm.Steepness, density: storm.ParticleDensity, theme: storm.Theme},
+		{name: "flagged steepness and density", preset: "storm",
+			flags: func(c *Config) {
+				c.WaveConfig.Steepness, c.WaveConfig.ParticleDensity = 0.3, 0.2
+				c.SetSource("steepness", "-steepness")
+				c.SetSource("density", "-density")
+			},
+			waves: len(storm.Components), steepness: 0.3, density: 0.2, theme: storm.Theme},
+		{name: "fewer waves", preset: "storm",
+			flags: func(c *Config) { c.WaveConfig.WaveCount = 2; c.SetSource("wave-count", "-wave-count") },
+			waves: 2, steepness: storm.Steepness, density: storm.ParticleDensity, theme: storm.Theme},
+		{name: "more waves than the preset has", preset: "storm",
+			flags: func(c *Config) { c.WaveConfig.WaveCount = 10; c.SetSource("wave-count", "-wave-count") },
+			waves: len(storm.Components), steepness: storm.Steepness, density: storm.ParticleDensity, theme: storm.Theme},
+		{name: "unknown preset", preset: "doldrums",
+			waves: def.WaveConfig.WaveCount, steepness: def.WaveConfig.Steepness, density: def.WaveConfig.ParticleDensity, theme: def.Theme},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Preset = tt.preset
+			if tt.flags != nil {
+				tt.flags(&cfg)
+			}
+			cfg.ApplyPreset()
+			w := cfg.WaveConfig
+			if w.WaveCount != tt.waves || w.Components != nil && len(w.Components) != tt.waves {
+				t.Errorf("%d waves (%d components), want %d", w.WaveCount, len(w.Components), tt.waves)
+			}
+			if w.Steepness != tt.steepness || w.ParticleDensity != tt.density || cfg.Theme != tt.theme {
+				t.Errorf("steepness %v, density %v, theme %q; want %v, %v, %q",
+					w.Steepness, w.ParticleDensity, cfg.Theme, tt.steepness, tt.density, tt.theme)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package app
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// Random is the scene, theme or preset that stands for one picked at random
+// each launch, see ApplyRandom.
+const Random = "random"
+
+// ApplyRandom replaces a scene, theme or preset set to Random with one
+// picked by Seed. A zero Seed is first set to seed, which main takes from
+// the clock once per launch, so reloading the configuration keeps the
+// picks and Seed tells how to pick the same again. The heatmap only shows
+// data it is given, so it is left out without a -heatmap-source.
+func (c *Config) ApplyRandom(seed int64) {
+	if c.Seed == 0 {
+		c.Seed = seed
+	}
+	// Each pick draws once, in a fixed order, so the scene a seed picks
+	// does not depend on whether the theme is picked too
+	rng := rand.New(rand.NewSource(c.Seed))
+	scenes := slices.DeleteFunc(slices.Clone(sceneNames), func(name string) bool {
+		return name == "heatmap" && c.HeatmapSource == ""
+	})
+	scene := scenes[rng.Intn(len(scenes))]
+	themes := theme.Names()
+	th := themes[rng.Intn(len(themes))]
+	presets := wave.PresetNames()
+	preset := presets[rng.Intn(len(presets))]
+
+	if c.Scene == Random {
+		c.Scene = scene
+	}
+	if c.Theme == Random {
+		c.Theme = th
+	}
+	if c.Preset == Random {
+		c.Preset = preset
+	}
+}
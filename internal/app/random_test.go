@@ -0,0 +1,81 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+func TestApplyRandom(t *testing.T) {
+	tests := []struct {
+		name                 string
+		scene, theme, preset string
+		seed                 int64 // Config.Seed before picking
+	}{
+		{name: "all random", scene: Random, theme: Random, preset: Random},
+		{name: "scene only", scene: Random, theme: "ocean", preset: "calm"},
+		{name: "theme and preset", scene: "ocean", theme: Random, preset: Random},
+		{name: "nothing random", scene: "matrix", theme: "ocean", preset: "calm"},
+		{name: "seed given", scene: Random, theme: Random, preset: Random, seed: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pick := func(seed int64) Config {
+				cfg := DefaultConfig()
+				cfg.Scene, cfg.Theme, cfg.Preset, cfg.Seed = tt.scene, tt.theme, tt.preset, tt.seed
+				cfg.ApplyRandom(seed)
+				return cfg
+			}
+			cfg := pick(42)
+			wantSeed := tt.seed
+			if wantSeed == 0 {
+				wantSeed = 42
+			}
+			if cfg.Seed != wantSeed {
+				t.Errorf("Seed = %d, want %d", cfg.Seed, wantSeed)
+			}
+			if again := pick(42); again.Scene != cfg.Scene || again.Theme != cfg.Theme || again.Preset != cfg.Preset {
+				t.Errorf("the same seed picked %s/%s/%s, then %s/%s/%s",
+					cfg.Scene, cfg.Theme, cfg.Preset, again.Scene, again.Theme, again.Preset)
+			}
+			check := func(setting, got, given string, names []string) {
+				switch {
+				case given != Random && got != given:
+					t.Errorf("%s %q changed to %q", setting, given, got)
+				case given == Random && !slices.Contains(names, got):
+					t.Errorf("picked unknown %s %q", setting, got)
+				}
+			}
+			check("scene", cfg.Scene, tt.scene, sceneNames)
+			check("theme", cfg.Theme, tt.theme, theme.Names())
+			check("preset", cfg.Preset, tt.preset, wave.PresetNames())
+		})
+	}
+}
+
+func TestApplyRandomIndependentPicks(t *testing.T) {
+	// The scene a seed picks does not depend on whether the theme is picked
+	for seed := range int64(20) {
+		a, b := DefaultConfig(), DefaultConfig()
+		a.Scene, b.Scene = Random, Random
+		b.Theme = Random
+		a.ApplyRandom(seed + 1)
+		b.ApplyRandom(seed + 1)
+		if a.Scene != b.Scene {
+			t.Errorf("seed %d picked %q alone but %q with a random theme", seed+1, a.Scene, b.Scene)
+		}
+	}
+}
+
+func TestApplyRandomHeatmap(t *testing.T) {
+	for seed := range int64(200) {
+		cfg := DefaultConfig()
+		cfg.Scene = Random
+		cfg.ApplyRandom(seed + 1)
+		if cfg.Scene == "heatmap" {
+			t.Fatalf("seed %d picked the heatmap without a source", seed+1)
+		}
+	}
+}
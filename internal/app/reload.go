@@ -0,0 +1,209 @@
+package app
+
+import (
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// configWatchInterval is how often the configuration file is checked for
+// changes.
+const configWatchInterval = time.Second
+
+// configWatcher notices edits to the configuration file by its
+// modification time. Polling once a second costs a stat call and, unlike
+// watching the directory, survives editors that replace the file.
+type configWatcher struct {
+	path string
+	mod  time.Time
+	next time.Time
+}
+
+// newConfigWatcher starts watching the configuration file, returning nil if
+// there is no config directory to find it in.
+func newConfigWatcher() *configWatcher {
+	path, err := configPath()
+	if err != nil {
+		return nil
+	}
+	w := &configWatcher{path: path}
+	w.mod = w.modTime()
+	return w
+}
+
+// modTime returns the modification time of the file, zero while it is missing.
+func (w *configWatcher) modTime() time.Time {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// changed reports whether the file was written, created or removed since
+// the last check, checking only when due.
+func (w *configWatcher) changed(now time.Time) bool {
+	if now.Before(w.next) {
+		return false
+	}
+	w.next = now.Add(configWatchInterval)
+	mod := w.modTime()
+	if mod.Equal(w.mod) {
+		return false
+	}
+	w.mod = mod
+	return true
+}
+
+// reloads reports whether the configuration follows its files while
+// running. Recorded and replayed sessions must play out identically and
+// guests run on the server's configuration, so they keep theirs.
+func (a *App) reloads() bool {
+	return a.config.Reload != nil && a.config.Replay == nil && a.config.Record == "" && !a.config.Guest
+}
+
+// reload reads the configuration again and applies what can change without
+// restarting: timing, colors, shading, layers, the render mode, beats,
+// holidays, the overlays, the dashboard pages, the transitions between
+// scenes and the scene settings. Outputs, inputs and other startup
+// settings stay as they are. An invalid configuration is ignored, keeping
+// the running one, and returned for the caller to show.
+func (a *App) reload() error {
+	cfg, err := a.config.Reload()
+	if err != nil {
+		return err
+	}
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	if a.config.EInk != nil {
+		cfg.FrameDelay = max(cfg.FrameDelay, einkFrameDelay)
+	}
+
+	// New pages start over from the first, and the page showing sets the scene
+	pagesChanged := !reflect.DeepEqual(cfg.Pages, a.config.Pages)
+	if pagesChanged {
+		a.page, a.pageStart = 0, time.Time{}
+	}
+	cfg.Scene = cfg.onPage(a.page).Scene
+	if cfg.Scene != a.config.Scene || !reflect.DeepEqual(sceneConfigs(cfg), sceneConfigs(a.config)) {
+		next := a.config
+		next.Scene = cfg.Scene
+		setSceneConfigs(&next, cfg)
+		sc, err := newScene(next)
+		if err != nil {
+			return invalidConfig(err)
+		}
+		a.attach(sc)
+		a.replaceScene(sc)
+		a.config.Scene = next.Scene
+		setSceneConfigs(&a.config, cfg)
+	}
+	if cfg.Theme != a.config.Theme {
+		if err := a.switchTheme(cfg.Theme); err != nil {
+			return invalidConfig(err)
+		}
+	}
+	if cfg.Ticker != a.config.Ticker || cfg.TickerSpeed != a.config.TickerSpeed || cfg.Logo != a.config.Logo ||
+		cfg.LogoWidth != a.config.LogoWidth || cfg.LogoOpacity != a.config.LogoOpacity || cfg.LogoDrift != a.config.LogoDrift ||
+		!reflect.DeepEqual(cfg.Placements, a.config.Placements) || pagesChanged {
+		a.overlays = newOverlays(cfg.onPage(a.page), a.weather, a.runningPlugins())
+	}
+	if cfg.FrameDelay != a.config.FrameDelay {
+		a.pacer.Reset(cfg.FrameDelay)
+	}
+
+	a.renderer.SetAdjustment(cfg.Color)
+	a.renderer.SetCellAspect(cfg.CellAspect)
+	a.renderer.SetColors(colorDepth(cfg))
+	a.renderer.SetLinearBlending(cfg.LinearBlending)
+	a.renderer.SetToneMap(toneMap(cfg))
+	a.renderer.SetMode(renderMode(cfg, a.screen))
+	a.renderer.SetTemporalDither(cfg.TemporalDither)
+	a.renderer.SetShadeDither(shadeDither(cfg))
+	a.renderer.SetShadeRamp(cfg.ShadeRamp)
+	for _, l := range []renderer.Layer{renderer.LayerSky, renderer.LayerScene, renderer.LayerParticles, renderer.LayerOverlay} {
+		style, ok := cfg.Layers[l]
+		if !ok {
+			style = l.DefaultStyle()
+		}
+		a.renderer.SetLayerStyle(l, style)
+	}
+
+	a.config.FrameDelay = cfg.FrameDelay
+	a.config.Theme = cfg.Theme
+	a.config.Layers = cfg.Layers
+	a.config.Color = cfg.Color
+	if cfg.Location != a.config.Location || cfg.Temperature != a.config.Temperature {
+		a.config.Temperature = cfg.Temperature
+		a.config.Location = cfg.Location
+		a.locate()
+	}
+	a.config.CellAspect = cfg.CellAspect
+	a.config.ColorDepth = cfg.ColorDepth
+	a.config.LinearBlending = cfg.LinearBlending
+	a.config.ToneMap = cfg.ToneMap
+	a.config.RenderMode = cfg.RenderMode
+	a.config.TemporalDither = cfg.TemporalDither
+	a.config.ShadeDither = cfg.ShadeDither
+	a.config.ShadeRamp = cfg.ShadeRamp
+	if cfg.Beat != a.config.Beat {
+		a.beats = nil
+	}
+	a.config.Beat = cfg.Beat
+	a.config.Kaleidoscope = cfg.Kaleidoscope
+	a.config.Holiday = cfg.Holiday
+	a.config.Holidays = cfg.Holidays
+	a.config.HideOverlays = cfg.HideOverlays
+	a.config.Ticker = cfg.Ticker
+	a.config.TickerSpeed = cfg.TickerSpeed
+	a.config.Logo = cfg.Logo
+	a.config.LogoWidth = cfg.LogoWidth
+	a.config.LogoOpacity = cfg.LogoOpacity
+	a.config.LogoDrift = cfg.LogoDrift
+	a.config.Placements = cfg.Placements
+	a.config.Pages = cfg.Pages
+	a.config.PageTime = cfg.PageTime
+	a.config.Transition = cfg.Transition
+	a.config.TransitionTime = cfg.TransitionTime
+	return nil
+}
+
+// sceneConfigs lists the settings of every scene, for comparison.
+func sceneConfigs(cfg Config) []any {
+	return []any{
+		cfg.WaveConfig, cfg.PendulumConfig, cfg.GalaxyConfig, cfg.ReactionConfig,
+		cfg.PlantsConfig, cfg.KaleidoConfig, cfg.HeatmapConfig, cfg.LifeConfig,
+		cfg.StarfieldConfig, cfg.MatrixConfig, cfg.PlasmaConfig, cfg.FireConfig,
+		cfg.PipesConfig, cfg.SnowConfig, cfg.RipplesConfig, cfg.AquariumConfig,
+		cfg.LavaConfig, cfg.DNAConfig, cfg.FractalConfig, cfg.BounceConfig,
+		cfg.ClockConfig,
+	}
+}
+
+// setSceneConfigs copies the settings of every scene from src to dst.
+func setSceneConfigs(dst *Config, src Config) {
+	dst.WaveConfig = src.WaveConfig
+	dst.PendulumConfig = src.PendulumConfig
+	dst.GalaxyConfig = src.GalaxyConfig
+	dst.ReactionConfig = src.ReactionConfig
+	dst.PlantsConfig = src.PlantsConfig
+	dst.KaleidoConfig = src.KaleidoConfig
+	dst.HeatmapConfig = src.HeatmapConfig
+	dst.LifeConfig = src.LifeConfig
+	dst.StarfieldConfig = src.StarfieldConfig
+	dst.MatrixConfig = src.MatrixConfig
+	dst.PlasmaConfig = src.PlasmaConfig
+	dst.FireConfig = src.FireConfig
+	dst.PipesConfig = src.PipesConfig
+	dst.SnowConfig = src.SnowConfig
+	dst.RipplesConfig = src.RipplesConfig
+	dst.AquariumConfig = src.AquariumConfig
+	dst.LavaConfig = src.LavaConfig
+	dst.DNAConfig = src.DNAConfig
+	dst.FractalConfig = src.FractalConfig
+	dst.BounceConfig = src.BounceConfig
+	dst.ClockConfig = src.ClockConfig
+}
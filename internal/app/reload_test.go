@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/timing"
+)
+
+// newReloadApp returns an app running cfg on a simulated screen, reloading
+// whatever reloaded returns.
+func newReloadApp(t *testing.T, cfg Config, reloaded func(Config) (Config, error)) *App {
+	t.Helper()
+	screen, r, err := newSnapshotScreen(cfg, 40, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(screen.Fini)
+	sc, err := newScene(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := cfg
+	cfg.Reload = func() (Config, error) { return reloaded(base) }
+	a := &App{config: cfg, screen: screen, renderer: r, scene: sc, overlays: newOverlays(cfg.onPage(0), nil, nil), pacer: timing.NewPacer(cfg.FrameDelay)}
+	t.Cleanup(a.pacer.Stop)
+	return a
+}
+
+func TestReload(t *testing.T) {
+	otherTheme := theme.Names()[0]
+	if otherTheme == DefaultConfig().Theme {
+		otherTheme = theme.Names()[1]
+	}
+	tests := []struct {
+		name     string
+		reloaded func(Config) (Config, error)
+		wantErr  string
+		newScene bool
+		check    func(t *testing.T, cfg Config)
+	}{
+		{
+			name: "theme and timing",
+			reloaded: func(cfg Config) (Config, error) {
+				cfg.Theme = otherTheme
+				cfg.FrameDelay = 50 * time.Millisecond
+				return cfg, nil
+			},
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Theme != otherTheme || cfg.FrameDelay != 50*time.Millisecond {
+					t.Errorf("theme %q and frame delay %v, want %q and 50ms", cfg.Theme, cfg.FrameDelay, otherTheme)
+				}
+			},
+		},
+		{
+			name: "scene settings",
+			reloaded: func(cfg Config) (Config, error) {
+				cfg.WaveConfig.Steepness = 1.5
+				return cfg, nil
+			},
+			newScene: true,
+			check: func(t *testing.T, cfg Config) {
+				if cfg.WaveConfig.Steepness != 1.5 {
+					t.Errorf("steepness %v, want 1.5", cfg.WaveConfig.Steepness)
+				}
+			},
+		},
+		{
+			name: "startup settings stay",
+			reloaded: func(cfg Config) (Config, error) {
+				cfg.Control = !cfg.Control
+				cfg.Takeover = !cfg.Takeover
+				return cfg, nil
+			},
+			check: func(t *testing.T, cfg Config) {
+				if want := DefaultConfig(); cfg.Control != want.Control || cfg.Takeover != want.Takeover {
+					t.Error("startup settings changed on reload")
+				}
+			},
+		},
+		{
+			name: "invalid configuration",
+			reloaded: func(cfg Config) (Config, error) {
+				cfg.Theme = "no-such-theme"
+				return cfg, nil
+			},
+			wantErr: "no-such-theme",
+			check: func(t *testing.T, cfg Config) {
+				if cfg.Theme != DefaultConfig().Theme {
+					t.Errorf("theme %q kept from an invalid configuration", cfg.Theme)
+				}
+			},
+		},
+		{
+			name: "unreadable configuration",
+			reloaded: func(Config) (Config, error) {
+				return Config{}, errors.New("config.toml:3: expected a value")
+			},
+			wantErr: "expected a value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newReloadApp(t, DefaultConfig(), tt.reloaded)
+			before := a.scene
+			err := a.reload()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("reload: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("reload error %v, want one mentioning %q", err, tt.wantErr)
+			}
+			if replaced := a.scene != before; replaced != tt.newScene {
+				t.Errorf("scene replaced %v, want %v", replaced, tt.newScene)
+			}
+			if tt.check != nil {
+				tt.check(t, a.config)
+			}
+		})
+	}
+}
+
+func TestSceneConfigs(t *testing.T) {
+	// Every scene's settings take part, so a change to any restarts the scene
+	cfg := DefaultConfig()
+	configs := sceneConfigs(cfg)
+	typ := reflect.TypeOf(cfg)
+	n := 0
+	for i := range typ.NumField() {
+		if f := typ.Field(i); strings.HasSuffix(f.Name, "Config") && f.Type.Kind() == reflect.Struct {
+			n++
+		}
+	}
+	if n != len(configs) {
+		t.Errorf("sceneConfigs lists %d settings, Config has %d scene configs", len(configs), n)
+	}
+
+	// setSceneConfigs copies every one of them
+	var dst Config
+	setSceneConfigs(&dst, cfg)
+	if !reflect.DeepEqual(sceneConfigs(dst), configs) {
+		t.Error("setSceneConfigs leaves scene settings behind")
+	}
+	dst.WaveConfig.Steepness++
+	if reflect.DeepEqual(sceneConfigs(dst), configs) {
+		t.Error("sceneConfigs does not tell a changed setting apart")
+	}
+}
+
+func TestConfigWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), configFile)
+	w := &configWatcher{path: path}
+	w.mod = w.modTime()
+	now := time.Now()
+	if w.changed(now) {
+		t.Error("missing file reported as changed")
+	}
+
+	if err := os.WriteFile(path, []byte("theme = \"ocean\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if w.changed(now.Add(configWatchInterval / 2)) {
+		t.Error("checked again before the interval was up")
+	}
+	now = now.Add(configWatchInterval)
+	if !w.changed(now) {
+		t.Error("created file not reported")
+	}
+	now = now.Add(configWatchInterval)
+	if w.changed(now) {
+		t.Error("unchanged file reported again")
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(configWatchInterval)
+	if !w.changed(now) {
+		t.Error("rewritten file not reported")
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(configWatchInterval)
+	if !w.changed(now) {
+		t.Error("removed file not reported")
+	}
+}
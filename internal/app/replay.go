@@ -0,0 +1,140 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/replay"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// LoadReplay replaces cfg with the configuration recorded in a replay file
+// and arranges for New to play the recorded input back. Only the takeover
+// and window choices of the current invocation are kept.
+func LoadReplay(path string, cfg *Config) error {
+	player, err := replay.Open(path)
+	if err != nil {
+		return invalidConfig(err)
+	}
+
+	recorded := DefaultConfig()
+	if err := json.Unmarshal(player.Header.Config, &recorded); err != nil {
+		return invalidConfig(fmt.Errorf("%s: reading config: %w", path, err))
+	}
+	// The recorded theme may be a custom one this machine does not have
+	if t := player.Header.Theme; t.Name != "" {
+		if _, ok := theme.Lookup(t.Name); !ok {
+			if err := theme.Register(t); err != nil {
+				return invalidConfig(fmt.Errorf("%s: %w", path, err))
+			}
+		}
+	}
+
+	recorded.Takeover = cfg.Takeover
+	recorded.Window = cfg.Window
+	recorded.Fullscreen = cfg.Fullscreen
+	recorded.Framebuffer = cfg.Framebuffer
+	recorded.Images = cfg.Images
+	recorded.EInk = cfg.EInk
+	recorded.LED = cfg.LED
+	// The intro depends on the terminal text and outside commands would
+	// change the outcome, so neither takes part in a replay
+	recorded.Intro = ""
+	recorded.Control = false
+	recorded.SeaState = cfg.SeaState
+	recorded.Replay = player
+	recorded.sourceFile = path
+	*cfg = recorded
+	return nil
+}
+
+// startRecording creates the replay file for the session and records the
+// starting configuration.
+func (a *App) startRecording(start time.Time) error {
+	cfg := a.config
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	th, _ := theme.Lookup(cfg.Theme)
+	w, h := a.screen.Size()
+	rec, err := replay.Create(cfg.Record, replay.Header{
+		Start:  start,
+		Width:  w,
+		Height: h,
+		Config: data,
+		Theme:  th,
+	})
+	if err != nil {
+		return err
+	}
+	a.recorder = rec
+	a.closers = append(a.closers, func() { rec.Close() })
+	return nil
+}
+
+// record appends an event to the replay file, if the session is recorded.
+// Recording is best effort and never interrupts the animation.
+func (a *App) record(e replay.Event) {
+	if a.recorder != nil {
+		_ = a.recorder.Record(e)
+	}
+}
+
+// recordEvent records a terminal input event delivered at the given frame.
+func (a *App) recordEvent(frame int, ev tcell.Event) {
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		a.record(replay.Event{
+			Frame: frame,
+			Kind:  replay.KindKey,
+			Key:   int16(ev.Key()),
+			Rune:  ev.Rune(),
+			Mod:   int16(ev.Modifiers()),
+		})
+	case *tcell.EventResize:
+		w, h := ev.Size()
+		a.record(replay.Event{Frame: frame, Kind: replay.KindResize, Width: w, Height: h})
+	}
+}
+
+// replayFrame delivers the events recorded for a frame, shown at now, and
+// reports whether the recorded session quit at this point.
+func (a *App) replayFrame(frame int, now time.Time) bool {
+	quit := false
+	for _, e := range a.config.Replay.Frame(frame) {
+		switch e.Kind {
+		case replay.KindKey:
+			ev := tcell.NewEventKey(tcell.Key(e.Key), e.Rune, tcell.ModMask(e.Mod))
+			quit = a.handleEvent(ev) || quit
+		case replay.KindCommand:
+			exit, err := a.execute(e.Command)
+			if err != nil {
+				a.notify(now, err)
+			}
+			quit = exit || quit
+		case replay.KindQuit:
+			quit = true
+		}
+		// Resizes are informational: the output follows the current terminal
+	}
+	return quit
+}
+
+// replayInput filters live input while a replay is playing: only the quit
+// keys and resizes get through, so the recording plays undisturbed.
+func replayInput(ev tcell.Event) bool {
+	switch ev := ev.(type) {
+	case *tcell.EventKey:
+		switch ev.Key() {
+		case tcell.KeyEscape, tcell.KeyCtrlC:
+			return true
+		case tcell.KeyRune:
+			return ev.Rune() == 'q' || ev.Rune() == 'Q'
+		}
+		return false
+	}
+	return true
+}
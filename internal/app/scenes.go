@@ -0,0 +1,278 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/scenes/aquarium"
+	"github.com/olegchuev/screensaver/internal/scenes/bounce"
+	"github.com/olegchuev/screensaver/internal/scenes/clock"
+	"github.com/olegchuev/screensaver/internal/scenes/dna"
+	"github.com/olegchuev/screensaver/internal/scenes/fire"
+	"github.com/olegchuev/screensaver/internal/scenes/fractal"
+	"github.com/olegchuev/screensaver/internal/scenes/galaxy"
+	"github.com/olegchuev/screensaver/internal/scenes/heatmap"
+	"github.com/olegchuev/screensaver/internal/scenes/kaleidoscope"
+	"github.com/olegchuev/screensaver/internal/scenes/lava"
+	"github.com/olegchuev/screensaver/internal/scenes/life"
+	"github.com/olegchuev/screensaver/internal/scenes/matrix"
+	"github.com/olegchuev/screensaver/internal/scenes/pendulum"
+	"github.com/olegchuev/screensaver/internal/scenes/pipes"
+	"github.com/olegchuev/screensaver/internal/scenes/plants"
+	"github.com/olegchuev/screensaver/internal/scenes/plasma"
+	"github.com/olegchuev/screensaver/internal/scenes/reaction"
+	"github.com/olegchuev/screensaver/internal/scenes/ripples"
+	"github.com/olegchuev/screensaver/internal/scenes/snow"
+	"github.com/olegchuev/screensaver/internal/scenes/starfield"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// SceneInfo describes a scene for listings and completions.
+type SceneInfo struct {
+	Name        string
+	Description string
+	// Options lists the flags and keys that only affect this scene
+	Options []string
+}
+
+// sceneEntry is a scene newScene can create.
+type sceneEntry struct {
+	SceneInfo
+	create func(cfg Config) scene
+}
+
+// sceneRegistry lists every scene in menu order.
+var sceneRegistry = []sceneEntry{
+	{
+		SceneInfo: SceneInfo{
+			Name:        "ocean",
+			Description: "Gerstner wave ocean surface with foam particles",
+			Options: []string{
+				"-preset calm|choppy|storm|tsunami picks a curated sea and theme",
+				"-steepness N sharpens or flattens the waves",
+				"-wave-count N combines fewer or more waves",
+				"-density N sets the amount of spray",
+				"-shadows N darkens the water the crests shade from the sun",
+				"-render-mode braille|halfblock|quadrant draws the surface in finer detail",
+				"-audio FILE swells and surges the waves with music",
+				"-mic sends gusts when blowing on the microphone",
+			},
+		},
+		create: func(cfg Config) scene { return &oceanScene{wave: wave.NewWave(cfg.WaveConfig)} },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "pendulum",
+			Description: "Double pendulums with nearly identical starts drifting apart, with fading trails",
+		},
+		create: func(cfg Config) scene { return pendulum.NewScene(cfg.PendulumConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "galaxy",
+			Description: "Barnes-Hut N-body simulation of a spiral galaxy, brightness from star density",
+		},
+		create: func(cfg Config) scene { return galaxy.NewScene(cfg.GalaxyConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "reaction",
+			Description: "Gray-Scott reaction-diffusion patterns",
+			Options:     reactionOptions(),
+		},
+		create: func(cfg Config) scene { return reaction.NewScene(cfg.ReactionConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "plants",
+			Description: "L-system plants growing, swaying in the wind and regrowing each season",
+		},
+		create: func(cfg Config) scene { return plants.NewScene(cfg.PlantsConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "kaleidoscope",
+			Description: "Drifting noise mirrored into eight-fold symmetry",
+		},
+		create: func(cfg Config) scene { return kaleidoscope.NewScene(cfg.KaleidoConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "life",
+			Description: "Conway's Game of Life on a turning torus or sphere",
+			Options:     []string{"-life-surface torus|sphere picks the shape", "s switches the shape", "r reseeds the board"},
+		},
+		create: func(cfg Config) scene { return life.NewScene(cfg.LifeConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "starfield",
+			Description: "Flight through a field of stars streaking past the viewer",
+			Options:     []string{"-stars N sets how many stars are in flight", "-star-speed N sets the velocity"},
+		},
+		create: func(cfg Config) scene { return starfield.NewScene(cfg.StarfieldConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "matrix",
+			Description: "Digital rain of green glyphs falling down the screen",
+			Options:     []string{"-rain-speed N sets how fast the glyphs fall", "-rain-density N sets how many columns rain"},
+		},
+		create: func(cfg Config) scene { return matrix.NewScene(cfg.MatrixConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "plasma",
+			Description: "Classic plasma of sine waves flowing through each other",
+			Options:     []string{"-plasma-palette smooth|cycle|bands sets how the theme colors are laid on", "-turbulence N warps the waves", "-plasma-speed N sets how fast it flows"},
+		},
+		create: func(cfg Config) scene { return plasma.NewScene(cfg.PlasmaConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "fire",
+			Description: "Flames rising and flickering from the bottom of the screen",
+			Options:     []string{"-fire-intensity N sets how high the flames reach", "-fire-wind N leans them left or right"},
+		},
+		create: func(cfg Config) scene { return fire.NewScene(cfg.FireConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "pipes",
+			Description: "3D pipes growing through a turning grid with random bends and colors",
+			Options:     []string{"-pipe-grid N sets the voxels along each edge", "-pipe-speed N sets how fast they grow", "-pipe-turns N sets how often they bend", "a controller or drag turns the view"},
+		},
+		create: func(cfg Config) scene { return pipes.NewScene(cfg.PipesConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "snow",
+			Description: "Snow falling in gusts of wind, piling up along the bottom and slowly melting",
+			Options:     []string{"-snow-density N sets how thick it falls", "-snow-wind N blows it sideways", "-snow-melt N sets how fast it melts"},
+		},
+		create: func(cfg Config) scene { return snow.NewScene(cfg.SnowConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "ripples",
+			Description: "Rain falling on still water, every drop spreading rings of ripples",
+			Options:     []string{"-rain N sets how hard it rains", "-ripple-damping N sets how long ripples last"},
+		},
+		create: func(cfg Config) scene { return ripples.NewScene(cfg.RipplesConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "aquarium",
+			Description: "Fish of all sizes swimming through a tank among seaweed and bubbles",
+			Options:     []string{"-fish N sets how many fish swim", "-fish-speed N sets how fast they swim"},
+		},
+		create: func(cfg Config) scene { return aquarium.NewScene(cfg.AquariumConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "lava",
+			Description: "A lava lamp of warm wax blobs rising, merging and sinking again",
+			Options:     []string{"-lava-blobs N sets how many blobs float", "-lava-speed N sets how fast they move", "-lava-palette lava|amber|magenta|theme colors the wax"},
+		},
+		create: func(cfg Config) scene { return lava.NewScene(cfg.LavaConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "dna",
+			Description: "A DNA double helix turning in 3D with its base pairs",
+			Options:     []string{"-dna-speed N sets how fast it turns", "-dna-colors #rrggbb,#rrggbb colors the two strands"},
+		},
+		create: func(cfg Config) scene { return dna.NewScene(cfg.DNAConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "fractal",
+			Description: "An endless zoom into the Mandelbrot set or a Julia set",
+			Options:     []string{"-fractal-set mandelbrot|julia picks the set", "-fractal-speed N sets how fast it zooms", "-fractal-depth N sets the magnification a zoom ends at, as a power of ten"},
+		},
+		create: func(cfg Config) scene { return fractal.NewScene(cfg.FractalConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "bounce",
+			Description: "A logo bouncing off the edges of the screen, changing color at every hit",
+			Options:     []string{"-bounce-text TEXT or -bounce-file PATH sets the logo", "-bounce-speed N sets how fast it moves"},
+		},
+		create: func(cfg Config) scene { return bounce.NewScene(cfg.BounceConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "clock",
+			Description: "A large digital or analog clock, optionally floating on waves",
+			Options: []string{
+				"-clock-style digital|analog picks the clock",
+				"-clock-12h counts the hours from 1 to 12 with AM and PM",
+				"-clock-seconds=false hides the seconds",
+				"-clock-zone NAME shows the time in another time zone",
+				"-clock-waves floats the clock on waves",
+			},
+		},
+		create: func(cfg Config) scene { return clock.NewScene(cfg.ClockConfig) },
+	},
+	{
+		SceneInfo: SceneInfo{
+			Name:        "heatmap",
+			Description: "Matrix of numbers from the control pipe or standard input as a smooth heat map",
+			Options:     []string{"-heatmap-range MIN:MAX fixes the values at the ends of the gradient"},
+		},
+		create: func(cfg Config) scene { return heatmap.NewScene(cfg.HeatmapConfig) },
+	},
+}
+
+// sceneNames lists every scene newScene can create, in menu order.
+var sceneNames = func() []string {
+	names := make([]string, len(sceneRegistry))
+	for i, e := range sceneRegistry {
+		names[i] = e.Name
+	}
+	return names
+}()
+
+// reactionOptions describes the preset keys from the presets the scene has.
+func reactionOptions() []string {
+	var names []string
+	for _, p := range reaction.Presets {
+		names = append(names, p.Name)
+	}
+	return []string{
+		fmt.Sprintf("p cycles the presets (%s)", strings.Join(names, ", ")),
+		fmt.Sprintf("1-%d pick a preset", len(names)),
+	}
+}
+
+// Scenes returns every scene in menu order.
+func Scenes() []SceneInfo {
+	infos := make([]SceneInfo, len(sceneRegistry))
+	for i, e := range sceneRegistry {
+		infos[i] = e.SceneInfo
+	}
+	return infos
+}
+
+// SceneNames returns the names of every scene, in menu order.
+func SceneNames() []string {
+	return slices.Clone(sceneNames)
+}
+
+// newScene creates the scene with the given name from the configuration.
+func newScene(cfg Config) (scene, error) {
+	name := cfg.Scene
+	if name == "" {
+		name = sceneNames[0]
+	}
+	if name, ok := pluginName(name); ok {
+		return &pluginScene{name: name}, nil
+	}
+	for _, e := range sceneRegistry {
+		if e.Name == name {
+			return e.create(cfg), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown scene %q (available: %s)", cfg.Scene, strings.Join(sceneNames, ", "))
+}
@@ -0,0 +1,77 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// resizedScenes are the scenes checked against odd screen sizes.
+var resizedScenes = []string{"starfield", "matrix", "plasma", "fire", "pipes", "snow", "ripples", "aquarium", "lava", "dna", "fractal", "bounce", "clock"}
+
+// renderSizes runs the scene of cfg for frames frames at each size in turn,
+// resizing the screen in between, and returns the cells of the last frame.
+func renderSizes(t *testing.T, cfg Config, frames int, sizes ...[2]int) [][]snapshotCell {
+	t.Helper()
+	screen, r, err := newSnapshotScreen(cfg, sizes[0][0], sizes[0][1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	sc, err := newScene(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{config: cfg, screen: screen, renderer: r, scene: sc, overlays: newOverlays(cfg, nil, nil)}
+	frame := 0
+	for _, size := range sizes {
+		screen.SetSize(size[0], size[1])
+		r.Resize()
+		for range frames {
+			t := float64(frame) * cfg.FrameDelay.Seconds()
+			a.tell(snapshotNoon.Add(time.Duration(frame) * cfg.FrameDelay))
+			a.update(t)
+			a.render(t, t)
+			frame++
+		}
+	}
+	return screenGrid(screen)
+}
+
+func TestScenesResize(t *testing.T) {
+	tests := [][][2]int{
+		{{1, 1}},
+		{{0, 0}},
+		{{40, 12}, {1, 1}, {0, 0}, {40, 12}},
+		{{3, 2}, {120, 40}},
+		{{120, 40}, {2, 3}},
+	}
+	for _, name := range resizedScenes {
+		for _, sizes := range tests {
+			t.Run(fmt.Sprint(name, sizes), func(t *testing.T) {
+				cfg := DefaultConfig()
+				cfg.Scene = name
+				last := sizes[len(sizes)-1]
+				grid := renderSizes(t, cfg, 5, sizes...)
+				if len(grid) != last[1] || len(grid) > 0 && len(grid[0]) != last[0] {
+					t.Errorf("drew %d rows, want %dx%d", len(grid), last[0], last[1])
+				}
+			})
+		}
+	}
+}
+
+func TestScenesDeterministic(t *testing.T) {
+	for _, name := range resizedScenes {
+		t.Run(name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Scene = name
+			first := renderSizes(t, cfg, 20, [2]int{60, 20}, [2]int{30, 10})
+			second := renderSizes(t, cfg, 20, [2]int{60, 20}, [2]int{30, 10})
+			if !reflect.DeepEqual(first, second) {
+				t.Error("two runs of the same configuration drew different frames")
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// seaStateInterval is how often the sea state file is rewritten.
+const seaStateInterval = time.Second
+
+// seaStater is implemented by scenes simulating an ocean.
+type seaStater interface {
+	SeaState() wave.SeaState
+}
+
+// seaStateReport is the content of the sea state file.
+type seaStateReport struct {
+	Scene string    `json:"scene"`
+	Time  time.Time `json:"time"`
+	wave.SeaState
+}
+
+// seaStateFile publishes the sea state of the running scene for scripts
+// and automations, next to the control pipe. Each instance writes its own
+// file, named by its process ID. The file is replaced whole, so readers
+// never see half of it, and removed while the scene has no sea.
+type seaStateFile struct {
+	path string
+	next time.Time
+}
+
+// seaStatePath returns the location of the sea state file of the process
+// pid in the user cache directory.
+func seaStatePath(pid int) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", fmt.Sprintf("sea-state-%d.json", pid)), nil
+}
+
+// newSeaStateFile prepares the sea state file, returning nil if there is
+// no cache directory to put it in.
+func newSeaStateFile() *seaStateFile {
+	path, err := seaStatePath(os.Getpid())
+	if err != nil || os.MkdirAll(filepath.Dir(path), 0o700) != nil {
+		return nil
+	}
+	return &seaStateFile{path: path}
+}
+
+// update rewrites the file with the state of sc when it is due.
+func (f *seaStateFile) update(sc scene, name string, now time.Time) {
+	if now.Before(f.next) {
+		return
+	}
+	f.next = now.Add(seaStateInterval)
+	s, ok := sc.(seaStater)
+	if !ok {
+		os.Remove(f.path)
+		return
+	}
+	data, err := json.MarshalIndent(seaStateReport{Scene: name, Time: now.Truncate(time.Second), SeaState: s.SeaState()}, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := f.path + ".tmp"
+	if os.WriteFile(tmp, append(data, '\n'), 0o600) == nil {
+		os.Rename(tmp, f.path)
+	}
+}
+
+// close removes the file, since nothing keeps it current anymore.
+func (f *seaStateFile) close() {
+	os.Remove(f.path)
+}
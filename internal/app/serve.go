@@ -0,0 +1,366 @@
+package app
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/telnet"
+	"github.com/olegchuev/screensaver/internal/weather"
+)
+
+// ServeOptions configures Serve.
+type ServeOptions struct {
+	// Addr is the TCP address telnet clients connect to, e.g. ":2323"
+	Addr string
+	// Stream sets the color depth and encoding of every session, and the
+	// size used until a client reports its own
+	Stream StreamOptions
+	// Allow lists the networks clients may connect from, nil allows any
+	Allow []*net.IPNet
+	// Password is asked for before a session starts, empty disables the prompt
+	Password string
+	// MaxSessions limits how many clients are served at once
+	MaxSessions int
+	// MaxFPS caps the frame rate of every session
+	MaxFPS int
+	// MaxSize is the largest screen in cells a client gets, whatever size
+	// its window reports; it bounds the memory and work of a session
+	MaxSize image.Point
+	// IdleTimeout ends sessions without a key press for this long, 0 disables
+	IdleTimeout time.Duration
+	// Log receives a line when clients connect and leave, nil discards them
+	Log *log.Logger
+}
+
+// Clients get this many tries and this long to enter the password.
+const (
+	loginAttempts = 3
+	loginTimeout  = 30 * time.Second
+)
+
+// At most this many clients wait at the password prompt at once, besides
+// the running sessions.
+const maxLogins = 32
+
+// MaxPassword is the longest password, in characters. Typing at the prompt
+// past it is counted but not kept, so a client cannot grow the line
+// without bound, and is never the password.
+const MaxPassword = 256
+
+// errLogin is returned for clients that did not enter the password.
+var errLogin = errors.New("wrong password")
+
+// errFull is returned for clients that got in while every session was
+// taken.
+var errFull = errors.New("server full")
+
+// ParseAllow parses a comma separated list of networks in CIDR notation or
+// single addresses, e.g. "192.168.1.0/24,10.0.0.5".
+func ParseAllow(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if _, n, err := net.ParseCIDR(field); err == nil {
+			nets = append(nets, n)
+			continue
+		}
+		ip := net.ParseIP(field)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid network %q: want an address like 10.0.0.5 or a range like 192.168.1.0/24", field)
+		}
+		bits := 8 * len(ip)
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// allowed reports whether a client at addr may connect.
+func (o ServeOptions) allowed(addr net.Addr) bool {
+	if o.Allow == nil {
+		return true
+	}
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, n := range o.Allow {
+		if n.Contains(tcp.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultServeOptions returns options listening on the telnet-like port
+// 2323 of this machine only, with limits that keep a public server
+// responsive.
+func DefaultServeOptions() ServeOptions {
+	return ServeOptions{
+		Addr:        "localhost:2323",
+		Stream:      DefaultStreamOptions(),
+		MaxSessions: 16,
+		MaxFPS:      15,
+		MaxSize:     image.Pt(200, 60),
+		IdleTimeout: 30 * time.Minute,
+	}
+}
+
+// sessionShare is what the sessions of serve share, so a new client does
+// not start fetching the weather or looking up the place of its own.
+type sessionShare struct {
+	weather  *forecast // Nil without a forecast panel
+	locateIP func() (weather.Location, error)
+}
+
+// Serve accepts telnet clients on opts.Addr and runs a session for each
+// until interrupted. Every session is an app of its own with its own scene
+// instance, so each client switches scenes with Tab and themes with t
+// without affecting the others, while the weather and the place the night
+// light follows are fetched once for all of them. Sessions are guests:
+// nothing they change is saved.
+func Serve(cfg Config, opts ServeOptions) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	switch {
+	case opts.MaxSessions < 1:
+		return invalidConfig(fmt.Errorf("invalid session limit %d: must be at least 1", opts.MaxSessions))
+	case opts.MaxFPS < minFPS || opts.MaxFPS > maxFPS:
+		return invalidConfig(fmt.Errorf("invalid frame rate limit %d: want %d to %d", opts.MaxFPS, minFPS, maxFPS))
+	case opts.MaxSize.X < minWidth || opts.MaxSize.Y < minHeight:
+		return invalidConfig(fmt.Errorf("invalid size limit %dx%d: must be at least %dx%d", opts.MaxSize.X, opts.MaxSize.Y, minWidth, minHeight))
+	case opts.IdleTimeout < 0:
+		return invalidConfig(fmt.Errorf("invalid idle timeout %v: must not be negative", opts.IdleTimeout))
+	}
+	share := &sessionShare{locateIP: sync.OnceValues(lookUpIP)}
+	var err error
+	if share.weather, err = openWeather(cfg); err != nil {
+		return invalidConfig(err)
+	}
+	if share.weather != nil {
+		defer share.weather.Close()
+	}
+	cfg.shared = share
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+	logf := func(format string, args ...any) {
+		if opts.Log != nil {
+			opts.Log.Printf(format, args...)
+		}
+	}
+	logf("serving on %s", ln.Addr())
+
+	// Sessions handle the signal themselves by fading out, the server only
+	// stops accepting new ones and waits for them
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	var interrupted atomic.Bool
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigChan:
+			interrupted.Store(true)
+			ln.Close()
+		case <-done:
+		}
+	}()
+
+	var sessions sync.WaitGroup
+	defer sessions.Wait()
+	// Clients hold a login slot until they are past the password prompt
+	// and a session slot from then on, so those still at the prompt do not
+	// keep others out
+	logins := make(chan struct{}, maxLogins)
+	running := make(chan struct{}, opts.MaxSessions)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if interrupted.Load() {
+				return nil
+			}
+			ln.Close()
+			return err
+		}
+		if !opts.allowed(conn.RemoteAddr()) {
+			logf("%s refused: not in -allow", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		select {
+		case logins <- struct{}{}:
+		default:
+			logf("%s refused: %d clients already logging in", conn.RemoteAddr(), maxLogins)
+			io.WriteString(conn, "The server is busy, try again later.\r\n")
+			conn.Close()
+			continue
+		}
+		sessions.Add(1)
+		go func() {
+			defer sessions.Done()
+			slot := logins
+			defer func() { <-slot }()
+			admit := func() bool {
+				select {
+				case running <- struct{}{}:
+					<-logins
+					slot = running
+					return true
+				default:
+					return false
+				}
+			}
+			addr := conn.RemoteAddr()
+			logf("%s connected", addr)
+			switch err := serveSession(cfg, opts, conn, admit); {
+			case errors.Is(err, errFull):
+				logf("%s refused: %d sessions already running", addr, opts.MaxSessions)
+			case err != nil:
+				logf("%s disconnected: %v", addr, err)
+			default:
+				logf("%s disconnected", addr)
+			}
+		}()
+	}
+}
+
+// serveSession runs the screensaver for one telnet client until the client
+// quits or disconnects. Once the client is past the password prompt, admit
+// takes a session slot for it, or reports the server full.
+func serveSession(cfg Config, opts ServeOptions, conn net.Conn, admit func() bool) error {
+	defer conn.Close()
+	if err := telnet.Negotiate(conn); err != nil {
+		return err
+	}
+	in := telnet.NewReader(conn)
+	var size telnet.Event
+	if opts.Password != "" {
+		var err error
+		if size, err = login(conn, in, opts.Password); err != nil {
+			return err
+		}
+	}
+	if !admit() {
+		io.WriteString(conn, "The server is full, try again later.\r\n")
+		return errFull
+	}
+
+	cfg.Guest = true
+	// The control pipe, the sea state file and the intro belong to the
+	// terminal the server runs in
+	cfg.Control = false
+	cfg.SeaState = false
+	cfg.Intro = ""
+	cfg.Record = ""
+	cfg.FrameDelay = max(cfg.FrameDelay, time.Second/time.Duration(opts.MaxFPS))
+	stream := opts.Stream
+	stream.Out = conn
+	stream.Width, stream.Height = min(stream.Width, opts.MaxSize.X), min(stream.Height, opts.MaxSize.Y)
+	cfg.Stream = &stream
+	a, err := New(cfg)
+	if err != nil {
+		return err
+	}
+
+	screen := a.screen.(*streamScreen)
+	resize := func(ev telnet.Event) {
+		screen.resize(min(ev.Width, opts.MaxSize.X), min(ev.Height, opts.MaxSize.Y))
+	}
+	if size.Width > 0 {
+		resize(size)
+	}
+	// Only key presses count as activity, window size reports do not
+	active := func() {
+		if opts.IdleTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(opts.IdleTimeout))
+		}
+	}
+	active()
+	go func() {
+		for {
+			ev, err := in.Read()
+			if err != nil {
+				// Gone, idle, or the session ended and closed the connection
+				screen.InjectKey(tcell.KeyCtrlC, 0, tcell.ModNone)
+				return
+			}
+			if ev.Width > 0 {
+				resize(ev)
+				continue
+			}
+			active()
+			screen.InjectKey(ev.Key, ev.Rune, ev.Mod)
+		}
+	}()
+	if err := a.Run(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// login prompts for the password until it is entered correctly, returning
+// the last window size the client reported meanwhile.
+func login(conn net.Conn, in *telnet.Reader, password string) (telnet.Event, error) {
+	conn.SetReadDeadline(time.Now().Add(loginTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var size telnet.Event
+	for range loginAttempts {
+		if _, err := io.WriteString(conn, "Password: "); err != nil {
+			return size, err
+		}
+		var typed []rune
+		extra := 0 // Characters typed past MaxPassword
+	line:
+		for {
+			ev, err := in.Read()
+			if err != nil {
+				return size, err
+			}
+			switch {
+			case ev.Width > 0:
+				size = ev
+			case ev.Key == tcell.KeyEnter:
+				break line
+			case ev.Key == tcell.KeyBackspace || ev.Key == tcell.KeyBackspace2:
+				if extra > 0 {
+					extra--
+				} else {
+					typed = typed[:max(len(typed)-1, 0)]
+				}
+			case ev.Key == tcell.KeyCtrlC || ev.Key == tcell.KeyCtrlD:
+				return size, errLogin
+			case ev.Key == tcell.KeyRune && len(typed) < MaxPassword:
+				typed = append(typed, ev.Rune)
+			case ev.Key == tcell.KeyRune:
+				extra++
+			}
+		}
+		// Echo is off, so the client's cursor is still on the prompt line
+		io.WriteString(conn, "\r\n")
+		if extra == 0 && subtle.ConstantTimeCompare([]byte(string(typed)), []byte(password)) == 1 {
+			return size, nil
+		}
+	}
+	io.WriteString(conn, "Wrong password.\r\n")
+	return size, errLogin
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/telnet"
+)
+
+func TestParseAllow(t *testing.T) {
+	tests := []struct {
+		in      string
+		allowed []string
+		denied  []string
+		err     bool
+	}{
+		{in: "10.0.0.5", allowed: []string{"10.0.0.5"}, denied: []string{"10.0.0.6"}},
+		{in: "192.168.1.0/24", allowed: []string{"192.168.1.1", "192.168.1.254"}, denied: []string{"192.168.2.1"}},
+		{in: " 10.0.0.5 , 172.16.0.0/12", allowed: []string{"10.0.0.5", "172.20.1.1"}, denied: []string{"10.0.0.4"}},
+		{in: "::1", allowed: []string{"::1"}, denied: []string{"::2", "127.0.0.1"}},
+		{in: "fd00::/8", allowed: []string{"fd12::1"}, denied: []string{"fe80::1"}},
+		// IPv4 addresses match whether they arrive as IPv4 or mapped into IPv6
+		{in: "10.0.0.5", allowed: []string{"::ffff:10.0.0.5"}},
+		{in: "", err: true},
+		{in: "10.0.0.5,", err: true},
+		{in: "10.0.0.256", err: true},
+		{in: "10.0.0.0/33", err: true},
+		{in: "localhost", err: true},
+	}
+	for _, tt := range tests {
+		nets, err := ParseAllow(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseAllow(%q): error %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		opts := ServeOptions{Allow: nets}
+		for _, ip := range tt.allowed {
+			if !opts.allowed(&net.TCPAddr{IP: net.ParseIP(ip)}) {
+				t.Errorf("ParseAllow(%q) turns away %s", tt.in, ip)
+			}
+		}
+		for _, ip := range tt.denied {
+			if opts.allowed(&net.TCPAddr{IP: net.ParseIP(ip)}) {
+				t.Errorf("ParseAllow(%q) lets in %s", tt.in, ip)
+			}
+		}
+	}
+}
+
+func TestLogin(t *testing.T) {
+	long := strings.Repeat("x", MaxPassword)
+	tests := []struct {
+		name, password, typed string
+		err                   error
+		width                 int  // Window width reported during the prompt
+		hangUp                bool // Whether the client disconnects after typing
+	}{
+		{name: "right", password: "secret", typed: "secret\r\n"},
+		{name: "second try", password: "secret", typed: "guess\rsecret\r"},
+		{name: "third try", password: "secret", typed: "a\rb\rsecret\r"},
+		{name: "three wrong", password: "secret", typed: "a\rb\rc\rsecret\r", err: errLogin},
+		{name: "backspace", password: "secret", typed: "secrex\x7ft\r"},
+		{name: "backspace past start", password: "ab", typed: "\x08\x08ab\r"},
+		{name: "unicode", password: "пароль", typed: "пароль\r"},
+		{name: "prefix", password: "secret", typed: "secre\rsecrets\rsecret \r", err: errLogin},
+		{name: "ctrl-c", password: "secret", typed: "\x03secret\r", err: errLogin},
+		{name: "ctrl-d", password: "secret", typed: "sec\x04", err: errLogin},
+		{name: "hang up", password: "secret", typed: "secr", err: io.EOF, hangUp: true},
+		{name: "window size", password: "secret", typed: "\xff\xfa\x1f\x00\x50\x00\x18\xff\xf0secret\r", width: 80},
+		{name: "longest", password: long, typed: long + "\r"},
+		{name: "past longest", password: long, typed: long + "x\r" + long + "xx\r" + long + "xxx\r", err: errLogin},
+		{name: "past longest and back", password: long, typed: long + "xx\x7f\x7f\r"},
+		{name: "flood", password: "secret", typed: strings.Repeat("y", 100000) + "\rsecret\r"},
+	}
+	for _, tt := range tests {
+		server, client := net.Pipe()
+		// The prompts have to be read for login to go on
+		go io.Copy(io.Discard, client)
+		go func() {
+			io.WriteString(client, tt.typed)
+			if tt.hangUp {
+				client.Close()
+			}
+		}()
+		size, err := login(server, telnet.NewReader(server), tt.password)
+		server.Close()
+		client.Close()
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+		}
+		if size.Width != tt.width {
+			t.Errorf("%s: got window width %d, want %d", tt.name, size.Width, tt.width)
+		}
+	}
+}
+
+func TestGuestStartsNoPlugins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Plugins, cfg.Guest = true, true
+	if h := startPlugins(cfg); h != nil {
+		h.Close()
+		t.Error("a guest session started the plugins")
+	}
+}
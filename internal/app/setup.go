@@ -0,0 +1,395 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/timing"
+	"github.com/olegchuev/screensaver/internal/toml"
+)
+
+// setupFPS are the frame rates offered by the setup wizard.
+var setupFPS = []int{8, 12, 15, 24, 30}
+
+var (
+	panelStyle    = tcell.StyleDefault.Foreground(tcell.NewRGBColor(220, 220, 220)).Background(tcell.NewRGBColor(25, 30, 40))
+	panelTitle    = panelStyle.Bold(true)
+	panelSelected = tcell.StyleDefault.Foreground(tcell.NewRGBColor(20, 20, 20)).Background(tcell.NewRGBColor(150, 200, 255))
+	panelHint     = panelStyle.Foreground(tcell.NewRGBColor(130, 140, 160))
+)
+
+// capabilities describes what the terminal can display.
+type capabilities struct {
+	term      string
+	colors    int
+	trueColor bool
+	blocks    bool // Block elements used by shading and sub-cell drawing
+	braille   bool // Braille patterns used for dot plotting
+}
+
+// detectCapabilities queries the initialized screen for color and glyph support.
+func detectCapabilities(screen tcell.Screen) capabilities {
+	return capabilities{
+		term:      os.Getenv("TERM"),
+		colors:    screen.Colors(),
+		trueColor: screen.Colors() >= 1<<24,
+		blocks:    screen.CanDisplay('█', false) && screen.CanDisplay('▁', false),
+		braille:   screen.CanDisplay('⣿', false),
+	}
+}
+
+// lines summarizes the capabilities for the first wizard page.
+func (c capabilities) lines() []string {
+	yesNo := func(ok bool) string {
+		if ok {
+			return "yes"
+		}
+		return "no"
+	}
+	lines := []string{
+		"Terminal:     " + c.term,
+		fmt.Sprintf("Colors:       %d", c.colors),
+		"True color:   " + yesNo(c.trueColor),
+		"Block glyphs: " + yesNo(c.blocks),
+		"Braille:      " + yesNo(c.braille),
+	}
+	if !c.trueColor {
+		lines = append(lines, "", "Gradients need 24-bit color and will look banded here.")
+	}
+	if !c.blocks {
+		lines = append(lines, "", "Your font lacks block elements, shading will be coarse.")
+	}
+	return lines
+}
+
+// setupStep is one page of the setup wizard.
+type setupStep struct {
+	title    string
+	info     []string
+	options  []string
+	selected int
+	// choose applies the highlighted option to the draft configuration
+	choose func(w *wizard, i int) error
+}
+
+// wizard holds the state of the interactive setup.
+type wizard struct {
+	config   Config
+	screen   tcell.Screen
+	renderer *renderer.Renderer
+	scene    scene
+	steps    []*setupStep
+	step     int
+}
+
+// Setup runs the interactive first-run wizard. It detects terminal
+// capabilities, lets the user pick a scene, theme and frame rate while the
+// choice previews live behind the menu, and saves the result as the
+// settings future runs start with.
+func Setup(cfg Config) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	screen, err := openScreen()
+	if err != nil {
+		return err
+	}
+
+	w := &wizard{
+		config:   cfg,
+		screen:   screen,
+		renderer: renderer.NewRenderer(screen),
+	}
+	w.renderer.SetCellAspect(cfg.CellAspect)
+	w.renderer.SetColors(colorDepth(cfg))
+	w.renderer.SetLinearBlending(cfg.LinearBlending)
+	w.renderer.SetToneMap(toneMap(cfg))
+	w.renderer.SetMode(renderMode(cfg, screen))
+	w.renderer.SetShadeDither(shadeDither(cfg))
+	w.renderer.SetShadeRamp(cfg.ShadeRamp)
+	w.steps = w.buildSteps(detectCapabilities(screen))
+
+	// Show the current choice of every step in the preview from the start
+	for _, s := range w.steps {
+		if err := s.choose(w, s.selected); err != nil {
+			screen.Fini()
+			return err
+		}
+	}
+
+	saved, err := w.run()
+	screen.Fini()
+	if err != nil || !saved {
+		return err
+	}
+
+	path, _ := configPath()
+	fmt.Printf("Saved scene %q, theme %q at %d fps to %s\n",
+		w.config.Scene, w.config.Theme, fpsOf(w.config.FrameDelay), path)
+	return nil
+}
+
+// save writes the choices to the configuration file. Earlier versions
+// saved them in the state file, which is read after the configuration file
+// and would override them, so they are dropped from there.
+func (w *wizard) save() error {
+	_, err := saveConfigFile([][2]string{
+		{"scene", toml.Quote(w.config.Scene)},
+		{"theme", toml.Quote(w.config.Theme)},
+		{"frame_delay", toml.Quote(w.config.FrameDelay.String())},
+	})
+	if err != nil {
+		return err
+	}
+	return updateState(func(st *State) {
+		st.Scene, st.Theme, st.FPS = "", "", 0
+	})
+}
+
+// buildSteps creates the wizard pages with the current configuration preselected.
+func (w *wizard) buildSteps(caps capabilities) []*setupStep {
+	themes := theme.Names()
+	themeOptions := make([]string, len(themes))
+	for i, name := range themes {
+		t, _ := theme.Lookup(name)
+		themeOptions[i] = fmt.Sprintf("%-8s %s", name, t.Description)
+	}
+	fpsOptions := make([]string, len(setupFPS))
+	for i, fps := range setupFPS {
+		fpsOptions[i] = fmt.Sprintf("%d fps", fps)
+	}
+
+	return []*setupStep{
+		{
+			title:   "Welcome to screensaver setup",
+			info:    caps.lines(),
+			options: []string{"Continue"},
+			choose:  func(w *wizard, i int) error { return nil },
+		},
+		{
+			title:    "Pick a scene",
+			options:  sceneNames,
+			selected: indexOf(sceneNames, w.config.Scene),
+			choose: func(w *wizard, i int) error {
+				w.config.Scene = sceneNames[i]
+				sc, err := newScene(w.config)
+				w.scene = sc
+				return err
+			},
+		},
+		{
+			title:    "Pick a theme",
+			options:  themeOptions,
+			selected: indexOf(themes, w.config.Theme),
+			choose: func(w *wizard, i int) error {
+				t, _ := theme.Lookup(themes[i])
+				w.config.Theme = t.Name
+				w.renderer.SetTheme(t)
+				return nil
+			},
+		},
+		{
+			title:    "Pick a frame rate",
+			info:     []string{"Lower rates use less CPU, especially over SSH."},
+			options:  fpsOptions,
+			selected: nearestFPS(fpsOf(w.config.FrameDelay)),
+			choose: func(w *wizard, i int) error {
+				w.config.FrameDelay = time.Second / time.Duration(setupFPS[i])
+				return nil
+			},
+		},
+		{
+			title:   "Save these settings?",
+			info:    nil, // Filled in when the page is shown
+			options: []string{"Save", "Discard"},
+			choose:  func(w *wizard, i int) error { return nil },
+		},
+	}
+}
+
+// run drives the wizard until the user saves or cancels, reporting whether to save.
+func (w *wizard) run() (bool, error) {
+	pacer := timing.NewPacer(w.config.FrameDelay)
+	defer pacer.Stop()
+
+	events := make(chan tcell.Event, 8)
+	go func() {
+		for {
+			ev := w.screen.PollEvent()
+			if ev == nil {
+				return
+			}
+			events <- ev
+		}
+	}()
+
+	t := 0.0
+	for {
+		select {
+		case ev := <-events:
+			done, save, err := w.handleEvent(ev)
+			if done || err != nil {
+				return save, err
+			}
+		case <-pacer.C():
+			// The frame rate step previews its choice immediately
+			if w.config.FrameDelay != pacer.Target() {
+				pacer.Reset(w.config.FrameDelay)
+			}
+			w.scene.Update(t)
+			w.renderer.Clear()
+			w.scene.Render(w.renderer)
+			w.drawPanel()
+			w.renderer.Flush()
+			t += w.config.FrameDelay.Seconds()
+		}
+	}
+}
+
+// handleEvent reacts to menu navigation. It reports whether the wizard is
+// finished and, if so, whether the settings should be saved.
+func (w *wizard) handleEvent(ev tcell.Event) (done, save bool, err error) {
+	switch ev := ev.(type) {
+	case *tcell.EventResize:
+		w.screen.Sync()
+		w.renderer.Resize()
+	case *tcell.EventKey:
+		s := w.steps[w.step]
+		switch ev.Key() {
+		case tcell.KeyCtrlC:
+			return true, false, nil
+		case tcell.KeyEscape, tcell.KeyLeft, tcell.KeyBackspace, tcell.KeyBackspace2:
+			if w.step == 0 {
+				return true, false, nil
+			}
+			w.step--
+		case tcell.KeyUp:
+			if s.selected > 0 {
+				s.selected--
+				return false, false, s.choose(w, s.selected)
+			}
+		case tcell.KeyDown:
+			if s.selected < len(s.options)-1 {
+				s.selected++
+				return false, false, s.choose(w, s.selected)
+			}
+		case tcell.KeyEnter, tcell.KeyRight:
+			if w.step == len(w.steps)-1 {
+				if s.selected != 0 {
+					return true, false, nil
+				}
+				return true, true, w.save()
+			}
+			w.step++
+			if w.step == len(w.steps)-1 {
+				w.steps[w.step].info = []string{
+					"Scene:      " + w.config.Scene,
+					"Theme:      " + w.config.Theme,
+					fmt.Sprintf("Frame rate: %d fps", fpsOf(w.config.FrameDelay)),
+				}
+			}
+		}
+	}
+	return false, false, nil
+}
+
+// drawPanel draws the current wizard page in a box over the preview.
+func (w *wizard) drawPanel() {
+	s := w.steps[w.step]
+
+	lines := []panelLine{{fmt.Sprintf("%s  (%d/%d)", s.title, w.step+1, len(w.steps)), panelTitle}, {}}
+	for _, text := range s.info {
+		lines = append(lines, panelLine{text, panelStyle})
+	}
+	if len(s.info) > 0 {
+		lines = append(lines, panelLine{})
+	}
+	for i, opt := range s.options {
+		if i == s.selected {
+			lines = append(lines, panelLine{"> " + opt, panelSelected})
+		} else {
+			lines = append(lines, panelLine{"  " + opt, panelStyle})
+		}
+	}
+	lines = append(lines, panelLine{}, panelLine{"↑/↓ choose   Enter next   Esc back", panelHint})
+
+	drawPanel(w.renderer, 2, 1, lines)
+}
+
+// panelLine is one row of text in a menu panel.
+type panelLine struct {
+	text  string
+	style tcell.Style
+}
+
+// drawPanel draws lines of text in a padded box whose top-left corner is at
+// (left, top). It returns the column and row where the first line starts.
+func drawPanel(r *renderer.Renderer, left, top int, lines []panelLine) (int, int) {
+	width := 0
+	for _, l := range lines {
+		width = max(width, len([]rune(l.text)))
+	}
+
+	// One row and two columns of padding around the text
+	x0, y0 := left+2, top+1
+	for row := -1; row <= len(lines); row++ {
+		var l panelLine
+		if row >= 0 && row < len(lines) {
+			l = lines[row]
+		}
+		text := []rune(l.text)
+		for col := -2; col < width+2; col++ {
+			ch, style := ' ', panelStyle
+			if col >= 0 && col < len(text) {
+				ch, style = text[col], l.style
+			} else if col >= 0 && col < width && l.style == panelSelected {
+				style = panelSelected // Highlight the full row width
+			}
+			r.SetCell(x0+col, y0+row, ch, overlay.Depth, style)
+		}
+	}
+	return x0, y0
+}
+
+// fpsOf converts a frame delay to whole frames per second.
+func fpsOf(delay time.Duration) int {
+	if delay <= 0 {
+		return 0
+	}
+	return int(time.Second / delay)
+}
+
+// nearestFPS returns the index of the offered frame rate closest to fps.
+func nearestFPS(fps int) int {
+	best := 0
+	for i, f := range setupFPS {
+		if abs(f-fps) < abs(setupFPS[best]-fps) {
+			best = i
+		}
+	}
+	return best
+}
+
+// indexOf returns the position of name in names, or 0 if it is missing.
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if strings.EqualFold(n, name) {
+			return i
+		}
+	}
+	return 0
+}
+
+// abs returns the absolute value of an integer.
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
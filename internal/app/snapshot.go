@@ -0,0 +1,454 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/rivo/uniseg"
+)
+
+// Snapshot formats accepted by SnapshotOptions.
+var snapshotFormats = []string{"txt", "svg", "png"}
+
+// Pixel width of one cell in svg snapshots; the height follows the cell
+// aspect ratio. PNG snapshots use the cell size of the font.
+const snapshotCellWidth = 8
+
+// snapshotNoon is the wall clock time an automatic night light and the
+// holiday calendar are evaluated at, and clocks start from, so snapshots do
+// not depend on when they are taken.
+var snapshotNoon = time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// SnapshotOptions selects what Snapshot captures.
+type SnapshotOptions struct {
+	// Frame is the number of frames the scene advances before the capture
+	Frame int
+	// Count is the number of consecutive frames captured, starting at Frame
+	Count int
+	// Width and Height are the size of the captured screen in cells
+	Width, Height int
+	// Format is "txt", "svg" or "png"
+	Format string
+}
+
+// DefaultSnapshotOptions returns the options of a typical terminal window.
+func DefaultSnapshotOptions() SnapshotOptions {
+	return SnapshotOptions{Frame: 100, Count: 1, Width: 100, Height: 30, Format: "txt"}
+}
+
+// SnapshotFormat picks the format from the file name's extension, or
+// returns "" if it is not one of the snapshot formats.
+func SnapshotFormat(path string) string {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, f := range snapshotFormats {
+		if ext == f {
+			return f
+		}
+	}
+	return ""
+}
+
+// ParseSize parses a screen size given as "WxH", e.g. "100x30".
+func ParseSize(s string) (int, int, error) {
+	size, ok := parseWxH(s)
+	if !ok || size.X < minWidth || size.Y < minHeight {
+		return 0, 0, fmt.Errorf("invalid size %q: want WxH of at least %dx%d, like 100x30", s, minWidth, minHeight)
+	}
+	return size.X, size.Y, nil
+}
+
+// ParseCellPixels parses the size of a cell in pixels given as "WxH", e.g.
+// "8x16".
+func ParseCellPixels(s string) (image.Point, error) {
+	size, ok := parseWxH(s)
+	if !ok || size.X <= 0 || size.Y <= 0 {
+		return image.Point{}, fmt.Errorf("invalid cell size %q: want WxH like 8x16", s)
+	}
+	return size, nil
+}
+
+// parseWxH parses two whole numbers joined by an x, nothing before or after.
+func parseWxH(s string) (image.Point, bool) {
+	width, height, ok := strings.Cut(s, "x")
+	if !ok {
+		return image.Point{}, false
+	}
+	w, errW := strconv.Atoi(width)
+	h, errH := strconv.Atoi(height)
+	return image.Pt(w, h), errW == nil && errH == nil
+}
+
+// trueColorScreen is a simulated screen reporting true color, so snapshots
+// and the window keep the exact theme colors instead of a dithered palette.
+type trueColorScreen struct {
+	tcell.SimulationScreen
+}
+
+// Colors reports 24-bit color support.
+func (trueColorScreen) Colors() int {
+	return 1 << 24
+}
+
+// Snapshot renders frames of the configured scene on a simulated screen
+// and writes each to the writer create returns for its number. Scenes are
+// seeded and advance on frame time, and so does the audio they react to,
+// so the same configuration and options always give the same output.
+func Snapshot(cfg Config, opts SnapshotOptions, create func(frame int) (io.WriteCloser, error)) error {
+	if err := Validate(cfg); err != nil {
+		return err
+	}
+	if err := opts.check(); err != nil {
+		return err
+	}
+	// Dashboards show their first page
+	cfg.Scene = cfg.onPage(0).Scene
+	sc, err := newScene(cfg)
+	if err != nil {
+		return invalidConfig(err)
+	}
+	clip, err := loadAudio(cfg)
+	if err != nil {
+		return err
+	}
+	face, err := snapshotFace(cfg, opts.Format)
+	if err != nil {
+		return err
+	}
+
+	screen, r, err := newSnapshotScreen(cfg, opts.Width, opts.Height)
+	if err != nil {
+		return err
+	}
+	defer screen.Fini()
+
+	a := &App{config: cfg, screen: screen, renderer: r, scene: sc, overlays: newOverlays(cfg.onPage(0), nil, nil), audio: clip}
+	a.celebrate(snapshotNoon)
+	// Scenes with particles or simulations depend on every step, not just the last
+	t := 0.0
+	for frame := range opts.Frame + opts.Count {
+		a.tell(snapshotNoon.Add(time.Duration(frame) * cfg.FrameDelay))
+		a.hear(frame)
+		a.update(t)
+		if frame >= opts.Frame {
+			a.render(t, float64(frame)*cfg.FrameDelay.Seconds())
+			if err := writeSnapshot(screenGrid(screen), cfg, opts.Format, face, frame, create); err != nil {
+				return err
+			}
+		}
+		t += cfg.FrameDelay.Seconds()
+	}
+	return nil
+}
+
+// check returns an error if the options do not select any frames or name
+// an unknown format.
+func (o SnapshotOptions) check() error {
+	if o.Frame < 0 {
+		return invalidConfig(fmt.Errorf("invalid frame %d: must not be negative", o.Frame))
+	}
+	if o.Count < 1 {
+		return invalidConfig(fmt.Errorf("invalid count %d: must be at least 1", o.Count))
+	}
+	if SnapshotFormat("."+o.Format) == "" {
+		return invalidConfig(fmt.Errorf("unknown snapshot format %q (available: %s)", o.Format, strings.Join(snapshotFormats, ", ")))
+	}
+	return nil
+}
+
+// snapshotFace loads the font png snapshots are drawn with, or returns nil
+// for the other formats.
+func snapshotFace(cfg Config, format string) (font.Face, error) {
+	if format != "png" {
+		return nil, nil
+	}
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	return face, nil
+}
+
+// newSnapshotScreen returns a simulated screen of the size on a black
+// background, with a renderer set up like the screensaver's at noon. Call
+// Fini on the screen when done.
+func newSnapshotScreen(cfg Config, width, height int) (trueColorScreen, *renderer.Renderer, error) {
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return trueColorScreen{}, nil, err
+	}
+	sim.SetSize(width, height)
+	screen := trueColorScreen{sim}
+	screen.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+
+	th, _ := theme.Lookup(cfg.Theme)
+	r := renderer.NewRenderer(screen)
+	r.SetAdjustment(cfg.Color)
+	r.SetCellAspect(cfg.CellAspect)
+	r.SetColors(colorDepth(cfg))
+	r.SetLinearBlending(cfg.LinearBlending)
+	r.SetToneMap(toneMap(cfg))
+	r.SetMode(renderMode(cfg, screen))
+	r.SetShadeDither(shadeDither(cfg))
+	r.SetShadeRamp(cfg.ShadeRamp)
+	r.SetTheme(th)
+	r.SetTemperature(cfg.Temperature.At(snapshotNoon))
+	for l, style := range cfg.Layers {
+		r.SetLayerStyle(l, style)
+	}
+	return screen, r, nil
+}
+
+// writeSnapshot writes one captured frame in the format.
+func writeSnapshot(grid [][]snapshotCell, cfg Config, format string, face font.Face, frame int, create func(frame int) (io.WriteCloser, error)) error {
+	out, err := create(frame)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(out)
+	switch format {
+	case "txt":
+		writeSnapshotText(bw, grid)
+	case "svg":
+		writeSnapshotSVG(bw, grid, cfg.CellAspect)
+	case "png":
+		err = png.Encode(bw, snapshotImage(grid, face))
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// snapshotCell is one captured cell with resolved colors.
+type snapshotCell struct {
+	char   rune
+	fg, bg color.RGBA
+}
+
+// screenGrid captures the cells of a simulated screen, row by row.
+func screenGrid(sim tcell.SimulationScreen) [][]snapshotCell {
+	cells, w, h := sim.GetContents()
+	grid := make([][]snapshotCell, h)
+	for y := range grid {
+		grid[y] = make([]snapshotCell, w)
+		for x := range grid[y] {
+			grid[y][x] = newSnapshotCell(cells[y*w+x])
+		}
+	}
+	return grid
+}
+
+// newSnapshotCell resolves the colors of a simulated cell, using the
+// screen's black background and a light grey for unset colors.
+func newSnapshotCell(c tcell.SimCell) snapshotCell {
+	char := ' '
+	if len(c.Runes) > 0 {
+		char = c.Runes[0]
+	}
+	return styledCell(char, c.Style)
+}
+
+// styledCell resolves the colors of style like newSnapshotCell, for
+// screens read through GetContent.
+func styledCell(char rune, style tcell.Style) snapshotCell {
+	if char == 0 {
+		char = ' '
+	}
+	fg, bg, _ := style.Decompose()
+	return snapshotCell{
+		char: char,
+		fg:   rgba(fg, color.RGBA{200, 200, 200, 255}),
+		bg:   rgba(bg, color.RGBA{0, 0, 0, 255}),
+	}
+}
+
+// rgba converts a tcell color, returning def for the terminal default.
+func rgba(c tcell.Color, def color.RGBA) color.RGBA {
+	if c == tcell.ColorDefault || c == tcell.ColorReset {
+		return def
+	}
+	r, g, b := c.RGB()
+	return color.RGBA{uint8(r), uint8(g), uint8(b), 255}
+}
+
+// writeSnapshotText writes the characters only, one line per row, without
+// trailing blanks. The cell after a wide character is left out, as the
+// character covers it.
+func writeSnapshotText(w io.Writer, grid [][]snapshotCell) {
+	for _, row := range grid {
+		line := make([]rune, 0, len(row))
+		for x := 0; x < len(row); x++ {
+			line = append(line, row[x].char)
+			if wideChar(row[x].char) {
+				x++
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(string(line), " "))
+	}
+}
+
+// wideChar reports whether r takes up two cells, as emoji do.
+func wideChar(r rune) bool {
+	return r >= 0x1100 && uniseg.StringWidth(string(r)) == 2
+}
+
+// writeSnapshotSVG writes the cells as colored monospace text over their
+// background colors.
+func writeSnapshotSVG(w io.Writer, grid [][]snapshotCell, aspect float64) {
+	cw := float64(snapshotCellWidth)
+	ch := cw * aspect
+	width, height := 0.0, float64(len(grid))*ch
+	if len(grid) > 0 {
+		width = float64(len(grid[0])) * cw
+	}
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n", width, height, width, height)
+	fmt.Fprintf(w, `<rect width="100%%" height="100%%" fill="#000000"/>`+"\n")
+	fmt.Fprintf(w, `<g font-family="monospace" font-size="%g" text-anchor="middle">`+"\n", ch*0.8)
+	for y, row := range grid {
+		for x, c := range row {
+			if c.bg != (color.RGBA{0, 0, 0, 255}) {
+				fmt.Fprintf(w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", float64(x)*cw, float64(y)*ch, cw, ch, hexColor(c.bg))
+			}
+			if c.char != ' ' {
+				center := float64(x) + 0.5
+				if wideChar(c.char) {
+					center += 0.5
+				}
+				fmt.Fprintf(w, `<text x="%g" y="%g" fill="%s">%s</text>`+"\n", center*cw, (float64(y)+0.8)*ch, hexColor(c.fg), escape.Replace(string(c.char)))
+			}
+		}
+	}
+	fmt.Fprintln(w, "</g>")
+	fmt.Fprintln(w, "</svg>")
+}
+
+// hexColor formats a color as #rrggbb.
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// snapshotImage rasterizes the cells with the configured font.
+func snapshotImage(grid [][]snapshotCell, face font.Face) *image.RGBA {
+	cw, ch := face.CellSize()
+	width := 0
+	if len(grid) > 0 {
+		width = len(grid[0])
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width*cw, len(grid)*ch))
+	masks := make(map[rune]*image.Alpha)
+	for y, row := range grid {
+		for x, c := range row {
+			mask, ok := masks[c.char]
+			if !ok {
+				mask = cellMask(face, c.char)
+				masks[c.char] = mask
+			}
+			for py := range ch {
+				for px := range cw {
+					cover := float64(mask.AlphaAt(px, py).A) / 255
+					img.SetRGBA(x*cw+px, y*ch+py, blend(c.bg, c.fg, cover))
+				}
+			}
+		}
+	}
+	return img
+}
+
+// cellMask returns the coverage of r over one cell of face. Block elements
+// and Braille patterns are drawn exactly so they tile seamlessly, other
+// characters come from the font, and characters it lacks show as a soft block.
+func cellMask(face font.Face, r rune) *image.Alpha {
+	if !blockGlyph(r) {
+		if mask, ok := face.Glyph(r); ok {
+			return mask
+		}
+	}
+	w, h := face.CellSize()
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	for py := range h {
+		for px := range w {
+			cover := glyphCoverage(r, float64(px)+0.5, float64(py)+0.5, float64(w), float64(h))
+			mask.SetAlpha(px, py, color.Alpha{A: uint8(255*cover + 0.5)})
+		}
+	}
+	return mask
+}
+
+// blockGlyph reports whether glyphCoverage draws r exactly, as it does for
+// block elements and Braille patterns.
+func blockGlyph(r rune) bool {
+	return r == ' ' || (r >= '▀' && r <= '▟') || (r >= 0x2800 && r <= 0x28ff)
+}
+
+// quadrantBits are the quadrants the quadrant blocks from ▖ to ▟ fill: bit
+// 0 the top left, 1 the top right, 2 the bottom left, 3 the bottom right.
+var quadrantBits = [...]uint8{4, 8, 1, 13, 9, 7, 11, 2, 6, 14}
+
+// glyphCoverage returns how much of the pixel at (px, py) inside a cell of
+// size w x h the glyph r covers, from 0 to 1.
+func glyphCoverage(r rune, px, py, w, h float64) float64 {
+	switch {
+	case r == ' ':
+		return 0
+	case r == '▀':
+		return boolCoverage(py < h/2)
+	case r >= '▁' && r <= '█':
+		// Lower eighth blocks up to the full block
+		return boolCoverage(py >= h*(1-float64(r-'▁'+1)/8))
+	case r >= '▉' && r <= '▏':
+		// Left blocks from seven eighths down to one eighth
+		return boolCoverage(px < w*float64('▏'-r+1)/8)
+	case r == '▐':
+		return boolCoverage(px >= w/2)
+	case r == '░':
+		return 0.25
+	case r == '▒':
+		return 0.5
+	case r == '▓':
+		return 0.75
+	case r >= '▔' && r <= '▕':
+		return boolCoverage((r == '▔' && py < h/8) || (r == '▕' && px >= w*7/8))
+	case r >= '▖' && r <= '▟':
+		col, row := int(px*2/w), int(py*2/h)
+		return boolCoverage(quadrantBits[r-'▖']&(1<<(row*2+col)) != 0)
+	case r >= 0x2800 && r <= 0x28ff:
+		// Braille dots are numbered down the left column, then the right,
+		// with the bottom row added last
+		col, row := int(px*2/w), int(py*4/h)
+		bit := [2][4]uint{{0, 1, 2, 6}, {3, 4, 5, 7}}[col][row]
+		return boolCoverage((r-0x2800)&(1<<bit) != 0)
+	}
+	// Any other glyph: a soft block inset from the cell edges
+	return boolCoverage(px > w*0.15 && px < w*0.85 && py > h*0.2 && py < h*0.85) * 0.6
+}
+
+// boolCoverage converts a covered test to full or no coverage.
+func boolCoverage(covered bool) float64 {
+	if covered {
+		return 1
+	}
+	return 0
+}
+
+// blend mixes from a toward b by f.
+func blend(a, b color.RGBA, f float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*f + 0.5) }
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// nopWriteCloser collects a snapshot in memory.
+type nopWriteCloser struct{ *bytes.Buffer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestSnapshotDeterministic(t *testing.T) {
+	for _, format := range []string{"txt", "svg"} {
+		t.Run(format, func(t *testing.T) {
+			take := func() []byte {
+				var buf bytes.Buffer
+				opts := DefaultSnapshotOptions()
+				opts.Frame, opts.Format = 20, format
+				err := Snapshot(DefaultConfig(), opts, func(int) (io.WriteCloser, error) {
+					return nopWriteCloser{&buf}, nil
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return buf.Bytes()
+			}
+			first, second := take(), take()
+			if len(first) == 0 || !bytes.Equal(first, second) {
+				t.Errorf("two snapshots of the same configuration differ (%d and %d bytes)", len(first), len(second))
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s    string
+		w, h int
+	}{
+		{"100x30", 100, 30},
+		{"200x60", 200, 60},
+	}
+	for _, tt := range tests {
+		w, h, err := ParseSize(tt.s)
+		if err != nil || w != tt.w || h != tt.h {
+			t.Errorf("ParseSize(%q) = %d, %d, %v; want %d, %d", tt.s, w, h, err, tt.w, tt.h)
+		}
+	}
+	for _, s := range []string{"", "100", "100x", "x30", "100x30junk", "100x30x2", "100 x 30", "100.5x30", "1x1", "-100x30", "axb"} {
+		if _, _, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestParseCellPixels(t *testing.T) {
+	if size, err := ParseCellPixels("8x16"); err != nil || size.X != 8 || size.Y != 16 {
+		t.Errorf("ParseCellPixels(\"8x16\") = %v, %v; want 8x16", size, err)
+	}
+	for _, s := range []string{"", "8", "8x", "8x16junk", "0x16", "8x-16", "8.5x16"} {
+		if _, err := ParseCellPixels(s); err == nil {
+			t.Errorf("ParseCellPixels(%q) succeeded, want an error", s)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// stateFile is the name of the file holding settings changed at runtime.
+const stateFile = "state.json"
+
+// State holds settings chosen in the setup wizard or adjusted with
+// keybindings that persist across runs.
+type State struct {
+	Color renderer.Adjustment `json:"color"`
+	Scene string              `json:"scene,omitempty"`
+	Theme string              `json:"theme,omitempty"`
+	FPS   int                 `json:"fps,omitempty"`
+	// ShadeRamp is the shade ramp saved by calibrate
+	ShadeRamp string `json:"shade_ramp,omitempty"`
+}
+
+// statePath returns the location of the state file in the user config directory.
+func statePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", stateFile), nil
+}
+
+// themeDir returns the directory user themes are saved to and loaded from.
+func themeDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "screensaver", "themes"), nil
+}
+
+// LoadThemes registers the user themes saved in the config directory.
+func LoadThemes() error {
+	dir, err := themeDir()
+	if err != nil {
+		return err
+	}
+	return theme.LoadDir(dir)
+}
+
+// readState reads the state file on top of the given defaults.
+// A missing state file leaves the defaults untouched.
+func readState(state State) (State, error) {
+	state, _, err := readStateLines(state)
+	return state, err
+}
+
+// readStateLines is readState that also reports where each setting is,
+// as "path:line" keyed by the lower cased JSON key.
+func readStateLines(state State) (State, map[string]string, error) {
+	path, err := statePath()
+	if err != nil {
+		return state, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil, nil
+	}
+	if err != nil {
+		return state, nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, nil, jsonError(path, data, err)
+	}
+	sources := make(map[string]string)
+	for key, line := range keyLines(data) {
+		sources[key] = fmt.Sprintf("%s:%d", path, line)
+	}
+	return state, sources, nil
+}
+
+// LoadState applies previously saved runtime settings to the configuration.
+// A missing state file is not an error.
+func LoadState(cfg *Config) error {
+	state, sources, err := readStateLines(State{Color: cfg.Color})
+	if err != nil {
+		return err
+	}
+	// Validate points problems with saved settings at their line in the file
+	for _, setting := range []string{"brightness", "contrast", "gamma", "scene", "theme", "fps"} {
+		if source, ok := sources[setting]; ok {
+			cfg.SetSource(setting, source)
+		}
+	}
+	cfg.Color = state.Color
+	if state.Scene != "" {
+		cfg.Scene = state.Scene
+	}
+	if state.Theme != "" {
+		cfg.Theme = state.Theme
+	}
+	if state.FPS > 0 {
+		cfg.FrameDelay = time.Second / time.Duration(state.FPS)
+	}
+	if state.ShadeRamp != "" {
+		cfg.ShadeRamp = state.ShadeRamp
+		if source, ok := sources["shade_ramp"]; ok {
+			cfg.SetSource("shade-ramp", source)
+		}
+	}
+	return nil
+}
+
+// updateState changes the saved settings with fn, keeping everything fn leaves alone.
+func updateState(fn func(*State)) error {
+	state, err := readState(State{Color: renderer.DefaultAdjustment()})
+	if err != nil {
+		return err
+	}
+	fn(&state)
+	return saveState(state)
+}
+
+// saveState writes the runtime settings so the next run starts with them.
+func saveState(state State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"io"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/ansi"
+)
+
+// StreamOptions configures writing the animation to a plain output stream
+// instead of driving the terminal, for example to pipe it over ssh.
+type StreamOptions struct {
+	// Out receives the frames as ANSI escape sequences
+	Out io.Writer
+	// Width and Height are the size of the streamed screen in cells
+	Width, Height int
+	// Repeat shortens runs of one character, see ansi.Encoder
+	Repeat bool
+	// Depth is the color depth, the highest one when Adaptive
+	Depth ansi.Depth
+	// Adaptive lowers the color depth and then the frame rate while the
+	// receiver cannot keep up, instead of stalling the animation
+	Adaptive bool
+	// WriteTimeout ends the stream when writing a frame blocks this long,
+	// for outputs such as network connections that support deadlines; 0
+	// waits forever
+	WriteTimeout time.Duration
+}
+
+// DefaultStreamOptions returns adaptive true color options for a classic
+// 80x24 terminal.
+func DefaultStreamOptions() StreamOptions {
+	return StreamOptions{Width: 80, Height: 24, Adaptive: true, WriteTimeout: 10 * time.Second}
+}
+
+// Streams adapt after this many consecutive frames whose write blocked for
+// more than half the frame time, or less than an eighth of it.
+const (
+	streamSlowFrames = 5
+	streamFastFrames = 100
+	// Most frames dropped after each sent one, a quarter of the frame rate
+	streamMaxSkip = 3
+)
+
+// streamQuality tracks how long writing frames blocks, a sign the receiver
+// or the link to it is falling behind, and picks the color depth and frame
+// rate the stream can sustain. It lowers the color depth first, since that
+// shrinks frames the most, and then drops frames; recovery goes the other
+// way round.
+type streamQuality struct {
+	delay   time.Duration // Time between frames
+	best    ansi.Depth
+	depth   ansi.Depth
+	skip    int // Frames dropped after each sent one
+	skipped int
+	slow    int
+	fast    int
+}
+
+// send reports whether the current frame is sent or dropped.
+func (q *streamQuality) send() bool {
+	if q.skipped < q.skip {
+		q.skipped++
+		return false
+	}
+	q.skipped = 0
+	return true
+}
+
+// observe records how long writing a frame blocked and reports whether
+// the quality changed.
+func (q *streamQuality) observe(blocked time.Duration) bool {
+	budget := q.delay * time.Duration(q.skip+1)
+	switch {
+	case blocked > budget/2:
+		q.slow, q.fast = q.slow+1, 0
+	case blocked < budget/8:
+		q.slow, q.fast = 0, q.fast+1
+	default:
+		q.slow, q.fast = 0, 0
+	}
+
+	switch {
+	case q.slow >= streamSlowFrames && q.depth < ansi.Mono:
+		q.depth++
+	case q.slow >= streamSlowFrames && q.skip < streamMaxSkip:
+		q.skip++
+	case q.fast >= streamFastFrames && q.skip > 0:
+		q.skip--
+	case q.fast >= streamFastFrames && q.depth > q.best:
+		q.depth--
+	default:
+		return false
+	}
+	q.slow, q.fast = 0, 0
+	return true
+}
+
+// deadliner is an output that can give up on blocked writes, such as a
+// network connection.
+type deadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// streamScreen is a simulated screen that encodes every shown frame to the
+// stream. Frames only carry the cells that changed since the previous one.
+type streamScreen struct {
+	trueColorScreen
+	enc      *ansi.Encoder
+	cells    []ansi.Cell
+	quality  *streamQuality // Nil unless adaptive
+	deadline deadliner      // Nil unless writes time out
+	timeout  time.Duration
+	failed   bool
+}
+
+// openStream creates the simulated screen written to opts.Out, showing a
+// frame every delay.
+func openStream(opts StreamOptions, delay time.Duration) (tcell.Screen, error) {
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return nil, err
+	}
+	sim.SetSize(opts.Width, opts.Height)
+
+	enc := ansi.NewEncoder(opts.Out)
+	enc.Repeat = opts.Repeat
+	enc.SetDepth(opts.Depth)
+	s := &streamScreen{trueColorScreen: trueColorScreen{sim}, enc: enc, timeout: opts.WriteTimeout}
+	if d, ok := opts.Out.(deadliner); ok && opts.WriteTimeout > 0 {
+		s.deadline = d
+	}
+	if opts.Adaptive {
+		s.quality = &streamQuality{delay: delay, best: opts.Depth, depth: opts.Depth}
+	}
+	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+	return s, nil
+}
+
+// Show encodes the frame to the stream, unless the stream is dropping
+// frames to keep up.
+func (s *streamScreen) Show() {
+	s.SimulationScreen.Show()
+	if s.failed || (s.quality != nil && !s.quality.send()) {
+		return
+	}
+	contents, w, _ := s.GetContents()
+	s.cells = s.cells[:0]
+	for _, c := range contents {
+		sc := newSnapshotCell(c)
+		s.cells = append(s.cells, ansi.Cell{Char: sc.char, FG: sc.fg, BG: sc.bg})
+	}
+	start := time.Now()
+	s.extendDeadline(start)
+	if err := s.enc.Encode(s.cells, w); err != nil {
+		// Nobody is watching anymore, so quit like a key press would
+		s.failed = true
+		s.InjectKey(tcell.KeyCtrlC, 0, tcell.ModNone)
+		return
+	}
+	if s.quality != nil && s.quality.observe(time.Since(start)) {
+		if s.quality.depth < s.enc.Depth() {
+			s.enc.Reset() // Repaint what was sent with fewer colors
+		}
+		s.enc.SetDepth(s.quality.depth)
+	}
+}
+
+// extendDeadline gives the next write until the timeout from now.
+func (s *streamScreen) extendDeadline(now time.Time) {
+	if s.deadline != nil {
+		// Outputs without deadline support, such as a terminal, just block
+		_ = s.deadline.SetWriteDeadline(now.Add(s.timeout))
+	}
+}
+
+// Fini leaves the receiving terminal with default colors and a cursor.
+func (s *streamScreen) Fini() {
+	if !s.failed {
+		s.extendDeadline(time.Now())
+		_ = s.enc.Close()
+	}
+	s.SimulationScreen.Fini()
+}
+
+// resize changes the size of the streamed screen, as the window of a
+// terminal would. Sizes the screensaver cannot fill are raised to the minimum.
+func (s *streamScreen) resize(width, height int) {
+	width, height = max(width, minWidth), max(height, minHeight)
+	if w, h := s.Size(); w != width || h != height {
+		s.SetSize(width, height)
+		s.PostEvent(tcell.NewEventResize(width, height))
+	}
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+var (
+	switcherTitle    = tcell.StyleDefault.Foreground(tcell.NewRGBColor(220, 220, 220)).Bold(true)
+	switcherBorder   = tcell.StyleDefault.Foreground(tcell.NewRGBColor(70, 75, 90))
+	switcherSelected = tcell.StyleDefault.Foreground(tcell.NewRGBColor(150, 200, 255)).Bold(true)
+)
+
+// switcher is a full-screen scene menu showing a live thumbnail of every
+// scene, each running its own instance in an offscreen viewport.
+type switcher struct {
+	names    []string
+	scenes   []scene
+	views    []*renderer.Renderer
+	selected int
+	cols     int
+}
+
+// newSwitcher creates thumbnails for every scene with the current one
+// selected, drawn in the theme th.
+func newSwitcher(cfg Config, th theme.Theme) (*switcher, error) {
+	s := &switcher{names: sceneNames, selected: indexOf(sceneNames, cfg.Scene), cols: 1}
+	for _, name := range s.names {
+		c := cfg
+		c.Scene = name
+		sc, err := newScene(c)
+		if err != nil {
+			return nil, err
+		}
+		view := renderer.NewViewport(0, 0)
+		view.SetCellAspect(cfg.CellAspect)
+		view.SetTheme(th)
+		s.scenes = append(s.scenes, sc)
+		s.views = append(s.views, view)
+	}
+	return s, nil
+}
+
+// Update advances every thumbnail scene to time t.
+func (s *switcher) Update(t float64) {
+	for _, sc := range s.scenes {
+		sc.Update(t)
+	}
+}
+
+// Render lays the thumbnails out in a grid with a label under each one.
+func (s *switcher) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	drawText(r, 2, 0, "Scenes   ←↑↓→ move   Enter switch   Tab/Esc close", switcherTitle)
+
+	s.cols = 3
+	if width < 60 {
+		s.cols = 2
+	}
+	rows := (len(s.scenes) + s.cols - 1) / s.cols
+	// Each tile has a one cell border and a label row, separated by a gap
+	tileW := width / s.cols
+	tileH := (height - 2) / rows
+	viewW, viewH := tileW-4, tileH-3
+
+	for i, sc := range s.scenes {
+		x := (i%s.cols)*tileW + 2
+		y := (i/s.cols)*tileH + 2
+
+		style := switcherBorder
+		if i == s.selected {
+			style = switcherSelected
+		}
+
+		// Terminals too small for previews still get a usable list of names
+		if viewW >= 4 && viewH >= 2 {
+			view := s.views[i]
+			view.SetSize(viewW, viewH)
+			view.Clear()
+			sc.Render(view)
+			r.Blit(view, x, y, 0)
+			drawBox(r, x-1, y-1, viewW+2, viewH+2, style)
+		}
+		drawText(r, x, y+max(viewH, 0)+1, s.names[i], style)
+	}
+}
+
+// HandleKey moves the selection. It returns true once the menu should close,
+// with the chosen scene name, or an empty name if the menu was dismissed.
+func (s *switcher) HandleKey(ev *tcell.EventKey) (bool, string) {
+	n := len(s.names)
+	switch ev.Key() {
+	case tcell.KeyEscape, tcell.KeyTab:
+		return true, ""
+	case tcell.KeyEnter:
+		return true, s.names[s.selected]
+	case tcell.KeyLeft:
+		s.selected = (s.selected + n - 1) % n
+	case tcell.KeyRight:
+		s.selected = (s.selected + 1) % n
+	case tcell.KeyUp:
+		if s.selected >= s.cols {
+			s.selected -= s.cols
+		}
+	case tcell.KeyDown:
+		if s.selected+s.cols < n {
+			s.selected += s.cols
+		}
+	}
+	return false, ""
+}
+
+// drawText writes a single line of text starting at (x, y) in front of everything.
+func drawText(r *renderer.Renderer, x, y int, text string, style tcell.Style) {
+	for i, ch := range []rune(text) {
+		r.SetCell(x+i, y, ch, overlay.Depth, style)
+	}
+}
+
+// drawBox outlines a w x h rectangle with its top-left corner at (x, y).
+func drawBox(r *renderer.Renderer, x, y, w, h int, style tcell.Style) {
+	for i := 1; i < w-1; i++ {
+		r.SetCell(x+i, y, '─', overlay.Depth, style)
+		r.SetCell(x+i, y+h-1, '─', overlay.Depth, style)
+	}
+	for j := 1; j < h-1; j++ {
+		r.SetCell(x, y+j, '│', overlay.Depth, style)
+		r.SetCell(x+w-1, y+j, '│', overlay.Depth, style)
+	}
+	r.SetCell(x, y, '┌', overlay.Depth, style)
+	r.SetCell(x+w-1, y, '┐', overlay.Depth, style)
+	r.SetCell(x, y+h-1, '└', overlay.Depth, style)
+	r.SetCell(x+w-1, y+h-1, '┘', overlay.Depth, style)
+}
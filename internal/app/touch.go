@@ -0,0 +1,83 @@
+package app
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	// doubleTapTime and doubleTapDistance bound how far apart in time and
+	// cells two taps may be to count as a double tap
+	doubleTapTime     = 400 * time.Millisecond
+	doubleTapDistance = 2
+	// wheelZoom is the zoom step of one scroll, which touchscreen terminals
+	// send for a two-finger drag
+	wheelZoom = 1.1
+	// Camera zoom limits
+	minZoom, maxZoom = 0.5, 4.0
+)
+
+// touchReactive is implemented by scenes that react to being touched.
+type touchReactive interface {
+	// Touch is called for every cell a finger is dragged over; r is the
+	// renderer the scene draws with, to map the cell into the scene
+	Touch(x, y int, r *renderer.Renderer)
+}
+
+// touchTracker recognizes gestures in the mouse events of a touchscreen
+// terminal, which reports a finger as the first mouse button.
+type touchTracker struct {
+	down    bool
+	moved   bool
+	x, y    int
+	lastTap time.Time
+	tapX    int
+	tapY    int
+}
+
+// handleMouse turns mouse events into gestures: a double tap switches to the
+// next scene, dragging touches the scene and scrolling zooms the camera.
+func (a *App) handleMouse(ev *tcell.EventMouse, now time.Time) {
+	tt := &a.touch
+	x, y := ev.Position()
+	buttons := ev.Buttons()
+	switch {
+	case buttons&tcell.WheelUp != 0:
+		a.zoom(wheelZoom)
+	case buttons&tcell.WheelDown != 0:
+		a.zoom(1 / wheelZoom)
+	case buttons&tcell.Button1 != 0:
+		if !tt.down {
+			tt.down, tt.moved, tt.x, tt.y = true, false, x, y
+			return
+		}
+		if x == tt.x && y == tt.y {
+			return
+		}
+		tt.moved, tt.x, tt.y = true, x, y
+		if t, ok := a.scene.(touchReactive); ok {
+			t.Touch(x, y, a.renderer)
+		}
+	case tt.down:
+		// Released
+		tt.down = false
+		if tt.moved {
+			return
+		}
+		if now.Sub(tt.lastTap) < doubleTapTime && abs(x-tt.tapX) <= doubleTapDistance && abs(y-tt.tapY) <= doubleTapDistance {
+			tt.lastTap = time.Time{}
+			a.cycleScene(1)
+			return
+		}
+		tt.lastTap, tt.tapX, tt.tapY = now, x, y
+	}
+}
+
+// zoom scales the camera's zoom by factor, within its limits.
+func (a *App) zoom(factor float64) {
+	c := a.renderer.Camera()
+	c.Zoom = min(max(c.Zoom*factor, minZoom), maxZoom)
+	a.renderer.SetCamera(c)
+}
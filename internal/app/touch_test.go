@@ -0,0 +1,78 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// touchRecorder is a scene recording the cells it is touched at.
+type touchRecorder struct {
+	scene
+	touched [][2]int
+}
+
+func (s *touchRecorder) Touch(x, y int, r *renderer.Renderer) {
+	s.touched = append(s.touched, [2]int{x, y})
+}
+
+func TestHandleMouse(t *testing.T) {
+	type event struct {
+		x, y    int
+		buttons tcell.ButtonMask
+		after   time.Duration // Since the previous event
+	}
+	// tap returns a press and release at x, y, after the previous event
+	tap := func(x, y int, after time.Duration) []event {
+		return []event{{x, y, tcell.Button1, after}, {x, y, tcell.ButtonNone, 50 * time.Millisecond}}
+	}
+	tests := []struct {
+		name     string
+		events   []event
+		switched bool
+		touched  [][2]int
+		zoom     float64
+	}{
+		{name: "tap", events: tap(5, 5, 0), zoom: 1},
+		{name: "double tap", events: slices.Concat(tap(5, 5, 0), tap(6, 4, 100*time.Millisecond)), switched: true, zoom: 1},
+		{name: "taps far apart", events: slices.Concat(tap(5, 5, 0), tap(9, 5, 100*time.Millisecond)), zoom: 1},
+		{name: "taps too slow", events: slices.Concat(tap(5, 5, 0), tap(5, 5, time.Second)), zoom: 1},
+		{name: "triple tap", events: slices.Concat(tap(5, 5, 0), tap(5, 5, 100*time.Millisecond), tap(5, 5, 100*time.Millisecond)), switched: true, zoom: 1},
+		{name: "drag", events: []event{
+			{5, 5, tcell.Button1, 0}, {6, 5, tcell.Button1, 10 * time.Millisecond}, {6, 5, tcell.Button1, 10 * time.Millisecond},
+			{7, 6, tcell.Button1, 10 * time.Millisecond}, {7, 6, tcell.ButtonNone, 10 * time.Millisecond},
+		}, touched: [][2]int{{6, 5}, {7, 6}}, zoom: 1},
+		{name: "drag is no tap", events: slices.Concat(tap(5, 5, 0), []event{
+			{5, 5, tcell.Button1, 100 * time.Millisecond}, {6, 5, tcell.Button1, 10 * time.Millisecond}, {6, 5, tcell.ButtonNone, 10 * time.Millisecond},
+		}), touched: [][2]int{{6, 5}}, zoom: 1},
+		{name: "scroll up", events: []event{{0, 0, tcell.WheelUp, 0}, {0, 0, tcell.WheelUp, 0}}, zoom: wheelZoom * wheelZoom},
+		{name: "scroll down", events: []event{{0, 0, tcell.WheelDown, 0}}, zoom: 1 / wheelZoom},
+		{name: "zoomed in to the limit", events: slices.Repeat([]event{{0, 0, tcell.WheelUp, 0}}, 100), zoom: maxZoom},
+		{name: "zoomed out to the limit", events: slices.Repeat([]event{{0, 0, tcell.WheelDown, 0}}, 100), zoom: minZoom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			a := newReloadApp(t, cfg, func(c Config) (Config, error) { return c, nil })
+			rec := &touchRecorder{scene: a.scene}
+			a.scene = rec
+			now := time.Unix(0, 0)
+			for _, ev := range tt.events {
+				now = now.Add(ev.after)
+				a.handleMouse(tcell.NewEventMouse(ev.x, ev.y, ev.buttons, tcell.ModNone), now)
+			}
+			if switched := a.config.Scene != cfg.Scene; switched != tt.switched {
+				t.Errorf("scene %q after starting with %q, want switched %v", a.config.Scene, cfg.Scene, tt.switched)
+			}
+			if !slices.Equal(rec.touched, tt.touched) {
+				t.Errorf("touched %v, want %v", rec.touched, tt.touched)
+			}
+			if zoom := a.renderer.Camera().Zoom; zoom < tt.zoom-1e-9 || zoom > tt.zoom+1e-9 {
+				t.Errorf("zoom %v, want %v", zoom, tt.zoom)
+			}
+		})
+	}
+}
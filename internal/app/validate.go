@@ -0,0 +1,496 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/olegchuev/screensaver/internal/datasource"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/holiday"
+	"github.com/olegchuev/screensaver/internal/ledmatrix"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/presence"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/scenes/clock"
+	"github.com/olegchuev/screensaver/internal/scenes/fractal"
+	"github.com/olegchuev/screensaver/internal/scenes/lava"
+	"github.com/olegchuev/screensaver/internal/scenes/life"
+	"github.com/olegchuev/screensaver/internal/scenes/plasma"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/transition"
+	"github.com/olegchuev/screensaver/internal/wave"
+	"github.com/olegchuev/screensaver/internal/weather"
+)
+
+// Accepted ranges for the numeric settings.
+const (
+	minFPS, maxFPS   = 1, 60
+	minGrid, maxGrid = 2, 400
+	maxStars         = 10000
+	maxStarSpeed     = 4
+	maxPipeGrid      = 32
+	maxFish          = 100
+	maxBlobs         = 20
+	// Chat services limit how often a status may change
+	minPresenceInterval = 30 * time.Second
+)
+
+// Problem is one invalid setting found by Validate.
+type Problem struct {
+	// Setting is the flag name of the setting, e.g. "fps"
+	Setting string
+	// Source is where the value came from, e.g. "-fps" or "state.json:4"; empty for defaults
+	Source string
+	// Message explains what is wrong with the value
+	Message string
+}
+
+// String formats the problem as "source: setting: message". A flag
+// source already names the setting, so it is not repeated.
+func (p Problem) String() string {
+	switch {
+	case p.Source == "":
+		return p.Setting + ": " + p.Message
+	case strings.HasPrefix(p.Source, "-"):
+		return p.Source + ": " + p.Message
+	}
+	return p.Source + ": " + p.Setting + ": " + p.Message
+}
+
+// ValidationError lists every problem Validate found. It matches
+// ErrConfigInvalid with errors.Is.
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error reports the number of problems followed by one problem per line.
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrConfigInvalid.Error())
+	if len(e.Problems) == 1 {
+		b.WriteString(" (1 problem)")
+	} else {
+		fmt.Fprintf(&b, " (%d problems)", len(e.Problems))
+	}
+	for _, p := range e.Problems {
+		b.WriteString("\n  " + p.String())
+	}
+	return b.String()
+}
+
+// Unwrap makes a ValidationError match ErrConfigInvalid.
+func (e *ValidationError) Unwrap() error {
+	return ErrConfigInvalid
+}
+
+// SetSource records where a setting was given, so problems with it can
+// point there. main uses "-name" for flags set on the command line.
+func (c *Config) SetSource(setting, source string) {
+	if c.Sources == nil {
+		c.Sources = make(map[string]string)
+	}
+	c.Sources[setting] = source
+}
+
+// Validate checks every setting and returns a *ValidationError listing all
+// problems, or nil. It is called before the terminal is taken over, so the
+// report stays readable.
+func Validate(cfg Config) error {
+	var problems []Problem
+	report := func(setting, format string, args ...any) {
+		source, ok := cfg.Sources[setting]
+		if !ok {
+			source = cfg.sourceFile
+		}
+		problems = append(problems, Problem{Setting: setting, Source: source, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if _, ok := pluginName(cfg.Scene); ok {
+		if !cfg.Plugins {
+			report("scene", "the %s scene needs -plugins", cfg.Scene)
+		}
+	} else if cfg.Scene != "" && !slices.Contains(sceneNames, cfg.Scene) {
+		report("scene", "unknown scene %q (available: %s)", cfg.Scene, strings.Join(sceneNames, ", "))
+	}
+	if _, ok := theme.Lookup(cfg.Theme); !ok {
+		report("theme", "unknown theme %q (available: %s)", cfg.Theme, strings.Join(theme.Names(), ", "))
+	}
+	if fps := fpsOf(cfg.FrameDelay); fps < minFPS || fps > maxFPS {
+		report("fps", "%d is out of range, want %d to %d", fps, minFPS, maxFPS)
+	}
+	if cfg.FadeIn < 0 {
+		report("fade-in", "%v is negative", cfg.FadeIn)
+	}
+	if cfg.FadeOut < 0 {
+		report("fade-out", "%v is negative", cfg.FadeOut)
+	}
+	if _, err := transition.NewSwitch(cfg.Transition); err != nil {
+		report("transition", "%v", err)
+	}
+	if cfg.TransitionTime < 0 {
+		report("transition-time", "%v is negative", cfg.TransitionTime)
+	}
+	if cfg.Intro != "" && cfg.Intro != "melt" && cfg.Intro != "dissolve" {
+		report("intro", "unknown intro effect %q (available: melt, dissolve)", cfg.Intro)
+	}
+	if cfg.MOTD != "" && cfg.MOTDFile != "" {
+		report("motd-file", "cannot be combined with -motd, pick one")
+	}
+	if cfg.MOTDTime <= 0 {
+		report("motd-time", "%v must be positive", cfg.MOTDTime)
+	}
+
+	// Same limits as the color keybindings
+	inRange := func(setting string, v, lo, hi float64) {
+		if v < lo || v > hi {
+			report(setting, "%g is out of range, want %g to %g", v, lo, hi)
+		}
+	}
+	inRange("brightness", cfg.Color.Brightness, 0.05, 2)
+	inRange("contrast", cfg.Color.Contrast, 0.1, 3)
+	inRange("gamma", cfg.Color.Gamma, 0.2, 3)
+
+	if cfg.CellAspect <= 0 {
+		report("cell-aspect", "%g must be positive", cfg.CellAspect)
+	}
+	if !slices.Contains(colorDepthNames, cfg.ColorDepth) {
+		report("color-depth", "unknown color depth %q (available: %s)", cfg.ColorDepth, strings.Join(colorDepthNames, ", "))
+	}
+	if _, err := renderer.ParseMode(cfg.RenderMode); err != nil {
+		report("render-mode", "%v", err)
+	}
+	if _, err := renderer.ParseToneMap(cfg.ToneMap); err != nil {
+		report("tone-map", "%v", err)
+	}
+	if _, err := renderer.ParseShadeDither(cfg.ShadeDither); err != nil {
+		report("shade-dither", "%v", err)
+	}
+	if ramp := []rune(cfg.ShadeRamp); len(ramp) < 2 {
+		report("shade-ramp", "%q needs at least 2 characters", cfg.ShadeRamp)
+	} else if i := slices.IndexFunc(ramp, func(r rune) bool { return !unicode.IsPrint(r) }); i >= 0 {
+		report("shade-ramp", "%q has the unprintable character %U", cfg.ShadeRamp, ramp[i])
+	}
+	if cfg.Kaleidoscope < 0 {
+		report("kaleidoscope", "%d segments is negative, use 0 to disable", cfg.Kaleidoscope)
+	}
+	if h := cfg.Holiday; h != "" && h != "off" && !slices.Contains(holiday.Effects, h) {
+		report("holiday", "unknown effect %q (available: %s, off)", h, strings.Join(holiday.Effects, ", "))
+	}
+	inRange("beat-sensitivity", cfg.Beat.Sensitivity, 0, 1)
+	if cfg.Beat.MinInterval < 0 {
+		report("beat-interval", "%v is negative", cfg.Beat.MinInterval)
+	}
+	if cfg.HideOverlays < 0 {
+		report("hide-overlays", "%v is negative, use 0 to keep the overlays", cfg.HideOverlays)
+	}
+	if cfg.LogoWidth < 1 {
+		report("logo-width", "%d must be at least 1", cfg.LogoWidth)
+	}
+	inRange("logo-opacity", cfg.LogoOpacity, 0, 1)
+	if cfg.LogoDrift < 0 {
+		report("logo-drift", "%d is negative, use 0 to keep the logo still", cfg.LogoDrift)
+	}
+	if cfg.Logo != "" {
+		if _, err := overlay.LoadLogo(cfg.Logo, max(cfg.LogoWidth, 1), cfg.LogoOpacity, cfg.LogoDrift); err != nil {
+			report("logo", "%v", err)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(cfg.Placements)) {
+		p := cfg.Placements[name]
+		if !knownOverlay(name) {
+			report("place", "unknown overlay %q (available: %s)", name, strings.Join(overlayNames(), ", "))
+		}
+		if p.Anchor < overlay.TopLeft || p.Anchor > overlay.BottomRight {
+			report("place", "%s: %v is no anchor", name, p.Anchor)
+		}
+		if p.Margin < 0 {
+			report("place", "%s: margin %d is negative", name, p.Margin)
+		}
+	}
+	// Pages only pick from the overlays set up with their own settings
+	shown := map[string]string{"ticker": cfg.Ticker, "logo": cfg.Logo, "weather": cfg.Weather}
+	for _, p := range cfg.Pages {
+		for _, name := range p.Overlays {
+			if _, ok := pluginName(name); ok {
+				if !cfg.Plugins {
+					report("page", "%s: the %s overlay needs -plugins", p, name)
+				}
+				continue
+			}
+			if shown[name] == "" {
+				report("page", "%s: the %s overlay needs -%s", p, name, name)
+			}
+		}
+	}
+	if len(cfg.Pages) > 1 && cfg.PageTime <= 0 {
+		report("page-time", "%v must be positive", cfg.PageTime)
+	}
+	if cfg.PluginBudget <= 0 {
+		report("plugin-budget", "%v must be positive", cfg.PluginBudget)
+	}
+	inRange("plugin-cpu", cfg.PluginCPU, 0, 1)
+	if cfg.HeatmapSource != "" {
+		if err := datasource.Check(cfg.HeatmapSource); err != nil {
+			report("heatmap-source", "%v", err)
+		}
+	}
+	if cfg.SourceTTL <= 0 {
+		report("source-ttl", "%v must be positive", cfg.SourceTTL)
+	}
+	if cfg.Ticker != "" && cfg.TickerSpeed <= 0 {
+		report("ticker-speed", "%g must be positive", cfg.TickerSpeed)
+	}
+
+	if cfg.Fullscreen && !cfg.Window {
+		report("fullscreen", "only applies together with -window")
+	}
+	if !slices.Contains(imageProtocols, cfg.Images) {
+		report("images", "unknown image protocol %q (available: %s)", cfg.Images, strings.Join(imageProtocols, ", "))
+	}
+	if cfg.Framebuffer != "" && cfg.Window {
+		report("framebuffer", "cannot be combined with -window")
+	}
+	if cfg.EInk != nil {
+		if cfg.Window || cfg.Framebuffer != "" {
+			report("eink", "cannot be combined with -window or -framebuffer")
+		}
+		if cfg.EInk.FullEvery < 1 {
+			report("eink-full-every", "%d must be at least 1", cfg.EInk.FullEvery)
+		}
+	}
+	if len(cfg.LED) > 0 && (cfg.Window || cfg.Framebuffer != "" || cfg.EInk != nil) {
+		report("led", "cannot be combined with -window, -framebuffer or -eink")
+	}
+	if cfg.Mic && (cfg.Record != "" || cfg.Replay != nil) {
+		report("mic", "cannot be combined with -record or -replay, a replay has no gusts to play back")
+	}
+	if cfg.Gamepad && (cfg.Record != "" || cfg.Replay != nil) {
+		report("gamepad", "cannot be combined with -record or -replay, controller input is not recorded")
+	}
+	if cfg.Location == locateByIP {
+		if cfg.Record != "" || cfg.Replay != nil {
+			report("location", "ip cannot be combined with -record or -replay, the place could differ on playback")
+		}
+	} else if cfg.Location != "" {
+		if _, err := weather.ParseLocation(cfg.Location); err != nil {
+			report("location", "%v, or ip", err)
+		}
+	}
+	if cfg.Weather != "" {
+		if _, err := weather.ParseLocation(cfg.Weather); err != nil {
+			report("weather", "%v", err)
+		} else if cfg.Record != "" || cfg.Replay != nil {
+			report("weather", "cannot be combined with -record or -replay, the forecast would differ on playback")
+		}
+	}
+	if cfg.Touch && (cfg.Record != "" || cfg.Replay != nil) {
+		report("touch", "cannot be combined with -record or -replay, gestures are not recorded")
+	}
+	if cfg.DebugDumpEvery < 1 {
+		report("debug-dump-every", "%d must be positive", cfg.DebugDumpEvery)
+	}
+	for _, spec := range cfg.Presence {
+		if _, err := presence.Open(spec); err != nil {
+			report("presence", "%v", err)
+		}
+	}
+	if len(cfg.Presence) > 0 && cfg.PresenceInterval < minPresenceInterval {
+		report("presence-interval", "%v is too short, want at least %v", cfg.PresenceInterval, minPresenceInterval)
+	}
+	for _, spec := range cfg.LED {
+		if _, err := ledmatrix.Open(spec); err != nil {
+			report("led", "%v", err)
+		}
+	}
+	// Loading a BDF file takes a while, and terminals draw with their own font
+	if rasterizes(cfg) {
+		if _, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y); err != nil {
+			report("font", "%v", err)
+		}
+	}
+
+	if _, ok := wave.LookupPreset(cfg.Preset); cfg.Preset != "" && !ok {
+		report("preset", "unknown preset %q (available: %s)", cfg.Preset, strings.Join(wave.PresetNames(), ", "))
+	}
+	wc := cfg.WaveConfig
+	if wc.GridWidth < minGrid || wc.GridWidth > maxGrid {
+		report("grid-width", "%d is out of range, want %d to %d", wc.GridWidth, minGrid, maxGrid)
+	}
+	if wc.GridDepth < minGrid || wc.GridDepth > maxGrid {
+		report("grid-depth", "%d is out of range, want %d to %d", wc.GridDepth, minGrid, maxGrid)
+	}
+	if len(wc.Components) == 0 && (wc.WaveCount < 1 || wc.WaveCount > wave.MaxWaveCount) {
+		report("wave-count", "%d is out of range, want 1 to %d", wc.WaveCount, wave.MaxWaveCount)
+	}
+	for i, c := range wc.Components {
+		switch {
+		case c.Amplitude <= 0 || c.Wavelength <= 0 || c.Speed <= 0:
+			report("components", "wave %d needs a positive amplitude, wavelength and speed", i+1)
+		case c.Direction.X == 0 && c.Direction.Y == 0:
+			report("components", "wave %d has no direction", i+1)
+		case c.Steepness < 0 || c.Steepness > 1:
+			report("components", "wave %d steepness %g is out of range, want 0 to 1", i+1, c.Steepness)
+		}
+	}
+	if wc.ParticleDensity < 0 || wc.ParticleDensity > 1 {
+		report("density", "%g is out of range, want 0 to 1", wc.ParticleDensity)
+	}
+	if wc.Steepness <= 0 {
+		report("steepness", "%g must be positive", wc.Steepness)
+	} else if sum := wc.CombinedSteepness(); sum > 1 {
+		report("steepness", "%g makes the wave crests loop over themselves (combined steepness %.2f, must be at most 1)", wc.Steepness, sum)
+	}
+	if wc.Shadows < 0 || wc.Shadows > 1 {
+		report("shadows", "%g is out of range, want 0 to 1", wc.Shadows)
+	}
+	if wc.Light.Z <= 0 {
+		report("light", "the light must shine from above the water, with a positive Z")
+	}
+
+	if err := life.ValidSurface(cfg.LifeConfig.Surface); err != nil {
+		report("life-surface", "%v", err)
+	}
+	if n := cfg.StarfieldConfig.Stars; n < 1 || n > maxStars {
+		report("stars", "%d is out of range, want 1 to %d", n, maxStars)
+	}
+	if v := cfg.StarfieldConfig.Speed; v <= 0 {
+		report("star-speed", "%g must be positive", v)
+	} else if v > maxStarSpeed {
+		report("star-speed", "%g is too fast, want at most %d depths of the field per second", v, maxStarSpeed)
+	}
+	if cfg.MatrixConfig.Speed <= 0 {
+		report("rain-speed", "%g must be positive", cfg.MatrixConfig.Speed)
+	}
+	inRange("rain-density", cfg.MatrixConfig.Density, 0.01, 1)
+	if err := plasma.ValidPalette(cfg.PlasmaConfig.Palette); err != nil {
+		report("plasma-palette", "%v", err)
+	}
+	inRange("turbulence", cfg.PlasmaConfig.Turbulence, 0, 4)
+	if cfg.PlasmaConfig.Speed <= 0 {
+		report("plasma-speed", "%g must be positive", cfg.PlasmaConfig.Speed)
+	}
+	inRange("fire-intensity", cfg.FireConfig.Intensity, 0.1, 2)
+	inRange("fire-wind", cfg.FireConfig.Wind, -1, 1)
+	if n := cfg.PipesConfig.Size; n < 2 || n > maxPipeGrid {
+		report("pipe-grid", "%d is out of range, want 2 to %d", n, maxPipeGrid)
+	}
+	if cfg.PipesConfig.Speed <= 0 {
+		report("pipe-speed", "%g must be positive", cfg.PipesConfig.Speed)
+	}
+	inRange("pipe-turns", cfg.PipesConfig.Turns, 0, 1)
+	inRange("pipe-fill", cfg.PipesConfig.Fill, 0.01, 1)
+	inRange("snow-density", cfg.SnowConfig.Density, 0.1, 5)
+	inRange("snow-wind", cfg.SnowConfig.Wind, -1, 1)
+	if cfg.SnowConfig.Melt < 0 {
+		report("snow-melt", "%g is negative, use 0 to keep the snow", cfg.SnowConfig.Melt)
+	}
+	inRange("rain", cfg.RipplesConfig.Rain, 0, 10)
+	inRange("ripple-damping", cfg.RipplesConfig.Damping, 0, 0.999)
+	if n := cfg.AquariumConfig.Fish; n < 0 || n > maxFish {
+		report("fish", "%d is out of range, want 0 to %d", n, maxFish)
+	}
+	inRange("fish-speed", cfg.AquariumConfig.Speed, 0.1, 5)
+	if n := cfg.LavaConfig.Blobs; n < 0 || n > maxBlobs {
+		report("lava-blobs", "%d is out of range, want 0 to %d", n, maxBlobs)
+	}
+	inRange("lava-speed", cfg.LavaConfig.Speed, 0.1, 5)
+	if err := lava.ValidPalette(cfg.LavaConfig.Palette); err != nil {
+		report("lava-palette", "%v", err)
+	}
+	inRange("dna-speed", cfg.DNAConfig.Speed, 0, 5)
+	if err := fractal.ValidSet(cfg.FractalConfig.Set); err != nil {
+		report("fractal-set", "%v", err)
+	}
+	inRange("fractal-speed", cfg.FractalConfig.Speed, 0.1, 5)
+	inRange("fractal-depth", cfg.FractalConfig.Depth, 1, 280)
+	if strings.TrimSpace(strings.Join(cfg.BounceConfig.Logo, "")) == "" {
+		report("bounce-text", "the logo is blank")
+	}
+	inRange("bounce-speed", cfg.BounceConfig.Speed, 0.1, 10)
+	if err := clock.ValidStyle(cfg.ClockConfig.Style); err != nil {
+		report("clock-style", "%v", err)
+	}
+	if err := clock.ValidZone(cfg.ClockConfig.Zone); err != nil {
+		report("clock-zone", "%v", err)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: problems}
+}
+
+// rasterizes reports whether frames are drawn with the raster font, in a
+// window, on a console framebuffer, e-ink display or LED matrix, as video
+// or as iTerm2 images. Commands such as snapshot and calibrate load the
+// font themselves.
+func rasterizes(cfg Config) bool {
+	return cfg.Window || cfg.Framebuffer != "" || cfg.EInk != nil || len(cfg.LED) > 0 ||
+		cfg.Video != nil || useITerm(cfg)
+}
+
+// keyLines returns the line every object key in a JSON document starts on,
+// keyed by the lower cased name. Nested keys are included under their own
+// name, which is unique enough for the flat settings files.
+func keyLines(data []byte) map[string]int {
+	// Each open container, and for objects whether a key comes next
+	type container struct{ object, key bool }
+
+	lines := make(map[string]int)
+	var stack []container
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return lines
+		}
+		if n := len(stack); n > 0 && stack[n-1].object && stack[n-1].key {
+			if key, ok := tok.(string); ok {
+				lines[strings.ToLower(key)] = lineAt(data, dec.InputOffset())
+				stack[n-1].key = false
+				continue
+			}
+		}
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, container{object: true, key: true})
+			continue
+		case json.Delim('['):
+			stack = append(stack, container{})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+		// A value is complete, so the enclosing object expects a key next
+		if n := len(stack); n > 0 && stack[n-1].object {
+			stack[n-1].key = true
+		}
+	}
+}
+
+// lineAt returns the 1-based line of the byte offset in data.
+func lineAt(data []byte, offset int64) int {
+	offset = min(offset, int64(len(data)))
+	return 1 + bytes.Count(data[:offset], []byte("\n"))
+}
+
+// jsonError prefixes a decoding error with the file and, where the error
+// knows it, the line it happened on.
+func jsonError(path string, data []byte, err error) error {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		return fmt.Errorf("%s:%d: %w", path, lineAt(data, syntax.Offset), err)
+	case errors.As(err, &typ):
+		return fmt.Errorf("%s:%d: %w", path, lineAt(data, typ.Offset), err)
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
@@ -0,0 +1,218 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/font"
+)
+
+// VideoOptions configures serving the animation as a video stream over
+// HTTP, for example as a browser source in OBS.
+type VideoOptions struct {
+	// Addr is the TCP address the HTTP server listens on, by default only
+	// on this machine, where OBS usually runs; ":8090" serves every network
+	Addr string
+	// Width and Height are the size of the screen in cells; the frames are
+	// as many pixels as the font's cells take
+	Width, Height int
+	// Transparent turns dark pixels transparent, so the animation can be
+	// laid over other video
+	Transparent bool
+	// Log receives the address the server listens on, nil discards it
+	Log *log.Logger
+}
+
+// DefaultVideoOptions returns options for a 96x27 cell screen, which is
+// close to 16:9 with the default font, served to this machine only.
+func DefaultVideoOptions() VideoOptions {
+	return VideoOptions{Addr: "localhost:8090", Width: 96, Height: 27}
+}
+
+// videoPage shows the stream scaled to fill a browser source.
+const videoPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>screensaver</title>
+<style>
+html, body { margin: 0; height: 100%; overflow: hidden; background: transparent; }
+img { width: 100%; height: 100%; object-fit: contain; }
+</style>
+</head>
+<body><img src="/stream" alt=""></body>
+</html>
+`
+
+// videoBoundary separates the frames of the stream.
+const videoBoundary = "frame"
+
+// videoScreen is a simulated screen whose frames are rasterized with a font
+// and sent to every viewer of the HTTP stream as PNG images. Frames are
+// only rendered while someone is watching.
+type videoScreen struct {
+	trueColorScreen
+	face        font.Face
+	transparent bool
+	server      *http.Server
+	enc         png.Encoder
+	buf         bytes.Buffer
+
+	mu      sync.Mutex
+	viewers map[chan []byte]struct{}
+}
+
+// openVideo creates the simulated screen and starts serving it.
+func openVideo(opts VideoOptions, cfg Config) (tcell.Screen, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, invalidConfig(err)
+	}
+	ln, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	sim.SetSize(opts.Width, opts.Height)
+
+	s := &videoScreen{
+		trueColorScreen: trueColorScreen{sim},
+		face:            face,
+		transparent:     opts.Transparent,
+		enc:             png.Encoder{CompressionLevel: png.BestSpeed},
+		viewers:         make(map[chan []byte]struct{}),
+	}
+	s.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, videoPage)
+	})
+	mux.HandleFunc("GET /stream", s.serveStream)
+	mux.HandleFunc("GET /frame.png", s.serveFrame)
+	s.server = &http.Server{Handler: mux}
+	go s.server.Serve(ln)
+	if opts.Log != nil {
+		cw, ch := face.CellSize()
+		opts.Log.Printf("serving %dx%d pixel video on http://%s/", opts.Width*cw, opts.Height*ch, ln.Addr())
+	}
+	return s, nil
+}
+
+// watch registers a viewer, returning the channel its frames arrive on and
+// a function to stop watching.
+func (s *videoScreen) watch() (chan []byte, func()) {
+	frames := make(chan []byte, 1)
+	s.mu.Lock()
+	s.viewers[frames] = struct{}{}
+	s.mu.Unlock()
+	return frames, func() {
+		s.mu.Lock()
+		delete(s.viewers, frames)
+		s.mu.Unlock()
+	}
+}
+
+// serveStream sends frames as they are rendered, each replacing the last,
+// which browsers show like a video.
+func (s *videoScreen) serveStream(w http.ResponseWriter, r *http.Request) {
+	frames, stop := s.watch()
+	defer stop()
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+videoBoundary)
+	w.Header().Set("Cache-Control", "no-store")
+	flusher, _ := w.(http.Flusher)
+	for {
+		select {
+		case frame := <-frames:
+			header := "--" + videoBoundary + "\r\nContent-Type: image/png\r\nContent-Length: " + strconv.Itoa(len(frame)) + "\r\n\r\n"
+			if _, err := io.WriteString(w, header); err != nil {
+				return
+			}
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+			if _, err := io.WriteString(w, "\r\n"); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// serveFrame sends the next frame as a single image.
+func (s *videoScreen) serveFrame(w http.ResponseWriter, r *http.Request) {
+	frames, stop := s.watch()
+	defer stop()
+	select {
+	case frame := <-frames:
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(frame)
+	case <-r.Context().Done():
+	}
+}
+
+// Show encodes the frame and hands it to every viewer. Viewers still busy
+// with the previous frame skip this one.
+func (s *videoScreen) Show() {
+	s.SimulationScreen.Show()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.viewers) == 0 {
+		return
+	}
+	var img image.Image = snapshotImage(screenGrid(s), s.face)
+	if s.transparent {
+		img = lumaKey(img.(*image.RGBA))
+	}
+	s.buf.Reset()
+	if err := s.enc.Encode(&s.buf, img); err != nil {
+		return
+	}
+	// Viewers keep the frame while they write it, so each frame gets its own copy
+	frame := bytes.Clone(s.buf.Bytes())
+	for viewer := range s.viewers {
+		select {
+		case viewer <- frame:
+		default:
+		}
+	}
+}
+
+// Fini stops the server, ending every stream.
+func (s *videoScreen) Fini() {
+	s.server.Close()
+	s.SimulationScreen.Fini()
+}
+
+// lumaKey makes dark pixels of img transparent: every pixel becomes its
+// brightest channel opaque, so black disappears and colors fading towards
+// black fade out instead.
+func lumaKey(img *image.RGBA) *image.NRGBA {
+	out := image.NewNRGBA(img.Bounds())
+	for i := 0; i < len(img.Pix); i += 4 {
+		r, g, b := int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+		a := max(r, g, b)
+		if a == 0 {
+			continue
+		}
+		out.Pix[i], out.Pix[i+1], out.Pix[i+2], out.Pix[i+3] = uint8(r*255/a), uint8(g*255/a), uint8(b*255/a), uint8(a)
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/datasource"
+	"github.com/olegchuev/screensaver/internal/weather"
+)
+
+// Forecasts are refreshed this often, and retried from this soon after a
+// failure, backing off up to the refresh interval.
+const (
+	weatherRefresh = 30 * time.Minute
+	weatherRetry   = 2 * time.Minute
+)
+
+// weatherCachePath returns the location of the weather cache in the user
+// cache directory, empty if there is none.
+func weatherCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "screensaver", "weather.json")
+}
+
+// forecast keeps the weather for the forecast panel up to date in the
+// background, fetched from Open-Meteo as a data source. The cache file
+// makes the report available at once and saves a request when it is
+// recent, and the last report stays through failed refreshes, so a laptop
+// going offline still shows the weather.
+type forecast struct {
+	src     *datasource.Source
+	reports *datasource.Binding[weather.Report]
+}
+
+// openWeather starts following the weather at the configured place,
+// returning nil if none is.
+func openWeather(cfg Config) (*forecast, error) {
+	if cfg.Weather == "" {
+		return nil, nil
+	}
+	loc, err := weather.ParseLocation(cfg.Weather)
+	if err != nil {
+		return nil, err
+	}
+	// The cache is only taken for the same request, so the same place and units
+	src, err := datasource.Open(weather.ForecastURL(loc, cfg.Fahrenheit), datasource.Options{
+		TTL:     weatherRefresh,
+		Backoff: datasource.Backoff{Min: weatherRetry, Max: weatherRefresh, Factor: 2},
+		Cache:   weatherCachePath(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &forecast{src: src, reports: datasource.Bind(src, weather.Decode)}, nil
+}
+
+// Report returns the latest report, and false while there is none yet.
+func (f *forecast) Report() (weather.Report, bool) {
+	rep, ok := f.reports.Get()
+	rep.Fetched = f.reports.Received()
+	return rep, ok
+}
+
+// Close stops refreshing.
+func (f *forecast) Close() {
+	f.src.Close()
+}
@@ -0,0 +1,250 @@
+//go:build ebiten
+
+package app
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/olegchuev/screensaver/internal/font"
+)
+
+const (
+	// Initial window size in cells
+	windowCols = 120
+	windowRows = 36
+	// Ticks before a held key starts repeating, and between repeats
+	keyRepeatDelay    = 30
+	keyRepeatInterval = 4
+	// Pixels the mouse may drift before a fullscreen window quits
+	mouseSlop = 10
+)
+
+// windowKeys maps the keys the app reacts to onto their terminal equivalents.
+var windowKeys = map[ebiten.Key]tcell.Key{
+	ebiten.KeyEscape:    tcell.KeyEscape,
+	ebiten.KeyTab:       tcell.KeyTab,
+	ebiten.KeyEnter:     tcell.KeyEnter,
+	ebiten.KeyBackspace: tcell.KeyBackspace2,
+	ebiten.KeyUp:        tcell.KeyUp,
+	ebiten.KeyDown:      tcell.KeyDown,
+	ebiten.KeyLeft:      tcell.KeyLeft,
+	ebiten.KeyRight:     tcell.KeyRight,
+	ebiten.KeyPageUp:    tcell.KeyPgUp,
+	ebiten.KeyPageDown:  tcell.KeyPgDn,
+}
+
+// window shows a simulated screen in a graphical window. The app draws to
+// the screen as if it were a terminal; the window paints its cells with a
+// raster font and feeds keyboard input back as terminal key events.
+type window struct {
+	sim    tcell.SimulationScreen
+	face   font.Face
+	cellW  int
+	cellH  int
+	glyphs map[rune]*ebiten.Image // White coverage masks, tinted per cell
+	keys   []ebiten.Key
+	chars  []rune
+
+	// Fullscreen windows quit on any input, with the cursor position at
+	// the first frame to notice mouse movement
+	fullscreen bool
+	cursor     image.Point
+	cursorSet  bool
+
+	done    chan struct{} // Closed when the app loop has returned
+	closing sync.Once
+}
+
+// openWindow creates the simulated screen the window displays.
+func openWindow(cfg Config) (tcell.Screen, *window, error) {
+	face, err := font.Load(cfg.Font, cfg.CellPixels.X, cfg.CellPixels.Y)
+	if err != nil {
+		return nil, nil, invalidConfig(err)
+	}
+	cellW, cellH := face.CellSize()
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return nil, nil, err
+	}
+	sim.SetSize(windowCols, windowRows)
+
+	w := &window{
+		sim:    sim,
+		face:   face,
+		cellW:  cellW,
+		cellH:  cellH,
+		glyphs: make(map[rune]*ebiten.Image),
+		done:   make(chan struct{}),
+
+		fullscreen: cfg.Fullscreen,
+	}
+	screen := trueColorScreen{sim}
+	screen.SetStyle(tcell.StyleDefault.Background(tcell.ColorBlack))
+	screen.HideCursor()
+	return screen, w, nil
+}
+
+// run opens the window and runs loop alongside it until loop returns.
+func (w *window) run(loop func() error) error {
+	ebiten.SetWindowTitle("screensaver")
+	ebiten.SetWindowSize(windowCols*w.cellW, windowRows*w.cellH)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	// Closing the window quits like a key press, so the fade-out still plays
+	ebiten.SetWindowClosingHandled(true)
+	if w.fullscreen {
+		ebiten.SetFullscreen(true)
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	}
+
+	var err error
+	go func() {
+		err = loop()
+		close(w.done)
+	}()
+	if runErr := ebiten.RunGame(w); runErr != nil {
+		w.quit()
+		<-w.done
+		return runErr
+	}
+	<-w.done
+	return err
+}
+
+// quit asks the app loop to exit.
+func (w *window) quit() {
+	w.closing.Do(func() { w.sim.InjectKey(tcell.KeyCtrlC, 0, tcell.ModNone) })
+}
+
+// Update forwards keyboard input and ends the game once the app has quit.
+func (w *window) Update() error {
+	select {
+	case <-w.done:
+		return ebiten.Termination
+	default:
+	}
+	if ebiten.IsWindowBeingClosed() {
+		w.quit()
+	}
+	if w.fullscreen {
+		if w.touched() {
+			w.quit()
+		}
+		return nil
+	}
+
+	var mod tcell.ModMask
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		mod |= tcell.ModShift
+	}
+	ctrl := ebiten.IsKeyPressed(ebiten.KeyControl)
+	if ctrl && inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		w.quit()
+	}
+
+	w.keys = inpututil.AppendPressedKeys(w.keys[:0])
+	for _, k := range w.keys {
+		tk, ok := windowKeys[k]
+		if !ok || !repeating(k) {
+			continue
+		}
+		if tk == tcell.KeyTab && mod&tcell.ModShift != 0 {
+			tk = tcell.KeyBacktab
+		}
+		w.sim.InjectKey(tk, 0, mod)
+	}
+	if !ctrl {
+		w.chars = ebiten.AppendInputChars(w.chars[:0])
+		for _, r := range w.chars {
+			w.sim.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+	}
+	return nil
+}
+
+// touched reports whether a key or mouse button was pressed, or the mouse
+// moved more than a few pixels, since the first frame.
+func (w *window) touched() bool {
+	cursor := image.Pt(ebiten.CursorPosition())
+	if !w.cursorSet {
+		w.cursor, w.cursorSet = cursor, true
+	}
+	if d := cursor.Sub(w.cursor); d.X*d.X+d.Y*d.Y > mouseSlop*mouseSlop {
+		return true
+	}
+	if len(inpututil.AppendJustPressedKeys(w.keys[:0])) > 0 {
+		return true
+	}
+	for b := ebiten.MouseButton0; b <= ebiten.MouseButtonMax; b++ {
+		if inpututil.IsMouseButtonJustPressed(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// repeating reports whether a held key produces a key event this tick.
+func repeating(k ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(k)
+	return d == 1 || (d > keyRepeatDelay && (d-keyRepeatDelay)%keyRepeatInterval == 0)
+}
+
+// Draw paints every cell of the simulated screen.
+func (w *window) Draw(dst *ebiten.Image) {
+	dst.Fill(color.Black)
+	cells, cols, rows := w.sim.GetContents()
+
+	for y := range rows {
+		for x := range cols {
+			c := newSnapshotCell(cells[y*cols+x])
+			px, py := float64(x*w.cellW), float64(y*w.cellH)
+			if c.bg != (color.RGBA{0, 0, 0, 255}) {
+				vector.DrawFilledRect(dst, float32(px), float32(py), float32(w.cellW), float32(w.cellH), c.bg, false)
+			}
+			if c.char == ' ' {
+				continue
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(px, py)
+			op.ColorScale.ScaleWithColor(c.fg)
+			dst.DrawImage(w.glyph(c.char), op)
+		}
+	}
+}
+
+// glyph returns the white coverage mask of r, see cellMask.
+func (w *window) glyph(r rune) *ebiten.Image {
+	if img, ok := w.glyphs[r]; ok {
+		return img
+	}
+	mask := cellMask(w.face, r)
+	pix := make([]byte, 4*w.cellW*w.cellH)
+	for py := range w.cellH {
+		for px := range w.cellW {
+			a := mask.AlphaAt(px, py).A
+			i := 4 * (py*w.cellW + px)
+			// Premultiplied alpha
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = a, a, a, a
+		}
+	}
+	img := ebiten.NewImage(w.cellW, w.cellH)
+	img.WritePixels(pix)
+	w.glyphs[r] = img
+	return img
+}
+
+// Layout resizes the simulated screen to fill the window with whole cells.
+func (w *window) Layout(outsideWidth, outsideHeight int) (int, int) {
+	cols, rows := max(outsideWidth/w.cellW, 1), max(outsideHeight/w.cellH, 1)
+	if cw, ch := w.sim.Size(); cw != cols || ch != rows {
+		w.sim.SetSize(cols, rows)
+		w.sim.PostEvent(tcell.NewEventResize(cols, rows))
+	}
+	return outsideWidth, outsideHeight
+}
@@ -0,0 +1,22 @@
+//go:build !ebiten
+
+package app
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// window is unavailable in builds without the ebiten tag.
+type window struct{}
+
+// openWindow reports that the window mode was not compiled in.
+func openWindow(cfg Config) (tcell.Screen, *window, error) {
+	return nil, nil, invalidConfig(errors.New("this build has no window support, rebuild with -tags ebiten"))
+}
+
+// run is never called, since openWindow always fails.
+func (w *window) run(loop func() error) error {
+	return loop()
+}
@@ -0,0 +1,130 @@
+// Package audio measures the loudness of recorded audio over time, so
+// scenes can react to music.
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+)
+
+// windowSize is the number of samples analyzed for each moment, about 46ms
+// at 44.1kHz. It is a power of two for the FFT.
+const windowSize = 2048
+
+// Frequency bands in Hz, from the lower edge of the bass to the upper edge
+// of the treble.
+const (
+	bassLow    = 20
+	bassHigh   = 250
+	trebleLow  = 4000
+	trebleHigh = 16000
+)
+
+// floorDB is the loudness shown as 0; full scale is 1.
+const floorDB = -48
+
+// Levels is the loudness of the audio around a moment, each from 0 for
+// silence to 1 for full scale.
+type Levels struct {
+	// Level is the overall loudness
+	Level float64
+	// Bass, Mid and Treble are the loudness below 250 Hz, up to 4 kHz and
+	// above
+	Bass, Mid, Treble float64
+}
+
+// Clip is decoded audio, mixed down to one channel.
+type Clip struct {
+	// Rate is the number of samples per second
+	Rate int
+	// Samples are the sample values from -1 to 1
+	Samples []float32
+}
+
+// Duration returns the length of the clip.
+func (c *Clip) Duration() time.Duration {
+	if c.Rate == 0 {
+		return 0
+	}
+	return time.Duration(len(c.Samples)) * time.Second / time.Duration(c.Rate)
+}
+
+// Levels measures the audio just before at. It depends on nothing but the
+// clip and at, so renders driven by it repeat exactly. Past the end of the
+// clip everything is silent.
+func (c *Clip) Levels(at time.Duration) Levels {
+	end := int(at.Seconds() * float64(c.Rate))
+	if end <= 0 || end > len(c.Samples) {
+		return Levels{}
+	}
+
+	// Hann windowed, with the gain corrected so a full scale sine reads 1
+	buf := make([]complex128, windowSize)
+	var sumSq float64
+	for i := range buf {
+		j := end - windowSize + i
+		if j < 0 {
+			continue
+		}
+		s := float64(c.Samples[j])
+		sumSq += s * s
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/windowSize)
+		buf[i] = complex(s*w, 0)
+	}
+	fft(buf)
+
+	binHz := float64(c.Rate) / windowSize
+	band := func(lo, hi float64) float64 {
+		var energy float64
+		for k := max(int(lo/binHz), 1); k <= min(int(hi/binHz), windowSize/2-1); k++ {
+			m := cmplx.Abs(buf[k])
+			energy += m * m
+		}
+		// A sine's energy spreads over 1.5 bins of the Hann window, each
+		// peaking at a quarter of the window
+		return scale(math.Sqrt(energy/1.5) / (windowSize / 4))
+	}
+	return Levels{
+		Level:  scale(math.Sqrt(2 * sumSq / windowSize)),
+		Bass:   band(bassLow, bassHigh),
+		Mid:    band(bassHigh, trebleLow),
+		Treble: band(trebleLow, trebleHigh),
+	}
+}
+
+// scale maps an amplitude to 0-1 on a decibel scale.
+func scale(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return 0
+	}
+	db := 20 * math.Log10(amplitude)
+	return min(max((db-floorDB)/-floorDB, 0), 1)
+}
+
+// fft transforms x in place; its length must be a power of two.
+func fft(x []complex128) {
+	n := len(x)
+	// Bit reversed order, so the butterflies can work in place
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
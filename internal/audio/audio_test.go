@@ -0,0 +1,167 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// wavFile encodes 16 bit stereo samples, the left channel carrying
+// samples and the right silence, with an extra chunk before the data.
+func wavFile(rate int, samples []float64) []byte {
+	var data bytes.Buffer
+	for _, s := range samples {
+		binary.Write(&data, binary.LittleEndian, int16(s*32767))
+		binary.Write(&data, binary.LittleEndian, int16(0))
+	}
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+24+10+8+data.Len()))
+	b.WriteString("WAVEfmt ")
+	for _, v := range []any{uint32(16), uint16(formatPCM), uint16(2), uint32(rate), uint32(rate * 4), uint16(4), uint16(16)} {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	b.WriteString("LIST")
+	binary.Write(&b, binary.LittleEndian, uint32(1))
+	b.WriteString("x\x00") // Odd sized chunk with its padding byte
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(data.Len()))
+	b.Write(data.Bytes())
+	return b.Bytes()
+}
+
+// sine returns a second of a sine wave.
+func sine(rate int, hz, amplitude float64) []float64 {
+	s := make([]float64, rate)
+	for i := range s {
+		s[i] = amplitude * math.Sin(2*math.Pi*hz*float64(i)/float64(rate))
+	}
+	return s
+}
+
+func TestReadWAV(t *testing.T) {
+	clip, err := ReadWAV(bytes.NewReader(wavFile(8000, []float64{0, 0.5, -1})))
+	if err != nil {
+		t.Fatalf("ReadWAV: %v", err)
+	}
+	if clip.Rate != 8000 || len(clip.Samples) != 3 {
+		t.Fatalf("got rate %d with %d samples, want 8000 with 3", clip.Rate, len(clip.Samples))
+	}
+	// Mixed with the silent right channel
+	for i, want := range []float32{0, 0.25, -0.5} {
+		if math.Abs(float64(clip.Samples[i]-want)) > 1e-3 {
+			t.Errorf("sample %d: got %v, want %v", i, clip.Samples[i], want)
+		}
+	}
+
+	if _, err := ReadWAV(bytes.NewReader([]byte("RIFF\x00\x00\x00\x00AVI "))); err == nil {
+		t.Error("ReadWAV accepted a file that is not WAV")
+	}
+}
+
+func TestLevels(t *testing.T) {
+	const rate = 44100
+	tests := []struct {
+		hz   float64
+		band func(Levels) float64
+	}{
+		{100, func(l Levels) float64 { return l.Bass }},
+		{1000, func(l Levels) float64 { return l.Mid }},
+		{8000, func(l Levels) float64 { return l.Treble }},
+	}
+	for _, tt := range tests {
+		clip := &Clip{Rate: rate}
+		for _, s := range sine(rate, tt.hz, 1) {
+			clip.Samples = append(clip.Samples, float32(s))
+		}
+		l := clip.Levels(500 * time.Millisecond)
+		if l.Level < 0.98 {
+			t.Errorf("%g Hz: full scale level %.2f, want 1", tt.hz, l.Level)
+		}
+		if got := tt.band(l); got < 0.95 {
+			t.Errorf("%g Hz: own band %.2f, want about 1 in %+v", tt.hz, got, l)
+		}
+		if sum := l.Bass + l.Mid + l.Treble; sum > tt.band(l)+0.8 {
+			t.Errorf("%g Hz: leaks into other bands: %+v", tt.hz, l)
+		}
+		if l := clip.Levels(2 * time.Second); l != (Levels{}) {
+			t.Errorf("past the end: got %+v, want silence", l)
+		}
+	}
+}
+
+// drums returns a clip of 80 Hz kicks, one every interval, over quiet hiss.
+func drums(rate int, length, interval time.Duration) *Clip {
+	clip := &Clip{Rate: rate, Samples: make([]float32, int(length.Seconds()*float64(rate)))}
+	period := int(interval.Seconds() * float64(rate))
+	for i := range clip.Samples {
+		s := 0.001 * math.Sin(float64(i)*1.7)
+		if j := i % period; j < rate/10 {
+			decay := 1 - float64(j)/float64(rate/10)
+			s += 0.8 * decay * math.Sin(2*math.Pi*80*float64(j)/float64(rate))
+		}
+		clip.Samples[i] = float32(s)
+	}
+	return clip
+}
+
+func TestBeats(t *testing.T) {
+	clip := drums(44100, 4*time.Second, 500*time.Millisecond)
+	tests := []struct {
+		config BeatConfig
+		want   int
+	}{
+		{DefaultBeatConfig(), 8},
+		{BeatConfig{Sensitivity: 0.5, MinInterval: 900 * time.Millisecond}, 4},
+	}
+	for _, tt := range tests {
+		d := clip.Beats(tt.config)
+		beats := 0
+		// Frames at 12.5 fps are longer than a kick's attack
+		for at := time.Duration(0); at <= 4*time.Second; at += 80 * time.Millisecond {
+			if strength := d.Advance(at); strength > 0 {
+				beats++
+				if strength > 1 {
+					t.Errorf("%+v: strength %.2f above 1", tt.config, strength)
+				}
+			}
+		}
+		if beats != tt.want {
+			t.Errorf("%+v: got %d beats, want %d", tt.config, beats, tt.want)
+		}
+	}
+
+	if strength := (&Clip{Rate: 44100, Samples: make([]float32, 44100)}).Beats(DefaultBeatConfig()).Advance(time.Second); strength != 0 {
+		t.Errorf("silence: got a beat of strength %.2f", strength)
+	}
+}
+
+func TestMicLevel(t *testing.T) {
+	r, w := io.Pipe()
+	m := &Mic{}
+	done := make(chan struct{})
+	go func() {
+		m.listen(r)
+		close(done)
+	}()
+
+	var block bytes.Buffer
+	for _, s := range sine(micRate, 440, 0.5)[:micBlock] {
+		binary.Write(&block, binary.LittleEndian, int16(s*32767))
+	}
+	w.Write(block.Bytes())
+	// The pipe hands this over only once the block before is measured
+	w.Write([]byte{0, 0})
+	if got, want := m.Level(), scale(0.5); math.Abs(got-want) > 0.01 {
+		t.Errorf("got level %.3f, want %.3f", got, want)
+	}
+
+	w.Close()
+	<-done
+	if got := m.Level(); got != 0 {
+		t.Errorf("stopped recorder: got level %.3f, want 0", got)
+	}
+}
@@ -0,0 +1,94 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// fluxHistory is the number of past analysis steps, about 0.75s at
+// 44.1kHz, an onset has to stand out from.
+const fluxHistory = 32
+
+// BeatConfig tunes beat detection.
+type BeatConfig struct {
+	// Sensitivity from 0, only the clearest onsets, to 1, nearly every rise
+	// in loudness
+	Sensitivity float64
+	// MinInterval is the shortest time between two beats
+	MinInterval time.Duration
+}
+
+// DefaultBeatConfig returns settings that follow the kick drum of most music.
+func DefaultBeatConfig() BeatConfig {
+	return BeatConfig{Sensitivity: 0.5, MinInterval: 250 * time.Millisecond}
+}
+
+// BeatDetector finds onsets in a clip: moments where the loudness of the
+// bands rises well above its recent rises. It steps through the clip at half
+// the analysis window whatever the frame rate, so short drum hits between
+// two frames are not missed.
+type BeatDetector struct {
+	clip    *Clip
+	config  BeatConfig
+	pos     time.Duration // Time analyzed up to
+	prev    Levels
+	history []float64
+	last    time.Duration // Time of the previous beat
+}
+
+// Beats returns a detector for the beats of the clip from its start.
+func (c *Clip) Beats(cfg BeatConfig) *BeatDetector {
+	return &BeatDetector{clip: c, config: cfg, last: -cfg.MinInterval}
+}
+
+// Advance analyzes the clip up to at and returns the strength of the
+// strongest beat since the previous call, from 0 for none to 1. Like
+// Clip.Levels, the result depends only on the clip and the times asked for.
+func (d *BeatDetector) Advance(at time.Duration) float64 {
+	if d.clip.Rate == 0 {
+		return 0
+	}
+	hop := time.Duration(windowSize/2) * time.Second / time.Duration(d.clip.Rate)
+	strength := 0.0
+	for ; d.pos+hop <= at; d.pos += hop {
+		strength = max(strength, d.step(d.pos+hop))
+	}
+	return strength
+}
+
+// step analyzes the moment at and returns the strength of a beat there.
+func (d *BeatDetector) step(at time.Duration) float64 {
+	l := d.clip.Levels(at)
+	flux := max(l.Bass-d.prev.Bass, 0) + max(l.Mid-d.prev.Mid, 0) + max(l.Treble-d.prev.Treble, 0)
+	d.prev = l
+
+	// The threshold follows the music, from three deviations above the
+	// average rise down to half of one
+	var mean, variance float64
+	for _, f := range d.history {
+		mean += f
+	}
+	if len(d.history) > 0 {
+		mean /= float64(len(d.history))
+	}
+	for _, f := range d.history {
+		variance += (f - mean) * (f - mean)
+	}
+	if len(d.history) > 0 {
+		variance /= float64(len(d.history))
+	}
+	threshold := mean + (3-2.5*d.config.Sensitivity)*math.Sqrt(variance)
+	// Noise in quiet passages is no beat
+	threshold = max(threshold, 0.05+0.25*(1-d.config.Sensitivity))
+
+	if len(d.history) == fluxHistory {
+		d.history = d.history[1:]
+	}
+	d.history = append(d.history, flux)
+
+	if flux <= threshold || at-d.last < d.config.MinInterval {
+		return 0
+	}
+	d.last = at
+	return min(flux/(2*threshold), 1)
+}
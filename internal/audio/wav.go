@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WAV sample formats.
+const (
+	formatPCM        = 1
+	formatFloat      = 3
+	formatExtensible = 0xfffe
+)
+
+// Load decodes the audio file at path. Only WAV files are supported;
+// other formats can be converted with e.g. ffmpeg -i song.mp3 song.wav.
+func Load(path string) (*Clip, error) {
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".wav" && ext != ".wave" {
+		return nil, fmt.Errorf("%s: only WAV files are supported, convert it with: ffmpeg -i %s %s.wav", path, path, strings.TrimSuffix(path, filepath.Ext(path)))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	clip, err := ReadWAV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return clip, nil
+}
+
+// ReadWAV decodes a WAV file with 8, 16, 24 or 32 bit integer or 32 bit
+// float samples, mixing all channels into one.
+func ReadWAV(r io.Reader) (*Clip, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+
+	var format, channels, bits int
+	rate := 0
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, errors.New("no audio data in the file")
+		}
+		id, size := string(chunk[:4]), int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch id {
+		case "fmt ":
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r, data); err != nil || size < 16 {
+				return nil, errors.New("truncated format chunk")
+			}
+			format = int(binary.LittleEndian.Uint16(data[0:]))
+			channels = int(binary.LittleEndian.Uint16(data[2:]))
+			rate = int(binary.LittleEndian.Uint32(data[4:]))
+			bits = int(binary.LittleEndian.Uint16(data[14:]))
+			if format == formatExtensible && size >= 26 {
+				// The sub format GUID starts with the plain format code
+				format = int(binary.LittleEndian.Uint16(data[24:]))
+			}
+		case "data":
+			if rate == 0 {
+				return nil, errors.New("audio data before the format chunk")
+			}
+			return readSamples(r, size, format, channels, bits, rate)
+		default:
+			// Chunks are padded to an even size
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, errors.New("truncated file")
+			}
+		}
+	}
+}
+
+// readSamples decodes the data chunk.
+func readSamples(r io.Reader, size int64, format, channels, bits, rate int) (*Clip, error) {
+	if channels < 1 || rate < 1 {
+		return nil, errors.New("invalid format chunk")
+	}
+	var sample func(b []byte) float64
+	switch {
+	case format == formatPCM && bits == 8:
+		sample = func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case format == formatPCM && bits == 16:
+		sample = func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / (1 << 15) }
+	case format == formatPCM && bits == 24:
+		sample = func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		}
+	case format == formatPCM && bits == 32:
+		sample = func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31) }
+	case format == formatFloat && bits == 32:
+		sample = func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	default:
+		return nil, fmt.Errorf("unsupported sample format %d with %d bits, want integer or 32 bit float samples", format, bits)
+	}
+
+	// Streams written while recording may claim more data than they hold
+	data, err := io.ReadAll(io.LimitReader(r, size))
+	if err != nil {
+		return nil, err
+	}
+	width := bits / 8
+	frame := width * channels
+	clip := &Clip{Rate: rate, Samples: make([]float32, len(data)/frame)}
+	for i := range clip.Samples {
+		var sum float64
+		for c := range channels {
+			sum += sample(data[i*frame+c*width:])
+		}
+		clip.Samples[i] = float32(sum / float64(channels))
+	}
+	return clip, nil
+}
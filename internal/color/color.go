@@ -0,0 +1,161 @@
+// Package color provides color space conversions (sRGB, linear RGB, HSL,
+// OKLab and OKLCH), perceptual interpolation and palette quantization with
+// ordered dithering for terminals without true color.
+package color
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// RGB is an sRGB color with channels from 0 to 1.
+type RGB struct {
+	R, G, B float64
+}
+
+// FromTcell converts a tcell color. Colors without an RGB value map to black.
+func FromTcell(c tcell.Color) RGB {
+	r, g, b := c.RGB()
+	if r < 0 {
+		return RGB{}
+	}
+	return RGB{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// From8 creates a color from 0-255 channel values.
+func From8(r, g, b int32) RGB {
+	return RGB{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+}
+
+// To8 returns the color as clamped, rounded 0-255 channel values.
+func (c RGB) To8() (r, g, b int32) {
+	to := func(v float64) int32 {
+		return int32(math.Round(clamp01(v) * 255))
+	}
+	return to(c.R), to(c.G), to(c.B)
+}
+
+// ParseHex parses a color written as #rrggbb.
+func ParseHex(s string) (RGB, error) {
+	var r, g, b int32
+	if len(s) != 7 {
+		return RGB{}, fmt.Errorf("invalid color %q: want #rrggbb", s)
+	}
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return RGB{}, fmt.Errorf("invalid color %q: want #rrggbb", s)
+	}
+	return From8(r, g, b), nil
+}
+
+// Hex formats the color as #rrggbb.
+func (c RGB) Hex() string {
+	r, g, b := c.To8()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+// MarshalText encodes the color as #rrggbb, so configurations read like
+// the flags.
+func (c RGB) MarshalText() ([]byte, error) {
+	return []byte(c.Hex()), nil
+}
+
+// UnmarshalText decodes a color written as #rrggbb.
+func (c *RGB) UnmarshalText(text []byte) error {
+	rgb, err := ParseHex(string(text))
+	if err != nil {
+		return err
+	}
+	*c = rgb
+	return nil
+}
+
+// Tcell converts the color to a true color tcell value.
+func (c RGB) Tcell() tcell.Color {
+	return tcell.NewRGBColor(c.To8())
+}
+
+// Clamp limits every channel to the displayable 0-1 range.
+func (c RGB) Clamp() RGB {
+	return RGB{clamp01(c.R), clamp01(c.G), clamp01(c.B)}
+}
+
+// Scale multiplies every channel by f.
+func (c RGB) Scale(f float64) RGB {
+	return RGB{c.R * f, c.G * f, c.B * f}
+}
+
+// Luminance returns the relative luminance of the color (0-1).
+func (c RGB) Luminance() float64 {
+	l := c.Linear()
+	return 0.2126*l.R + 0.7152*l.G + 0.0722*l.B
+}
+
+// Linear converts gamma encoded sRGB to linear light.
+func (c RGB) Linear() RGB {
+	return RGB{toLinear(c.R), toLinear(c.G), toLinear(c.B)}
+}
+
+// FromLinear converts linear light back to gamma encoded sRGB.
+func FromLinear(c RGB) RGB {
+	return RGB{fromLinear(c.R), fromLinear(c.G), fromLinear(c.B)}
+}
+
+// linear8 holds the linear light of every 8-bit sRGB channel value.
+var linear8 = func() (table [256]float64) {
+	for i := range table {
+		table[i] = toLinear(float64(i) / 255)
+	}
+	return table
+}()
+
+// encodeSteps is the number of steps of linear light encode8 holds.
+const encodeSteps = 4096
+
+// encode8 holds the 8-bit sRGB channel value of linear light in steps of
+// 1/encodeSteps, fine enough to round every step to the nearest value.
+var encode8 = func() (table [encodeSteps + 1]int32) {
+	for i := range table {
+		table[i] = int32(math.Round(fromLinear(float64(i)/encodeSteps) * 255))
+	}
+	return table
+}()
+
+// Linear8 converts an 8-bit sRGB channel value to linear light from 0 to
+// 1, by table lookup for code that runs for every cell.
+func Linear8(v int32) float64 {
+	return linear8[max(0, min(v, 255))]
+}
+
+// Encode8 converts linear light from 0 to 1 to an 8-bit sRGB channel
+// value, the reverse of Linear8.
+func Encode8(v float64) int32 {
+	return encode8[int(math.Round(clamp01(v)*encodeSteps))]
+}
+
+// toLinear applies the sRGB decoding curve to one channel.
+func toLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// fromLinear applies the sRGB encoding curve to one channel.
+func fromLinear(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// clamp01 limits v to [0, 1].
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(v, 1))
+}
+
+// lerp linearly interpolates between a and b.
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
@@ -0,0 +1,49 @@
+package color
+
+import "sort"
+
+// Stop is a color at a position along a gradient.
+type Stop struct {
+	Pos   float64
+	Color RGB
+}
+
+// Gradient interpolates smoothly between color stops.
+type Gradient struct {
+	stops []Stop
+	space Space
+}
+
+// NewGradient creates a gradient through the given stops, blending in space.
+func NewGradient(stops []Stop, space Space) Gradient {
+	sorted := append([]Stop(nil), stops...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+	return Gradient{stops: sorted, space: space}
+}
+
+// At returns the color at position t. Positions outside the stops take the
+// color of the nearest end.
+func (g Gradient) At(t float64) RGB {
+	n := len(g.stops)
+	switch {
+	case n == 0:
+		return RGB{}
+	case t <= g.stops[0].Pos:
+		return g.stops[0].Color
+	case t >= g.stops[n-1].Pos:
+		return g.stops[n-1].Color
+	}
+	i := sort.Search(n, func(i int) bool { return g.stops[i].Pos > t })
+	a, b := g.stops[i-1], g.stops[i]
+	return Mix(a.Color, b.Color, (t-a.Pos)/(b.Pos-a.Pos), g.space)
+}
+
+// Table samples the gradient at n evenly spaced positions from 0 to 1, for
+// constant time lookups in per-cell rendering code.
+func (g Gradient) Table(n int) []RGB {
+	table := make([]RGB, n)
+	for i := range table {
+		table[i] = g.At(float64(i) / float64(max(n-1, 1)))
+	}
+	return table
+}
@@ -0,0 +1,134 @@
+package color
+
+// Palette is a fixed set of colors a limited terminal can show.
+type Palette struct {
+	Colors []RGB
+	lab    []OKLab
+}
+
+// NewPalette creates a palette, precomputing the perceptual coordinates
+// used by Nearest.
+func NewPalette(colors []RGB) *Palette {
+	p := &Palette{Colors: colors, lab: make([]OKLab, len(colors))}
+	for i, c := range colors {
+		p.lab[i] = c.OKLab()
+	}
+	return p
+}
+
+// ansi16 are the standard xterm values of the 16 basic terminal colors.
+var ansi16 = [16][3]int32{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// ANSI16 returns the 16 color palette in terminal index order.
+func ANSI16() *Palette {
+	colors := make([]RGB, len(ansi16))
+	for i, c := range ansi16 {
+		colors[i] = From8(c[0], c[1], c[2])
+	}
+	return NewPalette(colors)
+}
+
+// ANSI8 returns the 8 basic colors, for terminals without the bright ones.
+func ANSI8() *Palette {
+	return NewPalette(ANSI16().Colors[:8])
+}
+
+// XTerm256 returns the 256 color palette in terminal index order: the 16
+// basic colors, a 6x6x6 color cube and a 24 step grey ramp.
+func XTerm256() *Palette {
+	colors := make([]RGB, 0, 256)
+	for _, c := range ansi16 {
+		colors = append(colors, From8(c[0], c[1], c[2]))
+	}
+	levels := [6]int32{0, 95, 135, 175, 215, 255}
+	for r := 0; r < 6; r++ {
+		for g := 0; g < 6; g++ {
+			for b := 0; b < 6; b++ {
+				colors = append(colors, From8(levels[r], levels[g], levels[b]))
+			}
+		}
+	}
+	for i := int32(0); i < 24; i++ {
+		v := 8 + i*10
+		colors = append(colors, From8(v, v, v))
+	}
+	return NewPalette(colors)
+}
+
+// Nearest returns the index of the palette color perceptually closest to c.
+func (p *Palette) Nearest(c RGB) int {
+	lab := c.Clamp().OKLab()
+	best, bestDist := 0, -1.0
+	for i, o := range p.lab {
+		d := lab.Distance(o)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// bayer4 is the 4x4 ordered dithering threshold matrix.
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Bayer returns the ordered dithering threshold for a cell, in [-0.5, 0.5).
+func Bayer(x, y int) float64 {
+	return (bayer4[y&3][x&3]+0.5)/16 - 0.5
+}
+
+// Dither returns the palette index for c at cell (x, y), nudging the color
+// by an ordered dithering pattern first so neighbouring cells alternate
+// between palette entries and approximate shades the palette lacks.
+// Strength is the size of the nudge in 0-1 channel units; 0 disables it.
+func (p *Palette) Dither(c RGB, x, y int, strength float64) int {
+	if strength <= 0 {
+		return p.Nearest(c)
+	}
+	offset := Bayer(x, y) * strength
+	return p.Nearest(RGB{c.R + offset, c.G + offset, c.B + offset})
+}
+
+// Alternate returns the index of one of the two palette colors nearest c:
+// the second nearest when threshold, in [0, 1), is below how far c lies
+// from the nearest towards it. Thresholds spread evenly over the frames a
+// cell is shown show each color in the share that blends to c.
+func (p *Palette) Alternate(c RGB, threshold float64) int {
+	lab := c.Clamp().OKLab()
+	near, next := -1, -1
+	var nearDist, nextDist float64
+	for i, o := range p.lab {
+		d := lab.Distance(o)
+		switch {
+		case near < 0 || d < nearDist:
+			near, next = i, near
+			nearDist, nextDist = d, nearDist
+		case next < 0 || d < nextDist:
+			next, nextDist = i, d
+		}
+	}
+	if next < 0 {
+		return near
+	}
+	// Project c onto the line between the two
+	a, b := p.lab[near], p.lab[next]
+	dl, da, db := b.L-a.L, b.A-a.A, b.B-a.B
+	span := dl*dl + da*da + db*db
+	if span == 0 {
+		return near
+	}
+	t := ((lab.L-a.L)*dl + (lab.A-a.A)*da + (lab.B-a.B)*db) / span
+	if threshold < t {
+		return next
+	}
+	return near
+}
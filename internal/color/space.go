@@ -0,0 +1,156 @@
+package color
+
+import "math"
+
+// HSL is a color as hue (degrees, 0-360), saturation and lightness (0-1).
+type HSL struct {
+	H, S, L float64
+}
+
+// HSL converts the color to hue, saturation and lightness.
+func (c RGB) HSL() HSL {
+	hi := math.Max(c.R, math.Max(c.G, c.B))
+	lo := math.Min(c.R, math.Min(c.G, c.B))
+	l := (hi + lo) / 2
+	if hi == lo {
+		return HSL{0, 0, l}
+	}
+
+	d := hi - lo
+	s := d / (1 - math.Abs(2*l-1))
+	var h float64
+	switch hi {
+	case c.R:
+		h = math.Mod((c.G-c.B)/d, 6)
+	case c.G:
+		h = (c.B-c.R)/d + 2
+	default:
+		h = (c.R-c.G)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return HSL{h, s, l}
+}
+
+// RGB converts the color back to sRGB.
+func (c HSL) RGB() RGB {
+	chroma := (1 - math.Abs(2*c.L-1)) * c.S
+	h := math.Mod(c.H, 360)
+	if h < 0 {
+		h += 360
+	}
+	h /= 60
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g = chroma, x
+	case h < 2:
+		r, g = x, chroma
+	case h < 3:
+		g, b = chroma, x
+	case h < 4:
+		g, b = x, chroma
+	case h < 5:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := c.L - chroma/2
+	return RGB{r + m, g + m, b + m}
+}
+
+// OKLab is a perceptually uniform color: L is lightness (0-1), A and B are
+// the green-red and blue-yellow axes. Equal distances look equally different.
+type OKLab struct {
+	L, A, B float64
+}
+
+// OKLab converts the color to the OKLab space.
+func (c RGB) OKLab() OKLab {
+	lin := c.Linear()
+	l := math.Cbrt(0.4122214708*lin.R + 0.5363325363*lin.G + 0.0514459929*lin.B)
+	m := math.Cbrt(0.2119034982*lin.R + 0.6806995451*lin.G + 0.1073969566*lin.B)
+	s := math.Cbrt(0.0883024619*lin.R + 0.2817188376*lin.G + 0.6299787005*lin.B)
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// RGB converts the color back to sRGB. Colors outside the sRGB gamut come
+// back with channels outside 0-1, which Clamp or To8 limit.
+func (c OKLab) RGB() RGB {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return FromLinear(RGB{
+		R: +4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		G: -1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		B: -0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	})
+}
+
+// Distance returns the perceptual difference between two colors.
+func (c OKLab) Distance(o OKLab) float64 {
+	dl, da, db := c.L-o.L, c.A-o.A, c.B-o.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// OKLCH is OKLab in polar form: lightness, chroma and hue in degrees.
+type OKLCH struct {
+	L, C, H float64
+}
+
+// OKLCH converts the color to lightness, chroma and hue.
+func (c RGB) OKLCH() OKLCH {
+	lab := c.OKLab()
+	h := math.Atan2(lab.B, lab.A) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return OKLCH{lab.L, math.Hypot(lab.A, lab.B), h}
+}
+
+// RGB converts the color back to sRGB.
+func (c OKLCH) RGB() RGB {
+	sin, cos := math.Sincos(c.H * math.Pi / 180)
+	return OKLab{c.L, c.C * cos, c.C * sin}.RGB()
+}
+
+// Space selects the color space used for interpolation.
+type Space int
+
+const (
+	// SpaceOKLab blends perceptually, without muddy or overly dark midpoints
+	SpaceOKLab Space = iota
+	// SpaceOKLCH blends along the hue wheel, keeping colors saturated
+	SpaceOKLCH
+	// SpaceLinear blends physically, like mixing light
+	SpaceLinear
+	// SpaceSRGB blends the raw channel values
+	SpaceSRGB
+)
+
+// Mix interpolates from a to b at t (0-1) in the given color space.
+func Mix(a, b RGB, t float64, space Space) RGB {
+	switch space {
+	case SpaceOKLCH:
+		ca, cb := a.OKLCH(), b.OKLCH()
+		// Take the short way around the hue wheel
+		dh := math.Mod(cb.H-ca.H+540, 360) - 180
+		return OKLCH{lerp(ca.L, cb.L, t), lerp(ca.C, cb.C, t), ca.H + dh*t}.RGB()
+	case SpaceLinear:
+		la, lb := a.Linear(), b.Linear()
+		return FromLinear(RGB{lerp(la.R, lb.R, t), lerp(la.G, lb.G, t), lerp(la.B, lb.B, t)})
+	case SpaceSRGB:
+		return RGB{lerp(a.R, b.R, t), lerp(a.G, b.G, t), lerp(a.B, b.B, t)}
+	default:
+		la, lb := a.OKLab(), b.OKLab()
+		return OKLab{lerp(la.L, lb.L, t), lerp(la.A, lb.A, t), lerp(la.B, lb.B, t)}.RGB()
+	}
+}
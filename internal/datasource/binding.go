@@ -0,0 +1,148 @@
+package datasource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Decoder turns a source's data into a typed value.
+type Decoder[T any] func(data []byte) (T, error)
+
+// Binding is a source's data decoded into a T. It decodes again only when
+// new data arrives, and data that fails to decode leaves the last value
+// that did, so one garbled message does not blank a display.
+type Binding[T any] struct {
+	src    *Source
+	decode Decoder[T]
+	mu     sync.Mutex
+	at     time.Time // Arrival of the data last decoded
+	value  T
+	valued time.Time // Arrival of the data value was decoded from
+	ok     bool
+	err    error
+}
+
+// Bind decodes the data of src with decode.
+func Bind[T any](src *Source, decode Decoder[T]) *Binding[T] {
+	return &Binding[T]{src: src, decode: decode}
+}
+
+// Get returns the value of the latest data that decoded, and false while
+// none has.
+func (b *Binding[T]) Get() (T, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if v, ok := b.src.Latest(); ok && !v.Time.Equal(b.at) {
+		b.at = v.Time
+		value, err := b.decode(v.Data)
+		b.err = err
+		if err == nil {
+			b.value, b.valued, b.ok = value, v.Time, true
+		}
+	}
+	return b.value, b.ok
+}
+
+// Received returns when the data of the value Get returns arrived, so a
+// display can tell how old it is; zero while there is none.
+func (b *Binding[T]) Received() time.Time {
+	b.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.valued
+}
+
+// Err returns why the source's latest data could not be had or decoded,
+// nil while all is well.
+func (b *Binding[T]) Err() error {
+	b.Get()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	return b.src.Err()
+}
+
+// JSON decodes the data as JSON into a T, or the part of it at path, see
+// Lookup.
+func JSON[T any](path string) Decoder[T] {
+	return func(data []byte) (T, error) {
+		var v T
+		part, err := Lookup(data, path)
+		if err != nil {
+			return v, err
+		}
+		err = json.Unmarshal(part, &v)
+		return v, err
+	}
+}
+
+// Number decodes a number: the data as text, such as a command's output, or
+// the JSON number or numeric string at path.
+func Number(path string) Decoder[float64] {
+	return func(data []byte) (float64, error) {
+		part, err := Lookup(data, path)
+		if err != nil {
+			return 0, err
+		}
+		text := strings.Trim(strings.TrimSpace(string(part)), `"`)
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is no number", text)
+		}
+		return n, nil
+	}
+}
+
+// Text decodes text: the data itself, trimmed, or the JSON value at path,
+// strings without their quotes.
+func Text(path string) Decoder[string] {
+	return func(data []byte) (string, error) {
+		part, err := Lookup(data, path)
+		if err != nil {
+			return "", err
+		}
+		var s string
+		if path != "" && json.Unmarshal(part, &s) == nil {
+			return s, nil
+		}
+		return string(bytes.TrimSpace(part)), nil
+	}
+}
+
+// Lookup returns the JSON value at a dotted path in data, where numbers
+// index arrays, for example "current.temperature" or "items.0.name". An
+// empty path returns all of data, which need not be JSON.
+func Lookup(data []byte, path string) ([]byte, error) {
+	if path == "" {
+		return data, nil
+	}
+	part := json.RawMessage(data)
+	for _, key := range strings.Split(path, ".") {
+		var object map[string]json.RawMessage
+		if json.Unmarshal(part, &object) == nil {
+			next, ok := object[key]
+			if !ok {
+				return nil, fmt.Errorf("no %q in %s", key, path)
+			}
+			part = next
+			continue
+		}
+		var array []json.RawMessage
+		if err := json.Unmarshal(part, &array); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(array) {
+			return nil, fmt.Errorf("no item %q of %d in %s", key, len(array), path)
+		}
+		part = array[i]
+	}
+	return part, nil
+}
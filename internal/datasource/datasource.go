@@ -0,0 +1,278 @@
+// Package datasource fetches the data overlays and data-driven scenes show:
+// JSON from HTTP endpoints, the output of commands, lines appended to files
+// and messages on MQTT topics. A Source keeps the latest data through
+// failures, fetches again once it is older than its TTL, retries with
+// backoff and can keep it in a cache file across restarts; a Binding
+// decodes it into a typed value.
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxData is the most bytes of data taken from one fetch or message.
+const maxData = 1 << 20
+
+// Schemes are the kinds of source a spec may name, see Open.
+var Schemes = []string{"http", "https", "exec", "file", "mqtt", "mqtts"}
+
+// Backoff spaces out the retries after failures: the first waits Min, and
+// every further one Factor times longer, up to Max.
+type Backoff struct {
+	Min, Max time.Duration
+	Factor   float64
+}
+
+// DefaultBackoff returns a backoff from 5 seconds to 5 minutes, doubling.
+func DefaultBackoff() Backoff {
+	return Backoff{Min: 5 * time.Second, Max: 5 * time.Minute, Factor: 2}
+}
+
+// Delay returns the wait before the retry after the given number of
+// failures in a row, at least 1.
+func (b Backoff) Delay(failures int) time.Duration {
+	d := float64(b.Min)
+	for i := 1; i < failures && d < float64(b.Max); i++ {
+		d *= max(b.Factor, 1)
+	}
+	return min(time.Duration(d), b.Max)
+}
+
+// Options tune a Source.
+type Options struct {
+	// TTL is how long fetched data stays fresh; HTTP and command sources
+	// fetch again once it has passed
+	TTL time.Duration
+	// Backoff spaces out the retries after failures
+	Backoff Backoff
+	// Cache is a file keeping the latest data across restarts, empty for none
+	Cache string
+}
+
+// DefaultOptions returns options refreshing every minute without a cache
+// file.
+func DefaultOptions() Options {
+	return Options{TTL: time.Minute, Backoff: DefaultBackoff()}
+}
+
+// Value is data as received.
+type Value struct {
+	Data []byte
+	// Time is when the data was received
+	Time time.Time
+}
+
+// poller fetches data on request, such as an HTTP endpoint.
+type poller interface {
+	fetch(ctx context.Context) ([]byte, error)
+}
+
+// streamer delivers data as it arrives, such as messages on a topic,
+// until the context is done or the connection fails.
+type streamer interface {
+	stream(ctx context.Context, emit func([]byte)) error
+}
+
+// parse returns the poller or streamer for a spec.
+func parse(spec string) (any, error) {
+	scheme, rest, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid data source %q: want a URL or exec:command, file:path", spec)
+	}
+	switch scheme {
+	case "http", "https":
+		u, err := url.Parse(spec)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid data source %q: want a URL like https://host/path", spec)
+		}
+		return newHTTP(spec), nil
+	case "exec":
+		if strings.TrimSpace(rest) == "" {
+			return nil, fmt.Errorf("invalid data source %q: no command after exec:", spec)
+		}
+		return command(rest), nil
+	case "file":
+		if rest == "" {
+			return nil, fmt.Errorf("invalid data source %q: no path after file:", spec)
+		}
+		return tail(rest), nil
+	case "mqtt", "mqtts":
+		return parseMQTT(spec)
+	}
+	return nil, fmt.Errorf("unknown data source %q (available: %s)", scheme, strings.Join(Schemes, ", "))
+}
+
+// Check reports whether Open accepts spec, without opening it.
+func Check(spec string) error {
+	_, err := parse(spec)
+	return err
+}
+
+// cached is the cache file's content.
+type cached struct {
+	Spec string
+	Data []byte
+	Time time.Time
+}
+
+// Source keeps the latest data of one origin up to date in the background.
+type Source struct {
+	spec    string
+	origin  any
+	opts    Options
+	changed chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu       sync.Mutex
+	value    Value
+	ok       bool
+	err      error
+	failures int // Failures in a row, touched by the refresh goroutine only
+}
+
+// Open starts following the data named by spec:
+//
+//   - http://host/path or https://host/path fetches the body, usually JSON,
+//     again every TTL
+//   - exec:command runs the command in the shell every TTL and takes its
+//     output
+//   - file:path takes the last line of the file, then every line appended
+//     to it, following it through truncation and rotation
+//   - mqtt://[user:password@]host[:port]/topic, or mqtts:// over TLS, takes
+//     the retained and every later message on the topic
+func Open(spec string, opts Options) (*Source, error) {
+	origin, err := parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	s := &Source{spec: spec, origin: origin, opts: opts, changed: make(chan struct{}, 1)}
+	s.load()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	go s.run(ctx)
+	return s, nil
+}
+
+// Latest returns the latest data, and false while there is none yet.
+func (s *Source) Latest() (Value, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value, s.ok
+}
+
+// Err returns the last failure, nil once data came through again.
+func (s *Source) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Changed receives after new data has arrived. Arrivals while nobody
+// receives are merged into one.
+func (s *Source) Changed() <-chan struct{} {
+	return s.changed
+}
+
+// Close stops following the data.
+func (s *Source) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// run fetches or streams the data until Close, waiting the TTL between
+// successful polls and the backoff after failures.
+func (s *Source) run(ctx context.Context) {
+	defer close(s.done)
+	wait := time.Duration(0)
+	if v, ok := s.Latest(); ok {
+		// Cached data still fresh saves a fetch
+		if _, polled := s.origin.(poller); polled {
+			wait = max(time.Until(v.Time.Add(s.opts.TTL)), 0)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		var err error
+		switch o := s.origin.(type) {
+		case poller:
+			var data []byte
+			if data, err = o.fetch(ctx); err == nil {
+				s.emit(data)
+			}
+		case streamer:
+			err = o.stream(ctx, s.emit)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		wait = s.opts.TTL
+		if err != nil {
+			s.failures++
+			wait = s.opts.Backoff.Delay(s.failures)
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+		}
+	}
+}
+
+// emit takes new data.
+func (s *Source) emit(data []byte) {
+	v := Value{Data: data[:min(len(data), maxData):min(len(data), maxData)], Time: time.Now()}
+	s.failures = 0
+	s.mu.Lock()
+	s.value, s.ok, s.err = v, true, nil
+	s.mu.Unlock()
+	s.save(v)
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// load takes the data from the cache file if it is for the same spec.
+func (s *Source) load() {
+	if s.opts.Cache == "" {
+		return
+	}
+	data, err := os.ReadFile(s.opts.Cache)
+	if err != nil {
+		return
+	}
+	var c cached
+	if json.Unmarshal(data, &c) != nil || c.Spec != s.spec {
+		return
+	}
+	s.value, s.ok = Value{Data: c.Data, Time: c.Time}, true
+}
+
+// save writes the data to the cache file. Failing to is no reason to stop
+// showing it.
+func (s *Source) save(v Value) {
+	if s.opts.Cache == "" {
+		return
+	}
+	data, err := json.Marshal(cached{Spec: s.spec, Data: v.Data, Time: v.Time})
+	if err != nil || os.MkdirAll(filepath.Dir(s.opts.Cache), 0o700) != nil {
+		return
+	}
+	// Written aside and renamed, so a reader never sees half a file
+	tmp := s.opts.Cache + ".tmp"
+	if os.WriteFile(tmp, data, 0o600) == nil {
+		_ = os.Rename(tmp, s.opts.Cache)
+	}
+}
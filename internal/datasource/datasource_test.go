@@ -0,0 +1,200 @@
+package datasource
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fast refreshes quickly so tests do not wait on real intervals.
+var fast = Options{TTL: time.Hour, Backoff: Backoff{Min: 10 * time.Millisecond, Max: 40 * time.Millisecond, Factor: 2}}
+
+// await waits for the source's data to satisfy ok.
+func await(t *testing.T, s *Source, ok func(Value) bool) Value {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		if v, has := s.Latest(); has && ok(v) {
+			return v
+		}
+		select {
+		case <-s.Changed():
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			v, _ := s.Latest()
+			t.Fatalf("timed out with %q, last error %v", v.Data, s.Err())
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 5 * time.Second, Factor: 2}
+	for failures, want := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := b.Delay(max(failures, 1)); got != want {
+			t.Errorf("after %d failures: got %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestHTTPRetriesAndCaches(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first two requests fail
+		if requests.Add(1) <= 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"current": {"temperature": 12.5, "city": "Berlin"}, "items": [1, 2, 3]}`))
+	}))
+	defer srv.Close()
+
+	opts := fast
+	opts.Cache = filepath.Join(t.TempDir(), "cache.json")
+	s, err := Open(srv.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arrived := await(t, s, func(Value) bool { return true })
+	s.Close()
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+
+	temp, city := Bind(s, Number("current.temperature")), Bind(s, Text("current.city"))
+	if v, ok := temp.Get(); !ok || v != 12.5 {
+		t.Errorf("temperature: got %v, %v", v, ok)
+	}
+	if at := temp.Received(); !at.Equal(arrived.Time) {
+		t.Errorf("temperature received at %v, want %v", at, arrived.Time)
+	}
+	if v, ok := city.Get(); !ok || v != "Berlin" {
+		t.Errorf("city: got %q, %v", v, ok)
+	}
+	if v, ok := Bind(s, JSON[[]int]("items")).Get(); !ok || len(v) != 3 || v[2] != 3 {
+		t.Errorf("items: got %v, %v", v, ok)
+	}
+	if _, ok := Bind(s, Number("current.missing")).Get(); ok {
+		t.Error("a missing field decoded")
+	}
+
+	// Fresh cached data is used without asking the server again
+	cachedSource, err := Open(srv.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cachedSource.Close()
+	if v, ok := Bind(cachedSource, Number("current.temperature")).Get(); !ok || v != 12.5 {
+		t.Errorf("cached temperature: got %v, %v", v, ok)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := requests.Load(); n != 3 {
+		t.Errorf("got %d requests with a fresh cache, want 3", n)
+	}
+}
+
+func TestExec(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no shell")
+	}
+	s, err := Open("exec:echo 42", fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	await(t, s, func(Value) bool { return true })
+	if v, ok := Bind(s, Number("")).Get(); !ok || v != 42 {
+		t.Errorf("got %v, %v", v, ok)
+	}
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	if err := os.WriteFile(path, []byte("first\nsecond\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open("file:"+path, fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	await(t, s, func(v Value) bool { return string(v.Data) == "second" })
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("third\nfour")
+	f.Close()
+	await(t, s, func(v Value) bool { return string(v.Data) == "third" })
+
+	// Truncated, as by log rotation
+	if err := os.WriteFile(path, []byte("fifth\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	await(t, s, func(v Value) bool { return string(v.Data) == "fifth" })
+}
+
+func TestMQTT(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	topics := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		if kind, _, err := readPacket(r); err != nil || kind>>4 != mqttConnect {
+			return
+		}
+		writePacket(conn, mqttConnAck<<4, []byte{0, 0})
+		_, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint16(body[2:])
+		topics <- string(body[4 : 4+n])
+		writePacket(conn, mqttSubAck<<4, []byte{0, 1, 0})
+		publish := appendString(nil, "home/temperature")
+		writePacket(conn, mqttPublish<<4, append(publish, "21.5"...))
+		// Wait for the client to hang up
+		readPacket(r)
+	}()
+
+	s, err := Open("mqtt://"+ln.Addr().String()+"/home/temperature", fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	await(t, s, func(Value) bool { return true })
+	if topic := <-topics; topic != "home/temperature" {
+		t.Errorf("subscribed to %q", topic)
+	}
+	if v, ok := Bind(s, Number("")).Get(); !ok || v != 21.5 {
+		t.Errorf("got %v, %v", v, ok)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, spec := range []string{"https://example.com/data.json", "exec:date", "file:/var/log/syslog", "mqtt://user:pw@broker/a/b", "mqtts://broker:8884/a"} {
+		if err := Check(spec); err != nil {
+			t.Errorf("Check(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"", "example.com", "https://", "exec:", "file:", "mqtt://broker", "gopher://host/x"} {
+		if err := Check(spec); err == nil {
+			t.Errorf("Check(%q) succeeded, want an error", spec)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package datasource
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// tailInterval is how often a followed file is checked for new lines.
+const tailInterval = 500 * time.Millisecond
+
+// tail follows the lines appended to a file, like tail -F.
+type tail string
+
+// stream takes the last line of the file, then every complete line
+// appended to it. A file that shrinks or is replaced, as log rotation
+// does, is read again from the start.
+func (t tail) stream(ctx context.Context, emit func([]byte)) error {
+	f, err := os.Open(string(t))
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	var pending []byte // A line still being written
+	first := true
+	read := func() error {
+		data, err := io.ReadAll(io.LimitReader(f, maxData))
+		if err != nil {
+			return err
+		}
+		pending = append(pending, data...)
+		end := bytes.LastIndexByte(pending, '\n')
+		if end < 0 {
+			if first && len(pending) > 0 {
+				// A file without a newline yet is one line
+				emit(bytes.Clone(pending))
+			}
+			first = false
+			return nil
+		}
+		lines := bytes.Split(pending[:end], []byte("\n"))
+		// Only the latest line counts, the earlier ones are already stale
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := bytes.TrimSpace(lines[i]); len(line) > 0 {
+				emit(bytes.Clone(line))
+				break
+			}
+		}
+		pending = append(pending[:0], pending[end+1:]...)
+		first = false
+		return nil
+	}
+	if err := read(); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		opened, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		now, err := os.Stat(string(t))
+		if err != nil {
+			// Moved away and not yet replaced
+			continue
+		}
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if !os.SameFile(opened, now) || now.Size() < offset {
+			next, err := os.Open(string(t))
+			if err != nil {
+				continue
+			}
+			f.Close()
+			f, pending = next, pending[:0]
+		}
+		if err := read(); err != nil {
+			return err
+		}
+	}
+}
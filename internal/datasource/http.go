@@ -0,0 +1,44 @@
+package datasource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds every request and command, so a hanging endpoint
+// cannot hold up the refreshes or the exit.
+const requestTimeout = 10 * time.Second
+
+// httpSource fetches the body of a URL.
+type httpSource struct {
+	url    string
+	client *http.Client
+}
+
+// newHTTP returns a source fetching url.
+func newHTTP(url string) *httpSource {
+	return &httpSource{url: url, client: &http.Client{Timeout: requestTimeout}}
+}
+
+// fetch returns the body of a successful response.
+func (h *httpSource) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/plain;q=0.9, */*;q=0.1")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("%s: %s: %s", h.url, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxData))
+}
@@ -0,0 +1,253 @@
+package datasource
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// mqttKeepAlive is the keep alive interval told to the broker; a ping goes
+// out every half of it.
+const mqttKeepAlive = 30 * time.Second
+
+// MQTT 3.1.1 packet types, in the high nibble of the first byte.
+const (
+	mqttConnect    = 1
+	mqttConnAck    = 2
+	mqttPublish    = 3
+	mqttSubscribe  = 8
+	mqttSubAck     = 9
+	mqttPingReq    = 12
+	mqttPingResp   = 13
+	mqttDisconnect = 14
+)
+
+// mqttSource subscribes to a topic on an MQTT broker. It speaks just enough
+// of MQTT 3.1.1 to receive messages at QoS 0.
+type mqttSource struct {
+	addr     string
+	tls      bool
+	topic    string
+	user     *url.Userinfo
+	clientID string
+}
+
+// parseMQTT reads an mqtt:// or mqtts:// spec.
+func parseMQTT(spec string) (*mqttSource, error) {
+	u, err := url.Parse(spec)
+	topic := ""
+	if err == nil {
+		topic = strings.TrimPrefix(u.Path, "/")
+	}
+	if err != nil || u.Hostname() == "" || topic == "" {
+		return nil, fmt.Errorf("invalid data source %q: want mqtt://[user:password@]host[:port]/topic", spec)
+	}
+	m := &mqttSource{addr: u.Host, tls: u.Scheme == "mqtts", topic: topic, user: u.User}
+	if u.Port() == "" {
+		port := "1883"
+		if m.tls {
+			port = "8883"
+		}
+		m.addr = net.JoinHostPort(u.Hostname(), port)
+	}
+	m.clientID = fmt.Sprintf("screensaver-%d", os.Getpid())
+	return m, nil
+}
+
+// stream connects, subscribes and takes every message until the context is
+// done or the connection fails.
+func (m *mqttSource) stream(ctx context.Context, emit func([]byte)) error {
+	// Also ends the pings when the connection fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	dialer := &net.Dialer{Timeout: requestTimeout}
+	var conn net.Conn
+	var err error
+	if m.tls {
+		conn, err = (&tls.Dialer{NetDialer: dialer}).DialContext(ctx, "tcp", m.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", m.addr)
+	}
+	if err != nil {
+		return err
+	}
+	// Closing the connection ends a blocked read when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = writePacket(conn, mqttDisconnect<<4, nil)
+		conn.Close()
+	})
+	defer func() {
+		if stop() {
+			conn.Close()
+		}
+	}()
+	r := bufio.NewReader(conn)
+
+	if err := writePacket(conn, mqttConnect<<4, m.connect()); err != nil {
+		return err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(requestTimeout))
+	kind, body, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+	if kind>>4 != mqttConnAck || len(body) < 2 {
+		return fmt.Errorf("mqtt %s: no connection acknowledgement", m.addr)
+	}
+	if body[1] != 0 {
+		return fmt.Errorf("mqtt %s: connection refused with code %d", m.addr, body[1])
+	}
+
+	// Subscribe with packet identifier 1 at QoS 0
+	sub := binary.BigEndian.AppendUint16(nil, 1)
+	sub = appendString(sub, m.topic)
+	sub = append(sub, 0)
+	if err := writePacket(conn, mqttSubscribe<<4|2, sub); err != nil {
+		return err
+	}
+
+	pings := time.NewTicker(mqttKeepAlive / 2)
+	defer pings.Stop()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-pings.C:
+				if writePacket(conn, mqttPingReq<<4, nil) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		// The broker answers every ping, so silence means a dead connection
+		_ = conn.SetReadDeadline(time.Now().Add(mqttKeepAlive * 3 / 2))
+		kind, body, err := readPacket(r)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		switch kind >> 4 {
+		case mqttSubAck:
+			if len(body) >= 3 && body[2] == 0x80 {
+				return fmt.Errorf("mqtt %s: subscription to %q refused", m.addr, m.topic)
+			}
+		case mqttPublish:
+			payload, err := publishPayload(kind, body)
+			if err != nil {
+				return err
+			}
+			emit(payload)
+		case mqttPingResp:
+		}
+	}
+}
+
+// connect returns the body of the CONNECT packet: a clean session with the
+// credentials from the URL.
+func (m *mqttSource) connect() []byte {
+	flags := byte(0x02) // Clean session
+	password, hasPassword := "", false
+	if m.user != nil {
+		flags |= 0x80
+		if password, hasPassword = m.user.Password(); hasPassword {
+			flags |= 0x40
+		}
+	}
+	b := appendString(nil, "MQTT")
+	b = append(b, 4, flags) // Protocol level 4 is MQTT 3.1.1
+	b = binary.BigEndian.AppendUint16(b, uint16(mqttKeepAlive/time.Second))
+	b = appendString(b, m.clientID)
+	if m.user != nil {
+		b = appendString(b, m.user.Username())
+		if hasPassword {
+			b = appendString(b, password)
+		}
+	}
+	return b
+}
+
+// publishPayload returns the message of a PUBLISH packet, skipping the
+// topic and the packet identifier messages above QoS 0 carry.
+func publishPayload(kind byte, body []byte) ([]byte, error) {
+	if len(body) < 2 {
+		return nil, errors.New("mqtt: short publish packet")
+	}
+	skip := 2 + int(binary.BigEndian.Uint16(body))
+	if (kind>>1)&3 > 0 {
+		skip += 2
+	}
+	if skip > len(body) {
+		return nil, errors.New("mqtt: short publish packet")
+	}
+	return body[skip:], nil
+}
+
+// appendString appends s with its length in front, as MQTT encodes strings.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// writePacket writes a packet with the given first byte and body.
+func writePacket(w io.Writer, kind byte, body []byte) error {
+	packet := []byte{kind}
+	// The remaining length takes 7 bits a byte, low bits first
+	n := len(body)
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := w.Write(append(packet, body...))
+	return err
+}
+
+// readPacket reads a packet, returning its first byte and body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	kind, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n := 0
+	for shift := 0; ; shift += 7 {
+		if shift > 21 {
+			return 0, nil, errors.New("mqtt: malformed packet length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if n > maxData+1024 {
+		return 0, nil, fmt.Errorf("mqtt: packet of %d bytes is too large", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return kind, body, nil
+}
@@ -0,0 +1,99 @@
+// Package devkit runs a scene under development in a process of its own,
+// so the screensaver's dev mode can rebuild and restart it whenever its
+// source changes without restarting itself.
+//
+// The scene's package is compiled together with a generated main function
+// calling Serve, which gives a worker rendering frames on request: the dev
+// mode sends a Request for every frame to the worker's standard input and
+// reads a Frame back from its standard output, both gob encoded. Whatever
+// the scene prints goes to standard error, which the dev mode shows as its
+// log.
+package devkit
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// Scene is what a scene package's NewScene returns.
+type Scene interface {
+	Update(t float64)
+	Render(r *renderer.Renderer)
+}
+
+// Param is a number or switch in a scene's configuration that can be
+// changed while the scene runs.
+type Param struct {
+	Name     string  // Name of the configuration field
+	Value    float64 // Default value, 0 or 1 for switches
+	Min, Max float64 // Range offered around the default
+	Int      bool    // Whole numbers only
+	Bool     bool    // A switch
+}
+
+// Hello is the first message of a worker.
+type Hello struct {
+	Scene  string  // Name of the scene's package
+	Params []Param // Settings of the scene's configuration
+}
+
+// Request asks a worker for the frame at Time.
+type Request struct {
+	Time          float64
+	Width, Height int
+	Aspect        float64 // Height-to-width ratio of a cell
+	// Theme to draw with from now on, nil to keep the current one
+	Theme *theme.Theme
+	// Params are settings to change by name; the scene is created anew
+	// with them and carries on from Time
+	Params map[string]float64
+	// Restart creates the scene anew as well, with the same settings
+	Restart bool
+}
+
+// Frame is a rendered frame of Width cells to a row.
+type Frame struct {
+	Width int
+	Cells []Cell
+}
+
+// Cell is one cell of a frame with its colors as 0xRRGGBB, or -1 for the
+// terminal's default.
+type Cell struct {
+	Ch     rune
+	Fg, Bg int32
+	Attrs  tcell.AttrMask
+}
+
+// Style returns the style the cell was drawn with.
+func (c Cell) Style() tcell.Style {
+	style := tcell.StyleDefault.Attributes(c.Attrs)
+	if c.Fg >= 0 {
+		style = style.Foreground(tcell.NewHexColor(c.Fg))
+	}
+	if c.Bg >= 0 {
+		style = style.Background(tcell.NewHexColor(c.Bg))
+	}
+	return style
+}
+
+// Blank reports whether the cell shows nothing.
+func (c Cell) Blank() bool {
+	return (c.Ch == ' ' || c.Ch == 0) && c.Bg < 0
+}
+
+// newCell captures a cell drawn with style.
+func newCell(ch rune, style tcell.Style) Cell {
+	fg, bg, attrs := style.Decompose()
+	return Cell{Ch: ch, Fg: hex(fg), Bg: hex(bg), Attrs: attrs}
+}
+
+// hex returns c as 0xRRGGBB, or -1 for the default color.
+func hex(c tcell.Color) int32 {
+	if c == tcell.ColorDefault || c == tcell.ColorReset {
+		return -1
+	}
+	r, g, b := c.RGB()
+	return r<<16 | g<<8 | b
+}
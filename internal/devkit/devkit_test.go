@@ -0,0 +1,166 @@
+package devkit
+
+import (
+	"encoding/gob"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+type testConfig struct {
+	Speed  float64
+	Count  int
+	Offset float64
+	Wrap   bool
+	Name   string
+	hidden int
+}
+
+// testScene draws a block Count cells wide at Speed times the time.
+type testScene struct {
+	cfg testConfig
+	t   float64
+}
+
+func (s *testScene) Update(t float64) { s.t = t }
+
+func (s *testScene) Render(r *renderer.Renderer) {
+	x := int(s.t * s.cfg.Speed)
+	style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, 128, 0))
+	for i := range s.cfg.Count {
+		r.SetCell(x+i, 0, '#', 0, style)
+	}
+}
+
+func TestParams(t *testing.T) {
+	cfg := testConfig{Speed: 1.5, Count: 3, Offset: -2, Wrap: true}
+	ps := params(&cfg)
+	want := []Param{
+		{Name: "Speed", Value: 1.5, Min: 0, Max: 4.5},
+		{Name: "Count", Value: 3, Min: 0, Max: 13, Int: true},
+		{Name: "Offset", Value: -2, Min: -6, Max: 2},
+		{Name: "Wrap", Value: 1, Max: 1, Bool: true},
+	}
+	if len(ps) != len(want) {
+		t.Fatalf("got %d params %+v, want %d", len(ps), ps, len(want))
+	}
+	for i := range want {
+		if ps[i] != want[i] {
+			t.Errorf("param %d = %+v, want %+v", i, ps[i], want[i])
+		}
+	}
+
+	setParams(&cfg, map[string]float64{"Speed": 2, "Count": 4.6, "Wrap": 0, "Name": 1, "hidden": 1, "Missing": 1})
+	if cfg.Speed != 2 || cfg.Count != 5 || cfg.Wrap || cfg.hidden != 0 {
+		t.Errorf("after setParams got %+v", cfg)
+	}
+}
+
+func TestServe(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	var wg sync.WaitGroup
+	var serveErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		create := func(c testConfig) Scene { return &testScene{cfg: c} }
+		serveErr = serve(inR, outW, "test", create, testConfig{Speed: 1, Count: 2})
+		outW.Close()
+	}()
+	enc, dec := gob.NewEncoder(inW), gob.NewDecoder(outR)
+
+	var hello Hello
+	if err := dec.Decode(&hello); err != nil {
+		t.Fatal(err)
+	}
+	if hello.Scene != "test" || len(hello.Params) != 4 {
+		t.Fatalf("got hello %+v", hello)
+	}
+
+	frame := func(req Request) Frame {
+		t.Helper()
+		if err := enc.Encode(req); err != nil {
+			t.Fatal(err)
+		}
+		var f Frame
+		if err := dec.Decode(&f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	row := func(f Frame) string {
+		var b strings.Builder
+		for _, c := range f.Cells[:f.Width] {
+			b.WriteRune(c.Ch)
+		}
+		return b.String()
+	}
+
+	f := frame(Request{Time: 3, Width: 10, Height: 2, Aspect: 2})
+	if f.Width != 10 || len(f.Cells) != 20 {
+		t.Fatalf("got %d cells %d wide, want 20 cells 10 wide", len(f.Cells), f.Width)
+	}
+	if got := row(f); got != "   ##     " {
+		t.Errorf("got row %q", got)
+	}
+	if c := f.Cells[3]; c.Fg != 0xff8000 || c.Bg != -1 || c.Blank() {
+		t.Errorf("got cell %+v, want orange on the default background", c)
+	}
+	if !f.Cells[0].Blank() {
+		t.Errorf("got cell %+v, want blank", f.Cells[0])
+	}
+
+	// New settings recreate the scene, a new size resizes the frame
+	f = frame(Request{Time: 1, Width: 6, Height: 1, Aspect: 2, Params: map[string]float64{"Count": 4}})
+	if got := row(f); got != " #### " {
+		t.Errorf("got row %q after changing Count", got)
+	}
+
+	inW.Close()
+	wg.Wait()
+	if serveErr != nil {
+		t.Errorf("serve returned %v", serveErr)
+	}
+}
+
+func TestWorker(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a scene")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no Go toolchain")
+	}
+	out := filepath.Join(t.TempDir(), "worker")
+	if err := Build("../scenes/snow", out); err != nil {
+		t.Fatal(err)
+	}
+	w, err := Start(out, func(string) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if w.Scene() != "snow" || len(w.Params()) == 0 {
+		t.Fatalf("got scene %q with %d params", w.Scene(), len(w.Params()))
+	}
+	f, err := w.Frame(Request{Time: 5, Width: 40, Height: 12, Aspect: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Width != 40 || len(f.Cells) != 40*12 {
+		t.Errorf("got %d cells %d wide", len(f.Cells), f.Width)
+	}
+
+	if err := Build(".", out); err == nil || !strings.Contains(err.Error(), "building") {
+		t.Errorf("building a package without NewScene gave %v", err)
+	}
+	if err := Build(t.TempDir(), out); err == nil {
+		t.Error("building outside the module succeeded")
+	}
+}
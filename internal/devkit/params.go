@@ -0,0 +1,72 @@
+package devkit
+
+import (
+	"math"
+	"reflect"
+)
+
+// params lists the numbers and switches among the exported fields of the
+// configuration cfg points to. Numbers are offered a range around their
+// default wide enough to try out, settings that cannot be negative staying
+// at or above zero.
+func params(cfg any) []Param {
+	v := reflect.ValueOf(cfg).Elem()
+	var ps []Param
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		p := Param{Name: field.Name}
+		f := v.Field(i)
+		switch f.Kind() {
+		case reflect.Bool:
+			p.Bool, p.Max = true, 1
+			if f.Bool() {
+				p.Value = 1
+			}
+			ps = append(ps, p)
+			continue
+		case reflect.Float32, reflect.Float64:
+			p.Value = f.Float()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			p.Value, p.Int = float64(f.Int()), true
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			p.Value, p.Int = float64(f.Uint()), true
+		default:
+			continue
+		}
+		span := max(2*math.Abs(p.Value), 1)
+		if p.Int {
+			span = max(span, 10)
+		}
+		p.Min, p.Max = p.Value-span, p.Value+span
+		if p.Value >= 0 {
+			p.Min = 0
+		}
+		ps = append(ps, p)
+	}
+	return ps
+}
+
+// setParams sets fields of the configuration cfg points to by name.
+// Unknown names are ignored.
+func setParams(cfg any, values map[string]float64) {
+	v := reflect.ValueOf(cfg).Elem()
+	for name, value := range values {
+		f := v.FieldByName(name)
+		if !f.IsValid() || !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Bool:
+			f.SetBool(value != 0)
+		case reflect.Float32, reflect.Float64:
+			f.SetFloat(value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			f.SetInt(int64(math.Round(value)))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			f.SetUint(uint64(max(math.Round(value), 0)))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package devkit
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Serve runs a worker for the scene create makes from cfg, answering
+// requests on standard input until it is closed. The main function
+// generated for a worker calls it with the package's NewScene and
+// DefaultConfig.
+func Serve[C any, S Scene](name string, create func(C) S, cfg C) {
+	// The frames have standard output to themselves, so what the scene
+	// prints goes to the log
+	out := os.Stdout
+	os.Stdout = os.Stderr
+	log.SetFlags(0)
+	if err := serve(os.Stdin, out, name, func(c C) Scene { return create(c) }, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// serve answers requests from in with frames written to out.
+func serve[C any](in io.Reader, out io.Writer, name string, create func(C) Scene, cfg C) error {
+	enc, dec := gob.NewEncoder(out), gob.NewDecoder(in)
+	if err := enc.Encode(Hello{Scene: name, Params: params(&cfg)}); err != nil {
+		return err
+	}
+
+	sim := tcell.NewSimulationScreen("UTF-8")
+	if err := sim.Init(); err != nil {
+		return err
+	}
+	defer sim.Fini()
+	r := renderer.NewRenderer(trueColorScreen{sim})
+	sc := create(cfg)
+	for {
+		var req Request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if req.Theme != nil {
+			r.SetTheme(*req.Theme)
+		}
+		if len(req.Params) > 0 {
+			setParams(&cfg, req.Params)
+			req.Restart = true
+		}
+		if req.Restart {
+			sc = create(cfg)
+		}
+		if w, h := sim.Size(); w != req.Width || h != req.Height {
+			sim.SetSize(req.Width, req.Height)
+			r.Resize()
+		}
+		r.SetCellAspect(req.Aspect)
+
+		sc.Update(req.Time)
+		r.Clear()
+		sc.Render(r)
+		r.Flush()
+		if err := enc.Encode(capture(sim)); err != nil {
+			return err
+		}
+	}
+}
+
+// trueColorScreen is a simulated screen reporting true color, so frames
+// keep the exact colors and the dev mode's terminal reduces them itself.
+type trueColorScreen struct {
+	tcell.SimulationScreen
+}
+
+// Colors reports 24-bit color support.
+func (trueColorScreen) Colors() int {
+	return 1 << 24
+}
+
+// capture returns the cells of a simulated screen.
+func capture(sim tcell.SimulationScreen) Frame {
+	cells, w, _ := sim.GetContents()
+	f := Frame{Width: w, Cells: make([]Cell, len(cells))}
+	for i, c := range cells {
+		ch := ' '
+		if len(c.Runes) > 0 {
+			ch = c.Runes[0]
+		}
+		f.Cells[i] = newCell(ch, c.Style)
+	}
+	return f
+}
@@ -0,0 +1,261 @@
+package devkit
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	module = "github.com/olegchuev/screensaver"
+	// workerDir is where the generated main package of a worker lives in
+	// the module. Nothing is there on disk: the file is laid over the
+	// source tree only for the build.
+	workerDir = "internal/devkit/worker"
+	// helloTimeout is how long a worker may take to start.
+	helloTimeout = 5 * time.Second
+	// frameTimeout is how long a worker may take for a frame before it is
+	// taken to be stuck and stopped.
+	frameTimeout = 2 * time.Second
+)
+
+// workerMain is the source of a worker's main package, given the import
+// path of the scene's package and its name.
+const workerMain = `// Code generated by screensaver dev. DO NOT EDIT.
+
+package main
+
+import (
+	scene %q
+
+	"github.com/olegchuev/screensaver/internal/devkit"
+)
+
+func main() {
+	devkit.Serve(%q, scene.NewScene, scene.DefaultConfig())
+}
+`
+
+// Build compiles the scene package in dir into a worker executable at out.
+// The package must be part of the screensaver's module, which needs the Go
+// toolchain and the module's source, and have a NewScene function taking
+// the Config that DefaultConfig returns, as the built-in scenes do. The
+// error of a failed build holds the compiler's messages.
+func Build(dir, out string) error {
+	list := exec.Command("go", "list", "-f", "{{.Name}}|{{.ImportPath}}|{{with .Module}}{{.Path}}|{{.Dir}}{{end}}", ".")
+	list.Dir = dir
+	output, err := list.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("building scenes needs the Go toolchain: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %s", dir, strings.TrimSpace(string(output)))
+	}
+	fields := strings.Split(strings.TrimSpace(string(output)), "|")
+	if len(fields) != 4 || fields[2] != module {
+		return fmt.Errorf("%s is not a package of %s", dir, module)
+	}
+	name, path, root := fields[0], fields[1], fields[3]
+	if name == "main" {
+		return fmt.Errorf("%s is a command, not a scene package", dir)
+	}
+
+	// The generated main goes next to the executable, laid over the
+	// module where it can import internal packages
+	src := out + ".go"
+	if err := os.WriteFile(src, fmt.Appendf(nil, workerMain, path, name), 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(src)
+	overlay, err := json.Marshal(map[string]map[string]string{
+		"Replace": {filepath.Join(root, workerDir, "main.go"): src},
+	})
+	if err != nil {
+		return err
+	}
+	overlayPath := out + ".json"
+	if err := os.WriteFile(overlayPath, overlay, 0o644); err != nil {
+		return err
+	}
+	defer os.Remove(overlayPath)
+
+	build := exec.Command("go", "build", "-overlay", overlayPath, "-o", out, "./"+workerDir)
+	build.Dir = root
+	if output, err := build.CombinedOutput(); err != nil {
+		return fmt.Errorf("building %s failed: %w\n%s", dir, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// Stamp returns a summary of the Go files in dir that changes whenever one
+// of them is edited, added or removed.
+func Stamp(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".go" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed since the listing
+		}
+		fmt.Fprintf(&b, "%s %d %d\n", e.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
+
+// Worker is a running worker process.
+type Worker struct {
+	cmd   *exec.Cmd
+	in    io.WriteCloser
+	enc   *gob.Encoder
+	dec   *gob.Decoder
+	hello Hello
+
+	mu  sync.Mutex
+	err error // Why the worker stopped, nil while it runs
+}
+
+// Start runs the worker executable at path. Every line it writes to
+// standard error is passed to logf, from another goroutine.
+func Start(path string, logf func(line string)) (*Worker, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = &lineWriter{logf: logf}
+	cmd.WaitDelay = time.Second
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	w := &Worker{cmd: cmd, in: in, enc: gob.NewEncoder(in), dec: gob.NewDecoder(out)}
+	if err := w.call(helloTimeout, func() error { return w.dec.Decode(&w.hello) }); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Scene returns the name of the worker's scene package.
+func (w *Worker) Scene() string {
+	return w.hello.Scene
+}
+
+// Params returns the settings of the worker's scene with their defaults.
+func (w *Worker) Params() []Param {
+	return w.hello.Params
+}
+
+// Frame asks the worker for a frame.
+func (w *Worker) Frame(req Request) (Frame, error) {
+	var f Frame
+	err := w.call(frameTimeout, func() error {
+		if err := w.enc.Encode(req); err != nil {
+			return err
+		}
+		return w.dec.Decode(&f)
+	})
+	return f, err
+}
+
+// call runs an exchange with the worker, stopping the worker if it fails
+// or takes longer than timeout. Once stopped, every call returns why.
+func (w *Worker) call(timeout time.Duration, exchange func() error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	done := make(chan error, 1)
+	go func() { done <- exchange() }()
+	select {
+	case err := <-done:
+		if err == nil {
+			return nil
+		}
+		w.err = w.stop(err)
+	case <-time.After(timeout):
+		w.err = w.stop(fmt.Errorf("no answer in %v", timeout))
+		<-done
+	}
+	return w.err
+}
+
+// stop ends the worker after an exchange failed with err and returns the
+// reason it stopped: how it exited if it did by itself.
+func (w *Worker) stop(err error) error {
+	w.cmd.Process.Kill()
+	waitErr := w.cmd.Wait()
+	var exit *exec.ExitError
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.As(waitErr, &exit) {
+			return fmt.Errorf("scene exited: %w", waitErr)
+		}
+		return errors.New("scene exited")
+	}
+	return fmt.Errorf("scene stopped: %w", err)
+}
+
+// Err returns why the worker stopped, or nil while it runs.
+func (w *Worker) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close ends the worker, giving it a moment to finish by itself.
+func (w *Worker) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return
+	}
+	w.in.Close()
+	done := make(chan struct{})
+	go func() {
+		w.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		w.cmd.Process.Kill()
+		<-done
+	}
+	w.err = errors.New("scene closed")
+}
+
+// lineWriter passes what is written to it on line by line.
+type lineWriter struct {
+	logf    func(line string)
+	partial []byte
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.partial = append(lw.partial, p...)
+	for {
+		i := bytes.IndexByte(lw.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		lw.logf(strings.TrimRight(string(lw.partial[:i]), "\r"))
+		lw.partial = lw.partial[i+1:]
+	}
+}
@@ -0,0 +1,149 @@
+// Package eink drives electronic paper displays. Frames are dithered to
+// black and white and only the regions that changed are refreshed, with a
+// full refresh every so often to clear the ghosting partial ones leave.
+package eink
+
+import (
+	"image"
+)
+
+// Panel is an e-ink display.
+type Panel interface {
+	// Bounds returns the display area in pixels
+	Bounds() image.Rectangle
+	// Update shows the pixels of img inside r. A full refresh flashes the
+	// area to clear ghosting, a partial one only switches changed pixels
+	// and can show black and white only.
+	Update(img *image.Gray, r image.Rectangle, full bool) error
+	// Close puts the display to sleep and releases it
+	Close() error
+}
+
+// Changes are tracked in tiles of this many pixels square.
+const tile = 32
+
+// A frame changing more than this fraction of the display gets a full
+// refresh, which takes about as long and leaves no ghosting.
+const fullArea = 0.5
+
+// Refresher shows frames on a panel with as few and as small refreshes as
+// possible.
+type Refresher struct {
+	// FullEvery forces a full refresh after this many partial ones
+	FullEvery int
+
+	panel    Panel
+	frame    *image.Gray // Dithered frame being shown
+	shown    *image.Gray // Dithered frame on the panel, nil before the first
+	partials int
+}
+
+// NewRefresher returns a refresher for p doing a full refresh after every
+// 30 partial ones.
+func NewRefresher(p Panel) *Refresher {
+	return &Refresher{FullEvery: 30, panel: p, frame: image.NewGray(p.Bounds())}
+}
+
+// Show dithers img, which covers the panel, and refreshes what changed
+// since the previous frame.
+func (r *Refresher) Show(img *image.Gray) error {
+	Dither(r.frame, img)
+	bounds := r.frame.Bounds()
+	dirty := []image.Rectangle{bounds}
+	if r.shown != nil {
+		dirty = changes(r.shown, r.frame)
+	}
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	area := 0
+	for _, d := range dirty {
+		area += d.Dx() * d.Dy()
+	}
+	if r.shown == nil || r.partials >= r.FullEvery || float64(area) > fullArea*float64(bounds.Dx()*bounds.Dy()) {
+		if err := r.panel.Update(r.frame, bounds, true); err != nil {
+			return err
+		}
+		r.partials = 0
+	} else {
+		for _, d := range dirty {
+			if err := r.panel.Update(r.frame, d, false); err != nil {
+				return err
+			}
+		}
+		r.partials++
+	}
+
+	if r.shown == nil {
+		r.shown = image.NewGray(bounds)
+	}
+	r.frame, r.shown = r.shown, r.frame
+	return nil
+}
+
+// changes returns rectangles covering every pixel that differs between a
+// and b. Rows of changed tiles whose spans overlap are merged, which keeps
+// the number of refreshes low for moving shapes.
+func changes(a, b *image.Gray) []image.Rectangle {
+	bounds := a.Bounds()
+	var rects []image.Rectangle
+	for ty := bounds.Min.Y; ty < bounds.Max.Y; ty += tile {
+		span := image.Rectangle{}
+		for tx := bounds.Min.X; tx < bounds.Max.X; tx += tile {
+			t := image.Rect(tx, ty, tx+tile, ty+tile).Intersect(bounds)
+			if tileChanged(a, b, t) {
+				span = span.Union(t)
+			}
+		}
+		if span.Empty() {
+			continue
+		}
+		if n := len(rects); n > 0 && rects[n-1].Max.Y == span.Min.Y && rects[n-1].Min.X < span.Max.X && span.Min.X < rects[n-1].Max.X {
+			rects[n-1] = rects[n-1].Union(span)
+			continue
+		}
+		rects = append(rects, span)
+	}
+	return rects
+}
+
+// tileChanged reports whether any pixel inside t differs between a and b.
+func tileChanged(a, b *image.Gray, t image.Rectangle) bool {
+	for y := t.Min.Y; y < t.Max.Y; y++ {
+		i, j := a.PixOffset(t.Min.X, y), b.PixOffset(t.Min.X, y)
+		if string(a.Pix[i:i+t.Dx()]) != string(b.Pix[j:j+t.Dx()]) {
+			return true
+		}
+	}
+	return false
+}
+
+// bayer is the 8x8 ordered dithering matrix.
+var bayer = [8][8]uint8{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// Dither writes src to dst as pure black and white with ordered dithering.
+// Unlike error diffusion, a pixel depends only on its own grey level and
+// position, so areas that stay the same between frames keep their pattern
+// and need no refresh.
+func Dither(dst, src *image.Gray) {
+	b := dst.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			v := uint8(0)
+			if int(src.GrayAt(x, y).Y) > int(bayer[y&7][x&7])*4+2 {
+				v = 255
+			}
+			dst.Pix[dst.PixOffset(x, y)] = v
+		}
+	}
+}
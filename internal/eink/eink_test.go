@@ -0,0 +1,102 @@
+package eink
+
+import (
+	"image"
+	"testing"
+)
+
+// fakePanel records the updates it receives.
+type fakePanel struct {
+	bounds  image.Rectangle
+	updates []update
+}
+
+type update struct {
+	r    image.Rectangle
+	full bool
+}
+
+func (p *fakePanel) Bounds() image.Rectangle { return p.bounds }
+
+func (p *fakePanel) Update(img *image.Gray, r image.Rectangle, full bool) error {
+	p.updates = append(p.updates, update{r, full})
+	return nil
+}
+
+func (p *fakePanel) Close() error { return nil }
+
+func TestDither(t *testing.T) {
+	b := image.Rect(0, 0, 16, 16)
+	for _, level := range []uint8{0, 64, 128, 255} {
+		src, dst := image.NewGray(b), image.NewGray(b)
+		for i := range src.Pix {
+			src.Pix[i] = level
+		}
+		Dither(dst, src)
+		white := 0
+		for _, v := range dst.Pix {
+			if v != 0 && v != 255 {
+				t.Fatalf("level %d: pixel %d is neither black nor white", level, v)
+			}
+			if v == 255 {
+				white++
+			}
+		}
+		// The share of white pixels follows the grey level
+		if got, want := float64(white)/float64(len(dst.Pix)), float64(level)/255; got < want-0.05 || got > want+0.05 {
+			t.Errorf("level %d: %.2f white, want about %.2f", level, got, want)
+		}
+	}
+}
+
+func TestRefresher(t *testing.T) {
+	panel := &fakePanel{bounds: image.Rect(0, 0, 256, 128)}
+	r := NewRefresher(panel)
+	r.FullEvery = 2
+	frame := image.NewGray(panel.bounds)
+	show := func() []update {
+		t.Helper()
+		panel.updates = nil
+		if err := r.Show(frame); err != nil {
+			t.Fatal(err)
+		}
+		return panel.updates
+	}
+
+	if got := show(); len(got) != 1 || !got[0].full || got[0].r != panel.bounds {
+		t.Fatalf("first frame: got %v, want one full refresh", got)
+	}
+	if got := show(); len(got) != 0 {
+		t.Errorf("unchanged frame: got %v, want no refresh", got)
+	}
+
+	// Two separate spots refresh the tiles around each
+	frame.Pix[frame.PixOffset(5, 5)] = 255
+	frame.Pix[frame.PixOffset(200, 100)] = 255
+	want := []update{{image.Rect(0, 0, 32, 32), false}, {image.Rect(192, 96, 224, 128), false}}
+	if got := show(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("small change: got %v, want %v", got, want)
+	}
+
+	// A spot moving down merges into one region
+	frame.Pix[frame.PixOffset(5, 5)] = 0
+	frame.Pix[frame.PixOffset(5, 40)] = 255
+	want = []update{{image.Rect(0, 0, 32, 64), false}}
+	if got := show(); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("moved spot: got %v, want %v", got, want)
+	}
+
+	// FullEvery partial refreshes were done
+	frame.Pix[frame.PixOffset(5, 40)] = 0
+	if got := show(); len(got) != 1 || !got[0].full {
+		t.Errorf("after %d partial refreshes: got %v, want a full one", r.FullEvery, got)
+	}
+
+	// Most of the display changing gets a full refresh
+	for i := range frame.Pix {
+		frame.Pix[i] = 255
+	}
+	if got := show(); len(got) != 1 || !got[0].full {
+		t.Errorf("large change: got %v, want a full refresh", got)
+	}
+}
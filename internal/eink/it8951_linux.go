@@ -0,0 +1,376 @@
+//go:build linux
+
+package eink
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// IT8951 host commands and registers, see the IT8951 I80/SPI programming
+// guide.
+const (
+	itPreambleCmd   = 0x6000
+	itPreambleWrite = 0x0000
+	itPreambleRead  = 0x1000
+
+	itCmdRun        = 0x0001
+	itCmdSleep      = 0x0003
+	itCmdRegRead    = 0x0010
+	itCmdRegWrite   = 0x0011
+	itCmdLoadArea   = 0x0021
+	itCmdLoadEnd    = 0x0022
+	itCmdDisplay    = 0x0034
+	itCmdVCOM       = 0x0039
+	itCmdDeviceInfo = 0x0302
+
+	itRegPacked    = 0x0004 // I80CPCR, packed pixel writes
+	itRegImageBase = 0x0208 // LISAR, low half; the high half follows
+	itRegLUTBusy   = 0x1224 // LUTAFSR, nonzero while refreshing
+
+	itFormat8bpp = 3
+	itModeGC16   = 2 // Full refresh with 16 greys
+	itModeDU     = 1 // Fast black and white refresh without flashing
+)
+
+// Waveshare HATs wire the controller's ready and reset lines to these
+// Raspberry Pi GPIOs.
+const (
+	itReadyLine = 24
+	itResetLine = 17
+)
+
+// itTimeout bounds every wait for the controller.
+const itTimeout = 5 * time.Second
+
+// spidev and GPIO character device ioctls, see linux/spi/spidev.h and
+// linux/gpio.h.
+const (
+	spiIOCWrMode     = 0x40016b01
+	spiIOCWrSpeed    = 0x40046b04
+	spiIOCMessage1   = 0x40206b00 // SPI_IOC_MESSAGE(1)
+	gpioGetLine      = 0xc16cb403
+	gpioGetValues    = 0xc040b408
+	gpioSetValues    = 0xc040b409
+	gpioInput        = 1
+	gpioOutput       = 2
+	spiSpeed         = 8000000
+	spiMaxTransfer   = 4096 // Default spidev buffer size
+	gpioMaxLines     = 64
+	gpioConsumerSize = 32
+)
+
+type spiTransfer struct {
+	TxBuf, RxBuf             uint64
+	Len, SpeedHz             uint32
+	DelayUsecs               uint16
+	BitsPerWord, CSChange    uint8
+	TxNBits, RxNBits         uint8
+	WordDelayUsecs, Reserved uint8
+}
+
+type gpioHandleRequest struct {
+	Offsets       [gpioMaxLines]uint32
+	Flags         uint32
+	DefaultValues [gpioMaxLines]uint8
+	Consumer      [gpioConsumerSize]byte
+	Lines         uint32
+	FD            int32
+}
+
+type gpioHandleData struct {
+	Values [gpioMaxLines]uint8
+}
+
+// IT8951 is a panel driven by an IT8951 controller over SPI, as on the
+// Waveshare e-Paper HATs for 6 to 10.3 inch displays.
+type IT8951 struct {
+	spi          *os.File
+	ready, reset *os.File // GPIO line handles
+	bounds       image.Rectangle
+	buf          []byte
+}
+
+// OpenIT8951 opens the controller on the SPI device spiPath, with its ready
+// and reset lines on the GPIO chip gpioPath. vcom is the panel's VCOM
+// voltage printed on its cable, e.g. -1.50, or 0 to keep the controller's.
+func OpenIT8951(spiPath, gpioPath string, vcom float64) (_ *IT8951, err error) {
+	p := &IT8951{}
+	defer func() {
+		if err != nil {
+			p.release()
+		}
+	}()
+	if p.spi, err = os.OpenFile(spiPath, os.O_RDWR, 0); err != nil {
+		return nil, err
+	}
+	mode, speed := uint8(0), uint32(spiSpeed)
+	if err := ioctl(p.spi, spiIOCWrMode, unsafe.Pointer(&mode)); err != nil {
+		return nil, fmt.Errorf("%s: %w", spiPath, err)
+	}
+	if err := ioctl(p.spi, spiIOCWrSpeed, unsafe.Pointer(&speed)); err != nil {
+		return nil, fmt.Errorf("%s: %w", spiPath, err)
+	}
+	chip, err := os.OpenFile(gpioPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer chip.Close()
+	if p.ready, err = gpioLine(chip, itReadyLine, gpioInput, 0); err != nil {
+		return nil, fmt.Errorf("%s: %w", gpioPath, err)
+	}
+	if p.reset, err = gpioLine(chip, itResetLine, gpioOutput, 1); err != nil {
+		return nil, fmt.Errorf("%s: %w", gpioPath, err)
+	}
+
+	if err := p.start(vcom); err != nil {
+		return nil, fmt.Errorf("IT8951: %w", err)
+	}
+	return p, nil
+}
+
+// start resets the controller and prepares it for image loads.
+func (p *IT8951) start(vcom float64) error {
+	for _, v := range []uint8{0, 1} {
+		data := gpioHandleData{Values: [gpioMaxLines]uint8{v}}
+		if err := ioctl(p.reset, gpioSetValues, unsafe.Pointer(&data)); err != nil {
+			return err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := p.command(itCmdRun); err != nil {
+		return err
+	}
+
+	if err := p.command(itCmdDeviceInfo); err != nil {
+		return err
+	}
+	info, err := p.read(20)
+	if err != nil {
+		return err
+	}
+	width, height := int(info[0]), int(info[1])
+	if width == 0 || height == 0 || width == 0xffff {
+		return errors.New("no controller answered, check the SPI wiring and that SPI is enabled")
+	}
+	p.bounds = image.Rect(0, 0, width, height)
+	if err := p.writeRegister(itRegImageBase+2, info[3]); err != nil {
+		return err
+	}
+	if err := p.writeRegister(itRegImageBase, info[2]); err != nil {
+		return err
+	}
+	if err := p.writeRegister(itRegPacked, 1); err != nil {
+		return err
+	}
+	if vcom != 0 {
+		mv := uint16(math.Round(math.Abs(vcom) * 1000))
+		if err := p.command(itCmdVCOM, 1, mv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bounds returns the display area reported by the controller.
+func (p *IT8951) Bounds() image.Rectangle {
+	return p.bounds
+}
+
+// Update loads the pixels of img inside r into the controller and shows
+// them, with a flashing GC16 refresh when full and a DU one otherwise.
+func (p *IT8951) Update(img *image.Gray, r image.Rectangle, full bool) error {
+	// Areas start and end on four pixel boundaries
+	r.Min.X &^= 3
+	r.Max.X = (r.Max.X + 3) &^ 3
+	r = r.Intersect(p.bounds)
+	if r.Empty() {
+		return nil
+	}
+	// The previous refresh has to finish before the image buffer changes
+	if err := p.waitRefresh(); err != nil {
+		return err
+	}
+	if err := p.command(itCmdLoadArea, itFormat8bpp<<4, uint16(r.Min.X), uint16(r.Min.Y), uint16(r.Dx()), uint16(r.Dy())); err != nil {
+		return err
+	}
+	// Two pixels per word, the first in the low byte, sent high byte first
+	p.buf = p.buf[:0]
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := img.Pix[img.PixOffset(r.Min.X, y):]
+		for x := 0; x < r.Dx(); x += 2 {
+			p.buf = append(p.buf, row[x+1], row[x])
+		}
+	}
+	if err := p.write(p.buf); err != nil {
+		return err
+	}
+	if err := p.command(itCmdLoadEnd); err != nil {
+		return err
+	}
+	mode := uint16(itModeDU)
+	if full {
+		mode = itModeGC16
+	}
+	return p.command(itCmdDisplay, uint16(r.Min.X), uint16(r.Min.Y), uint16(r.Dx()), uint16(r.Dy()), mode)
+}
+
+// Close waits for the last refresh, puts the controller to sleep and
+// releases the devices.
+func (p *IT8951) Close() error {
+	err := p.waitRefresh()
+	if err == nil {
+		err = p.command(itCmdSleep)
+	}
+	p.release()
+	return err
+}
+
+// release closes whatever devices are open.
+func (p *IT8951) release() {
+	for _, f := range []*os.File{p.spi, p.ready, p.reset} {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+// waitRefresh waits until the controller finished refreshing the display.
+func (p *IT8951) waitRefresh() error {
+	deadline := time.Now().Add(itTimeout)
+	for {
+		if err := p.command(itCmdRegRead, itRegLUTBusy); err != nil {
+			return err
+		}
+		busy, err := p.read(1)
+		if err != nil {
+			return err
+		}
+		if busy[0] == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("display refresh timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeRegister sets a controller register.
+func (p *IT8951) writeRegister(reg, value uint16) error {
+	return p.command(itCmdRegWrite, reg, value)
+}
+
+// command sends a command followed by its arguments.
+func (p *IT8951) command(cmd uint16, args ...uint16) error {
+	if err := p.transfer(itPreambleCmd, words(cmd), nil); err != nil {
+		return err
+	}
+	for _, a := range args {
+		if err := p.write(words(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write sends data, splitting it to fit spidev's transfer size.
+func (p *IT8951) write(data []byte) error {
+	for len(data) > 0 {
+		n := min(len(data), spiMaxTransfer-2)
+		if err := p.transfer(itPreambleWrite, data[:n], nil); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// read receives n words of command results.
+func (p *IT8951) read(n int) ([]uint16, error) {
+	// The controller answers after one dummy word
+	rx := make([]byte, 2+2*n)
+	if err := p.transfer(itPreambleRead, nil, rx); err != nil {
+		return nil, err
+	}
+	result := make([]uint16, n)
+	for i := range result {
+		result[i] = uint16(rx[2+2*i])<<8 | uint16(rx[3+2*i])
+	}
+	return result, nil
+}
+
+// transfer sends a preamble followed by tx, or followed by reading rx,
+// in one transaction once the controller is ready.
+func (p *IT8951) transfer(preamble uint16, tx, rx []byte) error {
+	if err := p.waitReady(); err != nil {
+		return err
+	}
+	buf := append(words(preamble), tx...)
+	buf = append(buf, make([]byte, len(rx))...)
+	t := spiTransfer{
+		TxBuf:       uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		RxBuf:       uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		Len:         uint32(len(buf)),
+		SpeedHz:     spiSpeed,
+		BitsPerWord: 8,
+	}
+	if err := ioctl(p.spi, spiIOCMessage1, unsafe.Pointer(&t)); err != nil {
+		return err
+	}
+	copy(rx, buf[2+len(tx):])
+	return nil
+}
+
+// waitReady waits for the controller's ready line to go high.
+func (p *IT8951) waitReady() error {
+	deadline := time.Now().Add(itTimeout)
+	for {
+		var data gpioHandleData
+		if err := ioctl(p.ready, gpioGetValues, unsafe.Pointer(&data)); err != nil {
+			return err
+		}
+		if data.Values[0] != 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("controller not ready")
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// words encodes 16 bit words high byte first.
+func words(ws ...uint16) []byte {
+	b := make([]byte, 0, 2*len(ws))
+	for _, w := range ws {
+		b = append(b, byte(w>>8), byte(w))
+	}
+	return b
+}
+
+// gpioLine requests one line of a GPIO chip as input or output.
+func gpioLine(chip *os.File, offset uint32, flags uint32, value uint8) (*os.File, error) {
+	req := gpioHandleRequest{Flags: flags, Lines: 1}
+	req.Offsets[0] = offset
+	req.DefaultValues[0] = value
+	copy(req.Consumer[:], "screensaver")
+	if err := ioctl(chip, gpioGetLine, unsafe.Pointer(&req)); err != nil {
+		return nil, fmt.Errorf("line %d: %w", offset, err)
+	}
+	return os.NewFile(uintptr(req.FD), fmt.Sprintf("gpio line %d", offset)), nil
+}
+
+// ioctl issues a request taking a pointer argument.
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package font
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Bitmap is a bitmap font read from a BDF file, such as Terminus or one of
+// the classic VGA fonts.
+type Bitmap struct {
+	width, height int
+	glyphs        map[rune]*image.Alpha
+}
+
+// ParseBDF reads a font in the Glyph Bitmap Distribution Format. Glyphs
+// are placed in cells the size of the font bounding box, so the font
+// should be monospaced.
+func ParseBDF(r io.Reader) (*Bitmap, error) {
+	b := &Bitmap{glyphs: make(map[rune]*image.Alpha)}
+	// Font bounding box offsets locate the baseline inside the cell
+	var offX, offY int
+	var (
+		encoding   = -1
+		bbx        [4]int
+		rows       []string
+		inBitmap   bool
+		lineNumber int
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if inBitmap && fields[0] != "ENDCHAR" {
+			rows = append(rows, fields[0])
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "FONTBOUNDINGBOX":
+			var box [4]int
+			if box, err = ints4(fields[1:]); err == nil {
+				b.width, b.height, offX, offY = box[0], box[1], box[2], box[3]
+			}
+		case "STARTCHAR":
+			encoding, bbx, rows = -1, [4]int{}, rows[:0]
+		case "ENCODING":
+			if len(fields) < 2 {
+				err = errors.New("ENCODING without a value")
+			} else {
+				encoding, err = strconv.Atoi(fields[1])
+			}
+		case "BBX":
+			bbx, err = ints4(fields[1:])
+		case "BITMAP":
+			inBitmap = true
+		case "ENDCHAR":
+			inBitmap = false
+			// Encoding -1 marks glyphs outside the font's character set
+			if encoding >= 0 {
+				var mask *image.Alpha
+				if mask, err = b.glyph(bbx, offX, offY, rows); err == nil {
+					b.glyphs[rune(encoding)] = mask
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if b.width <= 0 || b.height <= 0 {
+		return nil, errors.New("missing FONTBOUNDINGBOX")
+	}
+	if len(b.glyphs) == 0 {
+		return nil, errors.New("no glyphs")
+	}
+	return b, nil
+}
+
+// glyph draws the hex rows of one character into a cell sized mask.
+// bbx is the glyph's width, height and offsets from the origin.
+func (b *Bitmap) glyph(bbx [4]int, offX, offY int, rows []string) (*image.Alpha, error) {
+	if b.width <= 0 || b.height <= 0 {
+		return nil, errors.New("glyph before FONTBOUNDINGBOX")
+	}
+	w, h, x0 := bbx[0], bbx[1], bbx[2]-offX
+	// The cell's bottom row sits offY below the baseline
+	y0 := b.height + offY - bbx[3] - h
+
+	mask := image.NewAlpha(image.Rect(0, 0, b.width, b.height))
+	for y, row := range rows[:min(h, len(rows))] {
+		bits, err := strconv.ParseUint(row, 16, 64)
+		if err != nil || len(row) > 16 {
+			return nil, fmt.Errorf("invalid bitmap row %q", row)
+		}
+		// Rows are padded to whole bytes, most significant bit first
+		width := 4 * len(row)
+		for x := range min(w, width) {
+			if bits&(1<<(width-1-x)) != 0 {
+				mask.SetAlpha(x0+x, y0+y, color.Alpha{A: 255})
+			}
+		}
+	}
+	return mask, nil
+}
+
+// ints4 parses four integer fields.
+func ints4(fields []string) ([4]int, error) {
+	var v [4]int
+	if len(fields) < 4 {
+		return v, fmt.Errorf("want 4 numbers, got %d", len(fields))
+	}
+	for i := range v {
+		n, err := strconv.Atoi(fields[i])
+		if err != nil {
+			return v, err
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// CellSize returns the size of the font bounding box in pixels.
+func (b *Bitmap) CellSize() (int, int) {
+	return b.width, b.height
+}
+
+// Glyph returns the mask of r, or false if the font does not have it.
+func (b *Bitmap) Glyph(r rune) (*image.Alpha, bool) {
+	mask, ok := b.glyphs[r]
+	return mask, ok
+}
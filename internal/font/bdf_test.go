@@ -0,0 +1,138 @@
+package font
+
+import (
+	"strings"
+	"testing"
+)
+
+// testBDF is a 4x6 font with a descent of 1 holding a single glyph: a 2x3
+// box sitting on the baseline one pixel in from the left.
+const testBDF = `STARTFONT 2.1
+FONT test
+SIZE 6 75 75
+FONTBOUNDINGBOX 4 6 0 -1
+CHARS 1
+STARTCHAR box
+ENCODING 65
+DWIDTH 4 0
+BBX 2 3 1 0
+BITMAP
+C0
+40
+C0
+ENDCHAR
+ENDFONT
+`
+
+// rows renders a mask as strings of '#' and '.'.
+func rows(f Face, r rune) []string {
+	mask, ok := f.Glyph(r)
+	if !ok {
+		return nil
+	}
+	b := mask.Bounds()
+	var out []string
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		var row strings.Builder
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if mask.AlphaAt(x, y).A > 0 {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		out = append(out, row.String())
+	}
+	return out
+}
+
+func TestParseBDF(t *testing.T) {
+	f, err := ParseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatalf("ParseBDF: %v", err)
+	}
+	if w, h := f.CellSize(); w != 4 || h != 6 {
+		t.Errorf("CellSize = %dx%d, want 4x6", w, h)
+	}
+	want := []string{"....", "....", ".##.", "..#.", ".##.", "...."}
+	if got := rows(f, 'A'); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("glyph A:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if _, ok := f.Glyph('B'); ok {
+		t.Error("Glyph('B') found a glyph the font does not have")
+	}
+}
+
+func TestParseBDFErrors(t *testing.T) {
+	for name, src := range map[string]string{
+		"empty":       "",
+		"no glyphs":   "FONTBOUNDINGBOX 4 6 0 -1\n",
+		"bad bbx":     strings.Replace(testBDF, "BBX 2 3 1 0", "BBX 2 x 1 0", 1),
+		"bad bitmap":  strings.Replace(testBDF, "40\n", "4G\n", 1),
+		"no bounding": strings.Replace(testBDF, "FONTBOUNDINGBOX 4 6 0 -1\n", "", 1),
+	} {
+		if _, err := ParseBDF(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: ParseBDF succeeded, want an error", name)
+		}
+	}
+}
+
+func TestScaled(t *testing.T) {
+	f, err := ParseBDF(strings.NewReader(testBDF))
+	if err != nil {
+		t.Fatalf("ParseBDF: %v", err)
+	}
+	s := scaled(f, 8, 12)
+	if w, h := s.CellSize(); w != 8 || h != 12 {
+		t.Errorf("CellSize = %dx%d, want 8x12", w, h)
+	}
+	got := rows(s, 'A')
+	if len(got) != 12 || got[4] != "..####.." || got[6] != "....##.." {
+		t.Errorf("scaled glyph A:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestLoadBundled(t *testing.T) {
+	for _, name := range Names() {
+		f, err := Load(name, 0, 0)
+		if err != nil {
+			t.Errorf("Load(%q): %v", name, err)
+			continue
+		}
+		if w, h := f.CellSize(); w <= 0 || h <= 0 {
+			t.Errorf("%s: CellSize = %dx%d", name, w, h)
+		}
+		if _, ok := f.Glyph('A'); !ok {
+			t.Errorf("%s: no glyph for 'A'", name)
+		}
+	}
+	if _, err := Load("nope", 0, 0); err == nil {
+		t.Error("Load of an unknown font succeeded")
+	}
+}
+
+func TestParseStrike(t *testing.T) {
+	f, err := ParseStrike(terminusTTF, 16)
+	if err != nil {
+		t.Fatalf("ParseStrike: %v", err)
+	}
+	if w, h := f.CellSize(); w != 8 || h != 16 {
+		t.Errorf("CellSize = %dx%d, want 8x16", w, h)
+	}
+	// Terminus draws o as a ring standing on the baseline, 12 rows down
+	want := []string{"........", "..####..", ".#....#.", ".#....#.", ".#....#.", ".#....#.", ".#....#.", "..####..", "........"}
+	if got := rows(f, 'o')[4:13]; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("glyph o:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, row := range rows(f, '█') {
+		if row != "########" {
+			t.Errorf("full block row %q, want it covered", row)
+		}
+	}
+	if _, err := ParseStrike(terminusTTF, 15); err == nil {
+		t.Error("ParseStrike of a size without bitmaps succeeded")
+	}
+	if _, err := ParseStrike([]byte("not a font"), 16); err == nil {
+		t.Error("ParseStrike of garbage succeeded")
+	}
+}
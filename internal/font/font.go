@@ -0,0 +1,197 @@
+// Package font provides the raster fonts pixel backends draw cells with.
+package font
+
+import (
+	_ "embed"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/inconsolata"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Default is the name of the font used when none is configured.
+const Default = "gomono"
+
+// defaultMonoSize is the pixel size Go Mono is rendered at unless a cell
+// size asks for another.
+const defaultMonoSize = 16
+
+// terminusTTF is Terminus (TTF), whose embedded 16 pixel bitmaps are the
+// 8x16 Terminus font. It is under the SIL Open Font License, see
+// fonts/Terminus-OFL.txt.
+//
+//go:embed fonts/TerminusTTF-4.49.3.ttf
+var terminusTTF []byte
+
+// Face is a font rendered into fixed size character cells.
+type Face interface {
+	// CellSize returns the size of a character cell in pixels.
+	CellSize() (width, height int)
+	// Glyph returns the coverage mask of r covering one cell, or false if
+	// the font has no glyph for r.
+	Glyph(r rune) (*image.Alpha, bool)
+}
+
+// bundled lists the fonts compiled into the binary.
+var bundled = map[string]func(width, height int) (Face, error){
+	"gomono": func(w, h int) (Face, error) { return newMono(w, h) },
+	"inconsolata": func(w, h int) (Face, error) {
+		return scaled(newBasic(inconsolata.Regular8x16), w, h), nil
+	},
+	"inconsolata-bold": func(w, h int) (Face, error) {
+		return scaled(newBasic(inconsolata.Bold8x16), w, h), nil
+	},
+	"fixed": func(w, h int) (Face, error) {
+		return scaled(newBasic(basicfont.Face7x13), w, h), nil
+	},
+	"terminus": func(w, h int) (Face, error) {
+		b, err := ParseStrike(terminusTTF, 16)
+		if err != nil {
+			return nil, err
+		}
+		return scaled(b, w, h), nil
+	},
+}
+
+// Names returns the names of the bundled fonts in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(bundled))
+	for name := range bundled {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Load returns the bundled font with the given name, or reads the BDF file
+// it names. A positive width and height set the cell size in pixels; bitmap
+// fonts are scaled to it and Go Mono is rendered to fit. Zero keeps the
+// font's own cell size.
+func Load(name string, width, height int) (Face, error) {
+	if name == "" {
+		name = Default
+	}
+	if (width > 0) != (height > 0) || width < 0 || height < 0 {
+		return nil, fmt.Errorf("invalid cell size %dx%d", width, height)
+	}
+	if open, ok := bundled[name]; ok {
+		return open(width, height)
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".bdf") {
+		return nil, fmt.Errorf("unknown font %q (available: %s, or the path of a .bdf file)", name, strings.Join(Names(), ", "))
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	bdf, err := ParseBDF(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return scaled(bdf, width, height), nil
+}
+
+// drawn adapts a golang.org/x/image font face, drawing each glyph
+// horizontally centered on the baseline of a cell.
+type drawn struct {
+	face          font.Face
+	width, height int
+	ascent        int
+}
+
+// newBasic adapts one of the fixed size faces from x/image.
+func newBasic(f *basicfont.Face) *drawn {
+	return &drawn{face: f, width: f.Advance, height: f.Height, ascent: f.Ascent}
+}
+
+// newMono renders Go Mono to fill cells of the given size, or at its
+// default size when the size is zero.
+func newMono(width, height int) (Face, error) {
+	ttf, err := opentype.Parse(gomono.TTF)
+	if err != nil {
+		return nil, err
+	}
+	size := float64(defaultMonoSize)
+	if height > 0 {
+		// Line height is about 1.2 em for Go Mono
+		size = float64(height) / 1.2
+	}
+	face, err := opentype.NewFace(ttf, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	m := face.Metrics()
+	d := &drawn{face: face, width: width, height: height}
+	if width == 0 {
+		adv, _ := face.GlyphAdvance('M')
+		d.width, d.height = adv.Ceil(), m.Height.Ceil()
+	}
+	// Center the line in the cell
+	d.ascent = (d.height-(m.Ascent+m.Descent).Ceil())/2 + m.Ascent.Ceil()
+	return d, nil
+}
+
+// CellSize returns the size of a character cell in pixels.
+func (d *drawn) CellSize() (int, int) {
+	return d.width, d.height
+}
+
+// Glyph draws r into a cell sized mask.
+func (d *drawn) Glyph(r rune) (*image.Alpha, bool) {
+	adv, ok := d.face.GlyphAdvance(r)
+	if !ok {
+		return nil, false
+	}
+	mask := image.NewAlpha(image.Rect(0, 0, d.width, d.height))
+	dr := font.Drawer{Dst: mask, Src: image.Opaque, Face: d.face}
+	dr.Dot = fixed.Point26_6{X: (fixed.I(d.width) - adv) / 2, Y: fixed.I(d.ascent)}
+	dr.DrawString(string(r))
+	return mask, true
+}
+
+// scaling stretches the glyphs of another face to a different cell size
+// with nearest neighbour sampling, keeping bitmap fonts crisp.
+type scaling struct {
+	src           Face
+	width, height int
+}
+
+// scaled returns f with cells of the given size, or f itself for a zero
+// or unchanged size.
+func scaled(f Face, width, height int) Face {
+	if w, h := f.CellSize(); width == 0 || (w == width && h == height) {
+		return f
+	}
+	return &scaling{src: f, width: width, height: height}
+}
+
+// CellSize returns the size of a character cell in pixels.
+func (s *scaling) CellSize() (int, int) {
+	return s.width, s.height
+}
+
+// Glyph scales the source glyph of r to the cell size.
+func (s *scaling) Glyph(r rune) (*image.Alpha, bool) {
+	src, ok := s.src.Glyph(r)
+	if !ok {
+		return nil, false
+	}
+	sw, sh := s.src.CellSize()
+	mask := image.NewAlpha(image.Rect(0, 0, s.width, s.height))
+	for y := range s.height {
+		for x := range s.width {
+			mask.SetAlpha(x, y, src.AlphaAt(x*sw/s.width, y*sh/s.height))
+		}
+	}
+	return mask, true
+}
@@ -0,0 +1,195 @@
+package font
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// ParseStrike reads the bitmaps a TrueType font embeds for the given pixel
+// size, its EBLC and EBDT tables, as drawn by the font's designer rather
+// than rasterized from the outlines. Fonts converted from bitmap fonts,
+// such as Terminus (TTF), carry their original bitmaps this way. Only
+// monochrome strikes with bit-aligned images, formats 2 and 5, are read.
+func ParseStrike(data []byte, ppem int) (*Bitmap, error) {
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	eblc, err := table(data, "EBLC")
+	if err != nil {
+		return nil, err
+	}
+	ebdt, err := table(data, "EBDT")
+	if err != nil {
+		return nil, err
+	}
+	s := strike{eblc: eblc, ebdt: ebdt, images: make(map[sfnt.GlyphIndex]*image.Alpha)}
+	if err := s.read(ppem); err != nil {
+		return nil, err
+	}
+
+	b := &Bitmap{width: s.width, height: s.height, glyphs: make(map[rune]*image.Alpha)}
+	var buf sfnt.Buffer
+	for r := range rune(0x10000) {
+		if i, err := f.GlyphIndex(&buf, r); err == nil && i != 0 && s.images[i] != nil {
+			b.glyphs[r] = s.images[i]
+		}
+	}
+	if len(b.glyphs) == 0 {
+		return nil, errors.New("no glyphs")
+	}
+	return b, nil
+}
+
+// table returns the contents of the font table with the given tag.
+func table(data []byte, tag string) ([]byte, error) {
+	if len(data) < 12 {
+		return nil, errors.New("truncated font")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	for i := range n {
+		rec := 12 + 16*i
+		if rec+16 > len(data) {
+			break
+		}
+		if string(data[rec:rec+4]) != tag {
+			continue
+		}
+		off, size := binary.BigEndian.Uint32(data[rec+8:]), binary.BigEndian.Uint32(data[rec+12:])
+		if uint64(off)+uint64(size) > uint64(len(data)) {
+			return nil, fmt.Errorf("%s table out of bounds", tag)
+		}
+		return data[off : off+size], nil
+	}
+	return nil, fmt.Errorf("no %s table, so no embedded bitmaps", tag)
+}
+
+// strike collects the glyph images of one size of embedded bitmaps.
+type strike struct {
+	eblc, ebdt    []byte
+	width, height int
+	ascent        int // Rows from the top of the cell to the baseline
+	images        map[sfnt.GlyphIndex]*image.Alpha
+}
+
+// errTruncated reports a table shorter than its contents say.
+var errTruncated = errors.New("truncated embedded bitmap tables")
+
+// read finds the strike for ppem in the location table and draws every
+// glyph it holds.
+func (s *strike) read(ppem int) error {
+	if len(s.eblc) < 8 {
+		return errTruncated
+	}
+	sizes := int(binary.BigEndian.Uint32(s.eblc[4:]))
+	for i := range sizes {
+		rec := 8 + 48*i
+		if rec+48 > len(s.eblc) {
+			return errTruncated
+		}
+		size := s.eblc[rec:]
+		if int(size[44]) != ppem || int(size[45]) != ppem {
+			continue
+		}
+		if size[46] != 1 {
+			return fmt.Errorf("%d pixel bitmaps are not monochrome", ppem)
+		}
+		// Horizontal line metrics: ascender, descender and widest advance
+		s.ascent = int(int8(size[16]))
+		s.width, s.height = int(size[18]), s.ascent-int(int8(size[17]))
+		if s.width <= 0 || s.height <= 0 {
+			return fmt.Errorf("%d pixel bitmaps have no size", ppem)
+		}
+		array := int(binary.BigEndian.Uint32(size[0:]))
+		for j := range int(binary.BigEndian.Uint32(size[8:])) {
+			if err := s.subtable(array, array+8*j); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("no embedded bitmaps at %d pixels", ppem)
+}
+
+// subtable draws the glyphs of the index subtable whose entry in the
+// subtable array starting at array is at entry.
+func (s *strike) subtable(array, entry int) error {
+	if entry+8 > len(s.eblc) {
+		return errTruncated
+	}
+	first := int(binary.BigEndian.Uint16(s.eblc[entry:]))
+	last := int(binary.BigEndian.Uint16(s.eblc[entry+2:]))
+	sub := array + int(binary.BigEndian.Uint32(s.eblc[entry+4:]))
+	if sub+8 > len(s.eblc) || last < first {
+		return errTruncated
+	}
+	indexFormat := binary.BigEndian.Uint16(s.eblc[sub:])
+	imageFormat := binary.BigEndian.Uint16(s.eblc[sub+2:])
+	data := int(binary.BigEndian.Uint32(s.eblc[sub+4:]))
+
+	switch {
+	case indexFormat == 1 && imageFormat == 2:
+		// Offsets of each image, which starts with its small metrics
+		offsets := sub + 8
+		if offsets+4*(last-first+2) > len(s.eblc) {
+			return errTruncated
+		}
+		for g := first; g <= last; g++ {
+			at := data + int(binary.BigEndian.Uint32(s.eblc[offsets+4*(g-first):]))
+			end := data + int(binary.BigEndian.Uint32(s.eblc[offsets+4*(g-first+1):]))
+			if end <= at {
+				continue // No image for this glyph
+			}
+			if at+5 > len(s.ebdt) {
+				return errTruncated
+			}
+			m := s.ebdt[at:]
+			// Height, width, bearing X and Y, advance
+			if err := s.draw(g, at+5, int(m[1]), int(m[0]), int(int8(m[2])), int(int8(m[3]))); err != nil {
+				return err
+			}
+		}
+	case indexFormat == 2 && imageFormat == 5:
+		// Images of one size sharing the big metrics that follow it
+		if sub+20 > len(s.eblc) {
+			return errTruncated
+		}
+		size := int(binary.BigEndian.Uint32(s.eblc[sub+8:]))
+		m := s.eblc[sub+12:]
+		for g := first; g <= last; g++ {
+			if err := s.draw(g, data+(g-first)*size, int(m[1]), int(m[0]), int(int8(m[2])), int(int8(m[3]))); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("embedded bitmaps in index format %d with image format %d are not supported", indexFormat, imageFormat)
+	}
+	return nil
+}
+
+// draw places the bit-aligned image at offset in the data table, w by h
+// pixels with its left edge bearingX right of the origin and its top row
+// bearingY above the baseline, into a cell of glyph g.
+func (s *strike) draw(g, offset, w, h, bearingX, bearingY int) error {
+	if offset+(w*h+7)/8 > len(s.ebdt) {
+		return errTruncated
+	}
+	bits := s.ebdt[offset:]
+	mask := image.NewAlpha(image.Rect(0, 0, s.width, s.height))
+	// Rows follow each other without padding, most significant bit first
+	for y := range h {
+		for x := range w {
+			i := y*w + x
+			if bits[i/8]&(0x80>>(i%8)) != 0 {
+				mask.SetAlpha(bearingX+x, s.ascent-bearingY+y, color.Alpha{A: 255})
+			}
+		}
+	}
+	s.images[sfnt.GlyphIndex(g)] = mask
+	return nil
+}
@@ -0,0 +1,101 @@
+package gamepad
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// evdev event types and codes, from linux/input-event-codes.h.
+const (
+	evKey = 0x01
+	evAbs = 0x03
+)
+
+// evdevButtons maps key codes to buttons. Controllers following the kernel's
+// gamepad layout put A south, B east, X north and Y west.
+var evdevButtons = map[uint16]Control{
+	0x130: ButtonA,     // BTN_SOUTH
+	0x131: ButtonB,     // BTN_EAST
+	0x133: ButtonX,     // BTN_NORTH
+	0x134: ButtonY,     // BTN_WEST
+	0x136: ButtonLB,    // BTN_TL
+	0x137: ButtonRB,    // BTN_TR
+	0x13a: ButtonBack,  // BTN_SELECT
+	0x13b: ButtonStart, // BTN_START
+}
+
+// evdevAxes maps absolute axis codes to axes.
+var evdevAxes = map[uint16]Control{
+	0x00: AxisLeftX,  // ABS_X
+	0x01: AxisLeftY,  // ABS_Y
+	0x02: AxisLT,     // ABS_Z
+	0x03: AxisRightX, // ABS_RX
+	0x04: AxisRightY, // ABS_RY
+	0x05: AxisRT,     // ABS_RZ
+	0x10: DPadX,      // ABS_HAT0X
+	0x11: DPadY,      // ABS_HAT0Y
+}
+
+// axisRange is the span of raw values an axis reports.
+type axisRange struct{ min, max int32 }
+
+// evdevEvent translates an input event, reporting false for events that are
+// not controls of a gamepad. Axes are scaled from their range.
+func evdevEvent(typ, code uint16, value int32, ranges map[uint16]axisRange) (Event, bool) {
+	switch typ {
+	case evKey:
+		c, ok := evdevButtons[code]
+		if !ok || value > 1 {
+			// Key repeats are no new presses
+			return Event{}, false
+		}
+		return Event{Control: c, Value: float64(value)}, true
+	case evAbs:
+		c, ok := evdevAxes[code]
+		if !ok {
+			return Event{}, false
+		}
+		r, ok := ranges[code]
+		if !ok || r.max <= r.min {
+			r = axisRange{-1, 1}
+		}
+		v := float64(value-r.min) / float64(r.max-r.min)
+		switch c {
+		case AxisLT, AxisRT:
+			return Event{Control: c, Value: v}, true
+		case DPadX, DPadY:
+			return Event{Control: c, Value: 2*v - 1}, true
+		}
+		return Event{Control: c, Value: stick(2*v - 1)}, true
+	}
+	return Event{}, false
+}
+
+// joystickDevices returns the event device names, such as event5, of the
+// joysticks and gamepads listed in /proc/bus/input/devices: those the
+// kernel also gave a js handler.
+func joystickDevices(r io.Reader) []string {
+	var devices []string
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line, ok := strings.CutPrefix(s.Text(), "H: Handlers=")
+		if !ok {
+			continue
+		}
+		var event string
+		js := false
+		for _, h := range strings.Fields(line) {
+			switch {
+			case strings.HasPrefix(h, "js"):
+				js = true
+			case strings.HasPrefix(h, "event"):
+				event = h
+			}
+		}
+		if js && event != "" {
+			devices = append(devices, event)
+		}
+	}
+	return devices
+}
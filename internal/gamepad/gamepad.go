@@ -0,0 +1,91 @@
+// Package gamepad reads game controllers: evdev devices on Linux and XInput
+// controllers on Windows. Controls are named after the Xbox layout, which
+// other controllers map to.
+package gamepad
+
+import "fmt"
+
+// Control is a button or axis of a controller.
+type Control int
+
+const (
+	ButtonA Control = iota
+	ButtonB
+	ButtonX
+	ButtonY
+	ButtonLB
+	ButtonRB
+	ButtonBack
+	ButtonStart
+	// Sticks and the d-pad run from -1 to 1, left and up being negative
+	AxisLeftX
+	AxisLeftY
+	AxisRightX
+	AxisRightY
+	DPadX
+	DPadY
+	// Triggers run from 0 released to 1 fully pressed
+	AxisLT
+	AxisRT
+)
+
+// controlNames are the names Control.String returns.
+var controlNames = [...]string{
+	ButtonA: "A", ButtonB: "B", ButtonX: "X", ButtonY: "Y",
+	ButtonLB: "LB", ButtonRB: "RB", ButtonBack: "Back", ButtonStart: "Start",
+	AxisLeftX: "left stick X", AxisLeftY: "left stick Y",
+	AxisRightX: "right stick X", AxisRightY: "right stick Y",
+	DPadX: "d-pad X", DPadY: "d-pad Y", AxisLT: "LT", AxisRT: "RT",
+}
+
+// String returns the name of the control.
+func (c Control) String() string {
+	if c < 0 || int(c) >= len(controlNames) {
+		return fmt.Sprintf("control %d", int(c))
+	}
+	return controlNames[c]
+}
+
+// Button reports whether the control is a button.
+func (c Control) Button() bool {
+	return c <= ButtonStart
+}
+
+// Event is a change of one control. Buttons have the value 1 while
+// pressed and 0 once released.
+type Event struct {
+	Control Control
+	Value   float64
+}
+
+// deadZone is the part of a stick's travel around the center that reads
+// as centered, so worn sticks do not drift.
+const deadZone = 0.15
+
+// stick applies the dead zone to a stick position from -1 to 1, keeping
+// the full range outside it.
+func stick(v float64) float64 {
+	switch {
+	case v > deadZone:
+		return min((v-deadZone)/(1-deadZone), 1)
+	case v < -deadZone:
+		return max((v+deadZone)/(1-deadZone), -1)
+	}
+	return 0
+}
+
+// Pad delivers the events of the connected controllers.
+type Pad struct {
+	events chan Event
+	close  func() error
+}
+
+// Events returns the channel events arrive on. It is never closed.
+func (p *Pad) Events() <-chan Event {
+	return p.events
+}
+
+// Close stops reading the controllers.
+func (p *Pad) Close() error {
+	return p.close()
+}
@@ -0,0 +1,86 @@
+package gamepad
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// inputEventSize is the size of struct input_event: a timeval, then type,
+// code and value.
+const inputEventSize = int(unsafe.Sizeof(syscall.Timeval{})) + 8
+
+// Open starts reading every gamepad found under /dev/input. Reading the
+// devices usually needs membership of the input group.
+func Open() (*Pad, error) {
+	list, err := os.Open("/proc/bus/input/devices")
+	if err != nil {
+		return nil, err
+	}
+	names := joystickDevices(list)
+	list.Close()
+	if len(names) == 0 {
+		return nil, errors.New("no game controller found")
+	}
+
+	p := &Pad{events: make(chan Event, 16)}
+	var files []*os.File
+	var firstErr error
+	for _, name := range names {
+		f, err := os.Open(filepath.Join("/dev/input", name))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		files = append(files, f)
+		go p.read(f, axisRanges(f))
+	}
+	if len(files) == 0 {
+		return nil, firstErr
+	}
+	p.close = func() error {
+		for _, f := range files {
+			f.Close()
+		}
+		return nil
+	}
+	return p, nil
+}
+
+// axisRanges asks the device for the range of each axis it has.
+func axisRanges(f *os.File) map[uint16]axisRange {
+	ranges := make(map[uint16]axisRange)
+	for code := range evdevAxes {
+		// struct input_absinfo: value, minimum, maximum, fuzz, flat, resolution
+		var info [6]int32
+		// EVIOCGABS(code) = _IOR('E', 0x40 + code, struct input_absinfo)
+		req := uintptr(2<<30 | unsafe.Sizeof(info)<<16 | 'E'<<8 | (0x40 + uintptr(code)))
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(&info))); errno == 0 {
+			ranges[code] = axisRange{min: info[1], max: info[2]}
+		}
+	}
+	return ranges
+}
+
+// read translates the events of one device until it is closed or unplugged.
+func (p *Pad) read(r io.Reader, ranges map[uint16]axisRange) {
+	buf := make([]byte, inputEventSize)
+	head := inputEventSize - 8
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return
+		}
+		typ := binary.NativeEndian.Uint16(buf[head:])
+		code := binary.NativeEndian.Uint16(buf[head+2:])
+		value := int32(binary.NativeEndian.Uint32(buf[head+4:]))
+		if ev, ok := evdevEvent(typ, code, value, ranges); ok {
+			p.events <- ev
+		}
+	}
+}
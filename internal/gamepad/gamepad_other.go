@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package gamepad
+
+import "errors"
+
+// Open reports that game controllers are not supported on this system.
+func Open() (*Pad, error) {
+	return nil, errors.New("game controllers are only supported on Linux and Windows")
+}
@@ -0,0 +1,60 @@
+package gamepad
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJoystickDevices(t *testing.T) {
+	devices := `I: Bus=0011 Vendor=0001 Product=0001 Version=ab41
+N: Name="AT Translated Set 2 keyboard"
+H: Handlers=sysrq kbd event0 leds
+B: EV=120013
+
+I: Bus=0003 Vendor=045e Product=028e Version=0114
+N: Name="Microsoft X-Box 360 pad"
+H: Handlers=event5 js0
+B: EV=20000b
+
+I: Bus=0005 Vendor=054c Product=09cc Version=8100
+N: Name="Wireless Controller"
+H: Handlers=js1 event7 
+`
+	if got, want := joystickDevices(strings.NewReader(devices)), []string{"event5", "event7"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestEvdevEvent(t *testing.T) {
+	ranges := map[uint16]axisRange{
+		0x00: {-32768, 32767},
+		0x02: {0, 255},
+		0x10: {-1, 1},
+	}
+	tests := []struct {
+		typ, code uint16
+		value     int32
+		want      Event
+		ok        bool
+	}{
+		{evKey, 0x130, 1, Event{ButtonA, 1}, true},
+		{evKey, 0x13b, 0, Event{ButtonStart, 0}, true},
+		{evKey, 0x130, 2, Event{}, false}, // Auto repeat
+		{evKey, 0x1e, 1, Event{}, false},  // Keyboard A
+		{evAbs, 0x00, 32767, Event{AxisLeftX, 1}, true},
+		{evAbs, 0x00, -32768, Event{AxisLeftX, -1}, true},
+		{evAbs, 0x00, 1000, Event{AxisLeftX, 0}, true}, // Inside the dead zone
+		{evAbs, 0x02, 255, Event{AxisLT, 1}, true},
+		{evAbs, 0x10, -1, Event{DPadX, -1}, true},
+		{evAbs, 0x28, 5, Event{}, false}, // ABS_MISC
+		{0x04, 0x04, 5, Event{}, false},  // EV_MSC
+	}
+	for _, tt := range tests {
+		got, ok := evdevEvent(tt.typ, tt.code, tt.value, ranges)
+		if ok != tt.ok || got.Control != tt.want.Control || math.Abs(got.Value-tt.want.Value) > 1e-4 {
+			t.Errorf("type %#x code %#x value %d: got %v %v, want %v %v", tt.typ, tt.code, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package gamepad
+
+import (
+	"errors"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// xinputPoll is how often the controllers are read. XInput reports states
+// rather than events, so changes are found by comparing them.
+const xinputPoll = 16 * time.Millisecond
+
+// xinputState is XINPUT_STATE.
+type xinputState struct {
+	packet       uint32
+	buttons      uint16
+	leftTrigger  uint8
+	rightTrigger uint8
+	thumbLX      int16
+	thumbLY      int16
+	thumbRX      int16
+	thumbRY      int16
+}
+
+// xinputButtons maps XINPUT_GAMEPAD button bits to buttons.
+var xinputButtons = map[uint16]Control{
+	0x1000: ButtonA,
+	0x2000: ButtonB,
+	0x4000: ButtonX,
+	0x8000: ButtonY,
+	0x0100: ButtonLB,
+	0x0200: ButtonRB,
+	0x0020: ButtonBack,
+	0x0010: ButtonStart,
+}
+
+var xinputGetState = syscall.NewLazyDLL("xinput1_4.dll").NewProc("XInputGetState")
+
+// Open starts reading the XInput controllers, of which there may be four.
+func Open() (*Pad, error) {
+	if err := xinputGetState.Find(); err != nil {
+		return nil, err
+	}
+	connected := false
+	for i := range 4 {
+		var s xinputState
+		if r, _, _ := xinputGetState.Call(uintptr(i), uintptr(unsafe.Pointer(&s))); r == 0 {
+			connected = true
+		}
+	}
+	if !connected {
+		return nil, errors.New("no game controller found")
+	}
+
+	p := &Pad{events: make(chan Event, 16)}
+	done := make(chan struct{})
+	p.close = func() error {
+		close(done)
+		return nil
+	}
+	go p.poll(done)
+	return p, nil
+}
+
+// poll reads the controllers until done is closed, sending what changed.
+func (p *Pad) poll(done <-chan struct{}) {
+	var last [4][len(controlNames)]float64
+	ticker := time.NewTicker(xinputPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		for i := range last {
+			var s xinputState
+			if r, _, _ := xinputGetState.Call(uintptr(i), uintptr(unsafe.Pointer(&s))); r != 0 {
+				continue
+			}
+			for c, v := range xinputValues(s) {
+				if v != last[i][c] {
+					last[i][c] = v
+					p.events <- Event{Control: Control(c), Value: v}
+				}
+			}
+		}
+	}
+}
+
+// xinputValues returns the value of every control in a state. XInput
+// sticks point up for positive Y, so Y is flipped.
+func xinputValues(s xinputState) [len(controlNames)]float64 {
+	var v [len(controlNames)]float64
+	for bit, c := range xinputButtons {
+		if s.buttons&bit != 0 {
+			v[c] = 1
+		}
+	}
+	pressed := func(bit uint16) float64 {
+		if s.buttons&bit != 0 {
+			return 1
+		}
+		return 0
+	}
+	v[DPadX] = pressed(0x8) - pressed(0x4)
+	v[DPadY] = pressed(0x2) - pressed(0x1)
+	v[AxisLeftX] = stick(float64(s.thumbLX) / 32767)
+	v[AxisLeftY] = stick(-float64(s.thumbLY) / 32767)
+	v[AxisRightX] = stick(float64(s.thumbRX) / 32767)
+	v[AxisRightY] = stick(-float64(s.thumbRY) / 32767)
+	v[AxisLT] = float64(s.leftTrigger) / 255
+	v[AxisRT] = float64(s.rightTrigger) / 255
+	return v
+}
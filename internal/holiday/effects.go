@@ -0,0 +1,249 @@
+package holiday
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Depth places effects in front of any scene content and behind the
+// overlays.
+const Depth = 1e7
+
+// Effects names the effects rules can show.
+var Effects = []string{"fireworks", "hearts", "snow"}
+
+// Effect is drawn over the running scene. Particles move in screen units,
+// 0 to 1 across and down whatever the size, so an effect keeps going
+// before it is first drawn and through resizes.
+type Effect interface {
+	Update(t float64)
+	Render(r *renderer.Renderer)
+}
+
+// New creates the named effect, one of Effects. The seed makes runs
+// reproducible.
+func New(name string, seed int64) (Effect, error) {
+	switch name {
+	case "snow":
+		return newSnow(seed), nil
+	case "hearts":
+		return newHearts(seed), nil
+	case "fireworks":
+		return newFireworks(seed), nil
+	}
+	return nil, fmt.Errorf("unknown holiday effect %q (available: %s)", name, strings.Join(Effects, ", "))
+}
+
+// clock turns scene time into capped time steps.
+type clock struct {
+	lastT   float64
+	started bool
+}
+
+// step returns the seconds since the previous call, none on the first.
+func (c *clock) step(t float64) float64 {
+	if !c.started {
+		c.started = true
+		c.lastT = t
+	}
+	dt := min(t-c.lastT, 1)
+	c.lastT = t
+	return dt
+}
+
+// drifter is an effect of particles drifting across the screen, swaying
+// from side to side, like snowflakes or balloons.
+type drifter struct {
+	clock
+	particles *particle.System
+	look      func(p *particle.Particle) (rune, tcell.Style)
+	sway      float64 // Side to side distance, in screen widths
+}
+
+// warm fills the screen with particles already drifting, rather than
+// starting from an empty sky.
+func (d *drifter) warm(seconds float64) {
+	for range int(seconds * 10) {
+		d.particles.Update(0.1)
+	}
+}
+
+// Update advances the particles to time t.
+func (d *drifter) Update(t float64) {
+	d.particles.Update(d.step(t))
+}
+
+// Render draws the particles, each swaying at its own pace.
+func (d *drifter) Render(r *renderer.Renderer) {
+	w, h := r.Size()
+	for _, p := range d.particles.Particles() {
+		x := p.X + d.sway*math.Sin(p.Age*(1+p.Seed)+p.Seed*2*math.Pi)
+		ch, style := d.look(&p)
+		r.SetCell(int(math.Floor(x*float64(w))), int(math.Floor(p.Y*float64(h))), ch, Depth, style)
+	}
+}
+
+// newSnow returns snowflakes falling over the scene, the nearer ones
+// larger, brighter and faster.
+func newSnow(seed int64) *drifter {
+	d := &drifter{particles: particle.NewSystem(400, seed), sway: 0.01}
+	d.particles.Emitters = []*particle.Emitter{{
+		Rate: 12,
+		Spawn: func(p *particle.Particle, rng *rand.Rand) {
+			p.X = rng.Float64()*1.1 - 0.05
+			p.Y = -0.05
+			p.VY = 0.06 + 0.1*p.Seed
+			p.VX = 0.01 * (rng.Float64() - 0.5)
+			p.Life = 1.1 / p.VY
+		},
+	}}
+	d.look = func(p *particle.Particle) (rune, tcell.Style) {
+		level := int32(150 + 105*p.Seed)
+		style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(level-15, level-5, level))
+		switch {
+		case p.Seed > 0.8:
+			return '*', style
+		case p.Seed > 0.4:
+			return '+', style
+		default:
+			return '.', style
+		}
+	}
+	d.warm(15)
+	return d
+}
+
+// newHearts returns hearts floating up from the bottom of the screen in
+// shades of pink and red.
+func newHearts(seed int64) *drifter {
+	d := &drifter{particles: particle.NewSystem(100, seed), sway: 0.02}
+	d.particles.Emitters = []*particle.Emitter{{
+		Rate: 1.5,
+		Spawn: func(p *particle.Particle, rng *rand.Rand) {
+			p.X = 0.05 + 0.9*rng.Float64()
+			p.Y = 1.05
+			p.VY = -(0.05 + 0.05*rng.Float64())
+			p.Life = 1.15 / -p.VY
+		},
+	}}
+	d.look = func(p *particle.Particle) (rune, tcell.Style) {
+		green := int32(40 + 110*p.Seed)
+		return '♥', tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, green, green+40))
+	}
+	d.warm(15)
+	return d
+}
+
+// Fireworks settings, in screen heights and seconds.
+const (
+	launchRate   = 0.6  // Rockets per second
+	launchSpeed  = 0.65 // Upward speed of a rocket leaving the ground
+	rocketFall   = 0.5  // Gravity on rockets
+	sparkFall    = 0.12 // Gravity on sparks, slowed by the air
+	burstSparks  = 36
+	burstSpeed   = 0.3
+	sparkLife    = 1.6
+	defaultRatio = 1.7 // Screen width in heights until the first render
+)
+
+// fireworks launches rockets from the bottom of the screen that burst into
+// rings of sparks at the top of their flight.
+type fireworks struct {
+	clock
+	rockets, sparks *particle.System
+	ratio           float64 // Screen width in heights, so bursts come out round
+}
+
+// newFireworks returns a display of fireworks over the scene.
+func newFireworks(seed int64) *fireworks {
+	f := &fireworks{
+		rockets: particle.NewSystem(16, seed),
+		sparks:  particle.NewSystem(16*burstSparks, seed+1),
+		ratio:   defaultRatio,
+	}
+	f.rockets.Forces = []particle.Force{particle.Gravity(0, rocketFall, 0)}
+	f.rockets.Emitters = []*particle.Emitter{{
+		Rate: launchRate,
+		Spawn: func(p *particle.Particle, rng *rand.Rand) {
+			p.X = 0.15 + 0.7*rng.Float64()
+			p.Y = 1
+			p.VX = 0.04 * (rng.Float64() - 0.5)
+			p.VY = -launchSpeed * (0.8 + 0.3*rng.Float64())
+			// Bursting at the top of the climb
+			p.Life = -p.VY / rocketFall
+		},
+	}}
+	f.sparks.Forces = []particle.Force{particle.Drag(1.2), particle.Gravity(0, sparkFall, 0)}
+	return f
+}
+
+// Update advances the rockets and sparks to time t, bursting the rockets
+// that reach the top of their flight.
+func (f *fireworks) Update(t float64) {
+	dt := f.step(t)
+	if dt <= 0 {
+		return
+	}
+	for _, rocket := range f.rockets.Particles() {
+		if rocket.Age+dt < rocket.Life {
+			continue
+		}
+		// The sparks of a burst share its hue, kept in Z
+		hue := rocket.Seed
+		for i := range burstSparks {
+			angle := 2 * math.Pi * (float64(i) + 0.5*rocket.Seed) / burstSparks
+			f.sparks.Emit(func(p *particle.Particle, rng *rand.Rand) {
+				speed := burstSpeed * (0.8 + 0.4*rng.Float64())
+				p.X, p.Y, p.Z = rocket.X, rocket.Y, hue
+				p.VX = speed * math.Cos(angle) / f.ratio
+				p.VY = speed * math.Sin(angle)
+				p.Life = sparkLife * (0.7 + 0.6*rng.Float64())
+			})
+		}
+	}
+	f.rockets.Update(dt)
+	f.sparks.Update(dt)
+}
+
+// Render draws the rising rockets and the fading sparks.
+func (f *fireworks) Render(r *renderer.Renderer) {
+	w, h := r.Size()
+	if w == 0 || h == 0 {
+		return
+	}
+	f.ratio = float64(w) / (float64(h) * r.CellAspect())
+	cell := func(p *particle.Particle) (int, int) {
+		return int(math.Floor(p.X * float64(w))), int(math.Floor(p.Y * float64(h)))
+	}
+	trail := tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, 200, 120))
+	for _, p := range f.rockets.Particles() {
+		x, y := cell(&p)
+		r.SetCell(x, y, '|', Depth, trail)
+	}
+	for _, p := range f.sparks.Particles() {
+		x, y := cell(&p)
+		fade := 1 - p.Progress()
+		red, green, blue := hueRGB(p.Z)
+		style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(int32(red*fade), int32(green*fade), int32(blue*fade)))
+		r.SetCell(x, y, sparkChars[min(int(p.Progress()*float64(len(sparkChars))), len(sparkChars)-1)], Depth, style)
+	}
+}
+
+// sparkChars are the glyphs of a spark over its life, shrinking as it burns
+// out.
+var sparkChars = []rune{'*', '+', '·', '.'}
+
+// hueRGB returns a bright color of hue h from 0 to 1 around the color
+// wheel, with components from 0 to 255.
+func hueRGB(h float64) (float64, float64, float64) {
+	channel := func(offset float64) float64 {
+		return 255 * (0.6 + 0.4*math.Cos(2*math.Pi*(h-offset)))
+	}
+	return channel(0), channel(1.0 / 3), channel(2.0 / 3)
+}
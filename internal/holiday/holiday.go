@@ -0,0 +1,130 @@
+// Package holiday keeps a calendar of special effects for the days of the
+// year, such as snow in December or fireworks on New Year's Eve, and draws
+// them over whatever scene is running.
+package holiday
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// None is the effect of rules that keep a stretch of days free of effects,
+// e.g. to turn off a built-in rule.
+const None = "none"
+
+// Date is a day of the year, the same every year.
+type Date struct {
+	Month time.Month
+	Day   int
+}
+
+// String formats the date as MM-DD.
+func (d Date) String() string {
+	return fmt.Sprintf("%02d-%02d", int(d.Month), d.Day)
+}
+
+// before reports whether d comes earlier in the year than e.
+func (d Date) before(e Date) bool {
+	return d.Month < e.Month || d.Month == e.Month && d.Day < e.Day
+}
+
+// parseDate parses a date given as MM-DD, e.g. 12-24.
+func parseDate(s string) (Date, error) {
+	// Without a year it parses as year 0, a leap year, so 02-29 is fine
+	t, err := time.Parse("01-02", s)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid date %q: want MM-DD like 12-24", s)
+	}
+	return Date{Month: t.Month(), Day: t.Day()}, nil
+}
+
+// Rule shows an effect on the days from From to To, both included. A rule
+// whose To comes before its From runs over the turn of the year.
+type Rule struct {
+	Effect   string
+	From, To Date
+}
+
+// Calendar is the built-in rules, the more particular days first so they
+// win over the seasons they fall in.
+var Calendar = []Rule{
+	{Effect: "fireworks", From: Date{time.December, 31}, To: Date{time.December, 31}},
+	{Effect: "hearts", From: Date{time.February, 14}, To: Date{time.February, 14}},
+	{Effect: "snow", From: Date{time.December, 1}, To: Date{time.December, 31}},
+}
+
+// ParseRule parses a rule of the form "effect=MM-DD" for a single day or
+// "effect=MM-DD..MM-DD" for a stretch of days, for example "snow=12-01..01-15".
+// The effect is one of Effects or None.
+func ParseRule(s string) (Rule, error) {
+	effect, days, ok := strings.Cut(s, "=")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid holiday rule %q: want effect=MM-DD[..MM-DD]", s)
+	}
+	if effect != None && !slices.Contains(Effects, effect) {
+		return Rule{}, fmt.Errorf("unknown holiday effect %q (available: %s, %s)", effect, strings.Join(Effects, ", "), None)
+	}
+	from, to, ranged := strings.Cut(days, "..")
+	r := Rule{Effect: effect}
+	var err error
+	if r.From, err = parseDate(from); err != nil {
+		return Rule{}, err
+	}
+	r.To = r.From
+	if ranged {
+		if r.To, err = parseDate(to); err != nil {
+			return Rule{}, err
+		}
+	}
+	return r, nil
+}
+
+// String formats the rule the way ParseRule accepts it.
+func (r Rule) String() string {
+	if r.From == r.To {
+		return r.Effect + "=" + r.From.String()
+	}
+	return r.Effect + "=" + r.From.String() + ".." + r.To.String()
+}
+
+// MarshalText formats the rule for configuration files.
+func (r Rule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses a rule from a configuration file, see ParseRule.
+func (r *Rule) UnmarshalText(text []byte) error {
+	rule, err := ParseRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// Covers reports whether the rule applies on the day of t.
+func (r Rule) Covers(t time.Time) bool {
+	d := Date{Month: t.Month(), Day: t.Day()}
+	inside := !d.before(r.From) && !r.To.before(d)
+	if r.To.before(r.From) {
+		// Over the turn of the year
+		inside = !d.before(r.From) || !r.To.before(d)
+	}
+	return inside
+}
+
+// Find returns the effect for the day of t: that of the first of rules
+// covering it, then of the first Calendar rule, or "" for none.
+func Find(rules []Rule, t time.Time) string {
+	for _, r := range slices.Concat(rules, Calendar) {
+		if r.Covers(t) {
+			if r.Effect == None {
+				return ""
+			}
+			return r.Effect
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package holiday
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rule
+	}{
+		{"hearts=02-14", Rule{Effect: "hearts", From: Date{time.February, 14}, To: Date{time.February, 14}}},
+		{"snow=12-01..01-15", Rule{Effect: "snow", From: Date{time.December, 1}, To: Date{time.January, 15}}},
+		{"none=02-29", Rule{Effect: None, From: Date{time.February, 29}, To: Date{time.February, 29}}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if err != nil {
+			t.Errorf("ParseRule(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRule(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("ParseRule(%q).String() = %q", tt.in, got.String())
+		}
+	}
+
+	for _, in := range []string{"snow", "rain=12-01", "snow=12-32", "snow=13-01", "snow=12-01..", "snow=1-2"} {
+		if _, err := ParseRule(in); err == nil {
+			t.Errorf("ParseRule(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2025, month, d, 12, 0, 0, 0, time.Local)
+	}
+	rule := func(s string) Rule {
+		r, err := ParseRule(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	tests := []struct {
+		rules []Rule
+		at    time.Time
+		want  string
+	}{
+		{nil, day(time.December, 24), "snow"},
+		{nil, day(time.December, 31), "fireworks"},
+		{nil, day(time.February, 14), "hearts"},
+		{nil, day(time.January, 1), ""},
+		{nil, day(time.November, 30), ""},
+		// Over the turn of the year
+		{[]Rule{rule("snow=12-01..01-15")}, day(time.January, 10), "snow"},
+		{[]Rule{rule("snow=12-01..01-15")}, day(time.January, 16), ""},
+		// Own rules win over the calendar
+		{[]Rule{rule("fireworks=12-24")}, day(time.December, 24), "fireworks"},
+		{[]Rule{rule("none=12-01..12-30")}, day(time.December, 24), ""},
+		{[]Rule{rule("none=12-01..12-30")}, day(time.December, 31), "fireworks"},
+	}
+	for _, tt := range tests {
+		if got := Find(tt.rules, tt.at); got != tt.want {
+			t.Errorf("Find(%v, %s) = %q, want %q", tt.rules, tt.at.Format("01-02"), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package ledmatrix
+
+import (
+	"image"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Awtrix is a 32x8 clock running AWTRIX 3, which shows frames as a custom
+// app drawn from a bitmap.
+type Awtrix struct {
+	host     string
+	app      string
+	interval time.Duration
+	client   *http.Client
+	switched bool // Whether the clock was switched to the app
+}
+
+// Size returns the resolution of the display.
+func (a *Awtrix) Size() image.Point {
+	return image.Pt(32, 8)
+}
+
+// Interval returns the time between frames.
+func (a *Awtrix) Interval() time.Duration {
+	return a.interval
+}
+
+// String names the device.
+func (a *Awtrix) String() string {
+	return "awtrix " + a.host
+}
+
+// Show replaces the custom app's bitmap with img, switching the clock to
+// the app with the first frame.
+func (a *Awtrix) Show(img *image.RGBA) error {
+	b := img.Bounds()
+	bitmap := make([]int, 0, b.Dx()*b.Dy())
+	for i := 0; i < len(img.Pix); i += 4 {
+		bitmap = append(bitmap, int(img.Pix[i])<<16|int(img.Pix[i+1])<<8|int(img.Pix[i+2]))
+	}
+	body := map[string]any{
+		"draw": []map[string]any{{"db": []any{0, 0, b.Dx(), b.Dy(), bitmap}}},
+	}
+	if err := post(a.client, "http://"+a.host+"/api/custom?name="+url.QueryEscape(a.app), body, nil); err != nil {
+		a.switched = false
+		return err
+	}
+	if !a.switched {
+		if err := post(a.client, "http://"+a.host+"/api/switch", map[string]any{"name": a.app}, nil); err != nil {
+			return err
+		}
+		a.switched = true
+	}
+	return nil
+}
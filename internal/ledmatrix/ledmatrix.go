@@ -0,0 +1,129 @@
+// Package ledmatrix sends frames to LED matrix clocks on the local network,
+// the Divoom Pixoo and displays running AWTRIX, over their HTTP APIs.
+package ledmatrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Device is an LED matrix display.
+type Device interface {
+	// Size returns the resolution of the display
+	Size() image.Point
+	// Interval returns the shortest time between frames the device keeps up with
+	Interval() time.Duration
+	// Show displays img, which has the device's size
+	Show(img *image.RGBA) error
+	// String names the device in messages
+	String() string
+}
+
+// requestTimeout bounds every request, so an unplugged device cannot hold
+// up the frames.
+const requestTimeout = 5 * time.Second
+
+// Open returns the device described by a URL:
+//
+//	pixoo://192.168.1.20            Divoom Pixoo 64
+//	pixoo://192.168.1.20?size=16    a 16 or 32 pixel Pixoo
+//	awtrix://192.168.1.30           AWTRIX 3 clock, 32x8
+//	awtrix://192.168.1.30?app=waves a custom app of another name
+//
+// Every device accepts fps to set its frame rate, e.g. fps=2.
+func Open(spec string) (Device, error) {
+	u, err := url.Parse(spec)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid device %q: want a URL like pixoo://192.168.1.20 or awtrix://192.168.1.30", spec)
+	}
+	query := u.Query()
+	fps := 1.0
+	if s := query.Get("fps"); s != "" {
+		if fps, err = strconv.ParseFloat(s, 64); err != nil || fps <= 0 || fps > 30 {
+			return nil, fmt.Errorf("invalid fps %q for %s: want more than 0 and at most 30", s, u.Host)
+		}
+	}
+	interval := time.Duration(float64(time.Second) / fps)
+	client := &http.Client{Timeout: requestTimeout}
+
+	switch u.Scheme {
+	case "pixoo":
+		size := 64
+		if s := query.Get("size"); s != "" {
+			if size, err = strconv.Atoi(s); err != nil || (size != 16 && size != 32 && size != 64) {
+				return nil, fmt.Errorf("invalid size %q for %s: want 16, 32 or 64", s, u.Host)
+			}
+		}
+		return &Pixoo{host: u.Host, size: size, interval: interval, client: client}, nil
+	case "awtrix":
+		app := query.Get("app")
+		if app == "" {
+			app = "screensaver"
+		}
+		if query.Get("fps") == "" {
+			interval = time.Second / 4
+		}
+		return &Awtrix{host: u.Host, app: app, interval: interval, client: client}, nil
+	}
+	return nil, fmt.Errorf("unknown device type %q in %q (available: pixoo, awtrix)", u.Scheme, spec)
+}
+
+// post sends body as JSON to url and decodes the JSON answer into reply,
+// unless reply is nil.
+func post(client *http.Client, url string, body, reply any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	if reply == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// Scale fills dst with src, cropping src to the aspect ratio of dst around
+// its center and averaging the source pixels that fall into each
+// destination pixel.
+func Scale(dst, src *image.RGBA) {
+	db, sb := dst.Bounds(), src.Bounds()
+	// The largest centered part of src with the shape of dst
+	cw, ch := sb.Dx(), sb.Dy()
+	if cw*db.Dy() > ch*db.Dx() {
+		cw = ch * db.Dx() / db.Dy()
+	} else {
+		ch = cw * db.Dy() / db.Dx()
+	}
+	crop := image.Rect(0, 0, cw, ch).Add(sb.Min).Add(image.Pt((sb.Dx()-cw)/2, (sb.Dy()-ch)/2))
+
+	for y := range db.Dy() {
+		y0 := crop.Min.Y + y*ch/db.Dy()
+		y1 := max(crop.Min.Y+(y+1)*ch/db.Dy(), y0+1)
+		for x := range db.Dx() {
+			x0 := crop.Min.X + x*cw/db.Dx()
+			x1 := max(crop.Min.X+(x+1)*cw/db.Dx(), x0+1)
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := src.PixOffset(sx, sy)
+					r, g, b, n = r+int(src.Pix[i]), g+int(src.Pix[i+1]), b+int(src.Pix[i+2]), n+1
+				}
+			}
+			i := dst.PixOffset(db.Min.X+x, db.Min.Y+y)
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(b/n), 255
+		}
+	}
+}
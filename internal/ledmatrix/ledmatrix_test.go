@@ -0,0 +1,99 @@
+package ledmatrix
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScale(t *testing.T) {
+	// A wide source keeps its middle square: red | green blue | white
+	src := image.NewRGBA(image.Rect(0, 0, 8, 2))
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}
+	for x := range 8 {
+		for y := range 2 {
+			src.SetRGBA(x, y, colors[x/2])
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	Scale(dst, src)
+	if got, want := dst.RGBAAt(0, 0), (color.RGBA{0, 127, 127, 255}); got != want {
+		t.Errorf("got %v, want the average of the middle %v", got, want)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, spec := range []string{"192.168.1.20", "pixoo://host?size=48", "pixoo://host?fps=0", "lametric://host"} {
+		if _, err := Open(spec); err == nil {
+			t.Errorf("Open(%q) succeeded, want an error", spec)
+		}
+	}
+	d, err := Open("pixoo://host?size=32&fps=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Size() != image.Pt(32, 32) || d.Interval().Seconds() != 0.5 {
+		t.Errorf("got %v every %v, want 32x32 every 0.5s", d.Size(), d.Interval())
+	}
+}
+
+// recorder is a fake device API recording the requests it gets.
+func recorder(t *testing.T, reply string) (*httptest.Server, *[]string) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("%s: %v", r.URL, err)
+		}
+		name := r.URL.RequestURI()
+		if cmd, ok := body["Command"].(string); ok {
+			name += " " + cmd
+		}
+		requests = append(requests, name)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+func TestPixoo(t *testing.T) {
+	srv, requests := recorder(t, `{"error_code":0}`)
+	d, err := Open("pixoo://" + strings.TrimPrefix(srv.URL, "http://") + "?size=16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for range pixooResetEvery + 1 {
+		if err := d.Show(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Ids are reset before the first frame and after pixooResetEvery
+	reset, send := "/post Draw/ResetHttpGifId", "/post Draw/SendHttpGif"
+	got := *requests
+	if len(got) != pixooResetEvery+3 || got[0] != reset || got[1] != send || got[pixooResetEvery+1] != reset {
+		t.Errorf("got requests %v", got)
+	}
+}
+
+func TestAwtrix(t *testing.T) {
+	srv, requests := recorder(t, "OK")
+	d, err := Open("awtrix://" + strings.TrimPrefix(srv.URL, "http://") + "?app=waves")
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 32, 8))
+	for range 2 {
+		if err := d.Show(img); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"/api/custom?name=waves", "/api/switch", "/api/custom?name=waves"}
+	if got := *requests; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got requests %v, want %v", got, want)
+	}
+}
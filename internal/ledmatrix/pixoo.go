@@ -0,0 +1,85 @@
+package ledmatrix
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"net/http"
+	"time"
+)
+
+// pixooResetEvery restarts the animation ids after this many frames, since
+// the Pixoo stops showing new frames once the id grows past a few dozen.
+const pixooResetEvery = 32
+
+// Pixoo is a Divoom Pixoo display, which shows frames sent as single
+// picture animations.
+type Pixoo struct {
+	host     string
+	size     int
+	interval time.Duration
+	client   *http.Client
+	id       int // Id of the last frame sent, 0 before the ids are reset
+}
+
+// pixooReply is the answer to every Pixoo command.
+type pixooReply struct {
+	ErrorCode int `json:"error_code"`
+}
+
+// Size returns the resolution of the display.
+func (p *Pixoo) Size() image.Point {
+	return image.Pt(p.size, p.size)
+}
+
+// Interval returns the time between frames.
+func (p *Pixoo) Interval() time.Duration {
+	return p.interval
+}
+
+// String names the device.
+func (p *Pixoo) String() string {
+	return "pixoo " + p.host
+}
+
+// Show sends img as the next frame.
+func (p *Pixoo) Show(img *image.RGBA) error {
+	if p.id == 0 || p.id >= pixooResetEvery {
+		if err := p.command(map[string]any{"Command": "Draw/ResetHttpGifId"}); err != nil {
+			return err
+		}
+		p.id = 0
+	}
+	rgb := make([]byte, 0, 3*p.size*p.size)
+	for i := 0; i < len(img.Pix); i += 4 {
+		rgb = append(rgb, img.Pix[i], img.Pix[i+1], img.Pix[i+2])
+	}
+	err := p.command(map[string]any{
+		"Command":   "Draw/SendHttpGif",
+		"PicNum":    1,
+		"PicWidth":  p.size,
+		"PicOffset": 0,
+		"PicID":     p.id + 1,
+		"PicSpeed":  1000,
+		"PicData":   base64.StdEncoding.EncodeToString(rgb),
+	})
+	if err != nil {
+		// Start over with fresh ids, the device may have restarted
+		p.id = 0
+		return err
+	}
+	p.id++
+	return nil
+}
+
+// command sends one command to the Pixoo's API.
+func (p *Pixoo) command(body map[string]any) error {
+	var reply pixooReply
+	if err := post(p.client, "http://"+p.host+"/post", body, &reply); err != nil {
+		return err
+	}
+	if reply.ErrorCode != 0 {
+		return fmt.Errorf("%s: error code %d", body["Command"], reply.ErrorCode)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Package noise provides seeded coherent noise functions (value, Perlin and
+// simplex), fractal Brownian motion and curl noise for procedural scenes.
+package noise
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+// Noise2D is a coherent 2D noise function.
+type Noise2D interface {
+	Noise2(x, y float64) float64
+}
+
+// Noise3D is a coherent 3D noise function. Using the third axis as time
+// gives 2D patterns that evolve smoothly instead of just sliding.
+type Noise3D interface {
+	Noise3(x, y, z float64) float64
+}
+
+// permutation returns a seeded permutation of 0-255 repeated twice, so
+// lookups of p[i]+j never need wrapping.
+func permutation(seed int64) [512]uint8 {
+	var p [512]uint8
+	perm := rand.New(rand.NewSource(seed)).Perm(256)
+	for i, v := range perm {
+		p[i] = uint8(v)
+		p[i+256] = uint8(v)
+	}
+	return p
+}
+
+// fade is the quintic smootherstep curve used for gradient noise interpolation.
+func fade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// smooth is the cubic smoothstep curve.
+func smooth(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// lerp linearly interpolates between a and b.
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// Value is lattice value noise: random values at integer points, smoothly
+// interpolated. It is the cheapest noise here but shows its grid more.
+type Value struct {
+	seed uint64
+}
+
+// NewValue creates value noise with the given seed.
+func NewValue(seed int64) *Value {
+	return &Value{seed: uint64(seed)}
+}
+
+// Noise2 returns value noise at (x, y) in [0, 1].
+func (v *Value) Noise2(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	tx, ty := smooth(x-x0), smooth(y-y0)
+	ix, iy := int64(x0), int64(y0)
+
+	a := v.hash(ix, iy)
+	b := v.hash(ix+1, iy)
+	c := v.hash(ix, iy+1)
+	d := v.hash(ix+1, iy+1)
+	return lerp(lerp(a, b, tx), lerp(c, d, tx), ty)
+}
+
+// hash maps lattice coordinates to a pseudo-random value in [0, 1].
+func (v *Value) hash(x, y int64) float64 {
+	h := uint64(x)*0x9E3779B97F4A7C15 ^ uint64(y)*0xC2B2AE3D27D4EB4F ^ v.seed*0x94D049BB133111EB
+	h ^= h >> 31
+	h *= 0xBF58476D1CE4E5B9
+	h ^= h >> 29
+	return float64(h>>11) / float64(1<<53)
+}
+
+// FBM sums octaves of a noise source at increasing frequency and decreasing
+// amplitude (fractal Brownian motion), adding detail at every scale.
+type FBM struct {
+	Octaves int
+	// Frequency multiplier between octaves, usually about 2
+	Lacunarity float64
+	// Amplitude multiplier between octaves, usually about 0.5
+	Gain float64
+}
+
+// DefaultFBM returns a four octave fractal with the usual doubling frequency.
+func DefaultFBM() FBM {
+	return FBM{Octaves: 4, Lacunarity: 2, Gain: 0.5}
+}
+
+// Noise2 returns the fractal sum of n at (x, y), normalized so the result
+// stays in the range of a single octave.
+func (f FBM) Noise2(n Noise2D, x, y float64) float64 {
+	sum, amp, norm := 0.0, 1.0, 0.0
+	for i := 0; i < f.Octaves; i++ {
+		sum += n.Noise2(x, y) * amp
+		norm += amp
+		amp *= f.Gain
+		x *= f.Lacunarity
+		y *= f.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// Noise3 returns the fractal sum of n at (x, y, z), normalized like Noise2.
+func (f FBM) Noise3(n Noise3D, x, y, z float64) float64 {
+	sum, amp, norm := 0.0, 1.0, 0.0
+	for i := 0; i < f.Octaves; i++ {
+		sum += n.Noise3(x, y, z) * amp
+		norm += amp
+		amp *= f.Gain
+		x *= f.Lacunarity
+		y *= f.Lacunarity
+		z *= f.Lacunarity
+	}
+	if norm == 0 {
+		return 0
+	}
+	return sum / norm
+}
+
+// curlStep is the finite difference distance used to estimate derivatives.
+const curlStep = 1e-3
+
+// Curl2 returns the curl of the noise field n at (x, y), treating n as a
+// stream function. The resulting velocity field has no divergence, so
+// particles advected by it swirl without bunching up or thinning out.
+func Curl2(n Noise2D, x, y float64) vec.Vec2 {
+	dx := (n.Noise2(x+curlStep, y) - n.Noise2(x-curlStep, y)) / (2 * curlStep)
+	dy := (n.Noise2(x, y+curlStep) - n.Noise2(x, y-curlStep)) / (2 * curlStep)
+	return vec.Vec2{X: dy, Y: -dx}
+}
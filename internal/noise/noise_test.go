@@ -0,0 +1,164 @@
+package noise
+
+import (
+	"math"
+	"testing"
+)
+
+// sample evaluates f over a grid of non-integer points and returns the value range.
+func sample(f func(x, y float64) float64) (lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for i := 0; i < 200; i++ {
+		for j := 0; j < 200; j++ {
+			v := f(float64(i)*0.137-13, float64(j)*0.173-17)
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	return lo, hi
+}
+
+func TestRanges(t *testing.T) {
+	perlin, simplex := NewPerlin(1), NewSimplex(1)
+	tests := []struct {
+		name   string
+		f      func(x, y float64) float64
+		lo, hi float64
+	}{
+		{"value", NewValue(1).Noise2, 0, 1},
+		{"perlin2", perlin.Noise2, -1, 1},
+		{"perlin3", func(x, y float64) float64 { return perlin.Noise3(x, y, x*0.5-y) }, -1, 1},
+		{"simplex2", simplex.Noise2, -1, 1},
+		{"simplex3", func(x, y float64) float64 { return simplex.Noise3(x, y, x*0.5-y) }, -1, 1},
+	}
+	for _, tt := range tests {
+		lo, hi := sample(tt.f)
+		if lo < tt.lo || hi > tt.hi {
+			t.Errorf("%s: range [%.3f, %.3f] outside [%v, %v]", tt.name, lo, hi, tt.lo, tt.hi)
+		}
+		// A working noise function uses a good part of its range
+		if hi-lo < (tt.hi-tt.lo)*0.4 {
+			t.Errorf("%s: range [%.3f, %.3f] is suspiciously narrow", tt.name, lo, hi)
+		}
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	a, b, c := NewSimplex(7), NewSimplex(7), NewSimplex(8)
+	same, differ := true, false
+	for i := 0; i < 100; i++ {
+		x, y := float64(i)*0.31, float64(i)*0.17
+		if a.Noise2(x, y) != b.Noise2(x, y) {
+			same = false
+		}
+		if a.Noise2(x, y) != c.Noise2(x, y) {
+			differ = true
+		}
+	}
+	if !same {
+		t.Error("equal seeds produced different noise")
+	}
+	if !differ {
+		t.Error("different seeds produced identical noise")
+	}
+}
+
+func TestPerlinLattice(t *testing.T) {
+	p := NewPerlin(3)
+	for i := -5; i <= 5; i++ {
+		if v := p.Noise2(float64(i), float64(i*2)); v != 0 {
+			t.Errorf("Noise2(%d, %d) = %v, want 0 at lattice points", i, i*2, v)
+		}
+	}
+}
+
+func TestContinuity(t *testing.T) {
+	s := NewSimplex(1)
+	for i := 0; i < 1000; i++ {
+		x := float64(i) * 0.0123
+		if d := math.Abs(s.Noise2(x, 0.5) - s.Noise2(x+1e-4, 0.5)); d > 1e-2 {
+			t.Fatalf("jump of %v at x=%v", d, x)
+		}
+	}
+}
+
+func TestFBM(t *testing.T) {
+	v := NewValue(1)
+	f := DefaultFBM()
+	lo, hi := sample(func(x, y float64) float64 { return f.Noise2(v, x, y) })
+	if lo < 0 || hi > 1 {
+		t.Errorf("fbm of value noise range [%v, %v] outside [0, 1]", lo, hi)
+	}
+	// A single octave is the source itself
+	one := FBM{Octaves: 1, Lacunarity: 2, Gain: 0.5}
+	if one.Noise2(v, 1.3, 2.7) != v.Noise2(1.3, 2.7) {
+		t.Error("single octave fbm differs from its source")
+	}
+	if (FBM{}).Noise2(v, 1, 1) != 0 {
+		t.Error("zero octave fbm is not zero")
+	}
+}
+
+func TestCurlDivergenceFree(t *testing.T) {
+	s := NewSimplex(5)
+	const h = 1e-2
+	for i := 0; i < 50; i++ {
+		x, y := float64(i)*0.37, float64(i)*0.21+0.1
+		div := (Curl2(s, x+h, y).X-Curl2(s, x-h, y).X)/(2*h) +
+			(Curl2(s, x, y+h).Y-Curl2(s, x, y-h).Y)/(2*h)
+		if math.Abs(div) > 0.05 {
+			t.Errorf("divergence %v at (%v, %v)", div, x, y)
+		}
+	}
+}
+
+// sink keeps benchmark results alive so the compiler cannot drop the calls.
+var sink float64
+
+func BenchmarkValue2(b *testing.B) {
+	n := NewValue(1)
+	for i := 0; i < b.N; i++ {
+		sink += n.Noise2(float64(i)*0.01, 0.5)
+	}
+}
+
+func BenchmarkPerlin2(b *testing.B) {
+	n := NewPerlin(1)
+	for i := 0; i < b.N; i++ {
+		sink += n.Noise2(float64(i)*0.01, 0.5)
+	}
+}
+
+func BenchmarkPerlin3(b *testing.B) {
+	n := NewPerlin(1)
+	for i := 0; i < b.N; i++ {
+		sink += n.Noise3(float64(i)*0.01, 0.5, 0.25)
+	}
+}
+
+func BenchmarkSimplex2(b *testing.B) {
+	n := NewSimplex(1)
+	for i := 0; i < b.N; i++ {
+		sink += n.Noise2(float64(i)*0.01, 0.5)
+	}
+}
+
+func BenchmarkSimplex3(b *testing.B) {
+	n := NewSimplex(1)
+	for i := 0; i < b.N; i++ {
+		sink += n.Noise3(float64(i)*0.01, 0.5, 0.25)
+	}
+}
+
+func BenchmarkFBM3(b *testing.B) {
+	n, f := NewSimplex(1), DefaultFBM()
+	for i := 0; i < b.N; i++ {
+		sink += f.Noise3(n, float64(i)*0.01, 0.5, 0.25)
+	}
+}
+
+func BenchmarkCurl2(b *testing.B) {
+	n := NewSimplex(1)
+	for i := 0; i < b.N; i++ {
+		sink += Curl2(n, float64(i)*0.01, 0.5).X
+	}
+}
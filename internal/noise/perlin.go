@@ -0,0 +1,104 @@
+package noise
+
+import "math"
+
+// Perlin is classic improved gradient noise. Values are in roughly [-1, 1]
+// and are zero at every integer lattice point.
+type Perlin struct {
+	perm [512]uint8
+}
+
+// NewPerlin creates Perlin noise with the given seed.
+func NewPerlin(seed int64) *Perlin {
+	return &Perlin{perm: permutation(seed)}
+}
+
+// Noise2 returns Perlin noise at (x, y).
+func (p *Perlin) Noise2(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	xi, yi := int(x0)&255, int(y0)&255
+	xf, yf := x-x0, y-y0
+	u, v := fade(xf), fade(yf)
+
+	perm := &p.perm
+	aa := perm[int(perm[xi])+yi]
+	ab := perm[int(perm[xi])+yi+1]
+	ba := perm[int(perm[xi+1])+yi]
+	bb := perm[int(perm[xi+1])+yi+1]
+
+	return lerp(
+		lerp(grad2(aa, xf, yf), grad2(ba, xf-1, yf), u),
+		lerp(grad2(ab, xf, yf-1), grad2(bb, xf-1, yf-1), u),
+		v,
+	)
+}
+
+// Noise3 returns Perlin noise at (x, y, z).
+func (p *Perlin) Noise3(x, y, z float64) float64 {
+	x0, y0, z0 := math.Floor(x), math.Floor(y), math.Floor(z)
+	xi, yi, zi := int(x0)&255, int(y0)&255, int(z0)&255
+	xf, yf, zf := x-x0, y-y0, z-z0
+	u, v, w := fade(xf), fade(yf), fade(zf)
+
+	perm := &p.perm
+	a := int(perm[xi]) + yi
+	aa, ab := int(perm[a])+zi, int(perm[a+1])+zi
+	b := int(perm[xi+1]) + yi
+	ba, bb := int(perm[b])+zi, int(perm[b+1])+zi
+
+	return lerp(
+		lerp(
+			lerp(grad3(perm[aa], xf, yf, zf), grad3(perm[ba], xf-1, yf, zf), u),
+			lerp(grad3(perm[ab], xf, yf-1, zf), grad3(perm[bb], xf-1, yf-1, zf), u),
+			v),
+		lerp(
+			lerp(grad3(perm[aa+1], xf, yf, zf-1), grad3(perm[ba+1], xf-1, yf, zf-1), u),
+			lerp(grad3(perm[ab+1], xf, yf-1, zf-1), grad3(perm[bb+1], xf-1, yf-1, zf-1), u),
+			v),
+		w,
+	)
+}
+
+// grad2 returns the dot product of a hashed 2D gradient direction with (x, y).
+func grad2(hash uint8, x, y float64) float64 {
+	switch hash & 7 {
+	case 0:
+		return x + y
+	case 1:
+		return -x + y
+	case 2:
+		return x - y
+	case 3:
+		return -x - y
+	case 4:
+		return x
+	case 5:
+		return -x
+	case 6:
+		return y
+	default:
+		return -y
+	}
+}
+
+// grad3 returns the dot product of one of the 12 cube edge gradients with (x, y, z).
+func grad3(hash uint8, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	v := z
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
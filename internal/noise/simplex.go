@@ -0,0 +1,118 @@
+package noise
+
+import "math"
+
+// Skewing factors between the simplex grid and regular coordinates
+var (
+	skew2   = 0.5 * (math.Sqrt(3) - 1)
+	unskew2 = (3 - math.Sqrt(3)) / 6
+)
+
+const (
+	skew3   = 1.0 / 3
+	unskew3 = 1.0 / 6
+)
+
+// gradients3 are the cube edge directions shared by 2D and 3D simplex noise.
+var gradients3 = [12][3]float64{
+	{1, 1, 0}, {-1, 1, 0}, {1, -1, 0}, {-1, -1, 0},
+	{1, 0, 1}, {-1, 0, 1}, {1, 0, -1}, {-1, 0, -1},
+	{0, 1, 1}, {0, -1, 1}, {0, 1, -1}, {0, -1, -1},
+}
+
+// Simplex is simplex gradient noise. It has fewer directional artifacts than
+// Perlin noise and is cheaper in 3D. Values are in roughly [-1, 1].
+type Simplex struct {
+	perm    [512]uint8
+	permMod [512]uint8
+}
+
+// NewSimplex creates simplex noise with the given seed.
+func NewSimplex(seed int64) *Simplex {
+	s := &Simplex{perm: permutation(seed)}
+	for i, v := range s.perm {
+		s.permMod[i] = v % 12
+	}
+	return s
+}
+
+// Noise2 returns simplex noise at (x, y).
+func (s *Simplex) Noise2(x, y float64) float64 {
+	// Find the simplex cell containing the point
+	k := (x + y) * skew2
+	i, j := math.Floor(x+k), math.Floor(y+k)
+	t := (i + j) * unskew2
+	x0, y0 := x-(i-t), y-(j-t)
+
+	// Upper or lower triangle of the skewed square
+	i1, j1 := 0, 1
+	if x0 > y0 {
+		i1, j1 = 1, 0
+	}
+	x1, y1 := x0-float64(i1)+unskew2, y0-float64(j1)+unskew2
+	x2, y2 := x0-1+2*unskew2, y0-1+2*unskew2
+
+	ii, jj := int(i)&255, int(j)&255
+	corner := func(g uint8, x, y float64) float64 {
+		t := 0.5 - x*x - y*y
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		gr := gradients3[g]
+		return t * t * (gr[0]*x + gr[1]*y)
+	}
+	n0 := corner(s.permMod[ii+int(s.perm[jj])], x0, y0)
+	n1 := corner(s.permMod[ii+i1+int(s.perm[jj+j1])], x1, y1)
+	n2 := corner(s.permMod[ii+1+int(s.perm[jj+1])], x2, y2)
+
+	// Scale the result to roughly [-1, 1]
+	return 70 * (n0 + n1 + n2)
+}
+
+// Noise3 returns simplex noise at (x, y, z).
+func (s *Simplex) Noise3(x, y, z float64) float64 {
+	k := (x + y + z) * skew3
+	i, j, l := math.Floor(x+k), math.Floor(y+k), math.Floor(z+k)
+	t := (i + j + l) * unskew3
+	x0, y0, z0 := x-(i-t), y-(j-t), z-(l-t)
+
+	// Pick the tetrahedron of the skewed cube containing the point
+	var i1, j1, k1, i2, j2, k2 int
+	switch {
+	case x0 >= y0 && y0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 1, 0
+	case x0 >= y0 && x0 >= z0:
+		i1, j1, k1, i2, j2, k2 = 1, 0, 0, 1, 0, 1
+	case x0 >= y0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 1, 0, 1
+	case y0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 0, 1, 0, 1, 1
+	case x0 < z0:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 0, 1, 1
+	default:
+		i1, j1, k1, i2, j2, k2 = 0, 1, 0, 1, 1, 0
+	}
+
+	x1, y1, z1 := x0-float64(i1)+unskew3, y0-float64(j1)+unskew3, z0-float64(k1)+unskew3
+	x2, y2, z2 := x0-float64(i2)+2*unskew3, y0-float64(j2)+2*unskew3, z0-float64(k2)+2*unskew3
+	x3, y3, z3 := x0-1+3*unskew3, y0-1+3*unskew3, z0-1+3*unskew3
+
+	ii, jj, kk := int(i)&255, int(j)&255, int(l)&255
+	perm, mod := &s.perm, &s.permMod
+	corner := func(g uint8, x, y, z float64) float64 {
+		t := 0.6 - x*x - y*y - z*z
+		if t < 0 {
+			return 0
+		}
+		t *= t
+		gr := gradients3[g]
+		return t * t * (gr[0]*x + gr[1]*y + gr[2]*z)
+	}
+	n0 := corner(mod[ii+int(perm[jj+int(perm[kk])])], x0, y0, z0)
+	n1 := corner(mod[ii+i1+int(perm[jj+j1+int(perm[kk+k1])])], x1, y1, z1)
+	n2 := corner(mod[ii+i2+int(perm[jj+j2+int(perm[kk+k2])])], x2, y2, z2)
+	n3 := corner(mod[ii+1+int(perm[jj+1+int(perm[kk+1])])], x3, y3, z3)
+
+	return 32 * (n0 + n1 + n2 + n3)
+}
@@ -0,0 +1,203 @@
+package overlay
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Anchor is the point of the screen an overlay is placed against.
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+// AnchorNames are the names of the anchors, in Anchor order.
+var AnchorNames = []string{"top-left", "top", "top-right", "left", "center", "right", "bottom-left", "bottom", "bottom-right"}
+
+// ParseAnchor returns the anchor with the given name, such as "top-right".
+func ParseAnchor(name string) (Anchor, error) {
+	for i, n := range AnchorNames {
+		if strings.EqualFold(n, name) {
+			return Anchor(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown anchor %q (available: %s)", name, strings.Join(AnchorNames, ", "))
+}
+
+// String returns the anchor's name.
+func (a Anchor) String() string {
+	if a < 0 || int(a) >= len(AnchorNames) {
+		return fmt.Sprintf("Anchor(%d)", int(a))
+	}
+	return AnchorNames[a]
+}
+
+// MarshalText encodes the anchor by name, so configurations read like the
+// -place flag.
+func (a Anchor) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText decodes an anchor name.
+func (a *Anchor) UnmarshalText(text []byte) error {
+	anchor, err := ParseAnchor(string(text))
+	*a = anchor
+	return err
+}
+
+// column returns -1, 0 or 1 for anchors on the left, middle or right.
+func (a Anchor) column() int {
+	return int(a)%3 - 1
+}
+
+// row returns -1, 0 or 1 for anchors at the top, middle or bottom.
+func (a Anchor) row() int {
+	return int(a)/3 - 1
+}
+
+// Placement says where an overlay goes on the screen.
+type Placement struct {
+	Anchor Anchor
+	// Margin is the number of cells kept free between the overlay and the
+	// screen edges it is anchored to, or the overlay stacked before it
+	Margin int
+}
+
+// Rect is an area of the screen in cells.
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Empty reports whether the area has no cells.
+func (r Rect) Empty() bool {
+	return r.W <= 0 || r.H <= 0
+}
+
+// overlaps reports whether the two areas share a cell.
+func (r Rect) overlaps(o Rect) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W && r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
+// placed is an overlay with its placement.
+type placed struct {
+	overlay   Overlay
+	placement Placement
+}
+
+// Layout arranges overlays on the screen by their placements, so they need
+// no coordinates of their own. Overlays at the same anchor stack away from
+// it in the order they were added; an overlay that would cover one placed
+// before it moves on along its stack, and is left out once it runs off the
+// screen. The zero Layout is empty and ready to use.
+type Layout struct {
+	items []placed
+}
+
+// Add places an overlay after the ones already added.
+func (l *Layout) Add(o Overlay, p Placement) {
+	l.items = append(l.items, placed{overlay: o, placement: p})
+}
+
+// Len returns the number of overlays in the layout.
+func (l *Layout) Len() int {
+	return len(l.items)
+}
+
+// Update advances every overlay to time t.
+func (l *Layout) Update(t float64) {
+	for _, it := range l.items {
+		it.overlay.Update(t)
+	}
+}
+
+// Render arranges the overlays for the screen size and draws each that fits.
+func (l *Layout) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	for i, area := range l.arrange(width, height) {
+		if !area.Empty() {
+			l.items[i].overlay.Render(r, area)
+		}
+	}
+}
+
+// arrange returns the area of every overlay on a screen of the given size,
+// empty for overlays that do not fit.
+func (l *Layout) arrange(width, height int) []Rect {
+	areas := make([]Rect, len(l.items))
+	// Row each anchor's stack continues from: the row below it for stacks
+	// growing down from the top and middle, the row above it for stacks
+	// growing up from the bottom
+	next := map[Anchor]int{}
+	for i, it := range l.items {
+		p := it.placement
+		margin := max(p.Margin, 0)
+		w, h := it.overlay.Size(width, height)
+		if p.Anchor.column() != 0 {
+			w = min(w, width-margin)
+		}
+		w, h = min(w, width), min(h, height)
+		if w <= 0 || h <= 0 {
+			continue
+		}
+
+		area := Rect{W: w, H: h}
+		switch p.Anchor.column() {
+		case -1:
+			area.X = margin
+		case 0:
+			area.X = (width - w) / 2
+		case 1:
+			area.X = width - w - margin
+		}
+		cursor, stacking := next[p.Anchor]
+		step := 1
+		switch p.Anchor.row() {
+		case -1:
+			area.Y = cursor + margin
+		case 0:
+			area.Y = (height - h) / 2
+			if stacking {
+				area.Y = cursor + margin
+			}
+		case 1:
+			if !stacking {
+				cursor = height
+			}
+			area.Y = cursor - margin - h
+			step = -1
+		}
+		for area.Y >= 0 && area.Y+h <= height && covers(areas[:i], area) {
+			area.Y += step
+		}
+		if area.Y < 0 || area.Y+h > height {
+			continue
+		}
+		areas[i] = area
+		next[p.Anchor] = area.Y + h
+		if step < 0 {
+			next[p.Anchor] = area.Y
+		}
+	}
+	return areas
+}
+
+// covers reports whether area overlaps any of the placed areas.
+func covers(placed []Rect, area Rect) bool {
+	for _, p := range placed {
+		if !p.Empty() && p.overlaps(area) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package overlay
+
+import (
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// box is an overlay of a fixed size; a width of -1 spans the screen.
+type box struct{ w, h int }
+
+func (b box) Update(float64) {}
+
+func (b box) Size(width, height int) (int, int) {
+	if b.w < 0 {
+		return width, b.h
+	}
+	return b.w, b.h
+}
+
+func (b box) Render(*renderer.Renderer, Rect) {}
+
+func TestArrange(t *testing.T) {
+	var l Layout
+	l.Add(box{-1, 1}, Placement{Anchor: Bottom, Margin: 1})
+	l.Add(box{10, 2}, Placement{Anchor: TopRight, Margin: 1})
+	l.Add(box{8, 3}, Placement{Anchor: TopRight, Margin: 1})
+	// Would cover the ticker, so it moves up past it
+	l.Add(box{6, 1}, Placement{Anchor: BottomLeft, Margin: 1})
+	l.Add(box{4, 2}, Placement{Anchor: Center})
+	l.Add(box{4, 2}, Placement{Anchor: Center})
+	// No room left above the other one at this corner
+	l.Add(box{6, 20}, Placement{Anchor: BottomLeft})
+
+	want := []Rect{
+		{X: 0, Y: 18, W: 40, H: 1},
+		{X: 29, Y: 1, W: 10, H: 2},
+		{X: 31, Y: 4, W: 8, H: 3},
+		{X: 1, Y: 17, W: 6, H: 1},
+		{X: 18, Y: 9, W: 4, H: 2},
+		{X: 18, Y: 11, W: 4, H: 2},
+		{},
+	}
+	got := l.arrange(40, 20)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("overlay %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseAnchor(t *testing.T) {
+	for i, name := range AnchorNames {
+		a, err := ParseAnchor(name)
+		if err != nil || a != Anchor(i) {
+			t.Errorf("ParseAnchor(%q) = %v, %v; want %v", name, a, err, Anchor(i))
+		}
+		text, _ := a.MarshalText()
+		if string(text) != name {
+			t.Errorf("%v: marshaled as %q", a, text)
+		}
+	}
+	if _, err := ParseAnchor("middle"); err == nil {
+		t.Error("ParseAnchor accepted an unknown anchor")
+	}
+}
@@ -0,0 +1,295 @@
+package overlay
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/png" // PNG logos
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// logoDriftPeriod is roughly how many seconds a drifting logo takes to
+// wander across its drift area and back.
+const logoDriftPeriod = 300
+
+// logoText is the style of plain text logos, and of ANSI logos where they
+// use the default colors.
+var logoText = tcell.StyleDefault.Foreground(logoForeground)
+
+// logoForeground is the color of text in logos without a color of its own.
+var logoForeground = tcell.NewRGBColor(235, 235, 235)
+
+// logoCell is one cell of a logo; cells with no character are transparent.
+type logoCell struct {
+	ch    rune
+	style tcell.Style
+}
+
+// Logo is a watermark drawn translucently over the scene, such as a
+// company logo on a lobby display. It wanders a few cells around its place
+// over the minutes so it never burns into the screen.
+type Logo struct {
+	cells   [][]logoCell
+	width   int
+	opacity float64
+	drift   int // Cells the logo wanders sideways, half as many up and down
+	dx, dy  int
+}
+
+// LoadLogo reads a watermark from a PNG image, drawn with half blocks at
+// most width cells wide, from a text file with ANSI colors (.ans), or from
+// plain text. It is drawn at opacity from 0 to 1 and wanders up to drift
+// cells, 0 to keep it still.
+func LoadLogo(path string, width int, opacity float64, drift int) (*Logo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Logo{opacity: opacity, drift: drift}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		l.cells = halfBlocks(img, width)
+	case ".ans", ".ansi":
+		l.cells = parseANSI(string(data))
+	default:
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var row []logoCell
+			for _, ch := range strings.ReplaceAll(scanner.Text(), "\t", "        ") {
+				row = append(row, logoCell{ch: visible(ch, logoText), style: logoText})
+			}
+			l.cells = append(l.cells, row)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	for _, row := range l.cells {
+		l.width = max(l.width, len(row))
+	}
+	return l, nil
+}
+
+// Update moves the logo along its drift path to time t.
+func (l *Logo) Update(t float64) {
+	// Two slow waves at unrelated periods cover the area without repeating
+	// a visible pattern
+	phase := 2 * math.Pi * t / logoDriftPeriod
+	l.dx = int(math.Round(float64(l.drift) * math.Sin(phase)))
+	l.dy = int(math.Round(float64(l.drift/2) * math.Sin(phase*0.618+1)))
+}
+
+// Size returns the size of the logo plus the room it drifts in.
+func (l *Logo) Size(width, height int) (int, int) {
+	return l.width + 2*l.drift, len(l.cells) + 2*(l.drift/2)
+}
+
+// Render draws the logo at its drift offset in the area, blending it with
+// the scene below by its opacity.
+func (l *Logo) Render(r *renderer.Renderer, area Rect) {
+	style := r.LayerStyle(renderer.LayerOverlay)
+	faded := style
+	faded.Opacity *= l.opacity
+	r.SetLayerStyle(renderer.LayerOverlay, faded)
+	defer r.SetLayerStyle(renderer.LayerOverlay, style)
+
+	left, top := l.drift+l.dx, l.drift/2+l.dy
+	for y, row := range l.cells {
+		for x, c := range row {
+			if c.ch == 0 || left+x >= area.W || top+y >= area.H {
+				continue
+			}
+			r.SetCell(area.X+left+x, area.Y+top+y, c.ch, Depth, c.style)
+		}
+	}
+}
+
+// visible returns ch, or no character for a space without a background
+// so the scene shows through it.
+func visible(ch rune, style tcell.Style) rune {
+	if _, bg, _ := style.Decompose(); ch == ' ' && bg == tcell.ColorDefault {
+		return 0
+	}
+	return ch
+}
+
+// halfBlocks scales img down to at most width cells and draws every cell
+// as two pixels stacked, the upper in the foreground of '▀' and the lower in
+// its background. Transparent pixels leave the scene showing.
+func halfBlocks(img image.Image, width int) [][]logoCell {
+	b := img.Bounds()
+	w := min(width, b.Dx())
+	if w <= 0 || b.Dy() <= 0 {
+		return nil
+	}
+	// Cells are about twice as tall as wide, so pixels come out square
+	h := max(int(math.Round(float64(b.Dy())*float64(w)/float64(b.Dx()))), 1)
+	// pixel averages the source pixels covered by the scaled pixel x, y,
+	// returning its color and whether it is mostly opaque
+	pixel := func(x, y int) (tcell.Color, bool) {
+		x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+max((x+1)*b.Dx()/w, x*b.Dx()/w+1)
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+max((y+1)*b.Dy()/h, y*b.Dy()/h+1)
+		var sr, sg, sb, sa, n uint64
+		for py := y0; py < y1; py++ {
+			for px := x0; px < x1; px++ {
+				cr, cg, cb, ca := img.At(px, py).RGBA()
+				sr, sg, sb, sa, n = sr+uint64(cr), sg+uint64(cg), sb+uint64(cb), sa+uint64(ca), n+1
+			}
+		}
+		if sa < n*0x8000 {
+			return tcell.ColorDefault, false
+		}
+		// Colors are premultiplied, so dividing by the alpha undoes it
+		return tcell.NewRGBColor(int32(sr*255/sa), int32(sg*255/sa), int32(sb*255/sa)), true
+	}
+
+	cells := make([][]logoCell, (h+1)/2)
+	for y := range cells {
+		cells[y] = make([]logoCell, w)
+		for x := range w {
+			upper, hasUpper := pixel(x, 2*y)
+			lower, hasLower := tcell.ColorDefault, false
+			if 2*y+1 < h {
+				lower, hasLower = pixel(x, 2*y+1)
+			}
+			switch {
+			case hasUpper && hasLower:
+				cells[y][x] = logoCell{'▀', tcell.StyleDefault.Foreground(upper).Background(lower)}
+			case hasUpper:
+				cells[y][x] = logoCell{'▀', tcell.StyleDefault.Foreground(upper)}
+			case hasLower:
+				cells[y][x] = logoCell{'▄', tcell.StyleDefault.Foreground(lower)}
+			}
+		}
+	}
+	return cells
+}
+
+// ansiColors are the 16 standard terminal colors, normal then bright.
+var ansiColors = [16][3]int32{
+	{0, 0, 0}, {170, 0, 0}, {0, 170, 0}, {170, 85, 0}, {0, 0, 170}, {170, 0, 170}, {0, 170, 170}, {170, 170, 170},
+	{85, 85, 85}, {255, 85, 85}, {85, 255, 85}, {255, 255, 85}, {85, 85, 255}, {255, 85, 255}, {85, 255, 255}, {255, 255, 255},
+}
+
+// parseANSI reads text colored with SGR escape sequences: the 16 standard
+// colors, bold as bright, 256 colors and true color. Other escape sequences
+// are skipped.
+func parseANSI(text string) [][]logoCell {
+	cells := [][]logoCell{nil}
+	style := logoText
+	bold := false
+	fgIndex := -1 // Standard foreground color, brightened by bold
+	runes := []rune(strings.ReplaceAll(text, "\r\n", "\n"))
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '\n':
+			cells = append(cells, nil)
+		case ch == '\x1b' && i+1 < len(runes) && runes[i+1] == '[':
+			// Control sequence: parameters up to a final letter
+			end := i + 2
+			for end < len(runes) && (runes[end] < '@' || runes[end] > '~') {
+				end++
+			}
+			if end < len(runes) && runes[end] == 'm' {
+				style, bold, fgIndex = sgr(string(runes[i+2:end]), style, bold, fgIndex)
+			}
+			i = end
+		case ch == '\t':
+			for range 8 {
+				cells[len(cells)-1] = append(cells[len(cells)-1], logoCell{})
+			}
+		case ch < ' ':
+		default:
+			cells[len(cells)-1] = append(cells[len(cells)-1], logoCell{ch: visible(ch, style), style: style})
+		}
+	}
+	// Files usually end with a newline
+	if len(cells[len(cells)-1]) == 0 {
+		cells = cells[:len(cells)-1]
+	}
+	return cells
+}
+
+// sgr applies the parameters of a Select Graphic Rendition sequence.
+func sgr(params string, style tcell.Style, bold bool, fgIndex int) (tcell.Style, bool, int) {
+	codes := []int{0}
+	if params != "" {
+		codes = codes[:0]
+		for _, p := range strings.Split(params, ";") {
+			n, _ := strconv.Atoi(p)
+			codes = append(codes, n)
+		}
+	}
+	rgb := func(c [3]int32) tcell.Color { return tcell.NewRGBColor(c[0], c[1], c[2]) }
+	standard := func(i int) tcell.Color {
+		if bold && i < 8 {
+			i += 8
+		}
+		return rgb(ansiColors[i])
+	}
+	// extended reads a 256 or true color given from codes[j], returning the
+	// color and the number of codes it took
+	extended := func(j int) (tcell.Color, int) {
+		switch {
+		case j+1 < len(codes) && codes[j] == 5:
+			return tcell.PaletteColor(codes[j+1]).TrueColor(), 2
+		case j+3 < len(codes) && codes[j] == 2:
+			return tcell.NewRGBColor(int32(codes[j+1]), int32(codes[j+2]), int32(codes[j+3])), 4
+		}
+		return tcell.ColorDefault, len(codes) - j
+	}
+	for j := 0; j < len(codes); j++ {
+		switch c := codes[j]; {
+		case c == 0:
+			style, bold, fgIndex = logoText, false, -1
+		case c == 1:
+			bold = true
+			if fgIndex >= 0 {
+				style = style.Foreground(standard(fgIndex))
+			}
+		case c == 22:
+			bold = false
+			if fgIndex >= 0 {
+				style = style.Foreground(standard(fgIndex))
+			}
+		case c >= 30 && c <= 37:
+			fgIndex = c - 30
+			style = style.Foreground(standard(fgIndex))
+		case c >= 90 && c <= 97:
+			fgIndex = -1
+			style = style.Foreground(rgb(ansiColors[c-90+8]))
+		case c == 39:
+			fgIndex = -1
+			style = style.Foreground(logoForeground)
+		case c >= 40 && c <= 47:
+			style = style.Background(rgb(ansiColors[c-40]))
+		case c >= 100 && c <= 107:
+			style = style.Background(rgb(ansiColors[c-100+8]))
+		case c == 49:
+			style = style.Background(tcell.ColorDefault)
+		case c == 38 || c == 48:
+			color, n := extended(j + 1)
+			j += n
+			if c == 38 {
+				fgIndex = -1
+				style = style.Foreground(color)
+			} else {
+				style = style.Background(color)
+			}
+		}
+	}
+	return style, bold, fgIndex
+}
@@ -0,0 +1,74 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseANSI(t *testing.T) {
+	cells := parseANSI("\x1b[31mA\x1b[1mB\x1b[0m C\n\x1b[38;2;1;2;3;44m D\x1b[K\n")
+	if len(cells) != 2 {
+		t.Fatalf("got %d rows, want 2", len(cells))
+	}
+	fg := func(c logoCell) tcell.Color {
+		f, _, _ := c.style.Decompose()
+		return f
+	}
+	want := []struct {
+		ch rune
+		fg tcell.Color
+	}{
+		{'A', tcell.NewRGBColor(170, 0, 0)},
+		// Bold brightens the standard colors
+		{'B', tcell.NewRGBColor(255, 85, 85)},
+		// A space without background is transparent
+		{0, logoForeground},
+		{'C', logoForeground},
+	}
+	if len(cells[0]) != len(want) {
+		t.Fatalf("got %d cells in the first row, want %d", len(cells[0]), len(want))
+	}
+	for i, w := range want {
+		if c := cells[0][i]; c.ch != w.ch || fg(c) != w.fg {
+			t.Errorf("cell %d: got %q in %v, want %q in %v", i, c.ch, fg(c), w.ch, w.fg)
+		}
+	}
+
+	// A space on a background is drawn
+	space := cells[1][0]
+	if _, bg, _ := space.style.Decompose(); space.ch != ' ' || bg != tcell.NewRGBColor(0, 0, 170) {
+		t.Errorf("got %q on %v, want a space on blue", space.ch, bg)
+	}
+	if got := fg(cells[1][1]); got != tcell.NewRGBColor(1, 2, 3) {
+		t.Errorf("true color: got %v, want #010203", got)
+	}
+}
+
+func TestHalfBlocks(t *testing.T) {
+	// Red over blue with a transparent right half, 4 by 4 pixels
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 2 {
+			c := color.NRGBA{R: 255, A: 255}
+			if y >= 2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	cells := halfBlocks(img, 2)
+	if len(cells) != 1 || len(cells[0]) != 2 {
+		t.Fatalf("got %d rows, want 1 row of 2 cells", len(cells))
+	}
+	fg, bg, _ := cells[0][0].style.Decompose()
+	if cells[0][0].ch != '▀' || fg != tcell.NewRGBColor(255, 0, 0) || bg != tcell.NewRGBColor(0, 0, 255) {
+		t.Errorf("got %q in %v on %v, want ▀ in red on blue", cells[0][0].ch, fg, bg)
+	}
+	if cells[0][1].ch != 0 {
+		t.Errorf("transparent cell: got %q, want none", cells[0][1].ch)
+	}
+}
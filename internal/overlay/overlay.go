@@ -0,0 +1,17 @@
+// Package overlay provides widgets drawn on top of the active scene.
+package overlay
+
+import "github.com/olegchuev/screensaver/internal/renderer"
+
+// Depth places overlays in front of any scene content.
+const Depth = 1e8
+
+// Overlay is a widget that is updated and drawn after the scene every frame,
+// in the area a Layout gives it.
+type Overlay interface {
+	Update(t float64)
+	// Size returns the cells the overlay would like on a screen of the
+	// given size; it may be given fewer
+	Size(width, height int) (w, h int)
+	Render(r *renderer.Renderer, area Rect)
+}
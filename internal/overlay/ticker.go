@@ -0,0 +1,100 @@
+package overlay
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/animation"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	tickerGap     = 6   // Empty cells between repeated messages
+	tickerPadding = 1   // Cells of pill background on each side of the text
+	tickerSlideIn = 1.2 // Seconds for the ticker to rise into place
+)
+
+var (
+	tickerBackground = tcell.NewRGBColor(40, 60, 90)
+	tickerText       = tcell.StyleDefault.Foreground(tcell.NewRGBColor(235, 235, 235)).Background(tickerBackground)
+	tickerDot        = tcell.StyleDefault.Foreground(tcell.NewRGBColor(120, 150, 190))
+)
+
+// Ticker scrolls a message across its area, one row spanning the screen
+// unless placed otherwise. The message pill
+// moves with sub-cell precision using partial block edges, and the separator
+// dots between repetitions glide in Braille steps, so the motion stays smooth
+// even though glyphs can only occupy whole cells.
+type Ticker struct {
+	text    []rune
+	speed   float64 // Cells per second
+	pos     float64 // Scroll offset in cells
+	slide   animation.Tween
+	start   float64
+	elapsed float64
+	started bool
+}
+
+// NewTicker creates a ticker scrolling text at the given speed in cells per second.
+func NewTicker(text string, speed float64) *Ticker {
+	return &Ticker{
+		text:  []rune(text),
+		speed: speed,
+		slide: animation.NewTween(2, 0, tickerSlideIn, animation.EaseOutCubic),
+	}
+}
+
+// Update advances the scroll position to time t.
+func (tk *Ticker) Update(t float64) {
+	if !tk.started {
+		tk.started = true
+		tk.start = t
+	}
+	tk.elapsed = t - tk.start
+	tk.pos = t * tk.speed
+}
+
+// Size returns a single row across the screen.
+func (tk *Ticker) Size(width, height int) (int, int) {
+	return width, 1
+}
+
+// Render draws the repeated message pills and separators along the top row
+// of the area, cut off at its sides.
+func (tk *Ticker) Render(r *renderer.Renderer, area Rect) {
+	if len(tk.text) == 0 {
+		return
+	}
+	row := area.Y
+	left, right := float64(area.X), float64(area.X+area.W)
+	// Rows below the resting position while sliding in
+	offset := tk.slide.Value(tk.elapsed)
+	pill := float64(len(tk.text) + tickerPadding*2)
+	period := pill + tickerGap
+
+	// First repetition starts just off the left side
+	start := left - math.Mod(tk.pos, period)
+	for x := start; x < right; x += period {
+		if from, to := max(x, left), min(x+pill, right); to > from {
+			r.FillRect(from, float64(row)+offset, to-from, 1, tickerBackground, Depth)
+		}
+		if offset > 0 {
+			continue // Text only fits once the pill rests on whole cells
+		}
+
+		// Glyphs may only occupy cells entirely covered by the pill
+		first := int(math.Round(x)) + tickerPadding
+		for i, ch := range tk.text {
+			cx := first + i
+			if float64(cx) < max(x, left) || float64(cx+1) > min(x+pill, right) {
+				continue
+			}
+			r.SetCell(cx, row, ch, Depth+1, tickerText)
+		}
+
+		// Separator glides through the gap at Braille resolution
+		if dot := x + pill + tickerGap/2.0; dot >= left && dot < right {
+			r.PlotDot(dot, float64(row)+0.5, Depth, tickerDot)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package overlay
+
+import (
+	"fmt"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/weather"
+)
+
+const (
+	weatherPadding = 1 // Cells of panel background around the contents
+	weatherGap     = 2 // Cells between the icon and the current weather
+	// weatherStale is the age from which a report shows when it is from,
+	// after refreshes have failed for a while
+	weatherStale = 90 * time.Minute
+)
+
+// weatherIcons are the ASCII art icons of the conditions, in
+// weather.Condition order, all iconWidth cells wide.
+var weatherIcons = [][]string{
+	{
+		`   \   /   `,
+		`    .-.    `,
+		` - (   ) - `,
+		`    '-'    `,
+	},
+	{
+		`  \  /     `,
+		`_ /"".-.   `,
+		`  \_(   ). `,
+		`  /(___(__)`,
+	},
+	{
+		`           `,
+		`    .--.   `,
+		` .-(    ). `,
+		`(___.__)__)`,
+	},
+	{
+		`           `,
+		` _ - _ - _ `,
+		`  _ - _ - _`,
+		` _ - _ - _ `,
+	},
+	{
+		`    .-.    `,
+		`   (   ).  `,
+		`  (___(__) `,
+		`   ' ' ' ' `,
+	},
+	{
+		`    .-.    `,
+		`   (   ).  `,
+		`  (___(__) `,
+		`  ,',',',' `,
+	},
+	{
+		`    .-.    `,
+		`   (   ).  `,
+		`  (___(__) `,
+		`   *  *  * `,
+	},
+	{
+		`    .-.    `,
+		`   (   ).  `,
+		`  (___(__) `,
+		`   /_ /_   `,
+	},
+}
+
+const iconWidth = 11
+
+var (
+	weatherBackground = tcell.NewRGBColor(30, 40, 60)
+	weatherText       = tcell.StyleDefault.Foreground(tcell.NewRGBColor(235, 235, 235)).Background(weatherBackground)
+	weatherDim        = tcell.StyleDefault.Foreground(tcell.NewRGBColor(150, 165, 190)).Background(weatherBackground)
+	// weatherIconColors tint the icons, in weather.Condition order
+	weatherIconColors = []tcell.Color{
+		tcell.NewRGBColor(255, 210, 60),
+		tcell.NewRGBColor(230, 220, 170),
+		tcell.NewRGBColor(190, 195, 205),
+		tcell.NewRGBColor(160, 165, 175),
+		tcell.NewRGBColor(140, 180, 230),
+		tcell.NewRGBColor(90, 150, 235),
+		tcell.NewRGBColor(240, 245, 255),
+		tcell.NewRGBColor(255, 230, 90),
+	}
+)
+
+// Reporter provides the latest weather report, false while there is none.
+type Reporter interface {
+	Report() (weather.Report, bool)
+}
+
+// Weather is a panel with an icon of the current conditions, the
+// temperature and a forecast for the next days. It takes no room until the
+// first report arrives, and says how old the report is once refreshes have
+// failed for a while.
+type Weather struct {
+	source Reporter
+	report weather.Report
+	ok     bool
+}
+
+// NewWeather creates a panel showing the reports of source.
+func NewWeather(source Reporter) *Weather {
+	return &Weather{source: source}
+}
+
+// Update picks up the latest report.
+func (w *Weather) Update(t float64) {
+	w.report, w.ok = w.source.Report()
+}
+
+// lines returns the text beside the icon and the forecast lines below it.
+func (w *Weather) lines() (current, forecast []string) {
+	rep := w.report
+	current = []string{
+		fmt.Sprintf("%.0f%s", rep.Temperature, rep.Unit),
+		capitalize(rep.Condition.String()),
+	}
+	if age := time.Since(rep.Fetched); age >= weatherStale {
+		current = append(current, "as of "+rep.Fetched.Local().Format("Mon 15:04"))
+	}
+	for _, d := range rep.Days {
+		forecast = append(forecast, fmt.Sprintf("%s  %-13s %3.0f° %3.0f°", d.Date.Format("Mon"), d.Condition, d.High, d.Low))
+	}
+	return current, forecast
+}
+
+// Size returns the size of the panel, nothing before the first report.
+func (w *Weather) Size(width, height int) (int, int) {
+	if !w.ok {
+		return 0, 0
+	}
+	current, forecast := w.lines()
+	inner := 0
+	for _, l := range current {
+		inner = max(inner, iconWidth+weatherGap+utf8.RuneCountInString(l))
+	}
+	for _, l := range forecast {
+		inner = max(inner, utf8.RuneCountInString(l))
+	}
+	rows := len(weatherIcons[0]) + len(forecast)
+	return inner + 2*weatherPadding, rows + 2*weatherPadding
+}
+
+// Render draws the panel into the area, cut off at its sides.
+func (w *Weather) Render(r *renderer.Renderer, area Rect) {
+	if !w.ok {
+		return
+	}
+	r.FillRect(float64(area.X), float64(area.Y), float64(area.W), float64(area.H), weatherBackground, Depth)
+	text := func(x, y int, s string, style tcell.Style) {
+		for _, ch := range s {
+			if x >= area.X+area.W || y >= area.Y+area.H {
+				return
+			}
+			if ch != ' ' {
+				r.SetCell(x, y, ch, Depth+1, style)
+			}
+			x++
+		}
+	}
+
+	x, y := area.X+weatherPadding, area.Y+weatherPadding
+	c := w.report.Condition
+	if c < 0 || int(c) >= len(weatherIcons) {
+		c = weather.Cloudy
+	}
+	icon := weatherText.Foreground(weatherIconColors[c])
+	current, forecast := w.lines()
+	for i, line := range weatherIcons[c] {
+		text(x, y+i, line, icon)
+		if i < len(current) {
+			style := weatherText
+			if i > 0 {
+				style = weatherDim
+			}
+			text(x+iconWidth+weatherGap, y+i, current[i], style)
+		}
+	}
+	for i, line := range forecast {
+		text(x, y+len(weatherIcons[c])+i, line, weatherDim)
+	}
+}
+
+// capitalize returns s with its first letter in upper case.
+func capitalize(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[n:]
+}
@@ -0,0 +1,152 @@
+// Package particle provides a pooled particle system with emitters, forces
+// and lifetimes, shared by scenes and effects that need spray, rain, snow,
+// sparks and similar short-lived particles.
+package particle
+
+import "math/rand"
+
+// Particle is a single particle. Scenes choose what the coordinates mean:
+// 2D scenes use X and Y in cells, 3D scenes use world space.
+type Particle struct {
+	X, Y, Z    float64
+	VX, VY, VZ float64
+	// Seconds since the particle was emitted and its total lifetime
+	Age, Life float64
+	// Random value in [0, 1) fixed at emission, for per-particle variation
+	Seed float64
+}
+
+// Progress returns how far the particle is through its life, from 0 to 1.
+func (p *Particle) Progress() float64 {
+	if p.Life <= 0 {
+		return 1
+	}
+	return min(p.Age/p.Life, 1)
+}
+
+// Force changes a particle's velocity over a time step of dt seconds.
+type Force func(p *Particle, dt float64)
+
+// Gravity accelerates particles by the given vector in units per second squared.
+func Gravity(x, y, z float64) Force {
+	return func(p *Particle, dt float64) {
+		p.VX += x * dt
+		p.VY += y * dt
+		p.VZ += z * dt
+	}
+}
+
+// Drag slows particles down, losing the given fraction of velocity per second.
+func Drag(k float64) Force {
+	return func(p *Particle, dt float64) {
+		f := max(1-k*dt, 0)
+		p.VX *= f
+		p.VY *= f
+		p.VZ *= f
+	}
+}
+
+// Wind pulls particle velocities toward the wind velocity at the given rate per second.
+func Wind(x, y, z, rate float64) Force {
+	return func(p *Particle, dt float64) {
+		f := min(rate*dt, 1)
+		p.VX += (x - p.VX) * f
+		p.VY += (y - p.VY) * f
+		p.VZ += (z - p.VZ) * f
+	}
+}
+
+// Emitter spawns particles continuously at a fixed rate.
+type Emitter struct {
+	// Particles emitted per second
+	Rate float64
+	// Spawn initializes a new particle's position, velocity and lifetime
+	Spawn func(p *Particle, rng *rand.Rand)
+	// Fractional particle carried over between updates
+	carry float64
+}
+
+// System owns a fixed-capacity pool of particles. Dead particles are
+// recycled in place, so a running system does not allocate.
+type System struct {
+	Forces    []Force
+	Emitters  []*Emitter
+	particles []Particle
+	rng       *rand.Rand
+}
+
+// NewSystem creates a system holding at most capacity live particles.
+func NewSystem(capacity int, seed int64) *System {
+	return &System{
+		particles: make([]Particle, 0, capacity),
+		rng:       rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Emit adds one particle initialized by spawn. It returns false if the pool is full.
+func (s *System) Emit(spawn func(p *Particle, rng *rand.Rand)) bool {
+	if len(s.particles) == cap(s.particles) {
+		return false
+	}
+	s.particles = append(s.particles, Particle{Seed: s.rng.Float64()})
+	p := &s.particles[len(s.particles)-1]
+	spawn(p, s.rng)
+	if p.Life <= 0 {
+		p.Life = 1
+	}
+	return true
+}
+
+// Update runs the emitters, applies forces, moves particles and retires the
+// ones that outlived their lifetime.
+func (s *System) Update(dt float64) {
+	if dt <= 0 {
+		return
+	}
+	for _, e := range s.Emitters {
+		e.carry += e.Rate * dt
+		for ; e.carry >= 1; e.carry-- {
+			s.Emit(e.Spawn)
+		}
+	}
+
+	for i := 0; i < len(s.particles); {
+		p := &s.particles[i]
+		p.Age += dt
+		if p.Age >= p.Life {
+			// Swap the last live particle into the free slot
+			last := len(s.particles) - 1
+			s.particles[i] = s.particles[last]
+			s.particles = s.particles[:last]
+			continue
+		}
+		for _, f := range s.Forces {
+			f(p, dt)
+		}
+		p.X += p.VX * dt
+		p.Y += p.VY * dt
+		p.Z += p.VZ * dt
+		i++
+	}
+}
+
+// Particles returns the live particles. The slice is only valid until the next Update or Emit.
+func (s *System) Particles() []Particle {
+	return s.particles
+}
+
+// Len returns the number of live particles.
+func (s *System) Len() int {
+	return len(s.particles)
+}
+
+// Rand returns the system's random source, so emission decisions made by
+// the caller stay reproducible with the same seed.
+func (s *System) Rand() *rand.Rand {
+	return s.rng
+}
+
+// Clear removes all particles, keeping the pool.
+func (s *System) Clear() {
+	s.particles = s.particles[:0]
+}
@@ -0,0 +1,51 @@
+package particle
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Canvas is the drawing surface particles are rendered to.
+// It is implemented by *renderer.Renderer.
+type Canvas interface {
+	SetCell(x, y int, char rune, depth float64, style tcell.Style)
+	PlotDot(x, y float64, depth float64, style tcell.Style)
+}
+
+// Style describes how particles look over their lifetime.
+type Style struct {
+	// Glyphs from birth to death, picked by life progress
+	Chars []rune
+	// Colors at birth and at death, blended by life progress
+	From, To [3]int32
+	// Dots draws Braille sub-cell dots instead of Chars
+	Dots bool
+}
+
+// Look returns the glyph and style of a particle at its current age.
+func (st Style) Look(p *Particle) (rune, tcell.Style) {
+	t := p.Progress()
+	ch := '•'
+	if len(st.Chars) > 0 {
+		ch = st.Chars[min(int(t*float64(len(st.Chars))), len(st.Chars)-1)]
+	}
+	mix := func(a, b int32) int32 {
+		return int32(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	color := tcell.NewRGBColor(mix(st.From[0], st.To[0]), mix(st.From[1], st.To[1]), mix(st.From[2], st.To[2]))
+	return ch, tcell.StyleDefault.Foreground(color)
+}
+
+// Draw renders every live particle at its X, Y position in cells.
+func (s *System) Draw(c Canvas, st Style, depth float64) {
+	for i := range s.particles {
+		p := &s.particles[i]
+		ch, style := st.Look(p)
+		if st.Dots {
+			c.PlotDot(p.X, p.Y, depth, style)
+			continue
+		}
+		c.SetCell(int(math.Floor(p.X)), int(math.Floor(p.Y)), ch, depth, style)
+	}
+}
@@ -0,0 +1,42 @@
+package plugin
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// clockTick is the unit of the times in /proc, 1/USER_HZ, which is 100 on
+// every architecture Linux supports.
+const clockTick = 10 * time.Millisecond
+
+// cpuTime returns the processor time process pid and its reaped children
+// have used, from /proc/pid/stat.
+func cpuTime(pid int) (time.Duration, bool) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, false
+	}
+	// The command name in parentheses may hold spaces; the fields after it
+	// start with the third, the state
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := bytes.Fields(data[i+1:])
+	if len(fields) < 15 {
+		return 0, false
+	}
+	// utime, stime, cutime and cstime are fields 14 to 17
+	var ticks int64
+	for _, f := range fields[11:15] {
+		n, err := strconv.ParseInt(string(f), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		ticks += n
+	}
+	return time.Duration(ticks) * clockTick, true
+}
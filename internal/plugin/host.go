@@ -0,0 +1,196 @@
+package plugin
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// watchInterval is how often a Host looks for changed plugins.
+const watchInterval = time.Second
+
+// hosted is a plugin file a Host has started, or failed to.
+type hosted struct {
+	plugin *Plugin // nil if it failed to start
+	err    error   // Why it failed to start
+	mod    time.Time
+	size   int64
+}
+
+// Host keeps the plugins of a directory running while they are worked on:
+// a plugin whose file changes is restarted, new files are started and
+// removed ones stopped, without the screensaver restarting. Requests carry
+// the screensaver's time, so a restarted plugin carries on where the old one
+// left off.
+type Host struct {
+	dir     string
+	limits  Limits
+	changed chan struct{}
+	quit    chan struct{}
+	done    chan struct{}
+
+	mu    sync.Mutex
+	files map[string]*hosted // By path
+}
+
+// NewHost starts the plugins in dir and then follows changes to them in the
+// background. A missing directory starts none, but is looked at again.
+func NewHost(dir string, limits Limits) *Host {
+	h := &Host{
+		dir:     dir,
+		limits:  limits,
+		changed: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		files:   map[string]*hosted{},
+	}
+	h.sync()
+	go h.watch()
+	return h
+}
+
+// watch syncs the plugins with their files until Close.
+func (h *Host) watch() {
+	defer close(h.done)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-h.quit:
+			return
+		case <-ticker.C:
+		}
+		if h.sync() {
+			select {
+			case h.changed <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// sync starts the plugins whose files are new or changed and stops the
+// ones whose files are gone, reporting whether any were.
+func (h *Host) sync() bool {
+	found, err := executables(h.dir)
+	if err != nil {
+		// Unreadable for now, perhaps while being replaced; the running
+		// plugins carry on
+		return false
+	}
+	h.mu.Lock()
+	var stale []*Plugin
+	var paths []string
+	for path, old := range h.files {
+		if _, ok := found[path]; !ok {
+			stale = append(stale, old.plugin)
+			delete(h.files, path)
+		}
+	}
+	for path, info := range found {
+		old, ok := h.files[path]
+		if ok && old.mod.Equal(info.ModTime()) && old.size == info.Size() {
+			continue
+		}
+		if ok {
+			stale = append(stale, old.plugin)
+		}
+		paths = append(paths, path)
+	}
+	h.mu.Unlock()
+	if len(stale) == 0 && len(paths) == 0 {
+		return false
+	}
+
+	// The old plugins go first, so the new ones can take their overlays and
+	// scenes
+	for _, p := range stale {
+		if p != nil {
+			p.Close()
+		}
+	}
+	slices.Sort(paths)
+	plugins, errs := startAll(paths, h.limits)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, path := range paths {
+		h.files[path] = &hosted{plugin: plugins[i], err: errs[i], mod: found[path].ModTime(), size: found[path].Size()}
+	}
+	// Overlay and scene names go to the plugins in path order
+	names := map[string]string{}
+	for _, path := range slices.Sorted(maps.Keys(h.files)) {
+		f := h.files[path]
+		if f.plugin == nil {
+			continue
+		}
+		if err := claim(names, f.plugin); err != nil {
+			f.plugin.Close()
+			f.plugin, f.err = nil, err
+		}
+	}
+	return true
+}
+
+// Changed receives after plugins were started or stopped because their
+// files changed. Changes while nobody receives are merged into one.
+func (h *Host) Changed() <-chan struct{} {
+	return h.changed
+}
+
+// Plugins returns the plugins started from the current files, in the order
+// of their paths. Some may have stopped since, see Plugin.Err.
+func (h *Host) Plugins() []*Plugin {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var plugins []*Plugin
+	for _, path := range slices.Sorted(maps.Keys(h.files)) {
+		if p := h.files[path].plugin; p != nil {
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins
+}
+
+// Scene returns the scene named name of the running plugins, nil if none
+// provides it. A plugin restarted after its file changed provides a new
+// Scene in place of the old one.
+func (h *Host) Scene(name string) *Scene {
+	for _, p := range h.Plugins() {
+		if !p.running() {
+			continue
+		}
+		for _, s := range p.scenes {
+			if s.name == name {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// Errors returns why plugins failed to start or have stopped, in the order
+// of their paths.
+func (h *Host) Errors() []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var errs []error
+	for _, path := range slices.Sorted(maps.Keys(h.files)) {
+		f := h.files[path]
+		if f.err != nil {
+			errs = append(errs, f.err)
+		} else if err := f.plugin.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Close stops following the files and stops every plugin.
+func (h *Host) Close() {
+	close(h.quit)
+	<-h.done
+	for _, p := range h.Plugins() {
+		p.Close()
+	}
+}
@@ -0,0 +1,151 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Overlay is an overlay drawn by a plugin. It asks the plugin for its
+// contents every frame the previous answer has come in by, and shows the
+// latest answer meanwhile.
+type Overlay struct {
+	plugin        *Plugin
+	name          string
+	width, height int
+	placement     overlay.Placement
+
+	mu         sync.Mutex
+	rows       []string
+	style      tcell.Style
+	background tcell.Color
+	area       overlay.Rect // Area of the last render, sent with requests
+	exchange
+}
+
+// exchange is the request an overlay or scene last sent its plugin, which
+// it waits for the answer to before asking again. Its owner's lock guards
+// it.
+type exchange struct {
+	asked  time.Time // When the unanswered request went out, zero if none
+	misses int       // Frames in a row the answer was overdue
+}
+
+// waiting reports whether the answer to the last request is still owed,
+// counting the frame as a miss if it is overdue, and the misses in a row.
+func (e *exchange) waiting(limit time.Duration) (bool, int) {
+	if e.asked.IsZero() {
+		return false, e.misses
+	}
+	if time.Since(e.asked) > limit {
+		e.misses++
+	}
+	return true, e.misses
+}
+
+// ask sends req to p, unless its requests are backed up.
+func (e *exchange) ask(p *Plugin, req request) {
+	select {
+	case p.requests <- req:
+		e.asked = time.Now()
+	default:
+	}
+}
+
+// answered marks the last request answered, clearing the misses if the
+// answer came in time.
+func (e *exchange) answered(limit time.Duration) {
+	if !e.asked.IsZero() && time.Since(e.asked) <= limit {
+		e.misses = 0
+	}
+	e.asked = time.Time{}
+}
+
+// Name returns the name the plugin gave the overlay.
+func (o *Overlay) Name() string {
+	return o.name
+}
+
+// Placement returns where the plugin would like the overlay.
+func (o *Overlay) Placement() overlay.Placement {
+	return o.placement
+}
+
+// Update asks the plugin for the overlay at time t, unless it still owes
+// the answer to the last request. A plugin that keeps its answers overdue
+// for too many frames is stopped.
+func (o *Overlay) Update(t float64) {
+	p := o.plugin
+	if !p.running() {
+		o.mu.Lock()
+		o.rows = nil
+		o.mu.Unlock()
+		return
+	}
+	o.mu.Lock()
+	if waiting, misses := o.waiting(p.limits.Frame); waiting {
+		o.mu.Unlock()
+		if misses >= maxMisses {
+			p.stop(fmt.Errorf("overlay %s: answered %d frames in a row later than %v", o.name, misses, p.limits.Frame))
+		}
+		return
+	}
+	o.ask(p, request{Overlay: o.name, Time: t, Width: o.area.W, Height: o.area.H})
+	o.mu.Unlock()
+}
+
+// answer takes the plugin's reply to the last request.
+func (o *Overlay) answer(r reply) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.answered(o.plugin.limits.Frame)
+	o.rows = r.Rows
+	o.style = r.style()
+	o.background = tcell.ColorDefault
+	if r.Background != "" {
+		o.background = tcell.GetColor(r.Background)
+		o.style = o.style.Background(o.background)
+	}
+}
+
+// style returns the style of the text of the reply, white by default.
+func (r reply) style() tcell.Style {
+	if r.Color == "" {
+		return tcell.StyleDefault.Foreground(tcell.ColorWhite)
+	}
+	return tcell.StyleDefault.Foreground(tcell.GetColor(r.Color))
+}
+
+// Size returns the size the plugin asked for.
+func (o *Overlay) Size(width, height int) (int, int) {
+	return o.width, o.height
+}
+
+// Render draws the latest answer, cut off at the sides of the area.
+func (o *Overlay) Render(r *renderer.Renderer, area overlay.Rect) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.area = area
+	if o.rows == nil {
+		return
+	}
+	if o.background != tcell.ColorDefault {
+		r.FillRect(float64(area.X), float64(area.Y), float64(area.W), float64(area.H), o.background, overlay.Depth)
+	}
+	for y, row := range o.rows[:min(len(o.rows), area.H)] {
+		x := area.X
+		for _, ch := range row {
+			if x >= area.X+area.W {
+				break
+			}
+			if ch != ' ' {
+				r.SetCell(x, area.Y+y, ch, overlay.Depth+1, o.style)
+			}
+			x++
+		}
+	}
+}
@@ -0,0 +1,454 @@
+// Package plugin runs overlays and scenes provided by plugins: executables
+// found in a directory that draw panels of text over the scene, or the
+// whole scene. A plugin speaks JSON lines on its standard input and output,
+// first naming its overlays and scenes and then answering every request
+// for the contents of one. Each plugin runs
+// in a process of its own that the frame never waits on, and one that
+// answers too slowly or takes too much of the processor is stopped.
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/overlay"
+)
+
+const (
+	startTimeout = 5 * time.Second // Most time a plugin may take to name its overlays
+	maxMisses    = 25              // Frames in a row an answer may be overdue
+	cpuWindow    = time.Second     // Span the processor use is measured over
+	maxStrikes   = 3               // Windows in a row over the CPU share
+	maxLine      = 64 << 10        // Longest line a plugin may write
+	maxSize      = 200             // Most cells an overlay may ask for either way
+)
+
+// Limits bound what a plugin may take from the screensaver.
+type Limits struct {
+	// Frame is how long a plugin may take to answer the request for a frame
+	Frame time.Duration
+	// CPU is the share of one processor a plugin may use on average, 0 for
+	// no limit. It is measured on Linux only.
+	CPU float64
+}
+
+// DefaultLimits returns limits of 20 milliseconds a frame and a fifth of a
+// processor.
+func DefaultLimits() Limits {
+	return Limits{Frame: 20 * time.Millisecond, CPU: 0.2}
+}
+
+// hello is the first line a plugin writes, naming its overlays.
+type hello struct {
+	Overlays []struct {
+		Name   string `json:"name"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+		// Anchor and Margin are where the overlay goes unless placed
+		// otherwise, top-left by default
+		Anchor string `json:"anchor"`
+		Margin int    `json:"margin"`
+	} `json:"overlays"`
+	Scenes []struct {
+		Name string `json:"name"`
+	} `json:"scenes"`
+}
+
+// request asks for the contents of an overlay or a scene at a time, for an
+// area of the given size.
+type request struct {
+	Overlay string  `json:"overlay,omitempty"`
+	Scene   string  `json:"scene,omitempty"`
+	Time    float64 `json:"time"`
+	Width   int     `json:"width"`
+	Height  int     `json:"height"`
+}
+
+// reply is a plugin's answer to a request: rows of text, with colors as
+// names or #rrggbb. Without a background spaces show the scene through.
+// Scenes may instead color their text by the theme, giving the brightness
+// of every character as a digit from 0 to 9 in Shades.
+type reply struct {
+	Overlay    string   `json:"overlay"`
+	Scene      string   `json:"scene"`
+	Rows       []string `json:"rows"`
+	Shades     []string `json:"shades"`
+	Color      string   `json:"color"`
+	Background string   `json:"background"`
+}
+
+// Plugin is a running plugin process.
+type Plugin struct {
+	name     string
+	cmd      *exec.Cmd
+	limits   Limits
+	stderr   *lastLine
+	requests chan request
+	overlays []*Overlay
+	scenes   []*Scene
+	exited   chan struct{}
+
+	mu      sync.Mutex
+	err     error // Why the plugin stopped, nil while it runs
+	stopped bool
+}
+
+// executables returns the programs in dir by path. A missing directory
+// holds none.
+func executables(dir string) (map[string]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]os.FileInfo{}
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && executable(info) {
+			found[filepath.Join(dir, e.Name())] = info
+		}
+	}
+	return found, nil
+}
+
+// startAll starts the programs at paths as plugins side by side, so a slow
+// one does not hold up the others, returning each plugin or why it failed.
+func startAll(paths []string, limits Limits) ([]*Plugin, []error) {
+	plugins := make([]*Plugin, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			plugins[i], errs[i] = Start(path, limits)
+		}()
+	}
+	wg.Wait()
+	return plugins, errs
+}
+
+// claim records the names of the plugin's overlays and scenes, failing if
+// another plugin already took one.
+func claim(names map[string]string, p *Plugin) error {
+	// Scenes and overlays may share a name, which has no colon
+	for _, o := range p.overlays {
+		if other, taken := names[o.name]; taken {
+			return fmt.Errorf("plugin %s: overlay %q is already provided by %s", p.name, o.name, other)
+		}
+	}
+	for _, s := range p.scenes {
+		if other, taken := names["scene:"+s.name]; taken {
+			return fmt.Errorf("plugin %s: scene %q is already provided by %s", p.name, s.name, other)
+		}
+	}
+	for _, o := range p.overlays {
+		names[o.name] = p.name
+	}
+	for _, s := range p.scenes {
+		names["scene:"+s.name] = p.name
+	}
+	return nil
+}
+
+// executable reports whether a directory entry is a program to run.
+func executable(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return slices.Contains([]string{".exe", ".bat", ".cmd"}, strings.ToLower(filepath.Ext(info.Name())))
+	}
+	return info.Mode().Perm()&0o111 != 0
+}
+
+// Start runs the program at path as a plugin and waits for it to name its
+// overlays and scenes.
+func Start(path string, limits Limits) (*Plugin, error) {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	p := &Plugin{
+		name:     name,
+		cmd:      exec.Command(path),
+		limits:   limits,
+		stderr:   &lastLine{},
+		requests: make(chan request, 16),
+		exited:   make(chan struct{}),
+	}
+	p.cmd.Dir = filepath.Dir(path)
+	p.cmd.Env = append(os.Environ(), "SCREENSAVER_PLUGIN=1")
+	p.cmd.Stderr = p.stderr
+	// Children left holding its standard error do not keep it waiting
+	p.cmd.WaitDelay = time.Second
+	isolate(p.cmd)
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	if err := p.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	go func() {
+		err := p.cmd.Wait()
+		if line := p.stderr.String(); err != nil && line != "" {
+			err = fmt.Errorf("%w: %s", err, line)
+		}
+		if err == nil {
+			err = errors.New("exited")
+		}
+		p.stop(err)
+		close(p.exited)
+	}()
+
+	lines := bufio.NewScanner(stdout)
+	lines.Buffer(nil, maxLine)
+	greeted := make(chan error, 1)
+	go func() {
+		if !lines.Scan() {
+			greeted <- errors.New("exited without naming its overlays or scenes")
+			return
+		}
+		greeted <- p.greet(lines.Bytes())
+	}()
+	select {
+	case err = <-greeted:
+	case <-time.After(startTimeout):
+		err = fmt.Errorf("named no overlays or scenes within %v", startTimeout)
+	case <-p.exited:
+		p.mu.Lock()
+		err = p.err
+		p.mu.Unlock()
+	}
+	if err != nil {
+		p.Close()
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+
+	go p.write(stdin)
+	go p.read(lines)
+	if limits.CPU > 0 {
+		go p.watchCPU()
+	}
+	return p, nil
+}
+
+// greet takes the overlays and scenes named in a plugin's first line.
+func (p *Plugin) greet(line []byte) error {
+	var h hello
+	if err := json.Unmarshal(line, &h); err != nil {
+		return fmt.Errorf("invalid first line: %w", err)
+	}
+	if len(h.Overlays) == 0 && len(h.Scenes) == 0 {
+		return errors.New("provides no overlays or scenes")
+	}
+	for _, s := range h.Scenes {
+		if !validName(s.Name) {
+			return fmt.Errorf("invalid scene name %q", s.Name)
+		}
+		p.scenes = append(p.scenes, &Scene{plugin: p, name: s.Name})
+	}
+	for _, o := range h.Overlays {
+		if !validName(o.Name) {
+			return fmt.Errorf("invalid overlay name %q", o.Name)
+		}
+		if o.Width < 1 || o.Width > maxSize || o.Height < 1 || o.Height > maxSize {
+			return fmt.Errorf("overlay %s: size %dx%d is out of range, want 1 to %d cells either way", o.Name, o.Width, o.Height, maxSize)
+		}
+		place := overlay.Placement{Anchor: overlay.TopLeft, Margin: max(o.Margin, 0)}
+		if o.Anchor != "" {
+			var err error
+			if place.Anchor, err = overlay.ParseAnchor(o.Anchor); err != nil {
+				return fmt.Errorf("overlay %s: %w", o.Name, err)
+			}
+		}
+		p.overlays = append(p.overlays, &Overlay{
+			plugin:    p,
+			name:      o.Name,
+			width:     o.Width,
+			height:    o.Height,
+			placement: place,
+			area:      overlay.Rect{W: o.Width, H: o.Height},
+		})
+	}
+	return nil
+}
+
+// validName reports whether an overlay or scene may be named name, which
+// must not hold the characters settings separate names with.
+func validName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " =+@:")
+}
+
+// write sends the requests to the plugin until it stops.
+func (p *Plugin) write(stdin io.WriteCloser) {
+	defer stdin.Close()
+	enc := json.NewEncoder(stdin)
+	for {
+		select {
+		case <-p.exited:
+			return
+		case req := <-p.requests:
+			if err := enc.Encode(req); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read takes the plugin's replies until it stops.
+func (p *Plugin) read(lines *bufio.Scanner) {
+	for lines.Scan() {
+		var r reply
+		if err := json.Unmarshal(lines.Bytes(), &r); err != nil {
+			p.stop(fmt.Errorf("invalid reply: %w", err))
+			return
+		}
+		for _, o := range p.overlays {
+			if o.name == r.Overlay {
+				o.answer(r)
+			}
+		}
+		for _, s := range p.scenes {
+			if s.name == r.Scene {
+				s.answer(r)
+			}
+		}
+	}
+	if err := lines.Err(); err != nil {
+		p.stop(err)
+	}
+}
+
+// watchCPU stops the plugin once it has used more than its share of the
+// processor for a few windows in a row.
+func (p *Plugin) watchCPU() {
+	ticker := time.NewTicker(cpuWindow)
+	defer ticker.Stop()
+	last, ok := cpuTime(p.cmd.Process.Pid)
+	if !ok {
+		return
+	}
+	strikes := 0
+	for {
+		select {
+		case <-p.exited:
+			return
+		case <-ticker.C:
+		}
+		used, ok := cpuTime(p.cmd.Process.Pid)
+		if !ok {
+			return
+		}
+		share := float64(used-last) / float64(cpuWindow)
+		last = used
+		if share <= p.limits.CPU {
+			strikes = 0
+			continue
+		}
+		if strikes++; strikes >= maxStrikes {
+			p.stop(fmt.Errorf("used %.0f%% of a processor, over its share of %.0f%%", share*100, p.limits.CPU*100))
+			return
+		}
+	}
+}
+
+// Name returns the plugin's name, its file name without extension.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Overlays returns the overlays the plugin provides.
+func (p *Plugin) Overlays() []*Overlay {
+	return p.overlays
+}
+
+// Scenes returns the scenes the plugin provides.
+func (p *Plugin) Scenes() []*Scene {
+	return p.scenes
+}
+
+// Err returns why the plugin stopped, nil while it runs.
+func (p *Plugin) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		return nil
+	}
+	return fmt.Errorf("plugin %s: %w", p.name, p.err)
+}
+
+// running reports whether the plugin has not been stopped.
+func (p *Plugin) running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.stopped
+}
+
+// stop ends the plugin's process for the given reason, nil when closed.
+// The first reason is kept.
+func (p *Plugin) stop(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	p.stopped, p.err = true, err
+	_ = kill(p.cmd)
+}
+
+// Close stops the plugin and waits for its process to exit.
+func (p *Plugin) Close() {
+	p.stop(nil)
+	<-p.exited
+}
+
+// lastLine keeps the last line a plugin wrote to its standard error, to
+// tell why it failed.
+type lastLine struct {
+	mu   sync.Mutex
+	line []byte
+	next []byte
+}
+
+func (l *lastLine) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range b {
+		if c == '\n' {
+			if len(l.next) > 0 {
+				l.line, l.next = l.next, nil
+			}
+			continue
+		}
+		if len(l.next) < 200 {
+			l.next = append(l.next, c)
+		}
+	}
+	return len(b), nil
+}
+
+// String returns the last line, or the line being written if it has
+// text.
+func (l *lastLine) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.next) > 0 {
+		return strings.TrimSpace(string(l.next))
+	}
+	return strings.TrimSpace(string(l.line))
+}
@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/overlay"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// script writes a shell script plugin to dir.
+func script(t *testing.T, dir, name, body string) {
+	t.Helper()
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no shell")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const greeting = `echo '{"overlays": [{"name": "clock", "width": 8, "height": 1, "anchor": "top-right", "margin": 1}]}'
+`
+
+// until updates o every millisecond until ok holds.
+func until(t *testing.T, o *Overlay, ok func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !ok() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out, plugin error %v", o.plugin.Err())
+		}
+		o.Update(0)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHost(t *testing.T) {
+	dir := t.TempDir()
+	script(t, dir, "clock.sh", greeting+`while read line; do echo '{"overlay": "clock", "rows": ["12:34"], "color": "#ff8800"}'; done
+`)
+	script(t, dir, "broken", "echo nonsense\n")
+	// Not executable, so not a plugin
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewHost(dir, DefaultLimits())
+	defer h.Close()
+	plugins, errs := h.Plugins(), h.Errors()
+	if len(plugins) != 1 || plugins[0].Name() != "clock" {
+		t.Fatalf("got %d plugins, want clock", len(plugins))
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken") {
+		t.Errorf("got errors %v, want one for broken", errs)
+	}
+	o := plugins[0].Overlays()[0]
+	if o.Name() != "clock" || o.Placement() != (overlay.Placement{Anchor: overlay.TopRight, Margin: 1}) {
+		t.Errorf("got overlay %q at %+v", o.Name(), o.Placement())
+	}
+	if w, h := o.Size(80, 24); w != 8 || h != 1 {
+		t.Errorf("got size %dx%d, want 8x1", w, h)
+	}
+	until(t, o, func() bool {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return len(o.rows) == 1 && o.rows[0] == "12:34"
+	})
+
+	// Edited, the plugin is restarted with the change
+	time.Sleep(10 * time.Millisecond)
+	script(t, dir, "clock.sh", greeting+`while read line; do echo '{"overlay": "clock", "rows": ["edited"]}'; done
+`)
+	select {
+	case <-h.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change noticed")
+	}
+	o = h.Plugins()[0].Overlays()[0]
+	until(t, o, func() bool {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return len(o.rows) == 1 && o.rows[0] == "edited"
+	})
+	if plugins[0].running() {
+		t.Error("the old plugin still runs")
+	}
+
+	missing := NewHost(filepath.Join(dir, "missing"), DefaultLimits())
+	defer missing.Close()
+	if plugins := missing.Plugins(); len(plugins) != 0 {
+		t.Errorf("got %d plugins from a missing directory", len(plugins))
+	}
+}
+
+func TestSlowPluginStops(t *testing.T) {
+	dir := t.TempDir()
+	script(t, dir, "slow", greeting+"while read line; do sleep 10; done\n")
+	p, err := Start(filepath.Join(dir, "slow"), Limits{Frame: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	o := p.Overlays()[0]
+	until(t, o, func() bool { return !p.running() })
+	if err := p.Err(); err == nil || !strings.Contains(err.Error(), "later than") {
+		t.Errorf("got %v, want an error for late answers", err)
+	}
+}
+
+func TestBusyPluginStops(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("processor time is measured on Linux only")
+	}
+	dir := t.TempDir()
+	script(t, dir, "busy", greeting+"while :; do :; done\n")
+	p, err := Start(filepath.Join(dir, "busy"), Limits{Frame: time.Second, CPU: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	select {
+	case <-p.exited:
+	case <-time.After(maxStrikes*cpuWindow + 5*time.Second):
+		t.Fatal("busy plugin still running")
+	}
+	if err := p.Err(); err == nil || !strings.Contains(err.Error(), "processor") {
+		t.Errorf("got %v, want an error for processor use", err)
+	}
+}
+
+func TestDuplicateOverlays(t *testing.T) {
+	dir := t.TempDir()
+	body := greeting + "while read line; do :; done\n"
+	script(t, dir, "a", body)
+	script(t, dir, "b", body)
+	h := NewHost(dir, DefaultLimits())
+	plugins, errs := h.Plugins(), h.Errors()
+	h.Close()
+	if len(plugins) != 1 || len(errs) != 1 || !strings.Contains(errs[0].Error(), "already provided") {
+		t.Errorf("got %d plugins and errors %v, want one of each", len(plugins), errs)
+	}
+}
+
+func TestHostScene(t *testing.T) {
+	dir := t.TempDir()
+	// Answers with the time it was asked for, as a scene drawn from it would
+	const answer = `while read line; do
+  t=$(echo "$line" | sed 's/.*"time":\([0-9.]*\).*/\1/')
+  echo "{\"scene\": \"tunnel\", \"rows\": [\"$1$t\"], \"shades\": [\"9\"]}"
+done
+`
+	greet := `echo '{"scenes": [{"name": "tunnel"}]}'
+`
+	script(t, dir, "tunnel", greet+`set -- at; `+answer)
+	h := NewHost(dir, DefaultLimits())
+	defer h.Close()
+	view := renderer.NewViewport(20, 5)
+	drawn := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			s := h.Scene("tunnel")
+			if s == nil {
+				t.Fatal("no tunnel scene")
+			}
+			s.Render(view)
+			s.mu.Lock()
+			got := strings.Join(s.rows, "")
+			s.mu.Unlock()
+			if got == want {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("scene drew %q, want %q, plugin error %v", got, want, s.plugin.Err())
+			}
+			s.Update(42.5)
+			time.Sleep(time.Millisecond)
+		}
+	}
+	drawn("at42.5")
+
+	// Edited, the new code draws the scene at the same time
+	time.Sleep(10 * time.Millisecond)
+	script(t, dir, "tunnel", greet+`set -- edited; `+answer)
+	select {
+	case <-h.Changed():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change noticed")
+	}
+	drawn("edited42.5")
+	if h.Scene("lava") != nil {
+		t.Error("found a scene no plugin provides")
+	}
+}
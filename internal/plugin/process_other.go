@@ -0,0 +1,13 @@
+//go:build !unix
+
+package plugin
+
+import "os/exec"
+
+// isolate leaves the plugin's process as it is.
+func isolate(cmd *exec.Cmd) {}
+
+// kill ends the plugin's process.
+func kill(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
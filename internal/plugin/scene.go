@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// Scene is a scene drawn by a plugin over the whole screen. Like an
+// overlay, it asks the plugin for its contents every frame the previous
+// answer has come in by, and shows the latest answer meanwhile. The time
+// it asks for is the screensaver's, so a plugin restarted with new code
+// draws the scene from where the old one left off.
+type Scene struct {
+	plugin *Plugin
+	name   string
+
+	mu            sync.Mutex
+	rows          []string
+	shades        []string
+	style         tcell.Style
+	background    tcell.Color
+	width, height int // Size of the last render, sent with requests
+	exchange
+}
+
+// Name returns the name the plugin gave the scene.
+func (s *Scene) Name() string {
+	return s.name
+}
+
+// Update asks the plugin for the scene at time t, unless it still owes
+// the answer to the last request. A plugin that keeps its answers overdue
+// for too many frames is stopped.
+func (s *Scene) Update(t float64) {
+	p := s.plugin
+	if !p.running() {
+		return
+	}
+	s.mu.Lock()
+	if waiting, misses := s.waiting(p.limits.Frame); waiting {
+		s.mu.Unlock()
+		if misses >= maxMisses {
+			p.stop(fmt.Errorf("scene %s: answered %d frames in a row later than %v", s.name, misses, p.limits.Frame))
+		}
+		return
+	}
+	if s.width > 0 && s.height > 0 {
+		s.ask(p, request{Scene: s.name, Time: t, Width: s.width, Height: s.height})
+	}
+	s.mu.Unlock()
+}
+
+// answer takes the plugin's reply to the last request.
+func (s *Scene) answer(r reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.answered(s.plugin.limits.Frame)
+	s.rows, s.shades = r.Rows, r.Shades
+	s.style = r.style()
+	s.background = tcell.ColorDefault
+	if r.Background != "" {
+		s.background = tcell.GetColor(r.Background)
+		s.style = s.style.Background(s.background)
+	}
+}
+
+// Render draws the latest answer over the screen, cut off at its edges.
+// Characters with a shade take their color from the theme.
+func (s *Scene) Render(r *renderer.Renderer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.width, s.height = r.Size()
+	if s.background != tcell.ColorDefault {
+		r.FillRect(0, 0, float64(s.width), float64(s.height), s.background, 0)
+	}
+	for y, row := range s.rows[:min(len(s.rows), s.height)] {
+		var shades []rune
+		if y < len(s.shades) {
+			shades = []rune(s.shades[y])
+		}
+		for x, ch := range []rune(row) {
+			if x >= s.width {
+				break
+			}
+			if ch == ' ' {
+				continue
+			}
+			style := s.style
+			if x < len(shades) && shades[x] >= '0' && shades[x] <= '9' {
+				style = r.GradientStyle(float64(shades[x]-'0') / 9)
+				if s.background != tcell.ColorDefault {
+					style = style.Background(s.background)
+				}
+			}
+			r.SetCell(x, y, ch, 1, style)
+		}
+	}
+}
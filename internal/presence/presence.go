@@ -0,0 +1,206 @@
+// Package presence tells chat services that the screensaver is running: it
+// posts an animated preview to a Discord channel through a webhook, or sets
+// the user's Slack status.
+package presence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Status is what an update shows.
+type Status struct {
+	// Text describes what is going on, e.g. "Idle since 14:02, watching ocean"
+	Text string
+	// GIF is an animated preview of the screen, nil for none
+	GIF []byte
+	// Expires is when the status should disappear by itself if no update
+	// follows, for example because the screensaver crashed
+	Expires time.Time
+}
+
+// Target is a service the status is shown on.
+type Target interface {
+	// Update shows s, replacing the previous status
+	Update(s Status) error
+	// Clear replaces the status with text once the screensaver quits
+	Clear(text string) error
+	// String names the target in messages
+	String() string
+}
+
+// requestTimeout bounds every request, so a slow service cannot hold up
+// the exit.
+const requestTimeout = 10 * time.Second
+
+// Open returns the target described by spec: a Discord webhook URL, or
+// "slack" for the Slack status of the user whose token is in the
+// SLACK_TOKEN environment variable.
+func Open(spec string) (Target, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	if spec == "slack" {
+		token := os.Getenv("SLACK_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("slack needs a user token with the users.profile:write scope in SLACK_TOKEN")
+		}
+		return &Slack{api: "https://slack.com/api", token: token, client: client}, nil
+	}
+	u, err := url.Parse(spec)
+	if err == nil && u.Scheme == "https" && strings.HasPrefix(u.Path, "/api/webhooks/") {
+		return &Discord{webhook: strings.TrimSuffix(spec, "/"), client: client}, nil
+	}
+	return nil, fmt.Errorf("invalid presence target %q: want a Discord webhook URL or slack", spec)
+}
+
+// Discord posts the status as a message through a webhook, then edits that
+// message with every update so the channel gets a single live preview.
+type Discord struct {
+	webhook string
+	client  *http.Client
+	message string // Id of the posted message, empty before the first update
+}
+
+// String names the target.
+func (d *Discord) String() string {
+	return "discord"
+}
+
+// Update posts or edits the message with the text and the preview.
+func (d *Discord) Update(s Status) error {
+	payload := map[string]any{"content": s.Text, "attachments": []any{}}
+	var files map[string][]byte
+	if s.GIF != nil {
+		payload["attachments"] = []any{map[string]any{"id": 0, "filename": "screensaver.gif"}}
+		files = map[string][]byte{"files[0]": s.GIF}
+	}
+	return d.send(payload, files)
+}
+
+// Clear edits the message to text and removes the preview.
+func (d *Discord) Clear(text string) error {
+	if d.message == "" {
+		return nil
+	}
+	return d.send(map[string]any{"content": text, "attachments": []any{}}, nil)
+}
+
+// send posts the message, or edits it once posted.
+func (d *Discord) send(payload map[string]any, files map[string][]byte) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if err := form.WriteField("payload_json", string(data)); err != nil {
+		return err
+	}
+	for field, file := range files {
+		w, err := form.CreateFormFile(field, "screensaver.gif")
+		if err != nil {
+			return err
+		}
+		w.Write(file)
+	}
+	form.Close()
+
+	method, target := http.MethodPatch, d.webhook+"/messages/"+d.message
+	if d.message == "" {
+		method, target = http.MethodPost, d.webhook+"?wait=true"
+	}
+	req, err := http.NewRequest(method, target, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound && d.message != "" {
+		// Someone deleted the message, post a new one next time
+		d.message = ""
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	var reply struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return err
+	}
+	d.message = reply.ID
+	return nil
+}
+
+// Slack sets the status text of a Slack user. Slack statuses have no room
+// for a preview, so the GIF is not used.
+type Slack struct {
+	api    string
+	token  string
+	client *http.Client
+}
+
+// slackEmoji is shown next to the status text.
+const slackEmoji = ":ocean:"
+
+// String names the target.
+func (s *Slack) String() string {
+	return "slack"
+}
+
+// Update sets the status until it expires.
+func (s *Slack) Update(st Status) error {
+	return s.setStatus(st.Text, slackEmoji, st.Expires)
+}
+
+// Clear removes the status; Slack has no place for the text.
+func (s *Slack) Clear(string) error {
+	return s.setStatus("", "", time.Time{})
+}
+
+// setStatus calls users.profile.set.
+func (s *Slack) setStatus(text, emoji string, expires time.Time) error {
+	profile := map[string]any{"status_text": text, "status_emoji": emoji, "status_expiration": 0}
+	if !expires.IsZero() {
+		profile["status_expiration"] = expires.Unix()
+	}
+	data, err := json.Marshal(map[string]any{"profile": profile})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.api+"/users.profile.set", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Slack answers 200 with ok set to false for API errors
+	var reply struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("%s: %w", resp.Status, err)
+	}
+	if !reply.OK {
+		return fmt.Errorf("users.profile.set: %s", reply.Error)
+	}
+	return nil
+}
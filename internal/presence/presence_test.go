@@ -0,0 +1,87 @@
+package presence
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpen(t *testing.T) {
+	if _, err := Open("https://discord.com/api/webhooks/1/token"); err != nil {
+		t.Errorf("Discord webhook: %v", err)
+	}
+	t.Setenv("SLACK_TOKEN", "")
+	for _, spec := range []string{"slack", "http://discord.com/api/webhooks/1/token", "https://example.com/"} {
+		if _, err := Open(spec); err == nil {
+			t.Errorf("Open(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	var requests []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+		}
+		_, gif := r.MultipartForm.File["files[0]"]
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+r.FormValue("payload_json")+map[bool]string{true: " +gif"}[gif])
+		io.WriteString(w, `{"id":"42"}`)
+	}))
+	defer srv.Close()
+
+	d := &Discord{webhook: srv.URL + "/api/webhooks/1/token", client: srv.Client()}
+	for _, text := range []string{"one", "two"} {
+		if err := d.Update(Status{Text: text, GIF: []byte("GIF89a")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Clear("back"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`POST /api/webhooks/1/token?wait=true {"attachments":[{"filename":"screensaver.gif","id":0}],"content":"one"} +gif`,
+		`PATCH /api/webhooks/1/token/messages/42 {"attachments":[{"filename":"screensaver.gif","id":0}],"content":"two"} +gif`,
+		`PATCH /api/webhooks/1/token/messages/42 {"attachments":[],"content":"back"}`,
+	}
+	if strings.Join(requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got requests\n%s\nwant\n%s", strings.Join(requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSlack(t *testing.T) {
+	var profiles []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer xoxp-1" {
+			t.Errorf("Authorization %q", got)
+		}
+		var body struct{ Profile map[string]any }
+		json.NewDecoder(r.Body).Decode(&body)
+		profiles = append(profiles, body.Profile)
+		if len(profiles) == 1 {
+			io.WriteString(w, `{"ok":true}`)
+			return
+		}
+		io.WriteString(w, `{"ok":false,"error":"invalid_auth"}`)
+	}))
+	defer srv.Close()
+
+	s := &Slack{api: srv.URL, token: "xoxp-1", client: srv.Client()}
+	expires := time.Unix(1700000000, 0)
+	if err := s.Update(Status{Text: "idle", Expires: expires}); err != nil {
+		t.Fatal(err)
+	}
+	if p := profiles[0]; p["status_text"] != "idle" || p["status_expiration"] != float64(expires.Unix()) {
+		t.Errorf("got profile %v", p)
+	}
+	if err := s.Clear("back"); err == nil || !strings.Contains(err.Error(), "invalid_auth") {
+		t.Errorf("got %v, want the API error", err)
+	}
+	if p := profiles[1]; p["status_text"] != "" || p["status_emoji"] != "" {
+		t.Errorf("clearing sent profile %v", p)
+	}
+}
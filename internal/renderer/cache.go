@@ -0,0 +1,129 @@
+package renderer
+
+import "math"
+
+// cachedRow is a run of cells one cached drawing left in a row of its
+// layer, from column x on. Cells it left empty stay unset.
+type cachedRow struct {
+	x, y  int
+	cells []cell
+}
+
+// cacheEntry holds the cells one cached drawing produced and the layer it
+// was drawn on.
+type cacheEntry struct {
+	layer Layer
+	rows  []cachedRow
+}
+
+// Cached draws content that rarely changes, such as a menu panel or a
+// static backdrop. The first time, and after the entry was invalidated,
+// draw is called on the current layer and the cells it leaves there are
+// kept under key, as they ended up after the depth test and overdrawing;
+// later calls copy them onto the layer instead of calling draw.
+//
+// Copied cells still go through the depth test against what the layer
+// already holds, and are composited with the layers below and above like
+// freshly drawn ones, as those change from frame to frame; the drawing
+// and its overdraw are what is saved. Every entry is invalidated when the
+// size, theme or cell aspect changes; callers invalidate their own entries
+// with Invalidate or InvalidateLayer when anything else draw depends on
+// changes.
+func (r *Renderer) Cached(key any, draw func()) {
+	if r.recording || r.uncached {
+		draw() // Nested entries become part of the outer recording
+		return
+	}
+	e, ok := r.caches[key]
+	if !ok {
+		e = r.record(draw)
+		if r.caches == nil {
+			r.caches = make(map[any]*cacheEntry)
+		}
+		r.caches[key] = e
+	}
+	r.paste(e)
+}
+
+// record draws on an empty copy of the current layer and returns the cells
+// drawn there.
+func (r *Renderer) record(draw func()) *cacheEntry {
+	e := &cacheEntry{layer: r.layer}
+	plane := r.planes[e.layer]
+	empty := newCells(r.width, r.height)
+	for _, row := range empty {
+		for x := range row {
+			row[x].depth = -math.MaxFloat64
+		}
+	}
+	r.planes[e.layer] = empty
+	r.recording = true
+	draw()
+	r.recording = false
+	drawn := r.planes[e.layer]
+	r.planes[e.layer] = plane
+
+	for y, row := range drawn {
+		first, last := len(row), -1
+		for x, c := range row {
+			if c.set {
+				first, last = min(first, x), max(last, x)
+			}
+		}
+		if first <= last {
+			e.rows = append(e.rows, cachedRow{x: first, y: y, cells: row[first : last+1]})
+		}
+	}
+	// Drawing on the copy composited its cells without the rest of the layer
+	for _, row := range e.rows {
+		r.composeSpan(row.x, row.x+len(row.cells)-1, row.y)
+	}
+	return e
+}
+
+// paste copies the cells of e onto its layer.
+func (r *Renderer) paste(e *cacheEntry) {
+	for _, row := range e.rows {
+		plane := r.planes[e.layer][row.y]
+		first, last := r.width, -1
+		for i, c := range row.cells {
+			x := row.x + i
+			if !c.set || c.depth <= plane[x].depth {
+				continue
+			}
+			plane[x] = c
+			first, last = min(first, x), max(last, x)
+		}
+		if first <= last {
+			r.composeSpan(first, last, row.y)
+		}
+	}
+}
+
+// SetCaching turns the recordings of Cached on or off. Without them every
+// call draws afresh, which gives the cells copying should reproduce.
+func (r *Renderer) SetCaching(on bool) {
+	r.uncached = !on
+	r.invalidateAll()
+}
+
+// Invalidate discards the cached cells of key so the next Cached call draws
+// them again.
+func (r *Renderer) Invalidate(key any) {
+	delete(r.caches, key)
+}
+
+// InvalidateLayer discards every cache entry drawn on layer l.
+func (r *Renderer) InvalidateLayer(l Layer) {
+	for key, e := range r.caches {
+		if e.layer == l {
+			delete(r.caches, key)
+		}
+	}
+}
+
+// invalidateAll discards every cache entry, for changes that affect all of
+// them such as a resize.
+func (r *Renderer) invalidateAll() {
+	clear(r.caches)
+}
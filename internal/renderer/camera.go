@@ -0,0 +1,83 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+// Camera turns and zooms the view of 3D surfaces such as the ocean.
+type Camera struct {
+	// Yaw turns the surface around its vertical axis, in radians
+	Yaw float64
+	// Tilt scales how steeply the surface recedes, 1 for the default view
+	Tilt float64
+	// Zoom scales the surface, 1 filling the screen width
+	Zoom float64
+}
+
+// DefaultCamera returns the straight-on view.
+func DefaultCamera() Camera {
+	return Camera{Tilt: 1, Zoom: 1}
+}
+
+// SetCamera sets the view used by RenderWave.
+func (r *Renderer) SetCamera(c Camera) {
+	if c != r.camera {
+		r.Invalidate(skyKey{})
+	}
+	r.camera = c
+	r.updateView()
+}
+
+// Camera returns the current view.
+func (r *Renderer) Camera() Camera {
+	return r.camera
+}
+
+// updateView rebuilds the matrix RenderWave projects with from the camera,
+// the screen size and the cell aspect. The surface turns around its
+// center, X runs across the screen, and Z (wave height) and Y (depth, for
+// perspective) both rise up it. The third row gives the depth for
+// z-ordering: points with higher Y are further back.
+func (r *Renderer) updateView() {
+	// Scale to fill the screen width, deriving the vertical scale from it so
+	// the surface keeps its proportions, but never taller than the screen
+	scaleX := float64(r.width) * scaleXFactor
+	scaleY := math.Min(scaleX*isotropicY/r.cellAspect, float64(r.height)*scaleYFactor)
+	scaleX *= r.camera.Zoom
+	scaleY *= r.camera.Zoom
+
+	screen := vec.Mat4{
+		scaleX, 0, 0, r.centerX,
+		0, -scaleY * perspectiveY * r.camera.Tilt, -scaleY, r.centerY,
+		0, 1, depthZFactor, 0,
+		0, 0, 0, 1,
+	}
+	r.view = screen.Mul(vec.RotateZ(r.camera.Yaw))
+}
+
+// Unproject returns the point at sea level, Z = 0, of a surface drawn by
+// RenderWave that appears in the cell at x, y.
+func (r *Renderer) Unproject(x, y int) (float64, float64) {
+	// Cells cover the projected coordinates from their index up. At Z = 0
+	// the view maps X and Y onto the screen by its top left 2x2 block.
+	m := r.view
+	sx, sy := float64(x)+0.5-m[3], float64(y)+0.5-m[7]
+	det := m[0]*m[5] - m[1]*m[4]
+	return (sx*m[5] - sy*m[1]) / det, (sy*m[0] - sx*m[4]) / det
+}
+
+// Perspective returns a projection with the given vertical field of view
+// and clip plane distances for the shape of the screen, so a scene that
+// projects through it and then places points with ToScreen keeps circles
+// round whatever the cell aspect.
+func (r *Renderer) Perspective(fovY, near, far float64) vec.Mat4 {
+	return vec.Perspective(fovY, float64(r.width)/(float64(r.height)*r.cellAspect), near, far)
+}
+
+// ToScreen returns the fractional cell coordinates of a point in normalized
+// device coordinates, such as one projected through Perspective.
+func (r *Renderer) ToScreen(ndc vec.Vec3) (float64, float64) {
+	return float64(r.width) / 2 * (1 + ndc.X), float64(r.height) / 2 * (1 - ndc.Y)
+}
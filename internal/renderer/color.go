@@ -0,0 +1,128 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+)
+
+// Adjustment holds user color corrections applied to every cell before output.
+type Adjustment struct {
+	// Brightness multiplies the final color (1 is unchanged)
+	Brightness float64
+	// Contrast scales colors around mid grey (1 is unchanged)
+	Contrast float64
+	// Gamma applies a power curve; values above 1 lift shadows (1 is unchanged)
+	Gamma float64
+}
+
+// DefaultAdjustment returns the neutral adjustment that leaves colors unchanged.
+func DefaultAdjustment() Adjustment {
+	return Adjustment{Brightness: 1, Contrast: 1, Gamma: 1}
+}
+
+// identity reports whether the adjustment leaves colors unchanged.
+func (a Adjustment) identity() bool {
+	return a.Brightness == 1 && a.Contrast == 1 && a.Gamma == 1
+}
+
+// apply adjusts a single color channel in the 0-1 range.
+func (a Adjustment) apply(c float64) float64 {
+	c = (c-0.5)*a.Contrast + 0.5
+	c = math.Max(0, math.Min(1, c))
+	if a.Gamma > 0 && a.Gamma != 1 {
+		c = math.Pow(c, 1/a.Gamma)
+	}
+	return math.Max(0, math.Min(1, c*a.Brightness))
+}
+
+// NeutralTemperature is the color temperature in Kelvin that leaves colors unchanged.
+const NeutralTemperature = 6500
+
+// whitePoint returns per-channel multipliers for a color temperature in Kelvin,
+// using Tanner Helland's blackbody approximation normalized to neutral white.
+func whitePoint(kelvin float64) [3]float64 {
+	t := math.Max(1000, math.Min(40000, kelvin)) / 100
+
+	var red, green, blue float64
+	if t <= 66 {
+		red = 255
+		green = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		red = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		green = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+	switch {
+	case t >= 66:
+		blue = 255
+	case t <= 19:
+		blue = 0
+	default:
+		blue = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	clamp := func(v float64) float64 { return math.Max(0, math.Min(1, v/255)) }
+	return [3]float64{clamp(red), clamp(green), clamp(blue)}
+}
+
+// SetTemperature shifts all output colors towards the white point of the given
+// color temperature in Kelvin. NeutralTemperature or 0 disables the shift.
+func (r *Renderer) SetTemperature(kelvin float64) {
+	if kelvin <= 0 || kelvin == NeutralTemperature {
+		r.tint = [3]float64{1, 1, 1}
+		return
+	}
+	r.tint = whitePoint(kelvin)
+}
+
+// SetFade sets the fade envelope multiplier (0 is black, 1 is unchanged)
+// applied to every cell when the buffer is flushed.
+func (r *Renderer) SetFade(f float64) {
+	r.fade = math.Max(0, math.Min(1, f))
+}
+
+// SetLinearBlending sets whether fades, layer opacity and the blends
+// between scenes mix colors in linear light, as light mixes, or in the raw
+// sRGB values terminals take. Blending raw values darkens and muddies the
+// colors halfway through, most between bright, saturated ones. The
+// adjustments and color temperature stay on sRGB values, and gradients are
+// blended perceptually either way. It is on by default.
+func (r *Renderer) SetLinearBlending(on bool) {
+	r.linear = on
+}
+
+// SetAdjustment sets the brightness, contrast and gamma corrections.
+func (r *Renderer) SetAdjustment(a Adjustment) {
+	r.adjust = a
+}
+
+// Adjustment returns the current color corrections.
+func (r *Renderer) Adjustment() Adjustment {
+	return r.adjust
+}
+
+// composite applies global color adjustments to a cell style before it reaches the screen.
+func (r *Renderer) composite(style tcell.Style) tcell.Style {
+	if r.fade >= 1 && r.adjust.identity() && r.tint == [3]float64{1, 1, 1} {
+		return style
+	}
+	fg, bg, _ := style.Decompose()
+	return style.Foreground(r.adjustColor(fg)).Background(r.adjustColor(bg))
+}
+
+// adjustColor runs one color through the adjustment and fade, leaving the default color untouched.
+func (r *Renderer) adjustColor(c tcell.Color) tcell.Color {
+	if c == tcell.ColorDefault || c == tcell.ColorReset {
+		return c
+	}
+	red, green, blue := c.RGB()
+	channel := func(v int32, tint float64) int32 {
+		v = int32(math.Round(r.adjust.apply(float64(v)/255) * tint * 255))
+		if r.linear {
+			return color.Encode8(color.Linear8(v) * r.fade)
+		}
+		return int32(math.Round(float64(v) * r.fade))
+	}
+	return tcell.NewRGBColor(channel(red, r.tint[0]), channel(green, r.tint[1]), channel(blue, r.tint[2]))
+}
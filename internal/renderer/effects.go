@@ -0,0 +1,61 @@
+package renderer
+
+import "math"
+
+// Kaleidoscope reflects a single wedge of every layer around the screen
+// center into segments-fold mirror symmetry. Rotation (radians) turns the
+// source wedge so static content still appears to revolve.
+func (r *Renderer) Kaleidoscope(segments int, rotation float64) {
+	if segments < 2 || r.width == 0 || r.height == 0 {
+		return
+	}
+
+	// Snapshot the layers since destination and source cells overlap
+	for l := range r.planes {
+		r.snapshot(Layer(l))
+	}
+
+	// Work in square units so mirrored wedges keep their angles
+	aspect := r.cellAspect
+	wedge := 2 * math.Pi / float64(segments)
+	for y := 0; y < r.height; y++ {
+		for x := 0; x < r.width; x++ {
+			dx := (float64(x) + 0.5 - r.centerX) / aspect
+			dy := float64(y) + 0.5 - r.centerY
+			radius := math.Hypot(dx, dy)
+
+			// Fold the angle into the first wedge, mirroring every other one
+			theta := math.Mod(math.Atan2(dy, dx)-rotation, wedge)
+			if theta < 0 {
+				theta += wedge
+			}
+			if theta > wedge/2 {
+				theta = wedge - theta
+			}
+			theta += rotation
+
+			sx := int(math.Floor(r.centerX + math.Cos(theta)*radius*aspect))
+			sy := int(math.Floor(r.centerY + math.Sin(theta)*radius))
+			for l, plane := range r.planes {
+				if sx < 0 || sx >= r.width || sy < 0 || sy >= r.height {
+					plane[y][x] = cell{depth: -math.MaxFloat64}
+				} else {
+					plane[y][x] = r.scratch[l][sy][sx]
+				}
+			}
+		}
+	}
+	r.composeAll()
+}
+
+// snapshot copies the cells of layer l into its scratch plane and returns
+// it, for effects that move cells around the layer.
+func (r *Renderer) snapshot(l Layer) [][]cell {
+	if len(r.scratch[l]) != r.height || (r.height > 0 && len(r.scratch[l][0]) != r.width) {
+		r.scratch[l] = newCells(r.width, r.height)
+	}
+	for y, row := range r.planes[l] {
+		copy(r.scratch[l][y], row)
+	}
+	return r.scratch[l]
+}
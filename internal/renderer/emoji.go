@@ -0,0 +1,45 @@
+package renderer
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// Emoji the surface is drawn with by themes that set Emoji, two cells wide
+// apiece, from the troughs up to the foam on the crests.
+const (
+	emojiWater = '🔵'
+	emojiWave  = '🌊'
+	emojiCrest = '⬜'
+	emojiSpray = '💧'
+)
+
+// emojiWaveHeight and emojiCrestHeight are the heights of the surface, from
+// 0 to 1, above which it is drawn as waves and as foaming crests.
+const (
+	emojiWaveHeight  = 0.55
+	emojiCrestHeight = 0.8
+)
+
+// renderSurfaceEmoji fills the surface in slots two cells wide and draws
+// an emoji in each by the height of the water in it. Their color comes
+// from the font, so the theme's gradient only tints what is drawn behind.
+func (r *Renderer) renderSurfaceEmoji(w *wave.Wave, minZ, zRange float64) {
+	r.fillSurface(w, minZ, zRange, r.width/2, r.height)
+	for y := range r.pixelRows {
+		for slot := range r.pixelCols {
+			p := r.pixels[y*r.pixelCols+slot]
+			if !p.set {
+				continue
+			}
+			char := emojiWater
+			switch {
+			case p.height > emojiCrestHeight:
+				char = emojiCrest
+			case p.height > emojiWaveHeight:
+				char = emojiWave
+			}
+			r.SetCell(slot*2, y, char, p.depth, tcell.StyleDefault)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// fuzzSize limits fuzzed screen dimensions to something that renders quickly.
+func fuzzSize(w, h uint8) (int, int) {
+	return int(w % 120), int(h % 60)
+}
+
+// checkBuffer fails if any drawn cell lies outside the renderer's size.
+func checkBuffer(t *testing.T, r *Renderer) {
+	t.Helper()
+	w, h := r.Size()
+	if len(r.buffer) != h {
+		t.Fatalf("buffer has %d rows, want %d", len(r.buffer), h)
+	}
+	for y := range r.buffer {
+		if len(r.buffer[y]) != w {
+			t.Fatalf("buffer row %d has %d cells, want %d", y, len(r.buffer[y]), w)
+		}
+	}
+}
+
+func FuzzProject3D(f *testing.F) {
+	f.Add(0.0, 0.0, 0.0, uint8(80), uint8(24))
+	f.Add(1.0, -1.0, 0.5, uint8(0), uint8(0))
+	f.Add(math.Inf(1), math.Inf(-1), math.NaN(), uint8(80), uint8(24))
+	f.Add(math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64, uint8(1), uint8(1))
+	f.Add(1e300, 1e-300, -1e300, uint8(255), uint8(255))
+
+	f.Fuzz(func(t *testing.T, x, y, z float64, w, h uint8) {
+		width, height := fuzzSize(w, h)
+		r := NewViewport(width, height)
+		r.Clear()
+
+		sx, sy, _ := r.project3D(wave.Point3D{X: x, Y: y, Z: z})
+		if abs(sx) > offscreenLimit || abs(sy) > offscreenLimit {
+			t.Fatalf("project3D(%v, %v, %v) = (%d, %d), outside the clamped range", x, y, z, sx, sy)
+		}
+
+		// Projected points feed straight into line drawing in RenderWave
+		ox, oy, _ := r.project3D(wave.Point3D{})
+		r.drawShadedLine(ox, oy, sx, sy, 0, 0.5, 0.5, tcell.StyleDefault)
+		r.SetCell(sx, sy, '*', 0, tcell.StyleDefault)
+		checkBuffer(t, r)
+	})
+}
+
+func FuzzDrawLine(f *testing.F) {
+	f.Add(0, 0, 10, 5, uint8(80), uint8(24))
+	f.Add(3, 3, 3, 3, uint8(0), uint8(0))
+	f.Add(-1000000, 5, 1000000, 5, uint8(80), uint8(24))
+	f.Add(math.MinInt, math.MinInt, math.MaxInt, math.MaxInt, uint8(80), uint8(24))
+	f.Add(math.MaxInt, 0, math.MinInt, 0, uint8(1), uint8(1))
+	f.Add(-5, -5, -1, -1, uint8(10), uint8(10))
+
+	f.Fuzz(func(t *testing.T, x1, y1, x2, y2 int, w, h uint8) {
+		width, height := fuzzSize(w, h)
+		r := NewViewport(width, height)
+		r.Clear()
+
+		r.DrawLine(x1, y1, x2, y2, '*', 0, tcell.StyleDefault)
+		r.drawShadedLine(x1, y1, x2, y2, 0, 0.5, 0.5, tcell.StyleDefault)
+		checkBuffer(t, r)
+
+		// Visible endpoints are always drawn
+		for _, p := range [][2]int{{x1, y1}, {x2, y2}} {
+			if p[0] >= 0 && p[0] < width && p[1] >= 0 && p[1] < height && !r.buffer[p[1]][p[0]].set {
+				t.Errorf("visible endpoint %v of line (%d, %d)-(%d, %d) not drawn", p, x1, y1, x2, y2)
+			}
+		}
+	})
+}
+
+func FuzzSetCell(f *testing.F) {
+	f.Add(0, 0, 0.0, uint8(80), uint8(24))
+	f.Add(-1, -1, math.NaN(), uint8(0), uint8(0))
+	f.Add(math.MaxInt, math.MinInt, math.Inf(1), uint8(80), uint8(24))
+	f.Add(79, 23, -math.MaxFloat64, uint8(80), uint8(24))
+
+	f.Fuzz(func(t *testing.T, x, y int, depth float64, w, h uint8) {
+		width, height := fuzzSize(w, h)
+		r := NewViewport(width, height)
+		r.Clear()
+
+		r.SetCell(x, y, '*', depth, tcell.StyleDefault)
+		r.PlotDot(float64(x)+0.5, float64(y)+0.5, depth, tcell.StyleDefault)
+		checkBuffer(t, r)
+	})
+}
+
+func FuzzFillRect(f *testing.F) {
+	f.Add(0.0, 0.0, 4.0, 2.0, uint8(80), uint8(24))
+	f.Add(-1e9, -1e9, 2e9, 2e9, uint8(80), uint8(24))
+	f.Add(math.NaN(), 0.0, math.Inf(1), 1.0, uint8(10), uint8(10))
+	f.Add(0.5, 0.5, math.SmallestNonzeroFloat64, 1e300, uint8(0), uint8(0))
+
+	f.Fuzz(func(t *testing.T, x, y, rw, rh float64, w, h uint8) {
+		width, height := fuzzSize(w, h)
+		r := NewViewport(width, height)
+		r.Clear()
+
+		r.FillRect(x, y, rw, rh, tcell.ColorWhite, 0)
+		checkBuffer(t, r)
+	})
+}
@@ -0,0 +1,134 @@
+package renderer
+
+import (
+	"math"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// pixel is a part of a cell the surface is filled into, with the depth,
+// shade and height of the water drawn in it.
+type pixel struct {
+	depth, shade, height float64
+	set                  bool
+}
+
+// vertex is a projected grid point of the surface, in pixels, with its
+// position on the color gradient and its height from 0 to 1.
+type vertex struct {
+	x, y, depth, shade, height float64
+}
+
+// renderSurfaceBlocks fills the surface in pixels two to a cell and draws
+// each cell as an upper half block in the color of its top pixel on the
+// color of its bottom one.
+func (r *Renderer) renderSurfaceBlocks(w *wave.Wave, minZ, zRange float64) {
+	r.fillSurface(w, minZ, zRange, r.width, r.height*2)
+	color := func(p pixel) tcell.Color { return r.shadeColor(p.shade) }
+	for y := range r.height {
+		for x := range r.width {
+			top, bottom := r.pixels[y*2*r.width+x], r.pixels[(y*2+1)*r.width+x]
+			switch {
+			case top.set && bottom.set:
+				r.SetCell(x, y, '▀', math.Max(top.depth, bottom.depth), tcell.StyleDefault.Foreground(color(top)).Background(color(bottom)))
+			case top.set:
+				r.SetCell(x, y, '▀', top.depth, tcell.StyleDefault.Foreground(color(top)))
+			case bottom.set:
+				r.SetCell(x, y, '▄', bottom.depth, tcell.StyleDefault.Foreground(color(bottom)))
+			}
+		}
+	}
+}
+
+// shadeColor returns the color of a position on the gradient from 0 to 1.
+func (r *Renderer) shadeColor(shade float64) tcell.Color {
+	return r.gradient[max(0, min(int(shade*float64(len(r.gradient)-1)), len(r.gradient)-1))]
+}
+
+// fillSurface fills the quads of the surface grid into a grid of pixels
+// covering the screen, cols across and rows down, shaded smoothly between
+// their corners.
+func (r *Renderer) fillSurface(w *wave.Wave, minZ, zRange float64, cols, rows int) {
+	r.pixelCols, r.pixelRows = cols, rows
+	r.pixels = r.pixels[:0]
+	for range cols * rows {
+		r.pixels = append(r.pixels, pixel{depth: -math.MaxFloat64})
+	}
+
+	gridDepth, gridWidth := w.Size()
+	scaleX, scaleY := float64(cols)/float64(r.width), float64(rows)/float64(r.height)
+	at := func(depth, width int) vertex {
+		p := w.GridPoints[depth][width]
+		x, y, d := r.project(p)
+		normalizedZ := (p.Z - minZ) / zRange
+		depthFactor := float64(depth) / float64(gridDepth-1)
+		lit := 1 - w.Shadows[depth][width]
+		return vertex{
+			x: x * scaleX, y: y * scaleY, depth: d,
+			shade:  (normalizedZ*0.6 + depthFactor*0.4) * lit,
+			height: normalizedZ,
+		}
+	}
+	for depth := 0; depth < gridDepth-1; depth++ {
+		for width := 0; width < gridWidth-1; width++ {
+			v1, v2 := at(depth, width), at(depth, width+1)
+			v3, v4 := at(depth+1, width), at(depth+1, width+1)
+			r.fillTriangle(v1, v2, v4)
+			r.fillTriangle(v1, v4, v3)
+		}
+	}
+}
+
+// fillTriangle sets the pixels whose centers lie in a triangle, nearer
+// water over farther, interpolating the depth, shade and height of its
+// corners.
+func (r *Renderer) fillTriangle(a, b, c vertex) {
+	area := (b.x-a.x)*(c.y-a.y) - (b.y-a.y)*(c.x-a.x)
+	if area == 0 || math.IsNaN(area) || math.IsInf(area, 0) {
+		return
+	}
+	x0 := max(toScreen(math.Floor(min(a.x, b.x, c.x))), 0)
+	x1 := min(toScreen(math.Ceil(max(a.x, b.x, c.x))), r.pixelCols-1)
+	y0 := max(toScreen(math.Floor(min(a.y, b.y, c.y))), 0)
+	y1 := min(toScreen(math.Ceil(max(a.y, b.y, c.y))), r.pixelRows-1)
+	for py := y0; py <= y1; py++ {
+		for px := x0; px <= x1; px++ {
+			x, y := float64(px)+0.5, float64(py)+0.5
+			// Barycentric weights, all of one sign inside the triangle
+			wa := ((b.x-x)*(c.y-y) - (b.y-y)*(c.x-x)) / area
+			wb := ((c.x-x)*(a.y-y) - (c.y-y)*(a.x-x)) / area
+			wc := 1 - wa - wb
+			if wa < 0 || wb < 0 || wc < 0 {
+				continue
+			}
+			depth := wa*a.depth + wb*b.depth + wc*c.depth
+			p := &r.pixels[py*r.pixelCols+px]
+			if depth <= p.depth {
+				continue
+			}
+			*p = pixel{
+				depth:  depth,
+				shade:  wa*a.shade + wb*b.shade + wc*c.shade,
+				height: wa*a.height + wb*b.height + wc*c.height,
+				set:    true,
+			}
+		}
+	}
+}
+
+// overWater gives a style without a background the color of the half-block
+// or quadrant water drawn in the cell, so spray over the surface does not
+// punch holes in it.
+func (r *Renderer) overWater(x, y int, style tcell.Style) tcell.Style {
+	if x < 0 || x >= r.width || y < 0 || y >= r.height {
+		return style
+	}
+	under := r.buffer[y][x]
+	if _, bg, _ := style.Decompose(); bg != tcell.ColorDefault || !under.set || !slices.Contains(quadrants[1:], under.char) {
+		return style
+	}
+	water, _, _ := under.style.Decompose()
+	return style.Background(water)
+}
@@ -0,0 +1,50 @@
+package renderer
+
+import "math"
+
+// Sub-cell intensity accumulation lets scenes deposit brightness at fractional
+// positions. Many small contributions build up smooth density fields that are
+// resolved into shade characters and gradient colors in a single pass.
+
+// AddIntensity adds brightness to a single cell of the intensity buffer.
+func (r *Renderer) AddIntensity(x, y int, amount float64) {
+	if x < 0 || x >= r.width || y < 0 || y >= r.height {
+		return
+	}
+	r.intensity[y][x] += amount
+}
+
+// Splat adds brightness at a fractional screen position, spreading it
+// bilinearly over the four nearest cells so motion stays smooth below cell size.
+func (r *Renderer) Splat(x, y, amount float64) {
+	// Cell centers sit at +0.5, shift so weights are relative to centers
+	fx := x - 0.5
+	fy := y - 0.5
+	x0 := int(math.Floor(fx))
+	y0 := int(math.Floor(fy))
+	tx := fx - float64(x0)
+	ty := fy - float64(y0)
+
+	r.AddIntensity(x0, y0, amount*(1-tx)*(1-ty))
+	r.AddIntensity(x0+1, y0, amount*tx*(1-ty))
+	r.AddIntensity(x0, y0+1, amount*(1-tx)*ty)
+	r.AddIntensity(x0+1, y0+1, amount*tx*ty)
+}
+
+// ResolveIntensity converts the accumulated intensity buffer into shaded cells.
+// Brightness is scaled by gain and brought into range by the tone map, see
+// SetToneMap, so dense regions saturate gracefully instead of clipping.
+func (r *Renderer) ResolveIntensity(depth, gain float64) {
+	for y := range r.intensity {
+		for x, v := range r.intensity[y] {
+			if v <= 0 {
+				continue
+			}
+			level := r.toneMap.Apply(v * gain)
+			if level < 0.02 {
+				continue
+			}
+			r.SetCell(x, y, r.ShadeCharAt(x, y, level), depth, r.GradientStyle(level))
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+)
+
+// Layer identifies a group of drawing calls that is composited with its own
+// opacity and tint, for example to keep a clock prominent over a faint scene.
+type Layer int
+
+const (
+	// LayerScene holds the main scene content
+	LayerScene Layer = iota
+	// LayerParticles holds spray, foam and similar particle effects
+	LayerParticles
+	// LayerOverlay holds widgets such as the ticker
+	LayerOverlay
+	// LayerSky holds backdrops behind the scene, such as the sky over the
+	// ocean. It is composited below every other layer and hidden until
+	// given an opacity
+	LayerSky
+	// LayerUI holds menus and transitions, which always stay opaque
+	LayerUI
+	layerCount
+)
+
+// compositeOrder lists the layers from the bottom up. Translucent cells
+// blend with the layers below theirs, never with their own layer.
+var compositeOrder = [layerCount]Layer{LayerSky, LayerScene, LayerParticles, LayerOverlay, LayerUI}
+
+// layerNames are the user-facing names of the configurable layers.
+var layerNames = map[string]Layer{
+	"sky":       LayerSky,
+	"scene":     LayerScene,
+	"particles": LayerParticles,
+	"overlay":   LayerOverlay,
+}
+
+// ParseLayer returns the configurable layer with the given name.
+func ParseLayer(name string) (Layer, error) {
+	l, ok := layerNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown layer %q (available: sky, scene, particles, overlay)", name)
+	}
+	return l, nil
+}
+
+// MarshalText encodes the layer by name, so configurations saved as JSON
+// or written by hand read like the -layer flag.
+func (l Layer) MarshalText() ([]byte, error) {
+	for name, layer := range layerNames {
+		if layer == l {
+			return []byte(name), nil
+		}
+	}
+	return []byte(strconv.Itoa(int(l))), nil
+}
+
+// UnmarshalText decodes a layer name, or the number older replay files
+// stored.
+func (l *Layer) UnmarshalText(text []byte) error {
+	if n, err := strconv.Atoi(string(text)); err == nil && n >= 0 && n < int(layerCount) {
+		*l = Layer(n)
+		return nil
+	}
+	layer, err := ParseLayer(string(text))
+	*l = layer
+	return err
+}
+
+// LayerStyle controls how the cells of one layer are composited.
+type LayerStyle struct {
+	// Opacity from 0 (invisible) to 1 (opaque); translucent cells blend
+	// with whatever the layers below drew behind them, or the black
+	// background
+	Opacity float64
+	// Tint multiplies each color channel (0-1), white leaves colors unchanged
+	Tint [3]float64
+}
+
+// DefaultLayerStyle returns a fully opaque, untinted layer.
+func DefaultLayerStyle() LayerStyle {
+	return LayerStyle{Opacity: 1, Tint: [3]float64{1, 1, 1}}
+}
+
+// DefaultStyle returns the style layer l has until set otherwise: opaque
+// and untinted, except for the sky, which is hidden.
+func (l Layer) DefaultStyle() LayerStyle {
+	s := DefaultLayerStyle()
+	if l == LayerSky {
+		s.Opacity = 0
+	}
+	return s
+}
+
+// identity reports whether the style leaves colors unchanged.
+func (s LayerStyle) identity() bool {
+	return s.Opacity >= 1 && s.Tint == [3]float64{1, 1, 1}
+}
+
+// SetLayer selects the layer subsequent drawing calls belong to and returns
+// the previously selected one so callers can restore it.
+func (r *Renderer) SetLayer(l Layer) Layer {
+	prev := r.layer
+	if l >= 0 && l < layerCount {
+		r.layer = l
+	}
+	return prev
+}
+
+// SetLayerStyle sets the opacity and tint of a layer. The UI layer is always opaque.
+func (r *Renderer) SetLayerStyle(l Layer, s LayerStyle) {
+	if l < 0 || l >= LayerUI {
+		return
+	}
+	s.Opacity = math.Max(0, math.Min(s.Opacity, 1))
+	r.layers[l] = s
+}
+
+// LayerStyle returns the opacity and tint of a layer.
+func (r *Renderer) LayerStyle(l Layer) LayerStyle {
+	if l < 0 || l >= LayerUI {
+		return DefaultLayerStyle()
+	}
+	return r.layers[l]
+}
+
+// blendLayer applies the tint of a layer styled ls and blends the style
+// over the cell it covers according to the layer opacity.
+func (r *Renderer) blendLayer(style tcell.Style, under cell, ls LayerStyle) tcell.Style {
+	fg, bg, _ := style.Decompose()
+	ufg, ubg := tcell.ColorBlack, tcell.ColorBlack
+	if under.set {
+		ufg, ubg, _ = under.style.Decompose()
+	}
+	return style.Foreground(blendColor(fg, ufg, ls, r.linear)).Background(blendColor(bg, ubg, ls, r.linear))
+}
+
+// blendColor tints c and mixes it with the color below it by the layer
+// opacity, in linear light if linear is set and in raw sRGB values
+// otherwise.
+func blendColor(c, below tcell.Color, ls LayerStyle, linear bool) tcell.Color {
+	if c == tcell.ColorDefault || c == tcell.ColorReset {
+		return c
+	}
+	if below == tcell.ColorDefault || below == tcell.ColorReset {
+		below = tcell.ColorBlack
+	}
+	red, green, blue := c.RGB()
+	br, bgreen, bb := below.RGB()
+	channel := func(v, under int32, tint float64) int32 {
+		if linear {
+			tinted := int32(math.Round(float64(v) * tint))
+			return color.Encode8(color.Linear8(tinted)*ls.Opacity + color.Linear8(under)*(1-ls.Opacity))
+		}
+		return int32(math.Round(float64(v)*tint*ls.Opacity + float64(under)*(1-ls.Opacity)))
+	}
+	return tcell.NewRGBColor(channel(red, br, ls.Tint[0]), channel(green, bgreen, ls.Tint[1]), channel(blue, bb, ls.Tint[2]))
+}
+
+// composed returns the cell at (x, y) as the layers show it together. Going
+// up from the bottom layer, each cell in front of the ones below covers
+// them, blended with them by the opacity of its layer. Cells that leave
+// their background unset show the sky's through it.
+func (r *Renderer) composed(x, y int) cell {
+	out := cell{depth: -math.MaxFloat64}
+	sky := tcell.ColorDefault
+	for _, l := range compositeOrder {
+		c, ls := r.planes[l][y][x], r.layers[l]
+		if !c.set || c.depth <= out.depth || ls.Opacity <= 0 {
+			continue
+		}
+		if !c.composited && !ls.identity() {
+			c.style = r.blendLayer(c.style, out, ls)
+		}
+		_, bg, _ := c.style.Decompose()
+		switch {
+		case l == LayerSky:
+			sky = bg
+		case bg == tcell.ColorDefault:
+			c.style = c.style.Background(sky)
+		}
+		out = c
+	}
+	return out
+}
+
+// composeSpan composites the cells from x0 to x1 of row y, and the ones on
+// either side, whose wide characters a change may have broken or mended.
+func (r *Renderer) composeSpan(x0, x1, y int) {
+	x0, x1 = max(x0-1, 0), min(x1+1, r.width-1)
+	row := r.buffer[y]
+	for x := x0; x <= x1; x++ {
+		row[x] = r.composed(x, y)
+	}
+	// A wide character only shows along with the cell it spills into,
+	// which a layer in front may cover
+	for x := x0; x <= x1; x++ {
+		switch c := row[x]; {
+		case !c.set:
+		case c.char == 0:
+			if x == 0 || !r.isWide(r.composed(x-1, y).char) {
+				row[x].char = ' '
+			}
+		case r.isWide(c.char):
+			if x+1 == r.width {
+				row[x].char = ' '
+			} else if next := r.composed(x+1, y); !next.set || next.char != 0 {
+				row[x].char = ' '
+			}
+		}
+	}
+}
+
+// composeAll composites every cell, after changes to whole layers.
+func (r *Renderer) composeAll() {
+	for y := range r.buffer {
+		r.composeSpan(0, r.width-1, y)
+	}
+}
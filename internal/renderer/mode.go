@@ -0,0 +1,133 @@
+package renderer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// Mode is how RenderWave draws the ocean surface.
+type Mode int
+
+const (
+	// ModeText draws the surface grid with shade characters, a cell apiece
+	ModeText Mode = iota
+	// ModeBraille draws the surface grid with Braille dots, 2 by 4 to a
+	// cell, for four times the detail
+	ModeBraille
+	// ModeHalfBlock fills the surface with half blocks, colored separately
+	// above and below, for twice the rows of smoothly shaded water
+	ModeHalfBlock
+	// ModeQuadrant fills the surface with quadrant blocks, 2 by 2 to a
+	// cell in two colors, for twice the columns and rows of water
+	ModeQuadrant
+)
+
+// modeNames are the names of the modes, in order.
+var modeNames = []string{"text", "braille", "halfblock", "quadrant"}
+
+// ModeNames returns the names of the modes, the default first.
+func ModeNames() []string {
+	return slices.Clone(modeNames)
+}
+
+// ParseMode returns the mode with the given name.
+func ParseMode(name string) (Mode, error) {
+	i := slices.Index(modeNames, name)
+	if i < 0 {
+		return ModeText, fmt.Errorf("unknown render mode %q (available: %s)", name, strings.Join(modeNames, ", "))
+	}
+	return Mode(i), nil
+}
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+	return modeNames[m]
+}
+
+// Glyph returns a character the mode draws with that fonts may lack, to
+// check the terminal can show it, or 0 if it only needs the basics.
+func (m Mode) Glyph() rune {
+	switch m {
+	case ModeBraille:
+		return '⣿'
+	case ModeHalfBlock:
+		return '▀'
+	case ModeQuadrant:
+		return '▚'
+	}
+	return 0
+}
+
+// SetMode selects how RenderWave draws the ocean surface.
+func (r *Renderer) SetMode(m Mode) {
+	r.mode = m
+}
+
+// Mode returns how RenderWave draws the ocean surface.
+func (r *Renderer) Mode() Mode {
+	return r.mode
+}
+
+// renderSurfaceDots draws the lines of the surface grid with Braille dots.
+// Rows are drawn from the back, so the colors of nearer water win in cells
+// that rows share.
+func (r *Renderer) renderSurfaceDots(w *wave.Wave, minZ, zRange float64) {
+	gridDepth, gridWidth := w.Size()
+	for depth := gridDepth - 2; depth >= 0; depth-- {
+		for width := 0; width < gridWidth-1; width++ {
+			p1 := w.GridPoints[depth][width]
+			x1, y1, d1 := r.project(p1)
+			x2, y2, d2 := r.project(w.GridPoints[depth][width+1])
+			x3, y3, d3 := r.project(w.GridPoints[depth+1][width])
+
+			normalizedZ := (p1.Z - minZ) / zRange
+			depthFactor := float64(depth) / float64(gridDepth-1)
+			shadow := w.Shadows[depth][width]
+			style := r.getStyle(normalizedZ*(1-shadow), depthFactor*(1-shadow))
+
+			r.dotLine(x1, y1, x2, y2, (d1+d2)/2, style)
+			r.dotLine(x1, y1, x3, y3, (d1+d3)/2, style)
+		}
+	}
+}
+
+// dotLine draws a line of Braille dots between two fractional screen
+// positions, given in cells.
+func (r *Renderer) dotLine(x1, y1, x2, y2, depth float64, style tcell.Style) {
+	// Bresenham on the grid of dots, 2 across and 4 down a cell
+	dx1, dy1, dx2, dy2, visible := clipLine(toScreen(x1*2), toScreen(y1*4), toScreen(x2*2), toScreen(y2*4), r.width*2, r.height*4)
+	if !visible {
+		return
+	}
+	dx, dy := abs(dx2-dx1), abs(dy2-dy1)
+	sx, sy := 1, 1
+	if dx1 > dx2 {
+		sx = -1
+	}
+	if dy1 > dy2 {
+		sy = -1
+	}
+	err := dx - dy
+	for {
+		r.PlotDot((float64(dx1)+0.5)/2, (float64(dy1)+0.5)/4, depth, style)
+		if dx1 == dx2 && dy1 == dy2 {
+			return
+		}
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			dx1 += sx
+		}
+		if e2 < dx {
+			err += dx
+			dy1 += sy
+		}
+	}
+}
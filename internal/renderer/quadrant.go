@@ -0,0 +1,88 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// quadrants are the quadrant block characters by the quadrants they fill:
+// bit 0 the top left, 1 the top right, 2 the bottom left, 3 the bottom
+// right.
+var quadrants = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+// renderSurfaceQuadrants fills the surface in pixels 2 by 2 to a cell and
+// draws each cell as the quadrant block that best splits its pixels into
+// two colors, the foreground and the background. Cells the surface only
+// partly covers fill just those quadrants, over whatever is behind.
+func (r *Renderer) renderSurfaceQuadrants(w *wave.Wave, minZ, zRange float64) {
+	r.fillSurface(w, minZ, zRange, r.width*2, r.height*2)
+	var cell [4]pixel
+	for y := range r.height {
+		for x := range r.width {
+			mask, depth := 0, -math.MaxFloat64
+			for i := range cell {
+				cell[i] = r.pixels[(y*2+i/2)*r.pixelCols+x*2+i%2]
+				if cell[i].set {
+					mask |= 1 << i
+					depth = max(depth, cell[i].depth)
+				}
+			}
+			switch mask {
+			case 0:
+				continue
+			case 15:
+				fg, bg, split := splitQuadrants(cell)
+				r.SetCell(x, y, quadrants[split], depth, tcell.StyleDefault.Foreground(r.shadeColor(fg)).Background(r.shadeColor(bg)))
+			default:
+				fg, _ := meanShade(cell, mask)
+				r.SetCell(x, y, quadrants[mask], depth, tcell.StyleDefault.Foreground(r.shadeColor(fg)))
+			}
+		}
+	}
+}
+
+// splitQuadrants finds the quadrants of a cell to draw in the foreground
+// so the shades of the two groups stray least from their means, which it
+// returns. The brighter group is the foreground, and a cell of one shade
+// is a full block.
+func splitQuadrants(cell [4]pixel) (fg, bg float64, split int) {
+	best := math.Inf(1)
+	for mask := 15; mask > 0; mask-- {
+		f, errF := meanShade(cell, mask)
+		b, errB := meanShade(cell, 15&^mask)
+		if errF+errB < best {
+			best, fg, bg, split = errF+errB, f, b, mask
+		}
+	}
+	switch {
+	case split == 15:
+		bg = fg
+	case fg < bg:
+		fg, bg, split = bg, fg, 15&^split
+	}
+	return fg, bg, split
+}
+
+// meanShade returns the mean shade of the pixels of a cell in mask and
+// the sum of their squared distances from it.
+func meanShade(cell [4]pixel, mask int) (mean, spread float64) {
+	n := 0
+	for i, p := range cell {
+		if mask&(1<<i) != 0 {
+			mean += p.shade
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean /= float64(n)
+	for i, p := range cell {
+		if mask&(1<<i) != 0 {
+			spread += (p.shade - mean) * (p.shade - mean)
+		}
+	}
+	return mean, spread
+}
@@ -0,0 +1,107 @@
+package renderer
+
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+)
+
+// DefaultDither is the ordered dithering strength used on limited palettes.
+const DefaultDither = 0.08
+
+// maxQuantized bounds the quantization cache. Scenes with smooth gradients
+// show a great many colors, each cached at 16 thresholds, so the cache is
+// emptied when full rather than growing for as long as the scene runs.
+const maxQuantized = 1 << 16
+
+// paletteKey caches quantization results per color and dither threshold.
+type paletteKey struct {
+	color tcell.Color
+	cell  int // Position in the 4x4 dither matrix, or threshold step over frames
+}
+
+// paletteFor picks the palette matching a terminal's color count, or nil
+// if the terminal shows true color and needs no quantization.
+func paletteFor(colors int) *color.Palette {
+	switch {
+	case colors >= 1<<24:
+		return nil
+	case colors >= 256:
+		return color.XTerm256()
+	case colors >= 16:
+		return color.ANSI16()
+	case colors >= 8:
+		return color.ANSI8()
+	}
+	return nil
+}
+
+// SetColors quantizes output for a terminal showing the given number of
+// colors, such as 256, for when the screen misreports its color depth. 0
+// goes back to the number the screen reports.
+func (r *Renderer) SetColors(colors int) {
+	if colors == 0 && r.screen != nil {
+		colors = r.screen.Colors()
+	}
+	r.palette = paletteFor(colors)
+	clear(r.quantized)
+	r.invalidateAll()
+}
+
+// SetDither sets the ordered dithering strength used when the terminal only
+// supports 256 or 16 colors (0 disables dithering).
+func (r *Renderer) SetDither(strength float64) {
+	r.dither = strength
+	clear(r.quantized)
+}
+
+// SetTemporalDither sets whether colors on a 256 color terminal alternate
+// between the two nearest palette entries from frame to frame, so shades
+// between them show as their blend. Each cell steps through the ordered
+// dithering thresholds in turn, out of step with its neighbours, trading
+// banding for a faint flicker some find distracting. It is on by default.
+func (r *Renderer) SetTemporalDither(on bool) {
+	r.temporal = on
+	clear(r.quantized)
+}
+
+// quantize maps the style's colors onto the terminal palette, dithering by
+// cell position so gradients alternate between entries instead of banding.
+func (r *Renderer) quantize(style tcell.Style, x, y int) tcell.Style {
+	fg, bg, _ := style.Decompose()
+	return style.Foreground(r.quantizeColor(fg, x, y)).Background(r.quantizeColor(bg, x, y))
+}
+
+// quantizeColor maps one color onto the palette, leaving the default color untouched.
+func (r *Renderer) quantizeColor(c tcell.Color, x, y int) tcell.Color {
+	if c == tcell.ColorDefault || c == tcell.ColorReset || c&tcell.ColorIsRGB == 0 {
+		return c
+	}
+	if r.temporal && len(r.palette.Colors) >= 256 {
+		// Stepping 5 of 16 thresholds a frame visits every one in 16
+		// frames, keeping turns between the two entries frequent
+		step := (int((color.Bayer(x, y)+0.5)*16) + r.frame*5) & 15
+		key := paletteKey{c, step}
+		if q, ok := r.quantized[key]; ok {
+			return q
+		}
+		q := tcell.PaletteColor(r.palette.Alternate(color.FromTcell(c), (float64(step)+0.5)/16))
+		r.remember(key, q)
+		return q
+	}
+	key := paletteKey{c, (y&3)*4 + x&3}
+	if q, ok := r.quantized[key]; ok {
+		return q
+	}
+	q := tcell.PaletteColor(r.palette.Dither(color.FromTcell(c), x, y, r.dither))
+	r.remember(key, q)
+	return q
+}
+
+// remember caches the palette entry a color quantizes to, emptying the
+// cache first if it is full.
+func (r *Renderer) remember(key paletteKey, q tcell.Color) {
+	if len(r.quantized) >= maxQuantized {
+		clear(r.quantized)
+	}
+	r.quantized[key] = q
+}
@@ -5,11 +5,23 @@ import (
 	"math"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/theme"
+	"github.com/olegchuev/screensaver/internal/vec"
 	"github.com/olegchuev/screensaver/internal/wave"
 )
 
 // ASCII characters for 3D shading effect - from darkest/furthest to brightest/closest
-var shadeChars = []rune{'·', ':', '÷', '≈', '≠', '≡', '∫', '#', '▓', '█'}
+var shadeChars = []rune(DefaultShadeRamp)
+
+// Foam is drawn as bright white spray that fades into the water as its
+// brightness falls, see sprayLook
+var foamStyle = particle.Style{
+	Chars: []rune{'•', '•', '∙', '·'},
+	From:  [3]int32{255, 255, 255},
+	To:    [3]int32{140, 150, 160},
+}
 
 // Block characters for filled areas
 var blockChars = []rune{'░', '▒', '▓', '█'}
@@ -19,16 +31,80 @@ const (
 	scaleYFactor = 0.7
 	perspectiveY = 0.4
 	depthZFactor = 0.3
+	// Vertical wave scale relative to the horizontal one in square units,
+	// tuned so a 16:9 window looks like the original full-height projection
+	isotropicY = 0.415
 )
 
+// offscreenLimit bounds projected coordinates, far outside any real screen.
+const offscreenLimit = 1 << 20
+
+// gradientSteps is the resolution of the sampled theme gradient.
+const gradientSteps = 256
+
+// DefaultCellAspect is the typical height-to-width ratio of a terminal cell.
+const DefaultCellAspect = 2.0
+
 // Renderer handles 3D to 2D projection and drawing to the terminal screen.
 type Renderer struct {
-	screen  tcell.Screen
-	width   int
-	height  int
-	buffer  [][]cell
-	centerX float64
-	centerY float64
+	screen tcell.Screen
+	width  int
+	height int
+	// Cells drawn on each layer, and all of them composited as they are
+	// shown, kept up to date as cells are drawn
+	planes [layerCount][][]cell
+	buffer [][]cell
+	// Accumulated sub-cell brightness, resolved by ResolveIntensity and
+	// the ocean's spray with the tone map, and the cells the spray fell on
+	intensity [][]float64
+	toneMap   ToneMap
+	spray     []sprayDrop
+	// Rounding error carried between cells by ShadeCharAt, and how it dithers
+	shadeError  [][]float64
+	shadeDither ShadeDither
+	// Shade characters from darkest to brightest, see SetShadeRamp
+	ramp []rune
+	// Copy of the layers used by post-effects that read and write overlapping cells
+	scratch [layerCount][][]cell
+	// Fade envelope multiplier applied when compositing to the screen
+	fade float64
+	// User color adjustments applied when compositing to the screen
+	adjust Adjustment
+	// Per-channel color temperature multipliers
+	tint [3]float64
+	// Whether fades and blends mix colors in linear light, see SetLinearBlending
+	linear bool
+	// Height-to-width ratio of a terminal cell, used to keep shapes undistorted
+	cellAspect float64
+	// Color gradient of the active theme, sampled into a lookup table
+	gradient []tcell.Color
+	// Layer receiving drawing calls and the compositing style of each layer
+	layer  Layer
+	layers [layerCount]LayerStyle
+	// Terminal palette for screens without true color, with cached lookups
+	palette   *color.Palette
+	dither    float64
+	temporal  bool // Whether 256 color palettes alternate over frames
+	frame     int  // Frames flushed, stepping the temporal dithering
+	quantized map[paletteKey]tcell.Color
+	// Recorded drawings of content that rarely changes, see Cached
+	caches    map[any]*cacheEntry
+	recording bool // Whether Cached is recording an entry
+	uncached  bool // Whether Cached always draws, see SetCaching
+	centerX   float64
+	centerY   float64
+	// View of 3D surfaces, and the matrix projecting them onto the screen
+	camera Camera
+	view   vec.Mat4
+	// How RenderWave draws the ocean surface, and the pixels modes that
+	// fill it use, pixelCols across and pixelRows down
+	mode                 Mode
+	pixels               []pixel
+	pixelCols, pixelRows int
+	// Characters known to be wide or not, see isWide
+	widths map[rune]bool
+	// Whether the theme draws the ocean surface with emoji, see SetTheme
+	emoji bool
 }
 
 // cell represents a single terminal cell with character, style, and depth information.
@@ -37,77 +113,205 @@ type cell struct {
 	style tcell.Style
 	depth float64
 	set   bool
+	// Copied from cells another renderer composited, so the layer style
+	// does not apply again
+	composited bool
 }
 
 // NewRenderer creates a new renderer attached to the given tcell screen.
 func NewRenderer(screen tcell.Screen) *Renderer {
 	w, h := screen.Size()
 	r := &Renderer{
-		screen:  screen,
-		width:   w,
-		height:  h,
-		centerX: float64(w) / 2,
-		centerY: float64(h) / 2,
+		screen:     screen,
+		width:      w,
+		height:     h,
+		centerX:    float64(w) / 2,
+		centerY:    float64(h) / 2,
+		fade:       1,
+		adjust:     DefaultAdjustment(),
+		tint:       [3]float64{1, 1, 1},
+		cellAspect: DefaultCellAspect,
+		gradient:   defaultGradient(),
+		layers:     defaultLayers(),
+		palette:    paletteFor(screen.Colors()),
+		dither:     DefaultDither,
+		temporal:   true,
+		ramp:       shadeChars,
+		linear:     true,
+		quantized:  make(map[paletteKey]tcell.Color),
+		camera:     DefaultCamera(),
 	}
 	r.initBuffer()
+	r.updateView()
 	return r
 }
 
-// initBuffer allocates the internal rendering buffer matching screen dimensions.
+// defaultLayers returns the default style of every layer.
+func defaultLayers() [layerCount]LayerStyle {
+	var layers [layerCount]LayerStyle
+	for i := range layers {
+		layers[i] = Layer(i).DefaultStyle()
+	}
+	return layers
+}
+
+// defaultGradient returns the gradient of the default theme.
+func defaultGradient() []tcell.Color {
+	t, _ := theme.Lookup(theme.Default)
+	return gradientTable(t.Gradient)
+}
+
+// gradientTable blends smoothly between theme stops in OKLab and samples
+// the result. Each stop's color sits at the middle of the band it would
+// fill as a flat step, so themes keep their overall look without banding.
+func gradientTable(stops []theme.Stop) []tcell.Color {
+	points := make([]color.Stop, len(stops))
+	lo := 0.0
+	for i, s := range stops {
+		hi := math.Min(s.Threshold, 1)
+		points[i] = color.Stop{Pos: (lo + hi) / 2, Color: color.From8(s.R, s.G, s.B)}
+		lo = hi
+	}
+
+	samples := color.NewGradient(points, color.SpaceOKLab).Table(gradientSteps)
+	table := make([]tcell.Color, len(samples))
+	for i, c := range samples {
+		table[i] = c.Tcell()
+	}
+	return table
+}
+
+// initBuffer allocates the internal rendering buffer matching screen dimensions
+// and drops the drawings cached for the previous size.
 func (r *Renderer) initBuffer() {
-	r.buffer = make([][]cell, r.height)
+	r.buffer = newCells(r.width, r.height)
+	for l := range r.planes {
+		r.planes[l] = newCells(r.width, r.height)
+	}
+	r.intensity = make([][]float64, r.height)
+	r.shadeError = make([][]float64, r.height)
 	for i := range r.buffer {
-		r.buffer[i] = make([]cell, r.width)
+		r.intensity[i] = make([]float64, r.width)
+		r.shadeError[i] = make([]float64, r.width)
 	}
+	r.invalidateAll()
+}
+
+// newCells allocates empty cells, height rows of width.
+func newCells(width, height int) [][]cell {
+	cells := make([][]cell, height)
+	for i := range cells {
+		cells[i] = make([]cell, width)
+	}
+	return cells
 }
 
 // Resize handles terminal resize events by updating dimensions and reallocating buffers.
 func (r *Renderer) Resize() {
+	if r.screen == nil {
+		return // Viewports are sized with SetSize
+	}
 	r.width, r.height = r.screen.Size()
 	r.centerX = float64(r.width) / 2
 	r.centerY = float64(r.height) / 2
 	r.initBuffer()
+	r.updateView()
+}
+
+// Size returns the current drawing area dimensions (width, height) in cells.
+func (r *Renderer) Size() (int, int) {
+	return r.width, r.height
+}
+
+// CellAspect returns the height-to-width ratio of a terminal cell. Scenes
+// multiply horizontal distances by it so circles stay round.
+func (r *Renderer) CellAspect() float64 {
+	return r.cellAspect
+}
+
+// SetCellAspect sets the height-to-width ratio of a terminal cell.
+func (r *Renderer) SetCellAspect(aspect float64) {
+	if aspect > 0 && aspect != r.cellAspect {
+		r.cellAspect = aspect
+		r.updateView()
+		r.invalidateAll()
+	}
 }
 
 // Clear clears the rendering buffer and screen, preparing for a new frame.
 func (r *Renderer) Clear() {
+	empty := cell{depth: -math.MaxFloat64}
 	for y := range r.buffer {
 		for x := range r.buffer[y] {
-			r.buffer[y][x] = cell{depth: -math.MaxFloat64}
+			r.buffer[y][x] = empty
+			for _, plane := range r.planes {
+				plane[y][x] = empty
+			}
+			r.intensity[y][x] = 0
+			r.shadeError[y][x] = 0
 		}
 	}
-	r.screen.Clear()
+	if r.screen != nil {
+		r.screen.Clear()
+	}
 }
 
 // project3D converts a 3D point to 2D screen coordinates with depth for z-ordering.
 func (r *Renderer) project3D(p wave.Point3D) (int, int, float64) {
-	// Scale to fill the screen width
-	scaleX := float64(r.width) * scaleXFactor
-	scaleY := float64(r.height) * scaleYFactor
-
-	// Project X directly (horizontal position)
-	screenX := int(r.centerX + p.X*scaleX)
-
-	// Project Y and Z combined for vertical position
-	// Z (wave height) affects vertical position, Y (depth) adds perspective
-	screenY := int(r.centerY - p.Z*scaleY - p.Y*scaleY*perspectiveY)
+	x, y, depth := r.project(p)
+	return toScreen(x), toScreen(y), depth
+}
 
-	// Depth for z-ordering: elements with higher Y are "further back"
-	depth := p.Y + p.Z*depthZFactor
+// project converts a 3D point to fractional screen coordinates, in cells,
+// with depth for z-ordering.
+func (r *Renderer) project(p wave.Point3D) (float64, float64, float64) {
+	q, _ := r.view.MulPoint(p)
+	return q.X, q.Y, q.Z
+}
 
-	return screenX, screenY, depth
+// toScreen converts a projected coordinate to a cell index. Values far off
+// screen are clamped so later integer arithmetic cannot overflow, and NaN
+// is sent off screen.
+func toScreen(v float64) int {
+	if math.IsNaN(v) {
+		return -offscreenLimit
+	}
+	return int(math.Max(-offscreenLimit, math.Min(v, offscreenLimit)))
 }
 
-// RenderWave renders the particle-based ocean surface to the buffer.
+// RenderWave renders the particle-based ocean surface to the buffer, over
+// the sky if its layer is shown.
 func (r *Renderer) RenderWave(w *wave.Wave) {
-	gridDepth, gridWidth := w.Size()
 	minZ, maxZ := w.MinZ, w.MaxZ
 	zRange := maxZ - minZ
 	if zRange == 0 {
 		zRange = 1
 	}
+	r.renderSky()
+	r.refractSky(w)
+
+	switch {
+	case r.emoji:
+		r.renderSurfaceEmoji(w, minZ, zRange)
+	case r.mode == ModeBraille:
+		r.renderSurfaceDots(w, minZ, zRange)
+	case r.mode == ModeHalfBlock:
+		r.renderSurfaceBlocks(w, minZ, zRange)
+	case r.mode == ModeQuadrant:
+		r.renderSurfaceQuadrants(w, minZ, zRange)
+	default:
+		r.renderSurfaceText(w, minZ, zRange)
+	}
+
+	prev := r.SetLayer(LayerParticles)
+	defer r.SetLayer(prev)
+	r.renderSpray(w, minZ, zRange)
+}
 
-	// Render surface grid
+// renderSurfaceText draws the surface grid with shade characters along
+// its lines and in the middle of every quad.
+func (r *Renderer) renderSurfaceText(w *wave.Wave, minZ, zRange float64) {
+	gridDepth, gridWidth := w.Size()
 	for depth := 0; depth < gridDepth-1; depth++ {
 		for width := 0; width < gridWidth-1; width++ {
 			// Get four corners of the grid cell
@@ -128,6 +332,12 @@ func (r *Renderer) RenderWave(w *wave.Wave) {
 			avgDepth := (d1 + d2 + d3 + d4) / 4.0
 			depthFactor := float64(depth) / float64(gridDepth-1)
 
+			// Shadowed water sits lower on the gradient
+			shadow := (w.Shadows[depth][width] + w.Shadows[depth][width+1] +
+				w.Shadows[depth+1][width] + w.Shadows[depth+1][width+1]) / 4.0
+			normalizedZ *= 1 - shadow
+			depthFactor *= 1 - shadow
+
 			// Get style based on wave height
 			style := r.getStyle(normalizedZ, depthFactor)
 			char := r.getShadeChar(normalizedZ, depthFactor)
@@ -139,17 +349,9 @@ func (r *Renderer) RenderWave(w *wave.Wave) {
 			// Fill the quad center with a character
 			centerX := (x1 + x2 + x3 + x4) / 4
 			centerY := (y1 + y2 + y3 + y4) / 4
-			r.setCell(centerX, centerY, char, avgDepth, style)
+			r.SetCell(centerX, centerY, char, avgDepth, style)
 		}
 	}
-
-	// Render particles (spray/foam effect)
-	for _, particle := range w.Particles {
-		px, py, pd := r.project3D(particle.Pos)
-		// Particles use brighter colors and special characters
-		particleStyle := tcell.StyleDefault.Foreground(tcell.NewRGBColor(255, 255, 255))
-		r.setCell(px, py, '•', pd, particleStyle)
-	}
 }
 
 // getShadeChar returns an ASCII character based on depth and height for 3D effect.
@@ -157,7 +359,12 @@ func (r *Renderer) getShadeChar(normalizedZ float64, layerFactor float64) rune {
 	// Combine height and layer for shading
 	// Front layers (high layerFactor) and peaks (high normalizedZ) are brighter
 	shade := normalizedZ*0.7 + layerFactor*0.3
-	return mapToChar(shade, shadeChars)
+	return mapToChar(shade, r.ramp)
+}
+
+// ShadeChar returns the shade character for a normalized (0-1) brightness level.
+func (r *Renderer) ShadeChar(level float64) rune {
+	return mapToChar(level, r.ramp)
 }
 
 // getBlockChar returns a block character for filled vertical sections.
@@ -178,38 +385,53 @@ func mapToChar(value float64, chars []rune) rune {
 	return chars[idx]
 }
 
-type colorStop struct {
-	threshold float64
-	r, g, b   int32
-}
-
-var colorGradient = []colorStop{
-	{0.15, 30, 30, 30},    // Dark Grey
-	{0.30, 80, 80, 80},    // Dim Grey
-	{0.45, 120, 120, 120}, // Grey
-	{0.60, 160, 160, 160}, // Silver
-	{0.75, 200, 200, 200}, // Light Grey
-	{0.90, 230, 230, 230}, // Gainsboro
-	{2.00, 255, 255, 255}, // White
-}
-
 // getStyle returns a color style based on normalized height and layer position.
 func (r *Renderer) getStyle(normalizedZ float64, layerFactor float64) tcell.Style {
 	// Create a blue-cyan-white gradient for 3D depth
-	t := normalizedZ*0.6 + layerFactor*0.4
+	return r.GradientStyle(normalizedZ*0.6 + layerFactor*0.4)
+}
 
-	for _, stop := range colorGradient {
-		if t < stop.threshold {
-			return tcell.StyleDefault.Foreground(tcell.NewRGBColor(stop.r, stop.g, stop.b))
-		}
+// GradientStyle returns the foreground style for a normalized (0-1) position on the color gradient.
+func (r *Renderer) GradientStyle(t float64) tcell.Style {
+	i := int(t * float64(len(r.gradient)-1))
+	i = max(0, min(i, len(r.gradient)-1))
+	return tcell.StyleDefault.Foreground(r.gradient[i])
+}
+
+// SetTheme switches the color gradient used by GradientStyle. Themes that
+// set Emoji also draw the ocean surface with emoji, on screens that can
+// show them.
+func (r *Renderer) SetTheme(t theme.Theme) {
+	r.emoji = t.Emoji && (r.screen == nil || r.screen.CanDisplay(emojiWave, false))
+	if len(t.Gradient) > 0 {
+		r.gradient = gradientTable(t.Gradient)
+		r.invalidateAll()
 	}
-	// Fallback (should be covered by the last stop)
-	last := colorGradient[len(colorGradient)-1]
-	return tcell.StyleDefault.Foreground(tcell.NewRGBColor(last.r, last.g, last.b))
 }
 
 // drawShadedLine draws a line with varying shade based on position.
 func (r *Renderer) drawShadedLine(x1, y1, x2, y2 int, depth, normalizedZ, layerFactor float64, style tcell.Style) {
+	// Choose character based on line direction and shading
+	var lineChar rune
+	if abs(y2-y1) > abs(x2-x1) {
+		// More vertical - use vertical-ish characters
+		lineChar = '|'
+	} else {
+		// More horizontal - use shade character
+		lineChar = r.getShadeChar(normalizedZ, layerFactor)
+	}
+
+	r.DrawLine(x1, y1, x2, y2, lineChar, depth, style)
+}
+
+// DrawLine rasterizes a line between two screen positions using Bresenham's
+// algorithm. Lines are clipped to the screen first, so only visible cells are visited.
+func (r *Renderer) DrawLine(x1, y1, x2, y2 int, char rune, depth float64, style tcell.Style) {
+	x1, y1, x2, y2, visible := clipLine(x1, y1, x2, y2, r.width, r.height)
+	if !visible {
+		return
+	}
+
 	dx := abs(x2 - x1)
 	dy := abs(y2 - y1)
 	sx := 1
@@ -222,21 +444,11 @@ func (r *Renderer) drawShadedLine(x1, y1, x2, y2 int, depth, normalizedZ, layerF
 	}
 	err := dx - dy
 
-	// Choose character based on line direction and shading
-	var lineChar rune
-	if dy > dx {
-		// More vertical - use vertical-ish characters
-		lineChar = '|'
-	} else {
-		// More horizontal - use shade character
-		lineChar = r.getShadeChar(normalizedZ, layerFactor)
-	}
-
 	steps := 0
 	maxSteps := dx + dy + 1
 
 	for steps < maxSteps {
-		r.setCell(x1, y1, lineChar, depth, style)
+		r.SetCell(x1, y1, char, depth, style)
 
 		if x1 == x2 && y1 == y2 {
 			break
@@ -254,33 +466,136 @@ func (r *Renderer) drawShadedLine(x1, y1, x2, y2 int, depth, normalizedZ, layerF
 	}
 }
 
-// setCell sets a character at the given position with depth testing for proper z-ordering.
-func (r *Renderer) setCell(x, y int, char rune, depth float64, style tcell.Style) {
+// clipLine clips a line to a width by height screen with the Liang-Barsky
+// algorithm, reporting false if no part of it is visible. Clipped endpoints
+// are rounded to the nearest cell.
+func clipLine(x1, y1, x2, y2, width, height int) (int, int, int, int, bool) {
+	inside := func(x, y int) bool { return x >= 0 && x < width && y >= 0 && y < height }
+	if inside(x1, y1) && inside(x2, y2) {
+		return x1, y1, x2, y2, true
+	}
+
+	fx, fy := float64(x1), float64(y1)
+	dx, dy := float64(x2)-fx, float64(y2)-fy
+	t0, t1 := 0.0, 1.0
+	// Each edge bounds the parameter range: p*t <= q
+	edges := [4][2]float64{
+		{-dx, fx},
+		{dx, float64(width-1) - fx},
+		{-dy, fy},
+		{dy, float64(height-1) - fy},
+	}
+	for _, e := range edges {
+		p, q := e[0], e[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, 0, 0, false // Parallel to and outside this edge
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		if t0 > t1 {
+			return 0, 0, 0, 0, false
+		}
+	}
+
+	clamp := func(v float64, n int) int { return max(0, min(int(math.Round(v)), n-1)) }
+	return clamp(fx+t0*dx, width), clamp(fy+t0*dy, height),
+		clamp(fx+t1*dx, width), clamp(fy+t1*dy, height), true
+}
+
+// LineChar picks an ASCII character approximating the direction of a line segment.
+func LineChar(dx, dy int) rune {
+	ax, ay := abs(dx), abs(dy)
+	switch {
+	case ay > ax*2:
+		return '|'
+	case ax > ay*2:
+		return '-'
+	case (dx > 0) == (dy > 0):
+		return '\\'
+	default:
+		return '/'
+	}
+}
+
+// DrawCircle rasterizes a circle outline centered at (cx, cy) with the radius
+// given in rows. The horizontal radius is stretched by the cell aspect ratio so
+// the circle looks round on screen.
+func (r *Renderer) DrawCircle(cx, cy, radius float64, char rune, depth float64, style tcell.Style) {
+	if radius <= 0 {
+		r.SetCell(int(math.Round(cx)), int(math.Round(cy)), char, depth, style)
+		return
+	}
+	rx := radius * r.cellAspect
+	// Enough samples that neighbouring points never skip a cell
+	steps := int(math.Ceil(2*math.Pi*math.Max(rx, radius))) * 2
+	for i := 0; i < steps; i++ {
+		a := 2 * math.Pi * float64(i) / float64(steps)
+		x := int(math.Round(cx + math.Cos(a)*rx))
+		y := int(math.Round(cy + math.Sin(a)*radius))
+		r.SetCell(x, y, char, depth, style)
+	}
+}
+
+// SetCell sets a character at the given position with depth testing for proper z-ordering.
+func (r *Renderer) SetCell(x, y int, char rune, depth float64, style tcell.Style) {
 	if x < 0 || x >= r.width || y < 0 || y >= r.height {
 		return
 	}
-	// Depth test - draw if in front of existing content
-	if depth > r.buffer[y][x].depth {
-		r.buffer[y][x] = cell{
-			char:  char,
-			style: style,
-			depth: depth,
-			set:   true,
-		}
+	r.place(x, y, cell{char: char, style: style, depth: depth, set: true})
+}
+
+// place puts c on the current layer at (x, y), if it is in front of what
+// the layer has there, and composites the cells that changed.
+func (r *Renderer) place(x, y int, c cell) {
+	row := r.planes[r.layer][y]
+	if c.depth <= row[x].depth {
+		return
 	}
+	// A wide character must also be in front in the cell it spills into
+	wide := r.isWide(c.char)
+	if wide && (x+1 >= r.width || c.depth <= row[x+1].depth) {
+		return
+	}
+	split := r.split(row, x)
+	if wide {
+		split = r.split(row, x+1) || split
+		row[x+1] = cell{style: c.style, depth: c.depth, set: true, composited: c.composited}
+	}
+	row[x] = c
+	if wide || split || r.widePart(r.buffer[y][x]) {
+		r.composeSpan(x, x+1, y)
+		return
+	}
+	r.buffer[y][x] = r.composed(x, y)
 }
 
 // Flush renders the internal buffer to the actual screen and displays it.
 func (r *Renderer) Flush() {
+	if r.screen == nil {
+		return // Viewports are shown by blitting them onto a screen renderer
+	}
 	for y := 0; y < r.height; y++ {
 		for x := 0; x < r.width; x++ {
 			c := r.buffer[y][x]
-			if c.set {
-				r.screen.SetContent(x, y, c.char, nil, c.style)
+			if !c.set || c.char == 0 {
+				continue // Unset, or covered by a wide character
+			}
+			style := r.composite(c.style)
+			if r.palette != nil {
+				style = r.quantize(style, x, y)
 			}
+			r.screen.SetContent(x, y, c.char, nil, style)
 		}
 	}
 	r.screen.Show()
+	r.frame++
 }
 
 // abs returns the absolute value of an integer.
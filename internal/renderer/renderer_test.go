@@ -0,0 +1,732 @@
+package renderer
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// newTestRenderer returns a renderer drawing to a simulated screen of the given size.
+func newTestRenderer(t *testing.T, width, height int) (*Renderer, tcell.SimulationScreen) {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	t.Cleanup(screen.Fini)
+	screen.SetSize(width, height)
+
+	r := NewRenderer(screen)
+	r.Clear()
+	return r, screen
+}
+
+// charAt returns the buffered character at (x, y), or 0 if the cell is empty.
+func charAt(r *Renderer, x, y int) rune {
+	if c := r.buffer[y][x]; c.set {
+		return c.char
+	}
+	return 0
+}
+
+// setCells returns the positions of every drawn cell.
+func setCells(r *Renderer) map[[2]int]rune {
+	cells := make(map[[2]int]rune)
+	for y := range r.buffer {
+		for x, c := range r.buffer[y] {
+			if c.set {
+				cells[[2]int{x, y}] = c.char
+			}
+		}
+	}
+	return cells
+}
+
+func TestSetCellDepthTest(t *testing.T) {
+	r, _ := newTestRenderer(t, 10, 5)
+
+	r.SetCell(2, 2, 'a', 1, tcell.StyleDefault)
+	r.SetCell(2, 2, 'b', 2, tcell.StyleDefault)
+	if got := charAt(r, 2, 2); got != 'b' {
+		t.Errorf("nearer cell: got %q, want 'b'", got)
+	}
+
+	r.SetCell(2, 2, 'c', 0.5, tcell.StyleDefault)
+	if got := charAt(r, 2, 2); got != 'b' {
+		t.Errorf("farther cell overwrote nearer one: got %q, want 'b'", got)
+	}
+
+	// Equal depth keeps what was drawn first
+	r.SetCell(2, 2, 'd', 2, tcell.StyleDefault)
+	if got := charAt(r, 2, 2); got != 'b' {
+		t.Errorf("equal depth: got %q, want 'b'", got)
+	}
+}
+
+func TestClearResetsDepth(t *testing.T) {
+	r, _ := newTestRenderer(t, 4, 4)
+	r.SetCell(1, 1, 'a', 100, tcell.StyleDefault)
+	r.Clear()
+
+	if got := charAt(r, 1, 1); got != 0 {
+		t.Fatalf("cell survived Clear: %q", got)
+	}
+	r.SetCell(1, 1, 'b', -100, tcell.StyleDefault)
+	if got := charAt(r, 1, 1); got != 'b' {
+		t.Errorf("far cell after Clear: got %q, want 'b'", got)
+	}
+}
+
+func TestSetCellClipping(t *testing.T) {
+	r, _ := newTestRenderer(t, 8, 4)
+	for _, p := range [][2]int{{-1, 0}, {0, -1}, {8, 0}, {0, 4}, {-100, 100}, {1 << 30, 1 << 30}} {
+		r.SetCell(p[0], p[1], 'x', 0, tcell.StyleDefault)
+	}
+	if cells := setCells(r); len(cells) != 0 {
+		t.Errorf("off-screen cells were drawn: %v", cells)
+	}
+
+	// The last row and column are still inside
+	r.SetCell(7, 3, 'x', 0, tcell.StyleDefault)
+	if got := charAt(r, 7, 3); got != 'x' {
+		t.Errorf("corner cell: got %q, want 'x'", got)
+	}
+}
+
+func TestResize(t *testing.T) {
+	r, screen := newTestRenderer(t, 10, 5)
+	r.SetCell(9, 4, 'x', 0, tcell.StyleDefault)
+
+	screen.SetSize(20, 8)
+	r.Resize()
+	r.Clear()
+	if w, h := r.Size(); w != 20 || h != 8 {
+		t.Fatalf("Size after growing = %dx%d, want 20x8", w, h)
+	}
+	r.SetCell(19, 7, 'y', 0, tcell.StyleDefault)
+	if got := charAt(r, 19, 7); got != 'y' {
+		t.Errorf("new corner: got %q, want 'y'", got)
+	}
+
+	screen.SetSize(3, 2)
+	r.Resize()
+	r.Clear()
+	r.SetCell(5, 5, 'z', 0, tcell.StyleDefault) // Outside after shrinking
+	r.DrawLine(0, 0, 19, 7, '*', 0, tcell.StyleDefault)
+	if w, h := r.Size(); w != 3 || h != 2 {
+		t.Fatalf("Size after shrinking = %dx%d, want 3x2", w, h)
+	}
+	for p := range setCells(r) {
+		if p[0] >= 3 || p[1] >= 2 {
+			t.Errorf("cell %v drawn outside the shrunk screen", p)
+		}
+	}
+}
+
+func TestFlush(t *testing.T) {
+	r, screen := newTestRenderer(t, 6, 3)
+	r.SetCell(1, 2, 'q', 0, tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 100, 50)))
+	r.Flush()
+
+	cells, w, _ := screen.GetContents()
+	got := cells[2*w+1]
+	if len(got.Runes) != 1 || got.Runes[0] != 'q' {
+		t.Fatalf("screen cell = %q, want \"q\"", got.Runes)
+	}
+	// The simulation screen has 256 colors, so true color is quantized
+	if fg, _, _ := got.Style.Decompose(); fg&tcell.ColorIsRGB != 0 {
+		t.Errorf("foreground %v was not mapped onto the palette", fg)
+	}
+}
+
+func TestTemporalDither(t *testing.T) {
+	r, screen := newTestRenderer(t, 1, 1)
+	// Halfway between the greys 138 and 148 of the 256 color ramp
+	grey := tcell.StyleDefault.Foreground(tcell.NewRGBColor(143, 143, 143))
+	shown := func() map[tcell.Color]int {
+		counts := make(map[tcell.Color]int)
+		for range 16 {
+			r.SetCell(0, 0, '#', 0, grey)
+			r.Flush()
+			cells, _, _ := screen.GetContents()
+			fg, _, _ := cells[0].Style.Decompose()
+			counts[fg]++
+		}
+		return counts
+	}
+
+	counts := shown()
+	if len(counts) != 2 || counts[tcell.PaletteColor(245)] < 4 || counts[tcell.PaletteColor(246)] < 4 {
+		t.Errorf("frames showed %v, want greys 245 and 246 in about equal shares", counts)
+	}
+	r.SetTemporalDither(false)
+	if counts := shown(); len(counts) != 1 {
+		t.Errorf("without temporal dithering frames showed %v, want one color", counts)
+	}
+}
+
+func TestQuantizeCacheBounded(t *testing.T) {
+	r, _ := newTestRenderer(t, 4, 4)
+	// A smooth gradient over many frames, as the plasma shows
+	for frame := range 64 {
+		for i := range 4096 {
+			c := tcell.NewRGBColor(int32(i&255), int32(i>>4), int32(frame*4))
+			r.quantizeColor(c, i&3, i>>2&3)
+		}
+		r.frame++
+	}
+	if n := len(r.quantized); n > maxQuantized {
+		t.Errorf("cached %d quantized colors, want at most %d", n, maxQuantized)
+	}
+}
+
+func TestSetColors(t *testing.T) {
+	r, screen := newTestRenderer(t, 1, 1)
+	teal := tcell.StyleDefault.Foreground(tcell.NewRGBColor(0, 180, 170))
+	shown := func() tcell.Color {
+		r.SetCell(0, 0, '#', 0, teal)
+		r.Flush()
+		cells, _, _ := screen.GetContents()
+		fg, _, _ := cells[0].Style.Decompose()
+		return fg
+	}
+
+	for _, colors := range []int{16, 8} {
+		r.SetColors(colors)
+		if fg := shown(); fg&tcell.ColorIsRGB != 0 || fg < tcell.ColorValid || int(fg-tcell.ColorValid) >= colors {
+			t.Errorf("with %d colors showed %v, want one of the first %d palette colors", colors, fg, colors)
+		}
+	}
+	r.SetColors(1 << 24)
+	if fg := shown(); fg != tcell.NewRGBColor(0, 180, 170) {
+		t.Errorf("with true color showed %v, want the color as drawn", fg)
+	}
+}
+
+func TestDrawLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		x1, y1, x2, y2 int
+		want           int // Number of cells covered
+	}{
+		{"horizontal", 1, 2, 8, 2, 8},
+		{"vertical", 3, 0, 3, 5, 6},
+		{"diagonal", 0, 0, 5, 5, 6},
+		{"reversed", 8, 5, 1, 2, 8},
+		{"point", 4, 4, 4, 4, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestRenderer(t, 10, 6)
+			r.DrawLine(tt.x1, tt.y1, tt.x2, tt.y2, '*', 0, tcell.StyleDefault)
+			cells := setCells(r)
+
+			if len(cells) != tt.want {
+				t.Errorf("covered %d cells, want %d", len(cells), tt.want)
+			}
+			for _, p := range [][2]int{{tt.x1, tt.y1}, {tt.x2, tt.y2}} {
+				if _, ok := cells[p]; !ok {
+					t.Errorf("endpoint %v not drawn", p)
+				}
+			}
+			// Every cell touches another one, so the line has no gaps
+			for p := range cells {
+				if len(cells) == 1 {
+					break
+				}
+				connected := false
+				for dy := -1; dy <= 1 && !connected; dy++ {
+					for dx := -1; dx <= 1; dx++ {
+						if _, ok := cells[[2]int{p[0] + dx, p[1] + dy}]; ok && (dx != 0 || dy != 0) {
+							connected = true
+							break
+						}
+					}
+				}
+				if !connected {
+					t.Errorf("cell %v is isolated", p)
+				}
+			}
+		})
+	}
+}
+
+func TestDrawLineClipped(t *testing.T) {
+	r, _ := newTestRenderer(t, 10, 4)
+	r.DrawLine(-20, 1, 30, 1, '-', 0, tcell.StyleDefault)
+
+	cells := setCells(r)
+	if len(cells) != 10 {
+		t.Errorf("clipped line covered %d cells, want 10", len(cells))
+	}
+	for x := 0; x < 10; x++ {
+		if cells[[2]int{x, 1}] != '-' {
+			t.Errorf("cell (%d, 1) missing", x)
+		}
+	}
+}
+
+func TestDotLine(t *testing.T) {
+	r, _ := newTestRenderer(t, 3, 2)
+	r.dotLine(-1, 0.1, 5, 0.1, 0, tcell.StyleDefault)
+
+	// The top row of dots in every cell of the first row, clipped
+	cells := setCells(r)
+	if len(cells) != 3 {
+		t.Errorf("dot line covered %d cells, want 3", len(cells))
+	}
+	for x := 0; x < 3; x++ {
+		if got := cells[[2]int{x, 0}]; got != '⠉' {
+			t.Errorf("cell (%d, 0) is %q, want '⠉'", x, got)
+		}
+	}
+}
+
+func TestFillTriangle(t *testing.T) {
+	r, _ := newTestRenderer(t, 4, 2)
+	r.pixels, r.pixelCols, r.pixelRows = make([]pixel, 4*4), 4, 4
+	for i := range r.pixels {
+		r.pixels[i].depth = -math.MaxFloat64
+	}
+	// The lower left half of the pixels with the diagonal, and a farther
+	// triangle over all
+	r.fillTriangle(vertex{x: 0, y: 0, depth: 1}, vertex{x: 4, y: 4, depth: 1}, vertex{x: 0, y: 4, depth: 1, shade: 1})
+	r.fillTriangle(vertex{x: -10, y: -10}, vertex{x: 30, y: -10}, vertex{x: -10, y: 30})
+
+	for y := range 4 {
+		for x := range 4 {
+			p := r.pixels[y*4+x]
+			if near := x <= y; !p.set || (p.depth == 1) != near {
+				t.Errorf("pixel (%d, %d) set %v at depth %g, want the nearer triangle %v", x, y, p.set, p.depth, near)
+			}
+		}
+	}
+}
+
+func TestSplitQuadrants(t *testing.T) {
+	shades := func(s ...float64) (cell [4]pixel) {
+		for i := range cell {
+			cell[i] = pixel{shade: s[i], set: true}
+		}
+		return cell
+	}
+	tests := []struct {
+		cell   [4]pixel
+		fg, bg float64
+		char   rune
+	}{
+		{shades(0.5, 0.5, 0.5, 0.5), 0.5, 0.5, '█'},
+		{shades(1, 1, 0, 0), 1, 0, '▀'},
+		{shades(0, 1, 1, 0), 1, 0, '▞'},
+		{shades(0.2, 0.9, 0.3, 0.2), 0.9, 0.7 / 3, '▝'},
+	}
+	for _, tt := range tests {
+		fg, bg, split := splitQuadrants(tt.cell)
+		if quadrants[split] != tt.char || math.Abs(fg-tt.fg) > 1e-9 || math.Abs(bg-tt.bg) > 1e-9 {
+			t.Errorf("splitQuadrants(%v) = %q in %g on %g, want %q in %g on %g", tt.cell, quadrants[split], fg, bg, tt.char, tt.fg, tt.bg)
+		}
+	}
+}
+
+func TestSetCellWide(t *testing.T) {
+	r, _ := newTestRenderer(t, 4, 1)
+	r.SetCell(0, 0, '🌊', 1, tcell.StyleDefault)
+	if c := r.buffer[0][1]; !c.set || c.char != 0 {
+		t.Fatalf("cell after a wide character = %q (set %v), want it covered", c.char, c.set)
+	}
+	// A wide character does not fit in the last cell
+	r.SetCell(3, 0, '🌊', 1, tcell.StyleDefault)
+	if r.buffer[0][3].set {
+		t.Error("wide character drawn in the last cell")
+	}
+	// Drawing over the right half leaves a space in the left one
+	r.SetCell(1, 0, 'x', 2, tcell.StyleDefault)
+	if got := string([]rune{r.buffer[0][0].char, r.buffer[0][1].char}); got != " x" {
+		t.Errorf("wide character drawn over = %q, want %q", got, " x")
+	}
+}
+
+func TestLayerCompositing(t *testing.T) {
+	r, _ := newTestRenderer(t, 3, 1)
+	rgb := func(x int) ([3]int32, [3]int32) {
+		fg, bg, _ := r.buffer[0][x].style.Decompose()
+		fr, fgreen, fb := fg.RGB()
+		br, bgreen, bb := bg.RGB()
+		return [3]int32{fr, fgreen, fb}, [3]int32{br, bgreen, bb}
+	}
+	red := tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 0, 0))
+	green := tcell.StyleDefault.Foreground(tcell.NewRGBColor(0, 200, 0))
+	r.SetLinearBlending(false)
+	r.SetLayerStyle(LayerScene, LayerStyle{Opacity: 0.5, Tint: [3]float64{1, 1, 1}})
+
+	// A translucent cell covers its own layer's cell behind it instead of
+	// blending with it
+	r.SetCell(0, 0, 'a', 1, red)
+	r.SetCell(0, 0, 'b', 2, green)
+	if fg, _ := rgb(0); charAt(r, 0, 0) != 'b' || fg != [3]int32{0, 100, 0} {
+		t.Errorf("translucent cell over its own layer: got %q in %v, want 'b' in [0 100 0]", charAt(r, 0, 0), fg)
+	}
+
+	// It blends with the layers below, whatever order they are drawn in,
+	// and shows the sky's background through its own
+	r.SetLayer(LayerSky)
+	r.SetLayerStyle(LayerSky, DefaultLayerStyle())
+	r.SetCell(0, 0, ' ', -1, tcell.StyleDefault.Foreground(tcell.NewRGBColor(0, 0, 200)).Background(tcell.NewRGBColor(0, 0, 80)))
+	if fg, bg := rgb(0); fg != [3]int32{0, 100, 100} || bg != [3]int32{0, 0, 80} {
+		t.Errorf("translucent cell over the sky: got %v on %v, want [0 100 100] on [0 0 80]", fg, bg)
+	}
+
+	// A hidden layer shows nothing
+	r.SetLayerStyle(LayerSky, LayerStyle{Tint: [3]float64{1, 1, 1}})
+	r.SetCell(1, 0, 'c', 0, red)
+	if charAt(r, 1, 0) != 0 {
+		t.Errorf("hidden layer: got %q, want nothing", charAt(r, 1, 0))
+	}
+
+	// A wide character half covered by a layer in front leaves a space
+	r.SetLayer(LayerScene)
+	r.SetCell(1, 0, '🌊', 1, tcell.StyleDefault)
+	r.SetLayer(LayerOverlay)
+	r.SetCell(2, 0, 'x', 2, tcell.StyleDefault)
+	if got := string([]rune{charAt(r, 1, 0), charAt(r, 2, 0)}); got != " x" {
+		t.Errorf("wide character covered by another layer = %q, want %q", got, " x")
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	for _, name := range ToneMapNames() {
+		tm, err := ParseToneMap(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tm.Apply(0); got != 0 {
+			t.Errorf("%s maps no light to %g, want 0", name, got)
+		}
+		// Brightness only ever brightens, up to white and no further
+		prev := 0.0
+		for v := 0.05; v < 100; v *= 1.5 {
+			got := tm.Apply(v)
+			if got < prev || got > 1 {
+				t.Errorf("%s maps %g to %g after %g", name, v, got, prev)
+			}
+			prev = got
+		}
+	}
+	if got := ToneMapReinhard.Apply(1); got != 0.5 {
+		t.Errorf("reinhard maps 1 to %g, want 0.5", got)
+	}
+	if _, err := ParseToneMap("filmic"); err == nil {
+		t.Error("ParseToneMap accepted an unknown name")
+	}
+}
+
+func TestSprayToneMapped(t *testing.T) {
+	// Drops in one cell add up and roll off to white under the tone map
+	fg := func(drops int, tm ToneMap) int32 {
+		r, _ := newTestRenderer(t, 40, 20)
+		r.SetToneMap(tm)
+		cfg := wave.DefaultConfig()
+		cfg.GridWidth, cfg.GridDepth = 10, 10
+		w := wave.NewWave(cfg)
+		w.Update(0)
+		for range drops {
+			w.Foam.Emit(func(p *particle.Particle, _ *rand.Rand) { p.Z, p.Life = 1, 1 })
+		}
+		r.SetLayer(LayerParticles)
+		r.renderSpray(w, w.MinZ, max(w.MaxZ-w.MinZ, 1))
+		x, y, _ := r.project3D(wave.Point3D{Z: 1})
+		c, _, _ := r.planes[LayerParticles][y][x].style.Decompose()
+		red, _, _ := c.RGB()
+		return red
+	}
+	one, many := fg(1, ToneMapReinhard), fg(6, ToneMapReinhard)
+	if many <= one || many > 255 {
+		t.Errorf("six drops drawn at red %d, one at %d", many, one)
+	}
+	if got := fg(6, ToneMapClip); got != 255 {
+		t.Errorf("clipped drops drawn at red %d, want 255", got)
+	}
+}
+
+func TestShadeCharAt(t *testing.T) {
+	r, _ := newTestRenderer(t, 8, 8)
+	// Halfway between the fourth and fifth shade characters
+	level := 4.0 / float64(len(shadeChars)-1)
+	counts := func() map[rune]int {
+		counts := make(map[rune]int)
+		for y := range 8 {
+			for x := range 8 {
+				counts[r.ShadeCharAt(x, y, level)]++
+			}
+		}
+		return counts
+	}
+
+	if got := counts(); len(got) != 1 || got[r.ShadeChar(level)] != 64 {
+		t.Errorf("undithered field shows %v, want only %q", got, r.ShadeChar(level))
+	}
+	for _, d := range []ShadeDither{ShadeDitherBayer, ShadeDitherDiffusion} {
+		r.Clear()
+		r.SetShadeDither(d)
+		got := counts()
+		if n := got[shadeChars[3]]; len(got) != 2 || n < 24 || n > 40 {
+			t.Errorf("%v dithered field shows %v, want %q and %q in about equal shares", d, got, shadeChars[3], shadeChars[4])
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, name := range ModeNames() {
+		m, err := ParseMode(name)
+		if err != nil || m.String() != name {
+			t.Errorf("ParseMode(%q) = %v, %v", name, m, err)
+		}
+	}
+	if _, err := ParseMode("ascii"); err == nil {
+		t.Error("ParseMode accepted an unknown mode")
+	}
+}
+
+func TestFillRect(t *testing.T) {
+	red := tcell.NewRGBColor(255, 0, 0)
+
+	t.Run("whole cells", func(t *testing.T) {
+		r, _ := newTestRenderer(t, 10, 6)
+		r.FillRect(1, 1, 3, 2, red, 0)
+		cells := setCells(r)
+		if len(cells) != 6 {
+			t.Fatalf("filled %d cells, want 6", len(cells))
+		}
+		for y := 1; y < 3; y++ {
+			for x := 1; x < 4; x++ {
+				if got := cells[[2]int{x, y}]; got != '█' {
+					t.Errorf("cell (%d, %d) = %q, want full block", x, y, got)
+				}
+			}
+		}
+	})
+
+	t.Run("partial width", func(t *testing.T) {
+		r, _ := newTestRenderer(t, 10, 6)
+		r.FillRect(0, 0, 2.5, 1, red, 0)
+		if got := charAt(r, 2, 0); got != '▌' {
+			t.Errorf("partial cell = %q, want left half block", got)
+		}
+		if got := charAt(r, 3, 0); got != 0 {
+			t.Errorf("cell past the edge = %q, want empty", got)
+		}
+	})
+
+	t.Run("partial height", func(t *testing.T) {
+		r, _ := newTestRenderer(t, 10, 6)
+		r.FillRect(0, 0.75, 1, 0.25, red, 0)
+		if got := charAt(r, 0, 0); got != '▂' {
+			t.Errorf("partial cell = %q, want lower quarter block", got)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		r, _ := newTestRenderer(t, 10, 6)
+		r.FillRect(2, 2, 0, 3, red, 0)
+		r.FillRect(2, 2, 3, -1, red, 0)
+		if cells := setCells(r); len(cells) != 0 {
+			t.Errorf("empty rectangles drew %v", cells)
+		}
+	})
+
+	t.Run("clipped", func(t *testing.T) {
+		r, _ := newTestRenderer(t, 4, 3)
+		r.FillRect(-2, -2, 10, 10, red, 0)
+		if cells := setCells(r); len(cells) != 12 {
+			t.Errorf("clipped fill covered %d cells, want 12", len(cells))
+		}
+	})
+}
+
+func TestCached(t *testing.T) {
+	r, _ := newTestRenderer(t, 10, 5)
+	draws := 0
+	draw := func() {
+		draws++
+		r.SetCell(1, 1, 'a', 1, tcell.StyleDefault)
+		r.SetCell(2, 1, 'b', 1, tcell.StyleDefault)
+	}
+
+	r.Cached("panel", draw)
+	r.Clear()
+	r.Cached("panel", draw)
+	if draws != 1 {
+		t.Errorf("draw called %d times, want 1", draws)
+	}
+	if got := setCells(r); len(got) != 2 || got[[2]int{1, 1}] != 'a' || got[[2]int{2, 1}] != 'b' {
+		t.Errorf("replayed cells: got %v", got)
+	}
+
+	// Replayed cells still lose the depth test against nearer content
+	r.Clear()
+	r.SetCell(1, 1, 'z', 5, tcell.StyleDefault)
+	r.Cached("panel", draw)
+	if got := charAt(r, 1, 1); got != 'z' {
+		t.Errorf("replayed cell overwrote nearer one: got %q, want 'z'", got)
+	}
+
+	r.Invalidate("panel")
+	r.Cached("panel", draw)
+	if draws != 2 {
+		t.Errorf("after Invalidate: draw called %d times, want 2", draws)
+	}
+
+	r.SetLayer(LayerOverlay)
+	r.Cached("overlay", draw)
+	r.InvalidateLayer(LayerOverlay)
+	r.Cached("overlay", draw)
+	r.Cached("panel", draw)
+	if draws != 4 {
+		t.Errorf("after InvalidateLayer: draw called %d times, want 4", draws)
+	}
+
+	r.SetCellAspect(3)
+	r.Cached("panel", draw)
+	if draws != 5 {
+		t.Errorf("after SetCellAspect: draw called %d times, want 5", draws)
+	}
+
+	r.SetCaching(false)
+	r.Cached("panel", draw)
+	r.Cached("panel", draw)
+	if draws != 7 {
+		t.Errorf("without caching: draw called %d times, want 7", draws)
+	}
+}
+
+func TestCachedMatchesDrawing(t *testing.T) {
+	// Overdrawn cells of a translucent layer over content below it, and a
+	// cell drawn at negative depth
+	frame := func(r *Renderer) [][]cell {
+		r.Clear()
+		r.SetLayer(LayerScene)
+		r.SetCell(1, 0, 'w', 0, tcell.StyleDefault.Foreground(tcell.NewRGBColor(0, 0, 200)))
+		r.SetLayer(LayerOverlay)
+		r.Cached("panel", func() {
+			for x := range 3 {
+				r.SetCell(x, 0, '-', 1, tcell.StyleDefault.Foreground(tcell.ColorRed))
+				r.SetCell(x, 0, 'a'+rune(x), 2, tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 200, 0)))
+			}
+			r.SetCell(3, 0, '.', -1, tcell.StyleDefault)
+		})
+		return r.buffer
+	}
+	cached, _ := newTestRenderer(t, 4, 1)
+	drawn, _ := newTestRenderer(t, 4, 1)
+	drawn.SetCaching(false)
+	for _, r := range []*Renderer{cached, drawn} {
+		r.SetLayerStyle(LayerOverlay, LayerStyle{Opacity: 0.5, Tint: [3]float64{1, 1, 1}})
+	}
+	frame(cached)
+	for range 2 {
+		if got, want := frame(cached), frame(drawn); !reflect.DeepEqual(got, want) {
+			t.Errorf("cached cells differ from drawn ones:\ngot  %v\nwant %v", got, want)
+		}
+	}
+}
+
+func TestMix(t *testing.T) {
+	red := tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 0, 0))
+	blue := tcell.StyleDefault.Foreground(tcell.NewRGBColor(0, 0, 200))
+	from, to := NewViewport(3, 1), NewViewport(3, 1)
+	from.Clear()
+	to.Clear()
+	from.SetCell(0, 0, 'a', 0, red)
+	from.SetCell(1, 0, 'a', 0, red)
+	to.SetCell(1, 0, 'b', 0, blue)
+	to.SetCell(2, 0, 'b', 0, blue)
+
+	fg := func(r *Renderer, x int) [3]int32 {
+		c, _, _ := r.buffer[0][x].style.Decompose()
+		red, green, blue := c.RGB()
+		return [3]int32{red, green, blue}
+	}
+	r, _ := newTestRenderer(t, 3, 1)
+	type cell struct {
+		char rune
+		fg   [3]int32
+	}
+	check := func(want []cell) {
+		t.Helper()
+		for x, w := range want {
+			if got := charAt(r, x, 0); got != w.char || fg(r, x) != w.fg {
+				t.Errorf("cell %d: got %q in %v, want %q in %v", x, got, fg(r, x), w.char, w.fg)
+			}
+		}
+	}
+	r.Mix(from, to, 0, func(int, int) float64 { return 0.25 })
+	// A quarter of the way in linear light keeps more of the light of
+	// both sides than of raw sRGB values
+	check([]cell{{'a', [3]int32{176, 0, 0}}, {'a', [3]int32{176, 0, 106}}, {'b', [3]int32{0, 0, 106}}})
+	r.SetLinearBlending(false)
+	r.Clear()
+	r.Mix(from, to, 0, func(int, int) float64 { return 0.25 })
+	check([]cell{{'a', [3]int32{150, 0, 0}}, {'a', [3]int32{150, 0, 50}}, {'b', [3]int32{0, 0, 50}}})
+
+	// Cells turned all the way show only the new scene
+	r.Clear()
+	r.Mix(from, to, 0, func(int, int) float64 { return 1 })
+	if got := setCells(r); len(got) != 2 || got[[2]int{1, 0}] != 'b' || got[[2]int{2, 0}] != 'b' {
+		t.Errorf("mixed cells: got %v", got)
+	}
+}
+
+func TestUnproject(t *testing.T) {
+	r, _ := newTestRenderer(t, 120, 40)
+	for _, c := range []Camera{DefaultCamera(), {Yaw: 0.7, Tilt: 1.5, Zoom: 2}} {
+		r.SetCamera(c)
+		for _, p := range []wave.Point3D{{X: 0, Y: 0}, {X: 0.5, Y: -0.3}, {X: -0.8, Y: 0.6}} {
+			x, y, _ := r.project3D(p)
+			gx, gy := r.Unproject(x, y)
+			// Within the cell the point falls in
+			if math.Abs(gx-p.X) > 0.1 || math.Abs(gy-p.Y) > 0.2 {
+				t.Errorf("camera %+v: %v drawn at %d,%d unprojects to %.2f,%.2f", c, p, x, y, gx, gy)
+			}
+		}
+	}
+}
+
+func TestRefractSky(t *testing.T) {
+	r, _ := newTestRenderer(t, 60, 30)
+	r.SetLayerStyle(LayerSky, LayerStyle{Opacity: 1, Tint: [3]float64{1, 1, 1}})
+	cfg := wave.DefaultConfig()
+	cfg.GridWidth, cfg.GridDepth = 20, 20
+	w := wave.NewWave(cfg)
+	refracted := func(height func(x, y float64) float64) int {
+		for i, row := range w.GridPoints {
+			for j := range row {
+				x, y := float64(j)/10-1, float64(i)/10-1
+				row[j] = wave.Point3D{X: x, Y: y, Z: height(x, y)}
+			}
+		}
+		r.Clear()
+		r.renderSky()
+		sky := r.snapshot(LayerSky)
+		r.refractSky(w)
+		moved := 0
+		for y, row := range r.planes[LayerSky] {
+			for x, c := range row {
+				if c != sky[y][x] {
+					moved++
+				}
+			}
+		}
+		return moved
+	}
+	if n := refracted(func(x, y float64) float64 { return 0 }); n != 0 {
+		t.Errorf("flat water moved %d sky cells", n)
+	}
+	if n := refracted(func(x, y float64) float64 { return 0.3 * math.Sin(6*y) }); n == 0 {
+		t.Error("rolling water moved no sky cells")
+	}
+}
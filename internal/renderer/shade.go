@@ -0,0 +1,122 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/color"
+)
+
+// DefaultShadeRamp is the shade characters brightness is mapped to unless
+// SetShadeRamp picks others, from darkest to brightest.
+const DefaultShadeRamp = "·:÷≈≠≡∫#▓█"
+
+// SetShadeRamp sets the characters ShadeChar and ShadeCharAt map brightness
+// to, from darkest to brightest, such as " .:-=+*#%@". A ramp of fewer than
+// two characters restores the default.
+func (r *Renderer) SetShadeRamp(ramp string) {
+	chars := []rune(ramp)
+	if len(chars) < 2 {
+		chars = shadeChars
+	}
+	if !slices.Equal(chars, r.ramp) {
+		r.ramp = chars
+		r.invalidateAll()
+	}
+}
+
+// ShadeRamp returns the shade characters from darkest to brightest.
+func (r *Renderer) ShadeRamp() string {
+	return string(r.ramp)
+}
+
+// ShadeDither is how ShadeCharAt spreads brightness between neighbouring
+// cells, so fields of brightness keep detail finer than the steps between
+// shade characters.
+type ShadeDither int
+
+const (
+	// ShadeDitherOff maps each cell to its nearest shade character
+	ShadeDitherOff ShadeDither = iota
+	// ShadeDitherBayer nudges cells up or down a step by an ordered 4x4
+	// pattern, which stays put as long as the field does
+	ShadeDitherBayer
+	// ShadeDitherDiffusion carries each cell's rounding error over to the
+	// cells right of and below it, Floyd-Steinberg style, for the finest
+	// detail but patterns that shift every frame the field moves
+	ShadeDitherDiffusion
+)
+
+// shadeDitherNames are the names of the shade ditherings, in order.
+var shadeDitherNames = []string{"off", "bayer", "floyd-steinberg"}
+
+// ShadeDitherNames returns the names of the shade ditherings, the default
+// first.
+func ShadeDitherNames() []string {
+	return slices.Clone(shadeDitherNames)
+}
+
+// ParseShadeDither returns the shade dithering with the given name.
+func ParseShadeDither(name string) (ShadeDither, error) {
+	i := slices.Index(shadeDitherNames, name)
+	if i < 0 {
+		return ShadeDitherOff, fmt.Errorf("unknown shade dithering %q (available: %s)", name, strings.Join(shadeDitherNames, ", "))
+	}
+	return ShadeDither(i), nil
+}
+
+// String returns the name of the shade dithering.
+func (d ShadeDither) String() string {
+	if d < 0 || int(d) >= len(shadeDitherNames) {
+		return fmt.Sprintf("ShadeDither(%d)", int(d))
+	}
+	return shadeDitherNames[d]
+}
+
+// SetShadeDither selects how ShadeCharAt dithers brightness.
+func (r *Renderer) SetShadeDither(d ShadeDither) {
+	r.shadeDither = d
+}
+
+// ShadeCharAt returns the shade character for a normalized (0-1)
+// brightness level of the cell at (x, y) of a field of brightness, dithered
+// as set by SetShadeDither. Undithered it is ShadeChar. Error diffusion
+// expects the cells of a frame in rows from the top, left to right, as
+// scenes draw their fields.
+func (r *Renderer) ShadeCharAt(x, y int, level float64) rune {
+	// In steps between characters, with step i standing for character i;
+	// rounding down the level, as ShadeChar does, is rounding this off
+	steps := float64(len(r.ramp) - 1)
+	v := level*steps - 0.5
+	var i float64
+	switch r.shadeDither {
+	case ShadeDitherBayer:
+		i = math.Floor(v + color.Bayer(x, y) + 0.5)
+	case ShadeDitherDiffusion:
+		if x < 0 || x >= r.width || y < 0 || y >= r.height {
+			return r.ShadeChar(level)
+		}
+		v = max(0, min(v+r.shadeError[y][x], steps))
+		i = math.Round(v)
+		r.spreadError(x, y, v-i)
+	default:
+		return r.ShadeChar(level)
+	}
+	return r.ramp[int(max(0, min(i, steps)))]
+}
+
+// spreadError carries the rounding error of a cell over to the cells
+// after it in the Floyd-Steinberg proportions.
+func (r *Renderer) spreadError(x, y int, err float64) {
+	add := func(x, y int, share float64) {
+		if x >= 0 && x < r.width && y < r.height {
+			r.shadeError[y][x] += err * share
+		}
+	}
+	add(x+1, y, 7.0/16)
+	add(x-1, y+1, 3.0/16)
+	add(x, y+1, 5.0/16)
+	add(x+1, y+1, 1.0/16)
+}
@@ -0,0 +1,109 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/vec"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// skyDepth puts the sky behind everything else drawn.
+const skyDepth = -math.MaxFloat64 / 2
+
+// skyHorizon is the position on the theme's gradient the sky takes its
+// color from at the horizon, brightest there and darkening towards the top
+// of the screen and, as the depths seen through the water, the bottom.
+const skyHorizon = 0.3
+
+// refraction is how far, in world units, the tilt of the surface moves the
+// point of the sky seen through it, and maxRefraction the most cells that
+// shifts it on screen, keeping the effect subtle.
+const (
+	refraction    = 0.08
+	maxRefraction = 2
+)
+
+// skyKey caches the sky, which only changes with the size, theme and camera.
+type skyKey struct{}
+
+// renderSky fills the sky layer with the dark end of the theme's gradient,
+// if the layer is shown.
+func (r *Renderer) renderSky() {
+	if r.layers[LayerSky].Opacity <= 0 {
+		return
+	}
+	prev := r.SetLayer(LayerSky)
+	defer r.SetLayer(prev)
+	r.Cached(skyKey{}, func() {
+		// The far edge of the surface at sea level
+		_, horizon, _ := r.project(vec.Vec3{Y: 1})
+		horizon = max(0, min(horizon, float64(r.height-1)))
+		for y := range r.height {
+			t := skyHorizon * float64(y) / max(horizon, 1)
+			if float64(y) > horizon {
+				t = skyHorizon * (1 - 0.6*(float64(y)-horizon)/max(float64(r.height-1)-horizon, 1))
+			}
+			style := tcell.StyleDefault.Background(r.gradient[int(t*float64(len(r.gradient)-1))])
+			for x := range r.width {
+				r.SetCell(x, y, ' ', skyDepth, style)
+			}
+		}
+	})
+}
+
+// refractSky shifts the cells of the sky under each quad of the surface by
+// the quad's tilt, so what shows through the gaps between the lines of the
+// surface wavers with the water. Flat water leaves the sky as it is.
+func (r *Renderer) refractSky(w *wave.Wave) {
+	if r.layers[LayerSky].Opacity <= 0 {
+		return
+	}
+	sky := r.snapshot(LayerSky)
+	plane := r.planes[LayerSky]
+	up := vec.Vec3{Z: refraction}
+	gridDepth, gridWidth := w.Size()
+	for depth := 0; depth < gridDepth-1; depth++ {
+		for width := 0; width < gridWidth-1; width++ {
+			p1 := w.GridPoints[depth][width]
+			p2 := w.GridPoints[depth][width+1]
+			p3 := w.GridPoints[depth+1][width]
+			p4 := w.GridPoints[depth+1][width+1]
+			normal := p2.Sub(p1).Cross(p3.Sub(p1)).Normalize()
+			if normal.Z < 0 {
+				normal = normal.Scale(-1)
+			}
+
+			// The tilt as it looks from the camera: where the normal
+			// points on screen, less where it would on flat water
+			center := p1.Add(p2).Add(p3).Add(p4).Scale(0.25)
+			tx, ty, _ := r.project(center.Add(normal.Scale(refraction)))
+			fx, fy, _ := r.project(center.Add(up))
+			dx := max(-maxRefraction, min(int(math.Round(tx-fx)), maxRefraction))
+			dy := max(-maxRefraction, min(int(math.Round(ty-fy)), maxRefraction))
+			if dx == 0 && dy == 0 {
+				continue
+			}
+
+			// Every cell the quad covers
+			x1, y1, _ := r.project3D(p1)
+			x2, y2, _ := r.project3D(p2)
+			x3, y3, _ := r.project3D(p3)
+			x4, y4, _ := r.project3D(p4)
+			x0, xn := max(min(x1, x2, x3, x4), 0), min(max(x1, x2, x3, x4), r.width-1)
+			y0, yn := max(min(y1, y2, y3, y4), 0), min(max(y1, y2, y3, y4), r.height-1)
+			if x0 > xn {
+				continue
+			}
+			for y := y0; y <= yn; y++ {
+				sy := max(0, min(y+dy, r.height-1))
+				for x := x0; x <= xn; x++ {
+					if c := sky[sy][max(0, min(x+dx, r.width-1))]; c.set && plane[y][x].set {
+						plane[y][x] = c
+					}
+				}
+				r.composeSpan(x0, xn, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/vec"
+	"github.com/olegchuev/screensaver/internal/wave"
+)
+
+// sprayGlow is the brightness a fresh drop of spray adds to its cell,
+// fading as the drop falls, and crestGlow what the top of a crest under
+// spray adds, lit from above.
+const (
+	sprayGlow = 0.6
+	crestGlow = 0.5
+)
+
+// sprayCrest is the height, from 0 at the troughs to 1 at the highest
+// crest, above which the water under spray glows.
+const sprayCrest = 0.6
+
+// sprayDrop is a cell spray was projected onto, at the depth of the drop.
+type sprayDrop struct {
+	x, y  int
+	depth float64
+}
+
+// renderSpray draws the spray flying off the crests. The brightness of
+// the drops in a cell and of the crest under them add up in the intensity
+// buffer and are brought into range by the tone map, so spray piling up
+// over a bright crest rolls off to white rather than clipping.
+func (r *Renderer) renderSpray(w *wave.Wave, minZ, zRange float64) {
+	r.spray = r.spray[:0]
+	foam := w.Foam.Particles()
+	for i := range foam {
+		p := &foam[i]
+		x, y, depth := r.project3D(vec.Vec3{X: p.X, Y: p.Y, Z: p.Z})
+		if r.emoji {
+			x &^= 1 // Lined up with the slots of the emoji under it
+		}
+		if x < 0 || x >= r.width || y < 0 || y >= r.height {
+			continue
+		}
+		if r.intensity[y][x] == 0 {
+			// The crest counts once however many drops fly over it
+			if z, ok := w.HeightAt(p.X, p.Y); ok {
+				crest := ((z-minZ)/zRange - sprayCrest) / (1 - sprayCrest)
+				r.intensity[y][x] += crestGlow * max(crest, 0)
+			}
+		}
+		r.intensity[y][x] += sprayGlow * (1 - p.Progress())
+		r.spray = append(r.spray, sprayDrop{x: x, y: y, depth: depth})
+	}
+
+	for _, d := range r.spray {
+		level := r.toneMap.Apply(r.intensity[d.y][d.x])
+		char, style := r.sprayLook(level)
+		if r.mode == ModeHalfBlock || r.mode == ModeQuadrant {
+			style = r.overWater(d.x, d.y, style)
+		}
+		r.SetCell(d.x, d.y, char, d.depth, style)
+	}
+}
+
+// sprayLook returns how spray of brightness level, from 0 to 1, is drawn:
+// from a faint grey speck up to a white drop.
+func (r *Renderer) sprayLook(level float64) (rune, tcell.Style) {
+	if r.emoji {
+		return emojiSpray, tcell.StyleDefault
+	}
+	chars := foamStyle.Chars
+	char := chars[min(int((1-level)*float64(len(chars))), len(chars)-1)]
+	mix := func(i int) int32 {
+		from, to := float64(foamStyle.To[i]), float64(foamStyle.From[i])
+		return int32(math.Round(from + (to-from)*level))
+	}
+	return char, tcell.StyleDefault.Foreground(tcell.NewRGBColor(mix(0), mix(1), mix(2)))
+}
@@ -0,0 +1,115 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Block elements for partial cell coverage, indexed by eighths (0-8).
+var (
+	leftEighths  = []rune{' ', '▏', '▎', '▍', '▌', '▋', '▊', '▉', '█'}
+	lowerEighths = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+)
+
+// brailleBase is the empty Braille pattern; dots are OR-ed into it.
+const brailleBase = 0x2800
+
+// brailleDots maps a dot position (column 0-1, row 0-3) to its bit.
+var brailleDots = [2][4]rune{
+	{0x01, 0x02, 0x04, 0x40},
+	{0x08, 0x10, 0x20, 0x80},
+}
+
+// FillRect fills a rectangle given in fractional cell coordinates. Edges
+// that fall inside a cell are drawn with eighth block elements, so a
+// rectangle moving by less than a cell visibly moves instead of jumping.
+func (r *Renderer) FillRect(x, y, w, h float64, color tcell.Color, depth float64) {
+	if !(w > 0 && h > 0) {
+		return // Also rejects NaN sizes
+	}
+	x1, y1 := x+w, y+h
+	// Only visit cells on screen
+	x, y = math.Max(x, 0), math.Max(y, 0)
+	x1, y1 = math.Min(x1, float64(r.width)), math.Min(y1, float64(r.height))
+	if !(x < x1 && y < y1) {
+		return
+	}
+	fill := tcell.StyleDefault.Foreground(color)
+
+	for cy := int(math.Floor(y)); float64(cy) < y1; cy++ {
+		top := math.Max(y, float64(cy))
+		bottom := math.Min(y1, float64(cy+1))
+		vertical := bottom - top
+
+		for cx := int(math.Floor(x)); float64(cx) < x1; cx++ {
+			left := math.Max(x, float64(cx))
+			right := math.Min(x1, float64(cx+1))
+			horizontal := right - left
+
+			switch {
+			case horizontal >= 1 && vertical >= 1:
+				r.SetCell(cx, cy, '█', depth, fill)
+			case vertical >= 1:
+				r.setPartialColumn(cx, cy, left-float64(cx), horizontal, color, depth)
+			default:
+				r.setPartialRow(cx, cy, top-float64(cy), vertical, color, depth)
+			}
+		}
+	}
+}
+
+// setPartialColumn draws a cell covered horizontally from offset to offset+size (0-1).
+func (r *Renderer) setPartialColumn(x, y int, offset, size float64, color tcell.Color, depth float64) {
+	eighths := int(math.Round(size * 8))
+	if eighths == 0 {
+		return
+	}
+	if offset < 0.01 {
+		// Left aligned coverage maps directly onto the left block elements
+		r.SetCell(x, y, leftEighths[eighths], depth, tcell.StyleDefault.Foreground(color))
+		return
+	}
+	// Right aligned coverage: draw the uncovered left part in the screen
+	// background color over a filled cell
+	style := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(color)
+	r.SetCell(x, y, leftEighths[8-eighths], depth, style)
+}
+
+// setPartialRow draws a cell covered vertically from offset to offset+size (0-1).
+func (r *Renderer) setPartialRow(x, y int, offset, size float64, color tcell.Color, depth float64) {
+	eighths := int(math.Round(size * 8))
+	if eighths == 0 {
+		return
+	}
+	if offset+size > 0.99 {
+		// Bottom aligned coverage maps onto the lower block elements
+		r.SetCell(x, y, lowerEighths[eighths], depth, tcell.StyleDefault.Foreground(color))
+		return
+	}
+	style := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(color)
+	r.SetCell(x, y, lowerEighths[8-eighths], depth, style)
+}
+
+// PlotDot sets a single Braille dot at a fractional cell position. Each cell
+// holds a 2x4 grid of dots, and dots plotted into the same cell are merged.
+func (r *Renderer) PlotDot(x, y float64, depth float64, style tcell.Style) {
+	cx, cy := int(math.Floor(x)), int(math.Floor(y))
+	if cx < 0 || cx >= r.width || cy < 0 || cy >= r.height {
+		return
+	}
+	dx := min(int((x-float64(cx))*2), 1)
+	dy := min(int((y-float64(cy))*4), 3)
+
+	plane := r.planes[r.layer]
+	existing := plane[cy][cx]
+	pattern := rune(brailleBase)
+	if existing.set && existing.char >= brailleBase && existing.char <= brailleBase+0xFF {
+		pattern = existing.char
+		depth = math.Max(depth, existing.depth)
+		// Let the merged pattern replace it
+		plane[cy][cx].depth = -math.MaxFloat64
+		plane[cy][cx].set = false
+	}
+	r.SetCell(cx, cy, pattern|brailleDots[dx][dy], depth, style)
+}
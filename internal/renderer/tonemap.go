@@ -0,0 +1,74 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ToneMap is the curve ResolveIntensity and the ocean's spray bring
+// brightness accumulated in the intensity buffer, which has no upper bound,
+// into the 0-1 range of shade characters and colors.
+type ToneMap int
+
+const (
+	// ToneMapExponential saturates like exposed film, 1 - e^-v, the
+	// brightest regions flattening out early
+	ToneMapExponential ToneMap = iota
+	// ToneMapReinhard compresses by v / (1 + v), which rolls off more
+	// slowly and keeps detail in the brightest regions
+	ToneMapReinhard
+	// ToneMapACES follows the filmic curve of the ACES reference rendering,
+	// darkening the faintest light and giving highlights a soft shoulder
+	ToneMapACES
+	// ToneMapClip takes brightness as it is and clips it at 1, for
+	// comparison
+	ToneMapClip
+)
+
+// toneMapNames are the names of the tone maps, in order.
+var toneMapNames = []string{"exponential", "reinhard", "aces", "clip"}
+
+// ToneMapNames returns the names of the tone maps, the default first.
+func ToneMapNames() []string {
+	return slices.Clone(toneMapNames)
+}
+
+// ParseToneMap returns the tone map with the given name.
+func ParseToneMap(name string) (ToneMap, error) {
+	i := slices.Index(toneMapNames, name)
+	if i < 0 {
+		return ToneMapExponential, fmt.Errorf("unknown tone map %q (available: %s)", name, strings.Join(toneMapNames, ", "))
+	}
+	return ToneMap(i), nil
+}
+
+// String returns the name of the tone map.
+func (t ToneMap) String() string {
+	if t < 0 || int(t) >= len(toneMapNames) {
+		return fmt.Sprintf("ToneMap(%d)", int(t))
+	}
+	return toneMapNames[t]
+}
+
+// Apply maps brightness from 0 up to a level from 0 to 1.
+func (t ToneMap) Apply(v float64) float64 {
+	v = max(v, 0)
+	switch t {
+	case ToneMapReinhard:
+		return v / (1 + v)
+	case ToneMapACES:
+		// Krzysztof Narkowicz's fit of the ACES curve
+		return min((v*(2.51*v+0.03))/(v*(2.43*v+0.59)+0.14), 1)
+	case ToneMapClip:
+		return min(v, 1)
+	}
+	return 1 - math.Exp(-v)
+}
+
+// SetToneMap selects the curve ResolveIntensity and the ocean's spray map
+// brightness with.
+func (r *Renderer) SetToneMap(t ToneMap) {
+	r.toneMap = t
+}
@@ -0,0 +1,144 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// NewViewport creates an offscreen renderer of the given size. Scenes draw
+// into it exactly as they would into the screen renderer, and the result is
+// copied onto another renderer with Blit, which makes it possible to show
+// several scenes side by side or a scene in a thumbnail.
+func NewViewport(width, height int) *Renderer {
+	r := &Renderer{
+		fade:       1,
+		adjust:     DefaultAdjustment(),
+		tint:       [3]float64{1, 1, 1},
+		cellAspect: DefaultCellAspect,
+		gradient:   defaultGradient(),
+		layers:     defaultLayers(),
+		ramp:       shadeChars,
+		linear:     true,
+		camera:     DefaultCamera(),
+	}
+	r.SetSize(width, height)
+	return r
+}
+
+// SetSize changes the dimensions of an offscreen renderer.
+// Renderers attached to a screen follow the screen size instead.
+func (r *Renderer) SetSize(width, height int) {
+	if r.screen != nil || (width == r.width && height == r.height && r.buffer != nil) {
+		return
+	}
+	r.width, r.height = max(width, 0), max(height, 0)
+	r.centerX = float64(r.width) / 2
+	r.centerY = float64(r.height) / 2
+	r.initBuffer()
+	r.updateView()
+}
+
+// Blit copies the drawn cells of src onto the current layer of r with its
+// top-left corner at (x, y). Copied cells are placed at the given depth so
+// the viewport as a whole sorts against the content of r; cells falling
+// outside r are clipped. The cells were already composited by src, so the
+// layer styles of r do not apply.
+func (r *Renderer) Blit(src *Renderer, x, y int, depth float64) {
+	for sy := range src.buffer {
+		dy := y + sy
+		if dy < 0 || dy >= r.height {
+			continue
+		}
+		row := r.planes[r.layer][dy]
+		first, last := r.width, -1
+		for sx, c := range src.buffer[sy] {
+			dx := x + sx
+			if !c.set || dx < 0 || dx >= r.width || depth <= row[dx].depth {
+				continue
+			}
+			c.depth, c.composited = depth, true
+			row[dx] = c
+			first, last = min(first, dx), max(last, dx)
+		}
+		if first <= last {
+			r.composeSpan(first, last, dy)
+		}
+	}
+}
+
+// Offscreen creates a viewport the size of r that draws like r does, with
+// its theme, cell aspect, camera, layer styles, mode and shading.
+func (r *Renderer) Offscreen() *Renderer {
+	v := NewViewport(r.width, r.height)
+	v.gradient = r.gradient
+	v.layers = r.layers
+	v.SetCellAspect(r.cellAspect)
+	v.SetCamera(r.camera)
+	v.mode = r.mode
+	v.emoji = r.emoji
+	v.shadeDither = r.shadeDither
+	v.ramp = r.ramp
+	v.linear = r.linear
+	v.toneMap = r.toneMap
+	return v
+}
+
+// Mix copies a blend of the drawn cells of from and to onto the current
+// layer of r at the given depth, like Blit does with one viewport at the
+// top-left corner. mix tells
+// how far each cell has turned from the first to the second, from 0 to 1.
+// A cell drawn on both sides shows the character of the side it is nearer
+// and the two colors blended; a cell drawn on one side only fades that
+// side in or out against the black background.
+func (r *Renderer) Mix(from, to *Renderer, depth float64, mix func(x, y int) float64) {
+	at := func(v *Renderer, x, y int) cell {
+		if y < len(v.buffer) && x < len(v.buffer[y]) {
+			return v.buffer[y][x]
+		}
+		return cell{}
+	}
+	for y, row := range r.planes[r.layer] {
+		first, last := r.width, -1
+		for x := range row {
+			a, b := at(from, x, y), at(to, x, y)
+			if !a.set && !b.set {
+				continue
+			}
+			m := math.Max(0, math.Min(mix(x, y), 1))
+			c, weight := a, 1-m
+			if b.set && (!a.set || m >= 0.5) {
+				c, weight = b, m
+			}
+			if weight <= 0 {
+				continue
+			}
+			afg, abg, _ := a.style.Decompose()
+			bfg, bbg, _ := b.style.Decompose()
+			c.style = c.style.Foreground(mixColor(afg, bfg, m, r.linear)).Background(mixColor(abg, bbg, m, r.linear))
+			if depth > row[x].depth {
+				c.depth, c.composited = depth, true
+				row[x] = c
+				first, last = min(first, x), max(last, x)
+			}
+		}
+		if first <= last {
+			r.composeSpan(first, last, y)
+		}
+	}
+}
+
+// mixColor blends a into b by m, taking the terminal's default color for
+// black unless both are the default.
+func mixColor(a, b tcell.Color, m float64, linear bool) tcell.Color {
+	isDefault := func(c tcell.Color) bool { return c == tcell.ColorDefault || c == tcell.ColorReset }
+	switch {
+	case isDefault(a) && isDefault(b):
+		return b
+	case isDefault(a):
+		a = tcell.ColorBlack
+	case isDefault(b):
+		b = tcell.ColorBlack
+	}
+	return blendColor(b, a, LayerStyle{Opacity: m, Tint: [3]float64{1, 1, 1}}, linear)
+}
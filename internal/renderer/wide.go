@@ -0,0 +1,43 @@
+package renderer
+
+import "github.com/rivo/uniseg"
+
+// Wide characters such as emoji take up two cells. The cell to the right of
+// one stays set, with no character of its own, so drawing over either half
+// replaces the whole character.
+
+// isWide reports whether char takes up two cells.
+func (r *Renderer) isWide(char rune) bool {
+	if char < 0x1100 {
+		return false // Nothing before the Hangul Jamo is wide
+	}
+	wide, ok := r.widths[char]
+	if !ok {
+		if r.widths == nil {
+			r.widths = make(map[rune]bool)
+		}
+		wide = uniseg.StringWidth(string(char)) == 2
+		r.widths[char] = wide
+	}
+	return wide
+}
+
+// split turns a wide character of row that drawing at x would cover half
+// of into a space in the half left over, reporting whether it did.
+func (r *Renderer) split(row []cell, x int) bool {
+	split := false
+	if x > 0 && row[x].set && row[x].char == 0 {
+		row[x-1].char = ' '
+		split = true
+	}
+	if x+1 < len(row) && row[x+1].set && row[x+1].char == 0 {
+		row[x+1].char = ' '
+		split = true
+	}
+	return split
+}
+
+// widePart reports whether c is either half of a wide character.
+func (r *Renderer) widePart(c cell) bool {
+	return c.set && (c.char == 0 || r.isWide(c.char))
+}
@@ -0,0 +1,152 @@
+// Package replay records the input stream of a session (configuration, key
+// presses, resizes and control commands, each stamped with its frame
+// number) to a compact gzip compressed file and plays it back. Scenes are
+// deterministic for a given configuration and frame sequence, so replaying
+// the stream reproduces the session without storing any pixels.
+package replay
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// Version is the replay file format version written by this build.
+const Version = 1
+
+// Kind identifies the type of a recorded event.
+type Kind string
+
+// Recorded event kinds
+const (
+	KindKey     Kind = "key"
+	KindResize  Kind = "resize"
+	KindCommand Kind = "command"
+	KindQuit    Kind = "quit"
+)
+
+// Header describes the recorded session and is stored as the first record.
+type Header struct {
+	Version int       `json:"version"`
+	Start   time.Time `json:"start"` // Wall clock time of frame 0
+	Width   int       `json:"width"`
+	Height  int       `json:"height"`
+	// Config is the application configuration the session started with
+	Config json.RawMessage `json:"config"`
+	// Theme is stored in full so custom themes replay on other machines
+	Theme theme.Theme `json:"theme"`
+}
+
+// Event is one input delivered at the start of a frame.
+type Event struct {
+	Frame   int    `json:"f"`
+	Kind    Kind   `json:"k"`
+	Key     int16  `json:"key,omitempty"`
+	Rune    rune   `json:"r,omitempty"`
+	Mod     int16  `json:"m,omitempty"`
+	Width   int    `json:"w,omitempty"`
+	Height  int    `json:"h,omitempty"`
+	Command string `json:"c,omitempty"`
+}
+
+// Recorder appends events to a replay file.
+type Recorder struct {
+	file *os.File
+	zip  *gzip.Writer
+	enc  *json.Encoder
+}
+
+// Create starts a replay file at path, writing the header.
+func Create(path string, h Header) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	zw := gzip.NewWriter(f)
+	r := &Recorder{file: f, zip: zw, enc: json.NewEncoder(zw)}
+	h.Version = Version
+	if err := r.enc.Encode(h); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, r.zip.Flush()
+}
+
+// Record appends an event. Every event is flushed so a crashed session
+// still leaves a replay up to the crash.
+func (r *Recorder) Record(e Event) error {
+	if err := r.enc.Encode(e); err != nil {
+		return err
+	}
+	return r.zip.Flush()
+}
+
+// Close finishes the compressed stream and closes the file.
+func (r *Recorder) Close() error {
+	return errors.Join(r.zip.Close(), r.file.Close())
+}
+
+// Player hands out recorded events frame by frame.
+type Player struct {
+	Header Header
+	events []Event
+	next   int
+}
+
+// Open reads a replay file. A stream cut short by a crash is read up to
+// the last complete event.
+func Open(path string) (*Player, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: not a replay file: %w", path, err)
+	}
+	dec := json.NewDecoder(bufio.NewReader(zr))
+
+	p := &Player{}
+	if err := dec.Decode(&p.Header); err != nil {
+		return nil, fmt.Errorf("%s: reading header: %w", path, err)
+	}
+	if p.Header.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported replay version %d (want %d)", path, p.Header.Version, Version)
+	}
+	for {
+		var e Event
+		err := dec.Decode(&e)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: event %d: %w", path, len(p.events)+1, err)
+		}
+		p.events = append(p.events, e)
+	}
+	return p, nil
+}
+
+// Frame returns the events recorded for the given frame, in order. Frames
+// must be requested in increasing order.
+func (p *Player) Frame(frame int) []Event {
+	start := p.next
+	for p.next < len(p.events) && p.events[p.next].Frame <= frame {
+		p.next++
+	}
+	return p.events[start:p.next]
+}
+
+// Done reports whether every recorded event has been played.
+func (p *Player) Done() bool {
+	return p.next >= len(p.events)
+}
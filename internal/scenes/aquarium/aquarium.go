@@ -0,0 +1,335 @@
+// Package aquarium provides a scene of fish swimming through a fish tank
+// among seaweed and rising bubbles.
+package aquarium
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	stepRate    = 20.0 // Simulation steps per second, whatever the frame rate
+	fishSpeed   = 2.0  // Speed of the farthest fish in cells per second at speed 1
+	nearSpeed   = 6.0  // Extra speed of the nearest fish
+	bubbleRate  = 0.3  // Bubbles a fish breathes out a second
+	bubbleRise  = 4.0  // Cells a second bubbles rise
+	swayRate    = 1.2  // Seaweed sways a second
+	weedSpacing = 7    // Columns per strand of seaweed
+	tankDepth   = 0.0  // Depth of the water surface and the sand
+	lifeDepth   = 1.0  // Depth of the farthest fish, seaweed and bubbles
+	warmUp      = 5    // Seconds a new tank has been running
+)
+
+// sandChars make up the bottom of the tank.
+var sandChars = []rune("_.,-_:_'")
+
+// sprites are the fish facing right, from small to large. Spaces inside the
+// outline are drawn, hiding whatever swims behind.
+var sprites = [][]string{
+	{`><>`},
+	{`><(((o>`},
+	{
+		` __`,
+		`\/ o\`,
+		`/\__/`,
+	},
+	{
+		`    _`,
+		`\/\/ \`,
+		` >   o>`,
+		`/\/\_/`,
+	},
+	{
+		`     __`,
+		`\   /  \___`,
+		` >=(  o    >`,
+		`/   \____ /`,
+		`        \|`,
+	},
+}
+
+// mirrored swaps the characters that change when a sprite faces left.
+var mirrored = strings.NewReplacer("<", ">", ">", "<", "(", ")", ")", "(", "/", `\`, `\`, "/", "[", "]", "]", "[", "{", "}", "}", "{")
+
+// colors are the fish's colors.
+var colors = []color.RGB{
+	color.From8(240, 130, 30),
+	color.From8(235, 210, 40),
+	color.From8(220, 60, 60),
+	color.From8(60, 200, 210),
+	color.From8(210, 90, 200),
+	color.From8(120, 220, 90),
+	color.From8(230, 230, 230),
+}
+
+// water is the color of the tank, which the farther fish fade into.
+var water = color.From8(20, 50, 110)
+
+// Config holds parameters for the aquarium scene.
+type Config struct {
+	// Fish is the number of fish in the tank
+	Fish int
+	// Speed scales how fast the fish swim, 1 for a lazy pace
+	Speed float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a well stocked tank.
+func DefaultConfig() Config {
+	return Config{Fish: 12, Speed: 1, Seed: 1}
+}
+
+// fish is one fish swimming across the tank.
+type fish struct {
+	rows  [][]rune // Sprite facing the way the fish swims
+	x     float64  // Column of the sprite's left edge
+	y     int      // Row of the sprite's top
+	dir   float64  // 1 swimming right, -1 left
+	z     float64  // Nearness from 0 at the back of the tank to 1 at the front
+	speed float64  // Cells per second
+	style tcell.Style
+}
+
+// strand is a strand of seaweed growing from the sand.
+type strand struct {
+	x      int
+	height int
+	z      float64
+	phase  float64
+}
+
+// Scene shows a fish tank. Fish of all sizes swim across at their own
+// depth, nearer ones faster and brighter, and seaweed grows in front of
+// and behind them, all sorted by the renderer's depth buffer. Fish breathe
+// out bubbles that rise to the surface.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	fish    []fish
+	weed    []strand
+	sand    []rune
+	bubbles *particle.System
+	width   int
+	height  int
+	clock   float64 // Seconds simulated, for the swaying seaweed and waves
+	steps   float64 // Simulation steps due, carried between frames
+	lastT   float64
+	started bool
+}
+
+// NewScene creates an aquarium scene. The tank is stocked on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	return &Scene{
+		config:  cfg,
+		rng:     rand.New(rand.NewSource(cfg.Seed)),
+		bubbles: particle.NewSystem(512, cfg.Seed),
+	}
+}
+
+// resize lays the tank out for a screen of the given size. A new tank
+// starts with its fish already swimming across it, a resized one keeps
+// them where they were.
+func (s *Scene) resize(width, height int) {
+	if width == s.width && height == s.height {
+		return
+	}
+	fresh := s.width == 0
+	oldWidth := s.width
+	s.width, s.height = width, height
+
+	s.sand = make([]rune, width)
+	for x := range s.sand {
+		s.sand[x] = sandChars[s.rng.Intn(len(sandChars))]
+	}
+	s.weed = s.weed[:0]
+	for x := s.rng.Intn(weedSpacing); x < width; x += weedSpacing/2 + s.rng.Intn(weedSpacing) {
+		z := 0.3 * s.rng.Float64()
+		// A few strands grow in front of the fish
+		if s.rng.Intn(4) == 0 {
+			z = 0.9 + 0.1*s.rng.Float64()
+		}
+		tallest := max(height/3, 3)
+		s.weed = append(s.weed, strand{x: x, height: 2 + s.rng.Intn(tallest-1), z: z, phase: s.rng.Float64() * 2})
+	}
+
+	if fresh {
+		s.fish = s.fish[:0]
+		for range max(s.config.Fish, 0) {
+			f := s.newFish()
+			f.x = s.rng.Float64()*float64(width+len(f.rows[0])) - float64(len(f.rows[0]))
+			s.fish = append(s.fish, f)
+		}
+		// With bubbles already on their way up
+		for range int(warmUp * stepRate) {
+			s.step(1 / stepRate)
+		}
+		return
+	}
+	for i := range s.fish {
+		f := &s.fish[i]
+		f.x *= float64(width) / float64(oldWidth)
+		f.y = min(f.y, s.lowest(len(f.rows)))
+	}
+	s.bubbles.Clear()
+}
+
+// lowest returns the lowest top row of a sprite rows tall, just above the
+// sand.
+func (s *Scene) lowest(rows int) int {
+	return max(s.height-1-rows, 1)
+}
+
+// newFish creates a random fish just off one side of the tank, about to
+// swim across it.
+func (s *Scene) newFish() fish {
+	sprite := sprites[s.rng.Intn(len(sprites))]
+	f := fish{
+		dir: 1,
+		z:   s.rng.Float64(),
+	}
+	if s.rng.Intn(2) == 0 {
+		f.dir = -1
+	}
+	f.speed = (fishSpeed + nearSpeed*f.z) * (0.8 + 0.4*s.rng.Float64()) * max(s.config.Speed, 0)
+
+	width := 0
+	for _, row := range sprite {
+		width = max(width, len(row))
+	}
+	for _, row := range sprite {
+		row += strings.Repeat(" ", width-len(row))
+		if f.dir < 0 {
+			row = mirrored.Replace(row)
+			runes := []rune(row)
+			for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+				runes[i], runes[j] = runes[j], runes[i]
+			}
+			row = string(runes)
+		}
+		f.rows = append(f.rows, []rune(row))
+	}
+
+	f.y = 1 + s.rng.Intn(max(s.lowest(len(f.rows)), 1))
+	f.x = float64(s.width)
+	if f.dir > 0 {
+		f.x = -float64(width)
+	}
+	// Far fish fade into the water
+	c := color.Mix(colors[s.rng.Intn(len(colors))], water, 0.6*(1-f.z), color.SpaceOKLab)
+	f.style = tcell.StyleDefault.Foreground(c.Tcell())
+	return f
+}
+
+// Update runs the simulation steps due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.steps += max(t-s.lastT, 0) * stepRate
+	s.lastT = t
+	// A long pause would otherwise take seconds of steps to catch up on
+	s.steps = min(s.steps, stepRate)
+	for ; s.steps >= 1; s.steps-- {
+		s.step(1 / stepRate)
+	}
+}
+
+// step moves the fish and bubbles on by dt seconds, replacing fish that
+// have swum out of the tank with new ones.
+func (s *Scene) step(dt float64) {
+	if s.width == 0 || s.height == 0 {
+		return
+	}
+	s.clock += dt
+	s.bubbles.Update(dt)
+	for i := range s.fish {
+		f := &s.fish[i]
+		f.x += f.dir * f.speed * dt
+		width := float64(len(f.rows[0]))
+		if f.x > float64(s.width) || f.x < -width {
+			*f = s.newFish()
+			continue
+		}
+		if s.rng.Float64() < bubbleRate*dt {
+			mouth := f.x + width
+			if f.dir < 0 {
+				mouth = f.x - 1
+			}
+			top, z := float64(f.y+len(f.rows)/2), f.z
+			s.bubbles.Emit(func(p *particle.Particle, rng *rand.Rand) {
+				p.X, p.Y, p.Z = mouth, top, z
+				p.VY = -bubbleRise * (0.7 + 0.6*p.Seed)
+				// Popped just under the surface
+				p.Life = (top - 1) / -p.VY
+			})
+		}
+	}
+}
+
+// bubbleStyle makes bubbles grow as they rise.
+var bubbleStyle = particle.Style{Chars: []rune{'.', 'o', 'O'}, From: [3]int32{140, 190, 230}, To: [3]int32{220, 240, 255}}
+
+// Render draws the surface and the sand, then the seaweed, fish and bubbles
+// at their depths.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	s.resize(width, height)
+	if width == 0 || height < 3 {
+		return
+	}
+
+	surface := tcell.StyleDefault.Foreground(tcell.NewRGBColor(110, 170, 230))
+	sand := tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 170, 110))
+	for x := range width {
+		ch := '~'
+		if math.Sin(float64(x)*0.45-s.clock*1.5) > 0.3 {
+			ch = '^'
+		}
+		r.SetCell(x, 0, ch, tankDepth, surface)
+		r.SetCell(x, height-1, s.sand[x], tankDepth, sand)
+	}
+
+	for _, w := range s.weed {
+		c := color.Mix(color.From8(40, 170, 70), water, 0.6*(1-w.z), color.SpaceOKLab)
+		style := tcell.StyleDefault.Foreground(c.Tcell())
+		sway := int(s.clock*swayRate + w.phase)
+		for i := range w.height {
+			// Alternate segments lean either way and swap over as it sways
+			if (i+sway)%2 == 0 {
+				r.SetCell(w.x, height-2-i, '(', lifeDepth+w.z, style)
+			} else {
+				r.SetCell(w.x+1, height-2-i, ')', lifeDepth+w.z, style)
+			}
+		}
+	}
+
+	for _, f := range s.fish {
+		left := int(math.Floor(f.x))
+		for y, row := range f.rows {
+			first := strings.IndexFunc(string(row), func(ch rune) bool { return ch != ' ' })
+			last := strings.LastIndexFunc(string(row), func(ch rune) bool { return ch != ' ' })
+			if first < 0 {
+				continue
+			}
+			for x := first; x <= last; x++ {
+				r.SetCell(left+x, f.y+y, row[x], lifeDepth+f.z, f.style)
+			}
+		}
+	}
+
+	for _, p := range s.bubbles.Particles() {
+		ch, style := bubbleStyle.Look(&p)
+		// Bubbles wobble as they rise
+		x := p.X + 0.4*math.Sin(p.Age*3+p.Seed*2*math.Pi)
+		r.SetCell(int(math.Floor(x)), int(math.Floor(p.Y)), ch, lifeDepth+p.Z, style)
+	}
+}
@@ -0,0 +1,139 @@
+// Package bounce provides a scene of a logo bouncing around the screen.
+package bounce
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	depth     = 0.0
+	pace      = 8.0  // Columns a second the logo moves at speed 1
+	flashTime = 1.5  // Seconds a corner hit lights the logo up
+	colorStep = 0.38 // Gradient positions between the colors of successive hits, near the golden ratio so they never repeat closely
+)
+
+// DefaultLogo is the name of the program in the Calvin S figlet font.
+var DefaultLogo = []string{
+	"╔═╗┌─┐┬─┐┌─┐┌─┐┌┐┌┌─┐┌─┐┬  ┬┌─┐┬─┐",
+	"╚═╗│  ├┬┘├┤ ├┤ │││└─┐├─┤└┐┌┘├┤ ├┬┘",
+	"╚═╝└─┘┴└─└─┘└─┘┘└┘└─┘┴ ┴ └┘ └─┘┴└─",
+}
+
+// Config holds parameters for the bounce scene.
+type Config struct {
+	// Logo is the lines of the logo, such as figlet output; spaces are
+	// transparent
+	Logo []string
+	// Speed scales how fast the logo moves
+	Speed float64
+	// Seed picks where the logo starts and the way it heads
+	Seed int64
+}
+
+// DefaultConfig returns defaults for the program's name bouncing at an
+// unhurried pace.
+func DefaultConfig() Config {
+	return Config{Logo: DefaultLogo, Speed: 1, Seed: 1}
+}
+
+// Scene bounces a logo off the edges of the screen like the idle screen of
+// a DVD player, taking another color of the renderer's gradient at every
+// hit. The logo moves diagonally at the same speed on screen across and
+// down, and a hit right in a corner, as rare as ever, lights it up.
+type Scene struct {
+	config  Config
+	rows    [][]rune
+	width   int     // Columns of the widest row
+	startX  float64 // Starting place as a share of the room to move
+	startY  float64
+	travel  float64 // Columns moved since the start
+	hits    int     // Edges hit before the current frame, -1 before the first
+	flash   float64 // Time of the last corner hit
+	clock   float64
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a bounce scene with the logo at a place picked by the
+// seed.
+func NewScene(cfg Config) *Scene {
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	s := &Scene{config: cfg, startX: rng.Float64(), startY: rng.Float64(), hits: -1, flash: math.Inf(-1)}
+	for _, line := range cfg.Logo {
+		row := []rune(strings.ReplaceAll(line, "\t", "        "))
+		s.rows = append(s.rows, row)
+		s.width = max(s.width, len(row))
+	}
+	return s
+}
+
+// Update moves the logo on to time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.travel += pace * max(s.config.Speed, 0) * max(t-s.lastT, 0)
+	s.clock = t
+	s.lastT = t
+}
+
+// bounce returns the place u along an edge room long, going back and forth
+// between its ends, and how many times it has turned.
+func bounce(u, room float64) (float64, int) {
+	if room <= 0 {
+		return 0, 0
+	}
+	turns := math.Floor(u / room)
+	f := u - turns*room
+	if int(turns)%2 == 1 {
+		f = room - f
+	}
+	return f, int(turns)
+}
+
+// Render draws the logo where it has got to, in the color of its last hit.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if width == 0 || height == 0 {
+		return
+	}
+	roomX := float64(max(width-s.width, 0))
+	roomY := float64(max(height-len(s.rows), 0))
+	// Rows are taller than columns are wide, so the logo covers fewer of
+	// them for the same distance
+	x, turnsX := bounce(s.startX*2*roomX+s.travel, roomX)
+	y, turnsY := bounce(s.startY*2*roomY+s.travel/r.CellAspect(), roomY)
+	if hits := turnsX + turnsY; hits != s.hits {
+		if s.hits >= 0 && hits == s.hits+2 && roomX > 0 && roomY > 0 {
+			s.flash = s.clock
+		}
+		s.hits = hits
+	}
+
+	style := r.GradientStyle(0.3 + 0.7*math.Mod(float64(s.hits)*colorStep+0.5, 1))
+	if since := s.clock - s.flash; since < flashTime {
+		style = flash(style, 1-since/flashTime)
+	}
+	left, top := int(math.Round(x)), int(math.Round(y))
+	for dy, row := range s.rows {
+		for dx, ch := range row {
+			if ch != ' ' {
+				r.SetCell(left+dx, top+dy, ch, depth, style)
+			}
+		}
+	}
+}
+
+// flash blends the foreground of style towards white by f from 0 to 1.
+func flash(style tcell.Style, f float64) tcell.Style {
+	fg, _, _ := style.Decompose()
+	c := color.Mix(color.FromTcell(fg), color.RGB{R: 1, G: 1, B: 1}, f, color.SpaceOKLab)
+	return style.Foreground(c.Clamp().Tcell()).Bold(f > 0.5)
+}
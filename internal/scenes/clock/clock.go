@@ -0,0 +1,402 @@
+// Package clock provides a scene of a large clock filling the screen.
+package clock
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	// Time zones are looked up in the embedded database where the system
+	// has none, as on Windows
+	_ "time/tzdata"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	waterDepth = 0.0
+	clockDepth = 1.0
+	margin     = 2    // Columns kept free on either side
+	waterLevel = 0.72 // Share of the height the still water line is at
+	shimmer    = 0.08 // Cycles a second the digits' colors drift along
+)
+
+// Styles lists the kinds of clock the scene can show.
+var Styles = []string{"digital", "analog"}
+
+// ValidStyle returns an error if name is not one of Styles.
+func ValidStyle(name string) error {
+	for _, s := range Styles {
+		if s == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown style %q (available: %s)", name, strings.Join(Styles, ", "))
+}
+
+// ValidZone returns an error if zone is neither empty nor a known IANA time
+// zone name.
+func ValidZone(zone string) error {
+	if zone == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(zone); err != nil {
+		return fmt.Errorf("unknown time zone %q, want a name such as Europe/Berlin", zone)
+	}
+	return nil
+}
+
+// Config holds parameters for the clock scene.
+type Config struct {
+	// Style is "digital" for big digits or "analog" for a clock face
+	Style string
+	// Hour12 counts the hours from 1 to 12 with AM and PM instead of from
+	// 0 to 23
+	Hour12 bool
+	// Seconds shows the seconds, as digits or a second hand
+	Seconds bool
+	// Zone is the IANA name of the time zone shown, such as "Asia/Tokyo";
+	// empty shows the local time
+	Zone string
+	// Waves floats the clock on waves along the bottom of the screen
+	Waves bool
+}
+
+// DefaultConfig returns defaults for a digital 24-hour clock with seconds
+// showing the local time.
+func DefaultConfig() Config {
+	return Config{Style: "digital", Seconds: true}
+}
+
+const (
+	// glyphRows is the height of the digits in pixels
+	glyphRows = 7
+	// blink stands in for the colon while it is blinked off
+	blink = "\x00"
+)
+
+// glyphs are the pixels of the digits, the colon and a blank digit for the
+// hours below ten on a 12-hour clock.
+var glyphs = map[rune][glyphRows]string{
+	'0': {".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."},
+	'2': {".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"},
+	'3': {".###.", "#...#", "....#", "..##.", "....#", "#...#", ".###."},
+	'4': {"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."},
+	'5': {"#####", "#....", "####.", "....#", "....#", "#...#", ".###."},
+	'6': {"..##.", ".#...", "#....", "####.", "#...#", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."},
+	'8': {".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."},
+	'9': {".###.", "#...#", "#...#", ".####", "....#", "...#.", ".##.."},
+	':': {".", ".", "#", ".", "#", ".", "."},
+	' ': {".....", ".....", ".....", ".....", ".....", ".....", "....."},
+}
+
+// Scene shows the time of day as big digits drawn with half blocks, or as
+// an analog clock face drawn with Braille dots, sized to fill the screen.
+// With waves the clock bobs along on a swell rolling across the bottom of
+// the screen.
+type Scene struct {
+	config Config
+	zone   *time.Location // Nil to keep the zone of the times given
+	now    time.Time
+	told   bool // Whether SetTime gives the time, rather than the system clock
+	t      float64
+	pixels []bool // Half-cell pixels of the digits, two rows to a cell
+}
+
+// NewScene creates a clock scene.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{config: cfg}
+	if cfg.Zone != "" {
+		// Validate checked the name
+		s.zone, _ = time.LoadLocation(cfg.Zone)
+	}
+	return s
+}
+
+// SetTime sets the wall clock time of the next frames, so recordings and
+// snapshots show the time they were taken at. Without it the scene reads the
+// system clock.
+func (s *Scene) SetTime(now time.Time) {
+	s.now, s.told = now, true
+}
+
+// Update moves the waves on to time t.
+func (s *Scene) Update(t float64) {
+	s.t = t
+	if !s.told {
+		s.now = time.Now()
+	}
+}
+
+// Render draws the clock showing the current time.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if width == 0 || height == 0 {
+		return
+	}
+	now := s.now
+	if s.zone != nil {
+		now = now.In(s.zone)
+	}
+
+	// The clock takes the room above the water and rides on its swell at
+	// the middle of the screen
+	bottom, bob := float64(height), 0.0
+	if s.config.Waves {
+		level := waterLevel * float64(height)
+		s.renderWater(r, level)
+		bottom = level - swellHeight(height)
+		bob = s.surface(float64(width)/2, width, height, level) - level
+	}
+	if s.config.Style == "analog" {
+		s.renderAnalog(r, now, bottom, bob)
+	} else {
+		s.renderDigital(r, now, bottom, bob)
+	}
+}
+
+// caption returns the line shown under the clock: the date, with AM or PM
+// on a 12-hour clock and the zone's abbreviation when one is chosen.
+func (s *Scene) caption(now time.Time) string {
+	parts := []string{now.Format("Monday 2 January 2006")}
+	if s.config.Hour12 {
+		parts = append([]string{now.Format("PM")}, parts...)
+	}
+	if s.zone != nil {
+		parts = append(parts, now.Format("MST"))
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// renderCaption draws text centered on row y.
+func renderCaption(r *renderer.Renderer, text string, y int) {
+	width, _ := r.Size()
+	runes := []rune(text)
+	if len(runes) > width {
+		return
+	}
+	style := r.GradientStyle(0.7)
+	x := (width - len(runes)) / 2
+	for i, ch := range runes {
+		r.SetCell(x+i, y, ch, clockDepth, style)
+	}
+}
+
+// digits returns the time as drawn by the digital clock. The colon blinks
+// once a second when there are no seconds to watch.
+func (s *Scene) digits(now time.Time) string {
+	hour := fmt.Sprintf("%02d", now.Hour())
+	if s.config.Hour12 {
+		hour = fmt.Sprintf("%2d", (now.Hour()+11)%12+1)
+	}
+	if s.config.Seconds {
+		return hour + now.Format(":04:05")
+	}
+	if now.Nanosecond() >= int(time.Second/2) {
+		return hour + blink + now.Format("04")
+	}
+	return hour + now.Format(":04")
+}
+
+// renderDigital draws the time in digits as big as fit above bottom, bob
+// rows lower than their resting place, with the caption below.
+func (s *Scene) renderDigital(r *renderer.Renderer, now time.Time, bottom, bob float64) {
+	width, height := r.Size()
+	text := []rune(s.digits(now))
+	columns := len(text) - 1 // Gaps between glyphs
+	for _, ch := range text {
+		columns += glyphWidth(ch)
+	}
+
+	// Pixels are whole columns wide and as many half rows tall as make
+	// them square at the width the digits would ideally take, which fills
+	// the screen with slightly tall digits when a column more each would
+	// not fit. The caption takes two rows under the digits.
+	fit := float64(width-2*margin) / float64(columns)
+	pixelRows := min(int(math.Round(fit*r.CellAspect()/2)), int(2*(bottom-2-2))/glyphRows)
+	scale := min(int(fit), max(int(math.Round(float64(pixelRows)*2/r.CellAspect())), 1))
+	caption := s.caption(now)
+	if scale < 1 || pixelRows < 1 {
+		// Too small for big digits
+		y := int(math.Round((bottom-1)/2 + bob))
+		renderCaption(r, strings.TrimSpace(strings.ReplaceAll(string(text), blink, ":")), y)
+		renderCaption(r, caption, y+1)
+		return
+	}
+
+	halfRows := glyphRows * pixelRows
+	left := (width - columns*scale) / 2
+	// Half rows from the top, so the digits bob by half a row at a time
+	top := int(math.Round(2*(bottom+bob) - float64(halfRows) - 4))
+	if !s.config.Waves {
+		top = int(math.Round(float64(2*height-halfRows-4) / 2))
+	}
+	top = max(top, 0)
+
+	if len(s.pixels) != 2*width*height {
+		s.pixels = make([]bool, 2*width*height)
+	} else {
+		clear(s.pixels)
+	}
+	px := 0
+	for _, ch := range text {
+		glyph, ok := glyphs[ch]
+		if ok {
+			for gy, line := range glyph {
+				for gx, bit := range line {
+					if bit == '#' {
+						s.fill(width, height, left+(px+gx)*scale, top+gy*pixelRows, scale, pixelRows)
+					}
+				}
+			}
+		}
+		px += glyphWidth(ch) + 1
+	}
+
+	span := float64(columns * scale)
+	for y := range height {
+		for x := range width {
+			upper, lower := s.pixels[2*y*width+x], s.pixels[(2*y+1)*width+x]
+			if !upper && !lower {
+				continue
+			}
+			ch := '█'
+			switch {
+			case !lower:
+				ch = '▀'
+			case !upper:
+				ch = '▄'
+			}
+			u := float64(x-left)/span - s.t*shimmer
+			r.SetCell(x, y, ch, clockDepth, r.GradientStyle(0.65+0.35*(0.5+0.5*math.Cos(2*math.Pi*u))))
+		}
+	}
+	renderCaption(r, caption, (top+halfRows+1)/2+1)
+}
+
+// glyphWidth returns the width of a glyph in pixels; a blinked-off colon
+// keeps its place.
+func glyphWidth(ch rune) int {
+	if string(ch) == blink {
+		return len(glyphs[':'][0])
+	}
+	return len(glyphs[ch][0])
+}
+
+// fill sets a w by h block of half-cell pixels with its top left corner at
+// column x and half row y, clipped to the screen.
+func (s *Scene) fill(width, height, x, y, w, h int) {
+	for py := max(y, 0); py < min(y+h, 2*height); py++ {
+		for px := max(x, 0); px < min(x+w, width); px++ {
+			s.pixels[py*width+px] = true
+		}
+	}
+}
+
+// renderAnalog draws a clock face as big as fits above bottom, bob rows
+// lower than its resting place, with the caption below.
+func (s *Scene) renderAnalog(r *renderer.Renderer, now time.Time, bottom, bob float64) {
+	width, height := r.Size()
+	aspect := r.CellAspect()
+	// The radius is in columns; the face is 2*radius/aspect rows tall and
+	// leaves two rows for the caption
+	radius := min(float64(width-2*margin)/2, (bottom-3)/2*aspect)
+	caption := s.caption(now)
+	if radius < 4 {
+		y := int(math.Round((bottom-1)/2 + bob))
+		renderCaption(r, now.Format("15:04:05"), y)
+		renderCaption(r, caption, y+1)
+		return
+	}
+	cx := float64(width) / 2
+	cy := bottom - 2 - radius/aspect + bob
+	if !s.config.Waves {
+		cy = (float64(height) - 2) / 2
+	}
+
+	// point returns the place at a share of the radius in a direction,
+	// turning clockwise from 12 o'clock by angle
+	point := func(angle, share float64) (float64, float64) {
+		return cx + share*radius*math.Sin(angle), cy - share*radius*math.Cos(angle)/aspect
+	}
+	// Braille dots are half a column apart, so lines are walked in quarter
+	// columns to leave no gaps
+	line := func(angle, from, to float64, style tcell.Style) {
+		steps := int(math.Ceil((to - from) * radius * 4))
+		for i := range steps + 1 {
+			x, y := point(angle, from+(to-from)*float64(i)/float64(max(steps, 1)))
+			r.PlotDot(x, y, clockDepth, style)
+		}
+	}
+
+	rim := r.GradientStyle(0.45)
+	for i, n := 0, int(2*math.Pi*radius*4); i < n; i++ {
+		x, y := point(2*math.Pi*float64(i)/float64(n), 1)
+		r.PlotDot(x, y, clockDepth, rim)
+	}
+	marks := r.GradientStyle(0.75)
+	for i := range 60 {
+		angle := 2 * math.Pi * float64(i) / 60
+		switch {
+		case i%15 == 0:
+			line(angle, 0.76, 0.92, marks)
+		case i%5 == 0:
+			line(angle, 0.82, 0.92, marks)
+		default:
+			x, y := point(angle, 0.9)
+			r.PlotDot(x, y, clockDepth, rim)
+		}
+	}
+
+	seconds := float64(now.Second()) + float64(now.Nanosecond())/1e9
+	minutes := float64(now.Minute()) + seconds/60
+	hours := float64(now.Hour()%12) + minutes/60
+	hands := r.GradientStyle(1)
+	// The hour hand is drawn twice, side by side, to make it thicker
+	for _, offset := range []float64{-0.012, 0.012} {
+		line(2*math.Pi*hours/12+offset, 0, 0.5, hands)
+	}
+	line(2*math.Pi*minutes/60, 0, 0.78, hands)
+	if s.config.Seconds {
+		line(2*math.Pi*seconds/60, -0.12, 0.86, r.GradientStyle(0.6))
+	}
+
+	renderCaption(r, caption, int(math.Round(cy+radius/aspect))+1)
+}
+
+// swellHeight returns the rows between the still water line and the crests.
+func swellHeight(height int) float64 {
+	return max(0.05*float64(height), 0.75)
+}
+
+// surface returns the row the water reaches at column x, level being its
+// still line.
+func (s *Scene) surface(x float64, width, height int, level float64) float64 {
+	u := x / float64(width)
+	t := s.t
+	wave := 0.6*math.Sin(2*math.Pi*2*u+0.9*t) +
+		0.3*math.Sin(2*math.Pi*3.3*u-0.6*t+1.3) +
+		0.1*math.Sin(2*math.Pi*7*u+1.7*t)
+	return level - swellHeight(height)*wave
+}
+
+// renderWater fills the screen below the waves' surface, its color
+// darkening with depth.
+func (s *Scene) renderWater(r *renderer.Renderer, level float64) {
+	width, height := r.Size()
+	deep := float64(height) - level + swellHeight(height)
+	for x := range width {
+		top := s.surface(float64(x)+0.5, width, height, level)
+		// The partly covered cell at the surface, then whole cells below
+		crest := math.Floor(top) + 1
+		fg, _, _ := r.GradientStyle(0.55).Decompose()
+		r.FillRect(float64(x), top, 1, crest-top, fg, waterDepth)
+		for y := int(crest); y < height; y++ {
+			below := (float64(y) - top) / deep
+			r.SetCell(x, y, '█', waterDepth, r.GradientStyle(0.5-0.35*min(below, 1)))
+		}
+	}
+}
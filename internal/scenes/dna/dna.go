@@ -0,0 +1,185 @@
+// Package dna provides a scene of a rotating DNA double helix.
+package dna
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+// The helix keeps the proportions of B-DNA: a radius of 1 nm, a turn every
+// 3.4 nm and 10.5 base pairs to a turn.
+const (
+	pitch      = 3.4            // Length of a turn in helix radii
+	pairs      = 10.5           // Base pairs per turn
+	groove     = 0.76 * math.Pi // Angle from one strand to the other, leaving a major and a minor groove
+	spin       = 0.6            // Rotation about the axis in radians per second at speed 1
+	distance   = 10.0           // Camera distance from the axis in helix radii
+	sway       = 0.25           // Most the axis turns towards and away from the camera, in radians
+	swayRate   = 0.13           // Sways a second, in radians of the sway's own cycle
+	radius     = 0.34           // Helix radius as a share of the screen height
+	sampleStep = 0.25           // Columns between the points drawn along a strand
+)
+
+// camera looks at the middle of the axis from in front of it, y up.
+var camera = vec.LookAt(vec.Vec3{Z: distance}, vec.Vec3{}, vec.Vec3{Y: 1})
+
+// bases are the colors of adenine, thymine, guanine and cytosine; a base
+// pairs with the one next to it, A with T and G with C.
+var bases = [4]color.RGB{
+	color.From8(230, 80, 80),
+	color.From8(240, 200, 70),
+	color.From8(80, 200, 120),
+	color.From8(90, 150, 240),
+}
+
+// Config holds parameters for the DNA scene.
+type Config struct {
+	// Speed scales how fast the helix turns about its axis
+	Speed float64
+	// Strands are the colors of the two backbones
+	Strands [2]color.RGB
+	// Seed for the random sequence of base pairs
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a slowly turning helix with a blue and
+// a magenta strand.
+func DefaultConfig() Config {
+	return Config{
+		Speed:   1,
+		Strands: [2]color.RGB{color.From8(70, 150, 255), color.From8(240, 90, 190)},
+		Seed:    1,
+	}
+}
+
+// Scene shows a double helix lying across the screen and turning about its
+// axis, which sways slowly towards and away from the viewer. The two
+// backbones and the base pairs bridging them are projected in perspective,
+// nearer parts drawn brighter and in front through the renderer's depth
+// buffer.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	bases   []int   // Base on the first strand of every pair, see base
+	angle   float64 // Rotation about the axis
+	clock   float64
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a DNA scene.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// Update turns the helix on to time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.angle += spin * max(s.config.Speed, 0) * max(t-s.lastT, 0)
+	s.clock = t
+	s.lastT = t
+}
+
+// base returns the base on the first strand of rung i, counted either way
+// from the middle of the axis, picking the sequence as far as needed.
+func (s *Scene) base(i int) int {
+	// Rungs alternate right and left of the middle in the sequence
+	i *= 2
+	if i < 0 {
+		i = -i - 1
+	}
+	for len(s.bases) <= i {
+		s.bases = append(s.bases, s.rng.Intn(len(bases)))
+	}
+	return s.bases[i]
+}
+
+// view projects points of the helix for one frame.
+type view struct {
+	r      *renderer.Renderer
+	model  vec.Mat4 // Turns the axis by the yaw
+	screen vec.Mat4 // Camera and perspective
+}
+
+// project returns the cell position of the point x along the axis, y up
+// and z towards the viewer, and its nearness from 0 at the back to 1 at
+// the front.
+func (v view) project(x, y, z float64) (col, row, near float64) {
+	p, _ := v.model.MulPoint(vec.Vec3{X: x, Y: y, Z: z})
+	ndc, _ := v.screen.MulPoint(p)
+	col, row = v.r.ToScreen(ndc)
+	return col, row, (p.Z + 1) / 2
+}
+
+// strand returns the point on strand k at x along the axis.
+func (s *Scene) strand(k int, x float64) (y, z float64) {
+	theta := 2*math.Pi*x/pitch + s.angle + float64(k)*groove
+	return math.Cos(theta), math.Sin(theta)
+}
+
+// Render draws the rungs and then the strands over the screen.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if width == 0 || height == 0 {
+		return
+	}
+	aspect := r.CellAspect()
+	cam := r.Camera()
+	yaw := sway*math.Sin(s.clock*swayRate) + cam.Yaw
+	// Columns per helix radius on the axis
+	scale := radius * float64(height) * aspect * cam.Zoom
+	v := view{
+		r:      r,
+		model:  vec.RotateY(yaw),
+		screen: r.Perspective(2*math.Atan(1/(2*radius*distance*cam.Zoom)), 0.1, 2*distance).Mul(camera),
+	}
+	// Long enough to run off both sides however the axis turns
+	half := float64(width)/2/scale/max(math.Cos(yaw), 0.3) + 1
+
+	dot := func(col, row, near float64, c color.RGB, strength float64) {
+		level := min((0.2+0.8*near)*strength, 1)
+		style := tcell.StyleDefault.Foreground(c.Scale(0.35 + 0.65*level).Clamp().Tcell())
+		r.SetCell(int(math.Floor(col)), int(math.Floor(row)), r.ShadeChar(level), near, style)
+	}
+
+	// Base pairs, each half in the color of its base
+	step := pitch / pairs
+	first := int(math.Floor(-half / step))
+	for i := first; float64(i)*step <= half; i++ {
+		x := float64(i) * step
+		b := s.base(i)
+		y0, z0 := s.strand(0, x)
+		y1, z1 := s.strand(1, x)
+		c0, r0, _ := v.project(x, y0, z0)
+		c1, r1, _ := v.project(x, y1, z1)
+		n := int(max(math.Abs(c1-c0), math.Abs(r1-r0))/sampleStep) + 1
+		for k := 1; k < n; k++ {
+			f := float64(k) / float64(n)
+			c := bases[b]
+			if f > 0.5 {
+				c = bases[b^1]
+			}
+			col, row, near := v.project(x, y0+(y1-y0)*f, z0+(z1-z0)*f)
+			dot(col, row, near, c, 0.6)
+		}
+	}
+
+	// Backbones, drawn finely enough to stay unbroken where they run steep
+	dx := sampleStep / scale
+	for k := range 2 {
+		for x := -half; x <= half; x += dx {
+			y, z := s.strand(k, x)
+			col, row, near := v.project(x, y, z)
+			// Slightly in front of the rungs they hold
+			dot(col, row, near+0.01, s.config.Strands[k], 1)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package fire provides the classic rising flames scene.
+package fire
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	depth     = 0.0
+	stepRate  = 30.0 // Propagation steps per second, whatever the frame rate
+	flameRise = 0.6  // Share of the screen height flames reach at intensity 1
+)
+
+// palette runs from embers through red and orange to a yellow white core.
+var palette = func() []tcell.Color {
+	g := color.NewGradient([]color.Stop{
+		{Pos: 0, Color: color.From8(40, 0, 0)},
+		{Pos: 0.3, Color: color.From8(190, 20, 0)},
+		{Pos: 0.6, Color: color.From8(255, 120, 0)},
+		{Pos: 0.85, Color: color.From8(255, 210, 60)},
+		{Pos: 1, Color: color.From8(255, 250, 220)},
+	}, color.SpaceOKLab)
+	table := make([]tcell.Color, 64)
+	for i, c := range g.Table(len(table)) {
+		table[i] = c.Tcell()
+	}
+	return table
+}()
+
+// Config holds parameters for the fire scene.
+type Config struct {
+	// Intensity scales how hot the fire burns and so how high the flames
+	// reach, 1 for about 60% of the screen
+	Intensity float64
+	// Wind leans the flames, from -1 blowing hard to the left to 1 to the
+	// right
+	Wind float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a steady fire in still air.
+func DefaultConfig() Config {
+	return Config{Intensity: 1, Wind: 0, Seed: 1}
+}
+
+// Scene burns a fire along the bottom of the screen. Every step each cell
+// takes the heat of the cell below it, cooled a little and carried a cell
+// sideways at random, so flames rise, flicker and die out; wind tips the
+// sideways drift one way.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	heat    [][]float64 // Heat of every cell, from 0 to 1
+	steps   float64     // Propagation steps due, carried between frames
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a fire scene. The heat field is laid out on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// resize lays the heat field out for a screen of the given size, keeping
+// the flames rooted at the bottom. A new fire is already burning.
+func (s *Scene) resize(width, height int) {
+	if len(s.heat) == height && (height == 0 || len(s.heat[0]) == width) {
+		return
+	}
+	heat := make([][]float64, height)
+	for y := range heat {
+		heat[y] = make([]float64, width)
+		if old := len(s.heat) - height + y; old >= 0 && old < len(s.heat) {
+			copy(heat[y], s.heat[old])
+		}
+	}
+	lit := len(s.heat) > 0
+	s.heat = heat
+	if !lit {
+		for range 2 * height {
+			s.step()
+		}
+	}
+}
+
+// Update runs the propagation steps due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.steps += max(t-s.lastT, 0) * stepRate
+	s.lastT = t
+	// A long pause would otherwise take seconds of steps to catch up on
+	s.steps = min(s.steps, stepRate)
+	for ; s.steps >= 1; s.steps-- {
+		s.step()
+	}
+}
+
+// step feeds the bottom row and lets the heat rise one row.
+func (s *Scene) step() {
+	height := len(s.heat)
+	if height == 0 {
+		return
+	}
+	width := len(s.heat[0])
+	intensity := max(s.config.Intensity, 0.01)
+	for x := range width {
+		s.heat[height-1][x] = min(intensity, 1) * (0.8 + 0.2*s.rng.Float64())
+	}
+
+	// Heat lasts about flameRise of the screen on average
+	cooling := 2 / (flameRise * float64(height) * intensity)
+	wind := s.config.Wind
+	for y := 0; y < height-1; y++ {
+		for x := range width {
+			drift := s.rng.Intn(3) - 1
+			if s.rng.Float64() < math.Abs(wind) {
+				if wind > 0 {
+					drift++
+				} else {
+					drift--
+				}
+			}
+			to := ((x+drift)%width + width) % width
+			s.heat[y][to] = max(s.heat[y+1][x]-cooling*s.rng.Float64(), 0)
+		}
+	}
+}
+
+// Render draws every burning cell with the shade ramp, colored from embers
+// to white by its heat.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	s.resize(width, height)
+	for y, row := range s.heat {
+		for x, h := range row {
+			if h < 0.03 {
+				continue
+			}
+			c := palette[min(int(h*float64(len(palette)-1)), len(palette)-1)]
+			r.SetCell(x, y, r.ShadeCharAt(x, y, h), depth, tcell.StyleDefault.Foreground(c))
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// Package fractal provides a scene zooming ever deeper into the Mandelbrot
+// set or a Julia set.
+package fractal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	depth       = 0.0
+	startWidth  = 3.6     // Width of the plane across the screen when a zoom starts
+	zoomRate    = 0.4     // Doublings of magnification per second at speed 1
+	baseIter    = 200     // Iterations a point may take to escape at the start of a zoom
+	maxIter     = 1 << 16 // Most iterations a point may ever take
+	iterBudget  = 1.5e6   // Iterations a frame may take on each processor
+	bailout     = 1e4     // Squared radius past which a point has escaped, large for smooth coloring
+	colorPeriod = 40.0    // Iterations per cycle through the gradient
+	steerRate   = 0.6     // Share of the way a second the view moves towards the detail
+	holdTime    = 4.0     // Seconds a finished zoom stays before the next starts
+	// Relative size of a cell below which float64 can no longer tell
+	// neighboring cells apart, and zooming goes on by perturbation
+	floatLimit = 1e-13
+	// Bits of precision of the view's center beyond those of its cells
+	guardBits = 64
+)
+
+// Sets lists the fractals the scene can zoom into.
+var Sets = []string{"mandelbrot", "julia"}
+
+// target is where a zoom starts: the point of the plane it heads for, and
+// for Julia sets the constant of the set.
+type target struct {
+	x, y float64
+	c    complex128
+}
+
+// targets are the starting points of the zooms into each set, near where
+// its boundary is richest. The zoom steers itself on from there.
+var targets = map[string][]target{
+	"mandelbrot": {
+		{x: -0.743643887037151, y: 0.131825904205330},    // Seahorse valley
+		{x: -0.7746806106269039, y: -0.1374168856037867}, // Double spirals
+		{x: 0.001643721971153, y: -0.822467633298876},    // Misiurewicz point
+		{x: -1.2505, y: 0.0201},                          // Along the antenna
+		{x: -0.088, y: 0.654},                            // Triple spiral valley
+		{x: 0.2549870375144766, y: -0.0005679790528465},  // Elephant valley
+	},
+	"julia": {
+		{c: complex(-0.8, 0.156)},
+		{c: complex(0.285, 0.01)},
+		{c: complex(-0.7269, 0.1889)},
+		{c: complex(-0.4, 0.6)},
+		{c: complex(-0.835, -0.2321)},
+	},
+}
+
+// Config holds parameters for the fractal scene.
+type Config struct {
+	// Set is the fractal zoomed into, one of Sets
+	Set string
+	// Speed scales how fast the view zooms in
+	Speed float64
+	// Depth is the magnification a zoom ends at, as a power of ten
+	Depth float64
+	// Seed picks the first zoom
+	Seed int64
+}
+
+// DefaultConfig returns defaults for zooms into the Mandelbrot set down to
+// a magnification of 10^40.
+func DefaultConfig() Config {
+	return Config{Set: "mandelbrot", Speed: 1, Depth: 40, Seed: 1}
+}
+
+// ValidSet reports an error if name is not one of Sets.
+func ValidSet(name string) error {
+	for _, s := range Sets {
+		if s == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown set %q (available: %s)", name, strings.Join(Sets, ", "))
+}
+
+// Scene zooms into a fractal without end. Every cell is colored by how
+// many iterations its point takes to escape, smoothed between whole
+// counts and cycled through the renderer's gradient; points that never do
+// are left dark. Each zoom starts near a known rich spot and steers itself
+// towards the slowest escaping cells, which lie along the boundary, so
+// there is detail on screen at any depth. Once float64 can no longer tell
+// the cells apart, the orbit of the view's center is iterated in big.Float
+// and every cell follows only its small difference from that orbit in
+// float64, which holds down to a magnification of about 10^300.
+type Scene struct {
+	config  Config
+	targets []target
+	next    int // Index of the next zoom's target
+	c       complex128
+	x, y    *big.Float // Center of the view
+	bits    float64    // Doublings of magnification
+	hold    float64    // Seconds the finished zoom has stayed
+	iter    int        // Iterations a point may take to escape
+	orbit   []complex128
+	counts  []float64 // Smooth iteration counts of the last frame, -1 inside the set
+	width   int
+	height  int
+	aspect  float64
+	lastT   float64
+	started bool
+	// Scratch values for iterating the center's orbit
+	zx, zy, x2, y2, xy *big.Float
+}
+
+// NewScene creates a fractal scene starting on a zoom picked by the seed.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{config: cfg, targets: targets[cfg.Set], aspect: renderer.DefaultCellAspect}
+	if len(s.targets) == 0 {
+		s.targets = targets["mandelbrot"]
+	}
+	for _, v := range []**big.Float{&s.zx, &s.zy, &s.x2, &s.y2, &s.xy} {
+		*v = new(big.Float)
+	}
+	s.next = rand.New(rand.NewSource(cfg.Seed)).Intn(len(s.targets))
+	s.restart()
+	return s
+}
+
+// julia reports whether the scene shows a Julia set.
+func (s *Scene) julia() bool {
+	return s.config.Set == "julia"
+}
+
+// restart begins the next zoom from the whole set.
+func (s *Scene) restart() {
+	t := s.targets[s.next]
+	s.next = (s.next + 1) % len(s.targets)
+	s.c = t.c
+	s.x, s.y = big.NewFloat(t.x), big.NewFloat(t.y)
+	s.bits, s.hold, s.iter = 0, 0, baseIter
+	s.orbit = s.orbit[:0]
+	for i := range s.counts {
+		s.counts[i] = -1
+	}
+}
+
+// Update zooms in to time t, steering towards the detail of the last
+// frame, and moves on to the next zoom once this one is done.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	dt := max(t-s.lastT, 0)
+	s.lastT = t
+
+	if s.bits >= s.config.Depth*math.Log2(10) {
+		if s.hold += dt; s.hold >= holdTime {
+			s.restart()
+		}
+		return
+	}
+	s.bits += zoomRate * max(s.config.Speed, 0) * dt
+	if !s.steer(min(steerRate*dt, 1)) {
+		s.restart()
+	}
+}
+
+// steer moves the center a share f of the way to the cell of the last
+// frame that took longest to escape, the nearest to the middle of those.
+// It reports false if the view has nothing left to show.
+func (s *Scene) steer(f float64) bool {
+	best, bestCount, bestDist := -1, 0.0, 0.0
+	inside, lo, hi := 0, math.Inf(1), math.Inf(-1)
+	for i, n := range s.counts {
+		if n < 0 {
+			inside++
+			continue
+		}
+		lo, hi = min(lo, n), max(hi, n)
+		dx, dy := float64(i%s.width-s.width/2), float64(i/s.width-s.height/2)*s.aspect
+		dist := dx*dx + dy*dy
+		if best < 0 || n > bestCount+0.5 || (n > bestCount-0.5 && dist < bestDist) {
+			best, bestCount, bestDist = i, n, dist
+		}
+	}
+	if len(s.counts) == 0 || s.bits < 1 {
+		return true
+	}
+	// Lost inside the set or out where it is all alike
+	if inside > len(s.counts)*19/20 || hi-lo < 1.5 {
+		return false
+	}
+	cell := s.cellSize()
+	dx := float64(best%s.width-s.width/2) * cell * f
+	dy := -float64(best/s.width-s.height/2) * cell * s.aspect * f
+	prec := s.prec()
+	s.x.SetPrec(prec).Add(s.x, big.NewFloat(dx))
+	s.y.SetPrec(prec).Add(s.y, big.NewFloat(dy))
+	return true
+}
+
+// cellSize returns the width of a cell on the plane.
+func (s *Scene) cellSize() float64 {
+	return startWidth * math.Exp2(-s.bits) / float64(max(s.width, 1))
+}
+
+// prec returns the precision the center needs at the current depth.
+func (s *Scene) prec() uint {
+	return uint(s.bits) + uint(math.Log2(float64(max(s.width, 1)))) + guardBits
+}
+
+// adapt sets the iterations a point may take for the next frame from the
+// counts of the last. Deeper in, points near the boundary take ever longer
+// to escape, and a limit they run into would show them as inside; but every
+// cell inside takes the whole limit, so the frame stays within a budget.
+func (s *Scene) adapt() {
+	late, spent := 0, 0.0
+	for _, n := range s.counts {
+		switch {
+		case n < 0:
+			spent += float64(s.iter)
+		case n > float64(s.iter)/2:
+			late++
+			fallthrough
+		default:
+			spent += n
+		}
+	}
+	budget := iterBudget * float64(runtime.GOMAXPROCS(0))
+	limit := maxIter
+	if spent > budget {
+		limit = int(float64(s.iter) * budget / spent)
+	}
+	switch {
+	case late > len(s.counts)/100:
+		s.iter = s.iter * 5 / 4
+	case late == 0:
+		s.iter = s.iter * 9 / 10
+	}
+	s.iter = max(min(s.iter, limit), baseIter)
+}
+
+// referenceOrbit iterates the center of the view in big.Float, keeping the
+// orbit rounded to float64 until it escapes or runs out of iterations.
+func (s *Scene) referenceOrbit(n int) {
+	prec := s.prec()
+	s.orbit = s.orbit[:0]
+	cx, cy := s.x, s.y
+	zx, zy := s.zx.SetPrec(prec).SetInt64(0), s.zy.SetPrec(prec).SetInt64(0)
+	if s.julia() {
+		zx.Set(s.x)
+		zy.Set(s.y)
+		cx, cy = big.NewFloat(real(s.c)), big.NewFloat(imag(s.c))
+	}
+	x2, y2, xy := s.x2.SetPrec(prec), s.y2.SetPrec(prec), s.xy.SetPrec(prec)
+	for range n + 1 {
+		fx, _ := zx.Float64()
+		fy, _ := zy.Float64()
+		s.orbit = append(s.orbit, complex(fx, fy))
+		if fx*fx+fy*fy > bailout {
+			return
+		}
+		x2.Mul(zx, zx)
+		y2.Mul(zy, zy)
+		xy.Mul(zx, zy)
+		zx.Sub(x2, y2).Add(zx, cx)
+		zy.Add(xy, xy).Add(zy, cy)
+	}
+}
+
+// escape returns the smooth iteration count at which an orbit escaped with
+// z after n iterations.
+func escape(n int, z complex128) float64 {
+	r2 := real(z)*real(z) + imag(z)*imag(z)
+	return float64(n) + 1 - math.Log2(math.Log(r2)/2/math.Ln2)
+}
+
+// direct iterates the point c of the plane, or the point z0 of a Julia
+// set, in float64.
+func (s *Scene) direct(z, c complex128, n int) float64 {
+	for i := range n {
+		z = z*z + c
+		if real(z)*real(z)+imag(z)*imag(z) > bailout {
+			return escape(i+1, z)
+		}
+	}
+	return -1
+}
+
+// perturbed iterates the point d away from the view's center by its
+// difference from the center's orbit. Where the point comes closer to
+// zero than to the orbit, or outlives it, it goes on from the start of
+// the orbit instead, which keeps the difference small and the float64
+// arithmetic exact enough.
+func (s *Scene) perturbed(d complex128, n int) float64 {
+	dz, dc := complex128(0), d
+	if s.julia() {
+		dz, dc = d, 0
+	}
+	m := 0
+	for i := range n {
+		dz = 2*s.orbit[m]*dz + dz*dz + dc
+		m++
+		z := s.orbit[m] + dz
+		r2 := real(z)*real(z) + imag(z)*imag(z)
+		if r2 > bailout {
+			return escape(i+1, z)
+		}
+		if m == len(s.orbit)-1 || (!s.julia() && r2 < real(dz)*real(dz)+imag(dz)*imag(dz)) {
+			dz, m = z-s.orbit[0], 0
+		}
+	}
+	return -1
+}
+
+// Render colors every cell by the escape count of its point.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if width == 0 || height == 0 {
+		return
+	}
+	if width != s.width || height != s.height {
+		s.width, s.height = width, height
+		s.counts = make([]float64, width*height)
+	}
+	s.aspect = r.CellAspect()
+	s.iterate()
+	s.adapt()
+
+	for i, count := range s.counts {
+		if count < 0 {
+			continue
+		}
+		level := 0.5 - 0.5*math.Cos(2*math.Pi*count/colorPeriod)
+		r.SetCell(i%width, i/width, r.ShadeCharAt(i%width, i/width, 0.3+0.7*level), depth, r.GradientStyle(level))
+	}
+}
+
+// iterate finds the escape count of every cell, spreading the rows over
+// the processors since deep in a zoom there can be thousands of iterations
+// to a cell.
+func (s *Scene) iterate() {
+	cell := s.cellSize()
+	cx, _ := s.x.Float64()
+	cy, _ := s.y.Float64()
+	perturb := cell < floatLimit*max(cmplx.Abs(complex(cx, cy)), 1)
+	if perturb {
+		s.referenceOrbit(s.iter)
+	}
+
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := w; y < s.height; y += workers {
+				dy := -(float64(y-s.height/2) + 0.5) * cell * s.aspect
+				for x := range s.width {
+					dx := (float64(x-s.width/2) + 0.5) * cell
+					var count float64
+					switch {
+					case perturb:
+						count = s.perturbed(complex(dx, dy), s.iter)
+					case s.julia():
+						count = s.direct(complex(cx+dx, cy+dy), s.c, s.iter)
+					default:
+						count = s.direct(0, complex(cx+dx, cy+dy), s.iter)
+					}
+					s.counts[y*s.width+x] = count
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,137 @@
+package galaxy
+
+// Barnes-Hut quadtree: distant groups of bodies are approximated by their
+// center of mass, reducing force evaluation from O(n²) to O(n log n).
+
+const (
+	noBody   = -1
+	internal = -2
+	maxDepth = 32 // Guards against infinite subdivision of coincident bodies
+)
+
+// node is one square region of the quadtree.
+type node struct {
+	cx, cy     float64 // Region center
+	half       float64 // Half of the region side length
+	mass       float64
+	comX, comY float64 // Center of mass
+	body       int     // Index of the single body, noBody, or internal
+	children   [4]int  // Child node indices, valid when body == internal
+}
+
+// quadtree holds nodes in a flat slice that is reused between frames.
+type quadtree struct {
+	nodes  []node
+	bodies []body
+	theta  float64 // Opening angle: smaller is more accurate and slower
+}
+
+// build rebuilds the tree for the given bodies.
+func (q *quadtree) build(bodies []body) {
+	q.bodies = bodies
+	q.nodes = q.nodes[:0]
+
+	minX, minY := bodies[0].x, bodies[0].y
+	maxX, maxY := minX, minY
+	for _, b := range bodies[1:] {
+		minX, maxX = min(minX, b.x), max(maxX, b.x)
+		minY, maxY = min(minY, b.y), max(maxY, b.y)
+	}
+	half := max(maxX-minX, maxY-minY)/2 + 1e-9
+
+	q.nodes = append(q.nodes, node{cx: (minX + maxX) / 2, cy: (minY + maxY) / 2, half: half, body: noBody})
+	for i := range bodies {
+		q.insert(0, i, 0)
+	}
+}
+
+// insert places body i into the subtree rooted at node n.
+func (q *quadtree) insert(n, i, depth int) {
+	b := q.bodies[i]
+	nd := &q.nodes[n]
+
+	// Update aggregate mass and center of mass on the way down
+	total := nd.mass + b.mass
+	nd.comX = (nd.comX*nd.mass + b.x*b.mass) / total
+	nd.comY = (nd.comY*nd.mass + b.y*b.mass) / total
+	nd.mass = total
+
+	switch {
+	case nd.body == noBody:
+		nd.body = i
+	case depth >= maxDepth:
+		// Coincident bodies: keep them merged in the aggregate
+	case nd.body == internal:
+		q.insert(q.child(n, b.x, b.y), i, depth+1)
+	default:
+		existing := nd.body
+		nd.body = internal
+		for c := range nd.children {
+			nd.children[c] = noBody
+		}
+		eb := q.bodies[existing]
+		q.insert(q.child(n, eb.x, eb.y), existing, depth+1)
+		q.insert(q.child(n, b.x, b.y), i, depth+1)
+	}
+}
+
+// child returns the child of node n containing (x, y), creating it if needed.
+func (q *quadtree) child(n int, x, y float64) int {
+	nd := q.nodes[n]
+	quad := 0
+	h := nd.half / 2
+	cx, cy := nd.cx-h, nd.cy-h
+	if x >= nd.cx {
+		quad |= 1
+		cx = nd.cx + h
+	}
+	if y >= nd.cy {
+		quad |= 2
+		cy = nd.cy + h
+	}
+	if c := nd.children[quad]; c != noBody {
+		return c
+	}
+	q.nodes = append(q.nodes, node{cx: cx, cy: cy, half: h, body: noBody})
+	idx := len(q.nodes) - 1
+	q.nodes[n].children[quad] = idx
+	return idx
+}
+
+// force returns the acceleration on body i from the whole tree.
+func (q *quadtree) force(i int, softening2 float64) (float64, float64) {
+	var ax, ay float64
+	b := q.bodies[i]
+
+	stack := [64 * 4]int{0}
+	top := 1
+	for top > 0 {
+		top--
+		nd := &q.nodes[stack[top]]
+		if nd.mass == 0 || nd.body == i {
+			continue
+		}
+
+		dx := nd.comX - b.x
+		dy := nd.comY - b.y
+		dist2 := dx*dx + dy*dy
+
+		// Treat the node as a point mass if it is a leaf or far enough away
+		size := nd.half * 2
+		if nd.body != internal || size*size < q.theta*q.theta*dist2 {
+			inv := 1 / (dist2 + softening2)
+			f := nd.mass * inv * sqrtInv(dist2+softening2)
+			ax += dx * f
+			ay += dy * f
+			continue
+		}
+
+		for _, c := range nd.children {
+			if c != noBody && top < len(stack) {
+				stack[top] = c
+				top++
+			}
+		}
+	}
+	return ax, ay
+}
@@ -0,0 +1,158 @@
+// Package heatmap provides a scene that shows a matrix of numbers fed from
+// outside as a smoothly changing heat map.
+package heatmap
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const depth = 0.0
+
+// waiting is shown until the first matrix arrives.
+const waiting = "waiting for data"
+
+// Config holds parameters for the heat map scene.
+type Config struct {
+	// Min and Max are the values at the ends of the color gradient; when
+	// both are zero the range follows the smallest and largest value of
+	// each matrix
+	Min, Max float64
+	// Smoothing is how many seconds the display takes to move most of the
+	// way to a new matrix
+	Smoothing float64
+}
+
+// DefaultConfig returns defaults with an automatic range and half a second
+// of smoothing.
+func DefaultConfig() Config {
+	return Config{Smoothing: 0.5}
+}
+
+// Parse reads a matrix written as rows separated by semicolons or new
+// lines, each holding numbers separated by spaces or commas, such as
+// "1 2 3; 4 5 6". Every row must have the same length.
+func Parse(text string) ([][]float64, error) {
+	var m [][]float64
+	for _, line := range strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\r' })
+		if len(fields) == 0 {
+			continue
+		}
+		row := make([]float64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+				return nil, fmt.Errorf("invalid value %q in row %d", f, len(m)+1)
+			}
+			row[i] = v
+		}
+		if len(m) > 0 && len(row) != len(m[0]) {
+			return nil, fmt.Errorf("row %d has %d values, want %d like the first", len(m)+1, len(row), len(m[0]))
+		}
+		m = append(m, row)
+	}
+	if len(m) == 0 {
+		return nil, errors.New("empty matrix")
+	}
+	return m, nil
+}
+
+// Scene stretches the latest matrix over the screen, interpolating between
+// its values, and eases every cell towards new matrices as they arrive.
+type Scene struct {
+	config Config
+	matrix [][]float64
+	lo, hi float64   // Range the matrix is colored with
+	shown  []float64 // Gradient position displayed in each cell
+	width  int
+	height int
+	lastT  float64
+	dt     float64 // Time since the previous update
+}
+
+// NewScene creates a heat map scene without data.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg}
+}
+
+// SetMatrix replaces the displayed data; the screen follows over the
+// smoothing time.
+func (s *Scene) SetMatrix(m [][]float64) {
+	s.matrix = m
+	s.lo, s.hi = s.config.Min, s.config.Max
+	if s.lo == 0 && s.hi == 0 {
+		s.lo, s.hi = math.Inf(1), math.Inf(-1)
+		for _, row := range m {
+			for _, v := range row {
+				s.lo, s.hi = min(s.lo, v), max(s.hi, v)
+			}
+		}
+	}
+}
+
+// Update records the time passed since the previous frame.
+func (s *Scene) Update(t float64) {
+	s.dt = max(t-s.lastT, 0)
+	s.lastT = t
+}
+
+// Render eases every cell towards the matrix and draws it.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if s.matrix == nil {
+		x := max((width-len(waiting))/2, 0)
+		for i, c := range waiting {
+			r.SetCell(x+i, height/2, c, depth, r.GradientStyle(0.5))
+		}
+		return
+	}
+
+	first := s.shown == nil || width != s.width || height != s.height
+	if first {
+		s.width, s.height = width, height
+		s.shown = make([]float64, width*height)
+	}
+	// Exponential easing moves every cell the same share of the way each
+	// second, whenever and however often matrices arrive
+	ease := 1.0
+	if s.config.Smoothing > 0 && !first {
+		ease = 1 - math.Exp(-s.dt*3/s.config.Smoothing)
+	}
+
+	for y := range height {
+		for x := range width {
+			target := s.level(s.sample((float64(x)+0.5)/float64(width), (float64(y)+0.5)/float64(height)))
+			i := y*width + x
+			s.shown[i] += (target - s.shown[i]) * ease
+			r.SetCell(x, y, '█', depth, r.GradientStyle(s.shown[i]))
+		}
+	}
+}
+
+// sample interpolates the matrix bilinearly at u, v in 0-1, with the
+// matrix values at the centers of the areas they cover.
+func (s *Scene) sample(u, v float64) float64 {
+	rows, cols := len(s.matrix), len(s.matrix[0])
+	fx := min(max(u*float64(cols)-0.5, 0), float64(cols-1))
+	fy := min(max(v*float64(rows)-0.5, 0), float64(rows-1))
+	x0, y0 := int(fx), int(fy)
+	x1, y1 := min(x0+1, cols-1), min(y0+1, rows-1)
+	tx, ty := fx-float64(x0), fy-float64(y0)
+	top := s.matrix[y0][x0]*(1-tx) + s.matrix[y0][x1]*tx
+	bottom := s.matrix[y1][x0]*(1-tx) + s.matrix[y1][x1]*tx
+	return top*(1-ty) + bottom*ty
+}
+
+// level maps a value to its 0-1 position on the gradient.
+func (s *Scene) level(v float64) float64 {
+	if s.hi <= s.lo {
+		return 0.5
+	}
+	return min(max((v-s.lo)/(s.hi-s.lo), 0), 1)
+}
@@ -0,0 +1,28 @@
+package heatmap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want [][]float64
+	}{
+		{"1 2 3; 4 5 6", [][]float64{{1, 2, 3}, {4, 5, 6}}},
+		{"1,2\n3,\t4\r\n", [][]float64{{1, 2}, {3, 4}}},
+		{";; -1.5e2 ;", [][]float64{{-150}}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.text)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %v, %v; want %v", tt.text, got, err, tt.want)
+		}
+	}
+	for _, text := range []string{"", " ; ", "1 2; 3", "1 x", "inf", "1 NaN"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", text)
+		}
+	}
+}
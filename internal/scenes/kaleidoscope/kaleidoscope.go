@@ -0,0 +1,88 @@
+// Package kaleidoscope provides a noise-fed kaleidoscope scene for the screensaver.
+package kaleidoscope
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/noise"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const depth = 0.0
+
+// Config holds parameters for the kaleidoscope scene.
+type Config struct {
+	// Number of mirrored segments
+	Segments int
+	// Rotation speed of the source wedge in radians per second
+	RotationSpeed float64
+	// Spatial frequency of the noise field (higher is busier)
+	Scale float64
+	// Speed at which the noise field drifts
+	Drift float64
+}
+
+// DefaultConfig returns defaults for a slowly turning eight-fold kaleidoscope.
+func DefaultConfig() Config {
+	return Config{
+		Segments:      8,
+		RotationSpeed: 0.15,
+		Scale:         0.12,
+		Drift:         0.4,
+	}
+}
+
+// Scene draws a drifting noise field and mirrors it into N-fold symmetry.
+type Scene struct {
+	config Config
+	noise  *noise.Value
+	t      float64
+}
+
+// NewScene creates a kaleidoscope scene with the given configuration.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, noise: noise.NewValue(0)}
+}
+
+// Update stores the animation time.
+func (s *Scene) Update(t float64) {
+	s.t = t
+}
+
+// Render fills the screen with layered noise, then applies the kaleidoscope effect.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	cfg := s.config
+	drift := s.t * cfg.Drift
+	aspect := r.CellAspect()
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			nx := float64(x) * cfg.Scale / aspect
+			ny := float64(y) * cfg.Scale
+
+			// Two octaves moving in different directions give shifting shapes
+			v := s.noise.Noise2(nx+drift, ny-drift*0.7)*0.65 +
+				s.noise.Noise2(nx*2.3-drift*1.3, ny*2.3+drift)*0.35
+			if v < 0.3 {
+				continue
+			}
+			level := math.Min((v-0.3)/0.45, 1)
+			style := tcell.StyleDefault.Foreground(palette(level, s.t))
+			r.SetCell(x, y, r.ShadeCharAt(x, y, level), depth, style)
+		}
+	}
+
+	r.Kaleidoscope(cfg.Segments, s.t*cfg.RotationSpeed)
+}
+
+// palette maps a level to a slowly cycling cosine color palette.
+func palette(level, t float64) tcell.Color {
+	phase := level + t*0.05
+	c := func(offset float64) int32 {
+		v := 0.5 + 0.5*math.Cos(2*math.Pi*(phase+offset))
+		return int32(255 * v * (0.35 + 0.65*level))
+	}
+	return tcell.NewRGBColor(c(0), c(0.33), c(0.67))
+}
@@ -0,0 +1,230 @@
+// Package lava provides a lava lamp scene of warm blobs rising, merging and
+// sinking again.
+package lava
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	depth      = 0.0
+	stepRate   = 20.0 // Simulation steps per second, whatever the frame rate
+	heatRate   = 0.25 // Heat a second a blob takes from the lamp at the bottom
+	coolRate   = 0.12 // Heat a second a blob loses near the top
+	buoyancy   = 0.12 // Upward pull in screen heights per second² of a hot blob
+	drag       = 0.8  // Share of its speed a blob loses a second
+	wobble     = 0.03 // Sideways drift in screen heights per second
+	poolHeight = 0.07 // Height of the molten pool at the bottom
+	threshold  = 0.7  // Field strength at the blobs' soft edge
+	glow       = 0.9  // Field strength above the threshold drawn brightest
+	shades     = 64   // Entries of the palette lookup table
+	warmUp     = 20   // Seconds a new lamp has been heating
+)
+
+// Palettes lists the color schemes of the wax; theme takes the theme's
+// gradient.
+var Palettes = []string{"lava", "amber", "magenta", "theme"}
+
+// palettes are the wax colors from the blobs' edges to their centers.
+var palettes = map[string][]color.RGB{
+	"lava":    {color.From8(90, 0, 10), color.From8(200, 30, 10), color.From8(255, 120, 20), color.From8(255, 220, 90)},
+	"amber":   {color.From8(70, 30, 0), color.From8(180, 90, 10), color.From8(245, 170, 40), color.From8(255, 235, 160)},
+	"magenta": {color.From8(70, 0, 60), color.From8(180, 20, 120), color.From8(250, 90, 150), color.From8(255, 200, 170)},
+}
+
+// Config holds parameters for the lava lamp scene.
+type Config struct {
+	// Blobs is the number of wax blobs in the lamp
+	Blobs int
+	// Speed scales how fast the wax moves, 1 for the slow pace of a real lamp
+	Speed float64
+	// Palette colors the wax, one of Palettes
+	Palette string
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a classic red lamp.
+func DefaultConfig() Config {
+	return Config{Blobs: 6, Speed: 1, Palette: "lava", Seed: 1}
+}
+
+// ValidPalette reports an error if name is not one of Palettes.
+func ValidPalette(name string) error {
+	for _, p := range Palettes {
+		if p == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette %q (available: %s)", name, strings.Join(Palettes, ", "))
+}
+
+// blob is one blob of wax, in screen heights from the top left.
+type blob struct {
+	x, y   float64
+	vy     float64
+	radius float64
+	heat   float64 // From 0, sinking, to 1, rising
+	phase  float64 // Offset of the sideways wobble
+}
+
+// Scene shows a lava lamp. Each blob is a metaball: the field of all of
+// them is summed over the screen, so nearby blobs bulge towards each other
+// and merge into one shape before parting again. Blobs warm up in the pool
+// at the bottom, rise, cool off under the top and sink back.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	blobs   []blob
+	styles  []tcell.Style // Palette lookup, nil for the theme's gradient
+	aspect  float64       // Screen width in screen heights
+	clock   float64       // Seconds simulated, for the wobble
+	steps   float64       // Simulation steps due, carried between frames
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a lava lamp scene. The blobs are placed on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{config: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+	if stops := palettes[cfg.Palette]; stops != nil {
+		var gradient []color.Stop
+		for i, c := range stops {
+			gradient = append(gradient, color.Stop{Pos: float64(i) / float64(len(stops)-1), Color: c})
+		}
+		for _, c := range color.NewGradient(gradient, color.SpaceOKLab).Table(shades) {
+			s.styles = append(s.styles, tcell.StyleDefault.Foreground(c.Tcell()))
+		}
+	}
+	return s
+}
+
+// resize fits the lamp to a screen the given width in screen heights. A new
+// lamp starts with its wax already on the move, a resized one keeps the
+// blobs where they were.
+func (s *Scene) resize(aspect float64) {
+	if aspect == s.aspect {
+		return
+	}
+	fresh := s.aspect == 0
+	s.aspect = aspect
+	if !fresh {
+		for i := range s.blobs {
+			b := &s.blobs[i]
+			b.x = min(b.x, aspect-b.radius)
+		}
+		return
+	}
+	s.blobs = s.blobs[:0]
+	for range max(s.config.Blobs, 0) {
+		radius := 0.07 + 0.07*s.rng.Float64()
+		s.blobs = append(s.blobs, blob{
+			x:      radius + s.rng.Float64()*max(aspect-2*radius, 0),
+			y:      s.rng.Float64(),
+			radius: radius,
+			heat:   s.rng.Float64(),
+			phase:  s.rng.Float64() * 2 * math.Pi,
+		})
+	}
+	for range int(warmUp * stepRate) {
+		s.step(1 / stepRate)
+	}
+}
+
+// Update runs the simulation steps due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.steps += max(t-s.lastT, 0) * stepRate
+	s.lastT = t
+	// A long pause would otherwise take seconds of steps to catch up on
+	s.steps = min(s.steps, stepRate)
+	for ; s.steps >= 1; s.steps-- {
+		s.step(1 / stepRate)
+	}
+}
+
+// step moves the wax on by dt seconds at the configured speed.
+func (s *Scene) step(dt float64) {
+	if s.aspect == 0 {
+		return
+	}
+	dt *= max(s.config.Speed, 0)
+	s.clock += dt
+	for i := range s.blobs {
+		b := &s.blobs[i]
+		// The lamp heats the bottom; the top is cool
+		switch {
+		case b.y > 1-poolHeight-b.radius:
+			b.heat = min(b.heat+heatRate*dt, 1)
+		case b.y < 0.3:
+			b.heat = max(b.heat-coolRate*dt, 0)
+		default:
+			b.heat = max(b.heat-0.2*coolRate*dt, 0)
+		}
+		// Warm wax is lighter than the liquid around it, cool wax heavier
+		b.vy -= (b.heat - 0.5) * buoyancy * dt
+		b.vy *= 1 - drag*dt
+		b.y += b.vy * dt
+		b.x += wobble * math.Sin(s.clock*0.4+b.phase) * dt
+
+		// Blobs come to rest against the glass
+		if top := b.radius * 0.5; b.y < top {
+			b.y, b.vy = top, max(b.vy, 0)
+		}
+		if bottom := 1 - poolHeight; b.y > bottom {
+			b.y, b.vy = bottom, min(b.vy, 0)
+		}
+		b.x = max(b.radius, min(b.x, s.aspect-b.radius))
+	}
+}
+
+// field returns the strength of the wax at (x, y): the metaball sum over
+// the blobs and the molten pool at the bottom.
+func (s *Scene) field(x, y float64) float64 {
+	pool := poolHeight / max(1-y, 1e-3)
+	sum := pool * pool
+	for _, b := range s.blobs {
+		dx, dy := x-b.x, y-b.y
+		sum += b.radius * b.radius / (dx*dx + dy*dy + 1e-6)
+	}
+	return sum
+}
+
+// Render draws the wax where the field is strong enough, brighter towards
+// the blobs' centers.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if height == 0 {
+		return
+	}
+	// Cells are taller than wide, so x is scaled for round blobs
+	scale := 1 / float64(height)
+	aspect := r.CellAspect()
+	s.resize(float64(width) * scale / aspect)
+	for y := range height {
+		v := (float64(y) + 0.5) * scale
+		for x := range width {
+			f := s.field((float64(x)+0.5)*scale/aspect, v)
+			if f < threshold {
+				continue
+			}
+			level := min((f-threshold)/glow, 1)
+			style := r.GradientStyle(level)
+			if s.styles != nil {
+				style = s.styles[int(level*(shades-1))]
+			}
+			r.SetCell(x, y, r.ShadeCharAt(x, y, 0.3+0.7*level), depth, style)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package life provides Conway's Game of Life played on the surface of a
+// rotating torus or sphere.
+package life
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+const (
+	tilt        = 0.55 // Angle the view looks down onto the shape, in radians
+	distance    = 4.0  // Camera distance from the center of the shape
+	torusRadius = 1.0  // Distance from the torus center to the middle of its tube
+	tubeRadius  = 0.45
+	stallSteps  = 30 // Generations with an unchanged population before reseeding
+)
+
+// The camera sits in front of the shape looking at its center, z up.
+var (
+	eye    = vec.Vec3{Y: -distance}
+	up     = vec.Vec3{Z: 1}
+	camera = vec.LookAt(eye, vec.Vec3{}, up)
+)
+
+// light is the direction the surface is lit from, in world space.
+var light = vec.Vec3{X: -0.4, Y: -0.7, Z: 0.6}.Normalize()
+
+// Surfaces lists the shapes the board can be wrapped around.
+var Surfaces = []string{"torus", "sphere"}
+
+// Config holds parameters for the Game of Life scene.
+type Config struct {
+	// Surface the board is wrapped around, one of Surfaces
+	Surface string
+	// Width is the number of cells around the shape, Height across it
+	Width, Height int
+	// Generations per second of animation time
+	StepsPerSecond float64
+	// Rotation speed of the shape in radians per second
+	RotationSpeed float64
+	// Share of cells alive at the start and in reseeded patches
+	Density float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a slowly turning torus.
+func DefaultConfig() Config {
+	return Config{
+		Surface:        "torus",
+		Width:          96,
+		Height:         40,
+		StepsPerSecond: 6,
+		RotationSpeed:  0.35,
+		Density:        0.3,
+		Seed:           1,
+	}
+}
+
+// ValidSurface reports an error if name is not one of Surfaces.
+func ValidSurface(name string) error {
+	for _, s := range Surfaces {
+		if s == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown surface %q (available: torus, sphere)", name)
+}
+
+// point is a position on the surface with its outward normal.
+type point struct {
+	pos, normal vec.Vec3
+}
+
+// Scene runs the Game of Life on a board whose edges wrap around a torus,
+// or around a sphere between its poles, and draws the live cells with
+// depth shading as the shape turns.
+type Scene struct {
+	config  Config
+	sphere  bool
+	cells   []bool
+	next    []bool
+	rng     *rand.Rand
+	angle   float64
+	lastT   float64
+	started bool
+	pending float64 // Fractional generations carried to the next frame
+	living  int     // Population of the last generation
+	stalled int     // Generations the population has not changed for
+}
+
+// NewScene creates a Game of Life scene with a random board.
+func NewScene(cfg Config) *Scene {
+	cfg.Width, cfg.Height = max(cfg.Width, 8), max(cfg.Height, 8)
+	s := &Scene{
+		config: cfg,
+		sphere: cfg.Surface == "sphere",
+		cells:  make([]bool, cfg.Width*cfg.Height),
+		next:   make([]bool, cfg.Width*cfg.Height),
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	s.sprinkle(0, 0, cfg.Width, cfg.Height)
+	return s
+}
+
+// HandleKey switches the surface with 's' and reseeds the board with 'r'.
+func (s *Scene) HandleKey(ev *tcell.EventKey) bool {
+	if ev.Key() != tcell.KeyRune {
+		return false
+	}
+	switch ev.Rune() {
+	case 's', 'S':
+		s.sphere = !s.sphere
+		return true
+	case 'r', 'R':
+		clear(s.cells)
+		s.sprinkle(0, 0, s.config.Width, s.config.Height)
+		return true
+	}
+	return false
+}
+
+// Beat bursts a patch of new cells somewhere on the board, larger for
+// stronger beats.
+func (s *Scene) Beat(strength float64) {
+	size := max(int(float64(min(s.config.Width, s.config.Height))*strength/4), 2)
+	s.sprinkle(s.rng.Intn(s.config.Width), s.rng.Intn(s.config.Height), size, size)
+}
+
+// Update turns the shape and advances the generations due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	dt := max(t-s.lastT, 0)
+	s.lastT = t
+	s.angle = t * s.config.RotationSpeed
+
+	s.pending += dt * s.config.StepsPerSecond
+	for ; s.pending >= 1; s.pending-- {
+		s.step()
+	}
+}
+
+// step computes the next generation.
+func (s *Scene) step() {
+	w, h := s.config.Width, s.config.Height
+	alive := 0
+	for y := range h {
+		for x := range w {
+			n := 0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if (dx != 0 || dy != 0) && s.alive(x+dx, y+dy) {
+						n++
+					}
+				}
+			}
+			i := y*w + x
+			s.next[i] = n == 3 || (n == 2 && s.cells[i])
+			if s.next[i] {
+				alive++
+			}
+		}
+	}
+	s.cells, s.next = s.next, s.cells
+
+	// Boards settle into still lifes and blinkers; new patches keep them going
+	if alive == s.living {
+		s.stalled++
+	} else {
+		s.stalled = 0
+	}
+	s.living = alive
+	if s.stalled >= stallSteps || alive < w*h/100 {
+		size := min(w, h) / 3
+		s.sprinkle(s.rng.Intn(w), s.rng.Intn(h), size, size)
+		s.stalled = 0
+	}
+}
+
+// alive reports whether the cell at x, y lives. The board wraps around
+// the torus in both directions; on the sphere it wraps around the
+// equator, and beyond the poles is empty.
+func (s *Scene) alive(x, y int) bool {
+	w, h := s.config.Width, s.config.Height
+	x = (x + w) % w
+	if s.sphere {
+		if y < 0 || y >= h {
+			return false
+		}
+	} else {
+		y = (y + h) % h
+	}
+	return s.cells[y*w+x]
+}
+
+// sprinkle brings a random share of the cells in a rectangle to life.
+func (s *Scene) sprinkle(x0, y0, w, h int) {
+	for y := y0; y < y0+h; y++ {
+		for x := x0; x < x0+w; x++ {
+			if s.rng.Float64() < s.config.Density {
+				s.cells[(y%s.config.Height)*s.config.Width+x%s.config.Width] = true
+			}
+		}
+	}
+}
+
+// surface returns the point at board coordinates u (around) and v
+// (across), both in cells and possibly fractional.
+func (s *Scene) surface(u, v float64) point {
+	w, h := float64(s.config.Width), float64(s.config.Height)
+	theta := 2 * math.Pi * u / w
+	if s.sphere {
+		lat := math.Pi*v/h - math.Pi/2
+		n := vec.Vec3{X: math.Cos(lat) * math.Cos(theta), Y: math.Cos(lat) * math.Sin(theta), Z: math.Sin(lat)}
+		return point{n, n}
+	}
+	phi := 2 * math.Pi * v / h
+	n := vec.Vec3{X: math.Cos(phi) * math.Cos(theta), Y: math.Cos(phi) * math.Sin(theta), Z: math.Sin(phi)}
+	ring := torusRadius + tubeRadius*math.Cos(phi)
+	return point{vec.Vec3{X: ring * math.Cos(theta), Y: ring * math.Sin(theta), Z: tubeRadius * n.Z}, n}
+}
+
+// model returns the matrix placing the shape in the world, spun by its
+// rotation and tilted toward the camera.
+func (s *Scene) model() vec.Mat4 {
+	return vec.RotateX(tilt).Mul(vec.RotateZ(s.angle))
+}
+
+// fov returns the vertical field of view fitting the shape, which spans
+// about 1.5 units from its center on either side, into the screen.
+func fov(r *renderer.Renderer) float64 {
+	width, height := r.Size()
+	w, h := float64(width), float64(height)*r.CellAspect()
+	return 2 * math.Atan(1.5/distance*h/min(w, h))
+}
+
+// Render draws the live cells facing the camera, shaded by the light and
+// dimmed with distance.
+func (s *Scene) Render(r *renderer.Renderer) {
+	model := s.model()
+	project := r.Perspective(fov(r), 0.1, 2*distance).Mul(camera)
+
+	w, h := s.config.Width, s.config.Height
+	for cy := range h {
+		for cx := range w {
+			if !s.cells[cy*w+cx] {
+				continue
+			}
+			// Cells are drawn at four points each so they cover the
+			// screen cells they span even close to the camera
+			for _, sub := range [4][2]float64{{0.25, 0.25}, {0.75, 0.25}, {0.25, 0.75}, {0.75, 0.75}} {
+				p := s.surface(float64(cx)+sub[0], float64(cy)+sub[1])
+				pos, _ := model.MulPoint(p.pos)
+				normal := model.MulDir(p.normal)
+				if normal.Dot(pos.Sub(eye)) > 0 {
+					continue // Facing away from the camera
+				}
+				lit := max(normal.Dot(light), 0)
+				near := (1.5 - pos.Y) / 3 // 1 at the front, 0 at the back
+				level := min(max(0.15+0.6*lit+0.25*near, 0), 1)
+
+				ndc, _ := project.MulPoint(pos)
+				sx, sy := r.ToScreen(ndc)
+				r.SetCell(int(math.Round(sx)), int(math.Round(sy)), r.ShadeChar(level), -pos.Y, r.GradientStyle(level))
+			}
+		}
+	}
+}
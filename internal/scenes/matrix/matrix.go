@@ -0,0 +1,167 @@
+// Package matrix provides the falling glyph "digital rain" scene.
+package matrix
+
+import (
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const depth = 0.0
+
+// glyphs are the characters that rain: half-width katakana, which take one
+// cell like the digits and letters mixed in with them.
+var glyphs = []rune("ｦｱｳｴｵｶｷｹｺｻｼｽｾｿﾀﾂﾃﾅﾆﾇﾈﾊﾋﾎﾏﾐﾑﾒﾓﾔﾕﾗﾘﾜ0123456789Z:.=*+-<>¦")
+
+// Config holds parameters for the digital rain scene.
+type Config struct {
+	// Speed is the average fall speed of the drops in rows per second; each
+	// column falls at its own speed around it
+	Speed float64
+	// Density is the share of columns with a drop falling at any time
+	Density float64
+	// Mutation is how often a glyph in a trail changes, per second
+	Mutation float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for steady rain.
+func DefaultConfig() Config {
+	return Config{Speed: 14, Density: 0.7, Mutation: 1.5, Seed: 1}
+}
+
+// column is one column of the screen with a drop falling down it.
+type column struct {
+	head   float64 // Row of the leading glyph
+	speed  float64 // Rows per second
+	length int     // Rows in the fading trail
+	wait   float64 // Seconds before the next drop starts, while idle
+	glyphs []rune  // Glyph at every row of the screen
+}
+
+// Scene draws columns of glyphs falling at their own speeds, each led by a
+// bright glyph and trailing off into darker green, with glyphs flickering
+// to others as they hang there. The columns follow the screen size.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	columns []column
+	height  int
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a digital rain scene. Columns are laid out on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// resize lays the columns out for a screen of the given size, keeping the
+// drops already falling in columns that remain.
+func (s *Scene) resize(width, height int) {
+	if width == len(s.columns) && height == s.height {
+		return
+	}
+	s.height = height
+	if len(s.columns) > width {
+		s.columns = s.columns[:width]
+	}
+	for i := range s.columns {
+		c := &s.columns[i]
+		if len(c.glyphs) < height {
+			c.glyphs = append(c.glyphs, s.randomGlyphs(height-len(c.glyphs))...)
+		}
+		c.glyphs = c.glyphs[:height]
+	}
+	for len(s.columns) < width {
+		c := column{glyphs: s.randomGlyphs(height)}
+		s.drop(&c)
+		// Start anywhere on the screen instead of all at the top
+		c.head = s.rng.Float64() * float64(height+c.length)
+		if s.rng.Float64() < s.config.Density {
+			c.wait = 0
+		}
+		s.columns = append(s.columns, c)
+	}
+}
+
+// randomGlyphs returns n random glyphs.
+func (s *Scene) randomGlyphs(n int) []rune {
+	g := make([]rune, n)
+	for i := range g {
+		g[i] = glyphs[s.rng.Intn(len(glyphs))]
+	}
+	return g
+}
+
+// drop starts a new drop at the top of a column, after a pause that keeps
+// the share of idle columns near 1 - Density.
+func (s *Scene) drop(c *column) {
+	c.speed = s.config.Speed * (0.5 + s.rng.Float64())
+	c.length = 4 + s.rng.Intn(max(s.height/2, 4))
+	c.head = 0
+	// A drop takes this long to cross the screen and clear it
+	fall := float64(s.height+c.length) / c.speed
+	density := min(max(s.config.Density, 0.01), 1)
+	c.wait = fall * (1 - density) / density * 2 * s.rng.Float64()
+}
+
+// Update lets the drops fall and the glyphs change.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	dt := max(t-s.lastT, 0)
+	s.lastT = t
+
+	chance := s.config.Mutation * dt
+	for i := range s.columns {
+		c := &s.columns[i]
+		if c.wait > 0 {
+			c.wait -= dt
+			continue
+		}
+		c.head += c.speed * dt
+		if int(c.head)-c.length > s.height {
+			s.drop(c)
+		}
+		for j := range c.glyphs {
+			if s.rng.Float64() < chance {
+				c.glyphs[j] = glyphs[s.rng.Intn(len(glyphs))]
+			}
+		}
+	}
+}
+
+// Render draws every falling drop.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	s.resize(width, height)
+	for x, c := range s.columns {
+		if c.wait > 0 {
+			continue
+		}
+		head := int(c.head)
+		for i := 0; i <= c.length; i++ {
+			y := head - i
+			if y < 0 || y >= height {
+				continue
+			}
+			level := 1 - float64(i)/float64(c.length+1)
+			r.SetCell(x, y, c.glyphs[y], depth, tcell.StyleDefault.Foreground(green(level, i == 0)))
+		}
+	}
+}
+
+// green returns the color of a trail glyph at a level from 1 at the head to
+// 0 at the tail end. The head itself glows nearly white.
+func green(level float64, head bool) tcell.Color {
+	if head {
+		return tcell.NewRGBColor(200, 255, 200)
+	}
+	return tcell.NewRGBColor(int32(30*level*level), int32(40+200*level), int32(20+50*level*level))
+}
@@ -0,0 +1,275 @@
+// Package pendulum provides a double pendulum chaos scene for the screensaver.
+package pendulum
+
+import (
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	gravity      = 9.81
+	armLength    = 1.0
+	stepSize     = 0.005 // Integration step in simulation seconds
+	armScale     = 0.22  // Arm length as a fraction of screen height
+	trailDepth   = -1.0
+	armDepth     = 1.0
+	bobDepth     = 2.0
+	minTrailFade = 0.15
+)
+
+// Config holds parameters for the double pendulum scene.
+type Config struct {
+	// Number of pendulums swinging from the same pivot
+	Count int
+	// Difference in initial angle between neighbouring pendulums (radians)
+	Spread float64
+	// Number of recent bob positions kept for the fading trail
+	TrailLength int
+	// Initial angles of the first pendulum (radians)
+	Theta1, Theta2 float64
+}
+
+// DefaultConfig returns defaults that make the divergence visible within a few seconds.
+func DefaultConfig() Config {
+	return Config{
+		Count:       5,
+		Spread:      0.001,
+		TrailLength: 80,
+		Theta1:      math.Pi * 0.75,
+		Theta2:      math.Pi * 0.9,
+	}
+}
+
+// state holds the angles and angular velocities of one double pendulum.
+type state struct {
+	theta1, theta2 float64
+	omega1, omega2 float64
+}
+
+// point is a bob position in pendulum space, relative to the pivot.
+type point struct {
+	x, y float64
+}
+
+// pendulum is a single double pendulum with its trail history.
+type pendulum struct {
+	state state
+	trail []point // Ring buffer of outer bob positions
+	head  int
+	count int
+	color [3]int32
+}
+
+// palette gives each pendulum a distinct color so diverging paths stay readable.
+var palette = [][3]int32{
+	{255, 255, 255},
+	{120, 200, 255},
+	{255, 170, 90},
+	{150, 255, 150},
+	{255, 120, 200},
+	{255, 240, 120},
+	{180, 140, 255},
+}
+
+// Scene simulates several double pendulums with slightly different initial conditions.
+type Scene struct {
+	config    Config
+	pendulums []*pendulum
+	lastT     float64
+	started   bool
+}
+
+// NewScene creates a double pendulum scene with the given configuration.
+func NewScene(cfg Config) *Scene {
+	if cfg.Count < 1 {
+		cfg.Count = 1
+	}
+	if cfg.TrailLength < 2 {
+		cfg.TrailLength = 2
+	}
+
+	s := &Scene{
+		config:    cfg,
+		pendulums: make([]*pendulum, cfg.Count),
+	}
+	for i := range s.pendulums {
+		offset := float64(i) * cfg.Spread
+		s.pendulums[i] = &pendulum{
+			state: state{
+				theta1: cfg.Theta1 + offset,
+				theta2: cfg.Theta2 + offset,
+			},
+			trail: make([]point, cfg.TrailLength),
+			color: palette[i%len(palette)],
+		}
+	}
+	return s
+}
+
+// Update advances the simulation to time t, integrating in fixed steps.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+		for _, p := range s.pendulums {
+			p.record()
+		}
+		return
+	}
+
+	dt := t - s.lastT
+	s.lastT = t
+	if dt <= 0 {
+		return
+	}
+
+	steps := int(math.Ceil(dt / stepSize))
+	h := dt / float64(steps)
+	for _, p := range s.pendulums {
+		for i := 0; i < steps; i++ {
+			p.state = rk4(p.state, h)
+		}
+		p.record()
+	}
+}
+
+// Render draws trails, arms and bobs for every pendulum.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	pivotX := float64(width) / 2
+	pivotY := float64(height) / 2
+	scale := float64(height) * armScale
+	aspect := r.CellAspect()
+
+	toScreen := func(p point) (int, int) {
+		return int(math.Round(pivotX + p.x*scale*aspect)), int(math.Round(pivotY + p.y*scale))
+	}
+
+	// Trails first so the arms always stay on top
+	for _, p := range s.pendulums {
+		for i := 1; i < p.count; i++ {
+			older := p.at(i)
+			newer := p.at(i - 1)
+			fade := 1.0 - float64(i)/float64(len(p.trail))
+			if fade < minTrailFade {
+				fade = minTrailFade
+			}
+			style := tcell.StyleDefault.Foreground(scaleColor(p.color, fade*0.7))
+			x1, y1 := toScreen(older)
+			x2, y2 := toScreen(newer)
+			r.DrawLine(x1, y1, x2, y2, trailChar(fade), trailDepth+fade, style)
+		}
+	}
+
+	for _, p := range s.pendulums {
+		inner, outer := p.state.positions()
+		px, py := int(math.Round(pivotX)), int(math.Round(pivotY))
+		x1, y1 := toScreen(inner)
+		x2, y2 := toScreen(outer)
+		armStyle := tcell.StyleDefault.Foreground(scaleColor(p.color, 0.8))
+		r.DrawLine(px, py, x1, y1, renderer.LineChar(x1-px, y1-py), armDepth, armStyle)
+		r.DrawLine(x1, y1, x2, y2, renderer.LineChar(x2-x1, y2-y1), armDepth, armStyle)
+
+		bobStyle := tcell.StyleDefault.Foreground(scaleColor(p.color, 1.0))
+		r.SetCell(x1, y1, '●', bobDepth, bobStyle)
+		r.SetCell(x2, y2, '●', bobDepth, bobStyle)
+	}
+
+	r.SetCell(int(math.Round(pivotX)), int(math.Round(pivotY)), '◆', bobDepth+1,
+		tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 200, 200)))
+}
+
+// record appends the current outer bob position to the trail ring buffer.
+func (p *pendulum) record() {
+	_, outer := p.state.positions()
+	p.trail[p.head] = outer
+	p.head = (p.head + 1) % len(p.trail)
+	if p.count < len(p.trail) {
+		p.count++
+	}
+}
+
+// at returns the trail point recorded i samples ago (0 is the newest).
+func (p *pendulum) at(i int) point {
+	idx := (p.head - 1 - i + len(p.trail)*2) % len(p.trail)
+	return p.trail[idx]
+}
+
+// positions returns the inner and outer bob positions relative to the pivot.
+func (s state) positions() (point, point) {
+	inner := point{
+		x: armLength * math.Sin(s.theta1),
+		y: armLength * math.Cos(s.theta1),
+	}
+	outer := point{
+		x: inner.x + armLength*math.Sin(s.theta2),
+		y: inner.y + armLength*math.Cos(s.theta2),
+	}
+	return inner, outer
+}
+
+// derivative returns the time derivative of the pendulum state (equal masses and arm lengths).
+func derivative(s state) state {
+	delta := s.theta1 - s.theta2
+	den := 3 - math.Cos(2*delta) // 2*m1 + m2 - m2*cos(2*delta) with m1 = m2 = 1
+
+	alpha1 := (-3*gravity*math.Sin(s.theta1) -
+		gravity*math.Sin(s.theta1-2*s.theta2) -
+		2*math.Sin(delta)*(s.omega2*s.omega2*armLength+s.omega1*s.omega1*armLength*math.Cos(delta))) /
+		(armLength * den)
+
+	alpha2 := (2 * math.Sin(delta) *
+		(s.omega1*s.omega1*armLength*2 + gravity*2*math.Cos(s.theta1) + s.omega2*s.omega2*armLength*math.Cos(delta))) /
+		(armLength * den)
+
+	return state{
+		theta1: s.omega1,
+		theta2: s.omega2,
+		omega1: alpha1,
+		omega2: alpha2,
+	}
+}
+
+// rk4 advances the state by h using fourth-order Runge-Kutta integration.
+func rk4(s state, h float64) state {
+	k1 := derivative(s)
+	k2 := derivative(s.add(k1, h/2))
+	k3 := derivative(s.add(k2, h/2))
+	k4 := derivative(s.add(k3, h))
+
+	return state{
+		theta1: s.theta1 + h/6*(k1.theta1+2*k2.theta1+2*k3.theta1+k4.theta1),
+		theta2: s.theta2 + h/6*(k1.theta2+2*k2.theta2+2*k3.theta2+k4.theta2),
+		omega1: s.omega1 + h/6*(k1.omega1+2*k2.omega1+2*k3.omega1+k4.omega1),
+		omega2: s.omega2 + h/6*(k1.omega2+2*k2.omega2+2*k3.omega2+k4.omega2),
+	}
+}
+
+// add returns s + d*h, used for intermediate Runge-Kutta states.
+func (s state) add(d state, h float64) state {
+	return state{
+		theta1: s.theta1 + d.theta1*h,
+		theta2: s.theta2 + d.theta2*h,
+		omega1: s.omega1 + d.omega1*h,
+		omega2: s.omega2 + d.omega2*h,
+	}
+}
+
+// trailChar maps trail freshness to a shade character, newest being densest.
+func trailChar(fade float64) rune {
+	switch {
+	case fade > 0.66:
+		return '•'
+	case fade > 0.33:
+		return '∙'
+	default:
+		return '·'
+	}
+}
+
+// scaleColor returns the color scaled by the given brightness factor (0-1).
+func scaleColor(c [3]int32, f float64) tcell.Color {
+	return tcell.NewRGBColor(int32(float64(c[0])*f), int32(float64(c[1])*f), int32(float64(c[2])*f))
+}
@@ -0,0 +1,284 @@
+// Package pipes provides the classic 3D pipes scene.
+package pipes
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/color"
+	"github.com/olegchuev/screensaver/internal/renderer"
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+const (
+	tilt       = 0.45 // Angle the view looks down into the grid, in radians
+	distance   = 4.0  // Camera distance from the center of the grid
+	spin       = 0.12 // Rotation of the grid in radians per second
+	pipeRadius = 0.28 // Radius of a pipe in voxels
+	jointScale = 1.5  // Radius of the ball at a bend, in pipe radii
+	samples    = 4    // Spheres drawn per voxel of pipe, sweeping out the tube
+	restartLag = 2.0  // Seconds a full grid stays on screen before clearing
+)
+
+// The camera sits in front of the grid looking at its center, z up.
+var (
+	eye    = vec.Vec3{Y: -distance}
+	camera = vec.LookAt(eye, vec.Vec3{}, vec.Vec3{Z: 1})
+)
+
+// colors are the pipes' colors, taken in turn.
+var colors = []color.RGB{
+	color.From8(220, 40, 40),
+	color.From8(40, 190, 60),
+	color.From8(50, 90, 230),
+	color.From8(235, 210, 40),
+	color.From8(40, 200, 210),
+	color.From8(210, 60, 200),
+	color.From8(230, 230, 230),
+	color.From8(240, 130, 30),
+}
+
+// directions are the six ways a pipe can run along the grid.
+var directions = [6][3]int{{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1}}
+
+// Config holds parameters for the pipes scene.
+type Config struct {
+	// Size is the number of voxels along each edge of the grid
+	Size int
+	// Speed is how many voxels a pipe grows per second
+	Speed float64
+	// Turns is the chance of a pipe turning at each voxel, from 0 to 1
+	Turns float64
+	// Fill is the share of the grid filled before it is cleared
+	Fill float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for the familiar look.
+func DefaultConfig() Config {
+	return Config{Size: 12, Speed: 10, Turns: 0.25, Fill: 0.35, Seed: 1}
+}
+
+// pipe is one pipe through the grid, voxel by voxel.
+type pipe struct {
+	voxels [][3]int
+	color  color.RGB
+	dir    int // Index into directions of the way it grows
+}
+
+// Scene grows pipes through a turning voxel grid, one at a time, each
+// turning at random and taking the next color. A pipe that runs into a wall
+// or another pipe ends and the next starts from a free voxel; once the grid
+// is filled far enough it is cleared and the pipes start over. Pipes are
+// swept spheres projected in perspective, drawn in front of each other
+// through the renderer's depth buffer, and the camera turns with any
+// controller or touch input.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	filled  []bool
+	pipes   []pipe
+	used    int     // Voxels filled
+	steps   float64 // Growth steps due, carried between frames
+	full    bool    // Whether the grid has no room for more
+	wait    float64 // Seconds the grid has been full
+	angle   float64
+	lastT   float64
+	started bool
+	nextHue int
+}
+
+// NewScene creates a pipes scene with the first pipe starting.
+func NewScene(cfg Config) *Scene {
+	cfg.Size = max(cfg.Size, 2)
+	s := &Scene{
+		config: cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		filled: make([]bool, cfg.Size*cfg.Size*cfg.Size),
+	}
+	s.start()
+	return s
+}
+
+// index returns the index of voxel v in filled, -1 outside the grid.
+func (s *Scene) index(v [3]int) int {
+	n := s.config.Size
+	for _, c := range v {
+		if c < 0 || c >= n {
+			return -1
+		}
+	}
+	return (v[2]*n+v[1])*n + v[0]
+}
+
+// free reports whether v is inside the grid and empty.
+func (s *Scene) free(v [3]int) bool {
+	i := s.index(v)
+	return i >= 0 && !s.filled[i]
+}
+
+// fill marks v as taken.
+func (s *Scene) fill(v [3]int) {
+	s.filled[s.index(v)] = true
+	s.used++
+}
+
+// start begins a new pipe at a random free voxel, returning false if none
+// was found.
+func (s *Scene) start() bool {
+	n := s.config.Size
+	for range 50 {
+		v := [3]int{s.rng.Intn(n), s.rng.Intn(n), s.rng.Intn(n)}
+		if !s.free(v) {
+			continue
+		}
+		s.fill(v)
+		s.pipes = append(s.pipes, pipe{voxels: [][3]int{v}, color: colors[s.nextHue%len(colors)], dir: s.rng.Intn(len(directions))})
+		s.nextHue++
+		return true
+	}
+	return false
+}
+
+// clear empties the grid.
+func (s *Scene) clear() {
+	clear(s.filled)
+	s.pipes = s.pipes[:0]
+	s.used = 0
+	s.full, s.wait = false, 0
+}
+
+// step grows the current pipe by one voxel, or ends it and starts the next.
+func (s *Scene) step() {
+	p := &s.pipes[len(s.pipes)-1]
+	head := p.voxels[len(p.voxels)-1]
+	next := func(d int) [3]int {
+		return [3]int{head[0] + directions[d][0], head[1] + directions[d][1], head[2] + directions[d][2]}
+	}
+
+	dir := p.dir
+	if !s.free(next(dir)) || s.rng.Float64() < s.config.Turns {
+		// Turn any free way but back
+		var ways []int
+		for d := range directions {
+			if d != dir && d != dir^1 && s.free(next(d)) {
+				ways = append(ways, d)
+			}
+		}
+		switch {
+		case len(ways) > 0:
+			dir = ways[s.rng.Intn(len(ways))]
+		case !s.free(next(dir)):
+			dir = -1
+		}
+	}
+	if dir < 0 {
+		s.full = !s.start()
+		return
+	}
+	p.dir = dir
+	v := next(dir)
+	s.fill(v)
+	p.voxels = append(p.voxels, v)
+}
+
+// Update grows the pipes and turns the grid, clearing it a while after it
+// filled up.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	dt := max(t-s.lastT, 0)
+	s.lastT = t
+	s.angle += spin * dt
+
+	if s.full || float64(s.used) >= s.config.Fill*float64(len(s.filled)) {
+		s.full = true
+		s.wait += dt
+		if s.wait >= restartLag {
+			s.clear()
+			s.start()
+		}
+		return
+	}
+	// A long pause would otherwise take a second of steps to catch up on
+	s.steps = min(s.steps+s.config.Speed*dt, s.config.Speed)
+	for ; s.steps >= 1 && !s.full; s.steps-- {
+		s.step()
+	}
+}
+
+// Render draws every pipe as spheres swept along its voxels, with a ball at
+// each bend, lit from the front and dimmed with distance.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	aspect := r.CellAspect()
+	cam := r.Camera()
+	// The grid spans about 1.7 units from its center to a corner
+	w, h := float64(width), float64(height)*aspect
+	fov := 2 * math.Atan(1.7/distance*h/min(w, h)/cam.Zoom)
+	project := r.Perspective(fov, 0.1, 2*distance).Mul(camera)
+	model := vec.RotateX(tilt).Mul(vec.RotateZ(s.angle + cam.Yaw))
+	voxel := 2 / float64(s.config.Size)
+
+	ball := func(gx, gy, gz, radius float64, c color.RGB) {
+		// Grid coordinates to the unit cube around the center, then the world
+		p, _ := model.MulPoint(vec.Vec3{X: (gx+0.5)*voxel - 1, Y: (gy+0.5)*voxel - 1, Z: (gz+0.5)*voxel - 1})
+		center, _ := project.MulPoint(p)
+		edge, _ := project.MulPoint(p.Add(vec.Vec3{X: radius * voxel}))
+		cx, cy := r.ToScreen(center)
+		ex, _ := r.ToScreen(edge)
+		rx := ex - cx
+		ry := rx / aspect
+		near := (1.7 - p.Y) / 3.4 // 1 at the front, 0 at the back
+		for py := int(math.Floor(cy - ry)); py <= int(math.Ceil(cy+ry)); py++ {
+			for px := int(math.Floor(cx - rx)); px <= int(math.Ceil(cx+rx)); px++ {
+				dx, dy := (float64(px)+0.5-cx)/max(rx, 0.5), (float64(py)+0.5-cy)/max(ry, 0.5)
+				d2 := dx*dx + dy*dy
+				if d2 > 1 {
+					continue
+				}
+				// Facing the viewer, with the light from the upper left
+				facing := math.Sqrt(1 - d2)
+				lit := max(0.55*facing-0.3*dx-0.3*dy+0.2, 0)
+				level := min(0.15+0.65*lit+0.2*near, 1)
+				style := tcell.StyleDefault.Foreground(c.Scale(level).Clamp().Tcell())
+				r.SetCell(px, py, r.ShadeChar(level), -(p.Y - facing*radius*voxel), style)
+			}
+		}
+	}
+
+	for _, p := range s.pipes {
+		for i, v := range p.voxels {
+			if i > 0 {
+				prev := p.voxels[i-1]
+				for k := 1; k < samples; k++ {
+					f := float64(k) / samples
+					ball(lerp(prev[0], v[0], f), lerp(prev[1], v[1], f), lerp(prev[2], v[2], f), pipeRadius, p.color)
+				}
+			}
+			radius := pipeRadius
+			if i == 0 || i == len(p.voxels)-1 || bends(p.voxels, i) {
+				radius *= jointScale
+			}
+			ball(float64(v[0]), float64(v[1]), float64(v[2]), radius, p.color)
+		}
+	}
+}
+
+// bends reports whether the pipe turns at voxel i.
+func bends(voxels [][3]int, i int) bool {
+	if i == 0 || i == len(voxels)-1 {
+		return false
+	}
+	a, b, c := voxels[i-1], voxels[i], voxels[i+1]
+	return b[0]-a[0] != c[0]-b[0] || b[1]-a[1] != c[1]-b[1] || b[2]-a[2] != c[2]-b[2]
+}
+
+// lerp returns the point a fraction f of the way from a to b.
+func lerp(a, b int, f float64) float64 {
+	return float64(a) + float64(b-a)*f
+}
@@ -0,0 +1,61 @@
+package plants
+
+import "strings"
+
+// species describes an L-system grammar and how the turtle interprets it.
+type species struct {
+	name       string
+	axiom      string
+	rules      map[byte]string
+	angle      float64 // Turn angle in degrees for '+' and '-'
+	iterations int
+}
+
+// catalog lists the grammars plants are grown from.
+var catalog = []species{
+	{
+		name:       "fern",
+		axiom:      "X",
+		rules:      map[byte]string{'X': "F+[[X]-X]-F[-FX]+X", 'F': "FF"},
+		angle:      25,
+		iterations: 4,
+	},
+	{
+		name:       "bush",
+		axiom:      "F",
+		rules:      map[byte]string{'F': "FF+[+F-F-F]-[-F+F+F]"},
+		angle:      22.5,
+		iterations: 3,
+	},
+	{
+		name:       "weed",
+		axiom:      "X",
+		rules:      map[byte]string{'X': "F[+X]F[-X]+X", 'F': "FF"},
+		angle:      20,
+		iterations: 5,
+	},
+	{
+		name:       "tree",
+		axiom:      "X",
+		rules:      map[byte]string{'X': "F[-X][+X]FX", 'F': "FF"},
+		angle:      26,
+		iterations: 4,
+	},
+}
+
+// expand applies the species rules to the axiom for the configured number of iterations.
+func (s species) expand() string {
+	current := s.axiom
+	for i := 0; i < s.iterations; i++ {
+		var b strings.Builder
+		for j := 0; j < len(current); j++ {
+			if rep, ok := s.rules[current[j]]; ok {
+				b.WriteString(rep)
+			} else {
+				b.WriteByte(current[j])
+			}
+		}
+		current = b.String()
+	}
+	return current
+}
@@ -0,0 +1,223 @@
+// Package plants provides a scene growing procedural L-system plants for the screensaver.
+package plants
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/animation"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	heightRatio = 0.85
+	branchDepth = 0.0
+	leafDepth   = 1.0
+)
+
+// Config holds parameters for the plants scene.
+type Config struct {
+	// Number of plants growing side by side
+	Count int
+	// Seconds for a plant to grow from seed to full size
+	GrowSeconds float64
+	// Seconds a fully grown plant is shown before it withers
+	HoldSeconds float64
+	// Seconds the withering fade lasts before regrowth
+	FadeSeconds float64
+	// Wind strength in degrees of sway at the branch tips
+	Wind float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a slowly growing garden of three plants.
+func DefaultConfig() Config {
+	return Config{
+		Count:       3,
+		GrowSeconds: 20,
+		HoldSeconds: 12,
+		FadeSeconds: 4,
+		Wind:        6,
+		Seed:        1,
+	}
+}
+
+// season holds the colors used for one regrowth cycle.
+type season struct {
+	name   string
+	branch [3]int32
+	leaf   [3]int32
+	leafCh rune
+}
+
+// seasons are cycled through on every regrowth.
+var seasons = []season{
+	{name: "spring", branch: [3]int32{120, 90, 60}, leaf: [3]int32{255, 170, 210}, leafCh: '*'},
+	{name: "summer", branch: [3]int32{110, 80, 50}, leaf: [3]int32{60, 200, 70}, leafCh: '&'},
+	{name: "autumn", branch: [3]int32{100, 70, 45}, leaf: [3]int32{235, 120, 30}, leafCh: '%'},
+	{name: "winter", branch: [3]int32{150, 140, 135}, leaf: [3]int32{235, 240, 255}, leafCh: '.'},
+}
+
+// plant is one L-system instance rooted on the ground.
+type plant struct {
+	spec    species
+	program string
+	segs    int     // Number of 'F' segments, used to pace growth
+	root    float64 // Horizontal root position as a fraction of screen width
+	phase   float64 // Wind phase offset
+	scale   float64 // Segment length multiplier relative to the screen fit
+}
+
+// Scene grows plants, sways them in the wind, and regrows them every season.
+type Scene struct {
+	config     Config
+	rng        *rand.Rand
+	plants     []plant
+	season     int
+	cycleStart float64
+	t          float64
+	started    bool
+}
+
+// NewScene creates a plants scene with the given configuration.
+func NewScene(cfg Config) *Scene {
+	if cfg.Count < 1 {
+		cfg.Count = 1
+	}
+	if cfg.GrowSeconds <= 0 {
+		cfg.GrowSeconds = 1
+	}
+	s := &Scene{
+		config: cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	s.sow()
+	return s
+}
+
+// sow replaces all plants with freshly picked species.
+func (s *Scene) sow() {
+	s.plants = s.plants[:0]
+	for i := 0; i < s.config.Count; i++ {
+		spec := catalog[s.rng.Intn(len(catalog))]
+		program := spec.expand()
+		segs := 0
+		for j := 0; j < len(program); j++ {
+			if program[j] == 'F' {
+				segs++
+			}
+		}
+		s.plants = append(s.plants, plant{
+			spec:    spec,
+			program: program,
+			segs:    segs,
+			root:    (float64(i) + 0.5 + (s.rng.Float64()-0.5)*0.4) / float64(s.config.Count),
+			phase:   s.rng.Float64() * 2 * math.Pi,
+			scale:   0.7 + s.rng.Float64()*0.3,
+		})
+	}
+}
+
+// Update advances time, moving to the next season once a cycle completes.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.cycleStart = t
+	}
+	s.t = t
+
+	cfg := s.config
+	if t-s.cycleStart >= cfg.GrowSeconds+cfg.HoldSeconds+cfg.FadeSeconds {
+		s.cycleStart = t
+		s.season = (s.season + 1) % len(seasons)
+		s.sow()
+	}
+}
+
+// Render interprets every plant with the turtle, drawing grown segments only.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	cfg := s.config
+	elapsed := s.t - s.cycleStart
+
+	// Growth slows as the plant matures, then the whole plant withers away
+	growth := animation.NewTween(0, 1, cfg.GrowSeconds, animation.EaseOutQuad).Value(elapsed)
+	brightness := animation.Sequence{
+		animation.Hold{At: 1, Length: cfg.GrowSeconds + cfg.HoldSeconds},
+		animation.NewTween(1, 0, cfg.FadeSeconds, animation.EaseInQuad),
+	}.Value(elapsed)
+
+	sn := seasons[s.season]
+	branchStyle := tcell.StyleDefault.Foreground(scaleColor(sn.branch, brightness))
+	leafStyle := tcell.StyleDefault.Foreground(scaleColor(sn.leaf, brightness))
+
+	for _, p := range s.plants {
+		s.drawPlant(r, p, width, height, growth, branchStyle, leafStyle, sn.leafCh)
+	}
+
+	// Ground line, which only changes with the screen size
+	r.Cached(groundKey{}, func() {
+		groundStyle := tcell.StyleDefault.Foreground(tcell.NewRGBColor(70, 60, 50))
+		r.DrawLine(0, height-1, width-1, height-1, '▁', branchDepth, groundStyle)
+	})
+}
+
+// groundKey names the cached ground line in the renderer.
+type groundKey struct{}
+
+// turtle is the drawing state while interpreting an L-system program.
+type turtle struct {
+	x, y  float64
+	angle float64 // Degrees, 90 points up
+	depth int     // Branch nesting level, used for wind sway
+}
+
+// drawPlant interprets one plant program, drawing only the first growth fraction of segments.
+func (s *Scene) drawPlant(r *renderer.Renderer, p plant, width, height int, growth float64,
+	branchStyle, leafStyle tcell.Style, leafCh rune) {
+	// Each doubling rule roughly doubles the height, fit it into the screen
+	step := float64(height) * heightRatio * p.scale / math.Sqrt(float64(p.segs)) / 2
+	limit := int(growth * float64(p.segs))
+	aspect := r.CellAspect()
+	sway := s.config.Wind * math.Sin(s.t*0.8+p.phase)
+
+	cur := turtle{x: p.root * float64(width), y: float64(height - 1), angle: 90}
+	stack := make([]turtle, 0, 16)
+	drawn := 0
+
+	for i := 0; i < len(p.program) && drawn < limit; i++ {
+		switch p.program[i] {
+		case 'F':
+			// Deeper branches sway more, like thin twigs
+			a := (cur.angle + sway*float64(cur.depth)/4) * math.Pi / 180
+			nx := cur.x + math.Cos(a)*step*aspect
+			ny := cur.y - math.Sin(a)*step
+			x1, y1 := int(math.Round(cur.x)), int(math.Round(cur.y))
+			x2, y2 := int(math.Round(nx)), int(math.Round(ny))
+			r.DrawLine(x1, y1, x2, y2, renderer.LineChar(x2-x1, y2-y1), branchDepth, branchStyle)
+			cur.x, cur.y = nx, ny
+			drawn++
+		case '+':
+			cur.angle += p.spec.angle
+		case '-':
+			cur.angle -= p.spec.angle
+		case '[':
+			stack = append(stack, cur)
+			cur.depth++
+		case ']':
+			// Branch tips carry the seasonal foliage
+			r.SetCell(int(math.Round(cur.x)), int(math.Round(cur.y)), leafCh, leafDepth, leafStyle)
+			if len(stack) > 0 {
+				cur = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// scaleColor returns the color scaled by the given brightness factor (0-1).
+func scaleColor(c [3]int32, f float64) tcell.Color {
+	return tcell.NewRGBColor(int32(float64(c[0])*f), int32(float64(c[1])*f), int32(float64(c[2])*f))
+}
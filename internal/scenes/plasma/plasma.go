@@ -0,0 +1,120 @@
+// Package plasma provides the classic sine-sum plasma scene.
+package plasma
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/olegchuev/screensaver/internal/noise"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const depth = 0.0
+
+// warp is the fractal noise the turbulence pushes the plasma around by.
+var warp = noise.FBM{Octaves: 2, Lacunarity: 2, Gain: 0.5}
+
+// Palettes lists the ways plasma values can be laid onto the theme gradient.
+var Palettes = []string{"smooth", "cycle", "bands"}
+
+// bands is the number of contour bands of the bands palette.
+const bands = 6
+
+// Config holds parameters for the plasma scene.
+type Config struct {
+	// Palette maps plasma values onto the theme gradient, one of Palettes:
+	// smooth follows the values, cycle rotates the gradient through them
+	// and bands cuts them into contours
+	Palette string
+	// Turbulence warps the sine waves through drifting noise, from 0 for
+	// smooth interference patterns up to about 2 for churning ones
+	Turbulence float64
+	// Speed scales how fast the plasma flows
+	Speed float64
+}
+
+// DefaultConfig returns defaults for a gently flowing plasma.
+func DefaultConfig() Config {
+	return Config{Palette: "smooth", Turbulence: 0.5, Speed: 1}
+}
+
+// ValidPalette reports an error if name is not one of Palettes.
+func ValidPalette(name string) error {
+	for _, p := range Palettes {
+		if p == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette %q (available: %s)", name, strings.Join(Palettes, ", "))
+}
+
+// Scene fills every cell with the sum of sine waves across the screen,
+// around a moving center and through each other, shaded and colored through
+// the renderer's gradient. Drawing the whole screen each frame makes it a
+// good measure of the per-cell cost of the renderer.
+type Scene struct {
+	config Config
+	t      float64
+	noise  *noise.Simplex
+}
+
+// NewScene creates a plasma scene.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, noise: noise.NewSimplex(1)}
+}
+
+// Update advances the plasma to time t.
+func (s *Scene) Update(t float64) {
+	s.t = t * s.config.Speed
+}
+
+// value returns the plasma at (u, v), in screen heights from the top left,
+// from 0 to 1.
+func (s *Scene) value(u, v float64) float64 {
+	t := s.t
+	if k := s.config.Turbulence; k > 0 {
+		// Two samples far apart in the noise push u and v independently,
+		// the time axis letting the warp churn rather than slide
+		u, v = u+k*0.15*warp.Noise3(s.noise, u*2, v*2, t*0.2),
+			v+k*0.15*warp.Noise3(s.noise, u*2+31.7, v*2-17.3, t*0.2)
+	}
+	cx, cy := u-0.5-0.6*math.Sin(t*0.23), v-0.5-0.4*math.Cos(t*0.31)
+	sum := math.Sin(u*7+t) +
+		math.Sin((v*6+t)*0.9) +
+		math.Sin((u+v)*5+t*1.3) +
+		math.Sin(math.Sqrt(cx*cx+cy*cy)*12-t*1.7)
+	// The sum rarely reaches its extremes, so it is stretched over the
+	// whole gradient
+	return (max(-1, min(sum/2.5, 1)) + 1) / 2
+}
+
+// level maps a plasma value to a gradient position through the palette.
+func (s *Scene) level(value float64) float64 {
+	switch s.config.Palette {
+	case "cycle":
+		// Up the gradient and back down, so the rotation has no seam
+		return math.Abs(math.Mod(value*2+s.t*0.2, 2) - 1)
+	case "bands":
+		return math.Min(math.Floor(value*bands)/(bands-1), 1)
+	}
+	return value
+}
+
+// Render draws the plasma over every cell of the screen.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if height == 0 {
+		return
+	}
+	// Cells are taller than wide, so x is scaled for round blobs
+	scale := 1 / float64(height)
+	aspect := r.CellAspect()
+	for y := range height {
+		v := float64(y) * scale
+		for x := range width {
+			level := s.level(s.value(float64(x)*scale/aspect, v))
+			r.SetCell(x, y, r.ShadeCharAt(x, y, level), depth, r.GradientStyle(level))
+		}
+	}
+}
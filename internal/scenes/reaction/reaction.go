@@ -0,0 +1,240 @@
+// Package reaction provides a Gray-Scott reaction-diffusion scene for the screensaver.
+package reaction
+
+import (
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	diffusionU = 1.0
+	diffusionV = 0.5
+	depth      = 0.0
+)
+
+// Preset holds the feed and kill rates that select a Gray-Scott pattern family.
+type Preset struct {
+	Name string
+	Feed float64
+	Kill float64
+}
+
+// Presets lists the built-in parameter sets in the order they are cycled.
+var Presets = []Preset{
+	{Name: "mitosis", Feed: 0.0367, Kill: 0.0649},
+	{Name: "coral", Feed: 0.0545, Kill: 0.062},
+	{Name: "waves", Feed: 0.014, Kill: 0.045},
+}
+
+// Config holds parameters for the reaction-diffusion scene.
+type Config struct {
+	// Name of the initial preset
+	Preset string
+	// Simulation steps per second of animation time
+	StepsPerSecond float64
+	// Seconds between new seed drops that keep the pattern evolving
+	ReseedInterval float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults starting with the coral preset.
+func DefaultConfig() Config {
+	return Config{
+		Preset:         "coral",
+		StepsPerSecond: 150,
+		ReseedInterval: 12,
+		Seed:           1,
+	}
+}
+
+// Scene simulates two chemicals U and V reacting and diffusing on the cell grid.
+type Scene struct {
+	config     Config
+	preset     int
+	width      int
+	height     int
+	u, v       []float64
+	nextU      []float64
+	nextV      []float64
+	rng        *rand.Rand
+	lastT      float64
+	started    bool
+	pending    float64 // Fractional steps carried to the next frame
+	lastReseed float64
+}
+
+// NewScene creates a reaction-diffusion scene with the given configuration.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{
+		config: cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for i, p := range Presets {
+		if p.Name == cfg.Preset {
+			s.preset = i
+		}
+	}
+	return s
+}
+
+// Preset returns the active parameter preset.
+func (s *Scene) Preset() Preset {
+	return Presets[s.preset]
+}
+
+// NextPreset switches to the next preset and reseeds the grid.
+func (s *Scene) NextPreset() {
+	s.SetPreset((s.preset + 1) % len(Presets))
+}
+
+// SetPreset switches to the preset at index i and reseeds the grid.
+func (s *Scene) SetPreset(i int) {
+	if i < 0 || i >= len(Presets) {
+		return
+	}
+	s.preset = i
+	s.reset()
+}
+
+// HandleKey switches presets: 'p' cycles, digits select directly.
+func (s *Scene) HandleKey(ev *tcell.EventKey) bool {
+	if ev.Key() != tcell.KeyRune {
+		return false
+	}
+	switch r := ev.Rune(); {
+	case r == 'p' || r == 'P':
+		s.NextPreset()
+		return true
+	case r >= '1' && r <= '9':
+		s.SetPreset(int(r - '1'))
+		return true
+	}
+	return false
+}
+
+// resize reallocates the grid to match the screen and reseeds it.
+func (s *Scene) resize(width, height int) {
+	s.width, s.height = width, height
+	n := width * height
+	s.u = make([]float64, n)
+	s.v = make([]float64, n)
+	s.nextU = make([]float64, n)
+	s.nextV = make([]float64, n)
+	s.reset()
+}
+
+// reset fills the grid with U and drops a few V seeds.
+func (s *Scene) reset() {
+	for i := range s.u {
+		s.u[i] = 1
+		s.v[i] = 0
+	}
+	seeds := max(3, s.width*s.height/400)
+	for i := 0; i < seeds; i++ {
+		s.drop()
+	}
+}
+
+// drop places a small square of V at a random position.
+func (s *Scene) drop() {
+	if s.width < 4 || s.height < 4 {
+		return
+	}
+	cx := s.rng.Intn(s.width)
+	cy := s.rng.Intn(s.height)
+	for dy := -3; dy <= 3; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			i := s.index(cx+dx, cy+dy)
+			s.u[i] = 0.5
+			s.v[i] = 0.25 + s.rng.Float64()*0.05
+		}
+	}
+}
+
+// index returns the flat grid index with toroidal wrapping.
+func (s *Scene) index(x, y int) int {
+	x = (x%s.width + s.width) % s.width
+	y = (y%s.height + s.height) % s.height
+	return y*s.width + x
+}
+
+// Update advances the simulation by the number of steps elapsed since the last frame.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+		s.lastReseed = t
+		return
+	}
+	dt := t - s.lastT
+	s.lastT = t
+	if dt <= 0 || s.width == 0 {
+		return
+	}
+
+	if s.config.ReseedInterval > 0 && t-s.lastReseed >= s.config.ReseedInterval {
+		s.lastReseed = t
+		s.drop()
+	}
+
+	s.pending += dt * s.config.StepsPerSecond
+	steps := int(s.pending)
+	s.pending -= float64(steps)
+	for i := 0; i < steps; i++ {
+		s.step()
+	}
+}
+
+// step performs a single explicit Euler update of the Gray-Scott equations.
+func (s *Scene) step() {
+	p := Presets[s.preset]
+	w, h := s.width, s.height
+	for y := 0; y < h; y++ {
+		up := ((y - 1 + h) % h) * w
+		row := y * w
+		down := ((y + 1) % h) * w
+		for x := 0; x < w; x++ {
+			left := (x - 1 + w) % w
+			right := (x + 1) % w
+			i := row + x
+
+			// 3x3 Laplacian: adjacent 0.2, diagonal 0.05, center -1
+			lapU := 0.2*(s.u[up+x]+s.u[down+x]+s.u[row+left]+s.u[row+right]) +
+				0.05*(s.u[up+left]+s.u[up+right]+s.u[down+left]+s.u[down+right]) - s.u[i]
+			lapV := 0.2*(s.v[up+x]+s.v[down+x]+s.v[row+left]+s.v[row+right]) +
+				0.05*(s.v[up+left]+s.v[up+right]+s.v[down+left]+s.v[down+right]) - s.v[i]
+
+			u, v := s.u[i], s.v[i]
+			uvv := u * v * v
+			s.nextU[i] = u + diffusionU*lapU - uvv + p.Feed*(1-u)
+			s.nextV[i] = v + diffusionV*lapV + uvv - (p.Feed+p.Kill)*v
+		}
+	}
+	s.u, s.nextU = s.nextU, s.u
+	s.v, s.nextV = s.nextV, s.v
+}
+
+// Render maps the V concentration through the shade ramp and color gradient.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	if width != s.width || height != s.height {
+		s.resize(width, height)
+	}
+
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			// V rarely exceeds ~0.4, stretch it to the full range
+			level := s.v[y*s.width+x] * 2.5
+			if level < 0.05 {
+				continue
+			}
+			if level > 1 {
+				level = 1
+			}
+			r.SetCell(x, y, r.ShadeCharAt(x, y, level), depth, r.GradientStyle(level))
+		}
+	}
+}
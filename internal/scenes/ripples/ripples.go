@@ -0,0 +1,150 @@
+// Package ripples provides a scene of rain falling on still water, every
+// drop sending out rings of ripples.
+package ripples
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	depth     = 0.0
+	stepRate  = 30.0  // Simulation steps per second, whatever the frame rate
+	dropRate  = 0.004 // Drops per cell per second at rain 1
+	dropDepth = 6.0   // How deep a drop pushes the water down
+	slopeGain = 0.3   // Brightness per unit of slope toward the light
+	warmUp    = 3     // Seconds of rain already fallen on a new screen
+)
+
+// Config holds parameters for the ripples scene.
+type Config struct {
+	// Rain scales how many drops fall, 1 for a steady shower
+	Rain float64
+	// Damping is the share of a ripple's height kept every step, from 0
+	// for water that swallows drops at once to 1 for ripples that never die
+	Damping float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a steady shower on a pond.
+func DefaultConfig() Config {
+	return Config{Rain: 1, Damping: 0.97, Seed: 1}
+}
+
+// Scene solves the classic water ripple height field: every step each
+// point moves toward the average of its neighbours, overshooting by its
+// height the step before, which makes disturbances spread as rings. The
+// field has about twice as many rows as the screen so the rings come out
+// round in tall cells.
+type Scene struct {
+	config  Config
+	rng     *rand.Rand
+	prev    [][]float64 // Heights a step ago
+	cur     [][]float64 // Heights now
+	rows    int         // Field rows per screen row, from the cell aspect
+	drops   float64     // Drops due, carried between steps
+	steps   float64     // Simulation steps due, carried between frames
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a ripples scene. The water is laid out on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	return &Scene{config: cfg, rng: rand.New(rand.NewSource(cfg.Seed))}
+}
+
+// resize lays the water out for a screen of the given size, already
+// rippling. Resizing calms it.
+func (s *Scene) resize(width, height int, aspect float64) {
+	rows := max(int(math.Round(aspect)), 1)
+	if len(s.cur) == height*rows && s.rows == rows && (height == 0 || len(s.cur[0]) == width) {
+		return
+	}
+	fresh := len(s.cur) == 0
+	s.rows = rows
+	s.prev = make([][]float64, height*rows)
+	s.cur = make([][]float64, height*rows)
+	for y := range s.cur {
+		s.prev[y] = make([]float64, width)
+		s.cur[y] = make([]float64, width)
+	}
+	if fresh {
+		for range int(warmUp * stepRate) {
+			s.step()
+		}
+	}
+}
+
+// Update runs the simulation steps due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.steps += max(t-s.lastT, 0) * stepRate
+	s.lastT = t
+	// A long pause would otherwise take seconds of steps to catch up on
+	s.steps = min(s.steps, stepRate)
+	for ; s.steps >= 1; s.steps-- {
+		s.step()
+	}
+}
+
+// step lets the drops due fall and moves the ripples on.
+func (s *Scene) step() {
+	h := len(s.cur)
+	if h < 3 || len(s.cur[0]) < 3 {
+		return
+	}
+	w := len(s.cur[0])
+
+	s.drops += dropRate * max(s.config.Rain, 0) * float64(w*h/s.rows) / stepRate
+	for ; s.drops >= 1; s.drops-- {
+		x, y := 1+s.rng.Intn(w-2), 1+s.rng.Intn(h-2)
+		strength := dropDepth * (0.5 + s.rng.Float64())
+		s.cur[y][x] -= strength
+		// A drop is wider than a point, so its rings are smooth
+		for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			s.cur[y+d[1]][x+d[0]] -= strength / 2
+		}
+	}
+
+	// The new heights replace the oldest ones, the edges stay still
+	for y := 1; y < h-1; y++ {
+		above, row, below, next := s.cur[y-1], s.cur[y], s.cur[y+1], s.prev[y]
+		for x := 1; x < w-1; x++ {
+			next[x] = ((row[x-1]+row[x+1]+above[x]+below[x])/2 - next[x]) * s.config.Damping
+		}
+	}
+	s.prev, s.cur = s.cur, s.prev
+}
+
+// Render shades the water by its slope, lit from the top left, with the
+// theme's gradient: faces of ripples toward the light are bright, those
+// away from it dark.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	s.resize(width, height, r.CellAspect())
+	if len(s.cur) < 3 {
+		return
+	}
+	for y := range height {
+		for x := range width {
+			// The field rows this screen row covers, each sloping left to
+			// right and top to bottom
+			slope := 0.0
+			for sub := range s.rows {
+				fy := y*s.rows + sub
+				up, down := max(fy-1, 0), min(fy+1, len(s.cur)-1)
+				left, right := max(x-1, 0), min(x+1, width-1)
+				slope += s.cur[fy][left] - s.cur[fy][right] + s.cur[up][x] - s.cur[down][x]
+			}
+			level := min(max(0.35+slope*slopeGain/float64(s.rows), 0), 1)
+			r.SetCell(x, y, r.ShadeCharAt(x, y, level), depth, r.GradientStyle(level))
+		}
+	}
+}
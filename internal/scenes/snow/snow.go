@@ -0,0 +1,209 @@
+// Package snow provides a scene of snow falling in the wind and piling up
+// along the bottom of the screen.
+package snow
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	stepRate   = 20.0 // Simulation steps per second, whatever the frame rate
+	flakeRate  = 0.25 // Flakes per column per second at density 1
+	windSpeed  = 8.0  // Sideways speed of flakes in cells per second at wind 1
+	flakeDepth = 0.15 // Rows of snow one landed flake adds
+	maxPile    = 0.35 // Share of the screen height the snow piles up to
+	pileDepth  = 0.0
+	flakeFront = 1.0
+)
+
+// pileChars fill the top cell of a column of snow by eighths.
+var pileChars = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// Config holds parameters for the snow scene.
+type Config struct {
+	// Density scales how thick the snow falls, 1 for a steady snowfall
+	Density float64
+	// Wind blows the flakes sideways in gusts, from -1 hard to the left to
+	// 1 hard to the right
+	Wind float64
+	// Melt is how many rows of the fallen snow melt away a minute
+	Melt float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a steady snowfall in a light breeze.
+func DefaultConfig() Config {
+	return Config{Density: 1, Wind: 0.2, Melt: 1, Seed: 1}
+}
+
+// Scene lets snowflakes fall in the wind. Flakes that reach the ground add
+// to the snow piled up in their column, which slides off into lower
+// neighbours and slowly melts away.
+type Scene struct {
+	config  Config
+	flakes  *particle.System
+	pile    []float64 // Rows of snow lying in every column
+	width   int
+	height  int
+	gust    float64 // Sideways speed flakes are pulled toward, in cells per second
+	clock   float64 // Seconds simulated, for the gusts
+	steps   float64 // Simulation steps due, carried between frames
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a snow scene. The flakes start falling on the first
+// render, once the screen size is known.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{config: cfg, flakes: particle.NewSystem(4096, cfg.Seed)}
+	s.flakes.Forces = []particle.Force{func(p *particle.Particle, dt float64) {
+		// Nearer flakes, with a larger seed, catch more of the wind
+		target := s.gust * (0.5 + p.Seed)
+		p.VX += (target - p.VX) * min(2*dt, 1)
+	}}
+	s.flakes.Emitters = []*particle.Emitter{{Spawn: s.spawn}}
+	return s
+}
+
+// spawn starts a flake above a random column, falling faster the nearer it
+// is.
+func (s *Scene) spawn(p *particle.Particle, rng *rand.Rand) {
+	p.X = rng.Float64() * float64(s.width)
+	p.Y = -1
+	p.VX = s.gust * (0.5 + p.Seed)
+	p.VY = 2 + 5*p.Seed
+	// Retired when it lands
+	p.Life = math.Inf(1)
+}
+
+// resize fits the flakes and the fallen snow to a screen of the given
+// size. A new scene starts with the snow already falling.
+func (s *Scene) resize(width, height int) {
+	if width == s.width && height == s.height {
+		return
+	}
+	pile := make([]float64, width)
+	for x := range pile {
+		if s.width > 0 {
+			pile[x] = min(s.pile[x*s.width/width], maxPile*float64(height))
+		}
+	}
+	for i := range s.flakes.Particles() {
+		p := &s.flakes.Particles()[i]
+		if s.width > 0 {
+			p.X *= float64(width) / float64(s.width)
+			p.Y *= float64(height) / float64(s.height)
+		}
+	}
+	falling := s.width > 0
+	s.pile, s.width, s.height = pile, width, height
+	s.flakes.Emitters[0].Rate = flakeRate * max(s.config.Density, 0) * float64(width)
+	if !falling {
+		// Long enough for the slowest flakes to reach the ground
+		for range int(stepRate * float64(height) / 2) {
+			s.step(1 / stepRate)
+		}
+	}
+}
+
+// Update runs the simulation steps due by time t.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	s.steps += max(t-s.lastT, 0) * stepRate
+	s.lastT = t
+	// A long pause would otherwise take seconds of steps to catch up on
+	s.steps = min(s.steps, stepRate)
+	for ; s.steps >= 1; s.steps-- {
+		s.step(1 / stepRate)
+	}
+}
+
+// step moves the flakes on by dt seconds, lands those that reach the
+// snow, and lets the snow settle and melt.
+func (s *Scene) step(dt float64) {
+	if s.width == 0 || s.height == 0 {
+		return
+	}
+	s.clock += dt
+	// Gusts rise and fall around the configured wind
+	s.gust = windSpeed * s.config.Wind * (1 + 0.5*math.Sin(s.clock*0.4)*math.Sin(s.clock*0.13))
+	s.flakes.Update(dt)
+
+	w, h := float64(s.width), float64(s.height)
+	for i := range s.flakes.Particles() {
+		p := &s.flakes.Particles()[i]
+		p.X = math.Mod(math.Mod(p.X, w)+w, w)
+		x := min(int(p.X), s.width-1)
+		if p.Y < h-s.pile[x] || p.Age >= p.Life {
+			continue
+		}
+		if s.pile[x] < maxPile*h {
+			s.pile[x] += flakeDepth
+		}
+		p.Life = p.Age
+	}
+
+	// Snow slides off steep edges into the lower column next to it
+	for x := range s.width - 1 {
+		if d := s.pile[x] - s.pile[x+1]; math.Abs(d) > 1 {
+			move := (d - math.Copysign(1, d)) / 2
+			s.pile[x] -= move
+			s.pile[x+1] += move
+		}
+	}
+	melt := s.config.Melt * dt / 60
+	for x := range s.pile {
+		s.pile[x] = max(s.pile[x]-melt, 0)
+	}
+}
+
+// Render draws the fallen snow in blocks filled by eighths and the flakes
+// in front of it, the nearer ones larger and brighter.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	s.resize(width, height)
+	if width == 0 || height == 0 {
+		return
+	}
+
+	for x, depth := range s.pile {
+		for row := 0; float64(row) < depth && row < height; row++ {
+			fill := min(depth-float64(row), 1)
+			ch := pileChars[int(math.Round(fill*float64(len(pileChars)-1)))]
+			if ch == ' ' {
+				continue
+			}
+			// Packed snow is bluer than the fresh snow on top
+			shade := min((depth-float64(row))/(maxPile*float64(height)), 1)
+			style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(int32(245-70*shade), int32(248-55*shade), 255-int32(30*shade)))
+			r.SetCell(x, height-1-row, ch, pileDepth, style)
+		}
+	}
+
+	for _, p := range s.flakes.Particles() {
+		if p.Age >= p.Life {
+			continue
+		}
+		level := int32(140 + 115*p.Seed)
+		style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(level-10, level-5, level))
+		ch := '.'
+		switch {
+		case p.Seed > 0.85:
+			ch = '*'
+		case p.Seed > 0.5:
+			ch = '+'
+		}
+		// Flakes flutter from side to side as they fall
+		x := p.X + 0.6*math.Sin(p.Age*(2+p.Seed)+p.Seed*2*math.Pi)
+		r.SetCell(int(math.Floor(x)), int(math.Floor(p.Y)), ch, flakeFront+p.Seed, style)
+	}
+}
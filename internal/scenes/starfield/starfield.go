@@ -0,0 +1,119 @@
+// Package starfield provides a perspective starfield flying toward the viewer.
+package starfield
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	near  = 0.02 // Stars closer than this have passed the viewer
+	far   = 1.0  // Stars are born at this distance
+	fov   = 0.5  // Half the screen height spans this slope from the view axis
+	trail = 0.12 // Seconds of travel a streak shows behind its star
+)
+
+// Config holds parameters for the starfield scene.
+type Config struct {
+	// Number of stars in flight
+	Stars int
+	// Velocity toward the viewer, in depths of the field per second
+	Speed float64
+	// Seed for the random number generator
+	Seed int64
+}
+
+// DefaultConfig returns defaults for a steady cruise through the stars.
+func DefaultConfig() Config {
+	return Config{Stars: 400, Speed: 0.35, Seed: 1}
+}
+
+// star is a point in the field; x and y run across it, z away from the
+// viewer from near to far.
+type star struct {
+	x, y, z float64
+}
+
+// Scene flies through a field of stars that streak past the viewer, the
+// streaks growing with the speed. Nearer stars are brighter and drawn over
+// farther ones through the renderer's depth buffer.
+type Scene struct {
+	config  Config
+	stars   []star
+	rng     *rand.Rand
+	lastT   float64
+	started bool
+}
+
+// NewScene creates a starfield scene with the stars spread through the field.
+func NewScene(cfg Config) *Scene {
+	s := &Scene{
+		config: cfg,
+		stars:  make([]star, max(cfg.Stars, 0)),
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+	}
+	for i := range s.stars {
+		s.stars[i] = s.spawn(near + s.rng.Float64()*(far-near))
+	}
+	return s
+}
+
+// spawn returns a star at depth z, anywhere it can be seen from at the
+// field's far end.
+func (s *Scene) spawn(z float64) star {
+	return star{x: (s.rng.Float64()*2 - 1) * far, y: (s.rng.Float64()*2 - 1) * far, z: z}
+}
+
+// Update moves the stars toward the viewer and replaces those that passed.
+func (s *Scene) Update(t float64) {
+	if !s.started {
+		s.started = true
+		s.lastT = t
+	}
+	dt := max(t-s.lastT, 0)
+	s.lastT = t
+	for i := range s.stars {
+		st := &s.stars[i]
+		st.z -= s.config.Speed * dt
+		if st.z < near {
+			// However far it went past, the star comes back within the field
+			*st = s.spawn(far - math.Mod(near-st.z, far-near))
+		}
+	}
+}
+
+// Render draws each star as a streak from where it was a moment ago, shaded
+// brighter the closer it is.
+func (s *Scene) Render(r *renderer.Renderer) {
+	width, height := r.Size()
+	cx, cy := float64(width)/2, float64(height)/2
+	scaleY := cy / fov
+	scaleX := scaleY * r.CellAspect()
+	project := func(x, y, z float64) (float64, float64) {
+		return cx + x/z*scaleX, cy + y/z*scaleY
+	}
+
+	length := s.config.Speed * trail
+	for _, st := range s.stars {
+		tz := math.Min(st.z+length, far)
+		hx, hy := project(st.x, st.y, st.z)
+		tx, ty := project(st.x, st.y, tz)
+		level := 1 - (st.z-near)/(far-near)
+		// Drawn from the tail, so a head far off screen costs nothing
+		steps := int(math.Max(math.Abs(hx-tx), math.Abs(hy-ty)))
+		steps = min(steps, width+height)
+		for i := 0; i <= steps; i++ {
+			f := 1.0
+			if steps > 0 {
+				f = float64(i) / float64(steps)
+			}
+			// The streak fades toward its tail, which is also farther away
+			shade := level * (0.3 + 0.7*f)
+			x := int(math.Round(tx + (hx-tx)*f))
+			y := int(math.Round(ty + (hy-ty)*f))
+			r.SetCell(x, y, r.ShadeChar(shade), -(tz + (st.z-tz)*f), r.GradientStyle(shade))
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package starfield
+
+import "testing"
+
+func TestUpdateKeepsStarsInField(t *testing.T) {
+	for _, speed := range []float64{0.35, 4, 100} {
+		s := NewScene(Config{Stars: 50, Speed: speed, Seed: 1})
+		for frame := range 20 {
+			s.Update(float64(frame) * 0.25)
+			for _, st := range s.stars {
+				if st.z < near || st.z > far {
+					t.Fatalf("speed %g: star at depth %g after frame %d, want %g to %g", speed, st.z, frame, near, far)
+				}
+			}
+		}
+	}
+}
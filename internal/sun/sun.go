@@ -0,0 +1,78 @@
+// Package sun works out where the sun stands in the sky, for following
+// sunrise, sunset and golden hour at a place.
+package sun
+
+import (
+	"math"
+	"time"
+)
+
+// Elevations of the sun's center, in degrees above the horizon, at the
+// moments the day's light changes.
+const (
+	// Sunrise and sunset, when the upper edge of the sun meets the horizon
+	// seen through the atmosphere
+	Horizon = -0.833
+	// Golden hour lasts while the sun is lower than this
+	GoldenHour = 6
+	// Civil twilight ends, and night begins, with the sun this far down
+	Twilight = -6
+)
+
+// Elevation returns the height of the sun above the horizon in degrees at
+// time t, seen from latitude lat and longitude lon in degrees, following
+// NOAA's solar position equations; they are good to about a minute of
+// sunrise time away from the poles.
+func Elevation(t time.Time, lat, lon float64) float64 {
+	t = t.UTC()
+	// Julian centuries since J2000.0
+	jd := float64(t.Unix())/86400 + 2440587.5
+	c := (jd - 2451545) / 36525
+
+	// Geometric mean longitude and anomaly of the sun, and the earth's
+	// orbital eccentricity
+	l0 := math.Mod(280.46646+c*(36000.76983+c*0.0003032), 360)
+	m := 357.52911 + c*(35999.05029-0.0001537*c)
+	e := 0.016708634 - c*(0.000042037+0.0000001267*c)
+
+	center := sin(m)*(1.914602-c*(0.004817+0.000014*c)) + sin(2*m)*(0.019993-0.000101*c) + sin(3*m)*0.000289
+	omega := 125.04 - 1934.136*c
+	apparent := l0 + center - 0.00569 - 0.00478*sin(omega)
+	obliquity := 23 + (26+(21.448-c*(46.815+c*(0.00059-c*0.001813)))/60)/60 + 0.00256*cos(omega)
+	declination := deg(math.Asin(sin(obliquity) * sin(apparent)))
+
+	// Equation of time, in minutes
+	y := math.Pow(math.Tan(rad(obliquity/2)), 2)
+	eot := 4 * deg(y*sin(2*l0)-2*e*sin(m)+4*e*y*sin(m)*cos(2*l0)-0.5*y*y*sin(4*l0)-1.25*e*e*sin(2*m))
+
+	minutes := float64(t.Hour()*60+t.Minute()) + float64(t.Second())/60
+	solarTime := math.Mod(minutes+eot+4*lon, 1440)
+	hourAngle := solarTime/4 - 180
+	if hourAngle < -180 {
+		hourAngle += 360
+	}
+
+	zenith := deg(math.Acos(sin(lat)*sin(declination) + cos(lat)*cos(declination)*cos(hourAngle)))
+	return 90 - zenith
+}
+
+// Phase returns how far into the night the light is at an elevation, from
+// 0 in daylight through 0.5 at the horizon to 1 once twilight has ended.
+// The steps in between are golden hour above the horizon and twilight
+// below it.
+func Phase(elevation float64) float64 {
+	switch {
+	case elevation >= GoldenHour:
+		return 0
+	case elevation >= Horizon:
+		return 0.5 * (GoldenHour - elevation) / (GoldenHour - Horizon)
+	case elevation > Twilight:
+		return 0.5 + 0.5*(Horizon-elevation)/(Horizon-Twilight)
+	}
+	return 1
+}
+
+func rad(d float64) float64 { return d * math.Pi / 180 }
+func deg(r float64) float64 { return r * 180 / math.Pi }
+func sin(d float64) float64 { return math.Sin(rad(d)) }
+func cos(d float64) float64 { return math.Cos(rad(d)) }
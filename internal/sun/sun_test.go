@@ -0,0 +1,38 @@
+package sun
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestElevation(t *testing.T) {
+	tests := []struct {
+		name     string
+		at       time.Time
+		lat, lon float64
+		want     float64
+	}{
+		// Published times: sunrise in London 04:43 BST, solar noon in
+		// Berlin at 61.8 degrees, sunset in Sydney 17:08 AEST
+		{"London sunrise", time.Date(2024, 6, 21, 3, 43, 0, 0, time.UTC), 51.5074, -0.1278, Horizon},
+		{"Berlin noon", time.Date(2024, 6, 21, 11, 7, 0, 0, time.UTC), 52.52, 13.405, 60.95},
+		{"Sydney sunset", time.Date(2024, 3, 20, 8, 8, 0, 0, time.UTC), -33.8688, 151.2093, Horizon},
+		{"Equator midnight", time.Date(2024, 3, 20, 0, 7, 0, 0, time.UTC), 0, 0, -90},
+	}
+	for _, tt := range tests {
+		if got := Elevation(tt.at, tt.lat, tt.lon); math.Abs(got-tt.want) > 1 {
+			t.Errorf("%s: got %.2f degrees, want %.2f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPhase(t *testing.T) {
+	for _, tt := range []struct{ elevation, want float64 }{
+		{40, 0}, {GoldenHour, 0}, {Horizon, 0.5}, {Twilight, 1}, {-30, 1},
+	} {
+		if got := Phase(tt.elevation); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Phase(%g) = %g, want %g", tt.elevation, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+// Package telnet implements the server side of the telnet protocol as far
+// as a full screen animation needs it: character at a time input without
+// local echo, window size reports, and decoding of the key sequences
+// terminals send.
+package telnet
+
+import (
+	"bufio"
+	"io"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Protocol bytes, see RFC 854 and the option RFCs.
+const (
+	iac  = 255 // Interpret as command
+	dont = 254
+	do   = 253
+	wont = 252
+	will = 251
+	sb   = 250 // Subnegotiation begin
+	se   = 240 // Subnegotiation end
+
+	optEcho = 1  // RFC 857
+	optSGA  = 3  // Suppress go ahead, RFC 858
+	optNAWS = 31 // Negotiate about window size, RFC 1073
+)
+
+// Negotiate asks the client to send every key as it is pressed, to leave
+// echoing to the server, and to report its window size.
+func Negotiate(w io.Writer) error {
+	_, err := w.Write([]byte{iac, will, optEcho, iac, will, optSGA, iac, do, optNAWS})
+	return err
+}
+
+// Event is one input event from a client: a key press, or a new window
+// size when Width and Height are set.
+type Event struct {
+	Key  tcell.Key
+	Rune rune // The character for tcell.KeyRune
+	Mod  tcell.ModMask
+
+	Width, Height int
+}
+
+// escapeKeys maps the final byte of CSI and SS3 sequences to keys.
+var escapeKeys = map[byte]tcell.Key{
+	'A': tcell.KeyUp,
+	'B': tcell.KeyDown,
+	'C': tcell.KeyRight,
+	'D': tcell.KeyLeft,
+	'H': tcell.KeyHome,
+	'F': tcell.KeyEnd,
+	'Z': tcell.KeyBacktab,
+}
+
+// Reader decodes the input of a telnet client into events.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a reader decoding the client input read from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next event. Protocol commands and key sequences without
+// a meaning for the app are skipped.
+func (r *Reader) Read() (Event, error) {
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return Event{}, err
+		}
+		var ev Event
+		var ok bool
+		if b == iac {
+			ev, ok, err = r.command()
+		} else {
+			ev, ok, err = r.key(b)
+		}
+		if err != nil || ok {
+			return ev, err
+		}
+	}
+}
+
+// command reads the rest of a command, returning an event for window size
+// reports and for escaped 255 bytes in the data.
+func (r *Reader) command() (Event, bool, error) {
+	cmd, err := r.r.ReadByte()
+	if err != nil {
+		return Event{}, false, err
+	}
+	switch cmd {
+	case iac:
+		// A doubled IAC is a literal byte, which is never part of valid UTF-8
+		return Event{}, false, nil
+	case will, wont, do, dont:
+		_, err = r.r.ReadByte()
+		return Event{}, false, err
+	case sb:
+		opt, data, err := r.subnegotiation()
+		if err != nil || opt != optNAWS || len(data) != 4 {
+			return Event{}, false, err
+		}
+		w := int(data[0])<<8 | int(data[1])
+		h := int(data[2])<<8 | int(data[3])
+		return Event{Width: w, Height: h}, w > 0 && h > 0, nil
+	}
+	return Event{}, false, nil
+}
+
+// subnegotiation reads the option and data of a subnegotiation up to its
+// closing IAC SE, undoubling escaped IAC bytes.
+func (r *Reader) subnegotiation() (byte, []byte, error) {
+	opt, err := r.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	var data []byte
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if b == iac {
+			if b, err = r.r.ReadByte(); err != nil {
+				return 0, nil, err
+			}
+			if b == se {
+				return opt, data, nil
+			}
+		}
+		data = append(data, b)
+	}
+}
+
+// key decodes the key starting with byte b.
+func (r *Reader) key(b byte) (Event, bool, error) {
+	switch {
+	case b == 0x1b:
+		return r.escape()
+	case b == '\r':
+		// Clients end lines with CR LF or CR NUL
+		if next, err := r.peek(); err == nil && (next == '\n' || next == 0) {
+			r.r.ReadByte()
+		}
+		return Event{Key: tcell.KeyEnter}, true, nil
+	case b == '\n':
+		return Event{Key: tcell.KeyEnter}, true, nil
+	case b == '\t':
+		return Event{Key: tcell.KeyTab}, true, nil
+	case b == 0x08:
+		return Event{Key: tcell.KeyBackspace}, true, nil
+	case b == 0x7f:
+		return Event{Key: tcell.KeyBackspace2}, true, nil
+	case b >= 1 && b <= 26:
+		return Event{Key: tcell.KeyCtrlA + tcell.Key(b-1), Mod: tcell.ModCtrl}, true, nil
+	case b < 0x20:
+		return Event{}, false, nil
+	case b < utf8.RuneSelf:
+		return Event{Key: tcell.KeyRune, Rune: rune(b)}, true, nil
+	}
+	r.r.UnreadByte()
+	ch, _, err := r.r.ReadRune()
+	return Event{Key: tcell.KeyRune, Rune: ch}, err == nil, err
+}
+
+// escape decodes what follows an ESC byte. A lone ESC is the Escape key;
+// terminals send sequences in one piece, so anything already received
+// belongs to the sequence.
+func (r *Reader) escape() (Event, bool, error) {
+	if r.r.Buffered() == 0 {
+		return Event{Key: tcell.KeyEscape}, true, nil
+	}
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return Event{}, false, err
+	}
+	if b != '[' && b != 'O' {
+		// Alt held down
+		ev, ok, err := r.key(b)
+		ev.Mod |= tcell.ModAlt
+		return ev, ok, err
+	}
+	// Parameters up to the final byte, e.g. "1;2A" for Shift+Up
+	for {
+		b, err = r.r.ReadByte()
+		if err != nil {
+			return Event{}, false, err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	key, ok := escapeKeys[b]
+	return Event{Key: key}, ok, nil
+}
+
+// peek returns the next byte if it was already received.
+func (r *Reader) peek() (byte, error) {
+	if r.r.Buffered() == 0 {
+		return 0, io.EOF
+	}
+	p, err := r.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return p[0], nil
+}
@@ -0,0 +1,73 @@
+package telnet
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// readAll decodes every event of input.
+func readAll(t *testing.T, input string) []Event {
+	t.Helper()
+	r := NewReader(strings.NewReader(input))
+	var events []Event
+	for {
+		ev, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		events = append(events, ev)
+	}
+}
+
+func TestReadKeys(t *testing.T) {
+	got := readAll(t, "q\r\n\x1b[A\x1bOD\t\x7f\x03é\r\x00")
+	want := []Event{
+		{Key: tcell.KeyRune, Rune: 'q'},
+		{Key: tcell.KeyEnter},
+		{Key: tcell.KeyUp},
+		{Key: tcell.KeyLeft},
+		{Key: tcell.KeyTab},
+		{Key: tcell.KeyBackspace2},
+		{Key: tcell.KeyCtrlC, Mod: tcell.ModCtrl},
+		{Key: tcell.KeyRune, Rune: 'é'},
+		{Key: tcell.KeyEnter},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadLoneEscape(t *testing.T) {
+	got := readAll(t, "\x1b")
+	if len(got) != 1 || got[0].Key != tcell.KeyEscape {
+		t.Errorf("got %v, want a single Escape", got)
+	}
+}
+
+func TestReadCommands(t *testing.T) {
+	// Option replies are skipped, window sizes become events
+	input := string([]byte{iac, will, optNAWS, iac, sb, optNAWS, 0, 120, 0, 40, iac, se, 'x'})
+	got := readAll(t, input)
+	want := []Event{{Width: 120, Height: 40}, {Key: tcell.KeyRune, Rune: 'x'}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Sizes of 255 are sent as doubled IAC bytes
+	input = string([]byte{iac, sb, optNAWS, 0, iac, iac, 0, 50, iac, se})
+	if got := readAll(t, input); len(got) != 1 || got[0].Width != 255 || got[0].Height != 50 {
+		t.Errorf("escaped size: got %+v, want 255x50", got)
+	}
+}
@@ -0,0 +1,275 @@
+// Package theme provides the named color gradients scenes are rendered with.
+package theme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Stop is one step of a color gradient: values below Threshold use this color.
+type Stop struct {
+	Threshold float64 `json:"threshold"`
+	R         int32   `json:"r"`
+	G         int32   `json:"g"`
+	B         int32   `json:"b"`
+}
+
+// Theme is a named color gradient. Emoji variants draw the ocean surface
+// with emoji instead of shaded characters, over the same gradient.
+type Theme struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Gradient    []Stop `json:"gradient"`
+	Emoji       bool   `json:"emoji,omitempty"`
+}
+
+// Default is the name of the theme used when none is configured.
+const Default = "silver"
+
+var builtin = map[string]Theme{
+	"silver": {
+		Name:        "silver",
+		Description: "Elegant grey-silver-white metallic gradient",
+		Gradient: []Stop{
+			{0.15, 30, 30, 30},    // Dark Grey
+			{0.30, 80, 80, 80},    // Dim Grey
+			{0.45, 120, 120, 120}, // Grey
+			{0.60, 160, 160, 160}, // Silver
+			{0.75, 200, 200, 200}, // Light Grey
+			{0.90, 230, 230, 230}, // Gainsboro
+			{2.00, 255, 255, 255}, // White
+		},
+	},
+	"ocean": {
+		Name:        "ocean",
+		Description: "Deep navy through teal to white foam",
+		Gradient:    oceanGradient,
+	},
+	"emoji": {
+		Name:        "emoji",
+		Description: "The ocean drawn in emoji waves, droplets and foam",
+		Gradient:    oceanGradient,
+		Emoji:       true,
+	},
+	"lava": {
+		Name:        "lava",
+		Description: "Smouldering red through orange to yellow-white",
+		Gradient: []Stop{
+			{0.15, 40, 5, 0},
+			{0.30, 100, 15, 0},
+			{0.45, 170, 35, 0},
+			{0.60, 220, 80, 10},
+			{0.75, 250, 140, 30},
+			{0.90, 255, 200, 80},
+			{2.00, 255, 245, 200},
+		},
+	},
+	"forest": {
+		Name:        "forest",
+		Description: "Mossy dark greens rising to lime",
+		Gradient: []Stop{
+			{0.15, 10, 30, 15},
+			{0.30, 20, 60, 25},
+			{0.45, 35, 95, 40},
+			{0.60, 60, 135, 55},
+			{0.75, 100, 175, 70},
+			{0.90, 160, 210, 100},
+			{2.00, 220, 245, 170},
+		},
+	},
+	"sunset": {
+		Name:        "sunset",
+		Description: "Violet dusk through pink to warm orange",
+		Gradient: []Stop{
+			{0.15, 30, 10, 50},
+			{0.30, 70, 20, 90},
+			{0.45, 130, 35, 110},
+			{0.60, 190, 60, 110},
+			{0.75, 235, 100, 90},
+			{0.90, 250, 160, 90},
+			{2.00, 255, 220, 150},
+		},
+	},
+	"amber": {
+		Name:        "amber",
+		Description: "Monochrome amber like a vintage CRT",
+		Gradient: []Stop{
+			{0.15, 40, 20, 0},
+			{0.30, 90, 45, 0},
+			{0.45, 140, 75, 0},
+			{0.60, 190, 105, 0},
+			{0.75, 225, 135, 10},
+			{0.90, 250, 170, 40},
+			{2.00, 255, 210, 110},
+		},
+	},
+	"matrix": {
+		Name:        "matrix",
+		Description: "Phosphor green monochrome",
+		Gradient: []Stop{
+			{0.15, 0, 30, 0},
+			{0.30, 0, 70, 10},
+			{0.45, 0, 110, 20},
+			{0.60, 10, 160, 30},
+			{0.75, 40, 200, 60},
+			{0.90, 120, 235, 120},
+			{2.00, 210, 255, 210},
+		},
+	},
+	"storm": {
+		Name:        "storm",
+		Description: "Slate and gunmetal under a dark sky, with bright spray",
+		Gradient: []Stop{
+			{0.15, 12, 16, 22},
+			{0.30, 30, 38, 48},
+			{0.45, 52, 62, 72},
+			{0.60, 78, 90, 98},
+			{0.75, 118, 130, 136},
+			{0.90, 190, 198, 200},
+			{2.00, 245, 250, 250},
+		},
+	},
+	"silt": {
+		Name:        "silt",
+		Description: "Churned brown-green water carrying mud and debris",
+		Gradient: []Stop{
+			{0.15, 20, 18, 10},
+			{0.30, 48, 42, 22},
+			{0.45, 78, 70, 38},
+			{0.60, 104, 100, 58},
+			{0.75, 136, 134, 92},
+			{0.90, 180, 176, 140},
+			{2.00, 226, 222, 200},
+		},
+	},
+	"eink": {
+		Name:        "eink",
+		Description: "Four flat greys that dither cleanly on e-ink displays",
+		Gradient: []Stop{
+			// Few, widely spaced levels keep dither patterns steady and
+			// readable where the gradient would otherwise shimmer
+			{0.30, 0, 0, 0},
+			{0.55, 85, 85, 85},
+			{0.80, 170, 170, 170},
+			{2.00, 255, 255, 255},
+		},
+	},
+}
+
+// oceanGradient is shared by the ocean theme and its emoji variant.
+var oceanGradient = []Stop{
+	{0.15, 5, 20, 60},
+	{0.30, 10, 45, 110},
+	{0.45, 15, 80, 150},
+	{0.60, 30, 130, 180},
+	{0.75, 70, 180, 210},
+	{0.90, 150, 220, 235},
+	{2.00, 240, 250, 255},
+}
+
+// custom holds user themes, which take precedence over built-in ones of the same name.
+var custom = map[string]Theme{}
+
+// Lookup returns the user or built-in theme with the given name.
+func Lookup(name string) (Theme, bool) {
+	if t, ok := custom[name]; ok {
+		return t, true
+	}
+	t, ok := builtin[name]
+	return t, ok
+}
+
+// Names returns the names of all available themes in alphabetical order.
+func Names() []string {
+	names := make([]string, 0, len(builtin)+len(custom))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	for name := range custom {
+		if _, ok := builtin[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsCustom reports whether name refers to a user theme rather than a built-in one.
+func IsCustom(name string) bool {
+	_, ok := custom[name]
+	return ok
+}
+
+// Register makes a user theme available through Lookup and Names.
+func Register(t Theme) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	custom[t.Name] = t
+	return nil
+}
+
+// Validate checks that the theme has a usable name and gradient.
+func (t Theme) Validate() error {
+	if t.Name == "" || strings.ContainsAny(t.Name, `/\ `) {
+		return fmt.Errorf("invalid theme name %q", t.Name)
+	}
+	if len(t.Gradient) == 0 {
+		return fmt.Errorf("theme %q has no gradient stops", t.Name)
+	}
+	for i, s := range t.Gradient {
+		if i > 0 && s.Threshold <= t.Gradient[i-1].Threshold {
+			return fmt.Errorf("theme %q: stop thresholds must increase", t.Name)
+		}
+	}
+	return nil
+}
+
+// LoadDir registers every theme stored as a .json file in dir.
+// A missing directory is not an error.
+func LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var t Theme
+		if err := json.Unmarshal(data, &t); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+		if t.Name == "" {
+			t.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		if err := Register(t); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Save writes the theme to dir as <name>.json and registers it.
+func Save(dir string, t Theme) error {
+	if err := Register(t); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, t.Name+".json"), data, 0o644)
+}
@@ -0,0 +1,115 @@
+package timing
+
+import "time"
+
+// DefaultWindow is the number of frames the pacer's statistics cover.
+const DefaultWindow = 120
+
+// dropThreshold is how late a frame may arrive, as a multiple of the target
+// interval, before the frames it skipped over count as dropped.
+const dropThreshold = 1.5
+
+// Summary is a snapshot of recent frame timing.
+type Summary struct {
+	FPS      float64       // Achieved frames per second
+	Interval time.Duration // Mean time between frames
+	Work     time.Duration // Mean time spent updating and rendering
+	P50      time.Duration // Median work time
+	P95      time.Duration
+	P99      time.Duration
+	Max      time.Duration // Slowest frame in the window
+	Dropped  int           // Frames missed since the pacer started
+	Frames   int           // Frames presented since the pacer started
+}
+
+// Pacer delivers frame ticks at a target rate and measures how well the
+// loop keeps up. Call Begin when a tick is received and End once the frame
+// is on screen.
+type Pacer struct {
+	target    time.Duration
+	ticker    *time.Ticker
+	last      time.Time
+	begun     time.Time
+	intervals *Window
+	work      *Window
+	dropped   int
+	frames    int
+}
+
+// NewPacer starts a pacer ticking every target interval.
+func NewPacer(target time.Duration) *Pacer {
+	return &Pacer{
+		target:    target,
+		ticker:    time.NewTicker(target),
+		intervals: NewWindow(DefaultWindow),
+		work:      NewWindow(DefaultWindow),
+	}
+}
+
+// C returns the channel frame ticks are delivered on.
+func (p *Pacer) C() <-chan time.Time {
+	return p.ticker.C
+}
+
+// Target returns the target frame interval.
+func (p *Pacer) Target() time.Duration {
+	return p.target
+}
+
+// Reset changes the target frame interval and clears the statistics.
+func (p *Pacer) Reset(target time.Duration) {
+	p.target = target
+	p.ticker.Reset(target)
+	p.last = time.Time{}
+	p.intervals.Reset()
+	p.work.Reset()
+}
+
+// Stop releases the pacer's ticker.
+func (p *Pacer) Stop() {
+	p.ticker.Stop()
+}
+
+// Begin marks the start of a frame and returns how many frames were dropped
+// since the previous one because the loop fell behind.
+func (p *Pacer) Begin(now time.Time) int {
+	p.begun = now
+	if p.last.IsZero() {
+		p.last = now
+		return 0
+	}
+	interval := now.Sub(p.last)
+	p.last = now
+	p.intervals.Add(interval)
+
+	if float64(interval) < dropThreshold*float64(p.target) {
+		return 0
+	}
+	missed := int(interval/p.target) - 1
+	p.dropped += missed
+	return missed
+}
+
+// End marks the frame started by Begin as finished.
+func (p *Pacer) End(now time.Time) {
+	p.work.Add(now.Sub(p.begun))
+	p.frames++
+}
+
+// Summary returns the current frame timing statistics.
+func (p *Pacer) Summary() Summary {
+	s := Summary{
+		Interval: p.intervals.Mean(),
+		Work:     p.work.Mean(),
+		P50:      p.work.Percentile(50),
+		P95:      p.work.Percentile(95),
+		P99:      p.work.Percentile(99),
+		Max:      p.work.Max(),
+		Dropped:  p.dropped,
+		Frames:   p.frames,
+	}
+	if s.Interval > 0 {
+		s.FPS = float64(time.Second) / float64(s.Interval)
+	}
+	return s
+}
@@ -0,0 +1,78 @@
+// Package timing measures and paces frames: a ticker based frame pacer with
+// dropped frame detection, and rolling statistics over recent frame times.
+package timing
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// Window keeps the most recent durations in a ring buffer and summarizes them.
+type Window struct {
+	samples []time.Duration
+	next    int
+	full    bool
+	sum     time.Duration
+}
+
+// NewWindow creates a window over the last size samples.
+func NewWindow(size int) *Window {
+	return &Window{samples: make([]time.Duration, max(size, 1))}
+}
+
+// Add records a sample, evicting the oldest once the window is full.
+func (w *Window) Add(d time.Duration) {
+	if w.full {
+		w.sum -= w.samples[w.next]
+	}
+	w.samples[w.next] = d
+	w.sum += d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next, w.full = 0, true
+	}
+}
+
+// Len returns the number of samples currently in the window.
+func (w *Window) Len() int {
+	if w.full {
+		return len(w.samples)
+	}
+	return w.next
+}
+
+// Reset discards all samples.
+func (w *Window) Reset() {
+	w.next, w.full, w.sum = 0, false, 0
+}
+
+// Mean returns the rolling average, or 0 for an empty window.
+func (w *Window) Mean() time.Duration {
+	n := w.Len()
+	if n == 0 {
+		return 0
+	}
+	return w.sum / time.Duration(n)
+}
+
+// Max returns the longest sample in the window.
+func (w *Window) Max() time.Duration {
+	var longest time.Duration
+	for _, d := range w.samples[:w.Len()] {
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// Percentile returns the nearest-rank p-th percentile (0-100) of the window.
+func (w *Window) Percentile(p float64) time.Duration {
+	n := w.Len()
+	if n == 0 {
+		return 0
+	}
+	sorted := slices.Clone(w.samples[:n])
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	return sorted[min(max(rank-1, 0), n-1)]
+}
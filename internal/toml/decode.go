@@ -0,0 +1,209 @@
+package toml
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// Unmarshal decodes a document into v, which must be a pointer to a struct.
+//
+// Keys name struct fields the way encoding/json does, by their json tag or
+// else their field name, ignoring case, underscores and dashes, so both
+// frame_delay and FrameDelay set a FrameDelay field. Fields tagged
+// json:"-" cannot be set. Durations are strings such as "500ms", and types
+// implementing encoding.TextUnmarshaler are read from strings.
+//
+// Settings the document leaves out keep the value v holds, so v can carry
+// the defaults. Errors are *Error, pointing at the offending line.
+func Unmarshal(data []byte, v any) error {
+	root, err := parse(string(data))
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("toml: Unmarshal needs a pointer to a struct")
+	}
+	return decode(&value{v: root, line: 1}, rv.Elem(), "")
+}
+
+// decode stores v in dst; path names the setting in messages.
+func decode(v *value, dst reflect.Value, path string) error {
+	fail := func(format string, args ...any) error {
+		return &Error{Line: v.line, Msg: path + ": " + fmt.Sprintf(format, args...)}
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decode(v, dst.Elem(), path)
+	}
+	if dst.Addr().Type().Implements(textUnmarshalerType) {
+		s, ok := v.v.(string)
+		if !ok {
+			return fail("want a string")
+		}
+		if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return fail("%v", err)
+		}
+		return nil
+	}
+	if dst.Type() == durationType {
+		s, ok := v.v.(string)
+		d, err := time.ParseDuration(s)
+		if !ok || err != nil {
+			return fail(`want a duration like "500ms"`)
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Bool:
+		b, ok := v.v.(bool)
+		if !ok {
+			return fail("want true or false")
+		}
+		dst.SetBool(b)
+	case reflect.String:
+		s, ok := v.v.(string)
+		if !ok {
+			return fail("want a string")
+		}
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.v.(int64)
+		if !ok {
+			return fail("want an integer")
+		}
+		if dst.OverflowInt(i) {
+			return fail("%d is out of range", i)
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := v.v.(int64)
+		if !ok {
+			return fail("want an integer")
+		}
+		if i < 0 || dst.OverflowUint(uint64(i)) {
+			return fail("%d is out of range", i)
+		}
+		dst.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		switch x := v.v.(type) {
+		case float64:
+			dst.SetFloat(x)
+		case int64:
+			dst.SetFloat(float64(x))
+		default:
+			return fail("want a number")
+		}
+	case reflect.Slice, reflect.Array:
+		elems, ok := v.v.([]*value)
+		if !ok {
+			return fail("want an array")
+		}
+		if dst.Kind() == reflect.Array {
+			if len(elems) != dst.Len() {
+				return fail("want %d values, got %d", dst.Len(), len(elems))
+			}
+		} else {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(elems), len(elems)))
+		}
+		for i, elem := range elems {
+			if err := decode(elem, dst.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		t, ok := v.v.(*table)
+		if !ok {
+			return fail("want a table")
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMap(dst.Type()))
+		}
+		for _, k := range t.keys {
+			key := reflect.New(dst.Type().Key()).Elem()
+			switch {
+			case key.Addr().Type().Implements(textUnmarshalerType):
+				if err := key.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+					return &Error{Line: t.values[k].line, Msg: join(path, k) + ": " + err.Error()}
+				}
+			case key.Kind() == reflect.String:
+				key.SetString(k)
+			default:
+				return fail("cannot be set from a file")
+			}
+			// Entries start from what the map holds, like fields do
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if old := dst.MapIndex(key); old.IsValid() {
+				elem.Set(old)
+			}
+			if err := decode(t.values[k], elem, join(path, k)); err != nil {
+				return err
+			}
+			dst.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		t, ok := v.v.(*table)
+		if !ok {
+			return fail("want a table")
+		}
+		for _, k := range t.keys {
+			field, ok := findField(dst.Type(), k)
+			if !ok {
+				return &Error{Line: t.values[k].line, Msg: fmt.Sprintf("unknown setting %q", join(path, k))}
+			}
+			if err := decode(t.values[k], dst.FieldByIndex(field.Index), join(path, k)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fail("cannot be set from a file")
+	}
+	return nil
+}
+
+// findField returns the field of struct type t that key names.
+func findField(t reflect.Type, key string) (reflect.StructField, bool) {
+	want := normalize(key)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name := f.Name
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if normalize(name) == want {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// normalize lowers a name and drops its underscores and dashes.
+func normalize(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// join appends key to a dotted path.
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,47 @@
+package toml
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Set returns doc with the top-level key set to value, a TOML value such as
+// one Quote returns, and the rest of the document as it was written. A key
+// already set before the first table is changed on its line, written in
+// any of the spellings Unmarshal accepts; otherwise the key is added at the
+// top of the document.
+func Set(doc, key, value string) string {
+	line := key + " = " + value + "\n"
+	lines := strings.SplitAfter(doc, "\n")
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l)
+		if strings.HasPrefix(trimmed, "[") {
+			break // Keys from here on belong to tables
+		}
+		name, _, ok := strings.Cut(trimmed, "=")
+		if ok && normalize(strings.TrimSpace(name)) == normalize(key) {
+			lines[i] = line
+			return strings.Join(lines, "")
+		}
+	}
+	return line + doc
+}
+
+// Quote returns s as a TOML basic string.
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,456 @@
+// Package toml reads configuration files written in TOML into Go values,
+// and changes settings in them without disturbing the rest.
+//
+// It covers the part of TOML configuration files use: tables, arrays of
+// tables, dotted and quoted keys, strings, integers, floats, booleans,
+// arrays and inline tables. Multi-line strings and dates are not
+// supported.
+package toml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error is a problem with a document, at the line it happened on.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+// Error formats the problem with its line.
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// value is a parsed value with the line it started on. Its v is a string,
+// int64, float64, bool, []*value or table.
+type value struct {
+	v    any
+	line int
+}
+
+// table maps keys to values, keeping the order they were written in so
+// problems are reported in the order of the document.
+type table struct {
+	keys   []string
+	values map[string]*value
+	// defined is set for tables given a header or inline, which may not
+	// be defined again
+	defined bool
+}
+
+func newTable() *table {
+	return &table{values: make(map[string]*value)}
+}
+
+// set adds a key, failing if it exists.
+func (t *table) set(key string, v *value) error {
+	if _, ok := t.values[key]; ok {
+		return fmt.Errorf("duplicate key %q", key)
+	}
+	t.keys = append(t.keys, key)
+	t.values[key] = v
+	return nil
+}
+
+// parser reads a document one token at a time.
+type parser struct {
+	src  string
+	pos  int
+	line int
+}
+
+// parse reads a whole document into its root table.
+func parse(src string) (*table, error) {
+	p := &parser{src: src, line: 1}
+	root := newTable()
+	current := root
+	for {
+		p.skipSpace(true)
+		if p.pos >= len(p.src) {
+			return root, nil
+		}
+		line := p.line
+		var err error
+		if p.peek() == '[' {
+			current, err = p.header(root)
+		} else {
+			err = p.keyValue(current)
+		}
+		if err == nil {
+			err = p.endOfLine()
+		}
+		if err != nil {
+			return nil, &Error{Line: max(line, p.line), Msg: err.Error()}
+		}
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace skips blanks and comments, and line breaks too if newlines is set.
+func (p *parser) skipSpace(newlines bool) {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+			p.line++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine checks nothing but a comment follows on the line.
+func (p *parser) endOfLine() error {
+	p.skipSpace(false)
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		return fmt.Errorf("unexpected %q after the value", p.rest())
+	}
+	return nil
+}
+
+// rest returns the remainder of the line for messages.
+func (p *parser) rest() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		return p.src[p.pos:]
+	}
+	return strings.TrimSpace(p.src[p.pos : p.pos+end])
+}
+
+// header reads a [table] or [[array of tables]] header and returns the
+// table the following keys go into.
+func (p *parser) header(root *table) (*table, error) {
+	array := strings.HasPrefix(p.src[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace(false)
+	keys, err := p.key()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace(false)
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return nil, fmt.Errorf("missing %s after the table name", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := p.descend(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := keys[len(keys)-1]
+	existing, ok := parent.values[last]
+	if array {
+		if !ok {
+			existing = &value{v: []*value{}, line: p.line}
+			parent.set(last, existing)
+		}
+		elems, isArray := existing.v.([]*value)
+		if !isArray || (len(elems) > 0 && !isTable(elems[0])) {
+			return nil, fmt.Errorf("%q is not an array of tables", last)
+		}
+		t := newTable()
+		t.defined = true
+		existing.v = append(elems, &value{v: t, line: p.line})
+		return t, nil
+	}
+	if !ok {
+		t := newTable()
+		t.defined = true
+		return t, parent.set(last, &value{v: t, line: p.line})
+	}
+	t, isTable := existing.v.(*table)
+	if !isTable || t.defined {
+		return nil, fmt.Errorf("table %q is defined twice", strings.Join(keys, "."))
+	}
+	t.defined = true
+	return t, nil
+}
+
+// descend follows dotted key parts from t, creating tables on the way and
+// entering the last table of arrays of tables.
+func (p *parser) descend(t *table, keys []string) (*table, error) {
+	for _, k := range keys {
+		v, ok := t.values[k]
+		if !ok {
+			next := newTable()
+			t.set(k, &value{v: next, line: p.line})
+			t = next
+			continue
+		}
+		switch x := v.v.(type) {
+		case *table:
+			t = x
+		case []*value:
+			if len(x) == 0 || !isTable(x[len(x)-1]) {
+				return nil, fmt.Errorf("%q is not a table", k)
+			}
+			t = x[len(x)-1].v.(*table)
+		default:
+			return nil, fmt.Errorf("%q is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+func isTable(v *value) bool {
+	_, ok := v.v.(*table)
+	return ok
+}
+
+// keyValue reads key = value into t.
+func (p *parser) keyValue(t *table) error {
+	line := p.line
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skipSpace(false)
+	if p.peek() != '=' {
+		return fmt.Errorf("missing = after %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipSpace(false)
+	v, err := p.value()
+	if err != nil {
+		return err
+	}
+	v.line = line
+	parent, err := p.descend(t, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	return parent.set(keys[len(keys)-1], v)
+}
+
+// key reads a possibly dotted key of bare and quoted parts.
+func (p *parser) key() ([]string, error) {
+	var keys []string
+	for {
+		var k string
+		switch c := p.peek(); {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			k = s
+		case isBare(c):
+			start := p.pos
+			for p.pos < len(p.src) && isBare(p.src[p.pos]) {
+				p.pos++
+			}
+			k = p.src[start:p.pos]
+		default:
+			return nil, fmt.Errorf("expected a key, found %q", p.rest())
+		}
+		keys = append(keys, k)
+		p.skipSpace(false)
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+		p.skipSpace(false)
+	}
+}
+
+func isBare(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// value reads a string, number, boolean, array or inline table.
+func (p *parser) value() (*value, error) {
+	line := p.line
+	switch c := p.peek(); {
+	case c == '"' || c == '\'':
+		if strings.HasPrefix(p.src[p.pos:], `"""`) || strings.HasPrefix(p.src[p.pos:], `'''`) {
+			return nil, fmt.Errorf("multi-line strings are not supported")
+		}
+		s, err := p.str()
+		return &value{v: s, line: line}, err
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\r\n#,]}", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	word := p.src[start:p.pos]
+	switch word {
+	case "":
+		return nil, fmt.Errorf("missing value")
+	case "true", "false":
+		return &value{v: word == "true", line: line}, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		return nil, fmt.Errorf("%s is not a usable setting", word)
+	}
+	clean := strings.ReplaceAll(word, "_", "")
+	if !strings.ContainsAny(clean, ".eE") || strings.HasPrefix(clean, "0x") {
+		// Decimal unless prefixed 0x, 0o or 0b, leading zeros included
+		base := 10
+		if len(clean) > 2 && clean[0] == '0' && strings.ContainsRune("xob", rune(clean[1])) {
+			base = 0
+		}
+		if i, err := strconv.ParseInt(clean, base, 64); err == nil {
+			return &value{v: i, line: line}, nil
+		}
+	} else if f, err := strconv.ParseFloat(clean, 64); err == nil && !math.IsInf(f, 0) {
+		return &value{v: f, line: line}, nil
+	}
+	if len(word) >= 10 && word[4] == '-' && word[7] == '-' {
+		return nil, fmt.Errorf("dates are not supported")
+	}
+	return nil, fmt.Errorf("invalid value %q, strings need quotes", word)
+}
+
+// str reads a basic "string" with escapes or a literal 'string'.
+func (p *parser) str() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			return "", fmt.Errorf("unterminated string")
+		case c == '\\' && quote == '"':
+			r, err := p.escape()
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+// escape reads an escape sequence in a basic string.
+func (p *parser) escape() (rune, error) {
+	p.pos++ // Backslash
+	if p.pos >= len(p.src) {
+		return 0, fmt.Errorf("unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		return '\b', nil
+	case 't':
+		return '\t', nil
+	case 'n':
+		return '\n', nil
+	case 'f':
+		return '\f', nil
+	case 'r':
+		return '\r', nil
+	case 'e':
+		return '\x1b', nil
+	case '"', '\\':
+		return rune(c), nil
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return 0, fmt.Errorf("short \\%c escape", c)
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return 0, fmt.Errorf("invalid \\%c escape %q", c, p.src[p.pos:p.pos+n])
+		}
+		p.pos += n
+		return rune(code), nil
+	}
+	return 0, fmt.Errorf("invalid escape \\%c", c)
+}
+
+// array reads [a, b, ...], which may span lines and hold comments.
+func (p *parser) array() (*value, error) {
+	v := &value{line: p.line}
+	elems := []*value{}
+	p.pos++
+	for {
+		p.skipSpace(true)
+		if p.peek() == ']' {
+			p.pos++
+			v.v = elems
+			return v, nil
+		}
+		elem, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, elem)
+		p.skipSpace(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, fmt.Errorf("expected , or ] in array, found %q", p.rest())
+		}
+	}
+}
+
+// inlineTable reads { key = value, ... } on a single line.
+func (p *parser) inlineTable() (*value, error) {
+	t := newTable()
+	t.defined = true
+	v := &value{v: t, line: p.line}
+	p.pos++
+	p.skipSpace(false)
+	if p.peek() == '}' {
+		p.pos++
+		return v, nil
+	}
+	for {
+		p.skipSpace(false)
+		if err := p.keyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpace(false)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return v, nil
+		default:
+			return nil, fmt.Errorf("expected , or } in inline table, found %q", p.rest())
+		}
+	}
+}
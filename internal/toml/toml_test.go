@@ -0,0 +1,177 @@
+package toml
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type point struct{ X, Y float64 }
+
+type wave struct {
+	Amplitude float64
+	Direction point
+}
+
+type settings struct {
+	FrameDelay time.Duration
+	Scene      string
+	Control    bool
+	Count      int
+	Ratio      float64
+	Tint       [3]float64
+	Names      []string
+	Waves      []wave
+	Layers     map[string]point
+	Nested     struct{ GridWidth, GridDepth int }
+	Secret     string `json:"-"`
+	Renamed    string `json:"other_name"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	doc := `
+# Comments and blank lines are skipped
+frame_delay = "50ms"
+scene = 'ocean' # trailing comment
+control = false
+count = 1_000
+ratio = 2
+tint = [1, 0.5, 0.25]
+names = [
+  "a",
+  "b\t\"c\"é", # comment inside an array
+]
+other-name = "x"
+nested.GridDepth = 30
+
+[nested]
+grid_width = 90
+
+[layers]
+scene = { x = 1, y = -2.5e1 }
+
+[[waves]]
+amplitude = 0.1
+direction = { x = 1, y = 0 }
+
+[[waves]]
+amplitude = 0.2
+[waves.direction]
+y = 1
+`
+	got := settings{Scene: "galaxy", Control: true, Layers: map[string]point{"other": {X: 3}}}
+	got.Nested.GridDepth = 10
+	if err := Unmarshal([]byte(doc), &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := settings{
+		FrameDelay: 50 * time.Millisecond,
+		Scene:      "ocean",
+		Count:      1000,
+		Ratio:      2,
+		Tint:       [3]float64{1, 0.5, 0.25},
+		Names:      []string{"a", "b\t\"c\"é"},
+		Waves:      []wave{{Amplitude: 0.1, Direction: point{X: 1}}, {Amplitude: 0.2, Direction: point{Y: 1}}},
+		Layers:     map[string]point{"other": {X: 3}, "scene": {X: 1, Y: -25}},
+		Renamed:    "x",
+	}
+	want.Nested.GridWidth, want.Nested.GridDepth = 90, 30
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestUnmarshalIntegers(t *testing.T) {
+	tests := map[string]int{
+		"10":     10,
+		"010":    10,
+		"-007":   -7,
+		"0x1f":   31,
+		"0o17":   15,
+		"0b101":  5,
+		"1_024":  1024,
+		"+3":     3,
+		"0":      0,
+		"0xff_f": 4095,
+	}
+	for value, want := range tests {
+		var got settings
+		if err := Unmarshal([]byte("count = "+value), &got); err != nil {
+			t.Errorf("%s: %v", value, err)
+			continue
+		}
+		if got.Count != want {
+			t.Errorf("%s: got %d, want %d", value, got.Count, want)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		doc  string
+		line int
+		msg  string
+	}{
+		{"scene = \"ocean\"\nscen = 1", 2, `unknown setting "scen"`},
+		{"secret = \"x\"", 1, `unknown setting "secret"`},
+		{"count = 1.5", 1, "count: want an integer"},
+		{"count = 99999999999999999999", 1, "strings need quotes"},
+		{"frame_delay = 50", 1, "frame_delay: want a duration"},
+		{"tint = [1, 2]", 1, "want 3 values, got 2"},
+		{"scene = ocean", 1, "strings need quotes"},
+		{"scene = \"ocean", 1, "unterminated string"},
+		{"scene = \"a\" \"b\"", 1, "after the value"},
+		{"scene = \"a\"\nscene = \"b\"", 2, "duplicate key"},
+		{"[nested]\n[nested]", 2, "defined twice"},
+		{"scene = \"\"\"a\"\"\"", 1, "multi-line strings"},
+		{"scene = 2024-01-01", 1, "dates are not supported"},
+		{"\n\n[nested]\ngrid_width = true", 4, "nested.grid_width: want an integer"},
+		{"[[waves]]\namplitude = 1\n[[waves]]\ndirection = 3", 4, "waves[1].direction: want a table"},
+	}
+	for _, tt := range tests {
+		var s settings
+		err := Unmarshal([]byte(tt.doc), &s)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("%q: got %v, want an *Error", tt.doc, err)
+			continue
+		}
+		if e.Line != tt.line || !strings.Contains(e.Msg, tt.msg) {
+			t.Errorf("%q: got line %d %q, want line %d containing %q", tt.doc, e.Line, e.Msg, tt.line, tt.msg)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{"", "scene = \"fire\"\n"},
+		{"names = [\"a\"]\n", "scene = \"fire\"\nnames = [\"a\"]\n"},
+		{"# Mine\nscene = \"ocean\" # Calm\ncount = 1\n", "# Mine\nscene = \"fire\"\ncount = 1\n"},
+		{"Scene=\"ocean\"", "scene = \"fire\"\n"},
+		{"count = 1\n[layers]\nscene = { x = 1 }\n", "scene = \"fire\"\ncount = 1\n[layers]\nscene = { x = 1 }\n"},
+		{"# scene = \"ocean\"\n", "scene = \"fire\"\n# scene = \"ocean\"\n"},
+	}
+	for _, tt := range tests {
+		got := Set(tt.doc, "scene", Quote("fire"))
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.doc, got, tt.want)
+		}
+		var s settings
+		if err := Unmarshal([]byte(got), &s); err != nil || s.Scene != "fire" {
+			t.Errorf("%q: read back scene %q, %v", tt.doc, s.Scene, err)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, s := range []string{"ocean", `a "quoted" \ name`, "tab\tnew\nline\x7f", "ünï ☃"} {
+		var got settings
+		if err := Unmarshal([]byte("scene = "+Quote(s)), &got); err != nil || got.Scene != s {
+			t.Errorf("%q: read back %q, %v", s, got.Scene, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package transition
+
+import (
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/animation"
+	"github.com/olegchuev/screensaver/internal/font"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// bannerFade is how long a banner takes to fade in and to fade out, in
+// seconds.
+const bannerFade = 0.6
+
+// bannerFont is the font of large letters, 7 by 13 pixels. Each cell
+// shows two pixels stacked, so letters keep their shape.
+const bannerFont = "fixed"
+
+// Banner shows a message of the day centered on a black screen, fading in,
+// holding and fading out again before the scene starts.
+type Banner struct {
+	// Renditions of the message, in large letters and then as given, each
+	// a block of lines
+	renditions [][][]rune
+	envelope   animation.Sequence
+}
+
+// NewBanner creates a banner showing lines for hold seconds. With large set
+// the lines are drawn in large block letters where they fit the screen,
+// otherwise they are shown as they are, such as text already made into
+// letters by figlet.
+func NewBanner(lines []string, large bool, hold float64) (*Banner, error) {
+	b := &Banner{envelope: animation.Sequence{
+		animation.NewTween(0, 1, bannerFade, animation.EaseInOutSine),
+		animation.Hold{At: 1, Length: hold},
+		animation.NewTween(1, 0, bannerFade, animation.EaseInOutSine),
+	}}
+	if large {
+		face, err := font.Load(bannerFont, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		b.renditions = append(b.renditions, letters(lines, face))
+	}
+	// Plain text is the last resort for screens too small for letters
+	b.renditions = append(b.renditions, grid(lines))
+	return b, nil
+}
+
+// Duration returns how long the banner shows, fades included, in seconds.
+func (b *Banner) Duration() float64 {
+	return b.envelope.Duration()
+}
+
+// Render draws the largest rendition that fits the screen in the theme's
+// colors, brightest at the top, and returns true once the banner has faded
+// out.
+func (b *Banner) Render(r *renderer.Renderer, elapsed float64) bool {
+	width, height := r.Size()
+	rows := b.renditions[len(b.renditions)-1]
+	for _, rendition := range b.renditions {
+		if blockWidth(rendition) <= width && len(rendition) <= height {
+			rows = rendition
+			break
+		}
+	}
+
+	brightness := b.envelope.Value(elapsed)
+	left := (width - blockWidth(rows)) / 2
+	top := (height - len(rows)) / 2
+	for y, row := range rows {
+		fg, _, _ := r.GradientStyle(1 - 0.4*float64(y)/float64(max(len(rows)-1, 1))).Decompose()
+		red, green, blue := fg.RGB()
+		dim := func(v int32) int32 { return int32(float64(v) * brightness) }
+		style := tcell.StyleDefault.Foreground(tcell.NewRGBColor(dim(red), dim(green), dim(blue)))
+		for x, ch := range row {
+			if ch != ' ' {
+				r.SetCell(left+x, top+y, ch, overlayDepth-1, style)
+			}
+		}
+	}
+	return elapsed >= b.Duration()
+}
+
+// blockWidth returns the width of the longest row.
+func blockWidth(rows [][]rune) int {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	return width
+}
+
+// letters draws every line in large letters of the face's glyphs with half
+// block characters, leaving out the blank pixel rows above and below them.
+func letters(lines []string, face font.Face) [][]rune {
+	cw, ch := face.CellSize()
+	var rows [][]rune
+	for _, line := range grid(lines) {
+		// Pixels of the line, row by row
+		pixels := make([][]bool, ch)
+		for y := range pixels {
+			pixels[y] = make([]bool, len(line)*cw)
+		}
+		for i, c := range line {
+			mask, ok := face.Glyph(c)
+			if !ok {
+				continue
+			}
+			for y := range ch {
+				for x := range cw {
+					pixels[y][i*cw+x] = mask.AlphaAt(x, y).A >= 0x80
+				}
+			}
+		}
+
+		first, last := 0, ch-1
+		for first < ch && !slices.Contains(pixels[first], true) {
+			first++
+		}
+		for last > first && !slices.Contains(pixels[last], true) {
+			last--
+		}
+		if first > last {
+			// A blank line keeps a line of space
+			rows = append(rows, nil)
+			continue
+		}
+		for y := first; y <= last; y += 2 {
+			row := make([]rune, len(line)*cw)
+			for x := range row {
+				upper := pixels[y][x]
+				lower := y+1 <= last && pixels[y+1][x]
+				switch {
+				case upper && lower:
+					row[x] = '█'
+				case upper:
+					row[x] = '▀'
+				case lower:
+					row[x] = '▄'
+				default:
+					row[x] = ' '
+				}
+			}
+			rows = append(rows, row)
+		}
+		// A line of space between lines of letters
+		rows = append(rows, nil)
+	}
+	if len(rows) > 0 {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
@@ -0,0 +1,45 @@
+package transition
+
+import (
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const dissolveSeconds = 1.5
+
+// dissolve removes captured characters in random order until the scene is fully revealed.
+type dissolve struct {
+	rows [][]rune
+	// Time in seconds at which each character disappears, per row
+	expiry [][]float64
+}
+
+// newDissolve creates a dissolve effect over the given lines.
+func newDissolve(lines []string, rng *rand.Rand) *dissolve {
+	d := &dissolve{rows: grid(lines)}
+	d.expiry = make([][]float64, len(d.rows))
+	for y, row := range d.rows {
+		d.expiry[y] = make([]float64, len(row))
+		for x := range row {
+			d.expiry[y][x] = rng.Float64() * dissolveSeconds
+		}
+	}
+	return d
+}
+
+// Render draws the characters that have not yet expired.
+func (d *dissolve) Render(r *renderer.Renderer, elapsed float64) bool {
+	_, height := r.Size()
+	for y, row := range d.rows {
+		if y >= height {
+			break
+		}
+		for x := range row {
+			if d.expiry[y][x] > elapsed {
+				drawRow(r, row, x, y)
+			}
+		}
+	}
+	return elapsed >= dissolveSeconds
+}
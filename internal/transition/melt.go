@@ -0,0 +1,71 @@
+package transition
+
+import (
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+const (
+	meltMaxDelay = 0.6  // Seconds of random start delay between columns
+	meltGravity  = 90.0 // Rows per second squared
+)
+
+// melt slides every column down at its own pace, DOOM style, revealing the
+// scene above the falling text.
+type melt struct {
+	rows   [][]rune
+	delays []float64
+	rng    *rand.Rand
+}
+
+// newMelt creates a melt effect over the given lines.
+func newMelt(lines []string, rng *rand.Rand) *melt {
+	return &melt{rows: grid(lines), rng: rng}
+}
+
+// columnDelays grows the per-column delay table as a random walk, so
+// neighbouring columns start melting at similar times.
+func (m *melt) columnDelays(width int) {
+	if len(m.delays) >= width {
+		return
+	}
+	step := meltMaxDelay / 8
+	prev := m.rng.Float64() * meltMaxDelay
+	if n := len(m.delays); n > 0 {
+		prev = m.delays[n-1]
+	}
+	for len(m.delays) < width {
+		prev += (m.rng.Float64()*2 - 1) * step
+		if prev < 0 {
+			prev = 0
+		}
+		if prev > meltMaxDelay {
+			prev = meltMaxDelay
+		}
+		m.delays = append(m.delays, prev)
+	}
+}
+
+// Render draws each column shifted down by its fall distance.
+func (m *melt) Render(r *renderer.Renderer, elapsed float64) bool {
+	width, height := r.Size()
+	m.columnDelays(width)
+
+	done := true
+	for x := 0; x < width; x++ {
+		shift := 0
+		if t := elapsed - m.delays[x]; t > 0 {
+			shift = int(meltGravity * t * t / 2)
+		}
+		if shift < height {
+			done = false
+		}
+		for y, row := range m.rows {
+			if sy := y + shift; sy < height {
+				drawRow(r, row, x, sy)
+			}
+		}
+	}
+	return done
+}
@@ -0,0 +1,54 @@
+package transition
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// wipeEdge is how many cells wide the soft edge of a wipe is.
+const wipeEdge = 6
+
+// bayer orders the cells of a 4 by 4 tile for the dither, so every step
+// of the way spreads the new scene's cells evenly over the screen.
+var bayer = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// Switches are the ways one scene gives way to the next, see NewSwitch.
+var Switches = []string{"cut", "dissolve", "wipe", "dither"}
+
+// Switch tells how far the cell at x, y of a width by height screen has
+// turned from the old scene to the new one, from 0 to 1, when progress
+// (0-1) of the way through the transition.
+type Switch func(x, y, width, height int, progress float64) float64
+
+// NewSwitch returns the named transition between scenes: "dissolve" fades
+// the old scene into the new one, "wipe" sweeps the new one in from the
+// left and "dither" turns cells over in an ordered dither pattern. "cut"
+// returns nil, for a switch without a transition.
+func NewSwitch(name string) (Switch, error) {
+	switch name {
+	case "cut":
+		return nil, nil
+	case "dissolve":
+		return func(_, _, _, _ int, progress float64) float64 { return progress }, nil
+	case "wipe":
+		return func(x, _, width, _ int, progress float64) float64 {
+			front := progress * float64(width+wipeEdge)
+			return math.Max(0, math.Min((front-float64(x))/wipeEdge, 1))
+		}, nil
+	case "dither":
+		return func(x, y, _, _ int, progress float64) float64 {
+			if progress*16 > bayer[y%4][x%4] {
+				return 1
+			}
+			return 0
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown transition %q (available: %s)", name, strings.Join(Switches, ", "))
+	}
+}
@@ -0,0 +1,89 @@
+// Package transition provides startup effects that dissolve captured terminal
+// text into the running scene instead of an abrupt clear, and the ways one
+// scene gives way to the next.
+package transition
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/olegchuev/screensaver/internal/renderer"
+)
+
+// overlayDepth keeps captured text in front of anything a scene draws.
+const overlayDepth = 1e9
+
+// Effect draws captured content on top of a scene for a limited time.
+type Effect interface {
+	// Render draws the effect at the given elapsed time (seconds) and
+	// returns true once the effect has finished.
+	Render(r *renderer.Renderer, elapsed float64) bool
+}
+
+// New creates the named effect ("melt" or "dissolve") over the captured lines.
+func New(name string, lines []string, seed int64) (Effect, error) {
+	rng := rand.New(rand.NewSource(seed))
+	switch name {
+	case "melt":
+		return newMelt(lines, rng), nil
+	case "dissolve":
+		return newDissolve(lines, rng), nil
+	default:
+		return nil, fmt.Errorf("unknown intro effect %q (available: melt, dissolve)", name)
+	}
+}
+
+// Capture returns the text to melt away. It reads the given file if set,
+// otherwise asks tmux for the visible pane contents when running inside tmux.
+// It returns nil when no content can be captured.
+func Capture(path string) ([]string, error) {
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		var lines []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		return lines, scanner.Err()
+	}
+
+	// The terminal's existing contents cannot be read back through tcell,
+	// but tmux can report what the pane showed before we took over
+	if os.Getenv("TMUX") == "" {
+		return nil, nil
+	}
+	out, err := exec.Command("tmux", "capture-pane", "-p").Output()
+	if err != nil {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimRight(string(out), "\n"), "\n"), nil
+}
+
+// grid converts lines into rows of runes, expanding tabs.
+func grid(lines []string) [][]rune {
+	rows := make([][]rune, len(lines))
+	for i, line := range lines {
+		rows[i] = []rune(strings.ReplaceAll(line, "\t", "        "))
+	}
+	return rows
+}
+
+// textStyle is used for the captured terminal text.
+var textStyle = tcell.StyleDefault.Foreground(tcell.NewRGBColor(200, 200, 200))
+
+// drawRow draws the visible characters of a row starting at screen row y.
+func drawRow(r *renderer.Renderer, row []rune, x, y int) {
+	if x < len(row) && row[x] != ' ' {
+		r.SetCell(x, y, row[x], overlayDepth, textStyle)
+	}
+}
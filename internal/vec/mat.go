@@ -0,0 +1,147 @@
+package vec
+
+import "math"
+
+// Mat4 is a 4x4 matrix in row-major order, applied to column vectors:
+// transforming a point by A then B is B.Mul(A).
+type Mat4 [16]float64
+
+// Identity returns the identity matrix.
+func Identity() Mat4 {
+	return Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Translate returns a matrix moving points by t.
+func Translate(t Vec3) Mat4 {
+	return Mat4{
+		1, 0, 0, t.X,
+		0, 1, 0, t.Y,
+		0, 0, 1, t.Z,
+		0, 0, 0, 1,
+	}
+}
+
+// Scaling returns a matrix scaling each axis by the components of s.
+func Scaling(s Vec3) Mat4 {
+	return Mat4{
+		s.X, 0, 0, 0,
+		0, s.Y, 0, 0,
+		0, 0, s.Z, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// RotateX returns a matrix rotating by angle radians around the X axis.
+func RotateX(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		1, 0, 0, 0,
+		0, cos, -sin, 0,
+		0, sin, cos, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// RotateY returns a matrix rotating by angle radians around the Y axis.
+func RotateY(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		cos, 0, sin, 0,
+		0, 1, 0, 0,
+		-sin, 0, cos, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// RotateZ returns a matrix rotating by angle radians around the Z axis.
+func RotateZ(angle float64) Mat4 {
+	sin, cos := math.Sincos(angle)
+	return Mat4{
+		cos, -sin, 0, 0,
+		sin, cos, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// LookAt returns a view matrix for a camera at eye looking at target. The
+// camera looks down its negative Z axis with up pointing along positive Y.
+func LookAt(eye, target, up Vec3) Mat4 {
+	f := target.Sub(eye).Normalize()
+	s := f.Cross(up).Normalize()
+	u := s.Cross(f)
+	return Mat4{
+		s.X, s.Y, s.Z, -s.Dot(eye),
+		u.X, u.Y, u.Z, -u.Dot(eye),
+		-f.X, -f.Y, -f.Z, f.Dot(eye),
+		0, 0, 0, 1,
+	}
+}
+
+// Perspective returns a projection matrix with the given vertical field of
+// view in radians, width-to-height aspect ratio and clip plane distances.
+// Projected points inside the view volume have X, Y and Z in [-1, 1].
+func Perspective(fovY, aspect, near, far float64) Mat4 {
+	f := 1 / math.Tan(fovY/2)
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) / (near - far), 2 * far * near / (near - far),
+		0, 0, -1, 0,
+	}
+}
+
+// Mul returns the matrix product m * o.
+func (m Mat4) Mul(o Mat4) Mat4 {
+	var out Mat4
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			var sum float64
+			for k := 0; k < 4; k++ {
+				sum += m[row*4+k] * o[k*4+col]
+			}
+			out[row*4+col] = sum
+		}
+	}
+	return out
+}
+
+// Transpose returns m with rows and columns swapped.
+func (m Mat4) Transpose() Mat4 {
+	var out Mat4
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			out[col*4+row] = m[row*4+col]
+		}
+	}
+	return out
+}
+
+// MulPoint transforms the point p, dividing by the resulting w so
+// projection matrices yield normalized device coordinates. It also returns
+// w, which is the view-space depth for perspective projections and negative
+// for points behind the camera.
+func (m Mat4) MulPoint(p Vec3) (Vec3, float64) {
+	x := m[0]*p.X + m[1]*p.Y + m[2]*p.Z + m[3]
+	y := m[4]*p.X + m[5]*p.Y + m[6]*p.Z + m[7]
+	z := m[8]*p.X + m[9]*p.Y + m[10]*p.Z + m[11]
+	w := m[12]*p.X + m[13]*p.Y + m[14]*p.Z + m[15]
+	if w == 0 {
+		return Vec3{x, y, z}, w
+	}
+	return Vec3{x / w, y / w, z / w}, w
+}
+
+// MulDir transforms the direction d, ignoring translation.
+func (m Mat4) MulDir(d Vec3) Vec3 {
+	return Vec3{
+		m[0]*d.X + m[1]*d.Y + m[2]*d.Z,
+		m[4]*d.X + m[5]*d.Y + m[6]*d.Z,
+		m[8]*d.X + m[9]*d.Y + m[10]*d.Z,
+	}
+}
@@ -0,0 +1,117 @@
+package vec
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIdentity(t *testing.T) {
+	p := Vec3{1, -2, 3}
+	got, w := Identity().MulPoint(p)
+	if got != p || w != 1 {
+		t.Errorf("Identity().MulPoint(%v) = %v, %v", p, got, w)
+	}
+	m := RotateX(0.3).Mul(Translate(Vec3{1, 2, 3}))
+	if Identity().Mul(m) != m || m.Mul(Identity()) != m {
+		t.Error("multiplying by the identity changed the matrix")
+	}
+}
+
+func TestTranslateScale(t *testing.T) {
+	p := Vec3{1, 2, 3}
+	if got, _ := Translate(Vec3{10, 20, 30}).MulPoint(p); got != (Vec3{11, 22, 33}) {
+		t.Errorf("Translate = %v, want {11 22 33}", got)
+	}
+	if got, _ := Scaling(Vec3{2, 3, 4}).MulPoint(p); got != (Vec3{2, 6, 12}) {
+		t.Errorf("Scaling = %v, want {2 6 12}", got)
+	}
+	// Directions ignore translation
+	if got := Translate(Vec3{10, 20, 30}).MulDir(p); got != p {
+		t.Errorf("MulDir = %v, want %v", got, p)
+	}
+}
+
+func TestRotations(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Mat4
+		in   Vec3
+		want Vec3
+	}{
+		{"X", RotateX(math.Pi / 2), Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{"Y", RotateY(math.Pi / 2), Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{"Z", RotateZ(math.Pi / 2), Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulDir(tt.in); !nearVec3(got, tt.want) {
+			t.Errorf("Rotate%s(pi/2) of %v = %v, want %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMulOrder(t *testing.T) {
+	// Rotate first, then translate
+	m := Translate(Vec3{5, 0, 0}).Mul(RotateZ(math.Pi / 2))
+	got, _ := m.MulPoint(Vec3{1, 0, 0})
+	if !nearVec3(got, Vec3{5, 1, 0}) {
+		t.Errorf("translate after rotate = %v, want {5 1 0}", got)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	m := Translate(Vec3{1, 2, 3})
+	tr := m.Transpose()
+	if tr[12] != 1 || tr[13] != 2 || tr[14] != 3 || tr[3] != 0 {
+		t.Errorf("Transpose = %v", tr)
+	}
+	if tr.Transpose() != m {
+		t.Error("transposing twice did not restore the matrix")
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	view := LookAt(Vec3{0, 0, 5}, Vec3{}, Vec3{0, 1, 0})
+
+	// The target ends up straight ahead on the negative Z axis
+	got, _ := view.MulPoint(Vec3{})
+	if !nearVec3(got, Vec3{0, 0, -5}) {
+		t.Errorf("target in view space = %v, want {0 0 -5}", got)
+	}
+	// Up and right are preserved for a camera on the Z axis
+	got, _ = view.MulPoint(Vec3{1, 1, 0})
+	if !nearVec3(got, Vec3{1, 1, -5}) {
+		t.Errorf("offset point in view space = %v, want {1 1 -5}", got)
+	}
+}
+
+func TestPerspective(t *testing.T) {
+	const zNear, zFar = 1.0, 10.0
+	proj := Perspective(math.Pi/2, 2, zNear, zFar)
+
+	tests := []struct {
+		name  string
+		in    Vec3
+		wantZ float64
+	}{
+		{"near plane", Vec3{0, 0, -zNear}, -1},
+		{"far plane", Vec3{0, 0, -zFar}, 1},
+	}
+	for _, tt := range tests {
+		got, _ := proj.MulPoint(tt.in)
+		if !nearVec3(got, Vec3{0, 0, tt.wantZ}) {
+			t.Errorf("%s projects to %v, want z=%v", tt.name, got, tt.wantZ)
+		}
+	}
+
+	// With a 90 degree field of view the top edge at depth d is at y = d,
+	// and the aspect ratio widens the horizontal extent
+	got, w := proj.MulPoint(Vec3{4, 2, -2})
+	if !near(got.X, 1) || !near(got.Y, 1) || !near(w, 2) {
+		t.Errorf("frustum corner projects to %v (w=%v), want x=1 y=1 w=2", got, w)
+	}
+
+	// Points behind the camera have negative w
+	if _, w := proj.MulPoint(Vec3{0, 0, 1}); w >= 0 {
+		t.Errorf("point behind the camera has w=%v, want negative", w)
+	}
+}
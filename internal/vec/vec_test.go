@@ -0,0 +1,121 @@
+package vec
+
+import (
+	"math"
+	"testing"
+)
+
+const epsilon = 1e-9
+
+// near reports whether a and b are equal within epsilon.
+func near(a, b float64) bool {
+	return math.Abs(a-b) < epsilon
+}
+
+// nearVec3 reports whether every component of a and b is equal within epsilon.
+func nearVec3(a, b Vec3) bool {
+	return near(a.X, b.X) && near(a.Y, b.Y) && near(a.Z, b.Z)
+}
+
+func TestVec2Arithmetic(t *testing.T) {
+	a, b := Vec2{3, 4}, Vec2{-1, 2}
+	if got := a.Add(b); got != (Vec2{2, 6}) {
+		t.Errorf("Add = %v, want {2 6}", got)
+	}
+	if got := a.Sub(b); got != (Vec2{4, 2}) {
+		t.Errorf("Sub = %v, want {4 2}", got)
+	}
+	if got := a.Scale(2); got != (Vec2{6, 8}) {
+		t.Errorf("Scale = %v, want {6 8}", got)
+	}
+	if got := a.Dot(b); got != 5 {
+		t.Errorf("Dot = %v, want 5", got)
+	}
+	if got := a.Cross(b); got != 10 {
+		t.Errorf("Cross = %v, want 10", got)
+	}
+	if got := a.Len(); got != 5 {
+		t.Errorf("Len = %v, want 5", got)
+	}
+}
+
+func TestVec2Normalize(t *testing.T) {
+	tests := []struct {
+		in   Vec2
+		want Vec2
+	}{
+		{Vec2{3, 4}, Vec2{0.6, 0.8}},
+		{Vec2{0, -2}, Vec2{0, -1}},
+		{Vec2{}, Vec2{}},
+	}
+	for _, tt := range tests {
+		got := tt.in.Normalize()
+		if !near(got.X, tt.want.X) || !near(got.Y, tt.want.Y) {
+			t.Errorf("%v.Normalize() = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVec2Rotate(t *testing.T) {
+	got := Vec2{1, 0}.Rotate(math.Pi / 2)
+	if !near(got.X, 0) || !near(got.Y, 1) {
+		t.Errorf("Rotate(pi/2) = %v, want {0 1}", got)
+	}
+}
+
+func TestVec3Arithmetic(t *testing.T) {
+	a, b := Vec3{1, 2, 3}, Vec3{4, -5, 6}
+	if got := a.Add(b); got != (Vec3{5, -3, 9}) {
+		t.Errorf("Add = %v, want {5 -3 9}", got)
+	}
+	if got := a.Sub(b); got != (Vec3{-3, 7, -3}) {
+		t.Errorf("Sub = %v, want {-3 7 -3}", got)
+	}
+	if got := a.Scale(-1); got != (Vec3{-1, -2, -3}) {
+		t.Errorf("Scale = %v, want {-1 -2 -3}", got)
+	}
+	if got := a.Dot(b); got != 12 {
+		t.Errorf("Dot = %v, want 12", got)
+	}
+	if got := (Vec3{2, 3, 6}).Len(); got != 7 {
+		t.Errorf("Len = %v, want 7", got)
+	}
+	if got := a.Lerp(b, 0.5); got != (Vec3{2.5, -1.5, 4.5}) {
+		t.Errorf("Lerp = %v, want {2.5 -1.5 4.5}", got)
+	}
+}
+
+func TestVec3Cross(t *testing.T) {
+	x, y, z := Vec3{1, 0, 0}, Vec3{0, 1, 0}, Vec3{0, 0, 1}
+	tests := []struct {
+		a, b, want Vec3
+	}{
+		{x, y, z},
+		{y, z, x},
+		{z, x, y},
+		{y, x, z.Scale(-1)},
+		{x, x, Vec3{}},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Cross(tt.b); got != tt.want {
+			t.Errorf("%v x %v = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	// The cross product is perpendicular to both inputs
+	a, b := Vec3{1, 2, 3}, Vec3{-2, 0.5, 4}
+	c := a.Cross(b)
+	if !near(c.Dot(a), 0) || !near(c.Dot(b), 0) {
+		t.Errorf("%v is not perpendicular to %v and %v", c, a, b)
+	}
+}
+
+func TestVec3Normalize(t *testing.T) {
+	got := Vec3{0, 3, 4}.Normalize()
+	if !nearVec3(got, Vec3{0, 0.6, 0.8}) {
+		t.Errorf("Normalize = %v, want {0 0.6 0.8}", got)
+	}
+	if got := (Vec3{}).Normalize(); got != (Vec3{}) {
+		t.Errorf("zero vector Normalize = %v, want zero", got)
+	}
+}
@@ -0,0 +1,57 @@
+package wave
+
+import "math"
+
+const (
+	gustRadius = 0.35 // Reach of the calm patch, in grid units
+	gustCalm   = 1.2  // Seconds for the calm patch to fill in again
+	gustLife   = 4.0  // Seconds until a gust is forgotten
+	// Ripples spread from the gust at this speed in grid units per second,
+	// with this wavelength
+	rippleSpeed      = 0.6
+	rippleWavelength = 0.12
+)
+
+// gust is a puff of air on the water.
+type gust struct {
+	x, y     float64
+	start    float64
+	strength float64
+}
+
+// Blow lets a gust of air hit the surface at x, y, each from -1 to 1 across
+// the grid, with a strength from 0 to 1. The water under it flattens, then
+// fills in again as rings of ripples spread out.
+func (w *Wave) Blow(x, y, strength float64) {
+	w.gusts = append(w.gusts, gust{x: x, y: y, start: w.lastT, strength: min(max(strength, 0), 1)})
+}
+
+// forgetGusts drops the gusts that have died down by time t.
+func (w *Wave) forgetGusts(t float64) {
+	live := w.gusts[:0]
+	for _, g := range w.gusts {
+		if t-g.start < gustLife {
+			live = append(live, g)
+		}
+	}
+	w.gusts = live
+}
+
+// blown returns the height z of the surface at x0, y0 after the gusts.
+func (w *Wave) blown(x0, y0, z, t float64) float64 {
+	for _, g := range w.gusts {
+		age := max(t-g.start, 0)
+		d := math.Hypot(x0-g.x, y0-g.y)
+		// The patch under the gust is pressed flat and slowly fills in
+		calm := g.strength * math.Exp(-d*d/(gustRadius*gustRadius)) * math.Exp(-age/gustCalm)
+		z *= 1 - calm
+		// The ring front has not reached points further out yet
+		front := rippleSpeed * age
+		if d > front {
+			continue
+		}
+		fade := math.Exp(-age/(gustLife/3)) * math.Exp(-d/gustRadius)
+		z += 0.04 * g.strength * fade * math.Sin(2*math.Pi*(d-front)/rippleWavelength)
+	}
+	return z
+}
@@ -0,0 +1,103 @@
+package wave
+
+import "github.com/olegchuev/screensaver/internal/vec"
+
+// Preset is a curated sea: wave components, spray and the color gradient
+// that suits them, chosen together so the surface looks right as is.
+type Preset struct {
+	Name        string
+	Description string
+	// Components are the Gerstner waves of the sea, largest first
+	Components []WaveParams
+	// Steepness scales the sharpness of every component
+	Steepness float64
+	// ParticleDensity is the amount of spray
+	ParticleDensity float64
+	// Theme names the color gradient the sea is shown in
+	Theme string
+}
+
+// presets are the built-in seas, from the quietest to the wildest.
+var presets = []Preset{
+	{
+		Name:        "calm",
+		Description: "Long, low swell on a quiet day",
+		Components: []WaveParams{
+			{Amplitude: 0.06, Wavelength: 2.0, Speed: 0.5, Direction: vec.Vec2{X: 1.0, Y: 0.2}, Steepness: 0.3},
+			{Amplitude: 0.03, Wavelength: 1.1, Speed: 0.7, Direction: vec.Vec2{X: 0.8, Y: -0.4}, Steepness: 0.2},
+		},
+		Steepness:       0.8,
+		ParticleDensity: 0.1,
+		Theme:           "ocean",
+	},
+	{
+		Name:        "choppy",
+		Description: "Short, crossing waves whipped up by the wind",
+		Components: []WaveParams{
+			{Amplitude: 0.10, Wavelength: 1.0, Speed: 1.1, Direction: vec.Vec2{X: 1.0, Y: 0.3}, Steepness: 0.6},
+			{Amplitude: 0.07, Wavelength: 0.6, Speed: 1.4, Direction: vec.Vec2{X: 0.6, Y: -0.7}, Steepness: 0.5},
+			{Amplitude: 0.05, Wavelength: 0.35, Speed: 1.8, Direction: vec.Vec2{X: -0.4, Y: 0.9}, Steepness: 0.5},
+			{Amplitude: 0.03, Wavelength: 0.25, Speed: 2.2, Direction: vec.Vec2{X: -0.9, Y: -0.2}, Steepness: 0.4},
+		},
+		Steepness:       1,
+		ParticleDensity: 0.4,
+		Theme:           "silver",
+	},
+	{
+		Name:        "storm",
+		Description: "High, steep seas breaking into spray",
+		Components: []WaveParams{
+			{Amplitude: 0.25, Wavelength: 1.8, Speed: 1.2, Direction: vec.Vec2{X: 1.0, Y: 0.4}, Steepness: 0.8},
+			{Amplitude: 0.14, Wavelength: 1.0, Speed: 1.5, Direction: vec.Vec2{X: 0.7, Y: -0.5}, Steepness: 0.7},
+			{Amplitude: 0.08, Wavelength: 0.5, Speed: 1.9, Direction: vec.Vec2{X: -0.2, Y: 1.0}, Steepness: 0.6},
+			{Amplitude: 0.04, Wavelength: 0.3, Speed: 2.4, Direction: vec.Vec2{X: 0.9, Y: -0.8}, Steepness: 0.5},
+		},
+		Steepness:       1.1,
+		ParticleDensity: 0.8,
+		Theme:           "storm",
+	},
+	{
+		Name:        "tsunami",
+		Description: "One towering wave rolling over a murky sea",
+		Components: []WaveParams{
+			{Amplitude: 0.45, Wavelength: 4.0, Speed: 1.6, Direction: vec.Vec2{X: 1.0, Y: 0.0}, Steepness: 0.9},
+			{Amplitude: 0.05, Wavelength: 0.6, Speed: 1.2, Direction: vec.Vec2{X: 0.5, Y: 0.8}, Steepness: 0.4},
+		},
+		Steepness:       1,
+		ParticleDensity: 0.6,
+		Theme:           "silt",
+	},
+}
+
+// Presets returns the built-in presets, from the quietest sea to the wildest.
+func Presets() []Preset {
+	return presets
+}
+
+// PresetNames returns the names of the built-in presets in the same order.
+func PresetNames() []string {
+	names := make([]string, len(presets))
+	for i, p := range presets {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// LookupPreset returns the preset with the given name.
+func LookupPreset(name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Preset{}, false
+}
+
+// Apply sets the waves, steepness and spray of c to the preset's. The
+// components are copied, so changing c leaves the preset alone.
+func (p Preset) Apply(c *Config) {
+	c.Components = append([]WaveParams(nil), p.Components...)
+	c.WaveCount = len(p.Components)
+	c.Steepness = p.Steepness
+	c.ParticleDensity = p.ParticleDensity
+}
@@ -0,0 +1,87 @@
+package wave
+
+import (
+	"math"
+
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+const (
+	// shadowReach is the farthest a crest casts its shadow, in grid units
+	shadowReach = 0.8
+	// shadowPenumbra is how far in slope the light ray may pass below a
+	// crest, and above it, across which the shadow fades in
+	shadowPenumbra = 0.12
+)
+
+// DefaultLight is the direction towards the light: a low sun behind and to
+// the left of the viewer's line of sight, so the crests shade the troughs
+// in front of them.
+var DefaultLight = vec.Vec3{X: -0.6, Y: 1, Z: 0.6}
+
+// updateShadows finds how much each grid point is shaded from the light by
+// nearer crests. A ray from each point towards the light is marched over
+// the height field one grid step at a time; where it passes below the
+// surface the point is in shadow, softly so near the edge of a crest.
+func (w *Wave) updateShadows() {
+	cfg := w.config
+	for _, row := range w.Shadows {
+		clear(row)
+	}
+	if cfg.Shadows <= 0 {
+		return
+	}
+
+	dir := vec.Vec2{X: cfg.Light.X, Y: cfg.Light.Y}
+	run := dir.Len()
+	if run == 0 {
+		// A light overhead casts no shadows onto the surface
+		return
+	}
+	dir = dir.Scale(1 / run)
+	slope := cfg.Light.Z / run
+
+	// Steps are a grid cell long along the finer axis
+	cellX := 2 / float64(cfg.GridWidth-1)
+	cellY := 2 / float64(cfg.GridDepth-1)
+	step := math.Min(cellX, cellY)
+	steps := int(shadowReach / step)
+	di, dj := dir.Y*step/cellY, dir.X*step/cellX
+
+	for i, row := range w.GridPoints {
+		for j, p := range row {
+			lit := 1.0
+			for n := 1; n <= steps && lit > 0; n++ {
+				h, ok := w.heightAt(float64(i)+di*float64(n), float64(j)+dj*float64(n))
+				if !ok {
+					break
+				}
+				dist := step * float64(n)
+				gap := (p.Z + slope*dist - h) / dist
+				lit = math.Min(lit, 0.5+gap/shadowPenumbra)
+			}
+			w.Shadows[i][j] = cfg.Shadows * (1 - max(lit, 0))
+		}
+	}
+}
+
+// HeightAt returns the height of the surface at x, y on the grid, from -1
+// to 1 across, reporting false off the grid.
+func (w *Wave) HeightAt(x, y float64) (float64, bool) {
+	cfg := w.config
+	return w.heightAt((y+1)/2*float64(cfg.GridDepth-1), (x+1)/2*float64(cfg.GridWidth-1))
+}
+
+// heightAt interpolates the surface height between the grid points around
+// fractional grid indices, reporting false off the grid.
+func (w *Wave) heightAt(i, j float64) (float64, bool) {
+	i0, j0 := int(math.Floor(i)), int(math.Floor(j))
+	if i0 < 0 || j0 < 0 || i0 >= len(w.GridPoints)-1 || j0 >= len(w.GridPoints[0])-1 {
+		return 0, false
+	}
+	ti, tj := i-float64(i0), j-float64(j0)
+	near, far := w.GridPoints[i0], w.GridPoints[i0+1]
+	z0 := near[j0].Z + (near[j0+1].Z-near[j0].Z)*tj
+	z1 := far[j0].Z + (far[j0+1].Z-far[j0].Z)*tj
+	return z0 + (z1-z0)*ti, true
+}
@@ -3,8 +3,25 @@ package wave
 
 import (
 	"math"
+	"math/rand"
+
+	"github.com/olegchuev/screensaver/internal/noise"
+	"github.com/olegchuev/screensaver/internal/particle"
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+const (
+	foamCapacity = 800
+	foamGravity  = -0.6 // Pulls spray back down onto the surface
+	foamDrag     = 0.8
+	// Spatial frequency and drift speed of the detail ripples
+	detailScale = 5.0
+	detailSpeed = 0.6
 )
 
+// detailFBM gives the ripples a few octaves of chop without much cost.
+var detailFBM = noise.FBM{Octaves: 3, Lacunarity: 2.1, Gain: 0.5}
+
 // Config holds wave simulation parameters for controlling the wave appearance and behavior.
 type Config struct {
 	// Grid dimensions
@@ -14,6 +31,18 @@ type Config struct {
 	ParticleDensity float64
 	// Wave parameters using Gerstner wave equations
 	WaveCount int
+	// Components replaces the built-in Gerstner waves when not empty, in
+	// which case WaveCount is ignored
+	Components []WaveParams
+	// Steepness scales the sharpness of every wave component (1 is the default look)
+	Steepness float64
+	// Height of the small noise ripples layered over the Gerstner waves
+	Detail float64
+	// Shadows is how dark the crests shade the water behind them from the
+	// light, from 0 for no shadows to 1 for black
+	Shadows float64
+	// Light is the direction towards the light casting the shadows
+	Light vec.Vec3
 }
 
 // DefaultConfig returns sensible defaults for a particle-based ocean wave.
@@ -23,6 +52,10 @@ func DefaultConfig() Config {
 		GridDepth:       60,
 		ParticleDensity: 0.3,
 		WaveCount:       3,
+		Steepness:       1,
+		Detail:          0.015,
+		Shadows:         0.4,
+		Light:           DefaultLight,
 	}
 }
 
@@ -31,74 +64,90 @@ type WaveParams struct {
 	Amplitude  float64
 	Wavelength float64
 	Speed      float64
-	Direction  [2]float64 // Normalized direction vector
-	Steepness  float64    // 0-1, controls wave sharpness
+	Direction  vec.Vec2 // Direction of travel, normalized by NewWave
+	Steepness  float64  // 0-1, controls wave sharpness
 }
 
-// Point3D represents a point in 3D space with X, Y, Z coordinates.
-type Point3D struct {
-	X, Y, Z float64
+// components are the Gerstner waves the surface is built from, largest first.
+// WaveCount selects how many of them are used.
+var components = [...]WaveParams{
+	{Amplitude: 0.15, Wavelength: 1.5, Speed: 0.8, Direction: vec.Vec2{X: 1.0, Y: 0.3}, Steepness: 0.6},
+	{Amplitude: 0.08, Wavelength: 0.8, Speed: 1.2, Direction: vec.Vec2{X: 0.7, Y: -0.5}, Steepness: 0.4},
+	{Amplitude: 0.05, Wavelength: 0.4, Speed: 1.6, Direction: vec.Vec2{X: -0.3, Y: 0.8}, Steepness: 0.3},
 }
 
-// Particle represents a water particle with position and properties.
-type Particle struct {
-	Pos      Point3D
-	Velocity float64
+// MaxWaveCount is the largest supported WaveCount.
+const MaxWaveCount = len(components)
+
+// Waves returns the Gerstner waves the surface is built from: the
+// configured components, or else the first WaveCount built-in ones.
+func (c Config) Waves() []WaveParams {
+	if len(c.Components) > 0 {
+		return c.Components
+	}
+	return components[:min(max(c.WaveCount, 0), len(components))]
 }
 
+// CombinedSteepness returns the sum of Q*k*A over the wave components,
+// which must stay at or below 1 or the crests loop over themselves.
+func (c Config) CombinedSteepness() float64 {
+	waves := c.Waves()
+	sum := 0.0
+	for _, wave := range waves {
+		// Q is normalized by the component count, so k*A cancels out
+		sum += wave.Steepness * c.Steepness / float64(len(waves))
+	}
+	return sum
+}
+
+// Point3D represents a point in 3D space with X, Y, Z coordinates.
+type Point3D = vec.Vec3
+
 // Wave represents a particle-based ocean surface using Gerstner waves.
 type Wave struct {
 	config     Config
-	Particles  []Particle
-	GridPoints [][]Point3D // Surface grid for rendering
+	Foam       *particle.System // Spray thrown off the wave crests
+	GridPoints [][]Point3D      // Surface grid for rendering
+	Shadows    [][]float64      // Shade of each grid point, see Config.Shadows
 	waves      []WaveParams
+	detail     *noise.Simplex
 	MinZ       float64
 	MaxZ       float64
+	// Swell multiplies the wave heights and the spray, 1 for the
+	// configured sea; audio-reactive scenes raise it with the music
+	Swell   float64
+	gusts   []gust
+	lastT   float64
+	started bool
 }
 
 // NewWave creates a new particle-based ocean wave with the given configuration.
 func NewWave(cfg Config) *Wave {
 	w := &Wave{
 		config:     cfg,
-		Particles:  make([]Particle, 0),
+		Foam:       particle.NewSystem(foamCapacity, 1),
 		GridPoints: make([][]Point3D, cfg.GridDepth),
-		waves:      make([]WaveParams, cfg.WaveCount),
+		Shadows:    make([][]float64, cfg.GridDepth),
+		waves:      append([]WaveParams(nil), cfg.Waves()...),
+		detail:     noise.NewSimplex(1),
+		Swell:      1,
 	}
 
 	// Initialize grid
 	for i := range w.GridPoints {
 		w.GridPoints[i] = make([]Point3D, cfg.GridWidth)
+		w.Shadows[i] = make([]float64, cfg.GridWidth)
 	}
 
-	// Initialize Gerstner wave components with different characteristics
-	w.waves[0] = WaveParams{
-		Amplitude:  0.15,
-		Wavelength: 1.5,
-		Speed:      0.8,
-		Direction:  [2]float64{1.0, 0.3},
-		Steepness:  0.6,
-	}
-	w.waves[1] = WaveParams{
-		Amplitude:  0.08,
-		Wavelength: 0.8,
-		Speed:      1.2,
-		Direction:  [2]float64{0.7, -0.5},
-		Steepness:  0.4,
-	}
-	w.waves[2] = WaveParams{
-		Amplitude:  0.05,
-		Wavelength: 0.4,
-		Speed:      1.6,
-		Direction:  [2]float64{-0.3, 0.8},
-		Steepness:  0.3,
+	w.Foam.Forces = []particle.Force{
+		particle.Gravity(0, 0, foamGravity),
+		particle.Drag(foamDrag),
 	}
 
-	// Normalize wave directions
+	// Normalize wave directions and apply the configured steepness
 	for i := range w.waves {
-		len := math.Sqrt(w.waves[i].Direction[0]*w.waves[i].Direction[0] +
-			w.waves[i].Direction[1]*w.waves[i].Direction[1])
-		w.waves[i].Direction[0] /= len
-		w.waves[i].Direction[1] /= len
+		w.waves[i].Direction = w.waves[i].Direction.Normalize()
+		w.waves[i].Steepness *= cfg.Steepness
 	}
 
 	return w
@@ -109,6 +158,7 @@ func (w *Wave) Update(t float64) {
 	cfg := w.config
 	w.MinZ = math.MaxFloat64
 	w.MaxZ = -math.MaxFloat64
+	w.forgetGusts(t)
 
 	// Update surface grid using Gerstner waves
 	for depth := 0; depth < cfg.GridDepth; depth++ {
@@ -119,6 +169,12 @@ func (w *Wave) Update(t float64) {
 
 			// Apply Gerstner wave displacement
 			x, y, z := w.gerstnerWave(x0, y0, t)
+			if cfg.Detail > 0 {
+				z += cfg.Detail * detailFBM.Noise3(w.detail, x0*detailScale, y0*detailScale, t*detailSpeed)
+			}
+			if len(w.gusts) > 0 {
+				z = w.blown(x0, y0, z, t)
+			}
 
 			w.GridPoints[depth][width] = Point3D{X: x, Y: y, Z: z}
 
@@ -130,21 +186,39 @@ func (w *Wave) Update(t float64) {
 			}
 		}
 	}
+	w.updateShadows()
+
+	dt := 0.0
+	if w.started {
+		dt = t - w.lastT
+	}
+	w.started, w.lastT = true, t
+	w.updateFoam(dt)
+}
 
-	// Generate particles on the surface (spray/foam effect)
-	w.Particles = w.Particles[:0] // Clear existing particles
+// updateFoam throws spray off the crests and lets existing spray fall back.
+func (w *Wave) updateFoam(dt float64) {
+	cfg := w.config
+	w.Foam.Update(dt)
+
+	// Each sampled crest point sheds spray at a rate set by the density
+	chance := cfg.ParticleDensity * w.Swell * dt * 4
+	crest := (w.MaxZ-w.MinZ)*0.6 + w.MinZ
+	rng := w.Foam.Rand().Float64
 	for depth := 0; depth < cfg.GridDepth; depth += 3 {
 		for width := 0; width < cfg.GridWidth; width += 3 {
-			if math.Mod(float64(width+depth), 1.0/cfg.ParticleDensity) < 1.0 {
-				p := w.GridPoints[depth][width]
-				// Add particles at wave peaks
-				if p.Z > (w.MaxZ-w.MinZ)*0.6+w.MinZ {
-					w.Particles = append(w.Particles, Particle{
-						Pos:      p,
-						Velocity: p.Z,
-					})
-				}
+			p := w.GridPoints[depth][width]
+			if p.Z <= crest || rng() >= chance {
+				continue
 			}
+			w.Foam.Emit(func(s *particle.Particle, r *rand.Rand) {
+				s.X, s.Y, s.Z = p.X, p.Y, p.Z
+				// Blown along the dominant wave direction
+				s.VX = w.waves[0].Direction.X*0.08 + (r.Float64()-0.5)*0.04
+				s.VY = w.waves[0].Direction.Y*0.08 + (r.Float64()-0.5)*0.04
+				s.VZ = 0.1 + r.Float64()*0.15
+				s.Life = 0.6 + r.Float64()*0.6
+			})
 		}
 	}
 }
@@ -160,15 +234,15 @@ func (w *Wave) gerstnerWave(x0, y0, t float64) (float64, float64, float64) {
 		Q := wave.Steepness / (k * wave.Amplitude * float64(len(w.waves)))
 
 		// Direction components
-		dx, dy := wave.Direction[0], wave.Direction[1]
+		dx, dy := wave.Direction.X, wave.Direction.Y
 
 		// Phase
-		phase := k*(dx*x0+dy*y0) - c*t
+		phase := k*wave.Direction.Dot(vec.Vec2{X: x0, Y: y0}) - c*t
 
 		// Gerstner wave displacement
 		x += Q * wave.Amplitude * dx * math.Cos(phase)
 		y += Q * wave.Amplitude * dy * math.Cos(phase)
-		z += wave.Amplitude * math.Sin(phase)
+		z += wave.Amplitude * w.Swell * math.Sin(phase)
 	}
 
 	return x, y, z
@@ -178,3 +252,58 @@ func (w *Wave) gerstnerWave(x0, y0, t float64) (float64, float64, float64) {
 func (w *Wave) Size() (int, int) {
 	return w.config.GridDepth, w.config.GridWidth
 }
+
+// SeaState summarizes the surface the way a wave buoy would. Heights are in
+// grid units, the surface spanning 2 units across, and times in scene
+// seconds.
+type SeaState struct {
+	// SignificantHeight is four times the standard deviation of the surface
+	// elevation, the usual estimate of the mean height of the highest third
+	// of the waves
+	SignificantHeight float64 `json:"significant_height"`
+	// DominantPeriod is the period of the most energetic wave component
+	DominantPeriod float64 `json:"dominant_period"`
+	// DominantDirection is the heading that component travels towards, in
+	// degrees counterclockwise from the grid's X axis
+	DominantDirection float64 `json:"dominant_direction"`
+	// Storm is how rough the sea is, from 0 for calm to 1 where the crests
+	// start to break, see Config.CombinedSteepness
+	Storm float64 `json:"storm"`
+	// Spray is the number of spray particles in the air
+	Spray int `json:"spray"`
+}
+
+// SeaState measures the surface as of the last Update.
+func (w *Wave) SeaState() SeaState {
+	var sum, sumSq float64
+	n := 0
+	for _, row := range w.GridPoints {
+		for _, p := range row {
+			sum += p.Z
+			sumSq += p.Z * p.Z
+			n++
+		}
+	}
+	state := SeaState{Storm: min(w.config.CombinedSteepness(), 1), Spray: w.Foam.Len()}
+	if n > 0 {
+		mean := sum / float64(n)
+		state.SignificantHeight = 4 * math.Sqrt(max(sumSq/float64(n)-mean*mean, 0))
+	}
+
+	// Energy grows with the square of the amplitude
+	var dominant *WaveParams
+	for i := range w.waves {
+		if dominant == nil || w.waves[i].Amplitude > dominant.Amplitude {
+			dominant = &w.waves[i]
+		}
+	}
+	if dominant != nil {
+		// The phase advances by Speed radians per second, and a wave that
+		// stands still has no period
+		if dominant.Speed != 0 {
+			state.DominantPeriod = 2 * math.Pi / math.Abs(dominant.Speed)
+		}
+		state.DominantDirection = math.Atan2(dominant.Direction.Y, dominant.Direction.X) * 180 / math.Pi
+	}
+	return state
+}
@@ -0,0 +1,64 @@
+package wave
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/vec"
+)
+
+func TestSeaState(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []WaveParams
+		period     float64
+		direction  float64
+		calm       bool
+	}{
+		{
+			name:       "still",
+			components: []WaveParams{{Amplitude: 0, Wavelength: 1, Speed: 1, Direction: vec.Vec2{X: 1}}},
+			period:     2 * math.Pi,
+			calm:       true,
+		},
+		{
+			name: "largest wave dominates",
+			components: []WaveParams{
+				{Amplitude: 0.05, Wavelength: 0.5, Speed: 2, Direction: vec.Vec2{X: 1}},
+				{Amplitude: 0.2, Wavelength: 1.5, Speed: 0.5, Direction: vec.Vec2{Y: 1}},
+			},
+			period:    4 * math.Pi,
+			direction: 90,
+		},
+		{
+			name:       "standing wave",
+			components: []WaveParams{{Amplitude: 0.1, Wavelength: 1, Speed: 0, Direction: vec.Vec2{X: -1}}},
+			period:     0,
+			direction:  180,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.GridWidth, cfg.GridDepth = 20, 20
+			cfg.Detail = 0
+			cfg.Components = tt.components
+			w := NewWave(cfg)
+			w.Update(0.7)
+			s := w.SeaState()
+			if math.Abs(s.DominantPeriod-tt.period) > 1e-9 {
+				t.Errorf("DominantPeriod = %v, want %v", s.DominantPeriod, tt.period)
+			}
+			if math.Abs(s.DominantDirection-tt.direction) > 1e-9 {
+				t.Errorf("DominantDirection = %v, want %v", s.DominantDirection, tt.direction)
+			}
+			if calm := s.SignificantHeight < 1e-9; calm != tt.calm {
+				t.Errorf("SignificantHeight = %v, want calm %v", s.SignificantHeight, tt.calm)
+			}
+			if _, err := json.Marshal(s); err != nil {
+				t.Errorf("Marshal: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// requestTimeout bounds the request, so a slow service cannot hold up the
+// exit.
+const requestTimeout = 10 * time.Second
+
+// ipLocationAPI answers with the approximate location of the caller's
+// public IP address.
+const ipLocationAPI = "https://ipapi.co/json/"
+
+// LocateIP returns the approximate location of the public IP address the
+// request comes from, usually the nearest city.
+func LocateIP(ctx context.Context) (Location, error) {
+	return locateIP(ctx, &http.Client{Timeout: requestTimeout}, ipLocationAPI)
+}
+
+// locateIP asks api for the location.
+func locateIP(ctx context.Context, client *http.Client, api string) (Location, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api, nil)
+	if err != nil {
+		return Location{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Location{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("ip location: %s", resp.Status)
+	}
+	var body struct {
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Location{}, fmt.Errorf("ip location: %w", err)
+	}
+	if body.Latitude == nil || body.Longitude == nil {
+		return Location{}, errors.New("ip location: no coordinates in the response")
+	}
+	return Location{Latitude: *body.Latitude, Longitude: *body.Longitude}, nil
+}
@@ -0,0 +1,175 @@
+// Package weather reads current conditions and a short forecast from
+// Open-Meteo, which needs no account or key, fetched and cached like any
+// other data source. It can also find the place from the public IP
+// address.
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// forecastDays is the number of days after today the forecast covers.
+const forecastDays = 3
+
+// Condition is the kind of weather, coarse enough for an icon.
+type Condition int
+
+const (
+	Clear Condition = iota
+	PartlyCloudy
+	Cloudy
+	Fog
+	Drizzle
+	Rain
+	Snow
+	Thunder
+)
+
+// conditionNames describe the conditions, in Condition order.
+var conditionNames = []string{"clear", "partly cloudy", "cloudy", "fog", "drizzle", "rain", "snow", "thunderstorm"}
+
+// String describes the condition, e.g. "partly cloudy".
+func (c Condition) String() string {
+	if c < 0 || int(c) >= len(conditionNames) {
+		return fmt.Sprintf("Condition(%d)", int(c))
+	}
+	return conditionNames[c]
+}
+
+// conditionOf returns the condition of a WMO weather interpretation code,
+// as Open-Meteo reports them.
+func conditionOf(code int) Condition {
+	switch {
+	case code == 0:
+		return Clear
+	case code <= 2:
+		return PartlyCloudy
+	case code == 3:
+		return Cloudy
+	case code == 45 || code == 48:
+		return Fog
+	case code >= 51 && code <= 57:
+		return Drizzle
+	case code >= 71 && code <= 77, code == 85, code == 86:
+		return Snow
+	case code >= 95:
+		return Thunder
+	}
+	// Rain and showers, 61-67 and 80-82, and anything unknown
+	return Rain
+}
+
+// Day is the forecast for one day.
+type Day struct {
+	Date      time.Time
+	Condition Condition
+	Low, High float64
+}
+
+// Report is the weather at a place at one moment.
+type Report struct {
+	// Fetched is when the report was received
+	Fetched     time.Time
+	Temperature float64
+	Condition   Condition
+	// Days is the forecast for the days after today
+	Days []Day
+	// Unit is the temperature unit, "°C" or "°F"
+	Unit string
+}
+
+// Location is a place on Earth in decimal degrees.
+type Location struct {
+	Latitude, Longitude float64
+}
+
+// ParseLocation parses a location of the form "latitude,longitude", for
+// example "52.52,13.41".
+func ParseLocation(s string) (Location, error) {
+	lat, lon, ok := strings.Cut(s, ",")
+	var l Location
+	var errLat, errLon error
+	if ok {
+		l.Latitude, errLat = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+		l.Longitude, errLon = strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	}
+	if !ok || errLat != nil || errLon != nil || l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+		return Location{}, fmt.Errorf("invalid location %q: want latitude,longitude in degrees like 52.52,13.41", s)
+	}
+	return l, nil
+}
+
+// String formats the location like ParseLocation accepts it.
+func (l Location) String() string {
+	return strconv.FormatFloat(l.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+}
+
+// forecastAPI is the Open-Meteo endpoint for current weather and forecasts.
+const forecastAPI = "https://api.open-meteo.com/v1/forecast"
+
+// ForecastURL returns the Open-Meteo request for the current weather and
+// forecast at l, in degrees Celsius or Fahrenheit. Decode reads the answer.
+func ForecastURL(l Location, fahrenheit bool) string {
+	q := url.Values{
+		"latitude":      {strconv.FormatFloat(l.Latitude, 'f', -1, 64)},
+		"longitude":     {strconv.FormatFloat(l.Longitude, 'f', -1, 64)},
+		"current":       {"temperature_2m,weather_code"},
+		"daily":         {"weather_code,temperature_2m_max,temperature_2m_min"},
+		"timezone":      {"auto"},
+		"forecast_days": {strconv.Itoa(forecastDays + 1)},
+	}
+	if fahrenheit {
+		q.Set("temperature_unit", "fahrenheit")
+	}
+	return forecastAPI + "?" + q.Encode()
+}
+
+// forecast is the part of an Open-Meteo response a report is made of.
+type forecast struct {
+	CurrentUnits struct {
+		Temperature string `json:"temperature_2m"`
+	} `json:"current_units"`
+	Current *struct {
+		Temperature float64 `json:"temperature_2m"`
+		Code        int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Time []string  `json:"time"`
+		Code []int     `json:"weather_code"`
+		Max  []float64 `json:"temperature_2m_max"`
+		Min  []float64 `json:"temperature_2m_min"`
+	} `json:"daily"`
+}
+
+// Decode reads an Open-Meteo response to a ForecastURL request. The report's
+// Fetched time is left to the caller, who knows when the response came.
+func Decode(data []byte) (Report, error) {
+	var f forecast
+	if err := json.Unmarshal(data, &f); err != nil {
+		return Report{}, fmt.Errorf("open-meteo: %w", err)
+	}
+	if f.Current == nil {
+		return Report{}, errors.New("open-meteo: no current weather in the response")
+	}
+	rep := Report{
+		Temperature: f.Current.Temperature,
+		Condition:   conditionOf(f.Current.Code),
+		Unit:        f.CurrentUnits.Temperature,
+	}
+	d := f.Daily
+	// The first day is today
+	for i := 1; i < len(d.Time) && i < len(d.Code) && i < len(d.Max) && i < len(d.Min); i++ {
+		date, err := time.Parse(time.DateOnly, d.Time[i])
+		if err != nil {
+			return Report{}, fmt.Errorf("open-meteo: %w", err)
+		}
+		rep.Days = append(rep.Days, Day{Date: date, Condition: conditionOf(d.Code[i]), Low: d.Min[i], High: d.Max[i]})
+	}
+	return rep, nil
+}
@@ -0,0 +1,78 @@
+package weather
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const response = `{
+	"current_units": {"temperature_2m": "°C"},
+	"current": {"time": "2026-10-15T14:00", "temperature_2m": 12.4, "weather_code": 2},
+	"daily": {
+		"time": ["2026-10-15", "2026-10-16", "2026-10-17", "2026-10-18"],
+		"weather_code": [2, 61, 71, 95],
+		"temperature_2m_max": [14.1, 11.5, 3.2, 18],
+		"temperature_2m_min": [6, 5.5, -2.1, 9.9]
+	}
+}`
+
+func TestForecastURL(t *testing.T) {
+	u, err := url.Parse(ForecastURL(Location{Latitude: 52.52, Longitude: 13.41}, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("latitude") != "52.52" || q.Get("longitude") != "13.41" || q.Get("temperature_unit") != "fahrenheit" {
+		t.Errorf("query %q misses the location or units", u.RawQuery)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	rep, err := Decode([]byte(response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Temperature != 12.4 || rep.Condition != PartlyCloudy || rep.Unit != "°C" {
+		t.Errorf("current weather: got %v %v%s", rep.Condition, rep.Temperature, rep.Unit)
+	}
+	want := []Day{
+		{Date: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), Condition: Rain, Low: 5.5, High: 11.5},
+		{Date: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), Condition: Snow, Low: -2.1, High: 3.2},
+		{Date: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), Condition: Thunder, Low: 9.9, High: 18},
+	}
+	if len(rep.Days) != len(want) {
+		t.Fatalf("got %d forecast days, want %d", len(rep.Days), len(want))
+	}
+	for i, d := range want {
+		if rep.Days[i] != d {
+			t.Errorf("day %d: got %+v, want %+v", i, rep.Days[i], d)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	l, err := ParseLocation("52.52, -13.41")
+	if err != nil || l != (Location{Latitude: 52.52, Longitude: -13.41}) {
+		t.Errorf("got %+v, %v", l, err)
+	}
+	for _, s := range []string{"52.52", "north,east", "91,0", "0,181"} {
+		if _, err := ParseLocation(s); err == nil {
+			t.Errorf("ParseLocation(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestLocateIP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"city": "Berlin", "latitude": 52.52, "longitude": 13.41}`))
+	}))
+	defer srv.Close()
+	l, err := locateIP(context.Background(), srv.Client(), srv.URL)
+	if err != nil || l != (Location{Latitude: 52.52, Longitude: 13.41}) {
+		t.Errorf("got %+v, %v", l, err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/olegchuev/screensaver/internal/app"
+	"github.com/olegchuev/screensaver/internal/theme"
+)
+
+// listScenes prints every scene with its description and the options that
+// only apply to it, straight from the scene registry.
+func listScenes(*app.Config, []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, s := range app.Scenes() {
+		fmt.Fprintf(w, "%s\t%s\n", s.Name, s.Description)
+		for _, opt := range s.Options {
+			fmt.Fprintf(w, "\t  %s\n", opt)
+		}
+	}
+	return w.Flush()
+}
+
+// listThemes prints every built-in and user theme with its description.
+func listThemes(*app.Config, []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, name := range theme.Names() {
+		t, _ := theme.Lookup(name)
+		desc := t.Description
+		switch {
+		case theme.IsCustom(name):
+			desc += " (user theme)"
+		case name == theme.Default:
+			desc += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, desc)
+	}
+	return w.Flush()
+}
@@ -2,21 +2,351 @@
 package main
 
 import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/olegchuev/screensaver/internal/app"
 )
 
+// command is one subcommand of the binary.
+type command struct {
+	name    string
+	summary string
+	// flags registers the command's flags on fs, nil for commands without any
+	flags func(fs *flag.FlagSet, cfg *app.Config)
+	// args lists the accepted positional arguments for usage and completion
+	args []string
+	// checksConfig runs the command without the configuration file when it
+	// cannot be read, for the command to report why
+	checksConfig bool
+	// defaults starts the command from the default configuration and its
+	// flags alone, leaving out the configuration file and saved settings,
+	// for output that only depends on the command line
+	defaults bool
+	run      func(cfg *app.Config, args []string) error
+}
+
+// commands lists every subcommand in help order. The first is the default
+// when the command line starts with a flag or is empty.
+var commands = []command{
+	{
+		name:    "run",
+		summary: "run the screensaver (default)",
+		flags:   runFlags,
+		run:     run,
+	},
+	{
+		name:    "setup",
+		summary: "pick a scene, theme and frame rate with a live preview and save them",
+		flags:   configFlags,
+		run:     func(cfg *app.Config, _ []string) error { return app.Setup(*cfg) },
+	},
+	{
+		name:     "snapshot",
+		summary:  "render one deterministic frame of a scene to a txt, svg or png file",
+		flags:    snapshotFlags,
+		defaults: true,
+		run:      snapshot,
+	},
+	{
+		name:     "compare",
+		summary:  "render frames of a scene two ways given by -pipelines and report the cells that differ",
+		flags:    compareFlags,
+		defaults: true,
+		run:      compare,
+	},
+	{
+		name:    "calibrate",
+		summary: "order shade characters by how dense the -font draws them, adjust by eye and save the ramp",
+		flags:   calibrateFlags,
+		run: func(cfg *app.Config, _ []string) error {
+			return app.Calibrate(*cfg, calibrateOptions, os.Stdout)
+		},
+	},
+	{
+		name:    "dev",
+		summary: "run a scene from its package directory given as -scene, rebuilt whenever its source changes",
+		flags:   configFlags,
+		run:     func(cfg *app.Config, _ []string) error { return app.Dev(*cfg) },
+	},
+	{
+		name:    "stream",
+		summary: "write the animation to stdout as ANSI escape codes, e.g. to pipe it over ssh",
+		flags:   streamFlags,
+		run:     stream,
+	},
+	{
+		name:    "serve",
+		summary: "serve the animation to telnet clients, each with its own scene and theme",
+		flags:   serveFlags,
+		run: func(cfg *app.Config, _ []string) error {
+			opts := serveOptions
+			opts.Log = log.Default()
+			return app.Serve(*cfg, opts)
+		},
+	},
+	{
+		name:    "video",
+		summary: "serve the animation as a video stream over HTTP, e.g. for an OBS browser source",
+		flags:   videoFlags,
+		run: func(cfg *app.Config, _ []string) error {
+			opts := videoOptions
+			opts.Log = log.Default()
+			cfg.Video = &opts
+			application, err := app.New(*cfg)
+			if err != nil {
+				return err
+			}
+			return application.Run()
+		},
+	},
+	{
+		name:    "list-scenes",
+		summary: "print the available scenes with descriptions and options",
+		run:     listScenes,
+	},
+	{
+		name:    "list-themes",
+		summary: "print the available themes with descriptions, including saved user themes",
+		run:     listThemes,
+	},
+	{
+		name:         "doctor",
+		summary:      "check the configuration and terminal and report any problems",
+		flags:        configFlags,
+		checksConfig: true,
+		run: func(cfg *app.Config, _ []string) error {
+			// The report already describes every problem
+			if err := app.Doctor(*cfg, os.Stdout); err != nil {
+				os.Exit(app.ExitCode(err))
+			}
+			return nil
+		},
+	},
+	{
+		name:    "export",
+		summary: "print the effective configuration as JSON",
+		flags:   configFlags,
+		run:     func(cfg *app.Config, _ []string) error { return app.Export(*cfg, os.Stdout) },
+	},
+}
+
+func init() {
+	// The completion scripts list the commands, so it cannot be part of
+	// the initializer above
+	commands = append(commands, command{
+		name:    "completion",
+		summary: "print a shell completion script",
+		args:    shells,
+		run:     completion,
+	})
+}
+
 // main initializes and runs the screensaver application.
 func main() {
+	if err := app.LoadThemes(); err != nil {
+		log.Printf("ignoring custom themes: %v", err)
+	}
+
+	args, ok := screensaverArgs(os.Args[0], os.Args[1:])
+	if !ok {
+		return
+	}
+	name := commands[0].name
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage()
+		return
+	}
+	cmd, ok := lookupCommand(name)
+	if !ok {
+		usage()
+		fail(fmt.Errorf("%w: unknown command %q", app.ErrConfigInvalid, name))
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	fs.Usage = func() { commandUsage(cmd, fs) }
+	cfg, err := loadConfig(cmd, fs, args, log.Printf)
+	if err != nil {
+		fail(err)
+	}
+	// A running screensaver reloads the same way when its files change,
+	// quietly as the animation owns the terminal
+	cfg.Reload = func() (app.Config, error) {
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		return loadConfig(cmd, fs, args, func(string, ...any) {})
+	}
+
+	if cmd.args == nil && fs.NArg() > 0 {
+		fail(fmt.Errorf("%w: unexpected argument %q for %s", app.ErrConfigInvalid, fs.Arg(0), cmd.name))
+	}
+	if err := cmd.run(&cfg, fs.Args()); err != nil {
+		fail(err)
+	}
+}
+
+// launchSeed picks the random scene, theme and preset when -seed does not,
+// the same for every reload of the configuration.
+var launchSeed = time.Now().UnixNano()
+
+// loadConfig builds the configuration of cmd from the defaults, the
+// configuration file, the saved settings and the flags in args, each
+// overriding the ones before, or of the defaults and flags alone for
+// commands that ask so. Unreadable saved settings are skipped with a
+// warning through logf, and so is an unreadable configuration file for
+// commands that check it.
+func loadConfig(cmd command, fs *flag.FlagSet, args []string, logf func(format string, v ...any)) (app.Config, error) {
 	cfg := app.DefaultConfig()
-	application, err := app.New(cfg)
+	seed := launchSeed
+	if cmd.defaults {
+		// The same random picks every time
+		seed = 1
+	}
+	if cmd.flags != nil {
+		if !cmd.defaults {
+			file := cfg
+			switch err := app.LoadConfigFile(&file); {
+			case err == nil:
+				cfg = file
+			case !cmd.checksConfig:
+				return cfg, err
+			}
+			if err := app.LoadState(&cfg); err != nil {
+				logf("ignoring saved state: %v", err)
+			}
+		}
+		cmd.flags(fs, &cfg)
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, fmt.Errorf("%w: %w", app.ErrConfigInvalid, err)
+	}
+	fs.Visit(func(f *flag.Flag) { cfg.SetSource(f.Name, "-"+f.Name) })
+	cfg.ApplyRandom(seed)
+	cfg.ApplyPreset()
+	return cfg, nil
+}
+
+// run starts the screensaver, or plays back a recorded session.
+func run(cfg *app.Config, _ []string) error {
+	if replayPath != "" {
+		if err := app.LoadReplay(replayPath, cfg); err != nil {
+			return err
+		}
+	}
+	application, err := app.New(*cfg)
+	if err != nil {
+		return err
+	}
+	return application.Run()
+}
+
+// snapshot writes frames of the configured scene to -o or stdout. With
+// -count, an -o containing a verb like %04d names a file per frame.
+func snapshot(cfg *app.Config, _ []string) error {
+	opts := snapshotOptions
+	if opts.Format == "" {
+		opts.Format = cmp.Or(app.SnapshotFormat(snapshotOutput), "txt")
+	}
+	sequence := strings.Contains(snapshotOutput, "%")
+	if opts.Count > 1 && snapshotOutput != "" && !sequence {
+		return fmt.Errorf("%w: -count %d needs an -o with a frame number verb, like frame%%04d.png", app.ErrConfigInvalid, opts.Count)
+	}
+	return app.Snapshot(*cfg, opts, func(frame int) (io.WriteCloser, error) {
+		switch {
+		case snapshotOutput == "":
+			return nopCloser{os.Stdout}, nil
+		case sequence:
+			return os.Create(fmt.Sprintf(snapshotOutput, frame))
+		}
+		return os.Create(snapshotOutput)
+	})
+}
+
+// compare reports the frames of the configured scene that two pipelines
+// draw differently, writing their diff views to -o if given.
+func compare(cfg *app.Config, _ []string) error {
+	opts := compareOptions
+	if opts.Format == "" {
+		opts.Format = cmp.Or(app.SnapshotFormat(compareOutput), "txt")
+	}
+	var create func(frame int) (io.WriteCloser, error)
+	if compareOutput != "" {
+		create = func(frame int) (io.WriteCloser, error) {
+			if strings.Contains(compareOutput, "%") {
+				return os.Create(fmt.Sprintf(compareOutput, frame))
+			}
+			return os.Create(compareOutput)
+		}
+	}
+	return app.Compare(*cfg, opts, os.Stdout, create)
+}
+
+// nopCloser keeps stdout open after each frame.
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// stream runs the screensaver on stdout instead of the terminal.
+func stream(cfg *app.Config, _ []string) error {
+	opts := streamOptions
+	opts.Out = os.Stdout
+	cfg.Stream = &opts
+	application, err := app.New(*cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	return application.Run()
+}
 
-	if err := application.Run(); err != nil {
-		log.Fatal(err)
+// lookupCommand finds a subcommand by name.
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
 	}
+	return command{}, false
 }
 
+// usage prints the list of subcommands.
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: screensaver [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun \"screensaver <command> -h\" for the flags of a command.\n")
+}
+
+// commandUsage prints the usage of one subcommand and its flags.
+func commandUsage(cmd command, fs *flag.FlagSet) {
+	line := "screensaver " + cmd.name
+	if cmd.flags != nil {
+		line += " [flags]"
+	}
+	if cmd.args != nil {
+		line += " <" + strings.Join(cmd.args, "|") + ">"
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s\n\n%s.\n", line, strings.ToUpper(cmd.summary[:1])+cmd.summary[1:])
+	if cmd.flags != nil {
+		fmt.Fprintf(os.Stderr, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+// fail reports err with a hint on how to fix it and exits with the matching code.
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "screensaver: %v\n", err)
+	if hint := app.Hint(err); hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+	os.Exit(app.ExitCode(err))
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olegchuev/screensaver/internal/app"
+)
+
+// TestSnapshotDefaults checks that snapshots leave out the configuration
+// file and saved settings, which other commands read.
+func TestSnapshotDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir = filepath.Join(dir, "screensaver")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(`scene = "matrix"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		command string
+		scene   string
+	}{
+		{"run", "matrix"},
+		{"snapshot", app.DefaultConfig().Scene},
+		{"compare", app.DefaultConfig().Scene},
+	} {
+		cmd, _ := lookupCommand(tt.command)
+		cfg, err := loadConfig(cmd, flag.NewFlagSet(cmd.name, flag.ContinueOnError), nil, t.Logf)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.command, err)
+		}
+		if cfg.Scene != tt.scene {
+			t.Errorf("%s: scene %q, want %q", tt.command, cfg.Scene, tt.scene)
+		}
+	}
+}
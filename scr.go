@@ -0,0 +1,30 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// screensaverArgs translates the arguments Windows passes to a screensaver
+// installed as a .scr file into a command line. It returns false if the
+// program should exit without doing anything.
+//
+// Windows runs the screensaver with /s, the settings dialog with /c and a
+// preview inside the control panel with /p <window handle>; without any
+// arguments it asks for the settings. The screensaver runs fullscreen in
+// the graphical window. There is no settings dialog or preview: settings
+// are chosen with "screensaver setup" in a terminal and apply to the .scr.
+func screensaverArgs(exe string, args []string) ([]string, bool) {
+	if !strings.EqualFold(filepath.Ext(exe), ".scr") {
+		return args, true
+	}
+	if len(args) == 0 {
+		return nil, false
+	}
+	// The mode may be followed by a colon and the window handle
+	mode, _, _ := strings.Cut(strings.ToLower(args[0]), ":")
+	if mode != "/s" && mode != "-s" {
+		return nil, false
+	}
+	return []string{"run", "-window", "-fullscreen"}, true
+}